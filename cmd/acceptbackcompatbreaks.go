@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/spf13/cobra"
+)
+
+var (
+	acceptProjectFlagVal  string
+	acceptBreakIDsFlagVal []string
+)
+
+var acceptBackCompatBreaksCmd = &cobra.Command{
+	Use:   "accept-backcompat-breaks",
+	Short: "Accept the backwards incompatibilities of Conjure definitions",
+	Long: `Check the Conjure definitions of every project for backwards compatibility like the backcompat command and record
+the incompatibilities that are found in ` + conjureplugin.BackCompatLockFile + ` so that subsequent checks
+against the same baseline succeed. The baseline is specified using the same flags as the backcompat command. The
+accepted incompatibilities of every project that is checked are replaced by the incompatibilities that are found.
+If --project is specified, only that project is checked. If --break-id is specified, only the incompatibilities with
+the specified IDs (which are printed by the backcompat command) are accepted and no accepted incompatibilities are
+removed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opParams, err := backCompatBaselineParams()
+		if err != nil {
+			return err
+		}
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		if acceptProjectFlagVal != "" {
+			if projectParams, err = projectParams.Select([]string{acceptProjectFlagVal}); err != nil {
+				return err
+			}
+		}
+		if len(acceptBreakIDsFlagVal) > 0 {
+			opParams = append(opParams, conjureplugin.AcceptBreakIDsParam(acceptBreakIDsFlagVal...))
+		}
+		checkers, err := backCompatCheckers(projectParams, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		return conjureplugin.AcceptBackCompatBreaks(projectParams, projectDirFlag, baseRefFlagVal, checkers, cmd.OutOrStdout(), opParams...)
+	},
+}
+
+func init() {
+	acceptBackCompatBreaksCmd.Flags().StringVar(&baseRefFlagVal, "base-ref", "", "Git ref at which the definitions are compiled to compute the baseline")
+	acceptBackCompatBreaksCmd.Flags().BoolVar(&publishedBaselineFlagVal, "published", false, "use the IR of the latest published release of each project as the baseline")
+	acceptBackCompatBreaksCmd.Flags().StringVar(&acceptProjectFlagVal, "project", "", "project whose incompatibilities are accepted (if unspecified, the incompatibilities of all projects are accepted)")
+	acceptBackCompatBreaksCmd.Flags().StringSliceVar(&acceptBreakIDsFlagVal, "break-id", nil, "ID of an incompatibility to accept (may be specified multiple times or as a comma-separated list)")
+	addPublishedRepositoryFlags(acceptBackCompatBreaksCmd.Flags())
+	rootCmd.AddCommand(acceptBackCompatBreaksCmd)
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/assets"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var assetsCmd = &cobra.Command{
+	Use:   "assets",
+	Short: "Operations on the assets provided to the plugin",
+}
+
+var assetsVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify that the assets provided to the plugin are configured correctly",
+	Long: `Verify that every asset provided to the plugin is an executable that handles the _assetInfo command, reports a
+supported type and complies with the protocol for its type, and that asset-config only configures provided assets.
+Prints the name, type and version of every asset and exits with a non-zero status if any asset is misconfigured.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var reports []assets.Report
+		for _, assetPath := range assetsFlagVal {
+			reports = append(reports, assets.Verify(assetPath))
+		}
+		if len(reports) == 0 {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No assets are provided to the plugin")
+		}
+
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		failed := 0
+		for _, report := range reports {
+			names = append(names, report.Name)
+			version := report.Version
+			if version == "" {
+				version = "unknown version"
+			}
+			status := "OK"
+			if !report.OK() {
+				// misconfigured optional assets are skipped by the operations that use them
+				if projectParams.OptionalAsset(report.Name) {
+					status = "WARNING (optional)"
+				} else {
+					status = "FAILED"
+					failed++
+				}
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: %s (type: %s, %s, path: %s)\n", status, report.Name, typeOrUnknown(report.Type), version, report.Path)
+			for _, problem := range report.Problems {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), indentLines(problem))
+			}
+		}
+
+		if err := verifyAssetConfig(projectParams, names); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return errors.Errorf("%d of %d assets are misconfigured", failed, len(reports))
+		}
+		return nil
+	},
+}
+
+func typeOrUnknown(assetType assets.AssetType) string {
+	if assetType == "" {
+		return "unknown"
+	}
+	return string(assetType)
+}
+
+// indentLines returns the provided string with every line indented by two spaces.
+func indentLines(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n  ")
+}
+
+// verifyAssetConfig returns an error if the asset configuration or asset options in the provided parameters specify
+// configuration or options for an asset whose name is not one of the provided names.
+func verifyAssetConfig(projectParams conjureplugin.ConjureProjectParams, names []string) error {
+	provided := make(map[string]struct{})
+	for _, name := range names {
+		provided[name] = struct{}{}
+	}
+	var unknown, unknownOptions []string
+	for name := range projectParams.AssetConfig {
+		if _, ok := provided[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	for name := range projectParams.AssetOptions {
+		if _, ok := provided[name]; !ok {
+			unknownOptions = append(unknownOptions, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errors.Errorf("asset-config specifies configuration for assets that are not provided: %v (provided assets are %v)", unknown, names)
+	}
+	if len(unknownOptions) > 0 {
+		sort.Strings(unknownOptions)
+		return errors.Errorf("asset-options specifies options for assets that are not provided: %v (provided assets are %v)", unknownOptions, names)
+	}
+	return nil
+}
+
+// printSkippedAssets prints a warning for every optional asset that could not be loaded.
+func printSkippedAssets(loadedAssets assets.Assets, stdout io.Writer) {
+	for _, skipped := range loadedAssets.Skipped {
+		_, _ = fmt.Fprintf(stdout, "Warning: skipping optional asset %s: %v\n", skipped.Name, skipped.Err)
+	}
+}
+
+// loadAssets loads the assets provided to the plugin, prints a warning for every optional asset that could not be
+// loaded and verifies that the asset configuration of the provided parameters only configures provided assets. Returns
+// no assets without verifying the asset configuration if no assets are provided to the plugin.
+func loadAssets(projectParams conjureplugin.ConjureProjectParams, stdout io.Writer) (assets.Assets, error) {
+	if len(assetsFlagVal) == 0 {
+		return assets.Assets{}, nil
+	}
+	loadedAssets, err := assets.Load(assetsFlagVal, projectParams.OptionalAsset)
+	if err != nil {
+		return assets.Assets{}, err
+	}
+	printSkippedAssets(loadedAssets, stdout)
+	if err := verifyAssetConfig(projectParams, loadedAssets.Names()); err != nil {
+		return assets.Assets{}, err
+	}
+	return loadedAssets, nil
+}
+
+// irValidatorsParam returns a parameter that validates the IR of every project using the IR validator assets in the
+// provided assets.
+func irValidatorsParam(projectParams conjureplugin.ConjureProjectParams, loadedAssets assets.Assets) conjureplugin.OperationParam {
+	var validators []conjureplugin.IRValidator
+	for _, asset := range loadedAssets.IRValidator {
+		validators = append(validators, conjureplugin.NewConfiguredAssetIRValidator(asset.Path, asset.Name, projectParams.AssetConfig[asset.Name]))
+	}
+	return conjureplugin.IRValidatorsParam(validators...)
+}
+
+// resolvePublisherAsset sets the path and configuration of the publisher asset of the provided parameters if IR is
+// published by a publisher asset. Returns an error if the asset is not one of the provided assets.
+func resolvePublisherAsset(projectParams *conjureplugin.ConjureProjectParams, loadedAssets assets.Assets) error {
+	if projectParams.PublisherAsset == nil {
+		return nil
+	}
+	publisherAsset := *projectParams.PublisherAsset
+	var names []string
+	for _, asset := range loadedAssets.Publisher {
+		if asset.Name == publisherAsset.Name {
+			publisherAsset.Path = asset.Path
+		}
+		names = append(names, asset.Name)
+	}
+	if publisherAsset.Path == "" {
+		return errors.Errorf("publisher asset %s is not provided to the plugin (provided publisher assets are %v)", publisherAsset.Name, names)
+	}
+	publisherAsset.Config = projectParams.AssetConfig[publisherAsset.Name]
+	projectParams.PublisherAsset = &publisherAsset
+	return nil
+}
+
+// resolveGeneratorAssets sets the path and configuration of the generator asset of every project in the provided
+// parameters that is generated by a generator asset. Returns an error if the generator asset of a project is not one of
+// the provided assets.
+func resolveGeneratorAssets(projectParams conjureplugin.ConjureProjectParams, loadedAssets assets.Assets) error {
+	paths := make(map[string]string)
+	var names []string
+	for _, asset := range loadedAssets.Generator {
+		paths[asset.Name] = asset.Path
+		names = append(names, asset.Name)
+	}
+	for _, name := range projectParams.SortedKeys {
+		param := projectParams.Params[name]
+		if param.GeneratorAsset == nil {
+			continue
+		}
+		generatorAsset := *param.GeneratorAsset
+		path, ok := paths[generatorAsset.Name]
+		if !ok {
+			return errors.Errorf("generator asset %s of %s is not provided to the plugin (provided generator assets are %v)", generatorAsset.Name, name, names)
+		}
+		generatorAsset.Path = path
+		generatorAsset.Config = projectParams.AssetConfig[generatorAsset.Name]
+		param.GeneratorAsset = &generatorAsset
+		projectParams.Params[name] = param
+	}
+	return nil
+}
+
+func init() {
+	assetsCmd.AddCommand(assetsVerifyCmd)
+	rootCmd.AddCommand(assetsCmd)
+}
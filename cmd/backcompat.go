@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/assets"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	baseRefFlagVal           string
+	publishedBaselineFlagVal bool
+	reportFileFlagVal        string
+	sarifFileFlagVal         string
+)
+
+var backCompatCmd = &cobra.Command{
+	Use:   "backcompat",
+	Short: "Check Conjure definitions for backwards compatibility",
+	Long: `Check that the Conjure definitions of every project are backwards compatible with a baseline using the
+backcompat assets (or the built-in checker if no backcompat assets are provided). The baseline is computed by compiling
+the definitions of each project at the Git ref specified by --base-ref (for example, origin/develop), which does not
+require the project to have been published. If --published is specified, the baseline is instead the IR of the latest
+release of each project published to the Artifactory repository specified by --url, --repository and --group-id.
+Projects that configure backcompat-baseline are checked against their pinned baseline instead (pinned Maven coordinates
+are resolved from the repository specified by --url and --repository), and --base-ref may be omitted if every project
+configures one. If --report-file is specified, the result for every project (including the incompatibilities found) is
+also written to it as JSON. If --sarif-file is specified, the incompatibilities that have not been accepted are also
+written to it as a SARIF log.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opParams, err := backCompatBaselineParams()
+		if err != nil {
+			return err
+		}
+		var report *conjureplugin.BackCompatReport
+		var reportPath string
+		if reportFileFlagVal != "" {
+			// resolve the report path before changing the working directory so that it is relative to the directory in
+			// which the command was invoked
+			if reportPath, err = filepath.Abs(reportFileFlagVal); err != nil {
+				return errors.Wrapf(err, "failed to determine absolute path of %s", reportFileFlagVal)
+			}
+		}
+		var sarifPath string
+		var sarifOpts conjureplugin.SARIFOptions
+		if sarifFileFlagVal != "" {
+			if sarifPath, err = filepath.Abs(sarifFileFlagVal); err != nil {
+				return errors.Wrapf(err, "failed to determine absolute path of %s", sarifFileFlagVal)
+			}
+			if sarifOpts, err = sarifOptions(projectDirFlag, false); err != nil {
+				return err
+			}
+		}
+		if reportPath != "" || sarifPath != "" {
+			report = &conjureplugin.BackCompatReport{}
+			opParams = append(opParams, conjureplugin.BackCompatReportParam(report))
+		}
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		checkers, err := backCompatCheckers(projectParams, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		summary := conjureplugin.NewSummary("conjure-backcompat")
+		defer printSummary(summary, cmd.OutOrStdout())
+		projectParams, err = filterSelectedProjects(projectParams, summary)
+		if err != nil {
+			return err
+		}
+		opParams = append(opParams, conjureplugin.SummaryParam(summary))
+		backCompatErr := conjureplugin.BackCompat(projectParams, projectDirFlag, baseRefFlagVal, checkers, cmd.OutOrStdout(), opParams...)
+		if reportPath != "" {
+			// the report is written even if the check fails so that the failures can be inspected
+			if err := writeBackCompatReport(report, reportPath); err != nil && backCompatErr == nil {
+				return err
+			}
+		}
+		if sarifPath != "" {
+			if err := writeSARIF(sarifPath, func(w io.Writer) error {
+				return conjureplugin.WriteBackCompatSARIF(w, report, sarifOpts)
+			}); err != nil && backCompatErr == nil {
+				return err
+			}
+		}
+		return backCompatErr
+	},
+}
+
+func init() {
+	backCompatCmd.Flags().StringVar(&baseRefFlagVal, "base-ref", "", "Git ref at which the definitions are compiled to compute the baseline")
+	backCompatCmd.Flags().BoolVar(&publishedBaselineFlagVal, "published", false, "use the IR of the latest published release of each project as the baseline")
+	backCompatCmd.Flags().StringVar(&reportFileFlagVal, "report-file", "", "file to which a JSON report of the result for every project is written")
+	backCompatCmd.Flags().StringVar(&sarifFileFlagVal, "sarif-file", "", "file to which the incompatibilities that have not been accepted are written as a SARIF log (for example, for GitHub code scanning)")
+	addPublishedRepositoryFlags(backCompatCmd.Flags())
+	addProjectsFlag(backCompatCmd.Flags())
+	rootCmd.AddCommand(backCompatCmd)
+}
+
+// writeBackCompatReport writes the provided report as JSON to the file at the provided path.
+func writeBackCompatReport(report *conjureplugin.BackCompatReport, path string) error {
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write backcompat report to %s", path)
+	}
+	return nil
+}
+
+// backCompatBaselineParams returns the parameters that specify the baseline of the projects using the --base-ref,
+// --published and repository flags.
+func backCompatBaselineParams() ([]conjureplugin.OperationParam, error) {
+	switch {
+	case baseRefFlagVal != "" && publishedBaselineFlagVal:
+		return nil, errors.Errorf("--base-ref and --published cannot both be specified")
+	case publishedBaselineFlagVal:
+		repo, err := publishedRepository()
+		if err != nil {
+			return nil, err
+		}
+		return []conjureplugin.OperationParam{conjureplugin.PublishedBaselineParam(repo)}, nil
+	case publishedRepositoryFlagVal.URL != "" && publishedRepositoryFlagVal.Repository != "":
+		// the repository resolves the pinned baselines of projects that are specified as Maven coordinates
+		return []conjureplugin.OperationParam{conjureplugin.BaselineRepositoryParam(publishedRepositoryFlagVal)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// backCompatCheckers loads the backcompat assets and returns the checkers for them (or the built-in checker if there
+// are none). Changes the working directory to the project directory.
+func backCompatCheckers(projectParams conjureplugin.ConjureProjectParams, stdout io.Writer) ([]conjureplugin.BackCompatChecker, error) {
+	loadedAssets, err := assets.Load(assetsFlagVal, projectParams.OptionalAsset)
+	if err != nil {
+		return nil, err
+	}
+	printSkippedAssets(loadedAssets, stdout)
+	if err := os.Chdir(projectDirFlag); err != nil {
+		return nil, errors.Wrapf(err, "failed to set working directory")
+	}
+	if err := verifyAssetConfig(projectParams, loadedAssets.Names()); err != nil {
+		return nil, err
+	}
+	var checkers []conjureplugin.BackCompatChecker
+	for _, asset := range loadedAssets.BackCompat {
+		checkers = append(checkers, conjureplugin.NewConfiguredAssetBackCompatChecker(asset.Path, asset.Name, projectParams.AssetConfig[asset.Name]))
+	}
+	// the built-in checker is used unless it is overridden by backcompat assets
+	if len(checkers) == 0 {
+		checkers = append(checkers, conjureplugin.NewBuiltinBackCompatChecker())
+	}
+	return checkers, nil
+}
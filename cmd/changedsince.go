@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+const changedSinceFlagName = "changed-since"
+
+var (
+	changedSinceFlagVal string
+)
+
+func addChangedSinceFlag(flags *pflag.FlagSet) {
+	flags.StringVar(&changedSinceFlagVal, changedSinceFlagName, "", "only operate on projects whose inputs, outputs or configuration changed relative to the specified Git ref")
+}
+
+// filterChangedProjects returns the provided parameters filtered to only contain the projects that have changed
+// relative to the Git ref specified by the --changed-since flag. The projects that are filtered out are recorded as
+// skipped in the provided summary. Returns the provided parameters unmodified if the flag
+// is not specified. Must be called before the working directory is changed because the configuration file and project
+// directory may be specified relative to it.
+func filterChangedProjects(projectParams conjureplugin.ConjureProjectParams, summary *conjureplugin.Summary, stdout io.Writer) (conjureplugin.ConjureProjectParams, error) {
+	if changedSinceFlagVal == "" {
+		return projectParams, nil
+	}
+	baseCfgBytes, err := configBytesAtGitRef(changedSinceFlagVal, configFileFlag)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	baseParams, err := configBytesToProjectParams(baseCfgBytes)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "failed to resolve configuration at %s", changedSinceFlagVal)
+	}
+	changed, err := conjureplugin.ChangedProjects(projectParams, baseParams, projectDirFlag, changedSinceFlagVal)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	if len(changed) == 0 {
+		_, _ = fmt.Fprintf(stdout, "No projects changed since %s\n", changedSinceFlagVal)
+	} else if len(changed) < len(projectParams.SortedKeys) {
+		_, _ = fmt.Fprintf(stdout, "Projects changed since %s: %s\n", changedSinceFlagVal, strings.Join(changed, ", "))
+	}
+	changedSet := make(map[string]struct{})
+	for _, name := range changed {
+		changedSet[name] = struct{}{}
+	}
+	var unchanged []string
+	for _, name := range projectParams.SortedKeys {
+		if _, ok := changedSet[name]; !ok {
+			unchanged = append(unchanged, name)
+		}
+	}
+	summary.Skip(unchanged, "unchanged since "+changedSinceFlagVal)
+	return projectParams.Subset(changed), nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareAFlagVal string
+	compareBFlagVal string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the definitions of two Conjure projects",
+	Long: `Print a report that compares the definitions of the projects specified by --a and --b. The report summarizes the
+types and endpoints that are defined in both projects or only in one of them along with the signatures of the
+definitions that are defined in both projects but differ. Definitions are matched by name regardless of their package.
+Either flag may instead specify the path of an IR file, such as the IR snapshot written to
+".conjure/ir.snapshot.json" in the output directory of a project that configures "ir-snapshot".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if compareAFlagVal == "" || compareBFlagVal == "" {
+			return errors.Errorf("--a and --b must be specified")
+		}
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		return conjureplugin.Compare(projectParams, compareAFlagVal, compareBFlagVal, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	compareCmd.Flags().StringVar(&compareAFlagVal, "a", "", "first project (or IR file) to compare")
+	compareCmd.Flags().StringVar(&compareBFlagVal, "b", "", "second project (or IR file) to compare")
+	rootCmd.AddCommand(compareCmd)
+}
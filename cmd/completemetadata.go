@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completeMetadata is the JSON description of the CLI of the plugin printed by the "__complete-metadata" command.
+type completeMetadata struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// GlobalFlags are the flags that are accepted by every command.
+	GlobalFlags []flagMetadata    `json:"globalFlags"`
+	Commands    []commandMetadata `json:"commands"`
+}
+
+type commandMetadata struct {
+	// Command is the path of the command from the root command (for example, ["assets", "verify"]).
+	Command []string `json:"command"`
+	// Task is the name of the gödel task that runs the command. Empty if the command is not exposed as a task.
+	Task        string         `json:"task,omitempty"`
+	Description string         `json:"description"`
+	Flags       []flagMetadata `json:"flags"`
+}
+
+type flagMetadata struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	// Type is the type of the value of the flag as reported by pflag (for example, "string", "bool" or
+	// "stringSlice").
+	Type     string `json:"type"`
+	Default  string `json:"default,omitempty"`
+	Usage    string `json:"usage"`
+	Required bool   `json:"required,omitempty"`
+}
+
+var completeMetadataCmd = &cobra.Command{
+	Use:   "__complete-metadata",
+	Short: "Print a JSON description of the commands and flags of the plugin",
+	Long: `Print a JSON description of all of the commands of the plugin, the gödel tasks that run them and their flags
+(including the types of their values), which allows wrapper tooling and IDE runners to build interfaces for the plugin
+without hard-coding its CLI.`,
+	Hidden: true,
+	// flag parsing is disabled so that the required global flags do not need to be specified
+	DisableFlagParsing: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := taskNamesByCommand()
+		if err != nil {
+			return err
+		}
+		metadata := completeMetadata{
+			Name:        rootCmd.Name(),
+			Version:     Version,
+			GlobalFlags: flagsMetadata(rootCmd.PersistentFlags()),
+			Commands:    commandsMetadata(rootCmd, nil, tasks),
+		}
+		out, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal metadata")
+		}
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(out))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completeMetadataCmd)
+}
+
+// taskNamesByCommand returns the names of the gödel tasks of the plugin keyed by the JSON representation of their
+// command.
+func taskNamesByCommand() (map[string]string, error) {
+	pluginInfoJSON, err := json.Marshal(PluginInfo)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal plugin information")
+	}
+	var pluginInfo struct {
+		Tasks []struct {
+			Name    string   `json:"name"`
+			Command []string `json:"command"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(pluginInfoJSON, &pluginInfo); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal plugin information")
+	}
+	tasks := make(map[string]string)
+	for _, task := range pluginInfo.Tasks {
+		tasks[commandKey(task.Command)] = task.Name
+	}
+	return tasks, nil
+}
+
+// commandsMetadata returns the metadata of the runnable commands that are descendants of the provided command, whose
+// path is parentPath. Hidden commands and the help and completion commands added by cobra are omitted.
+func commandsMetadata(parent *cobra.Command, parentPath []string, tasks map[string]string) []commandMetadata {
+	var out []commandMetadata
+	for _, child := range parent.Commands() {
+		if !child.IsAvailableCommand() || child.Name() == "help" || child.Name() == "completion" {
+			continue
+		}
+		path := append(append([]string{}, parentPath...), child.Name())
+		if child.Runnable() {
+			out = append(out, commandMetadata{
+				Command:     path,
+				Task:        tasks[commandKey(path)],
+				Description: child.Short,
+				Flags:       flagsMetadata(child.LocalFlags()),
+			})
+		}
+		out = append(out, commandsMetadata(child, path, tasks)...)
+	}
+	return out
+}
+
+// flagsMetadata returns the metadata of the visible flags in the provided flag set sorted by name.
+func flagsMetadata(flags *pflag.FlagSet) []flagMetadata {
+	out := []flagMetadata{}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden {
+			return
+		}
+		defValue := flag.DefValue
+		// empty values of slice flags are printed as "[]"
+		if defValue == "[]" {
+			defValue = ""
+		}
+		out = append(out, flagMetadata{
+			Name:      flag.Name,
+			Shorthand: flag.Shorthand,
+			Type:      flag.Value.Type(),
+			Default:   defValue,
+			Usage:     flag.Usage,
+			Required:  len(flag.Annotations[cobra.BashCompOneRequiredFlag]) > 0 && flag.Annotations[cobra.BashCompOneRequiredFlag][0] == "true",
+		})
+	})
+	return out
+}
+
+func commandKey(command []string) string {
+	out, _ := json.Marshal(command)
+	return string(out)
+}
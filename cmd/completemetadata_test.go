@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteMetadata(t *testing.T) {
+	outBuf := &bytes.Buffer{}
+	rootCmd.SetOut(outBuf)
+	rootCmd.SetArgs([]string{"__complete-metadata"})
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+	require.NoError(t, rootCmd.Execute())
+
+	var metadata completeMetadata
+	require.NoError(t, json.Unmarshal(outBuf.Bytes(), &metadata), "Output: %s", outBuf.String())
+	assert.Equal(t, rootCmd.Name(), metadata.Name)
+	assert.Equal(t, Version, metadata.Version)
+
+	globalFlags := make(map[string]flagMetadata)
+	for _, flag := range metadata.GlobalFlags {
+		globalFlags[flag.Name] = flag
+	}
+	assert.Equal(t, flagMetadata{Name: "project-dir", Type: "string", Usage: globalFlags["project-dir"].Usage, Required: true}, globalFlags["project-dir"])
+	assert.Equal(t, "bool", globalFlags["offline"].Type)
+	assert.Equal(t, "false", globalFlags["offline"].Default)
+	assert.False(t, globalFlags["offline"].Required)
+
+	commands := make(map[string]commandMetadata)
+	for _, command := range metadata.Commands {
+		commands[commandKey(command.Command)] = command
+	}
+	// hidden commands and the commands added by cobra are omitted
+	for _, omitted := range [][]string{{"__complete-metadata"}, {"help"}, {"completion"}} {
+		assert.NotContains(t, commands, commandKey(omitted))
+	}
+
+	runCommand, ok := commands[commandKey([]string{"run"})]
+	require.True(t, ok, "run command missing from metadata: %s", outBuf.String())
+	assert.Equal(t, "conjure", runCommand.Task)
+	assert.Equal(t, runCmd.Short, runCommand.Description)
+	runFlags := make(map[string]flagMetadata)
+	for _, flag := range runCommand.Flags {
+		runFlags[flag.Name] = flag
+	}
+	assert.Equal(t, flagMetadata{Name: VerifyFlagName, Type: "bool", Default: "false", Usage: "verify that current project matches output of conjure"}, runFlags[VerifyFlagName])
+	assert.Equal(t, "int", runFlags["parallelism"].Type)
+	assert.Equal(t, "0", runFlags["parallelism"].Default)
+	// persistent flags of the root command are only reported as global flags
+	assert.NotContains(t, runFlags, "project-dir")
+
+	// subcommands are reported using their full path
+	assetsVerifyCommand, ok := commands[commandKey([]string{"assets", "verify"})]
+	require.True(t, ok, "assets verify command missing from metadata: %s", outBuf.String())
+	assert.Equal(t, assetsVerifyCmd.Short, assetsVerifyCommand.Description)
+
+	publishCommand, ok := commands[commandKey([]string{"publish"})]
+	require.True(t, ok, "publish command missing from metadata: %s", outBuf.String())
+	assert.Equal(t, "conjure-publish", publishCommand.Task)
+}
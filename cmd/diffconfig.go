@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffConfigGitRefFlagVal string
+)
+
+var diffConfigCmd = &cobra.Command{
+	Use:   "diff-config [base-config] [new-config]",
+	Short: "Print the differences in effective behavior between two revisions of the plugin configuration",
+	Long: `Print the differences in the resolved parameters (output directories, publish sets, generation flags) of two
+revisions of the plugin configuration. If two configuration files are provided, they are compared with each other. If a
+single configuration file is provided, it is compared with the current configuration. If --git-ref is specified, the
+current configuration is compared with the configuration file at the specified Git ref.`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		baseCfgFile, newCfgFile := "", configFileFlag
+		switch len(args) {
+		case 1:
+			baseCfgFile = args[0]
+		case 2:
+			baseCfgFile, newCfgFile = args[0], args[1]
+		}
+		if (baseCfgFile == "") == (diffConfigGitRefFlagVal == "") {
+			return errors.Errorf("exactly one of a base configuration file or --git-ref must be specified")
+		}
+
+		var baseCfgBytes []byte
+		if diffConfigGitRefFlagVal != "" {
+			gitCfgBytes, err := configBytesAtGitRef(diffConfigGitRefFlagVal, newCfgFile)
+			if err != nil {
+				return err
+			}
+			baseCfgBytes = gitCfgBytes
+		} else {
+			fileCfgBytes, err := os.ReadFile(baseCfgFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read configuration file %s", baseCfgFile)
+			}
+			baseCfgBytes = fileCfgBytes
+		}
+		newCfgBytes, err := os.ReadFile(newCfgFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read configuration file %s", newCfgFile)
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+
+		baseParams, err := configBytesToProjectParams(baseCfgBytes)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve base configuration")
+		}
+		newParams, err := configBytesToProjectParams(newCfgBytes)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve new configuration")
+		}
+
+		diffs := conjureplugin.DiffParams(baseParams, newParams)
+		if len(diffs) == 0 {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), "No differences in effective configuration")
+			return nil
+		}
+		for _, diff := range diffs {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), diff)
+		}
+		return nil
+	},
+}
+
+func init() {
+	diffConfigCmd.Flags().StringVar(&diffConfigGitRefFlagVal, "git-ref", "", "Git ref at which the base configuration should be read")
+	rootCmd.AddCommand(diffConfigCmd)
+}
+
+// configBytesAtGitRef returns the content of the provided configuration file at the provided Git ref. The file is
+// resolved relative to the project directory.
+func configBytesAtGitRef(gitRef, cfgFile string) ([]byte, error) {
+	absCfgFile, err := filepath.Abs(cfgFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	absProjectDir, err := filepath.Abs(projectDirFlag)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	relCfgFile, err := filepath.Rel(absProjectDir, absCfgFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "configuration file %s must be within the project directory", cfgFile)
+	}
+	gitCmd := exec.Command("git", "show", fmt.Sprintf("%s:./%s", gitRef, filepath.ToSlash(relCfgFile)))
+	gitCmd.Dir = absProjectDir
+	output, err := gitCmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s at Git ref %s", relCfgFile, gitRef)
+	}
+	return output, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportJSONSchemaProjectFlagVal string
+	exportJSONSchemaOutputFlagVal  string
+)
+
+var exportJSONSchemaCmd = &cobra.Command{
+	Use:   "export-jsonschema",
+	Short: "Export JSON Schema for the types of a Conjure project",
+	Long: `Export a JSON Schema (draft 7) document that describes the object, alias, enum and union types of the project
+specified by --project. Each type is defined in the "definitions" of the document under its qualified name. The
+document is written to the file specified by --output or to stdout if --output is not specified.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportJSONSchemaProjectFlagVal == "" {
+			return errors.Errorf("--project must be specified")
+		}
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		var w io.Writer = cmd.OutOrStdout()
+		if exportJSONSchemaOutputFlagVal != "" {
+			f, err := os.Create(exportJSONSchemaOutputFlagVal)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create %s", exportJSONSchemaOutputFlagVal)
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+			w = f
+		}
+		return conjureplugin.ExportJSONSchema(projectParams, exportJSONSchemaProjectFlagVal, w)
+	},
+}
+
+func init() {
+	exportJSONSchemaCmd.Flags().StringVar(&exportJSONSchemaProjectFlagVal, "project", "", "project for which JSON Schema is exported")
+	exportJSONSchemaCmd.Flags().StringVar(&exportJSONSchemaOutputFlagVal, "output", "", "file to which JSON Schema is written (stdout if unspecified)")
+	rootCmd.AddCommand(exportJSONSchemaCmd)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	extensionsProjectFlagVal string
+)
+
+var extensionsCmd = &cobra.Command{
+	Use:   "extensions",
+	Short: "Operations on the extensions of the IR of Conjure projects",
+}
+
+var extensionsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Print the differences between the published and current extensions of the IR of a project",
+	Long: `Print the differences between the extensions of the IR of the latest release of the project specified by
+--project published to the Artifactory repository specified by --url, --repository and --group-id and the extensions
+of the IR that the current configuration of the project produces. Extensions that were removed are prefixed with "-",
+extensions that were added are prefixed with "+" and extensions whose value changed are prefixed with "~".`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if extensionsProjectFlagVal == "" {
+			return errors.Errorf("--project must be specified")
+		}
+		repo, err := publishedRepository()
+		if err != nil {
+			return err
+		}
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		return conjureplugin.ExtensionsDiff(projectParams, extensionsProjectFlagVal, repo, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	extensionsDiffCmd.Flags().StringVar(&extensionsProjectFlagVal, "project", "", "project whose extensions are compared")
+	addPublishedRepositoryFlags(extensionsDiffCmd.Flags())
+	extensionsCmd.AddCommand(extensionsDiffCmd)
+	rootCmd.AddCommand(extensionsCmd)
+}
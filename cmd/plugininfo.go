@@ -46,6 +46,56 @@ var (
 			"Publish Conjure IR",
 			pluginapi.TaskInfoCommand("publish"),
 		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-backcompat",
+			"Check Conjure definitions for backwards compatibility using the backcompat assets",
+			pluginapi.TaskInfoCommand("backcompat"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-accept-backcompat-breaks",
+			"Accept the backwards incompatibilities of Conjure definitions",
+			pluginapi.TaskInfoCommand("accept-backcompat-breaks"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-export-jsonschema",
+			"Export JSON Schema for the types of a Conjure project",
+			pluginapi.TaskInfoCommand("export-jsonschema"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-compare",
+			"Compare the definitions of two Conjure projects",
+			pluginapi.TaskInfoCommand("compare"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-which",
+			"Print the project that generated a file",
+			pluginapi.TaskInfoCommand("which"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-write-ir",
+			"Write the IR of Conjure projects without generating code",
+			pluginapi.TaskInfoCommand("write-ir"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-preview",
+			"Bundle the changes that Conjure generation would make to the committed outputs for review",
+			pluginapi.TaskInfoCommand("preview"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-assets-verify",
+			"Verify that the assets provided to the plugin are configured correctly",
+			pluginapi.TaskInfoCommand("assets", "verify"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-diff-config",
+			"Print the differences in effective behavior between two revisions of the Conjure plugin configuration",
+			pluginapi.TaskInfoCommand("diff-config"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-extensions-diff",
+			"Print the differences between the published and current extensions of the IR of a Conjure project",
+			pluginapi.TaskInfoCommand("extensions", "diff"),
+		),
 		pluginapi.PluginInfoUpgradeConfigTaskInfo(
 			pluginapi.UpgradeConfigTaskInfoCommand("upgrade-config"),
 			pluginapi.LegacyConfigFile("conjure.yml"),
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	previewOutFlagVal string
+)
+
+var previewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "Bundle the changes that generation would make to the committed outputs",
+	Long: fmt.Sprintf(`Regenerate the code of projects into a temporary directory and write a zip archive to the file specified by --out
+that contains %s, a unified diff that updates the committed outputs to the regenerated code, and %s,
+the summary of the generation as JSON. The files in the project directory are not modified, so the archive can be
+used to review the changes to the generated code caused by changes to the definitions without checking them out.`, conjureplugin.PreviewDiffFileName, conjureplugin.PreviewSummaryFileName),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if previewOutFlagVal == "" {
+			return errors.Errorf("--out must be specified")
+		}
+		// resolve the output path before changing the working directory so that it is relative to the directory in
+		// which the command was invoked
+		outPath, err := filepath.Abs(previewOutFlagVal)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine absolute path of %s", previewOutFlagVal)
+		}
+		parsedConfigSet, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		summary := conjureplugin.NewSummary("conjure-preview")
+		defer printSummary(summary, cmd.OutOrStdout())
+		parsedConfigSet, err = filterSelectedProjects(parsedConfigSet, summary)
+		if err != nil {
+			return err
+		}
+		loadedAssets, err := loadAssets(parsedConfigSet, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		if err := resolveGeneratorAssets(parsedConfigSet, loadedAssets); err != nil {
+			return err
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		buf := &bytes.Buffer{}
+		if err := conjureplugin.Preview(parsedConfigSet, projectDirFlag, buf, cmd.OutOrStdout(),
+			conjureplugin.SummaryParam(summary),
+			conjureplugin.PluginVersionParam(Version),
+			irValidatorsParam(parsedConfigSet, loadedAssets),
+		); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write preview to %s", outPath)
+		}
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote preview to %s\n", outPath)
+		return nil
+	},
+}
+
+func init() {
+	previewCmd.Flags().StringVar(&previewOutFlagVal, "out", "", "file to which the zip archive of the preview is written")
+	addProjectsFlag(previewCmd.Flags())
+	rootCmd.AddCommand(previewCmd)
+}
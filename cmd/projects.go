@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/spf13/pflag"
+)
+
+const projectsFlagName = "projects"
+
+var (
+	projectsFlagVal []string
+)
+
+func addProjectsFlag(flags *pflag.FlagSet) {
+	flags.StringSliceVar(&projectsFlagVal, projectsFlagName, nil, "only operate on the specified projects (may be specified multiple times or as a comma-separated list)")
+}
+
+// filterSelectedProjects returns the provided parameters filtered to only contain the projects specified by the
+// --projects flag. The projects that are filtered out are recorded as skipped in the provided summary. Returns the
+// provided parameters unmodified if the flag is not specified and an error if it specifies an unknown project.
+func filterSelectedProjects(projectParams conjureplugin.ConjureProjectParams, summary *conjureplugin.Summary) (conjureplugin.ConjureProjectParams, error) {
+	if len(projectsFlagVal) == 0 {
+		return projectParams, nil
+	}
+	selected, err := projectParams.Select(projectsFlagVal)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	var unselected []string
+	for _, name := range projectParams.SortedKeys {
+		if _, ok := selected.Params[name]; !ok {
+			unselected = append(unselected, name)
+		}
+	}
+	summary.Skip(unselected, "not selected by --"+projectsFlagName)
+	return selected, nil
+}
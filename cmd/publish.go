@@ -27,13 +27,14 @@ import (
 )
 
 var (
-	groupIDFlagVal    string
-	urlFlagVal        string
-	usernameFlagVal   string
-	passwordFlagVal   string
-	repositoryFlagVal string
-	mavenNoPOMFlagVal bool
-	dryRunFlagVal     bool
+	groupIDFlagVal         string
+	urlFlagVal             string
+	usernameFlagVal        string
+	passwordFlagVal        string
+	repositoryFlagVal      string
+	mavenNoPOMFlagVal      bool
+	dryRunFlagVal          bool
+	verifyArtifactsFlagVal bool
 )
 
 var publishCmd = &cobra.Command{
@@ -44,6 +45,24 @@ var publishCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		summary := conjureplugin.NewSummary("conjure-publish")
+		defer printSummary(summary, cmd.OutOrStdout())
+
+		projectParams, err = filterSelectedProjects(projectParams, summary)
+		if err != nil {
+			return err
+		}
+		projectParams, err = filterChangedProjects(projectParams, summary, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		loadedAssets, err := loadAssets(projectParams, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		if err := resolvePublisherAsset(&projectParams, loadedAssets); err != nil {
+			return err
+		}
 		if err := os.Chdir(projectDirFlag); err != nil {
 			return errors.Wrapf(err, "failed to set working directory")
 		}
@@ -65,12 +84,15 @@ var publishCmd = &cobra.Command{
 			}
 			flagVals[currFlag.Name] = val
 		}
-		return conjureplugin.Publish(projectParams, projectDirFlag, flagVals, dryRunFlagVal, cmd.OutOrStdout())
+		return conjureplugin.Publish(projectParams, projectDirFlag, flagVals, dryRunFlagVal, cmd.OutOrStdout(), conjureplugin.SummaryParam(summary), conjureplugin.PluginVersionParam(Version), conjureplugin.VerifyArtifactsParam(verifyArtifactsFlagVal), irValidatorsParam(projectParams, loadedAssets))
 	},
 }
 
 func init() {
 	publishCmd.Flags().BoolVar(&dryRunFlagVal, "dry-run", false, "print the operations that would be performed")
+	publishCmd.Flags().BoolVar(&verifyArtifactsFlagVal, "verify-artifacts", false, "verify that the IR artifacts are valid before they are published")
+	addChangedSinceFlag(publishCmd.Flags())
+	addProjectsFlag(publishCmd.Flags())
 
 	publishCmd.Flags().StringVar(&groupIDFlagVal, string(publisher.GroupIDFlag.Name), "", publisher.GroupIDFlag.Description)
 	publishCmd.Flags().StringVar(&repositoryFlagVal, string(artifactory.PublisherRepositoryFlag.Name), "", artifactory.PublisherRepositoryFlag.Description)
@@ -0,0 +1,46 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/palantir/distgo/publisher"
+	"github.com/palantir/distgo/publisher/artifactory"
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+var (
+	publishedRepositoryFlagVal conjureplugin.PublishedBaseline
+)
+
+// addPublishedRepositoryFlags adds the flags that specify the Artifactory repository from which published IR is
+// resolved. The flags have the same names as the corresponding flags of the publish command.
+func addPublishedRepositoryFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&publishedRepositoryFlagVal.GroupID, string(publisher.GroupIDFlag.Name), "", "group ID of the published IR")
+	flags.StringVar(&publishedRepositoryFlagVal.Repository, string(artifactory.PublisherRepositoryFlag.Name), "", "repository from which the published IR is resolved")
+	flags.StringVar(&publishedRepositoryFlagVal.URL, string(publisher.ConnectionInfoURLFlag.Name), "", "URL of the Artifactory instance from which the published IR is resolved")
+	flags.StringVar(&publishedRepositoryFlagVal.Username, string(publisher.ConnectionInfoUsernameFlag.Name), "", publisher.ConnectionInfoUsernameFlag.Description)
+	flags.StringVar(&publishedRepositoryFlagVal.Password, string(publisher.ConnectionInfoPasswordFlag.Name), "", publisher.ConnectionInfoPasswordFlag.Description)
+}
+
+// publishedRepository returns the repository specified by the flags added by addPublishedRepositoryFlags. Returns an
+// error if the URL, repository or group ID is not specified.
+func publishedRepository() (conjureplugin.PublishedBaseline, error) {
+	if publishedRepositoryFlagVal.URL == "" || publishedRepositoryFlagVal.Repository == "" || publishedRepositoryFlagVal.GroupID == "" {
+		return conjureplugin.PublishedBaseline{}, errors.Errorf("--url, --repository and --group-id must be specified to resolve published IR")
+	}
+	return publishedRepositoryFlagVal, nil
+}
@@ -15,22 +15,48 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/ircache"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
 	"github.com/palantir/godel/v2/framework/pluginapi"
 	"github.com/palantir/pkg/cobracli"
 	"github.com/spf13/cobra"
 )
 
-const VerifyFlagName = "verify"
+const (
+	VerifyFlagName = "verify"
+
+	rejectLegacyConfigFlagName = "reject-legacy-config"
+	// rejectLegacyConfigEnvVar is the environment variable that, if set to "true", has the same effect as the
+	// --reject-legacy-config flag.
+	rejectLegacyConfigEnvVar = "CONJURE_PLUGIN_REJECT_LEGACY_CONFIG"
+	// hermeticEnvVar is the environment variable that, if set to "true", has the same effect as the --hermetic flag.
+	hermeticEnvVar = "CONJURE_PLUGIN_HERMETIC"
+)
 
 var (
-	debugFlagVal   bool
-	projectDirFlag string
-	configFileFlag string
+	debugFlagVal              bool
+	projectDirFlag            string
+	configFileFlag            string
+	assetsFlagVal             []string
+	rejectLegacyConfigFlagVal bool
+	tempDirFlagVal            string
+	refreshIRFlagVal          bool
+	offlineFlagVal            bool
+	hermeticFlagVal           bool
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "conjure-plugin",
 	Short: "Run conjure-go based on project configuration",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		tempfilecreator.SetRoot(tempDirFlagVal)
+		ircache.SetRefresh(refreshIRFlagVal)
+		offline.SetEnabled(offlineFlagVal)
+	},
 }
 
 func Execute() int {
@@ -47,4 +73,11 @@ func init() {
 	if err := rootCmd.MarkPersistentFlagRequired(pluginapi.ConfigFlagName); err != nil {
 		panic(err)
 	}
+	pluginapi.AddAssetsPFlagPtr(rootCmd.PersistentFlags(), &assetsFlagVal)
+	rootCmd.PersistentFlags().StringVar(&tempDirFlagVal, "temp-dir", "", fmt.Sprintf("directory in which temporary files are created (if unspecified, the value of %s or the default temporary directory is used)", tempfilecreator.RootEnvVar))
+	rootCmd.PersistentFlags().BoolVar(&jsonFlagVal, "json", false, "print the summary that is printed at the end of commands as JSON")
+	rootCmd.PersistentFlags().BoolVar(&refreshIRFlagVal, "refresh-ir", false, "download remote IR rather than using cached IR (the downloaded IR is still cached)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlagVal, "offline", false, fmt.Sprintf("fail immediately rather than accessing the network (for example, to download remote IR or publish); cached remote IR is used regardless of its age (can also be enabled by setting %s=true)", offline.EnvVar))
+	rootCmd.PersistentFlags().BoolVar(&hermeticFlagVal, "hermetic", os.Getenv(hermeticEnvVar) == "true", fmt.Sprintf("replace values that depend on the environment (durations, temporary file names and the paths of the temporary, project and home directories) with placeholders in the summary and verify report so that the output of runs in different environments can be compared (can also be enabled by setting %s=true)", hermeticEnvVar))
+	rootCmd.PersistentFlags().BoolVar(&rejectLegacyConfigFlagVal, rejectLegacyConfigFlagName, os.Getenv(rejectLegacyConfigEnvVar) == "true", fmt.Sprintf("reject configuration that does not explicitly declare the current configuration version rather than implicitly upgrading it (can also be enabled by setting %s=true)", rejectLegacyConfigEnvVar))
 }
@@ -15,7 +15,11 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config"
@@ -24,33 +28,223 @@ import (
 )
 
 var (
-	verifyFlag bool
+	verifyFlag        bool
+	outputRootFlagVal string
+	stdinJSONFlagVal  bool
+	parallelismFlag   int
+	incrementalFlag   bool
+	verifyOutputFlag  string
+	verifyPatchFlag   string
+	verifySARIFFlag   string
+	runDryRunFlag     bool
+)
+
+const (
+	verifyOutputText = "text"
+	verifyOutputJSON = "json"
 )
 
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run conjure-go based on project configuration",
-	RunE: func(cmd *cobra.Command, args []string) error {
-		parsedConfigSet, err := toProjectParams(configFileFlag)
+	RunE: func(cmd *cobra.Command, args []string) (rErr error) {
+		switch verifyOutputFlag {
+		case verifyOutputText:
+		case verifyOutputJSON:
+			if !verifyFlag {
+				return errors.Errorf("--verify-output %s can only be specified with --%s", verifyOutputJSON, VerifyFlagName)
+			}
+		default:
+			return errors.Errorf("--verify-output must be %q or %q, was %q", verifyOutputText, verifyOutputJSON, verifyOutputFlag)
+		}
+		if verifyPatchFlag != "" && !verifyFlag {
+			return errors.Errorf("--verify-patch can only be specified with --%s", VerifyFlagName)
+		}
+		if verifySARIFFlag != "" && !verifyFlag {
+			return errors.Errorf("--verify-sarif can only be specified with --%s", VerifyFlagName)
+		}
+		if runDryRunFlag && verifyFlag {
+			return errors.Errorf("--dry-run cannot be specified with --%s", VerifyFlagName)
+		}
+		var parsedConfigSet conjureplugin.ConjureProjectParams
+		var err error
+		if stdinJSONFlagVal {
+			parsedConfigSet, err = jsonToProjectParams(cmd.InOrStdin())
+		} else {
+			parsedConfigSet, err = toProjectParams(configFileFlag)
+		}
+		if err != nil {
+			return err
+		}
+		summaryName := "conjure"
+		if verifyFlag {
+			summaryName += " (verify)"
+		}
+		summary := conjureplugin.NewSummary(summaryName)
+		defer printSummary(summary, cmd.OutOrStdout())
+		postRunHooks := parsedConfigSet.PostRunHooks
+		defer func() {
+			rErr = runPostRunHooks(postRunHooks, summary, rErr, cmd.OutOrStdout())
+		}()
+
+		parsedConfigSet, err = filterSelectedProjects(parsedConfigSet, summary)
 		if err != nil {
 			return err
 		}
+		parsedConfigSet, err = filterChangedProjects(parsedConfigSet, summary, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		loadedAssets, err := loadAssets(parsedConfigSet, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		if err := resolveGeneratorAssets(parsedConfigSet, loadedAssets); err != nil {
+			return err
+		}
+		opParams := []conjureplugin.OperationParam{
+			irValidatorsParam(parsedConfigSet, loadedAssets),
+			conjureplugin.SummaryParam(summary),
+			conjureplugin.ParallelismParam(parallelismFlag),
+			conjureplugin.IncrementalParam(incrementalFlag),
+			conjureplugin.DryRunParam(runDryRunFlag),
+			conjureplugin.PluginVersionParam(Version),
+		}
+		// the paths of the files in the verify report are relative to the output root if it is specified
+		verifyRootDir := projectDirFlag
+		if outputRootFlagVal != "" {
+			// resolve the output root before changing the working directory so that it is relative to the directory
+			// in which the command was invoked
+			outputRoot, err := filepath.Abs(outputRootFlagVal)
+			if err != nil {
+				return errors.Wrapf(err, "failed to determine absolute path of %s", outputRootFlagVal)
+			}
+			opParams = append(opParams, conjureplugin.OutputRootParam(outputRoot))
+			verifyRootDir = outputRoot
+		}
+		var verifyReport *conjureplugin.VerifyReport
+		if verifyOutputFlag == verifyOutputJSON {
+			verifyReport = &conjureplugin.VerifyReport{}
+			opParams = append(opParams, conjureplugin.VerifyReportParam(verifyReport))
+		}
+		var verifySARIFPath string
+		var verifySARIFOptions conjureplugin.SARIFOptions
+		if verifySARIFFlag != "" {
+			// resolve the paths of the SARIF log before changing the working directory so that they are relative to
+			// the directory in which the command was invoked
+			if verifySARIFPath, err = filepath.Abs(verifySARIFFlag); err != nil {
+				return errors.Wrapf(err, "failed to determine absolute path of %s", verifySARIFFlag)
+			}
+			if verifySARIFOptions, err = sarifOptions(verifyRootDir, stdinJSONFlagVal); err != nil {
+				return err
+			}
+			if verifyReport == nil {
+				verifyReport = &conjureplugin.VerifyReport{}
+				opParams = append(opParams, conjureplugin.VerifyRecordParam(verifyReport))
+			}
+		}
+		var verifyPatch *bytes.Buffer
+		var verifyPatchPath string
+		if verifyPatchFlag != "" {
+			// resolve the patch path before changing the working directory so that it is relative to the directory in
+			// which the command was invoked
+			if verifyPatchPath, err = filepath.Abs(verifyPatchFlag); err != nil {
+				return errors.Wrapf(err, "failed to determine absolute path of %s", verifyPatchFlag)
+			}
+			verifyPatch = &bytes.Buffer{}
+			opParams = append(opParams, conjureplugin.VerifyPatchParam(verifyPatch))
+		}
 		if err := os.Chdir(projectDirFlag); err != nil {
 			return errors.Wrapf(err, "failed to set working directory")
 		}
-		return conjureplugin.Run(parsedConfigSet, verifyFlag, projectDirFlag, cmd.OutOrStdout())
+		runErr := conjureplugin.Run(parsedConfigSet, verifyFlag, projectDirFlag, cmd.OutOrStdout(), opParams...)
+		if verifyReport != nil && hermeticFlagVal {
+			verifyReport.Normalize(conjureplugin.NewNormalizer(projectDirFlag))
+		}
+		if verifyOutputFlag == verifyOutputJSON {
+			if err := verifyReport.PrintJSON(cmd.OutOrStdout()); err != nil && runErr == nil {
+				return err
+			}
+		}
+		if verifySARIFPath != "" {
+			// the log is written even if verification succeeds so that previously reported results are resolved
+			if err := writeSARIF(verifySARIFPath, func(w io.Writer) error {
+				return conjureplugin.WriteVerifySARIF(w, verifyReport, verifySARIFOptions)
+			}); err != nil && runErr == nil {
+				return err
+			}
+		}
+		if verifyPatch != nil && verifyPatch.Len() > 0 {
+			if err := os.WriteFile(verifyPatchPath, verifyPatch.Bytes(), 0644); err != nil {
+				return errors.Wrapf(err, "failed to write patch to %s", verifyPatchPath)
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote patch that updates the generated code to %s\n", verifyPatchPath)
+		}
+		return runErr
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolVar(&verifyFlag, VerifyFlagName, false, "verify that current project matches output of conjure")
+	runCmd.Flags().StringVar(&outputRootFlagVal, "output", "", "if specified, the output of every project is written under this directory instead of the project directory")
+	runCmd.Flags().BoolVar(&stdinJSONFlagVal, "stdin-json", false, "read the configuration of the projects to generate as a JSON document from stdin rather than from the configuration file")
+	runCmd.Flags().IntVar(&parallelismFlag, "parallelism", 0, "maximum number of projects that are generated concurrently (if unspecified, the value of parallelism in the configuration is used)")
+	runCmd.Flags().BoolVar(&incrementalFlag, "incremental", false, "skip generation for projects whose IR and generation options have not changed since they were last generated (can also be enabled using incremental in the configuration)")
+	runCmd.Flags().StringVar(&verifyOutputFlag, "verify-output", verifyOutputText, fmt.Sprintf("format in which the differences found by --%s are printed: %q prints indented text and %q prints a single line of JSON that lists the files that differ for every project", VerifyFlagName, verifyOutputText, verifyOutputJSON))
+	runCmd.Flags().StringVar(&verifyPatchFlag, "verify-patch", "", fmt.Sprintf("if --%s fails, write a patch that updates the generated code to this file (the patch can be applied in the project directory using \"git apply\")", VerifyFlagName))
+	runCmd.Flags().StringVar(&verifySARIFFlag, "verify-sarif", "", fmt.Sprintf("write the differences found by --%s to this file as a SARIF log (for example, for GitHub code scanning)", VerifyFlagName))
+	runCmd.Flags().BoolVar(&runDryRunFlag, "dry-run", false, "print the files that would be written, overwritten and deleted for every project without changing any files")
+	addChangedSinceFlag(runCmd.Flags())
+	addProjectsFlag(runCmd.Flags())
 	rootCmd.AddCommand(runCmd)
 }
 
+// runPostRunHooks runs the provided post-run hooks after the run that returned runErr. If the run succeeded, the error
+// returned by the hooks is returned. Otherwise, it is printed as a warning and runErr is returned so that failures of the
+// hooks are not mistaken for failures of generation.
+func runPostRunHooks(hooks []conjureplugin.PostRunHook, summary *conjureplugin.Summary, runErr error, stdout io.Writer) error {
+	finishSummary(summary)
+	if err := conjureplugin.RunPostRunHooks(hooks, summary, projectDirFlag, stdout); err != nil {
+		if runErr != nil {
+			_, _ = fmt.Fprintf(stdout, "Warning: %v\n", err)
+			return runErr
+		}
+		return err
+	}
+	return runErr
+}
+
 func toProjectParams(cfgFile string) (conjureplugin.ConjureProjectParams, error) {
-	config, err := config.ReadConfigFromFile(cfgFile)
+	cfgBytes, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, errors.WithStack(err)
+	}
+	return configBytesToProjectParams(cfgBytes)
+}
+
+// jsonToProjectParams returns the project parameters for the JSON configuration read from the provided reader. The
+// configuration uses the same keys and structure as the YAML configuration file.
+func jsonToProjectParams(r io.Reader) (conjureplugin.ConjureProjectParams, error) {
+	jsonBytes, err := io.ReadAll(r)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "failed to read JSON configuration")
+	}
+	cfg, err := config.ReadConfigFromJSON(jsonBytes)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	return cfg.ToParams()
+}
+
+func configBytesToProjectParams(cfgBytes []byte) (conjureplugin.ConjureProjectParams, error) {
+	if rejectLegacyConfigFlagVal {
+		if err := config.VerifyCurrentVersion(cfgBytes); err != nil {
+			return conjureplugin.ConjureProjectParams{}, err
+		}
+	}
+	cfg, err := config.ReadConfigFromBytes(cfgBytes)
 	if err != nil {
 		return conjureplugin.ConjureProjectParams{}, err
 	}
-	return config.ToParams()
+	return cfg.ToParams()
 }
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+)
+
+// sarifOptions returns the options of SARIF logs whose paths are relative to the provided root directory. The root
+// directory is omitted in hermetic mode, and the configuration file is recorded only if it is in the root directory.
+func sarifOptions(rootDir string, stdinJSON bool) (conjureplugin.SARIFOptions, error) {
+	opts := conjureplugin.SARIFOptions{
+		ToolVersion: Version,
+	}
+	absRootDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return conjureplugin.SARIFOptions{}, errors.Wrapf(err, "failed to determine absolute path of %s", rootDir)
+	}
+	if configFileFlag != "" && !stdinJSON {
+		absConfigFile, err := filepath.Abs(configFileFlag)
+		if err != nil {
+			return conjureplugin.SARIFOptions{}, errors.Wrapf(err, "failed to determine absolute path of %s", configFileFlag)
+		}
+		if relPath, err := filepath.Rel(absRootDir, absConfigFile); err == nil && !strings.HasPrefix(relPath, "..") {
+			opts.ConfigFile = filepath.ToSlash(relPath)
+		}
+	}
+	if !hermeticFlagVal {
+		opts.RootDir = absRootDir
+	}
+	return opts, nil
+}
+
+// writeSARIF writes the SARIF log written by the provided function to the file at the provided path.
+func writeSARIF(path string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write SARIF log to %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+)
+
+var (
+	jsonFlagVal bool
+)
+
+// printSummary finishes the provided summary and prints it to the provided writer as a table or, if --json is
+// specified, as a single line of JSON.
+func printSummary(summary *conjureplugin.Summary, w io.Writer) {
+	finishSummary(summary)
+	if jsonFlagVal {
+		_ = summary.PrintJSON(w)
+		return
+	}
+	summary.Print(w)
+}
+
+// finishSummary finishes the provided summary and, if --hermetic is specified, normalizes it.
+func finishSummary(summary *conjureplugin.Summary) {
+	summary.Finish()
+	if hermeticFlagVal {
+		summary.Normalize(conjureplugin.NewNormalizer(projectDirFlag))
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var whichCmd = &cobra.Command{
+	Use:   "which <path>",
+	Short: "Print the project that generated a file",
+	Long: `Print the project that generated the file at the specified path, the source of the IR of the project, the Conjure
+YAML files that define the package of the file (if the IR of the project is generated from local YAML) and the command
+that regenerates the file. The path is relative to the directory in which the command is invoked.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// resolve the path before changing the working directory so that it is relative to the directory in which the
+		// command was invoked
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine absolute path of %s", args[0])
+		}
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		return conjureplugin.Which(projectParams, ".", path, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(whichCmd)
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	writeIRProjectFlagVal string
+	writeIROutputFlagVal  string
+)
+
+var writeIRCmd = &cobra.Command{
+	Use:   "write-ir",
+	Short: "Write the IR of projects without generating code",
+	Long: `Compile the IR of projects and write it without generating code or publishing. If --project is specified, the IR of
+that project is written to the file specified by --output or to stdout if --output is "-" or is not specified.
+Otherwise, the IR of every project is written to "<project>.conjure.json" in the directory specified by --output.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectParams, err := toProjectParams(configFileFlag)
+		if err != nil {
+			return err
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		// the summary is not printed if the IR is written to stdout so that the output is only the IR
+		if writeIRProjectFlagVal == "" || (writeIROutputFlagVal != "" && writeIROutputFlagVal != "-") {
+			summary := conjureplugin.NewSummary("conjure-write-ir")
+			defer printSummary(summary, cmd.OutOrStdout())
+			return conjureplugin.WriteIR(projectParams, writeIRProjectFlagVal, writeIROutputFlagVal, cmd.OutOrStdout(), conjureplugin.SummaryParam(summary))
+		}
+		return conjureplugin.WriteIR(projectParams, writeIRProjectFlagVal, writeIROutputFlagVal, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	writeIRCmd.Flags().StringVar(&writeIRProjectFlagVal, "project", "", "project whose IR is written (if unspecified, the IR of all projects is written)")
+	writeIRCmd.Flags().StringVar(&writeIROutputFlagVal, "output", "", `file to which the IR is written ("-" for stdout) if --project is specified, and directory to which the IR files are written otherwise`)
+	rootCmd.AddCommand(writeIRCmd)
+}
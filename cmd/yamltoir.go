@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var yamlToIRCmd = &cobra.Command{
+	Use:   "yaml-to-ir [file|-]",
+	Short: "Compile Conjure YAML into IR",
+	Long: `Compile the Conjure YAML in the provided file into IR and write the IR to stdout. If no file is provided or the file
+is "-", the YAML is read from stdin. This is a plumbing command intended for tools that generate Conjure YAML
+programmatically.`,
+	Args:   cobra.MaximumNArgs(1),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var in io.Reader = cmd.InOrStdin()
+		if len(args) == 1 && args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return errors.Wrapf(err, "failed to open %s", args[0])
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+			in = f
+		}
+		irBytes, err := conjureircli.ReaderToIR(in)
+		if err != nil {
+			return err
+		}
+		if _, err := cmd.OutOrStdout().Write(irBytes); err != nil {
+			return errors.WithStack(err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(yamlToIRCmd)
+}
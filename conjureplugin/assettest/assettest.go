@@ -0,0 +1,320 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assettest provides conformance tests for assets of the plugin. Asset authors can run the tests against their
+// asset binary in their own CI to verify that the asset complies with the protocol of its type as implemented by the
+// plugin:
+//
+//	func TestConformance(t *testing.T) {
+//		assettest.Run(t, "build/my-asset")
+//	}
+package assettest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/assets"
+)
+
+// commandTimeout is the maximum amount of time that each invocation of the asset may take.
+const commandTimeout = 30 * time.Second
+
+// emptyIR is valid IR that contains no definitions.
+const emptyIR = `{"version":1,"errors":[],"types":[],"services":[],"extensions":{}}`
+
+// removedTypeIR is valid IR that defines a single object. IR that does not define the object is not backwards
+// compatible with it.
+const removedTypeIR = `{"version":1,"errors":[],"types":[{"type":"object","object":{"typeName":{"name":"Removed","package":"com.palantir.assettest"},"fields":[]}}],"services":[],"extensions":{}}`
+
+// Param configures the conformance tests.
+type Param interface {
+	apply(*args)
+}
+
+type args struct {
+	config     json.RawMessage
+	rejectedIR []byte
+}
+
+type paramFn func(*args)
+
+func (fn paramFn) apply(a *args) {
+	fn(a)
+}
+
+// ConfigParam returns a parameter that provides the provided configuration (which must be JSON) to the asset as the
+// "config" key of the JSON object it is invoked with, like the asset-config of the plugin configuration.
+func ConfigParam(config []byte) Param {
+	return paramFn(func(a *args) {
+		a.config = config
+	})
+}
+
+// RejectedIRParam returns a parameter that specifies IR that an IR validator asset must reject. If it is not specified,
+// the test that verifies that IR validator assets report rejected IR using exit status 1 is skipped.
+func RejectedIRParam(ir []byte) Param {
+	return paramFn(func(a *args) {
+		a.rejectedIR = ir
+	})
+}
+
+// Run runs the conformance tests for the asset at the provided path as subtests of t. The "handshake" test verifies
+// that the asset is executable and that its "_assetInfo" command prints a JSON object that reports a supported type.
+// The remaining tests are specific to the type of the asset:
+//
+//   - "happy path": the asset succeeds for IR that contains no definitions (backcompat assets must report that such IR
+//     is backwards compatible with itself, generator assets must generate output for it, publisher assets must perform
+//     a dry run of publishing it and IR validator assets must report that it is valid)
+//   - "exit codes": backcompat assets exit with status 1 and describe the incompatibilities if a type is removed, and IR
+//     validator assets exit with status 1 for the IR specified by RejectedIRParam
+//   - "malformed input": the asset exits with a status that does not report a result (a non-zero status, other than 1
+//     for backcompat and IR validator assets) if its argument is not JSON, if the IR it references does not exist and
+//     if it is invoked with a command that it does not support
+func Run(t *testing.T, assetPath string, params ...Param) {
+	var a args
+	for _, param := range params {
+		if param != nil {
+			param.apply(&a)
+		}
+	}
+
+	var info assetInfo
+	if !t.Run("handshake", func(t *testing.T) {
+		info = handshake(t, assetPath)
+	}) {
+		return
+	}
+	c := &conformance{
+		assetPath: assetPath,
+		assetType: info.Type,
+		args:      a,
+	}
+	t.Run("happy path", c.testHappyPath)
+	t.Run("exit codes", c.testExitCodes)
+	t.Run("malformed input", c.testMalformedInput)
+}
+
+type assetInfo struct {
+	Type    assets.AssetType `json:"type"`
+	Name    string           `json:"name,omitempty"`
+	Version string           `json:"version,omitempty"`
+}
+
+// handshake verifies that the asset at the provided path handles the "_assetInfo" command and returns the information
+// that it prints.
+func handshake(t *testing.T, assetPath string) assetInfo {
+	fi, err := os.Stat(assetPath)
+	if err != nil {
+		t.Fatalf("failed to stat asset: %v", err)
+	}
+	if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+		t.Fatalf("asset must be an executable regular file, but its mode is %s", fi.Mode())
+	}
+	output, exitCode, err := runAsset(assetPath, "_assetInfo")
+	if err != nil {
+		t.Fatalf("failed to run _assetInfo: %v", err)
+	}
+	if exitCode != 0 {
+		t.Fatalf("_assetInfo exited with status %d\nOutput:\n%s", exitCode, output)
+	}
+	var info assetInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		t.Fatalf("output of _assetInfo is not a JSON object: %v\nOutput:\n%s", err, output)
+	}
+	switch info.Type {
+	case assets.BackCompatAssetType, assets.GeneratorAssetType, assets.PublisherAssetType, assets.IRValidatorAssetType:
+	case "":
+		t.Fatalf("output of _assetInfo does not specify a type\nOutput:\n%s", output)
+	default:
+		t.Fatalf("_assetInfo reports unsupported type %q", info.Type)
+	}
+	return info
+}
+
+type conformance struct {
+	assetPath string
+	assetType assets.AssetType
+	args      args
+}
+
+// command returns the command that the plugin invokes for assets of the type of the asset.
+func (c *conformance) command() string {
+	switch c.assetType {
+	case assets.BackCompatAssetType:
+		return "checkBackCompat"
+	case assets.GeneratorAssetType:
+		return "generate"
+	case assets.PublisherAssetType:
+		return "publish"
+	default:
+		return "validateIR"
+	}
+}
+
+// invocation returns the JSON object with which the asset is invoked for the provided IR in the provided directory.
+// baseIR is only used by backcompat assets.
+func (c *conformance) invocation(t *testing.T, dir, baseIR, ir string) []byte {
+	irPath := filepath.Join(dir, "asset-conformance-0.0.0.conjure.json")
+	writeFile(t, irPath, ir)
+	obj := map[string]interface{}{
+		"project": "asset-conformance",
+	}
+	switch c.assetType {
+	case assets.BackCompatAssetType:
+		baseIRPath := filepath.Join(dir, "base-ir.json")
+		writeFile(t, baseIRPath, baseIR)
+		obj["projectDir"] = dir
+		obj["baseIR"] = baseIRPath
+		obj["currentIR"] = irPath
+	case assets.GeneratorAssetType:
+		outputDir := filepath.Join(dir, "output")
+		if err := os.Mkdir(outputDir, 0755); err != nil {
+			t.Fatalf("failed to create output directory: %v", err)
+		}
+		obj["projectDir"] = dir
+		obj["ir"] = irPath
+		obj["outputDir"] = outputDir
+	case assets.PublisherAssetType:
+		obj["artifactId"] = "asset-conformance"
+		obj["version"] = "0.0.0"
+		obj["irFile"] = irPath
+		obj["artifacts"] = []string{irPath}
+		obj["flags"] = map[string]interface{}{}
+		obj["dryRun"] = true
+	case assets.IRValidatorAssetType:
+		obj["projectDir"] = dir
+		obj["ir"] = irPath
+	}
+	if len(c.args.config) > 0 {
+		obj["config"] = c.args.config
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("failed to marshal invocation: %v", err)
+	}
+	return out
+}
+
+func (c *conformance) testHappyPath(t *testing.T) {
+	invocation := c.invocation(t, t.TempDir(), emptyIR, emptyIR)
+	output, exitCode, err := runAsset(c.assetPath, c.command(), string(invocation))
+	if err != nil {
+		t.Fatalf("failed to run %s: %v", c.command(), err)
+	}
+	if exitCode != 0 {
+		t.Errorf("%s exited with status %d for IR that contains no definitions, but must exit with status 0\nOutput:\n%s", c.command(), exitCode, output)
+	}
+}
+
+func (c *conformance) testExitCodes(t *testing.T) {
+	var invocation []byte
+	switch c.assetType {
+	case assets.BackCompatAssetType:
+		invocation = c.invocation(t, t.TempDir(), removedTypeIR, emptyIR)
+	case assets.IRValidatorAssetType:
+		if c.args.rejectedIR == nil {
+			t.Skip("RejectedIRParam is not specified")
+		}
+		invocation = c.invocation(t, t.TempDir(), "", string(c.args.rejectedIR))
+	default:
+		t.Skipf("assets of type %s do not report results using exit codes", c.assetType)
+	}
+	output, exitCode, err := runAsset(c.assetPath, c.command(), string(invocation))
+	if err != nil {
+		t.Fatalf("failed to run %s: %v", c.command(), err)
+	}
+	if exitCode != 1 {
+		t.Fatalf("%s exited with status %d, but must exit with status 1\nOutput:\n%s", c.command(), exitCode, output)
+	}
+	if c.assetType == assets.BackCompatAssetType && len(output) == 0 {
+		t.Errorf("%s did not describe the incompatibilities", c.command())
+	}
+}
+
+func (c *conformance) testMalformedInput(t *testing.T) {
+	missingIRInvocation := func(t *testing.T) string {
+		dir := t.TempDir()
+		invocation := c.invocation(t, dir, emptyIR, emptyIR)
+		// the invocation references the IR in the directory, which is removed
+		if err := os.Remove(filepath.Join(dir, "asset-conformance-0.0.0.conjure.json")); err != nil {
+			t.Fatalf("failed to remove IR: %v", err)
+		}
+		return string(invocation)
+	}
+	for _, tc := range []struct {
+		name string
+		args func(t *testing.T) []string
+	}{
+		{
+			name: "argument is not JSON",
+			args: func(t *testing.T) []string {
+				return []string{c.command(), "not JSON"}
+			},
+		},
+		{
+			name: "IR does not exist",
+			args: func(t *testing.T) []string {
+				return []string{c.command(), missingIRInvocation(t)}
+			},
+		},
+		{
+			name: "unsupported command",
+			args: func(t *testing.T) []string {
+				return []string{"unsupportedAssetConformanceCommand", "{}"}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			output, exitCode, err := runAsset(c.assetPath, tc.args(t)...)
+			if err != nil {
+				t.Fatalf("failed to run asset: %v", err)
+			}
+			if exitCode == 0 {
+				t.Errorf("asset exited with status 0, but must exit with a non-zero status\nOutput:\n%s", output)
+			} else if exitCode == 1 && (c.assetType == assets.BackCompatAssetType || c.assetType == assets.IRValidatorAssetType) {
+				t.Errorf("asset exited with status 1, which reports a result, but must exit with a different non-zero status\nOutput:\n%s", output)
+			}
+		})
+	}
+}
+
+// runAsset runs the asset at the provided path with the provided arguments and returns its standard output and exit
+// status. Returns an error if the asset could not be run or did not exit within commandTimeout.
+func runAsset(assetPath string, cmdArgs ...string) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, assetPath, cmdArgs...).Output()
+	if ctx.Err() != nil {
+		return nil, 0, ctx.Err()
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return append(output, exitErr.Stderr...), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return output, 0, nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assettest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/assettest"
+	"github.com/stretchr/testify/require"
+)
+
+// conformingAsset is a script that complies with the protocol of the asset type in $ASSET_TYPE. It exits with status 2
+// for malformed input, reports that IR that defines an object is not backwards compatible with IR that does not and
+// rejects IR that defines a type in the "com.palantir.rejected" package.
+const conformingAsset = `#!/bin/sh
+if [ "$1" = "_assetInfo" ]; then
+  echo '{"type":"'$ASSET_TYPE'","name":"conforming","version":"1.0.0"}'
+  exit 0
+fi
+case "$1" in
+  checkBackCompat|generate|publish|validateIR) ;;
+  *) echo "unsupported command $1" >&2; exit 2 ;;
+esac
+case "$2" in
+  {*}) ;;
+  *) echo "argument is not JSON" >&2; exit 2 ;;
+esac
+field() {
+  echo "$2" | sed -n 's/.*"'$1'":"\([^"]*\)".*/\1/p'
+}
+ir=$(field ir "$2")$(field currentIR "$2")$(field irFile "$2")
+if [ ! -f "$ir" ]; then
+  echo "IR $ir does not exist" >&2
+  exit 2
+fi
+case "$1" in
+  checkBackCompat)
+    if grep -q '"object"' "$(field baseIR "$2")" && ! grep -q '"object"' "$ir"; then
+      echo "type com.palantir.assettest.Removed was removed"
+      exit 1
+    fi ;;
+  generate)
+    echo "generated" > "$(field outputDir "$2")/index.ts" ;;
+  validateIR)
+    if grep -q 'com.palantir.rejected' "$ir"; then
+      echo '{"findings":[{"message":"package is rejected"}]}'
+      exit 1
+    fi ;;
+esac
+`
+
+func TestRun(t *testing.T) {
+	assetPath := filepath.Join(t.TempDir(), "asset")
+	require.NoError(t, os.WriteFile(assetPath, []byte(conformingAsset), 0755))
+
+	for _, assetType := range []string{"backcompat", "generator", "publisher", "ir-validator"} {
+		t.Run(assetType, func(t *testing.T) {
+			t.Setenv("ASSET_TYPE", assetType)
+			assettest.Run(t, assetPath,
+				assettest.ConfigParam([]byte(`{"strict":true}`)),
+				assettest.RejectedIRParam([]byte(`{"version":1,"errors":[],"types":[{"type":"object","object":{"typeName":{"name":"Foo","package":"com.palantir.rejected"},"fields":[]}}],"services":[],"extensions":{}}`)),
+			)
+		})
+	}
+}
@@ -0,0 +1,508 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/pkg/errors"
+)
+
+// BackCompatChecker checks whether the IR of a project is backwards compatible with a baseline IR.
+type BackCompatChecker interface {
+	// Name returns the name of the checker, which identifies it in output.
+	Name() string
+	// CheckBackCompat checks whether currentIR is backwards compatible with baseIR. Returns a non-empty description of
+	// the incompatibilities if it is not. Returns an error if the check could not be performed.
+	CheckBackCompat(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte) (string, error)
+}
+
+// frozenCheckerName is the name of the checker reported for changes to frozen projects.
+const frozenCheckerName = "frozen"
+
+// acceptingBackCompatChecker is a BackCompatChecker that is provided the descriptions of the breaks that have been
+// accepted for the project so that it can omit them from its output.
+type acceptingBackCompatChecker interface {
+	checkBackCompatAccepting(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte, acceptedBreaks []string) (string, error)
+}
+
+// breaksBackCompatChecker is a BackCompatChecker that reports every break separately with its category.
+type breaksBackCompatChecker interface {
+	checkBackCompatBreaks(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte) ([]BackCompatBreak, error)
+}
+
+// checkBackCompat returns the breaks of the provided project found by the provided checker, which is provided the
+// accepted breaks if it supports them. The output of checkers that do not report every break separately is a single
+// break whose category is the name of the checker.
+func checkBackCompat(checker BackCompatChecker, projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte, acceptedBreaks []string) ([]BackCompatBreak, error) {
+	if breaksChecker, ok := checker.(breaksBackCompatChecker); ok {
+		return breaksChecker.checkBackCompatBreaks(projectName, param, projectDir, baseIR, currentIR)
+	}
+	var description string
+	var err error
+	if accepting, ok := checker.(acceptingBackCompatChecker); ok {
+		description, err = accepting.checkBackCompatAccepting(projectName, param, projectDir, baseIR, currentIR, acceptedBreaks)
+	} else {
+		description, err = checker.CheckBackCompat(projectName, param, projectDir, baseIR, currentIR)
+	}
+	if err != nil || description == "" {
+		return nil, err
+	}
+	return []BackCompatBreak{{
+		Checker:     checker.Name(),
+		Category:    checker.Name(),
+		Description: strings.TrimRight(description, "\n"),
+	}}, nil
+}
+
+// BackCompat checks that the IR of every project is backwards compatible with its baseline using the provided
+// checkers. The baseline of a project is its pinned BackCompatBaseline if it has one. Otherwise, it is the IR compiled
+// from its definitions at the Git ref baseRef of the repository that contains projectDir or, if PublishedBaselineParam
+// is provided, the IR of its latest published release (in which case baseRef is ignored). baseRef may be empty if every
+// project has a pinned baseline. Projects that do not have a baseline (such as projects whose IR is not defined by files
+// in the repository, whose definitions do not exist at baseRef or that have not been released) are skipped. The IR of
+// frozen projects must not differ from the baseline other than in its documentation. If a checker whose name is an
+// optional asset fails, a warning is printed and the checker is skipped for the project. Breaks that have been accepted
+// in the BackCompatLockFile of projectDir are ignored. Returns ErrBackCompatFailed if any project is not backwards
+// compatible.
+func BackCompat(params ConjureProjectParams, projectDir, baseRef string, checkers []BackCompatChecker, stdout io.Writer, opParams ...OperationParam) (rErr error) {
+	opArgs := newOperationArgs(opParams)
+	summaries := newProjectSummaries(params)
+	defer func() {
+		rErr = summaries.finish(rErr, opArgs.summary)
+	}()
+
+	anyFrozen := false
+	for _, currParam := range params.Params {
+		anyFrozen = anyFrozen || currParam.Frozen
+	}
+	if len(checkers) == 0 && !anyFrozen {
+		_, _ = fmt.Fprintln(stdout, "No backcompat checkers are configured")
+		for i := range summaries.projects {
+			summaries.projects[i].Message = "no backcompat checkers are configured"
+		}
+		for _, projectName := range params.SortedKeys {
+			opArgs.backCompatReport.addProject(BackCompatProjectReport{
+				Project: projectName,
+				Status:  ProjectStatusSkipped,
+				Message: "no backcompat checkers are configured",
+			})
+		}
+		return nil
+	}
+
+	// baseline is the baseline of the projects that do not have a pinned baseline, which is nil if neither a published
+	// baseline nor a Git ref is specified
+	var baseline baselineSource
+	if opArgs.publishedBaseline != nil {
+		baseline = &publishedBaselineSource{
+			baseline: *opArgs.publishedBaseline,
+			stdout:   stdout,
+		}
+	} else if baseRef != "" {
+		baseTree, cleanup, err := newGitRefTree(projectDir, baseRef)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := cleanup(); rErr == nil && err != nil {
+				rErr = err
+			}
+		}()
+		baseline = baseTree
+	}
+	if baseline != nil && opArgs.backCompatReport != nil {
+		opArgs.backCompatReport.Baseline = baseline.String()
+	}
+
+	lock, err := ReadBackCompatLock(filepath.Join(projectDir, BackCompatLockFile))
+	if err != nil {
+		return err
+	}
+
+	var failedProjects []string
+	failures := make(map[string][]string)
+	for i, currParam := range params.OrderedParams() {
+		summaries.begin(i)
+		projectName := params.SortedKeys[i]
+		source, err := projectBaselineSource(projectName, currParam, baseline, opArgs.baselineRepository)
+		if err != nil {
+			return err
+		}
+		if currParam.BackCompatBaseline != nil {
+			_, _ = fmt.Fprintf(stdout, "Using %s of %s\n", source, projectName)
+		}
+		baseIR, baselineVersion, err := source.baselineIR(projectName, currParam, projectDir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to determine baseline IR of %s from %s", projectName, source)
+		}
+		if baseIR == nil {
+			reason, summaryReason := source.missingReason()
+			_, _ = fmt.Fprintf(stdout, "Skipping %s: %s\n", projectName, reason)
+			summaries.end(ProjectStatusSkipped, summaryReason)
+			opArgs.backCompatReport.addProject(BackCompatProjectReport{
+				Project:  projectName,
+				Status:   ProjectStatusSkipped,
+				Baseline: source.String(),
+				Message:  reason,
+			})
+			continue
+		}
+		currentIR, err := currParam.IRProvider.IRBytes()
+		if err != nil {
+			return Classify(errors.Wrapf(err, "failed to determine IR of %s", projectName), ErrIR)
+		}
+		var breaks, acceptedBreaks []BackCompatBreak
+		var warnings []string
+		// addBreaks records the provided breaks found by the checker with the provided name unless they have been
+		// accepted in the lockfile. Breaks whose severity is a warning only fail the project if the policy of the
+		// project fails on warnings.
+		addBreaks := func(name string, checkerBreaks []BackCompatBreak) {
+			accepted := make(map[string]struct{})
+			for _, description := range lock.acceptedBreaks(projectName, baselineVersion, name) {
+				accepted[description] = struct{}{}
+			}
+			var failing, warning []string
+			for _, checkerBreak := range checkerBreaks {
+				checkerBreak.ID = backCompatBreakID(projectName, checkerBreak.Checker, checkerBreak.Description)
+				checkerBreak.Severity = currParam.BackCompatPolicy.Severity(checkerBreak.Category)
+				if _, ok := accepted[checkerBreak.Description]; ok {
+					acceptedBreaks = append(acceptedBreaks, checkerBreak)
+					continue
+				}
+				breaks = append(breaks, checkerBreak)
+				if checkerBreak.Severity == BackCompatSeverityWarning && !currParam.BackCompatPolicy.FailOnWarnings {
+					warning = append(warning, checkerBreak.String())
+				} else {
+					failing = append(failing, checkerBreak.String())
+				}
+			}
+			if len(warning) > 0 {
+				warnings = append(warnings, fmt.Sprintf("%s:\n%s", name, indent(strings.Join(warning, "\n"), indentLen)))
+			}
+			if len(failing) == 0 {
+				return
+			}
+			if _, ok := failures[projectName]; !ok {
+				failedProjects = append(failedProjects, projectName)
+			}
+			failures[projectName] = append(failures[projectName], fmt.Sprintf("%s:\n%s", name, indent(strings.Join(failing, "\n"), indentLen)))
+		}
+		if currParam.Frozen {
+			same, err := irEqualIgnoringDocs(baseIR, currentIR)
+			if err != nil {
+				return errors.Wrapf(err, "failed to compare IR of %s", projectName)
+			}
+			if !same {
+				addBreaks(frozenCheckerName, []BackCompatBreak{{
+					Checker:     frozenCheckerName,
+					Category:    frozenCheckerName,
+					Description: "project is frozen, but its definitions have changes other than to documentation",
+				}})
+			}
+		}
+		for _, checker := range checkers {
+			checkerBreaks, err := checkBackCompat(checker, projectName, currParam, projectDir, baseIR, currentIR, lock.acceptedBreaks(projectName, baselineVersion, checker.Name()))
+			if err != nil {
+				if !params.OptionalAsset(checker.Name()) {
+					return errors.Wrapf(err, "backcompat checker %s failed for %s", checker.Name(), projectName)
+				}
+				_, _ = fmt.Fprintf(stdout, "Warning: skipping optional backcompat checker %s for %s: %v\n", checker.Name(), projectName, err)
+				continue
+			}
+			addBreaks(checker.Name(), checkerBreaks)
+		}
+		if len(acceptedBreaks) > 0 {
+			_, _ = fmt.Fprintf(stdout, "Ignoring %d breaks of %s accepted in %s\n", len(acceptedBreaks), projectName, BackCompatLockFile)
+		}
+		if len(warnings) > 0 {
+			_, _ = fmt.Fprintf(stdout, "Warning: %s has breaks whose severity is warning:\n", projectName)
+			for _, warning := range warnings {
+				_, _ = fmt.Fprintln(stdout, indent(warning, indentLen))
+			}
+		}
+		status := ProjectStatusSucceeded
+		if _, ok := failures[projectName]; ok {
+			status = ProjectStatusFailed
+			summaries.end(status, "not backwards compatible")
+		} else {
+			summaries.end(status, "")
+		}
+		opArgs.backCompatReport.addProject(BackCompatProjectReport{
+			Project:        projectName,
+			Status:         status,
+			Baseline:       baselineVersion,
+			Breaks:         breaks,
+			AcceptedBreaks: acceptedBreaks,
+		})
+	}
+
+	if len(failedProjects) > 0 {
+		baselineDescription := "their pinned baselines"
+		if baseline != nil {
+			baselineDescription = baseline.String()
+		}
+		_, _ = fmt.Fprintf(stdout, "Conjure definitions are not backwards compatible with %s: %v\n", baselineDescription, failedProjects)
+		for _, projectName := range failedProjects {
+			projectDescription := projectName
+			if pinned := params.Params[projectName].BackCompatBaseline; pinned != nil {
+				projectDescription += fmt.Sprintf(" (pinned baseline %s)", pinned)
+			}
+			_, _ = fmt.Fprintf(stdout, "%s%s:\n", strings.Repeat(" ", indentLen), projectDescription)
+			for _, failure := range failures[projectName] {
+				_, _ = fmt.Fprintln(stdout, indent(failure, indentLen*2))
+			}
+		}
+		return ErrBackCompatFailed
+	}
+	return nil
+}
+
+// indent returns the provided string with every line indented by the provided number of spaces.
+func indent(in string, n int) string {
+	prefix := strings.Repeat(" ", n)
+	return prefix + strings.Join(strings.Split(in, "\n"), "\n"+prefix)
+}
+
+// gitRefTree is a checkout of a repository at a specific ref.
+type gitRefTree struct {
+	// ref is the ref that is checked out.
+	ref string
+	// dir is the directory of the checkout.
+	dir string
+	// projectPrefix is the path of the project directory relative to the root of the repository.
+	projectPrefix string
+}
+
+// newGitRefTree checks out the repository that contains projectDir at the provided ref into a temporary worktree.
+// The returned function removes the worktree.
+func newGitRefTree(projectDir, ref string) (*gitRefTree, func() error, error) {
+	prefix, err := gitOutput(projectDir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "project directory %s must be in a Git repository", projectDir)
+	}
+	tmpDir, err := tempfilecreator.MkdirTemp("base-ref")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() error {
+		_ = runGit(projectDir, "worktree", "remove", "--force", tmpDir)
+		if err := os.RemoveAll(tmpDir); err != nil {
+			return errors.Wrapf(err, "failed to remove temporary directory")
+		}
+		return runGit(projectDir, "worktree", "prune")
+	}
+	if err := runGit(projectDir, "worktree", "add", "--quiet", "--detach", tmpDir, ref); err != nil {
+		_ = cleanup()
+		return nil, nil, errors.Wrapf(err, "failed to check out %s", ref)
+	}
+	return &gitRefTree{
+		ref:           ref,
+		dir:           tmpDir,
+		projectPrefix: strings.TrimSpace(prefix),
+	}, cleanup, nil
+}
+
+func (t *gitRefTree) String() string {
+	return t.ref
+}
+
+func (t *gitRefTree) missingReason() (string, string) {
+	return fmt.Sprintf("definitions do not exist in the repository at %s", t.ref), fmt.Sprintf("definitions do not exist at %s", t.ref)
+}
+
+func (t *gitRefTree) baselineIR(projectName string, param ConjureProjectParam, projectDir string) ([]byte, string, error) {
+	irBytes, err := t.irBytes(param, projectDir)
+	if err != nil || irBytes == nil {
+		return nil, "", err
+	}
+	return irBytes, t.ref, nil
+}
+
+// irBytes returns the IR for the provided project compiled from the definitions in the tree. Returns nil if the IR of
+// the project is not defined by files in the repository or if the files do not exist in the tree.
+func (t *gitRefTree) irBytes(param ConjureProjectParam, projectDir string) ([]byte, error) {
+	switch p := param.IRProvider.(type) {
+	case *localYAMLIRProvider:
+		// paths that do not exist in the tree (such as newly added directories) are omitted from the baseline
+		var paths []string
+		for _, currPath := range p.paths {
+			path, err := t.path(currPath, projectDir)
+			if err != nil {
+				return nil, err
+			}
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			return nil, nil
+		}
+		return NewLocalYAMLPathsIRProvider(paths, p.params...).IRBytes()
+	case *localFileIRProvider:
+		path, err := t.path(p.path, projectDir)
+		if err != nil || path == "" {
+			return nil, err
+		}
+		return NewLocalFileIRProvider(path).IRBytes()
+	case *projectIRProvider:
+		return t.irBytes(ConjureProjectParam{IRProvider: p.provider}, projectDir)
+	default:
+		return nil, nil
+	}
+}
+
+// path returns the path in the tree that corresponds to the provided path (or glob pattern), which is either absolute or
+// relative to projectDir. Returns an empty string if the path does not exist in the tree.
+func (t *gitRefTree) path(path, projectDir string) (string, error) {
+	if filepath.IsAbs(path) {
+		absProjectDir, err := filepath.Abs(projectDir)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(absProjectDir, path)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		path = relPath
+	}
+	treePath := filepath.Join(t.dir, t.projectPrefix, path)
+	// glob patterns are returned as patterns in the tree if they match any paths
+	if matches, err := filepath.Glob(treePath); err != nil || len(matches) == 0 {
+		return "", nil
+	}
+	return treePath, nil
+}
+
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to execute %v", cmd.Args)
+	}
+	return string(output), nil
+}
+
+var _ BackCompatChecker = &assetBackCompatChecker{}
+
+type assetBackCompatChecker struct {
+	assetPath string
+	name      string
+	config    []byte
+}
+
+// NewAssetBackCompatChecker returns a BackCompatChecker that checks backwards compatibility using the backcompat asset
+// at the provided path. The asset is invoked as "<asset> checkBackCompat <json>", where <json> is a JSON object with
+// the keys "project", "projectDir", "baseIR" and "currentIR" (the latter two are paths to files that contain the IR).
+// The asset must exit with status 0 if the IR is backwards compatible and with status 1 if it is not, in which case
+// its output should describe the incompatibilities. Any other exit status is treated as a failure of the check.
+func NewAssetBackCompatChecker(assetPath string) BackCompatChecker {
+	return NewConfiguredAssetBackCompatChecker(assetPath, filepath.Base(assetPath), nil)
+}
+
+// NewConfiguredAssetBackCompatChecker returns a BackCompatChecker that behaves like the one returned by
+// NewAssetBackCompatChecker, but has the provided name and provides the provided configuration (which must be JSON) to
+// the asset as the "config" key of the JSON object. If config is empty, the key is omitted.
+func NewConfiguredAssetBackCompatChecker(assetPath, name string, config []byte) BackCompatChecker {
+	return &assetBackCompatChecker{
+		assetPath: assetPath,
+		name:      name,
+		config:    config,
+	}
+}
+
+func (c *assetBackCompatChecker) Name() string {
+	return c.name
+}
+
+type checkBackCompatArgs struct {
+	Project        string          `json:"project"`
+	ProjectDir     string          `json:"projectDir"`
+	BaseIR         string          `json:"baseIR"`
+	CurrentIR      string          `json:"currentIR"`
+	Config         json.RawMessage `json:"config,omitempty"`
+	AcceptedBreaks []string        `json:"acceptedBreaks,omitempty"`
+}
+
+func (c *assetBackCompatChecker) CheckBackCompat(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte) (string, error) {
+	return c.checkBackCompatAccepting(projectName, param, projectDir, baseIR, currentIR, nil)
+}
+
+func (c *assetBackCompatChecker) checkBackCompatAccepting(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte, acceptedBreaks []string) (rBreaks string, rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("backcompat-" + projectName)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+
+	args := checkBackCompatArgs{
+		Project:        projectName,
+		ProjectDir:     projectDir,
+		BaseIR:         filepath.Join(tmpDir, "base-ir.json"),
+		CurrentIR:      filepath.Join(tmpDir, "current-ir.json"),
+		Config:         c.config,
+		AcceptedBreaks: acceptedBreaks,
+	}
+	if absProjectDir, err := filepath.Abs(projectDir); err == nil {
+		args.ProjectDir = absProjectDir
+	}
+	if err := os.WriteFile(args.BaseIR, baseIR, 0644); err != nil {
+		return "", errors.Wrapf(err, "failed to write baseline IR")
+	}
+	if err := os.WriteFile(args.CurrentIR, currentIR, 0644); err != nil {
+		return "", errors.Wrapf(err, "failed to write current IR")
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	cmd := exec.Command(c.assetPath, "checkBackCompat", string(argsJSON))
+	cmd.Env = append(os.Environ(), envSlice(param.Env)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			if len(output) == 0 {
+				return "definitions are not backwards compatible", nil
+			}
+			return string(output), nil
+		}
+		return "", errors.Wrapf(err, "failed to execute %v\nOutput:\n%s", cmd.Args, string(output))
+	}
+	return "", nil
+}
+
+// envSlice returns the provided environment variables in the "KEY=VALUE" form sorted by key.
+func envSlice(env map[string]string) []string {
+	var out []string
+	for k, v := range env {
+		out = append(out, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,514 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackCompatBaseRef(t *testing.T) {
+	repoDir := t.TempDir()
+	projectDir := filepath.Join(repoDir, "project")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+	gitCmd("init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "changed.json"), []byte(testIRJSON), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "unchanged.json"), []byte(testIRJSON), 0644))
+	gitCmd("add", ".")
+	gitCmd("commit", "--quiet", "-m", "base")
+	gitCmd("tag", "base")
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "changed.json"), []byte(testIRJSON+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "added.json"), []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"added", "changed", "unchanged"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"added": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "added.json")),
+			},
+			"changed": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "changed.json")),
+			},
+			"unchanged": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "unchanged.json")),
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	err := conjureplugin.BackCompat(params, projectDir, "base", []conjureplugin.BackCompatChecker{irEqualityChecker{}}, buf)
+	require.EqualError(t, err, "conjure backcompat failed")
+	assert.Equal(t, `Skipping added: definitions do not exist in the repository at base
+Conjure definitions are not backwards compatible with base: [changed]
+  changed:
+    ir-equality:
+      [485649b9fe] IR differs from baseline
+`, buf.String())
+
+	// temporary worktree should be removed
+	output, err := exec.Command("git", "-C", repoDir, "worktree", "list").Output()
+	require.NoError(t, err)
+	assert.Len(t, bytes.Split(bytes.TrimSpace(output), []byte("\n")), 1)
+}
+
+func TestBackCompatFrozen(t *testing.T) {
+	repoDir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+	gitCmd("init", "--quiet")
+	irFile := filepath.Join(repoDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	gitCmd("add", ".")
+	gitCmd("commit", "--quiet", "-m", "base")
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Frozen:     true,
+			},
+		},
+	}
+
+	docsIR := strings.Replace(testIRJSON, `"fieldName" : "name",`, `"fieldName" : "name", "docs" : "The name of the test case.",`, 1)
+	require.NoError(t, os.WriteFile(irFile, []byte(docsIR), 0644))
+	require.NoError(t, conjureplugin.BackCompat(params, repoDir, "HEAD", nil, &bytes.Buffer{}))
+
+	changedIR := strings.Replace(docsIR, `"primitive" : "STRING"`, `"primitive" : "INTEGER"`, 1)
+	require.NoError(t, os.WriteFile(irFile, []byte(changedIR), 0644))
+	buf := &bytes.Buffer{}
+	err := conjureplugin.BackCompat(params, repoDir, "HEAD", nil, buf)
+	require.EqualError(t, err, "conjure backcompat failed")
+	assert.Equal(t, `Conjure definitions are not backwards compatible with HEAD: [project-1]
+  project-1:
+    frozen:
+      [7731c3da6c] project is frozen, but its definitions have changes other than to documentation
+`, buf.String())
+}
+
+func TestBackCompatOptionalAsset(t *testing.T) {
+	repoDir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+	gitCmd("init", "--quiet")
+	irFile := filepath.Join(repoDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	gitCmd("add", ".")
+	gitCmd("commit", "--quiet", "-m", "base")
+
+	assetPath := filepath.Join(repoDir, "failing-asset")
+	require.NoError(t, os.WriteFile(assetPath, []byte("#!/bin/sh\necho crashed\nexit 2\n"), 0755))
+	checkers := []conjureplugin.BackCompatChecker{
+		conjureplugin.NewAssetBackCompatChecker(assetPath),
+	}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+
+	err := conjureplugin.BackCompat(params, repoDir, "HEAD", checkers, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backcompat checker failing-asset failed for project-1")
+
+	params.AssetOptions = map[string]conjureplugin.AssetOptions{
+		"failing-asset": {
+			Optional: true,
+		},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.BackCompat(params, repoDir, "HEAD", checkers, buf))
+	assert.Contains(t, buf.String(), "Warning: skipping optional backcompat checker failing-asset for project-1: failed to execute")
+}
+
+type irEqualityChecker struct{}
+
+func (irEqualityChecker) Name() string {
+	return "ir-equality"
+}
+
+func (irEqualityChecker) CheckBackCompat(projectName string, param conjureplugin.ConjureProjectParam, projectDir string, baseIR, currentIR []byte) (string, error) {
+	if bytes.Equal(baseIR, currentIR) {
+		return "", nil
+	}
+	return "IR differs from baseline", nil
+}
+
+func TestAssetBackCompatCheckerConfig(t *testing.T) {
+	dir := t.TempDir()
+	assetPath := filepath.Join(dir, "backcompat-asset")
+	// asset that reports its arguments as breaks
+	require.NoError(t, os.WriteFile(assetPath, []byte("#!/bin/sh\necho \"$2\"\nexit 1\n"), 0755))
+
+	checker := conjureplugin.NewConfiguredAssetBackCompatChecker(assetPath, "checker", []byte(`{"strict":true}`))
+	assert.Equal(t, "checker", checker.Name())
+	breaks, err := checker.CheckBackCompat("project-1", conjureplugin.ConjureProjectParam{}, dir, []byte(testIRJSON), []byte(testIRJSON))
+	require.NoError(t, err)
+	assert.Contains(t, breaks, `"config":{"strict":true}`)
+
+	checker = conjureplugin.NewAssetBackCompatChecker(assetPath)
+	assert.Equal(t, "backcompat-asset", checker.Name())
+	breaks, err = checker.CheckBackCompat("project-1", conjureplugin.ConjureProjectParam{}, dir, []byte(testIRJSON), []byte(testIRJSON))
+	require.NoError(t, err)
+	assert.NotContains(t, breaks, `"config"`)
+}
+
+func TestBuiltinBackCompatChecker(t *testing.T) {
+	const baseIR = `{"version":1,"errors":[{"errorName":{"name":"NotFound","package":"com.palantir.test"},"namespace":"Test","code":"NOT_FOUND","safeArgs":[],"unsafeArgs":[]}],"types":[
+{"type":"object","object":{"typeName":{"name":"Object","package":"com.palantir.test"},"fields":[{"fieldName":"name","type":{"type":"primitive","primitive":"STRING"}},{"fieldName":"size","type":{"type":"primitive","primitive":"INTEGER"}}]}},
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"},{"value":"B"}]}},
+{"type":"union","union":{"typeName":{"name":"Union","package":"com.palantir.test"},"union":[{"fieldName":"str","type":{"type":"primitive","primitive":"STRING"}},{"fieldName":"num","type":{"type":"primitive","primitive":"INTEGER"}}]}},
+{"type":"alias","alias":{"typeName":{"name":"Alias","package":"com.palantir.test"},"alias":{"type":"primitive","primitive":"STRING"}}},
+{"type":"alias","alias":{"typeName":{"name":"Removed","package":"com.palantir.test"},"alias":{"type":"primitive","primitive":"STRING"}}}
+],"services":[{"serviceName":{"name":"Service","package":"com.palantir.test"},"endpoints":[
+{"endpointName":"get","httpMethod":"GET","httpPath":"/get","args":[{"argName":"id","type":{"type":"primitive","primitive":"STRING"},"paramType":{"type":"query","query":{"paramId":"id"}}}],"returns":{"type":"primitive","primitive":"STRING"}},
+{"endpointName":"delete","httpMethod":"DELETE","httpPath":"/delete","args":[]}
+]}],"extensions":{}}`
+	const currentIR = `{"version":1,"errors":[],"types":[
+{"type":"object","object":{"typeName":{"name":"Object","package":"com.palantir.test"},"fields":[{"fieldName":"name","type":{"type":"primitive","primitive":"INTEGER"}},{"fieldName":"added","type":{"type":"primitive","primitive":"STRING"}},{"fieldName":"optional","type":{"type":"optional","optional":{"itemType":{"type":"primitive","primitive":"STRING"}}}}]}},
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"},{"value":"C"}]}},
+{"type":"union","union":{"typeName":{"name":"Union","package":"com.palantir.test"},"union":[{"fieldName":"str","type":{"type":"primitive","primitive":"STRING"}},{"fieldName":"other","type":{"type":"primitive","primitive":"INTEGER"}}]}},
+{"type":"object","object":{"typeName":{"name":"Alias","package":"com.palantir.test"},"fields":[]}}
+],"services":[{"serviceName":{"name":"Service","package":"com.palantir.test"},"endpoints":[
+{"endpointName":"get","httpMethod":"POST","httpPath":"/get","args":[{"argName":"id","type":{"type":"primitive","primitive":"INTEGER"},"paramType":{"type":"query","query":{"paramId":"id"}}},{"argName":"token","type":{"type":"primitive","primitive":"STRING"},"paramType":{"type":"query","query":{"paramId":"token"}}}]}
+]}],"extensions":{}}`
+
+	checker := conjureplugin.NewBuiltinBackCompatChecker()
+	assert.Equal(t, "builtin", checker.Name())
+
+	breaks, err := checker.CheckBackCompat("project-1", conjureplugin.ConjureProjectParam{}, ".", []byte(baseIR), []byte(baseIR))
+	require.NoError(t, err)
+	assert.Empty(t, breaks)
+
+	// documentation and additions that are not required are compatible
+	breaks, err = checker.CheckBackCompat("project-1", conjureplugin.ConjureProjectParam{}, ".", []byte(testIRJSON), []byte(strings.Replace(testIRJSON, `"fieldName" : "name",`, `"fieldName" : "name", "docs" : "The name.",`, 1)))
+	require.NoError(t, err)
+	assert.Empty(t, breaks)
+
+	breaks, err = checker.CheckBackCompat("project-1", conjureplugin.ConjureProjectParam{}, ".", []byte(baseIR), []byte(currentIR))
+	require.NoError(t, err)
+	assert.Equal(t, `endpoint com.palantir.test.Service.delete was removed
+endpoint com.palantir.test.Service.get added required argument token
+endpoint com.palantir.test.Service.get changed from GET /get to POST /get
+endpoint com.palantir.test.Service.get changed return type from STRING to none
+endpoint com.palantir.test.Service.get changed type of argument id from STRING to INTEGER
+enum com.palantir.test.Enum removed value B
+error com.palantir.test.NotFound was removed
+object com.palantir.test.Object added required field added
+object com.palantir.test.Object changed type of field name from STRING to INTEGER
+object com.palantir.test.Object removed field size
+type com.palantir.test.Alias changed from alias to object
+type com.palantir.test.Removed was removed
+union com.palantir.test.Union removed variant num`, breaks)
+
+	_, err = checker.CheckBackCompat("project-1", conjureplugin.ConjureProjectParam{}, ".", []byte("not IR"), []byte(baseIR))
+	assert.Error(t, err)
+}
+
+func TestBackCompatPublishedBaseline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/artifactory/releases/com/palantir/test/changed/maven-metadata.xml":
+			_, _ = w.Write([]byte(`<metadata><versioning><release>1.1.0</release><versions><version>1.0.0</version><version>1.1.0</version></versions></versioning></metadata>`))
+		case "/artifactory/releases/com/palantir/test/unchanged/maven-metadata.xml":
+			_, _ = w.Write([]byte(`<metadata><versioning><versions><version>2.0.0</version></versions></versioning></metadata>`))
+		case "/artifactory/releases/com/palantir/test/changed/1.1.0/changed-1.1.0.conjure.json",
+			"/artifactory/releases/com/palantir/test/unchanged/2.0.0/unchanged-2.0.0.conjure.json":
+			_, _ = w.Write([]byte(testIRJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "changed.json"), []byte(testIRJSON+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unchanged.json"), []byte(testIRJSON), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"changed", "unchanged", "unpublished"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"changed": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "changed.json")),
+			},
+			"unpublished": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "unchanged.json")),
+			},
+			"unchanged": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "unchanged.json")),
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	err := conjureplugin.BackCompat(params, dir, "", []conjureplugin.BackCompatChecker{irEqualityChecker{}}, buf, conjureplugin.PublishedBaselineParam(conjureplugin.PublishedBaseline{
+		URL:        server.URL,
+		Repository: "releases",
+		GroupID:    "com.palantir.test",
+		Username:   "user",
+		Password:   "pass",
+	}))
+	require.EqualError(t, err, "conjure backcompat failed")
+	assert.Equal(t, `Using published version 1.1.0 of changed as the baseline
+Using published version 2.0.0 of unchanged as the baseline
+Skipping unpublished: no release has been published to releases
+Conjure definitions are not backwards compatible with the latest releases in releases: [changed]
+  changed:
+    ir-equality:
+      [485649b9fe] IR differs from baseline
+`, buf.String())
+}
+
+func TestBackCompatPinnedBaseline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifactory/releases/com/palantir/test/maven-api/1.0.0/maven-api-1.0.0.conjure.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testIRJSON))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "baseline.json"), []byte(testIRJSON), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.json"), []byte(testIRJSON+"\n"), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"local", "maven"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"local": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "baseline.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "baseline.json"},
+			},
+			"maven": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "current.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Maven: "com.palantir.test:maven-api:1.0.0"},
+			},
+		},
+	}
+	checkers := []conjureplugin.BackCompatChecker{irEqualityChecker{}}
+	repoParam := conjureplugin.BaselineRepositoryParam(conjureplugin.PublishedBaseline{
+		URL:        server.URL,
+		Repository: "releases",
+	})
+
+	// a base ref is not required if every project has a pinned baseline
+	buf := &bytes.Buffer{}
+	err := conjureplugin.BackCompat(params, dir, "", checkers, buf, repoParam)
+	require.EqualError(t, err, "conjure backcompat failed")
+	assert.Equal(t, `Using pinned baseline baseline.json of local
+Using pinned baseline com.palantir.test:maven-api:1.0.0 of maven
+Conjure definitions are not backwards compatible with their pinned baselines: [maven]
+  maven (pinned baseline com.palantir.test:maven-api:1.0.0):
+    ir-equality:
+      [d812f07235] IR differs from baseline
+`, buf.String())
+
+	err = conjureplugin.BackCompat(params, dir, "", checkers, &bytes.Buffer{})
+	require.EqualError(t, err, "failed to determine baseline IR of maven from pinned baseline com.palantir.test:maven-api:1.0.0: a repository must be specified to resolve Maven coordinate com.palantir.test:maven-api:1.0.0")
+
+	params.SortedKeys = append(params.SortedKeys, "unpinned")
+	params.Params["unpinned"] = conjureplugin.ConjureProjectParam{
+		IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "current.json")),
+	}
+	err = conjureplugin.BackCompat(params, dir, "", checkers, &bytes.Buffer{}, repoParam)
+	require.EqualError(t, err, "unpinned does not have a pinned baseline, so a baseline Git ref must be specified")
+}
+
+func TestBackCompatReport(t *testing.T) {
+	const baseIR = `{"version":1,"errors":[],"types":[
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"},{"value":"B"}]}},
+{"type":"alias","alias":{"typeName":{"name":"Alias","package":"com.palantir.test"},"alias":{"type":"primitive","primitive":"STRING"}}}
+],"services":[],"extensions":{}}`
+	const currentIR = `{"version":1,"errors":[],"types":[
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"}]}}
+],"services":[],"extensions":{}}`
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.json"), []byte(baseIR), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.json"), []byte(currentIR), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"broken", "compatible"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"broken": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "current.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "base.json"},
+				Frozen:             true,
+			},
+			"compatible": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "base.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "base.json"},
+			},
+		},
+	}
+	checkers := []conjureplugin.BackCompatChecker{conjureplugin.NewBuiltinBackCompatChecker(), irEqualityChecker{}}
+	report := &conjureplugin.BackCompatReport{}
+	err := conjureplugin.BackCompat(params, dir, "", checkers, &bytes.Buffer{}, conjureplugin.BackCompatReportParam(report))
+	require.EqualError(t, err, "conjure backcompat failed")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, report.WriteJSON(buf))
+	assert.Equal(t, `{
+  "projects": [
+    {
+      "project": "broken",
+      "status": "failed",
+      "baseline": "base.json",
+      "breaks": [
+        {
+          "id": "763875f273",
+          "checker": "frozen",
+          "category": "frozen",
+          "description": "project is frozen, but its definitions have changes other than to documentation",
+          "severity": "error"
+        },
+        {
+          "id": "6153066d8c",
+          "checker": "builtin",
+          "category": "removed-enum-value",
+          "description": "enum com.palantir.test.Enum removed value B",
+          "severity": "error"
+        },
+        {
+          "id": "6fe78271a2",
+          "checker": "builtin",
+          "category": "removed-type",
+          "description": "type com.palantir.test.Alias was removed",
+          "severity": "error"
+        },
+        {
+          "id": "7bd945e862",
+          "checker": "ir-equality",
+          "category": "ir-equality",
+          "description": "IR differs from baseline",
+          "severity": "error"
+        }
+      ]
+    },
+    {
+      "project": "compatible",
+      "status": "succeeded",
+      "baseline": "base.json",
+      "breaks": []
+    }
+  ]
+}
+`, buf.String())
+}
+
+func TestBackCompatPolicy(t *testing.T) {
+	const baseIR = `{"version":1,"errors":[],"types":[
+{"type":"object","object":{"typeName":{"name":"Object","package":"com.palantir.test"},"fields":[]}},
+{"type":"alias","alias":{"typeName":{"name":"Alias","package":"com.palantir.test"},"alias":{"type":"primitive","primitive":"STRING"}}}
+],"services":[],"extensions":{}}`
+	const currentIR = `{"version":1,"errors":[],"types":[
+{"type":"object","object":{"typeName":{"name":"Object","package":"com.palantir.test"},"fields":[{"fieldName":"name","type":{"type":"primitive","primitive":"STRING"}}]}}
+],"services":[],"extensions":{}}`
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.json"), []byte(baseIR), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.json"), []byte(currentIR), 0644))
+	newParams := func(policy conjureplugin.BackCompatPolicy) conjureplugin.ConjureProjectParams {
+		return conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "current.json")),
+					BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "base.json"},
+					BackCompatPolicy:   policy,
+				},
+			},
+		}
+	}
+	checkers := []conjureplugin.BackCompatChecker{conjureplugin.NewBuiltinBackCompatChecker()}
+
+	// breaks whose severity is a warning are printed but do not fail the check
+	buf := &bytes.Buffer{}
+	report := &conjureplugin.BackCompatReport{}
+	err := conjureplugin.BackCompat(newParams(conjureplugin.BackCompatPolicy{
+		Severities: map[string]conjureplugin.BackCompatSeverity{
+			"added-required-field": conjureplugin.BackCompatSeverityWarning,
+		},
+	}), dir, "", checkers, buf, conjureplugin.BackCompatReportParam(report))
+	require.EqualError(t, err, "conjure backcompat failed")
+	assert.Equal(t, `Using pinned baseline base.json of project-1
+Warning: project-1 has breaks whose severity is warning:
+  builtin:
+    [6c067e5add] object com.palantir.test.Object added required field name
+Conjure definitions are not backwards compatible with their pinned baselines: [project-1]
+  project-1 (pinned baseline base.json):
+    builtin:
+      [0bad6aa554] type com.palantir.test.Alias was removed
+`, buf.String())
+	require.Len(t, report.Projects, 1)
+	assert.Equal(t, []conjureplugin.BackCompatBreak{
+		{
+			ID:          "6c067e5add",
+			Checker:     "builtin",
+			Category:    "added-required-field",
+			Description: "object com.palantir.test.Object added required field name",
+			Severity:    conjureplugin.BackCompatSeverityWarning,
+		},
+		{
+			ID:          "0bad6aa554",
+			Checker:     "builtin",
+			Category:    "removed-type",
+			Description: "type com.palantir.test.Alias was removed",
+			Severity:    conjureplugin.BackCompatSeverityError,
+		},
+	}, report.Projects[0].Breaks)
+
+	warningPolicy := conjureplugin.BackCompatPolicy{
+		Severities: map[string]conjureplugin.BackCompatSeverity{
+			"added-required-field": conjureplugin.BackCompatSeverityWarning,
+			"removed-type":         conjureplugin.BackCompatSeverityWarning,
+		},
+	}
+	require.NoError(t, conjureplugin.BackCompat(newParams(warningPolicy), dir, "", checkers, &bytes.Buffer{}))
+
+	warningPolicy.FailOnWarnings = true
+	err = conjureplugin.BackCompat(newParams(warningPolicy), dir, "", checkers, &bytes.Buffer{})
+	require.EqualError(t, err, "conjure backcompat failed")
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// BackCompatLockFile is the path of the lockfile, relative to the project directory, that records the backwards
+// incompatibilities that have been accepted. The lockfile is intended to be checked in.
+const BackCompatLockFile = ".palantir/conjure-backcompat.lock"
+
+const (
+	backCompatLockVersion = "1"
+	backCompatLockHeader  = "# Breaks accepted by conjure-accept-backcompat-breaks. Do not edit this file manually.\n"
+)
+
+// BackCompatLock records the breaks that have been accepted for every project.
+type BackCompatLock struct {
+	Version string `yaml:"version"`
+	// Projects are the accepted breaks of every project keyed by project name.
+	Projects map[string][]AcceptedBackCompatBreak `yaml:"projects,omitempty"`
+}
+
+// AcceptedBackCompatBreak is a break that has been accepted. A break is accepted only if it is found by the same
+// checker with the same description when checking against the same version of the baseline, so accepted breaks do not
+// apply once the baseline advances past them.
+type AcceptedBackCompatBreak struct {
+	// Baseline is the version of the baseline against which the break was accepted, which is the Git ref, the published
+	// version or the pinned baseline.
+	Baseline    string `yaml:"baseline"`
+	Checker     string `yaml:"checker"`
+	Description string `yaml:"description"`
+}
+
+// ReadBackCompatLock reads the lockfile at the provided path. Returns an empty lockfile if the file does not exist.
+func ReadBackCompatLock(path string) (BackCompatLock, error) {
+	lockBytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return BackCompatLock{Version: backCompatLockVersion}, nil
+	}
+	if err != nil {
+		return BackCompatLock{}, errors.Wrapf(err, "failed to read %s", path)
+	}
+	var lock BackCompatLock
+	if err := yaml.UnmarshalStrict(lockBytes, &lock); err != nil {
+		return BackCompatLock{}, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	if lock.Version != backCompatLockVersion {
+		return BackCompatLock{}, errors.Errorf("unsupported version %q of %s (must be %q)", lock.Version, path, backCompatLockVersion)
+	}
+	return lock, nil
+}
+
+// WriteBackCompatLock writes the provided lockfile to the provided path (creating its directory if necessary). The
+// accepted breaks of every project are sorted so that the content does not depend on the order in which they were
+// accepted.
+func WriteBackCompatLock(path string, lock BackCompatLock) error {
+	lock.Version = backCompatLockVersion
+	for projectName, breaks := range lock.Projects {
+		if len(breaks) == 0 {
+			delete(lock.Projects, projectName)
+			continue
+		}
+		sort.Slice(breaks, func(i, j int) bool {
+			if breaks[i].Baseline != breaks[j].Baseline {
+				return breaks[i].Baseline < breaks[j].Baseline
+			}
+			if breaks[i].Checker != breaks[j].Checker {
+				return breaks[i].Checker < breaks[j].Checker
+			}
+			return breaks[i].Description < breaks[j].Description
+		})
+	}
+	lockBytes, err := yaml.Marshal(lock)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", path)
+	}
+	if err := os.WriteFile(path, append([]byte(backCompatLockHeader), lockBytes...), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
+// acceptedBreaks returns the descriptions of the breaks of the provided project found by the provided checker that
+// have been accepted against the provided version of the baseline.
+func (l BackCompatLock) acceptedBreaks(projectName, baseline, checker string) []string {
+	var accepted []string
+	for _, acceptedBreak := range l.Projects[projectName] {
+		if acceptedBreak.Baseline == baseline && acceptedBreak.Checker == checker {
+			accepted = append(accepted, acceptedBreak.Description)
+		}
+	}
+	return accepted
+}
+
+// AcceptBreakIDsParam returns a parameter that limits AcceptBackCompatBreaks to accepting the breaks with the provided
+// IDs.
+func AcceptBreakIDsParam(ids ...string) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.acceptBreakIDs = append(a.acceptBreakIDs, ids...)
+	})
+}
+
+// AcceptBackCompatBreaks checks the backwards compatibility of every project like BackCompat and records the breaks
+// that are found in the lockfile in projectDir, so that subsequent checks against the same baseline succeed. The
+// accepted breaks of every project that is checked are replaced by the breaks that are found, so breaks that are no
+// longer found are removed from the lockfile. The accepted breaks of projects that are skipped are not changed. If
+// AcceptBreakIDsParam is provided, only the breaks with the provided IDs are added to the lockfile and no accepted
+// breaks are removed. Returns an error if any of the IDs does not identify a break that has not been accepted.
+func AcceptBackCompatBreaks(params ConjureProjectParams, projectDir, baseRef string, checkers []BackCompatChecker, stdout io.Writer, opParams ...OperationParam) error {
+	opArgs := newOperationArgs(opParams)
+	report := &BackCompatReport{}
+	// the output of the check is discarded because the breaks that it reports are accepted
+	err := BackCompat(params, projectDir, baseRef, checkers, ioutil.Discard, append(opParams, BackCompatReportParam(report))...)
+	if err != nil && !errors.Is(err, ErrBackCompatFailed) {
+		return err
+	}
+
+	// selectedIDs are the IDs of the breaks to accept, which is nil if all breaks are accepted
+	var selectedIDs map[string]struct{}
+	if len(opArgs.acceptBreakIDs) > 0 {
+		selectedIDs = make(map[string]struct{})
+		for _, id := range opArgs.acceptBreakIDs {
+			selectedIDs[id] = struct{}{}
+		}
+		found := make(map[string]struct{})
+		for _, project := range report.Projects {
+			for _, projectBreak := range project.Breaks {
+				found[projectBreak.ID] = struct{}{}
+			}
+		}
+		var unknown []string
+		for id := range selectedIDs {
+			if _, ok := found[id]; !ok {
+				unknown = append(unknown, id)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return errors.Errorf("no unaccepted break has ID %s", strings.Join(unknown, ", "))
+		}
+	}
+
+	lockPath := filepath.Join(projectDir, BackCompatLockFile)
+	lock, err := ReadBackCompatLock(lockPath)
+	if err != nil {
+		return err
+	}
+	if lock.Projects == nil {
+		lock.Projects = make(map[string][]AcceptedBackCompatBreak)
+	}
+	accepted := 0
+	for _, project := range report.Projects {
+		if project.Status == ProjectStatusSkipped {
+			continue
+		}
+		toAccepted := func(projectBreak BackCompatBreak) AcceptedBackCompatBreak {
+			return AcceptedBackCompatBreak{
+				Baseline:    project.Baseline,
+				Checker:     projectBreak.Checker,
+				Description: projectBreak.Description,
+			}
+		}
+		var newBreaks []BackCompatBreak
+		if selectedIDs != nil {
+			for _, projectBreak := range project.Breaks {
+				if _, ok := selectedIDs[projectBreak.ID]; ok {
+					newBreaks = append(newBreaks, projectBreak)
+					lock.Projects[project.Project] = append(lock.Projects[project.Project], toAccepted(projectBreak))
+				}
+			}
+		} else {
+			newBreaks = project.Breaks
+			var breaks []AcceptedBackCompatBreak
+			for _, projectBreak := range append(project.AcceptedBreaks, project.Breaks...) {
+				breaks = append(breaks, toAccepted(projectBreak))
+			}
+			lock.Projects[project.Project] = breaks
+		}
+		for _, projectBreak := range newBreaks {
+			_, _ = fmt.Fprintf(stdout, "Accepted break of %s against %s: %s\n", project.Project, project.Baseline, projectBreak)
+		}
+		accepted += len(newBreaks)
+	}
+	if accepted == 0 {
+		_, _ = fmt.Fprintln(stdout, "No breaks to accept")
+	}
+	return WriteBackCompatLock(lockPath, lock)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptBackCompatBreaks(t *testing.T) {
+	const baseIR = `{"version":1,"errors":[],"types":[
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"},{"value":"B"}]}},
+{"type":"alias","alias":{"typeName":{"name":"Alias","package":"com.palantir.test"},"alias":{"type":"primitive","primitive":"STRING"}}}
+],"services":[],"extensions":{}}`
+	const currentIR = `{"version":1,"errors":[],"types":[
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"}]}}
+],"services":[],"extensions":{}}`
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.json"), []byte(baseIR), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.json"), []byte(currentIR), 0644))
+	// asset that reports a break for the "broken" project unless it is provided accepted breaks
+	assetPath := filepath.Join(dir, "backcompat-asset")
+	require.NoError(t, os.WriteFile(assetPath, []byte("#!/bin/sh\ncase \"$2\" in *acceptedBreaks*|*compatible*) exit 0;; esac\necho \"asset break\"\nexit 1\n"), 0755))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"broken", "compatible"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"broken": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "current.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "base.json"},
+			},
+			"compatible": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "base.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "base.json"},
+			},
+		},
+	}
+	checkers := []conjureplugin.BackCompatChecker{conjureplugin.NewBuiltinBackCompatChecker(), conjureplugin.NewAssetBackCompatChecker(assetPath)}
+
+	err := conjureplugin.BackCompat(params, dir, "", checkers, &bytes.Buffer{})
+	require.ErrorIs(t, err, conjureplugin.ErrBackCompatFailed)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.AcceptBackCompatBreaks(params, dir, "", checkers, buf))
+	assert.Equal(t, `Accepted break of broken against base.json: [6153066d8c] enum com.palantir.test.Enum removed value B
+Accepted break of broken against base.json: [6fe78271a2] type com.palantir.test.Alias was removed
+Accepted break of broken against base.json: [21317eaea8] asset break
+`, buf.String())
+	lockBytes, err := os.ReadFile(filepath.Join(dir, conjureplugin.BackCompatLockFile))
+	require.NoError(t, err)
+	assert.Equal(t, `# Breaks accepted by conjure-accept-backcompat-breaks. Do not edit this file manually.
+version: "1"
+projects:
+  broken:
+  - baseline: base.json
+    checker: backcompat-asset
+    description: asset break
+  - baseline: base.json
+    checker: builtin
+    description: enum com.palantir.test.Enum removed value B
+  - baseline: base.json
+    checker: builtin
+    description: type com.palantir.test.Alias was removed
+`, string(lockBytes))
+
+	// the asset is provided the breaks accepted for it, so only the breaks of the built-in checker are ignored
+	buf = &bytes.Buffer{}
+	report := &conjureplugin.BackCompatReport{}
+	require.NoError(t, conjureplugin.BackCompat(params, dir, "", checkers, buf, conjureplugin.BackCompatReportParam(report)))
+	assert.Equal(t, `Using pinned baseline base.json of broken
+Ignoring 2 breaks of broken accepted in .palantir/conjure-backcompat.lock
+Using pinned baseline base.json of compatible
+`, buf.String())
+	require.Len(t, report.Projects, 2)
+	assert.Equal(t, conjureplugin.ProjectStatusSucceeded, report.Projects[0].Status)
+	assert.Empty(t, report.Projects[0].Breaks)
+	assert.Len(t, report.Projects[0].AcceptedBreaks, 2)
+
+	buf = &bytes.Buffer{}
+	require.NoError(t, conjureplugin.AcceptBackCompatBreaks(params, dir, "", checkers, buf))
+	assert.Equal(t, "No breaks to accept\n", buf.String())
+
+	// breaks are accepted only against the baseline against which they were accepted
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base-2.json"), []byte(baseIR), 0644))
+	broken := params.Params["broken"]
+	broken.BackCompatBaseline = &conjureplugin.BackCompatBaseline{Path: "base-2.json"}
+	params.Params["broken"] = broken
+	err = conjureplugin.BackCompat(params, dir, "", checkers, &bytes.Buffer{})
+	require.ErrorIs(t, err, conjureplugin.ErrBackCompatFailed)
+}
+
+func TestAcceptBackCompatBreaksByID(t *testing.T) {
+	const baseIR = `{"version":1,"errors":[],"types":[
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"},{"value":"B"}]}},
+{"type":"alias","alias":{"typeName":{"name":"Alias","package":"com.palantir.test"},"alias":{"type":"primitive","primitive":"STRING"}}}
+],"services":[],"extensions":{}}`
+	const currentIR = `{"version":1,"errors":[],"types":[
+{"type":"enum","enum":{"typeName":{"name":"Enum","package":"com.palantir.test"},"values":[{"value":"A"}]}}
+],"services":[],"extensions":{}}`
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "base.json"), []byte(baseIR), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "current.json"), []byte(currentIR), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"broken"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"broken": {
+				IRProvider:         conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "current.json")),
+				BackCompatBaseline: &conjureplugin.BackCompatBaseline{Path: "base.json"},
+			},
+		},
+	}
+	checkers := []conjureplugin.BackCompatChecker{conjureplugin.NewBuiltinBackCompatChecker()}
+
+	err := conjureplugin.AcceptBackCompatBreaks(params, dir, "", checkers, &bytes.Buffer{}, conjureplugin.AcceptBreakIDsParam("6fe78271a2", "0000000000"))
+	require.EqualError(t, err, "no unaccepted break has ID 0000000000")
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.AcceptBackCompatBreaks(params, dir, "", checkers, buf, conjureplugin.AcceptBreakIDsParam("6fe78271a2")))
+	assert.Equal(t, "Accepted break of broken against base.json: [6fe78271a2] type com.palantir.test.Alias was removed\n", buf.String())
+
+	// the break that was not accepted still fails the check
+	buf = &bytes.Buffer{}
+	err = conjureplugin.BackCompat(params, dir, "", checkers, buf)
+	require.ErrorIs(t, err, conjureplugin.ErrBackCompatFailed)
+	assert.Equal(t, `Using pinned baseline base.json of broken
+Ignoring 1 breaks of broken accepted in .palantir/conjure-backcompat.lock
+Conjure definitions are not backwards compatible with their pinned baselines: [broken]
+  broken (pinned baseline base.json):
+    builtin:
+      [6153066d8c] enum com.palantir.test.Enum removed value B
+`, buf.String())
+
+	// accepting a break by ID does not remove other accepted breaks
+	require.NoError(t, conjureplugin.AcceptBackCompatBreaks(params, dir, "", checkers, &bytes.Buffer{}, conjureplugin.AcceptBreakIDsParam("6153066d8c")))
+	require.NoError(t, conjureplugin.BackCompat(params, dir, "", checkers, &bytes.Buffer{}))
+}
+
+func TestReadBackCompatLock(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := conjureplugin.ReadBackCompatLock(filepath.Join(dir, "missing.lock"))
+	require.NoError(t, err)
+	assert.Empty(t, lock.Projects)
+
+	lockPath := filepath.Join(dir, "conjure-backcompat.lock")
+	require.NoError(t, os.WriteFile(lockPath, []byte("version: \"2\"\n"), 0644))
+	_, err = conjureplugin.ReadBackCompatLock(lockPath)
+	assert.EqualError(t, err, `unsupported version "2" of `+lockPath+` (must be "1")`)
+}
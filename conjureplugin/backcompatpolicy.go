@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BackCompatSeverity is the severity of a backwards incompatibility.
+type BackCompatSeverity string
+
+const (
+	// BackCompatSeverityError is the severity of breaks that fail the backcompat check.
+	BackCompatSeverityError = BackCompatSeverity("error")
+	// BackCompatSeverityWarning is the severity of breaks that are printed as warnings and only fail the backcompat
+	// check if the policy fails on warnings.
+	BackCompatSeverityWarning = BackCompatSeverity("warning")
+)
+
+// ParseBackCompatSeverity returns the severity with the provided name.
+func ParseBackCompatSeverity(val string) (BackCompatSeverity, error) {
+	switch severity := BackCompatSeverity(val); severity {
+	case BackCompatSeverityError, BackCompatSeverityWarning:
+		return severity, nil
+	default:
+		return "", errors.Errorf("severity must be %q or %q, was %q", BackCompatSeverityError, BackCompatSeverityWarning, val)
+	}
+}
+
+// BackCompatPolicy determines which backwards incompatibilities of a project fail the backcompat check.
+type BackCompatPolicy struct {
+	// Severities are the severities of the breaks in each category keyed by category. Breaks in categories that are
+	// not specified are errors.
+	Severities map[string]BackCompatSeverity
+	// FailOnWarnings specifies whether breaks whose severity is a warning fail the check.
+	FailOnWarnings bool
+}
+
+// Severity returns the severity of the breaks in the provided category.
+func (p BackCompatPolicy) Severity(category string) BackCompatSeverity {
+	if severity, ok := p.Severities[category]; ok {
+		return severity
+	}
+	return BackCompatSeverityError
+}
+
+func (p BackCompatPolicy) String() string {
+	var categories []string
+	for category := range p.Severities {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	var parts []string
+	for _, category := range categories {
+		parts = append(parts, fmt.Sprintf("%s=%s", category, p.Severities[category]))
+	}
+	return fmt.Sprintf("{severities: [%s], fail-on-warnings: %t}", strings.Join(parts, ", "), p.FailOnWarnings)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BackCompatReportParam returns a parameter that configures BackCompat to record the result of the check for every
+// project in the provided report in addition to printing it as text.
+func BackCompatReportParam(report *BackCompatReport) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.backCompatReport = report
+	})
+}
+
+// BackCompatReport records the result of BackCompat for every project.
+type BackCompatReport struct {
+	// Baseline describes the baseline of the projects that do not have a pinned baseline. Empty if every project has a
+	// pinned baseline.
+	Baseline string                    `json:"baseline,omitempty"`
+	Projects []BackCompatProjectReport `json:"projects"`
+}
+
+// BackCompatProjectReport records the result of BackCompat for a single project.
+type BackCompatProjectReport struct {
+	Project string        `json:"project"`
+	Status  ProjectStatus `json:"status"`
+	// Baseline is the version of the baseline against which the project was checked (the Git ref, the published
+	// version or the pinned baseline), or the description of its baseline if it was skipped.
+	Baseline string `json:"baseline,omitempty"`
+	// Message describes why the project was skipped.
+	Message string `json:"message,omitempty"`
+	// Breaks are the incompatibilities found in the order in which they were found, excluding the ones that have been
+	// accepted.
+	Breaks []BackCompatBreak `json:"breaks"`
+	// AcceptedBreaks are the incompatibilities found that have been accepted in the lockfile.
+	AcceptedBreaks []BackCompatBreak `json:"acceptedBreaks,omitempty"`
+}
+
+// BackCompatBreak is an incompatibility found by a checker.
+type BackCompatBreak struct {
+	// ID identifies the break so that it can be accepted individually. It is derived from the project, the checker
+	// and the description, so it is stable across checks.
+	ID string `json:"id"`
+	// Checker is the name of the checker that found the incompatibility ("frozen" for changes to frozen projects).
+	Checker string `json:"checker"`
+	// Category is the category of the incompatibility, which determines its severity. The categories of the breaks
+	// found by the built-in checker describe the kind of change (such as "removed-endpoint"), while the category of
+	// other breaks is the name of the checker.
+	Category    string             `json:"category"`
+	Description string             `json:"description"`
+	Severity    BackCompatSeverity `json:"severity"`
+}
+
+func (b BackCompatBreak) String() string {
+	return fmt.Sprintf("[%s] %s", b.ID, b.Description)
+}
+
+// backCompatBreakID returns the ID of the break of the provided project found by the provided checker with the provided
+// description.
+func backCompatBreakID(projectName, checker, description string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{projectName, checker, description}, "\x00")))
+	return hex.EncodeToString(sum[:])[:backCompatBreakIDLen]
+}
+
+// backCompatBreakIDLen is the number of hexadecimal characters in the ID of a break.
+const backCompatBreakIDLen = 10
+
+// WriteJSON writes the report as indented JSON to the provided writer.
+func (r *BackCompatReport) WriteJSON(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+	out := *r
+	if out.Projects == nil {
+		out.Projects = []BackCompatProjectReport{}
+	}
+	for i := range out.Projects {
+		if out.Projects[i].Breaks == nil {
+			out.Projects[i].Breaks = []BackCompatBreak{}
+		}
+	}
+	jsonBytes, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal backcompat report")
+	}
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return errors.WithStack(err)
+}
+
+// addProject records the result of the provided project. Does nothing if the report is nil.
+func (r *BackCompatReport) addProject(project BackCompatProjectReport) {
+	if r == nil {
+		return
+	}
+	r.Projects = append(r.Projects, project)
+}
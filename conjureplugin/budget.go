@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// SizeBudget specifies limits on the size of the code generated for a project. A value of 0 for any of the limits
+// indicates that the limit is not enforced.
+type SizeBudget struct {
+	// MaxFiles is the maximum number of files that may be generated.
+	MaxFiles int
+	// MaxTotalBytes is the maximum combined size of all of the generated files.
+	MaxTotalBytes int64
+	// MaxFileBytes is the maximum size of any single generated file.
+	MaxFileBytes int64
+	// WarnOnly specifies that budget violations should be printed as warnings rather than failing the operation.
+	WarnOnly bool
+}
+
+// violations returns a description of every way in which the provided files exceed the budget. Paths are reported
+// relative to projectDir.
+func (b SizeBudget) violations(files []renderedFile, projectDir string) []string {
+	var out []string
+	if b.MaxFiles > 0 && len(files) > b.MaxFiles {
+		out = append(out, fmt.Sprintf("generated %d files, which exceeds the maximum of %d", len(files), b.MaxFiles))
+	}
+	var totalBytes int64
+	for _, file := range files {
+		fileBytes := int64(len(file.content))
+		totalBytes += fileBytes
+		if b.MaxFileBytes > 0 && fileBytes > b.MaxFileBytes {
+			displayPath := file.absPath
+			if relPath, err := filepath.Rel(projectDir, file.absPath); err == nil {
+				displayPath = relPath
+			}
+			out = append(out, fmt.Sprintf("%s is %d bytes, which exceeds the maximum of %d", displayPath, fileBytes, b.MaxFileBytes))
+		}
+	}
+	if b.MaxTotalBytes > 0 && totalBytes > b.MaxTotalBytes {
+		out = append(out, fmt.Sprintf("generated %d bytes in total, which exceeds the maximum of %d", totalBytes, b.MaxTotalBytes))
+	}
+	return out
+}
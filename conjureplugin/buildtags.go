@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"go/build/constraint"
+
+	"github.com/pkg/errors"
+)
+
+// buildConstraint returns the build constraint expression that requires all of the provided build tag expressions to
+// be satisfied. Returns nil if no expressions are provided.
+func buildConstraint(tags []string) (constraint.Expr, error) {
+	var expr constraint.Expr
+	for _, tag := range tags {
+		tagExpr, err := constraint.Parse("//go:build " + tag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid build tag expression %q", tag)
+		}
+		if expr == nil {
+			expr = tagExpr
+			continue
+		}
+		expr = &constraint.AndExpr{X: expr, Y: tagExpr}
+	}
+	return expr, nil
+}
+
+// addBuildConstraint returns the provided files with a "//go:build" line for the provided build tag expressions
+// prepended to every generated Go file. Other files are returned unmodified.
+func addBuildConstraint(files []renderedFile, tags []string) ([]renderedFile, error) {
+	expr, err := buildConstraint(tags)
+	if err != nil || expr == nil {
+		return files, err
+	}
+	header := "//go:build " + expr.String() + "\n\n"
+	out := make([]renderedFile, 0, len(files))
+	for _, file := range files {
+		if isGeneratedGoFile(file.absPath) {
+			file.content = append([]byte(header), file.content...)
+		}
+		out = append(out, file)
+	}
+	return out, nil
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	conjurego "github.com/palantir/conjure-go/v6/conjure"
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+// BuiltinBackCompatCheckerName is the name of the BackCompatChecker returned by NewBuiltinBackCompatChecker.
+const BuiltinBackCompatCheckerName = "builtin"
+
+var _ BackCompatChecker = builtinBackCompatChecker{}
+
+type builtinBackCompatChecker struct{}
+
+// NewBuiltinBackCompatChecker returns a BackCompatChecker that compares the structure of the IR with the baseline IR and
+// reports the following changes as incompatibilities:
+//
+//   - removed services and endpoints, and endpoints whose HTTP method or path changed
+//   - removed endpoint arguments, added required endpoint arguments and arguments or return types whose type changed
+//   - removed types and types whose kind (object, enum, union or alias) changed
+//   - removed object fields, added required object fields and fields whose type changed
+//   - removed enum values
+//   - removed union variants and variants whose type changed
+//   - aliases whose aliased type changed
+//   - removed errors and errors whose code changed
+func NewBuiltinBackCompatChecker() BackCompatChecker {
+	return builtinBackCompatChecker{}
+}
+
+func (builtinBackCompatChecker) Name() string {
+	return BuiltinBackCompatCheckerName
+}
+
+func (c builtinBackCompatChecker) CheckBackCompat(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte) (string, error) {
+	breaks, err := c.checkBackCompatBreaks(projectName, param, projectDir, baseIR, currentIR)
+	if err != nil {
+		return "", err
+	}
+	var descriptions []string
+	for _, currBreak := range breaks {
+		descriptions = append(descriptions, currBreak.Description)
+	}
+	return strings.Join(descriptions, "\n"), nil
+}
+
+func (c builtinBackCompatChecker) checkBackCompatBreaks(projectName string, param ConjureProjectParam, projectDir string, baseIR, currentIR []byte) ([]BackCompatBreak, error) {
+	baseDef, err := conjurego.FromIRBytes(baseIR)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse baseline IR")
+	}
+	currentDef, err := conjurego.FromIRBytes(currentIR)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse current IR")
+	}
+	breaks := backCompatBreaks(baseDef, currentDef)
+	for i := range breaks {
+		breaks[i].Checker = c.Name()
+	}
+	return breaks, nil
+}
+
+// The categories of the breaks reported by the built-in checker.
+const (
+	breakCategoryRemovedService          = "removed-service"
+	breakCategoryRemovedEndpoint         = "removed-endpoint"
+	breakCategoryChangedEndpointPath     = "changed-endpoint-path"
+	breakCategoryAddedRequiredArgument   = "added-required-argument"
+	breakCategoryRemovedArgument         = "removed-argument"
+	breakCategoryChangedArgumentType     = "changed-argument-type"
+	breakCategoryChangedReturnType       = "changed-return-type"
+	breakCategoryRemovedType             = "removed-type"
+	breakCategoryChangedTypeKind         = "changed-type-kind"
+	breakCategoryChangedAliasType        = "changed-alias-type"
+	breakCategoryRemovedEnumValue        = "removed-enum-value"
+	breakCategoryAddedRequiredField      = "added-required-field"
+	breakCategoryRemovedField            = "removed-field"
+	breakCategoryChangedFieldType        = "changed-field-type"
+	breakCategoryRemovedUnionVariant     = "removed-union-variant"
+	breakCategoryChangedUnionVariantType = "changed-union-variant-type"
+	breakCategoryRemovedError            = "removed-error"
+	breakCategoryChangedErrorCode        = "changed-error-code"
+)
+
+// backCompatBreaks returns the changes from base to current that are not backwards compatible sorted by description.
+// The checker of the returned breaks is not set.
+func backCompatBreaks(base, current spec.ConjureDefinition) []BackCompatBreak {
+	var breaks []BackCompatBreak
+	addBreak := func(category, format string, args ...interface{}) {
+		breaks = append(breaks, BackCompatBreak{
+			Category:    category,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	currentServices := make(map[string]spec.ServiceDefinition)
+	for _, service := range current.Services {
+		currentServices[qualifiedName(service.ServiceName)] = service
+	}
+	for _, baseService := range base.Services {
+		serviceName := qualifiedName(baseService.ServiceName)
+		currentService, ok := currentServices[serviceName]
+		if !ok {
+			addBreak(breakCategoryRemovedService, "service %s was removed", serviceName)
+			continue
+		}
+		currentEndpoints := make(map[string]spec.EndpointDefinition)
+		for _, endpoint := range currentService.Endpoints {
+			currentEndpoints[string(endpoint.EndpointName)] = endpoint
+		}
+		for _, baseEndpoint := range baseService.Endpoints {
+			endpointName := serviceName + "." + string(baseEndpoint.EndpointName)
+			currentEndpoint, ok := currentEndpoints[string(baseEndpoint.EndpointName)]
+			if !ok {
+				addBreak(breakCategoryRemovedEndpoint, "endpoint %s was removed", endpointName)
+				continue
+			}
+			compareEndpoints(endpointName, baseEndpoint, currentEndpoint, addBreak)
+		}
+	}
+
+	currentTypes := make(map[string]spec.TypeDefinition)
+	for _, typeDef := range current.Types {
+		currentTypes[qualifiedName(newTypeDefinitionParts(typeDef).name)] = typeDef
+	}
+	for _, baseType := range base.Types {
+		typeName := qualifiedName(newTypeDefinitionParts(baseType).name)
+		currentType, ok := currentTypes[typeName]
+		if !ok {
+			addBreak(breakCategoryRemovedType, "type %s was removed", typeName)
+			continue
+		}
+		compareTypeDefinitions(typeName, baseType, currentType, addBreak)
+	}
+
+	currentErrors := make(map[string]spec.ErrorDefinition)
+	for _, errorDef := range current.Errors {
+		currentErrors[qualifiedName(errorDef.ErrorName)] = errorDef
+	}
+	for _, baseError := range base.Errors {
+		errorName := qualifiedName(baseError.ErrorName)
+		currentError, ok := currentErrors[errorName]
+		if !ok {
+			addBreak(breakCategoryRemovedError, "error %s was removed", errorName)
+			continue
+		}
+		if baseError.Code != currentError.Code {
+			addBreak(breakCategoryChangedErrorCode, "error %s changed code from %s to %s", errorName, baseError.Code, currentError.Code)
+		}
+	}
+
+	sort.Slice(breaks, func(i, j int) bool {
+		return breaks[i].Description < breaks[j].Description
+	})
+	return breaks
+}
+
+// compareEndpoints reports the incompatible changes from base to current of the endpoint with the provided name.
+func compareEndpoints(endpointName string, base, current spec.EndpointDefinition, addBreak func(string, string, ...interface{})) {
+	if base.HttpMethod != current.HttpMethod || base.HttpPath != current.HttpPath {
+		addBreak(breakCategoryChangedEndpointPath, "endpoint %s changed from %s %s to %s %s", endpointName, base.HttpMethod, base.HttpPath, current.HttpMethod, current.HttpPath)
+	}
+	baseArgs := make(map[string]spec.ArgumentDefinition)
+	for _, arg := range base.Args {
+		baseArgs[string(arg.ArgName)] = arg
+	}
+	currentArgs := make(map[string]spec.ArgumentDefinition)
+	for _, arg := range current.Args {
+		currentArgs[string(arg.ArgName)] = arg
+		if _, ok := baseArgs[string(arg.ArgName)]; !ok && isRequiredType(arg.Type) {
+			addBreak(breakCategoryAddedRequiredArgument, "endpoint %s added required argument %s", endpointName, arg.ArgName)
+		}
+	}
+	for _, baseArg := range base.Args {
+		currentArg, ok := currentArgs[string(baseArg.ArgName)]
+		if !ok {
+			addBreak(breakCategoryRemovedArgument, "endpoint %s removed argument %s", endpointName, baseArg.ArgName)
+			continue
+		}
+		if baseType, currentType := typeString(baseArg.Type), typeString(currentArg.Type); baseType != currentType {
+			addBreak(breakCategoryChangedArgumentType, "endpoint %s changed type of argument %s from %s to %s", endpointName, baseArg.ArgName, baseType, currentType)
+		}
+	}
+	if baseReturns, currentReturns := optionalTypeString(base.Returns), optionalTypeString(current.Returns); baseReturns != currentReturns {
+		addBreak(breakCategoryChangedReturnType, "endpoint %s changed return type from %s to %s", endpointName, baseReturns, currentReturns)
+	}
+}
+
+// compareTypeDefinitions reports the incompatible changes from base to current of the type with the provided name.
+func compareTypeDefinitions(typeName string, base, current spec.TypeDefinition, addBreak func(string, string, ...interface{})) {
+	baseParts, currentParts := newTypeDefinitionParts(base), newTypeDefinitionParts(current)
+	if baseParts.kind != currentParts.kind {
+		addBreak(breakCategoryChangedTypeKind, "type %s changed from %s to %s", typeName, baseParts.kind, currentParts.kind)
+		return
+	}
+	switch baseParts.kind {
+	case "alias":
+		if baseType, currentType := typeString(baseParts.alias.Alias), typeString(currentParts.alias.Alias); baseType != currentType {
+			addBreak(breakCategoryChangedAliasType, "alias %s changed from %s to %s", typeName, baseType, currentType)
+		}
+	case "enum":
+		currentValues := make(map[string]struct{})
+		for _, value := range currentParts.enum.Values {
+			currentValues[value.Value] = struct{}{}
+		}
+		for _, value := range baseParts.enum.Values {
+			if _, ok := currentValues[value.Value]; !ok {
+				addBreak(breakCategoryRemovedEnumValue, "enum %s removed value %s", typeName, value.Value)
+			}
+		}
+	case "object":
+		compareFields("object", typeName, "field", fieldCategories{
+			added:       breakCategoryAddedRequiredField,
+			removed:     breakCategoryRemovedField,
+			changedType: breakCategoryChangedFieldType,
+		}, baseParts.object.Fields, currentParts.object.Fields, true, addBreak)
+	case "union":
+		compareFields("union", typeName, "variant", fieldCategories{
+			removed:     breakCategoryRemovedUnionVariant,
+			changedType: breakCategoryChangedUnionVariantType,
+		}, baseParts.union.Union, currentParts.union.Union, false, addBreak)
+	}
+}
+
+// fieldCategories are the categories of the breaks reported by compareFields.
+type fieldCategories struct {
+	added       string
+	removed     string
+	changedType string
+}
+
+// compareFields reports removed fields and fields whose type changed from base to current. If checkAdded is true, added
+// fields whose type is required are also reported.
+func compareFields(kind, typeName, fieldKind string, categories fieldCategories, base, current []spec.FieldDefinition, checkAdded bool, addBreak func(string, string, ...interface{})) {
+	baseFields := make(map[string]spec.FieldDefinition)
+	for _, field := range base {
+		baseFields[string(field.FieldName)] = field
+	}
+	currentFields := make(map[string]spec.FieldDefinition)
+	for _, field := range current {
+		currentFields[string(field.FieldName)] = field
+		if _, ok := baseFields[string(field.FieldName)]; !ok && checkAdded && isRequiredType(field.Type) {
+			addBreak(categories.added, "%s %s added required %s %s", kind, typeName, fieldKind, field.FieldName)
+		}
+	}
+	for _, baseField := range base {
+		currentField, ok := currentFields[string(baseField.FieldName)]
+		if !ok {
+			addBreak(categories.removed, "%s %s removed %s %s", kind, typeName, fieldKind, baseField.FieldName)
+			continue
+		}
+		if baseType, currentType := typeString(baseField.Type), typeString(currentField.Type); baseType != currentType {
+			addBreak(categories.changedType, "%s %s changed type of %s %s from %s to %s", kind, typeName, fieldKind, baseField.FieldName, baseType, currentType)
+		}
+	}
+}
+
+// typeDefinitionParts is a type definition decomposed into its kind, name and definition. Only the definition of its
+// kind is set.
+type typeDefinitionParts struct {
+	kind   string
+	name   spec.TypeName
+	alias  spec.AliasDefinition
+	enum   spec.EnumDefinition
+	object spec.ObjectDefinition
+	union  spec.UnionDefinition
+}
+
+func newTypeDefinitionParts(typeDef spec.TypeDefinition) typeDefinitionParts {
+	var parts typeDefinitionParts
+	_ = typeDef.AcceptFuncs(
+		func(alias spec.AliasDefinition) error {
+			parts = typeDefinitionParts{kind: "alias", name: alias.TypeName, alias: alias}
+			return nil
+		},
+		func(enum spec.EnumDefinition) error {
+			parts = typeDefinitionParts{kind: "enum", name: enum.TypeName, enum: enum}
+			return nil
+		},
+		func(object spec.ObjectDefinition) error {
+			parts = typeDefinitionParts{kind: "object", name: object.TypeName, object: object}
+			return nil
+		},
+		func(union spec.UnionDefinition) error {
+			parts = typeDefinitionParts{kind: "union", name: union.TypeName, union: union}
+			return nil
+		},
+		func(typeName string) error {
+			parts = typeDefinitionParts{kind: typeName}
+			return nil
+		},
+	)
+	return parts
+}
+
+// optionalTypeString returns the description of the provided type, which is "none" if the type is nil.
+func optionalTypeString(t *spec.Type) string {
+	if t == nil {
+		return "none"
+	}
+	return typeString(*t)
+}
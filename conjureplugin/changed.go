@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChangedProjects returns the sorted names of the projects of the provided parameters that have changed relative to
+// the provided Git ref. baseParams are the parameters for the configuration at the Git ref. A project has changed if:
+//
+//   - its configuration differs from its configuration in baseParams (or it does not exist in baseParams)
+//   - any file in its local YAML or IR inputs, its output directory or its routes file differs from the Git ref
+//     (including files that are not tracked by Git)
+//   - its IR is provided by another project that has changed
+//
+// Changes to the IR of projects whose IR is not defined by local files (such as remote IR) are not detected unless
+// the configuration of the project changes.
+func ChangedProjects(params, baseParams ConjureProjectParams, projectDir, gitRef string) ([]string, error) {
+	changedFiles, err := changedFilesSince(projectDir, gitRef)
+	if err != nil {
+		return nil, err
+	}
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	changed := make(map[string]bool)
+	var isChanged func(name string) bool
+	isChanged = func(name string) bool {
+		if val, ok := changed[name]; ok {
+			return val
+		}
+		// mark as unchanged while computing to guard against cycles
+		changed[name] = false
+		param := params.Params[name]
+		baseParam, inBase := baseParams.Params[name]
+		result := !inBase || len(diffParam(baseParam, param)) > 0
+		if !result {
+			for _, inputPath := range changeInputPaths(param) {
+				if containsChangedFile(relativeToDir(inputPath, absProjectDir), changedFiles) {
+					result = true
+					break
+				}
+			}
+		}
+		if projectProvider, ok := param.IRProvider.(*projectIRProvider); !result && ok {
+			result = isChanged(projectProvider.project)
+		}
+		changed[name] = result
+		return result
+	}
+
+	var out []string
+	for _, name := range params.SortedKeys {
+		if isChanged(name) {
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
+
+// Subset returns the parameters that only contain the projects with the provided names.
+func (p *ConjureProjectParams) Subset(names []string) ConjureProjectParams {
+	include := make(map[string]struct{})
+	for _, name := range names {
+		include[name] = struct{}{}
+	}
+	out := ConjureProjectParams{
+		Params:         make(map[string]ConjureProjectParam),
+		AssetConfig:    p.AssetConfig,
+		AssetOptions:   p.AssetOptions,
+		Parallelism:    p.Parallelism,
+		Incremental:    p.Incremental,
+		NexusStaging:   p.NexusStaging,
+		PublisherAsset: p.PublisherAsset,
+		MavenLocal:     p.MavenLocal,
+		PostRunHooks:   p.PostRunHooks,
+	}
+	for _, name := range p.SortedKeys {
+		if _, ok := include[name]; !ok {
+			continue
+		}
+		out.SortedKeys = append(out.SortedKeys, name)
+		out.Params[name] = p.Params[name]
+	}
+	return out
+}
+
+// changeInputPaths returns the local paths (or glob patterns) whose changes change the output of the provided project.
+func changeInputPaths(param ConjureProjectParam) []string {
+	var paths []string
+	switch p := param.IRProvider.(type) {
+	case *localYAMLIRProvider:
+		paths = append(paths, p.paths...)
+	case *localFileIRProvider:
+		paths = append(paths, p.path)
+	}
+	for _, path := range []string{param.OutputDir, param.RoutesFile, param.IROutputPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// changedFilesSince returns the paths (relative to projectDir, using forward slashes) of the files in projectDir that
+// differ from the provided Git ref or are not tracked by Git.
+func changedFilesSince(projectDir, gitRef string) ([]string, error) {
+	diffOutput, err := gitOutput(projectDir, "diff", "--name-only", "--relative", gitRef, "--")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine files changed since %s", gitRef)
+	}
+	untrackedOutput, err := gitOutput(projectDir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine untracked files")
+	}
+	var files []string
+	for _, line := range strings.Split(diffOutput+"\n"+untrackedOutput, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// relativeToDir returns the provided path relative to the provided absolute directory using forward slashes. Relative
+// paths are assumed to already be relative to the directory.
+func relativeToDir(path, absDir string) string {
+	if filepath.IsAbs(path) {
+		if relPath, err := filepath.Rel(absDir, path); err == nil {
+			path = relPath
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// containsChangedFile returns true if any of the provided changed files is the provided path, is within the provided
+// path or is matched by the provided glob pattern (or is within a directory that is matched by it).
+func containsChangedFile(path string, changedFiles []string) bool {
+	for _, changedFile := range changedFiles {
+		if path == "." || changedFile == path || strings.HasPrefix(changedFile, path+"/") {
+			return true
+		}
+		if !isGlobPattern(path) {
+			continue
+		}
+		parts := strings.Split(changedFile, "/")
+		for i := range parts {
+			if matched, _ := filepath.Match(path, strings.Join(parts[:i+1], "/")); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedProjects(t *testing.T) {
+	repoDir := t.TempDir()
+	projectDir := filepath.Join(repoDir, "project")
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+	writeFile := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(projectDir, path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(projectDir, path), []byte(path), 0644))
+	}
+	gitCmd("init", "--quiet")
+	for _, path := range []string{"apis/a/conjure/a.yml", "apis/b/conjure/b.yml", "other/c.yml", "ir.json", "output/d/d.conjure.go"} {
+		writeFile(path)
+	}
+	gitCmd("add", ".")
+	gitCmd("commit", "--quiet", "-m", "base")
+	gitCmd("tag", "base")
+
+	baseParams := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"a", "all-apis", "b", "config", "d", "ir", "other", "uses-b"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"a":        {IRProvider: conjureplugin.NewLocalYAMLIRProvider("apis/a/conjure")},
+			"all-apis": {IRProvider: conjureplugin.NewLocalYAMLIRProvider("apis/*/conjure")},
+			"b":        {IRProvider: conjureplugin.NewLocalYAMLIRProvider("apis/b/conjure")},
+			"config":   {IRProvider: conjureplugin.NewLocalYAMLIRProvider("other")},
+			"d":        {IRProvider: conjureplugin.NewHTTPIRProvider("https://example.com/ir.json"), OutputDir: "output/d"},
+			"ir":       {IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "ir.json"))},
+			"other":    {IRProvider: conjureplugin.NewLocalYAMLIRProvider("other")},
+		},
+	}
+	baseParams.Params["uses-b"] = conjureplugin.ConjureProjectParam{
+		IRProvider: conjureplugin.NewProjectIRProvider("b", baseParams.Params["b"].IRProvider),
+	}
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: baseParams.SortedKeys,
+		Params:     make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for k, v := range baseParams.Params {
+		params.Params[k] = v
+	}
+	configParam := params.Params["config"]
+	configParam.Server = true
+	params.Params["config"] = configParam
+
+	changed, err := conjureplugin.ChangedProjects(params, baseParams, projectDir, "base")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config"}, changed)
+
+	// modified, untracked and deleted files are detected
+	writeFile("apis/b/conjure/b.yml.new")
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "apis/b/conjure/b.yml"), []byte("modified"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "output/d/d.conjure.go")))
+	changed, err = conjureplugin.ChangedProjects(params, baseParams, projectDir, "base")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"all-apis", "b", "config", "d", "uses-b"}, changed)
+
+	assert.Equal(t, []string{"b", "d"}, params.Subset([]string{"d", "b", "unknown"}).SortedKeys)
+}
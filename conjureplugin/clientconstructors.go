@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/conjure-go/v6/conjure/transforms"
+	"github.com/pkg/errors"
+)
+
+// clientConstructorSuffixes are the suffixes of the names of the variants of the generated constructors of service
+// clients.
+var clientConstructorSuffixes = []string{"", "WithAuth", "WithTokenProvider", "WithOptions"}
+
+// renameClientConstructors returns the provided files with the generated constructors of service clients renamed as
+// specified by the provided configuration. outputDir is the output directory of the project. Returns an error
+// if the configuration refers to a service that does not exist or if any renamed constructor would conflict with
+// another declaration in its package (including declarations in files that were not generated).
+func renameClientConstructors(def spec.ConjureDefinition, files []renderedFile, outputDir string, constructors map[string]ClientConstructor) ([]renderedFile, error) {
+	if len(constructors) == 0 {
+		return files, nil
+	}
+	// renamesByDir are the renames to apply to the files in each package directory
+	renamesByDir := make(map[string]map[string]string)
+	var keys []string
+	for k := range constructors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		service, err := clientConstructorService(def, key)
+		if err != nil {
+			return nil, err
+		}
+		oldName := "New" + service.ServiceName.Name + "Client"
+		newName := constructors[key].Name
+		if newName == "" {
+			newName = "New" + constructors[key].Prefix + service.ServiceName.Name + "Client"
+		}
+		dir, err := filepath.Abs(filepath.Join(outputDir, filepath.FromSlash(transforms.PackagePath(service.ServiceName.Package))))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if renamesByDir[dir] == nil {
+			renamesByDir[dir] = make(map[string]string)
+		}
+		for _, suffix := range clientConstructorSuffixes {
+			renamesByDir[dir][oldName+suffix] = newName + suffix
+		}
+	}
+
+	out := make([]renderedFile, len(files))
+	copy(out, files)
+	var dirs []string
+	for dir := range renamesByDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		if err := renameInPackage(out, dir, renamesByDir[dir]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// clientConstructorService returns the service with the provided simple or qualified name.
+func clientConstructorService(def spec.ConjureDefinition, name string) (spec.ServiceDefinition, error) {
+	var matches []spec.ServiceDefinition
+	for _, service := range def.Services {
+		if service.ServiceName.Name == name || qualifiedName(service.ServiceName) == name {
+			matches = append(matches, service)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return spec.ServiceDefinition{}, errors.Errorf("client-constructors specifies service %s, which is not defined", name)
+	case 1:
+		return matches[0], nil
+	default:
+		var names []string
+		for _, match := range matches {
+			names = append(names, qualifiedName(match.ServiceName))
+		}
+		return spec.ServiceDefinition{}, errors.Errorf("client-constructors specifies service %s, which matches multiple services (%s): use the qualified name", name, strings.Join(names, ", "))
+	}
+}
+
+// renameInPackage applies the provided renames to the identifiers in the generated Go files in the provided package
+// directory, which must be absolute. The files are modified in place.
+func renameInPackage(files []renderedFile, dir string, renames map[string]string) error {
+	// declared records the files in which each top-level identifier of the package is declared
+	declared := make(map[string][]string)
+	generated := make(map[string]struct{})
+	for i, file := range files {
+		fileDir, err := filepath.Abs(filepath.Dir(file.absPath))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if fileDir != dir || filepath.Ext(file.absPath) != ".go" {
+			continue
+		}
+		generated[filepath.Base(file.absPath)] = struct{}{}
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, file.absPath, file.content, parser.ParseComments)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse generated file %s", file.absPath)
+		}
+		renameIdents(astFile, renames)
+		buf := &bytes.Buffer{}
+		if err := format.Node(buf, fset, astFile); err != nil {
+			return errors.Wrapf(err, "failed to format generated file %s", file.absPath)
+		}
+		files[i].content = buf.Bytes()
+		for _, name := range topLevelNames(astFile) {
+			declared[name] = append(declared[name], filepath.Base(file.absPath))
+		}
+	}
+
+	// hand-written files in the package directory may declare identifiers that conflict with the renamed constructors
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to read directory %s", dir)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if _, ok := generated[entry.Name()]; ok || entry.IsDir() || filepath.Ext(path) != ".go" || strings.HasSuffix(path, ".conjure.go") {
+			continue
+		}
+		astFile, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s", path)
+		}
+		for _, name := range topLevelNames(astFile) {
+			declared[name] = append(declared[name], entry.Name())
+		}
+	}
+
+	var oldNames []string
+	for oldName := range renames {
+		oldNames = append(oldNames, oldName)
+	}
+	sort.Strings(oldNames)
+	for _, oldName := range oldNames {
+		newName := renames[oldName]
+		if declaredIn := declared[newName]; len(declaredIn) > 1 {
+			return errors.Errorf("client constructor %s cannot be renamed to %s because %s would be declared multiple times in package directory %s (in %s)", oldName, newName, newName, dir, strings.Join(declaredIn, ", "))
+		}
+	}
+	return nil
+}
+
+// renameIdents renames the identifiers in the provided file that are not qualified by a package.
+func renameIdents(file *ast.File, renames map[string]string) {
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			// the selector of a qualified identifier or field refers to a different scope
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Ident:
+			if newName, ok := renames[n.Name]; ok {
+				n.Name = newName
+			}
+		}
+		return true
+	}
+	ast.Inspect(file, visit)
+}
+
+// topLevelNames returns the names of the top-level declarations (other than methods) in the provided file.
+func topLevelNames(file *ast.File) []string {
+	var names []string
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"go/format"
+	"path/filepath"
+	"sort"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/conjure-go/v6/conjure/types"
+	"github.com/pkg/errors"
+)
+
+// clientOptionsFileName is the name of the file that contains the client options for a package.
+const clientOptionsFileName = "client_options" + generatedFileSuffix
+
+const (
+	httpclientImportPath = "github.com/palantir/conjure-go-runtime/v2/conjure-go-client/httpclient"
+
+	// optionsClientTypeName is the name of the unexported httpclient.Client implementation that applies client options.
+	optionsClientTypeName = "conjureOptionsClient"
+	// cancelOnCloseBodyTypeName is the name of the unexported response body wrapper that cancels the context of a
+	// request with a timeout when the body is closed.
+	cancelOnCloseBodyTypeName = "conjureCancelOnCloseBody"
+)
+
+// renderClientOptionsFiles returns a file for every package of the provided definition that contains services. For
+// every service, the file defines a functional option type, options that set default headers, a timeout and an RPC
+// method name override for every request, and a "New<Service>ClientWithOptions" constructor that applies the options.
+// The files are written to the same directories as the rest of the generated code for the packages.
+func renderClientOptionsFiles(conjureDef spec.ConjureDefinition, outputDir string) ([]renderedFile, error) {
+	def, err := types.NewConjureDefinition(outputDir, conjureDef)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration")
+	}
+	var pkgNames []string
+	for pkgName, pkg := range def.Packages {
+		if len(pkg.Services) > 0 {
+			pkgNames = append(pkgNames, pkgName)
+		}
+	}
+	sort.Strings(pkgNames)
+
+	var files []renderedFile
+	for _, pkgName := range pkgNames {
+		pkg := def.Packages[pkgName]
+		f := jen.NewFilePathName(pkg.ImportPath, pkg.PackageName)
+		f.HeaderComment("This file was generated by Conjure and should not be manually edited.")
+		for _, service := range pkg.Services {
+			addServiceClientOptions(f, service.Name)
+		}
+		addOptionsClient(f)
+
+		buf := &bytes.Buffer{}
+		if err := f.Render(buf); err != nil {
+			return nil, errors.Wrapf(err, "failed to generate client options for package %s", pkgName)
+		}
+		content, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to format client options for package %s", pkgName)
+		}
+		files = append(files, renderedFile{
+			absPath: filepath.Join(pkg.OutputDir, clientOptionsFileName),
+			content: content,
+		})
+	}
+	return files, nil
+}
+
+// addServiceClientOptions adds the option type, options and constructor for the service with the provided name.
+func addServiceClientOptions(f *jen.File, serviceName string) {
+	clientName := serviceName + "Client"
+	optionName := clientName + "Option"
+	constructorName := "New" + clientName + "WithOptions"
+
+	f.Commentf("%s configures the client returned by %s.", optionName, constructorName)
+	f.Type().Id(optionName).Func().Params(jen.Op("*").Id(optionsClientTypeName))
+
+	f.Commentf("With%sHeader returns an option that sets the provided header on every request made by the client unless the request sets it explicitly.", serviceName)
+	f.Func().Id("With"+serviceName+"Header").Params(jen.Id("key"), jen.Id("value").String()).Id(optionName).Block(
+		jen.Return(jen.Func().Params(jen.Id("c").Op("*").Id(optionsClientTypeName)).Block(
+			jen.Id("c").Dot("defaultParams").Op("=").Append(jen.Id("c").Dot("defaultParams"), jen.Qual(httpclientImportPath, "WithHeader").Call(jen.Id("key"), jen.Id("value"))),
+		)),
+	)
+
+	f.Commentf("With%sTimeout returns an option that limits the duration of every request made by the client (including reading the response body) to the provided timeout.", serviceName)
+	f.Func().Id("With" + serviceName + "Timeout").Params(jen.Id("timeout").Qual("time", "Duration")).Id(optionName).Block(
+		jen.Return(jen.Func().Params(jen.Id("c").Op("*").Id(optionsClientTypeName)).Block(
+			jen.Id("c").Dot("timeout").Op("=").Id("timeout"),
+		)),
+	)
+
+	f.Commentf("With%sRPCMethodName returns an option that overrides the RPC method name of every request made by the client.", serviceName)
+	f.Func().Id("With" + serviceName + "RPCMethodName").Params(jen.Id("name").String()).Id(optionName).Block(
+		jen.Return(jen.Func().Params(jen.Id("c").Op("*").Id(optionsClientTypeName)).Block(
+			jen.Id("c").Dot("overrideParams").Op("=").Append(jen.Id("c").Dot("overrideParams"), jen.Qual(httpclientImportPath, "WithRPCMethodName").Call(jen.Id("name"))),
+		)),
+	)
+
+	f.Commentf("%s returns a new %s that uses the provided client and applies the provided options to every request.", constructorName, clientName)
+	f.Func().Id(constructorName).Params(jen.Id("client").Qual(httpclientImportPath, "Client"), jen.Id("options").Op("...").Id(optionName)).Id(clientName).Block(
+		jen.Id("c").Op(":=").Op("&").Id(optionsClientTypeName).Values(jen.Dict{
+			jen.Id("Client"): jen.Id("client"),
+		}),
+		jen.For(jen.List(jen.Id("_"), jen.Id("option")).Op(":=").Range().Id("options")).Block(
+			jen.Id("option").Call(jen.Id("c")),
+		),
+		jen.Return(jen.Id("New"+clientName).Call(jen.Id("c"))),
+	)
+}
+
+// addOptionsClient adds the httpclient.Client implementation that applies the options of a client to every request.
+func addOptionsClient(f *jen.File) {
+	ctx := jen.Id("ctx").Qual("context", "Context")
+	params := jen.Id("params").Op("...").Qual(httpclientImportPath, "RequestParam")
+	results := jen.Params(jen.Op("*").Qual("net/http", "Response"), jen.Error())
+
+	f.Commentf("%s is an httpclient.Client that applies client options to every request.", optionsClientTypeName)
+	f.Type().Id(optionsClientTypeName).Struct(
+		jen.Qual(httpclientImportPath, "Client"),
+		jen.Id("defaultParams").Index().Qual(httpclientImportPath, "RequestParam"),
+		jen.Id("overrideParams").Index().Qual(httpclientImportPath, "RequestParam"),
+		jen.Id("timeout").Qual("time", "Duration"),
+	)
+
+	f.Func().Params(jen.Id("c").Op("*").Id(optionsClientTypeName)).Id("Do").Params(ctx, params).Add(results).Block(
+		jen.Id("allParams").Op(":=").Make(jen.Index().Qual(httpclientImportPath, "RequestParam"), jen.Lit(0), jen.Len(jen.Id("c").Dot("defaultParams")).Op("+").Len(jen.Id("params")).Op("+").Len(jen.Id("c").Dot("overrideParams"))),
+		jen.Id("allParams").Op("=").Append(jen.Id("allParams"), jen.Id("c").Dot("defaultParams").Op("...")),
+		jen.Id("allParams").Op("=").Append(jen.Id("allParams"), jen.Id("params").Op("...")),
+		jen.Id("allParams").Op("=").Append(jen.Id("allParams"), jen.Id("c").Dot("overrideParams").Op("...")),
+		jen.If(jen.Id("c").Dot("timeout").Op("<=").Lit(0)).Block(
+			jen.Return(jen.Id("c").Dot("Client").Dot("Do").Call(jen.Id("ctx"), jen.Id("allParams").Op("..."))),
+		),
+		jen.List(jen.Id("ctx"), jen.Id("cancel")).Op(":=").Qual("context", "WithTimeout").Call(jen.Id("ctx"), jen.Id("c").Dot("timeout")),
+		jen.List(jen.Id("resp"), jen.Err()).Op(":=").Id("c").Dot("Client").Dot("Do").Call(jen.Id("ctx"), jen.Id("allParams").Op("...")),
+		jen.If(jen.Err().Op("!=").Nil().Op("||").Id("resp").Op("==").Nil().Op("||").Id("resp").Dot("Body").Op("==").Nil()).Block(
+			jen.Id("cancel").Call(),
+			jen.Return(jen.Id("resp"), jen.Err()),
+		),
+		jen.Comment("the context must remain valid until the response body has been read"),
+		jen.Id("resp").Dot("Body").Op("=").Op("&").Id(cancelOnCloseBodyTypeName).Values(jen.Dict{
+			jen.Id("ReadCloser"): jen.Id("resp").Dot("Body"),
+			jen.Id("cancel"):     jen.Id("cancel"),
+		}),
+		jen.Return(jen.Id("resp"), jen.Nil()),
+	)
+
+	for _, method := range []struct {
+		name       string
+		httpMethod string
+	}{
+		{"Get", "GET"},
+		{"Head", "HEAD"},
+		{"Post", "POST"},
+		{"Put", "PUT"},
+		{"Delete", "DELETE"},
+	} {
+		f.Line()
+		f.Func().Params(jen.Id("c").Op("*").Id(optionsClientTypeName)).Id(method.name).Params(ctx.Clone(), params.Clone()).Add(results.Clone()).Block(
+			jen.Return(jen.Id("c").Dot("Do").Call(jen.Id("ctx"), jen.Append(jen.Index().Qual(httpclientImportPath, "RequestParam").Values(jen.Qual(httpclientImportPath, "WithRequestMethod").Call(jen.Lit(method.httpMethod))), jen.Id("params").Op("...")).Op("..."))),
+		)
+	}
+
+	f.Commentf("%s cancels the context of a request when its response body is closed.", cancelOnCloseBodyTypeName)
+	f.Type().Id(cancelOnCloseBodyTypeName).Struct(
+		jen.Qual("io", "ReadCloser"),
+		jen.Id("cancel").Qual("context", "CancelFunc"),
+	)
+	f.Line()
+	f.Func().Params(jen.Id("b").Op("*").Id(cancelOnCloseBodyTypeName)).Id("Close").Params().Error().Block(
+		jen.Defer().Id("b").Dot("cancel").Call(),
+		jen.Return(jen.Id("b").Dot("ReadCloser").Dot("Close").Call()),
+	)
+}
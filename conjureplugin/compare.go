@@ -0,0 +1,253 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+)
+
+// Compare writes a report that compares the definitions of the two projects with the provided names to the provided
+// writer. The report summarizes the types (including errors) and endpoints that are defined in both projects or only in
+// one of them along with the signatures of the definitions that are defined in both projects but differ. Definitions
+// are matched by name regardless of their package (endpoints are matched by the name of their service and their own
+// name) so that projects that define the same API in different packages can be compared. If a project defines multiple
+// types or services with the same name in different packages, their qualified names are used instead. A name that is
+// not the name of a configured project may be the path of an IR file (such as the IR snapshot of a project).
+func Compare(params ConjureProjectParams, projectA, projectB string, stdout io.Writer) error {
+	defA, err := compareDefinition(params, projectA)
+	if err != nil {
+		return err
+	}
+	defB, err := compareDefinition(params, projectB)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Comparing %s and %s\n", projectA, projectB)
+	printComparison(stdout, "Types", projectA, projectB, typeSignatures(defA), typeSignatures(defB))
+	printComparison(stdout, "Endpoints", projectA, projectB, endpointSignatures(defA), endpointSignatures(defB))
+	return nil
+}
+
+func compareDefinition(params ConjureProjectParams, projectName string) (spec.ConjureDefinition, error) {
+	param, err := params.Param(projectName)
+	if err != nil {
+		if fi, statErr := os.Stat(projectName); statErr == nil && !fi.IsDir() {
+			return conjureDefinitionFromProvider(NewLocalFileIRProvider(projectName), nil)
+		}
+		return spec.ConjureDefinition{}, err
+	}
+	return conjureDefinitionFromParam(param)
+}
+
+// printComparison prints the comparison of the provided signatures (keyed by the name of the definition) to the
+// provided writer.
+func printComparison(w io.Writer, kind, projectA, projectB string, signaturesA, signaturesB map[string]string) {
+	var onlyA, onlyB, common, different []string
+	for name, signatureA := range signaturesA {
+		signatureB, ok := signaturesB[name]
+		switch {
+		case !ok:
+			onlyA = append(onlyA, name)
+		case signatureA != signatureB:
+			different = append(different, name)
+			common = append(common, name)
+		default:
+			common = append(common, name)
+		}
+	}
+	for name := range signaturesB {
+		if _, ok := signaturesA[name]; !ok {
+			onlyB = append(onlyB, name)
+		}
+	}
+	_, _ = fmt.Fprintf(w, "%s: %d in both (%d with differences), %d only in %s, %d only in %s\n", kind, len(common), len(different), len(onlyA), projectA, len(onlyB), projectB)
+	printNames := func(header string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		sort.Strings(names)
+		_, _ = fmt.Fprintf(w, "  %s:\n", header)
+		for _, name := range names {
+			_, _ = fmt.Fprintf(w, "    %s\n", name)
+		}
+	}
+	printNames("Only in "+projectA, onlyA)
+	printNames("Only in "+projectB, onlyB)
+	if len(different) == 0 {
+		return
+	}
+	sort.Strings(different)
+	labelWidth := len(projectA)
+	if len(projectB) > labelWidth {
+		labelWidth = len(projectB)
+	}
+	_, _ = fmt.Fprintln(w, "  Differences:")
+	for _, name := range different {
+		_, _ = fmt.Fprintf(w, "    %s\n", name)
+		_, _ = fmt.Fprintf(w, "      %-*s %s\n", labelWidth+1, projectA+":", signaturesA[name])
+		_, _ = fmt.Fprintf(w, "      %-*s %s\n", labelWidth+1, projectB+":", signaturesB[name])
+	}
+}
+
+// typeSignatures returns the signatures of the types and errors of the provided definition keyed by their comparison
+// names.
+func typeSignatures(def spec.ConjureDefinition) map[string]string {
+	var typeNames []spec.TypeName
+	for _, typeDef := range def.Types {
+		_ = typeDef.AcceptFuncs(
+			func(alias spec.AliasDefinition) error {
+				typeNames = append(typeNames, alias.TypeName)
+				return nil
+			},
+			func(enum spec.EnumDefinition) error {
+				typeNames = append(typeNames, enum.TypeName)
+				return nil
+			},
+			func(object spec.ObjectDefinition) error {
+				typeNames = append(typeNames, object.TypeName)
+				return nil
+			},
+			func(union spec.UnionDefinition) error {
+				typeNames = append(typeNames, union.TypeName)
+				return nil
+			},
+			typeDef.ErrorOnUnknown,
+		)
+	}
+	for _, errorDef := range def.Errors {
+		typeNames = append(typeNames, errorDef.ErrorName)
+	}
+	nameFn := comparisonNameFn(typeNames)
+
+	signatures := make(map[string]string)
+	for _, typeDef := range def.Types {
+		_ = typeDef.AcceptFuncs(
+			func(alias spec.AliasDefinition) error {
+				signatures[nameFn(alias.TypeName)] = fmt.Sprintf("alias of %s", formatType(alias.Alias, nameFn))
+				return nil
+			},
+			func(enum spec.EnumDefinition) error {
+				var values []string
+				for _, value := range enum.Values {
+					values = append(values, value.Value)
+				}
+				signatures[nameFn(enum.TypeName)] = fmt.Sprintf("enum [%s]", strings.Join(values, ", "))
+				return nil
+			},
+			func(object spec.ObjectDefinition) error {
+				signatures[nameFn(object.TypeName)] = fmt.Sprintf("object %s", fieldsSignature(object.Fields, nameFn))
+				return nil
+			},
+			func(union spec.UnionDefinition) error {
+				signatures[nameFn(union.TypeName)] = fmt.Sprintf("union %s", fieldsSignature(union.Union, nameFn))
+				return nil
+			},
+			typeDef.ErrorOnUnknown,
+		)
+	}
+	for _, errorDef := range def.Errors {
+		signatures[nameFn(errorDef.ErrorName)] = fmt.Sprintf("error %s:%s %s, unsafe %s", errorDef.Namespace, errorDef.Code, fieldsSignature(errorDef.SafeArgs, nameFn), fieldsSignature(errorDef.UnsafeArgs, nameFn))
+	}
+	return signatures
+}
+
+// endpointSignatures returns the signatures of the endpoints of the provided definition keyed by the comparison name
+// of their service followed by a period and the name of the endpoint.
+func endpointSignatures(def spec.ConjureDefinition) map[string]string {
+	var serviceNames []spec.TypeName
+	for _, service := range def.Services {
+		serviceNames = append(serviceNames, service.ServiceName)
+	}
+	serviceNameFn := comparisonNameFn(serviceNames)
+	typeNameFn := func(typeName spec.TypeName) string {
+		return typeName.Name
+	}
+
+	signatures := make(map[string]string)
+	for _, service := range def.Services {
+		for _, endpoint := range service.Endpoints {
+			var args []string
+			for _, arg := range endpoint.Args {
+				args = append(args, fmt.Sprintf("%s: %s %s", arg.ArgName, formatType(arg.Type, typeNameFn), paramTypeDescription(arg.ParamType)))
+			}
+			signature := fmt.Sprintf("%s %s (%s) auth %s", endpoint.HttpMethod, endpoint.HttpPath, strings.Join(args, ", "), authDescription(endpoint.Auth))
+			if endpoint.Returns != nil {
+				signature += " -> " + formatType(*endpoint.Returns, typeNameFn)
+			}
+			signatures[fmt.Sprintf("%s.%s", serviceNameFn(service.ServiceName), endpoint.EndpointName)] = signature
+		}
+	}
+	return signatures
+}
+
+// comparisonNameFn returns a function that returns the name used to compare the provided type names. The name of a
+// type is used unless the provided names contain multiple types with the same name, in which case the qualified name is
+// used. Names that are not among the provided names (such as external types) are returned unqualified.
+func comparisonNameFn(typeNames []spec.TypeName) func(spec.TypeName) string {
+	packages := make(map[string]map[string]struct{})
+	for _, typeName := range typeNames {
+		if packages[typeName.Name] == nil {
+			packages[typeName.Name] = make(map[string]struct{})
+		}
+		packages[typeName.Name][typeName.Package] = struct{}{}
+	}
+	return func(typeName spec.TypeName) string {
+		if len(packages[typeName.Name]) > 1 {
+			return qualifiedName(typeName)
+		}
+		return typeName.Name
+	}
+}
+
+func fieldsSignature(fields []spec.FieldDefinition, typeNameFn func(spec.TypeName) string) string {
+	var parts []string
+	for _, field := range fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", field.FieldName, formatType(field.Type, typeNameFn)))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(parts, ", "))
+}
+
+func paramTypeDescription(paramType spec.ParameterType) string {
+	var out string
+	_ = paramType.AcceptFuncs(
+		func(spec.BodyParameterType) error {
+			out = "body"
+			return nil
+		},
+		func(header spec.HeaderParameterType) error {
+			out = fmt.Sprintf("header %q", header.ParamId)
+			return nil
+		},
+		func(spec.PathParameterType) error {
+			out = "path"
+			return nil
+		},
+		func(query spec.QueryParameterType) error {
+			out = fmt.Sprintf("query %q", query.ParamId)
+			return nil
+		},
+		func(typeName string) error {
+			out = typeName
+			return nil
+		},
+	)
+	return out
+}
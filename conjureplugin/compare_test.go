@@ -0,0 +1,87 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	irs := map[string]string{
+		"types":        testJSONSchemaIRJSON,
+		"types-v2":     strings.NewReplacer("com.example.api", "com.example.api.v2", `"HISTORY"`, `"SCIENCE"`, `"Identifier"`, `"Id"`).Replace(testJSONSchemaIRJSON),
+		"endpoints":    testServiceIRJSON,
+		"endpoints-v2": strings.Replace(testServiceIRJSON, `"/all"`, `"/everything"`, 1),
+	}
+	params := conjureplugin.ConjureProjectParams{
+		Params: make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for name, ir := range irs {
+		irFile := filepath.Join(dir, name+".json")
+		require.NoError(t, os.WriteFile(irFile, []byte(ir), 0644))
+		params.SortedKeys = append(params.SortedKeys, name)
+		params.Params[name] = conjureplugin.ConjureProjectParam{
+			OutputDir:  name,
+			IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+		}
+	}
+
+	for _, tc := range []struct {
+		a, b string
+		want string
+	}{
+		{
+			"types",
+			"types-v2",
+			`Comparing types and types-v2
+Types: 2 in both (1 with differences), 1 only in types, 1 only in types-v2
+  Only in types:
+    Identifier
+  Only in types-v2:
+    Id
+  Differences:
+    Genre
+      types:    enum [FICTION, HISTORY]
+      types-v2: enum [FICTION, SCIENCE]
+Endpoints: 0 in both (0 with differences), 0 only in types, 0 only in types-v2
+`,
+		},
+		{
+			"endpoints",
+			"endpoints-v2",
+			`Comparing endpoints and endpoints-v2
+Types: 0 in both (0 with differences), 0 only in endpoints, 0 only in endpoints-v2
+Endpoints: 1 in both (1 with differences), 0 only in endpoints, 0 only in endpoints-v2
+  Differences:
+    TestService.deleteAll
+      endpoints:    DELETE /all (token: BEARERTOKEN query "token", filter: optional<Filter> query "filter") auth header
+      endpoints-v2: DELETE /everything (token: BEARERTOKEN query "token", filter: optional<Filter> query "filter") auth header
+`,
+		},
+	} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, conjureplugin.Compare(params, tc.a, tc.b, buf))
+		assert.Equal(t, tc.want, buf.String())
+	}
+}
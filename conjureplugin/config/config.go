@@ -15,15 +15,30 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/build/constraint"
+	"go/token"
 	"io/ioutil"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
 	v1 "github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config/internal/v1"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
 	"github.com/pkg/errors"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 	"gopkg.in/yaml.v2"
 )
 
@@ -33,44 +48,735 @@ func ToConjurePluginConfig(in *ConjurePluginConfig) *v1.ConjurePluginConfig {
 	return (*v1.ConjurePluginConfig)(in)
 }
 
+// ToParams returns the parameters specified by the configuration. Errors are classified as
+// conjureplugin.ErrInvalidConfig.
 func (c *ConjurePluginConfig) ToParams() (conjureplugin.ConjureProjectParams, error) {
+	params, err := c.toParams()
+	return params, conjureplugin.Classify(err, conjureplugin.ErrInvalidConfig)
+}
+
+func (c *ConjurePluginConfig) toParams() (conjureplugin.ConjureProjectParams, error) {
 	var keys []string
 	for k := range c.ProjectConfigs {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
+	if c.Parallelism < 0 {
+		return conjureplugin.ConjureProjectParams{}, errors.Errorf("parallelism must be non-negative, was %d", c.Parallelism)
+	}
+	irProviders, err := c.irProviders(keys)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+
+	targetPlatforms, err := toTargetPlatforms(c.TargetPlatforms)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid target-platforms")
+	}
+
 	params := make(map[string]conjureplugin.ConjureProjectParam)
 	for key, currConfig := range c.ProjectConfigs {
-		irProvider, err := (*IRLocatorConfig)(&currConfig.IRLocator).ToIRProvider()
-		if err != nil {
-			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "failed to convert configuration for %s to provider", key)
-		}
+		env := projectEnv(c.Env, currConfig.Env)
+		jvmOptions := projectJVMOptions(c.JVMOptions, currConfig.JVMOptions)
+		irProvider := irProviders[key]
 
 		publishVal := false
 		// if value for "publish" is not specified, treat as "true" only if provider generates IR from YAML
 		if currConfig.Publish == nil {
 			publishVal = irProvider.GeneratedFromYAML()
+		} else {
+			publishVal = *currConfig.Publish
 		}
 		acceptFuncsFlag := true
 		if currConfig.AcceptFuncs != nil {
 			acceptFuncsFlag = *currConfig.AcceptFuncs
 		}
+		var sizeBudget *conjureplugin.SizeBudget
+		if currConfig.SizeBudget != nil {
+			sizeBudget = &conjureplugin.SizeBudget{
+				MaxFiles:      currConfig.SizeBudget.MaxFiles,
+				MaxTotalBytes: currConfig.SizeBudget.MaxTotalBytes,
+				MaxFileBytes:  currConfig.SizeBudget.MaxFileBytes,
+				WarnOnly:      currConfig.SizeBudget.WarnOnly,
+			}
+		}
+		forbiddenPatterns, err := toForbiddenPatterns(currConfig.ForbiddenPatterns)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid forbidden-patterns for %s", key)
+		}
+		clientConstructors, err := toClientConstructors(currConfig.ClientConstructors)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid client-constructors for %s", key)
+		}
+		for _, owner := range currConfig.Owners {
+			if strings.TrimSpace(owner) == "" {
+				return conjureplugin.ConjureProjectParams{}, errors.Errorf("owners of %s must not be blank", key)
+			}
+		}
+		externalGenerator, generatorAsset, err := toGenerator(currConfig)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid generator for %s", key)
+		}
+		postGenerate, err := toPostGenerateHooks(c.PostGenerate, currConfig.PostGenerate)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid post-generate for %s", key)
+		}
+		fileModePolicy := conjureplugin.FileModePolicy(currConfig.FileModePolicy)
+		switch fileModePolicy {
+		case "", conjureplugin.FileModePolicyIgnore, conjureplugin.FileModePolicyReport, conjureplugin.FileModePolicyNormalize:
+		default:
+			return conjureplugin.ConjureProjectParams{}, errors.Errorf("invalid file-mode-policy %q for %s: must be %q, %q or %q", fileModePolicy, key, conjureplugin.FileModePolicyIgnore, conjureplugin.FileModePolicyReport, conjureplugin.FileModePolicyNormalize)
+		}
+		format := conjureplugin.Formatter(currConfig.Format)
+		switch format {
+		case "", conjureplugin.FormatterGoimports, conjureplugin.FormatterGofumpt:
+		default:
+			return conjureplugin.ConjureProjectParams{}, errors.Errorf("invalid format %q for %s: must be %q or %q", format, key, conjureplugin.FormatterGoimports, conjureplugin.FormatterGofumpt)
+		}
+		for _, tag := range currConfig.BuildTags {
+			if _, err := constraint.Parse("//go:build " + tag); err != nil {
+				return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid build-tags for %s: invalid build tag expression %q", key, tag)
+			}
+		}
+		var filter *conjureplugin.DefinitionFilter
+		if currConfig.Filter != nil {
+			filter = &conjureplugin.DefinitionFilter{
+				IncludeServices: currConfig.Filter.IncludeServices,
+				ExcludeServices: currConfig.Filter.ExcludeServices,
+				IncludeTypes:    currConfig.Filter.IncludeTypes,
+				ExcludeTypes:    currConfig.Filter.ExcludeTypes,
+				IncludePackages: currConfig.Filter.IncludePackages,
+				ExcludePackages: currConfig.Filter.ExcludePackages,
+			}
+		}
+		yamlMethods := conjureplugin.YAMLMethods(currConfig.YAMLMethods)
+		switch yamlMethods {
+		case "", conjureplugin.YAMLMethodsSafeYAML, conjureplugin.YAMLMethodsYAMLV3, conjureplugin.YAMLMethodsNone:
+		default:
+			return conjureplugin.ConjureProjectParams{}, errors.Errorf("invalid yaml-methods %q for %s: must be %q, %q or %q", yamlMethods, key, conjureplugin.YAMLMethodsSafeYAML, conjureplugin.YAMLMethodsYAMLV3, conjureplugin.YAMLMethodsNone)
+		}
+		if err := validatePackagePaths(currConfig.PackagePaths); err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid package-paths for %s", key)
+		}
+		standaloneModule, err := toStandaloneModule(currConfig.StandaloneModule, currConfig.OutputDir)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid standalone-module for %s", key)
+		}
+		header := conjureplugin.Header(currConfig.Header)
+		if header != "" {
+			if err := header.Validate(); err != nil {
+				return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid header for %s", key)
+			}
+		}
+		var backCompatBaseline *conjureplugin.BackCompatBaseline
+		if currConfig.BackCompatBaseline != "" {
+			baseline, err := conjureplugin.ParseBackCompatBaseline(currConfig.BackCompatBaseline)
+			if err != nil {
+				return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid backcompat-baseline for %s", key)
+			}
+			backCompatBaseline = &baseline
+		}
+		backCompatPolicy, err := toBackCompatPolicy(c.BackCompatPolicy, currConfig.BackCompatPolicy)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid backcompat-policy for %s", key)
+		}
+		extensions, err := toProjectExtensions(currConfig.Extensions)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid extensions for %s", key)
+		}
+		extensions.File = currConfig.ExtensionsFile
+		var renamedFrom []conjureplugin.RenamedFrom
+		for _, currRenamedFrom := range currConfig.RenamedFrom {
+			if currRenamedFrom.Name == "" {
+				return conjureplugin.ConjureProjectParams{}, errors.Errorf("renamed-from entries for %s must specify a name", key)
+			}
+			if _, ok := c.ProjectConfigs[currRenamedFrom.Name]; ok {
+				return conjureplugin.ConjureProjectParams{}, errors.Errorf("%s cannot be renamed from %s because %s is a configured project", key, currRenamedFrom.Name, currRenamedFrom.Name)
+			}
+			renamedFrom = append(renamedFrom, conjureplugin.RenamedFrom{
+				Name:                 currRenamedFrom.Name,
+				OutputDir:            currRenamedFrom.OutputDir,
+				PublishRelocationPOM: currRenamedFrom.PublishRelocationPOM,
+			})
+		}
 		params[key] = conjureplugin.ConjureProjectParam{
-			OutputDir:   currConfig.OutputDir,
-			IRProvider:  irProvider,
-			AcceptFuncs: acceptFuncsFlag,
-			Server:      currConfig.Server,
-			CLI:         currConfig.CLI,
-			Publish:     publishVal,
+			OutputDir:          currConfig.OutputDir,
+			IRProvider:         irProvider,
+			AcceptFuncs:        acceptFuncsFlag,
+			Server:             currConfig.Server,
+			CLI:                currConfig.CLI,
+			Publish:            publishVal,
+			SizeBudget:         sizeBudget,
+			RenamedFrom:        renamedFrom,
+			PublishProperties:  currConfig.PublishProperties,
+			PublishSharded:     currConfig.PublishSharded,
+			Env:                env,
+			CompilerArgs:       currConfig.CompilerArgs,
+			Compiler:           string(currConfig.Compiler),
+			JVMOptions:         jvmOptions,
+			Frozen:             currConfig.Frozen,
+			BackCompatBaseline: backCompatBaseline,
+			BackCompatPolicy:   backCompatPolicy,
+			Extensions:         extensions,
+			ForbiddenPatterns:  forbiddenPatterns,
+			EndpointConstants:  currConfig.EndpointConstants,
+			RoutesFile:         currConfig.RoutesFile,
+			ClientOptions:      currConfig.ClientOptions,
+			ClientConstructors: clientConstructors,
+			Readme:             currConfig.Readme,
+			IRSnapshot:         currConfig.IRSnapshot,
+			Owners:             currConfig.Owners,
+			ExternalGenerator:  externalGenerator,
+			GeneratorAsset:     generatorAsset,
+			FileModePolicy:     fileModePolicy,
+			PostGenerate:       postGenerate,
+			Format:             format,
+			BuildTags:          currConfig.BuildTags,
+			Header:             header,
+			YAMLMethods:        yamlMethods,
+			Filter:             filter,
+			PackagePaths:       currConfig.PackagePaths,
+			StandaloneModule:   standaloneModule,
+			Mocks:              currConfig.Mocks,
+			Examples:           currConfig.Examples,
+			TargetPlatforms:    targetPlatforms,
+		}
+	}
+	assetConfig, err := toAssetConfig(c.AssetConfig)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	var assetOptions map[string]conjureplugin.AssetOptions
+	for name, options := range c.AssetOptions {
+		if assetOptions == nil {
+			assetOptions = make(map[string]conjureplugin.AssetOptions)
+		}
+		assetOptions[name] = conjureplugin.AssetOptions{
+			Optional: options.Optional,
+		}
+	}
+	nexusStaging, publisherAsset, mavenLocal, err := toPublishTarget(c.PublishTarget)
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid publish-target")
+	}
+	if publisherAsset != nil {
+		// relocation POMs are published to the Maven repository of the publish target, which publisher assets do not have
+		for _, key := range keys {
+			for _, renamedFrom := range params[key].RenamedFrom {
+				if renamedFrom.PublishRelocationPOM {
+					return conjureplugin.ConjureProjectParams{}, errors.Errorf("publish-relocation-pom cannot be specified for %s if the publish-target type is %q", key, v1.PublishTargetTypeAsset)
+				}
+			}
+		}
+	}
+	var postRunHooks []conjureplugin.PostRunHook
+	for i, hook := range c.PostRun {
+		if hook.Path == "" {
+			return conjureplugin.ConjureProjectParams{}, errors.Errorf("post-run entry %d must specify a path", i)
 		}
+		postRunHooks = append(postRunHooks, conjureplugin.PostRunHook{
+			Path: hook.Path,
+			Args: hook.Args,
+		})
 	}
 	return conjureplugin.ConjureProjectParams{
-		SortedKeys: keys,
-		Params:     params,
+		SortedKeys:     keys,
+		Params:         params,
+		AssetConfig:    assetConfig,
+		AssetOptions:   assetOptions,
+		Parallelism:    c.Parallelism,
+		Incremental:    c.Incremental,
+		NexusStaging:   nexusStaging,
+		PublisherAsset: publisherAsset,
+		MavenLocal:     mavenLocal,
+		PostRunHooks:   postRunHooks,
+	}, nil
+}
+
+// toBackCompatPolicy returns the backcompat policy of a project with the provided plugin-level and project-level
+// policies. The severities of the project take precedence over those of the plugin.
+func toBackCompatPolicy(pluginCfg, projectCfg *v1.BackCompatPolicyConfig) (conjureplugin.BackCompatPolicy, error) {
+	var policy conjureplugin.BackCompatPolicy
+	for _, cfg := range []*v1.BackCompatPolicyConfig{pluginCfg, projectCfg} {
+		if cfg == nil {
+			continue
+		}
+		for category, val := range cfg.Severities {
+			severity, err := conjureplugin.ParseBackCompatSeverity(val)
+			if err != nil {
+				return conjureplugin.BackCompatPolicy{}, errors.Wrapf(err, "invalid severity for %s", category)
+			}
+			if policy.Severities == nil {
+				policy.Severities = make(map[string]conjureplugin.BackCompatSeverity)
+			}
+			policy.Severities[category] = severity
+		}
+		if cfg.FailOnWarnings != nil {
+			policy.FailOnWarnings = *cfg.FailOnWarnings
+		}
+	}
+	return policy, nil
+}
+
+// toProjectExtensions returns the extensions specified by the provided configuration.
+func toProjectExtensions(cfg *v1.ExtensionsConfig) (conjureplugin.ProjectExtensions, error) {
+	var extensions conjureplugin.ProjectExtensions
+	if cfg == nil {
+		return extensions, nil
+	}
+	extensions.Schema = cfg.Schema
+	if cfg.MergeStrategy != "" {
+		strategy, err := conjureplugin.ParseExtensionsMergeStrategy(cfg.MergeStrategy)
+		if err != nil {
+			return conjureplugin.ProjectExtensions{}, err
+		}
+		extensions.MergeStrategy = strategy
+	}
+	if cfg.Git != nil {
+		git := &conjureplugin.GitMetadataExtension{
+			Key: cfg.Git.Key,
+		}
+		for _, val := range cfg.Git.Fields {
+			field, err := conjureplugin.ParseGitMetadataField(val)
+			if err != nil {
+				return conjureplugin.ProjectExtensions{}, errors.Wrapf(err, "invalid git")
+			}
+			git.Fields = append(git.Fields, field)
+		}
+		extensions.Git = git
+	}
+	if cfg.Build != nil {
+		build := &conjureplugin.BuildMetadataExtension{
+			Key: cfg.Build.Key,
+		}
+		for _, val := range cfg.Build.Fields {
+			field, err := conjureplugin.ParseBuildMetadataField(val)
+			if err != nil {
+				return conjureplugin.ProjectExtensions{}, errors.Wrapf(err, "invalid build")
+			}
+			build.Fields = append(build.Fields, field)
+		}
+		extensions.Build = build
+	}
+	return extensions, nil
+}
+
+// toTargetPlatforms returns the target platforms specified by the provided configuration, or nil if no platforms are
+// specified.
+func toTargetPlatforms(cfg *v1.TargetPlatformsConfig) (*conjureplugin.TargetPlatforms, error) {
+	if cfg == nil || len(cfg.Platforms) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{})
+	for _, platform := range cfg.Platforms {
+		if _, _, err := conjureplugin.ParsePlatform(platform); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[platform]; ok {
+			return nil, errors.Errorf("platform %q is specified multiple times", platform)
+		}
+		seen[platform] = struct{}{}
+	}
+	return &conjureplugin.TargetPlatforms{
+		Platforms: cfg.Platforms,
+		WarnOnly:  cfg.WarnOnly,
 	}, nil
 }
 
+// toPublishTarget returns the Nexus staging workflow, publisher asset or local Maven repository specified by the provided
+// configuration. All are nil if the configuration specifies that IR is published to Artifactory.
+func toPublishTarget(cfg *v1.PublishTargetConfig) (*conjureplugin.NexusStaging, *conjureplugin.PublisherAsset, *conjureplugin.MavenLocal, error) {
+	if cfg == nil {
+		return nil, nil, nil, nil
+	}
+	if cfg.Type != v1.PublishTargetTypeNexus && cfg.Nexus != nil {
+		return nil, nil, nil, errors.Errorf("nexus may only be specified if type is %q", v1.PublishTargetTypeNexus)
+	}
+	if cfg.Type != v1.PublishTargetTypeAsset && cfg.Asset != "" {
+		return nil, nil, nil, errors.Errorf("asset may only be specified if type is %q", v1.PublishTargetTypeAsset)
+	}
+	if cfg.Type != v1.PublishTargetTypeMavenLocal && cfg.MavenLocal != nil {
+		return nil, nil, nil, errors.Errorf("maven-local may only be specified if type is %q", v1.PublishTargetTypeMavenLocal)
+	}
+	switch cfg.Type {
+	case "", v1.PublishTargetTypeArtifactory:
+		return nil, nil, nil, nil
+	case v1.PublishTargetTypeNexus:
+		if cfg.Nexus == nil || cfg.Nexus.StagingProfileID == "" {
+			return nil, nil, nil, errors.Errorf("nexus.staging-profile-id must be specified if type is %q", v1.PublishTargetTypeNexus)
+		}
+		return &conjureplugin.NexusStaging{
+			StagingProfileID: cfg.Nexus.StagingProfileID,
+			Description:      cfg.Nexus.Description,
+			Release:          cfg.Nexus.Release,
+		}, nil, nil, nil
+	case v1.PublishTargetTypeAsset:
+		if cfg.Asset == "" {
+			return nil, nil, nil, errors.Errorf("asset must be specified if type is %q", v1.PublishTargetTypeAsset)
+		}
+		return nil, &conjureplugin.PublisherAsset{
+			Name: cfg.Asset,
+		}, nil, nil
+	case v1.PublishTargetTypeMavenLocal:
+		mavenLocal := &conjureplugin.MavenLocal{}
+		if cfg.MavenLocal != nil {
+			mavenLocal.Repository = cfg.MavenLocal.Repository
+		}
+		return nil, nil, mavenLocal, nil
+	default:
+		return nil, nil, nil, errors.Errorf("type must be %q, %q, %q or %q, was %q", v1.PublishTargetTypeArtifactory, v1.PublishTargetTypeNexus, v1.PublishTargetTypeAsset, v1.PublishTargetTypeMavenLocal, cfg.Type)
+	}
+}
+
+func toClientConstructors(cfgs map[string]v1.ClientConstructorConfig) (map[string]conjureplugin.ClientConstructor, error) {
+	if len(cfgs) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]conjureplugin.ClientConstructor)
+	for service, cfg := range cfgs {
+		switch {
+		case (cfg.Name == "") == (cfg.Prefix == ""):
+			return nil, errors.Errorf("exactly one of name and prefix must be specified for %s", service)
+		case cfg.Name != "" && !(token.IsIdentifier(cfg.Name) && token.IsExported(cfg.Name)):
+			return nil, errors.Errorf("name %q for %s is not a valid exported Go identifier", cfg.Name, service)
+		case cfg.Prefix != "" && !token.IsIdentifier("New"+cfg.Prefix):
+			return nil, errors.Errorf("prefix %q for %s does not result in a valid Go identifier", cfg.Prefix, service)
+		}
+		out[service] = conjureplugin.ClientConstructor{
+			Name:   cfg.Name,
+			Prefix: cfg.Prefix,
+		}
+	}
+	return out, nil
+}
+
+// validatePackagePaths returns an error if any of the provided package paths does not map a Conjure package to a
+// slash-separated path within the output directory.
+func validatePackagePaths(packagePaths map[string]string) error {
+	var pkgs []string
+	for pkg := range packagePaths {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		if pkg == "" || strings.HasPrefix(pkg, ".") || strings.HasSuffix(pkg, ".") || strings.Contains(pkg, "..") {
+			return errors.Errorf("%q is not a valid Conjure package", pkg)
+		}
+		pkgPath := packagePaths[pkg]
+		if pkgPath == "" || strings.Contains(pkgPath, `\`) || path.IsAbs(pkgPath) || path.Clean(pkgPath) != pkgPath || pkgPath == "." || pkgPath == ".." || strings.HasPrefix(pkgPath, "../") {
+			return errors.Errorf("path %q for %s must be a clean slash-separated path within the output directory", pkgPath, pkg)
+		}
+	}
+	return nil
+}
+
+// toStandaloneModule returns the standalone module specified by the provided configuration for a project with the
+// provided output directory, or nil if the configuration is nil.
+func toStandaloneModule(cfg *v1.StandaloneModuleConfig, outputDir string) (*conjureplugin.StandaloneModule, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if filepath.Clean(outputDir) == "." {
+		return nil, errors.Errorf("the output directory must not be the project directory")
+	}
+	var modPaths []string
+	for modPath := range cfg.Requires {
+		modPaths = append(modPaths, modPath)
+	}
+	sort.Strings(modPaths)
+	for _, modPath := range modPaths {
+		if err := module.CheckPath(modPath); err != nil {
+			return nil, errors.Wrapf(err, "invalid module path in requires")
+		}
+		if version := cfg.Requires[modPath]; !semver.IsValid(version) {
+			return nil, errors.Errorf("version %q of %s is not a valid semantic version", version, modPath)
+		}
+	}
+	return &conjureplugin.StandaloneModule{
+		Requires: cfg.Requires,
+	}, nil
+}
+
+// toGenerator returns the external generator or generator asset specified by the generator configuration of the
+// provided project. Both are nil if the configuration specifies the builtin generator.
+func toGenerator(projectCfg v1.SingleConjureConfig) (*conjureplugin.ExternalGenerator, *conjureplugin.GeneratorAsset, error) {
+	cfg := projectCfg.Generator
+	if cfg == nil {
+		return nil, nil, nil
+	}
+	if cfg.Type != v1.GeneratorTypeAsset && cfg.Asset != "" {
+		return nil, nil, errors.Errorf("asset may only be specified if type is %q", v1.GeneratorTypeAsset)
+	}
+	switch cfg.Type {
+	case "", v1.GeneratorTypeBuiltin:
+		if cfg.Path != "" || len(cfg.Args) > 0 {
+			return nil, nil, errors.Errorf("path and args may only be specified if type is %q", v1.GeneratorTypeExternal)
+		}
+		return nil, nil, nil
+	case v1.GeneratorTypeExternal:
+		if cfg.Path == "" {
+			return nil, nil, errors.Errorf("path must be specified if type is %q", v1.GeneratorTypeExternal)
+		}
+		return &conjureplugin.ExternalGenerator{
+			Path: cfg.Path,
+			Args: cfg.Args,
+		}, nil, nil
+	case v1.GeneratorTypeAsset:
+		if cfg.Asset == "" {
+			return nil, nil, errors.Errorf("asset must be specified if type is %q", v1.GeneratorTypeAsset)
+		}
+		if cfg.Path != "" || len(cfg.Args) > 0 {
+			return nil, nil, errors.Errorf("path and args may only be specified if type is %q", v1.GeneratorTypeExternal)
+		}
+		if goOptions := goOnlyOptions(projectCfg); len(goOptions) > 0 {
+			return nil, nil, errors.Errorf("%s only apply to generated Go code and cannot be specified if type is %q", strings.Join(goOptions, ", "), v1.GeneratorTypeAsset)
+		}
+		return nil, &conjureplugin.GeneratorAsset{
+			Name: cfg.Asset,
+		}, nil
+	default:
+		return nil, nil, errors.Errorf("type must be %q, %q or %q, was %q", v1.GeneratorTypeBuiltin, v1.GeneratorTypeExternal, v1.GeneratorTypeAsset, cfg.Type)
+	}
+}
+
+// goOnlyOptions returns the names of the options specified by the provided project configuration that only apply to
+// generated Go code.
+func goOnlyOptions(cfg v1.SingleConjureConfig) []string {
+	var out []string
+	for _, option := range []struct {
+		name      string
+		specified bool
+	}{
+		{"server", cfg.Server},
+		{"cli", cfg.CLI},
+		{"accept-funcs", cfg.AcceptFuncs != nil},
+		{"frozen", cfg.Frozen},
+		{"endpoint-constants", cfg.EndpointConstants},
+		{"routes-file", cfg.RoutesFile != ""},
+		{"client-options", cfg.ClientOptions},
+		{"client-constructors", len(cfg.ClientConstructors) > 0},
+		{"readme", cfg.Readme},
+		{"format", cfg.Format != ""},
+		{"build-tags", len(cfg.BuildTags) > 0},
+		{"header", cfg.Header != ""},
+		{"yaml-methods", cfg.YAMLMethods != ""},
+		{"package-paths", len(cfg.PackagePaths) > 0},
+		{"standalone-module", cfg.StandaloneModule != nil},
+		{"mocks", cfg.Mocks},
+		{"examples", cfg.Examples},
+	} {
+		if option.specified {
+			out = append(out, option.name)
+		}
+	}
+	return out
+}
+
+// toPostGenerateHooks returns the post-generate hooks of a project, which are the hooks for all projects followed by the
+// hooks of the project.
+func toPostGenerateHooks(pluginCfgs, projectCfgs []v1.PostGenerateHookConfig) ([]conjureplugin.PostGenerateHook, error) {
+	var out []conjureplugin.PostGenerateHook
+	for _, cfg := range append(append([]v1.PostGenerateHookConfig{}, pluginCfgs...), projectCfgs...) {
+		if cfg.Path == "" {
+			return nil, errors.Errorf("every entry must specify a path")
+		}
+		out = append(out, conjureplugin.PostGenerateHook{
+			Path: cfg.Path,
+			Args: cfg.Args,
+		})
+	}
+	return out, nil
+}
+
+// toAssetConfig returns the provided asset configuration with the value for every asset serialized as JSON.
+func toAssetConfig(cfg map[string]interface{}) (map[string][]byte, error) {
+	if len(cfg) == 0 {
+		return nil, nil
+	}
+	out := make(map[string][]byte)
+	for name, val := range cfg {
+		jsonBytes, err := json.Marshal(jsonCompatible(val))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to serialize asset-config for %s as JSON", name)
+		}
+		out[name] = jsonBytes
+	}
+	return out, nil
+}
+
+// jsonCompatible returns the provided value unmarshalled from YAML with every map converted to a map with string keys
+// so that it can be marshalled as JSON.
+func jsonCompatible(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			out[fmt.Sprint(k)] = jsonCompatible(elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = jsonCompatible(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// irProviders returns the IRProviders for the projects with the provided keys. Locators of type "project" are resolved
+// to providers that provide the IR of the referenced project.
+func (c *ConjurePluginConfig) irProviders(keys []string) (map[string]conjureplugin.IRProvider, error) {
+	jreParam, err := managedJREParam(c.ManagedJRE, runtime.GOOS+"-"+runtime.GOARCH)
+	if err != nil {
+		return nil, err
+	}
+	providers := make(map[string]conjureplugin.IRProvider)
+	// resolve returns the provider for the provided key. path contains the keys of the projects whose locators
+	// referenced the project (ending with the project itself) and is used to detect cycles.
+	var resolve func(key string, path []string) (conjureplugin.IRProvider, error)
+	resolve = func(key string, path []string) (conjureplugin.IRProvider, error) {
+		if provider, ok := providers[key]; ok {
+			return provider, nil
+		}
+		currConfig := c.ProjectConfigs[key]
+		if currConfig.Compiler != "" && currConfig.Compiler != v1.CompilerCLI && currConfig.Compiler != v1.CompilerNative {
+			return nil, errors.Errorf("invalid compiler %q for %s: must be %q or %q", currConfig.Compiler, key, v1.CompilerCLI, v1.CompilerNative)
+		}
+		locatorCfg := currConfig.IRLocator
+		if locatorCfg.Type != v1.LocatorTypeProject {
+			var irProviderParams []conjureircli.Param
+			if jreParam != nil {
+				irProviderParams = append(irProviderParams, jreParam)
+			}
+			if env := projectEnv(c.Env, currConfig.Env); len(env) > 0 {
+				irProviderParams = append(irProviderParams, conjureircli.EnvParam(env))
+			}
+			if len(currConfig.CompilerArgs) > 0 {
+				irProviderParams = append(irProviderParams, conjureircli.CompilerArgsParam(currConfig.CompilerArgs...))
+			}
+			if currConfig.Compiler == v1.CompilerNative {
+				irProviderParams = append(irProviderParams, conjureircli.NativeCompilerParam())
+			}
+			if jvmOptions := projectJVMOptions(c.JVMOptions, currConfig.JVMOptions); len(jvmOptions) > 0 {
+				irProviderParams = append(irProviderParams, conjureircli.JVMOptionsParam(jvmOptions...))
+			}
+			provider, err := (*IRLocatorConfig)(&locatorCfg).ToIRProvider(irProviderParams...)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to convert configuration for %s to provider", key)
+			}
+			providers[key] = provider
+			return provider, nil
+		}
+
+		if locatorCfg.Repo != "" || locatorCfg.Ref != "" || locatorCfg.Path != "" || len(locatorCfg.Locators) > 0 || locatorCfg.Auth != nil || locatorCfg.Retry != nil || locatorCfg.CacheTTL != "" || locatorCfg.SHA256 != "" || locatorCfg.Limits != nil {
+			return nil, errors.Errorf("failed to convert configuration for %s to provider: only locator can be specified for locator of type %s", key, v1.LocatorTypeProject)
+		}
+		if _, ok := c.ProjectConfigs[locatorCfg.Locator]; !ok {
+			return nil, errors.Errorf("failed to convert configuration for %s to provider: %q is not a configured project", key, locatorCfg.Locator)
+		}
+		for i, currKey := range path {
+			if currKey == locatorCfg.Locator {
+				return nil, errors.Errorf("locators of type %s form a cycle: %s", v1.LocatorTypeProject, strings.Join(append(path[i:], locatorCfg.Locator), " -> "))
+			}
+		}
+		referencedProvider, err := resolve(locatorCfg.Locator, append(path, locatorCfg.Locator))
+		if err != nil {
+			return nil, err
+		}
+		provider := conjureplugin.NewProjectIRProvider(locatorCfg.Locator, referencedProvider)
+		providers[key] = provider
+		return provider, nil
+	}
+	for _, key := range keys {
+		if _, err := resolve(key, []string{key}); err != nil {
+			return nil, err
+		}
+	}
+	return providers, nil
+}
+
+func toForbiddenPatterns(cfgs []v1.ForbiddenPatternConfig) ([]conjureplugin.ForbiddenPattern, error) {
+	var patterns []conjureplugin.ForbiddenPattern
+	for _, cfg := range cfgs {
+		kind := conjureplugin.ForbiddenPatternKind(cfg.Kind)
+		validKind := false
+		for _, currKind := range conjureplugin.ForbiddenPatternKinds() {
+			validKind = validKind || kind == currKind
+		}
+		if !validKind {
+			return nil, errors.Errorf("unknown kind %q: must be one of %v", cfg.Kind, conjureplugin.ForbiddenPatternKinds())
+		}
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %q", cfg.Pattern)
+		}
+		patterns = append(patterns, conjureplugin.ForbiddenPattern{
+			Kind:    kind,
+			Pattern: pattern,
+			Message: cfg.Message,
+		})
+	}
+	return patterns, nil
+}
+
+var platformRegexp = regexp.MustCompile(`^[a-z0-9]+-[a-z0-9]+$`)
+
+// managedJREParam returns the parameter that runs the Conjure compiler using the JRE configured for the provided
+// platform. Returns nil if no JRE is configured for the platform. Returns an error if the configuration for any platform
+// is invalid.
+func managedJREParam(cfgs map[string]v1.ManagedJREConfig, platform string) (conjureircli.Param, error) {
+	var platforms []string
+	for currPlatform := range cfgs {
+		platforms = append(platforms, currPlatform)
+	}
+	sort.Strings(platforms)
+	for _, currPlatform := range platforms {
+		if !platformRegexp.MatchString(currPlatform) {
+			return nil, errors.Errorf("invalid managed-jre platform %q: must be of the form <GOOS>-<GOARCH>", currPlatform)
+		}
+		cfg := cfgs[currPlatform]
+		if err := (conjureircli.JRE{URL: cfg.URL, SHA256: cfg.SHA256}).Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid managed-jre for %s", currPlatform)
+		}
+	}
+	cfg, ok := cfgs[platform]
+	if !ok {
+		return nil, nil
+	}
+	return conjureircli.JREParam(conjureircli.JRE{
+		URL:    cfg.URL,
+		SHA256: cfg.SHA256,
+	}), nil
+}
+
+// projectJVMOptions returns the JVM options for a project with the provided plugin-level and project-level options.
+// Project-level options are added after plugin-level options so that they take precedence.
+func projectJVMOptions(pluginOpts, projectOpts []string) []string {
+	if len(pluginOpts) == 0 && len(projectOpts) == 0 {
+		return nil
+	}
+	return append(append([]string(nil), pluginOpts...), projectOpts...)
+}
+
+// projectEnv returns the environment for a project with the provided plugin-level and project-level environment
+// variables. Project-level values take precedence, and references to environment variables in values are expanded
+// using the environment of the current process. Returns nil if no environment variables are specified.
+func projectEnv(pluginEnv, projectEnv map[string]string) map[string]string {
+	if len(pluginEnv) == 0 && len(projectEnv) == 0 {
+		return nil
+	}
+	env := make(map[string]string)
+	for _, currEnv := range []map[string]string{pluginEnv, projectEnv} {
+		for k, v := range currEnv {
+			env[k] = os.ExpandEnv(v)
+		}
+	}
+	return env
+}
+
 type SingleConjureConfig v1.SingleConjureConfig
 
 func ToSingleConjureConfig(in *SingleConjureConfig) *v1.SingleConjureConfig {
@@ -85,13 +791,49 @@ func ToIRLocatorConfig(in *IRLocatorConfig) *v1.IRLocatorConfig {
 	return (*v1.IRLocatorConfig)(in)
 }
 
-func (cfg *IRLocatorConfig) ToIRProvider() (conjureplugin.IRProvider, error) {
-	if cfg.Locator == "" {
+// ToIRProvider returns the IRProvider specified by the configuration. The provided params are used for any invocations
+// of the Conjure CLI performed by the provider.
+func (cfg *IRLocatorConfig) ToIRProvider(params ...conjureircli.Param) (conjureplugin.IRProvider, error) {
+	if len(cfg.Locators) > 0 {
+		if cfg.Type != "" && cfg.Type != v1.LocatorTypeAuto && cfg.Type != v1.LocatorTypeYAML {
+			return nil, errors.Errorf("locators can only be specified for locator of type %s", v1.LocatorTypeYAML)
+		}
+		if cfg.Locator != "" {
+			return nil, errors.Errorf("locator and locators cannot both be specified")
+		}
+		for _, locator := range cfg.Locators {
+			if locator == "" {
+				return nil, errors.Errorf("locators cannot contain empty values")
+			}
+		}
+	}
+	if cfg.Type == v1.LocatorTypeProject {
+		return nil, errors.Errorf("locators of type %s can only be resolved as part of the plugin configuration", v1.LocatorTypeProject)
+	}
+	if cfg.Type == v1.LocatorTypeGit {
+		if cfg.Auth != nil || cfg.Retry != nil || cfg.CacheTTL != "" || cfg.SHA256 != "" {
+			return nil, errors.Errorf("auth, retry, cache-ttl and sha256 can only be specified for locator of type %s", v1.LocatorTypeRemote)
+		}
+		if cfg.Limits != nil {
+			return nil, errors.Errorf("limits can only be specified for locator of type %s", v1.LocatorTypeRemote)
+		}
+		if cfg.Repo == "" || cfg.Ref == "" {
+			return nil, errors.Errorf("repo and ref must be specified for locator of type %s", v1.LocatorTypeGit)
+		}
+		// values that start with "-" would be parsed as options by Git
+		if strings.HasPrefix(cfg.Repo, "-") || strings.HasPrefix(cfg.Ref, "-") {
+			return nil, errors.Errorf("repo and ref must not start with \"-\" for locator of type %s", v1.LocatorTypeGit)
+		}
+		return conjureplugin.NewGitIRProvider(cfg.Repo, cfg.Ref, cfg.Path, params...), nil
+	}
+	if cfg.Locator == "" && len(cfg.Locators) == 0 {
 		return nil, errors.Errorf("locator cannot be empty")
 	}
 
 	locatorType := cfg.Type
-	if locatorType == "" || locatorType == v1.LocatorTypeAuto {
+	if len(cfg.Locators) > 0 {
+		locatorType = v1.LocatorTypeYAML
+	} else if locatorType == "" || locatorType == v1.LocatorTypeAuto {
 		if parsedURL, err := url.Parse(cfg.Locator); err == nil && parsedURL.Scheme != "" {
 			// if locator can be parsed as a URL and it has a scheme explicitly specified, assume it is remote
 			locatorType = v1.LocatorTypeRemote
@@ -114,11 +856,57 @@ func (cfg *IRLocatorConfig) ToIRProvider() (conjureplugin.IRProvider, error) {
 		}
 	}
 
+	if cfg.Auth != nil && locatorType != v1.LocatorTypeRemote {
+		return nil, errors.Errorf("auth can only be specified for locator of type %s", v1.LocatorTypeRemote)
+	}
+	if (cfg.Retry != nil || cfg.CacheTTL != "" || cfg.SHA256 != "") && locatorType != v1.LocatorTypeRemote {
+		return nil, errors.Errorf("retry, cache-ttl and sha256 can only be specified for locator of type %s", v1.LocatorTypeRemote)
+	}
+	if cfg.Limits != nil && locatorType != v1.LocatorTypeRemote {
+		return nil, errors.Errorf("limits can only be specified for locator of type %s", v1.LocatorTypeRemote)
+	}
+
 	switch locatorType {
 	case v1.LocatorTypeRemote:
-		return conjureplugin.NewHTTPIRProvider(cfg.Locator), nil
+		httpParams, err := httpIRProviderParams(cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Retry != nil {
+			retryPolicy, err := toRetryPolicy(*cfg.Retry)
+			if err != nil {
+				return nil, err
+			}
+			httpParams = append(httpParams, conjureplugin.HTTPIRProviderRetry(retryPolicy))
+		}
+		if cfg.CacheTTL != "" {
+			cacheTTL, err := time.ParseDuration(cfg.CacheTTL)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid cache-ttl")
+			}
+			httpParams = append(httpParams, conjureplugin.HTTPIRProviderCache(cacheTTL))
+		}
+		if cfg.SHA256 != "" {
+			if !sha256Regexp.MatchString(cfg.SHA256) {
+				return nil, errors.Errorf("sha256 must be a hex-encoded SHA-256 checksum, but was %q", cfg.SHA256)
+			}
+			httpParams = append(httpParams, conjureplugin.HTTPIRProviderSHA256(cfg.SHA256))
+		}
+		if cfg.Limits != nil {
+			if cfg.Limits.MaxBytes < 0 || cfg.Limits.MaxTypes < 0 {
+				return nil, errors.Errorf("limits must be non-negative")
+			}
+			httpParams = append(httpParams, conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+				MaxBytes: cfg.Limits.MaxBytes,
+				MaxTypes: cfg.Limits.MaxTypes,
+			}))
+		}
+		return conjureplugin.NewHTTPIRProvider(cfg.Locator, httpParams...), nil
 	case v1.LocatorTypeYAML:
-		return conjureplugin.NewLocalYAMLIRProvider(cfg.Locator), nil
+		if len(cfg.Locators) > 0 {
+			return conjureplugin.NewLocalYAMLPathsIRProvider(cfg.Locators, params...), nil
+		}
+		return conjureplugin.NewLocalYAMLIRProvider(cfg.Locator, params...), nil
 	case v1.LocatorTypeIRFile:
 		return conjureplugin.NewLocalFileIRProvider(cfg.Locator), nil
 	default:
@@ -126,18 +914,256 @@ func (cfg *IRLocatorConfig) ToIRProvider() (conjureplugin.IRProvider, error) {
 	}
 }
 
+// ReadConfigFromFile returns the configuration in the provided file. Errors are classified as
+// conjureplugin.ErrInvalidConfig.
 func ReadConfigFromFile(f string) (ConjurePluginConfig, error) {
 	bytes, err := ioutil.ReadFile(f)
 	if err != nil {
-		return ConjurePluginConfig{}, errors.WithStack(err)
+		return ConjurePluginConfig{}, conjureplugin.Classify(errors.WithStack(err), conjureplugin.ErrInvalidConfig)
 	}
 	return ReadConfigFromBytes(bytes)
 }
 
+// ReadConfigFromBytes returns the configuration represented by the provided YAML. References to environment variables
+// in string values are expanded (see expandEnvReferences). Errors are classified as conjureplugin.ErrInvalidConfig.
 func ReadConfigFromBytes(inputBytes []byte) (ConjurePluginConfig, error) {
+	inputBytes, err := expandEnvReferences(inputBytes)
+	if err != nil {
+		return ConjurePluginConfig{}, conjureplugin.Classify(err, conjureplugin.ErrInvalidConfig)
+	}
 	var cfg ConjurePluginConfig
 	if err := yaml.UnmarshalStrict(inputBytes, &cfg); err != nil {
-		return ConjurePluginConfig{}, errors.WithStack(err)
+		return ConjurePluginConfig{}, conjureplugin.Classify(errors.WithStack(err), conjureplugin.ErrInvalidConfig)
 	}
 	return cfg, nil
 }
+
+// envReference matches a reference to an environment variable of the form ${VAR} or ${VAR:-default} and the escaped
+// form $${, which is expanded to a literal "${".
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnvReferences returns the provided YAML with the references to environment variables in its string values
+// expanded using the environment of the current process. ${VAR} expands to the value of VAR (or an empty string if it
+// is not set) and ${VAR:-default} expands to the value of VAR or to default if VAR is not set or is empty. A value that
+// consists of a single reference can also be used for boolean and integer fields (see typedEnvValue). Keys and values
+// that are not strings are not expanded. Returns the YAML as-is if it does not contain any references.
+func expandEnvReferences(yamlBytes []byte) ([]byte, error) {
+	if !bytes.Contains(yamlBytes, []byte("${")) {
+		return yamlBytes, nil
+	}
+	var doc interface{}
+	if err := yaml.UnmarshalStrict(yamlBytes, &doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	expandedBytes, err := yaml.Marshal(expandEnvValue(doc, reflect.TypeOf(v1.ConjurePluginConfig{})))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to expand references to environment variables")
+	}
+	return expandedBytes, nil
+}
+
+// expandEnvValue returns the provided value unmarshalled from YAML with the references to environment variables in
+// its string values expanded. typ is the type into which the value is unmarshalled, or nil if it is not known (for
+// example, for the values of free-form configuration such as asset-config).
+func expandEnvValue(val interface{}, typ reflect.Type) interface{} {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		out := make(map[interface{}]interface{}, len(v))
+		for k, elem := range v {
+			out[k] = expandEnvValue(elem, yamlValueType(typ, k))
+		}
+		return out
+	case []interface{}:
+		var elemType reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elemType = typ.Elem()
+		}
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = expandEnvValue(elem, elemType)
+		}
+		return out
+	case string:
+		expanded := envReference.ReplaceAllStringFunc(v, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+			match := envReference.FindStringSubmatch(ref)
+			if val := os.Getenv(match[1]); val != "" || match[2] == "" {
+				return val
+			}
+			return match[3]
+		})
+		if typ == nil || v == "$${" || envReference.FindString(v) != v {
+			return expanded
+		}
+		return typedEnvValue(expanded, typ.Kind())
+	default:
+		return v
+	}
+}
+
+// yamlValueType returns the type into which the value for the provided key of a YAML map that is unmarshalled into
+// the provided type is unmarshalled. Returns nil if the type is not known.
+func yamlValueType(typ reflect.Type, key interface{}) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Map:
+		return typ.Elem()
+	case reflect.Struct:
+		name, ok := key.(string)
+		if !ok {
+			return nil
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			tagParts := strings.Split(field.Tag.Get("yaml"), ",")
+			if len(tagParts) > 1 && tagParts[1] == "inline" {
+				if fieldType := yamlValueType(field.Type, key); fieldType != nil {
+					return fieldType
+				}
+				continue
+			}
+			fieldName := tagParts[0]
+			if fieldName == "" {
+				fieldName = strings.ToLower(field.Name)
+			}
+			if fieldName == name {
+				return field.Type
+			}
+		}
+	}
+	return nil
+}
+
+// typedEnvValue returns the value of a string that consisted of a single reference to an environment variable and is
+// unmarshalled into a value of the provided kind. If the kind is a boolean or an integer, values that are in the
+// canonical form of the kind (for example, "true" or "42") are returned as booleans or integers so that they can be
+// unmarshalled into the field, and an empty value is returned as nil so that it is equivalent to a value that is not
+// specified. All other values are returned as-is.
+func typedEnvValue(val string, kind reflect.Kind) interface{} {
+	switch kind {
+	case reflect.Bool:
+		if val == "" {
+			return nil
+		}
+		if b, err := strconv.ParseBool(val); err == nil && strconv.FormatBool(b) == val {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val == "" {
+			return nil
+		}
+		if i, err := strconv.ParseInt(val, 10, 64); err == nil && strconv.FormatInt(i, 10) == val {
+			return i
+		}
+	}
+	return val
+}
+
+// ReadConfigFromJSON returns the configuration represented by the provided JSON document, which uses the same keys and
+// structure as the YAML configuration (for example, {"projects": {"project-1": {"output-dir": "conjure"}}}). This
+// allows tools that generate configuration programmatically to drive the plugin without writing a configuration file.
+// Errors are classified as conjureplugin.ErrInvalidConfig.
+func ReadConfigFromJSON(inputBytes []byte) (ConjurePluginConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(inputBytes))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return ConjurePluginConfig{}, conjureplugin.Classify(errors.Wrapf(err, "invalid JSON configuration"), conjureplugin.ErrInvalidConfig)
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return ConjurePluginConfig{}, conjureplugin.Classify(errors.Errorf("JSON configuration must be an object"), conjureplugin.ErrInvalidConfig)
+	}
+	// convert the document to YAML so that it is read in the same manner as YAML configuration
+	yamlBytes, err := yaml.Marshal(jsonToYAMLValue(doc))
+	if err != nil {
+		return ConjurePluginConfig{}, conjureplugin.Classify(errors.Wrapf(err, "failed to convert JSON configuration to YAML"), conjureplugin.ErrInvalidConfig)
+	}
+	return ReadConfigFromBytes(yamlBytes)
+}
+
+// jsonToYAMLValue returns the provided decoded JSON value with numbers converted to integers or floating-point values
+// so that they are marshalled as YAML numbers.
+func jsonToYAMLValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			out[k] = jsonToYAMLValue(elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = jsonToYAMLValue(elem)
+		}
+		return out
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// httpIRProviderParams returns the parameters for the provided authentication configuration. References to
+// environment variables in the configured values are expanded.
+func httpIRProviderParams(auth *v1.HTTPAuthConfig) ([]conjureplugin.HTTPIRProviderParam, error) {
+	if auth == nil {
+		return nil, nil
+	}
+	var params []conjureplugin.HTTPIRProviderParam
+	if auth.BearerToken != "" {
+		if auth.Username != "" || auth.Password != "" {
+			return nil, errors.Errorf("auth cannot specify both bearer-token and username/password")
+		}
+		params = append(params, conjureplugin.HTTPIRProviderBearerToken(os.ExpandEnv(auth.BearerToken)))
+	}
+	if auth.Username != "" || auth.Password != "" {
+		params = append(params, conjureplugin.HTTPIRProviderBasicAuth(os.ExpandEnv(auth.Username), os.ExpandEnv(auth.Password)))
+	}
+	var headerKeys []string
+	for k := range auth.Headers {
+		headerKeys = append(headerKeys, k)
+	}
+	sort.Strings(headerKeys)
+	for _, k := range headerKeys {
+		params = append(params, conjureplugin.HTTPIRProviderHeader(k, os.ExpandEnv(auth.Headers[k])))
+	}
+	return params, nil
+}
+
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+func toRetryPolicy(cfg v1.RetryConfig) (conjureplugin.RetryPolicy, error) {
+	policy := conjureplugin.RetryPolicy{
+		MaxAttempts:          cfg.MaxAttempts,
+		RetryableStatusCodes: cfg.RetryableStatusCodes,
+	}
+	if cfg.InitialBackoff != "" {
+		initialBackoff, err := time.ParseDuration(cfg.InitialBackoff)
+		if err != nil {
+			return conjureplugin.RetryPolicy{}, errors.Wrapf(err, "invalid initial-backoff")
+		}
+		policy.InitialBackoff = initialBackoff
+	}
+	if cfg.MaxBackoff != "" {
+		maxBackoff, err := time.ParseDuration(cfg.MaxBackoff)
+		if err != nil {
+			return conjureplugin.RetryPolicy{}, errors.Wrapf(err, "invalid max-backoff")
+		}
+		policy.MaxBackoff = maxBackoff
+	}
+	return policy, nil
+}
@@ -15,11 +15,15 @@
 package config_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config"
 	v1 "github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config/internal/v1"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
@@ -93,6 +97,27 @@ projects:
 		},
 		{
 			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     - conjure/api
+     - conjure/internal
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:     v1.LocatorTypeYAML,
+							Locators: []string{"conjure/api", "conjure/internal"},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
 projects:
  project:
    output-dir: outputDir
@@ -241,6 +266,126 @@ projects:
 				},
 			},
 		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator: local/yaml-dir
+   size-budget:
+     max-files: 10
+     max-total-bytes: 1048576
+     max-file-bytes: 262144
+     warn-only: true
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "local/yaml-dir",
+						},
+						SizeBudget: &v1.SizeBudgetConfig{
+							MaxFiles:      10,
+							MaxTotalBytes: 1048576,
+							MaxFileBytes:  262144,
+							WarnOnly:      true,
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator: local/yaml-dir
+   renamed-from:
+     - old-project
+     - name: older-project
+       output-dir: olderOutputDir
+       publish-relocation-pom: true
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "local/yaml-dir",
+						},
+						RenamedFrom: []v1.RenamedFromConfig{
+							{
+								Name: "old-project",
+							},
+							{
+								Name:                 "older-project",
+								OutputDir:            "olderOutputDir",
+								PublishRelocationPOM: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: git
+     repo: https://github.com/palantir/conjure-api.git
+     ref: 1.0.0
+     path: conjure
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type: v1.LocatorTypeGit,
+							Repo: "https://github.com/palantir/conjure-api.git",
+							Ref:  "1.0.0",
+							Path: "conjure",
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: remote
+     locator: https://artifactory.example.com/ir.json
+     auth:
+       bearer-token: ${ARTIFACTORY_TOKEN}
+       headers:
+         X-Client: godel
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeRemote,
+							Locator: "https://artifactory.example.com/ir.json",
+							Auth: &v1.HTTPAuthConfig{
+								BearerToken: "${ARTIFACTORY_TOKEN}",
+								Headers: map[string]string{
+									"X-Client": "godel",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	} {
 		var got config.ConjurePluginConfig
 		err := yaml.Unmarshal([]byte(tc.in), &got)
@@ -249,6 +394,156 @@ projects:
 	}
 }
 
+func TestReadConfigFromJSON(t *testing.T) {
+	// JSON configuration is commonly indented using tabs, which are not valid YAML indentation
+	got, err := config.ReadConfigFromJSON([]byte(`{
+	"version": 1,
+	"projects": {
+		"project": {
+			"output-dir": "outputDir",
+			"ir-locator": {"type": "yaml", "locator": "local/yaml-dir"},
+			"server": true,
+			"size-budget": {"max-total-bytes": 1000000}
+		}
+	}
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, "outputDir", got.ProjectConfigs["project"].OutputDir)
+	assert.Equal(t, v1.IRLocatorConfig{
+		Type:    v1.LocatorTypeYAML,
+		Locator: "local/yaml-dir",
+	}, got.ProjectConfigs["project"].IRLocator)
+	assert.True(t, got.ProjectConfigs["project"].Server)
+	require.NotNil(t, got.ProjectConfigs["project"].SizeBudget)
+	assert.EqualValues(t, 1000000, got.ProjectConfigs["project"].SizeBudget.MaxTotalBytes)
+
+	for i, tc := range []struct {
+		in      string
+		wantErr string
+	}{
+		{`projects: {}`, "invalid JSON configuration"},
+		{`[]`, "JSON configuration must be an object"},
+		{`{"projects": {"project": {"unknown-key": true}}}`, "field unknown-key not found"},
+	} {
+		_, err := config.ReadConfigFromJSON([]byte(tc.in))
+		require.Error(t, err, "Case %d", i)
+		assert.Contains(t, err.Error(), tc.wantErr, "Case %d", i)
+		assert.True(t, errors.Is(err, conjureplugin.ErrInvalidConfig), "Case %d", i)
+	}
+}
+
+func TestReadConfigExpandsEnvReferences(t *testing.T) {
+	t.Setenv("TEST_IR_HOST", "https://ir.example.com")
+	t.Setenv("TEST_EMPTY", "")
+	got, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: ${TEST_OUTPUT_DIR:-outputDir}
+    ir-locator: ${TEST_IR_HOST}/ir.json
+    extensions-file: ${TEST_EMPTY:-api-metadata.json}
+    publish-properties:
+      unset: prefix-${TEST_UNSET}
+      escaped: $${TEST_IR_HOST}
+      template: '{{ env "TEST_IR_HOST" }}'
+    forbidden-patterns:
+      - kind: type-name
+        pattern: com\.example\..*$
+`))
+	require.NoError(t, err)
+	projectCfg := got.ProjectConfigs["project-1"]
+	assert.Equal(t, "outputDir", projectCfg.OutputDir)
+	assert.Equal(t, "https://ir.example.com/ir.json", projectCfg.IRLocator.Locator)
+	assert.Equal(t, "api-metadata.json", projectCfg.ExtensionsFile)
+	assert.Equal(t, map[string]string{
+		"unset":    "prefix-",
+		"escaped":  "${TEST_IR_HOST}",
+		"template": `{{ env "TEST_IR_HOST" }}`,
+	}, projectCfg.PublishProperties)
+	require.Len(t, projectCfg.ForbiddenPatterns, 1)
+	assert.Equal(t, `com\.example\..*$`, projectCfg.ForbiddenPatterns[0].Pattern)
+}
+
+func TestReadConfigExpandsEnvReferencesInTypedFields(t *testing.T) {
+	t.Setenv("TEST_PUBLISH", "false")
+	t.Setenv("TEST_MAX_BYTES", "1024")
+	t.Setenv("TEST_MAX_ATTEMPTS", "3")
+	t.Setenv("TEST_EMPTY", "")
+	t.Setenv("TEST_OUTPUT_DIR", "0123")
+	t.Setenv("TEST_INVALID_BOOL", "yes")
+	got, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: ${TEST_OUTPUT_DIR}
+    ir-locator:
+      type: remote
+      locator: https://ir.example.com/ir.json
+      retry:
+        max-attempts: ${TEST_MAX_ATTEMPTS}
+      limits:
+        max-bytes: ${TEST_MAX_BYTES:-0}
+    publish: ${TEST_PUBLISH}
+    accept-funcs: ${TEST_EMPTY}
+    publish-properties:
+      bool: ${TEST_PUBLISH}
+      int: ${TEST_MAX_BYTES}
+`))
+	require.NoError(t, err)
+	projectCfg := got.ProjectConfigs["project-1"]
+	assert.Equal(t, "0123", projectCfg.OutputDir)
+	require.NotNil(t, projectCfg.Publish)
+	assert.False(t, *projectCfg.Publish)
+	assert.Nil(t, projectCfg.AcceptFuncs)
+	require.NotNil(t, projectCfg.IRLocator.Retry)
+	assert.Equal(t, 3, projectCfg.IRLocator.Retry.MaxAttempts)
+	require.NotNil(t, projectCfg.IRLocator.Limits)
+	assert.Equal(t, int64(1024), projectCfg.IRLocator.Limits.MaxBytes)
+	assert.Equal(t, map[string]string{
+		"bool": "false",
+		"int":  "1024",
+	}, projectCfg.PublishProperties)
+
+	// values in free-form configuration are not converted
+	t.Setenv("TEST_BUILD_NUMBER", "1")
+	got, err = config.ReadConfigFromBytes([]byte(`
+version: 1
+asset-config:
+  my-asset:
+    extensions:
+      build: ${TEST_BUILD_NUMBER}
+      quoted: "${TEST_BUILD_NUMBER}"
+      enabled: ${TEST_PUBLISH}
+      empty: ${TEST_EMPTY}
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: ir.json
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"my-asset": map[interface{}]interface{}{
+			"extensions": map[interface{}]interface{}{
+				"build":   "1",
+				"quoted":  "1",
+				"enabled": "false",
+				"empty":   "",
+			},
+		},
+	}, got.AssetConfig)
+
+	// values that are not in the canonical form of a boolean are not converted
+	_, err = config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: ir.json
+    publish: ${TEST_INVALID_BOOL}
+`))
+	require.Error(t, err)
+}
+
 func TestConjurePluginConfigToParam(t *testing.T) {
 	for i, tc := range []struct {
 		in   config.ConjurePluginConfig
@@ -357,6 +652,66 @@ func TestConjurePluginConfigToParam(t *testing.T) {
 				},
 			},
 		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "input.json",
+						},
+						SizeBudget: &v1.SizeBudgetConfig{
+							MaxFiles: 5,
+						},
+					},
+				},
+			},
+			conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{
+					"project-1",
+				},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:   "outputDir",
+						IRProvider:  conjureplugin.NewLocalFileIRProvider("input.json"),
+						AcceptFuncs: true,
+						SizeBudget: &conjureplugin.SizeBudget{
+							MaxFiles: 5,
+						},
+					},
+				},
+			},
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type: v1.LocatorTypeGit,
+							Repo: "https://github.com/palantir/conjure-api.git",
+							Ref:  "1.0.0",
+							Path: "conjure",
+						},
+						Publish: boolPtr(true),
+					},
+				},
+			},
+			conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{
+					"project-1",
+				},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:   "outputDir",
+						IRProvider:  conjureplugin.NewGitIRProvider("https://github.com/palantir/conjure-api.git", "1.0.0", "conjure"),
+						AcceptFuncs: true,
+						Publish:     true,
+					},
+				},
+			},
+		},
 	} {
 		got, err := tc.in.ToParams()
 		require.NoError(t, err, "Case %d", i)
@@ -364,6 +719,1839 @@ func TestConjurePluginConfigToParam(t *testing.T) {
 	}
 }
 
-func boolPtr(in bool) *bool {
-	return &in
+func TestConjurePluginConfigToParamPublish(t *testing.T) {
+	for i, tc := range []struct {
+		name      string
+		irLocator string
+		publish   string
+		want      bool
+	}{
+		{"YAML is published by default", "local/yaml-dir", "", true},
+		{"IR file is not published by default", "local/ir.json", "", false},
+		{"explicit true publishes IR file", "local/ir.json", "publish: true", true},
+		{"explicit false does not publish YAML", "local/yaml-dir", "publish: false", false},
+		{"explicit true publishes YAML", "local/yaml-dir", "publish: true", true},
+		{"explicit false does not publish IR file", "local/ir.json", "publish: false", false},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: ` + tc.irLocator + `
+    ` + tc.publish + `
+`))
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		got, err := cfg.ToParams()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.want, got.Params["project-1"].Publish, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestConjurePluginConfigToParamEnv(t *testing.T) {
+	t.Setenv("TEST_CONJURE_PLUGIN_TOKEN", "secret")
+	cfg := config.ConjurePluginConfig{
+		Env: map[string]string{
+			"PLUGIN_VAR":   "plugin",
+			"OVERRIDE_VAR": "plugin",
+		},
+		ProjectConfigs: map[string]v1.SingleConjureConfig{
+			"project-1": {
+				OutputDir: "outputDir",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeIRFile,
+					Locator: "input.json",
+				},
+				Env: map[string]string{
+					"OVERRIDE_VAR": "project",
+					"TOKEN":        "token-${TEST_CONJURE_PLUGIN_TOKEN}",
+				},
+			},
+			"project-2": {
+				OutputDir: "outputDir2",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeIRFile,
+					Locator: "input.json",
+				},
+			},
+		},
+	}
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLUGIN_VAR":   "plugin",
+		"OVERRIDE_VAR": "project",
+		"TOKEN":        "token-secret",
+	}, got.Params["project-1"].Env)
+	assert.Equal(t, map[string]string{
+		"PLUGIN_VAR":   "plugin",
+		"OVERRIDE_VAR": "plugin",
+	}, got.Params["project-2"].Env)
+}
+
+func TestConjurePluginConfigToParamCompilerArgs(t *testing.T) {
+	cfg := config.ConjurePluginConfig{
+		ProjectConfigs: map[string]v1.SingleConjureConfig{
+			"project-1": {
+				OutputDir: "outputDir",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeYAML,
+					Locator: "conjure",
+				},
+				CompilerArgs: []string{"--requireSafety"},
+			},
+			"project-2": {
+				OutputDir: "outputDir2",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeYAML,
+					Locator: "conjure",
+				},
+			},
+		},
+	}
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--requireSafety"}, got.Params["project-1"].CompilerArgs)
+	assert.Nil(t, got.Params["project-2"].CompilerArgs)
+}
+
+func TestConjurePluginConfigToParamCompiler(t *testing.T) {
+	for i, tc := range []struct {
+		name     string
+		compiler v1.Compiler
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "unspecified",
+		},
+		{
+			name:     "native",
+			compiler: v1.CompilerNative,
+			want:     "native",
+		},
+		{
+			name:     "invalid",
+			compiler: "javac",
+			wantErr:  `invalid compiler "javac" for project-1: must be "cli" or "native"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeYAML,
+							Locator: "conjure",
+						},
+						Compiler: tc.compiler,
+					},
+				},
+			}
+			got, err := cfg.ToParams()
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+				return
+			}
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, tc.want, got.Params["project-1"].Compiler, "Case %d", i)
+		})
+	}
+}
+
+func TestConjurePluginConfigToParamParallelism(t *testing.T) {
+	cfg := config.ConjurePluginConfig{
+		Parallelism: 4,
+		ProjectConfigs: map[string]v1.SingleConjureConfig{
+			"project-1": {
+				OutputDir: "outputDir",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeYAML,
+					Locator: "conjure",
+				},
+			},
+		},
+	}
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, 4, got.Parallelism)
+	assert.Equal(t, 4, got.Subset([]string{"project-1"}).Parallelism)
+	assert.False(t, got.Incremental)
+
+	cfg.Parallelism = -1
+	_, err = cfg.ToParams()
+	assert.EqualError(t, err, "parallelism must be non-negative, was -1")
+}
+
+func TestConjurePluginConfigToParamIncremental(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+incremental: true
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: local/yaml-dir
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.True(t, got.Incremental)
+	assert.True(t, got.Subset([]string{"project-1"}).Incremental)
+}
+
+func TestConjurePluginConfigToParamJVMOptions(t *testing.T) {
+	cfg := config.ConjurePluginConfig{
+		JVMOptions: []string{"-Xmx1g"},
+		ProjectConfigs: map[string]v1.SingleConjureConfig{
+			"project-1": {
+				OutputDir: "outputDir",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeYAML,
+					Locator: "conjure",
+				},
+				JVMOptions: []string{"-Xmx2g", "-XX:+UseSerialGC"},
+			},
+			"project-2": {
+				OutputDir: "outputDir2",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeYAML,
+					Locator: "conjure",
+				},
+			},
+		},
+	}
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-Xmx1g", "-Xmx2g", "-XX:+UseSerialGC"}, got.Params["project-1"].JVMOptions)
+	assert.Equal(t, []string{"-Xmx1g"}, got.Params["project-2"].JVMOptions)
+}
+
+func TestConjurePluginConfigToParamManagedJRE(t *testing.T) {
+	validSHA256 := strings.Repeat("a", 64)
+	for i, tc := range []struct {
+		name       string
+		managedJRE map[string]v1.ManagedJREConfig
+		wantErr    string
+	}{
+		{
+			name: "valid configuration",
+			managedJRE: map[string]v1.ManagedJREConfig{
+				"linux-amd64":   {URL: "https://example.com/jre-linux-x64.tar.gz", SHA256: validSHA256},
+				"darwin-arm64":  {URL: "https://example.com/jre-mac-aarch64.tar.gz", SHA256: validSHA256},
+				"windows-amd64": {URL: "https://example.com/jre-windows-x64.zip", SHA256: validSHA256},
+			},
+		},
+		{
+			name: "invalid platform",
+			managedJRE: map[string]v1.ManagedJREConfig{
+				"linux": {URL: "https://example.com/jre.tar.gz", SHA256: validSHA256},
+			},
+			wantErr: `invalid managed-jre platform "linux": must be of the form <GOOS>-<GOARCH>`,
+		},
+		{
+			name: "missing checksum",
+			managedJRE: map[string]v1.ManagedJREConfig{
+				"linux-amd64": {URL: "https://example.com/jre.tar.gz"},
+			},
+			wantErr: `invalid managed-jre for linux-amd64: sha256 "" must be a hex-encoded SHA-256 checksum`,
+		},
+		{
+			name: "unsupported archive",
+			managedJRE: map[string]v1.ManagedJREConfig{
+				"windows-amd64": {URL: "https://example.com/jre.7z", SHA256: validSHA256},
+			},
+			wantErr: "invalid managed-jre for windows-amd64: url https://example.com/jre.7z must refer to a .tar.gz, .tgz or .zip archive",
+		},
+	} {
+		cfg := config.ConjurePluginConfig{
+			ManagedJRE: tc.managedJRE,
+			ProjectConfigs: map[string]v1.SingleConjureConfig{
+				"project-1": {
+					OutputDir: "outputDir",
+					IRLocator: v1.IRLocatorConfig{
+						Type:    v1.LocatorTypeYAML,
+						Locator: "conjure",
+					},
+				},
+			},
+		}
+		_, err := cfg.ToParams()
+		if tc.wantErr == "" {
+			assert.NoError(t, err, "Case %d: %s", i, tc.name)
+			continue
+		}
+		assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestIRLocatorConfigAuth(t *testing.T) {
+	t.Setenv("TEST_CONJURE_PLUGIN_PASSWORD", "secret")
+	for i, tc := range []struct {
+		in      v1.IRLocatorConfig
+		want    conjureplugin.IRProvider
+		wantErr string
+	}{
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				Auth: &v1.HTTPAuthConfig{
+					Username: "user",
+					Password: "${TEST_CONJURE_PLUGIN_PASSWORD}",
+					Headers: map[string]string{
+						"X-Client": "godel",
+					},
+				},
+			},
+			conjureplugin.NewHTTPIRProvider("https://artifactory.example.com/ir.json",
+				conjureplugin.HTTPIRProviderBasicAuth("user", "secret"),
+				conjureplugin.HTTPIRProviderHeader("X-Client", "godel"),
+			),
+			"",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				Retry: &v1.RetryConfig{
+					MaxAttempts:    3,
+					InitialBackoff: "500ms",
+				},
+			},
+			conjureplugin.NewHTTPIRProvider("https://artifactory.example.com/ir.json",
+				conjureplugin.HTTPIRProviderRetry(conjureplugin.RetryPolicy{
+					MaxAttempts:    3,
+					InitialBackoff: 500 * time.Millisecond,
+				}),
+			),
+			"",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				Retry: &v1.RetryConfig{
+					MaxBackoff: "10",
+				},
+			},
+			nil,
+			`invalid max-backoff: time: missing unit in duration "10"`,
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				SHA256:  "abc",
+			},
+			nil,
+			`sha256 must be a hex-encoded SHA-256 checksum, but was "abc"`,
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				Limits: &v1.IRLimitsConfig{
+					MaxBytes: 1024,
+					MaxTypes: 10,
+				},
+			},
+			conjureplugin.NewHTTPIRProvider(
+				"https://artifactory.example.com/ir.json",
+				conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+					MaxBytes: 1024,
+					MaxTypes: 10,
+				}),
+			),
+			"",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				Limits: &v1.IRLimitsConfig{
+					MaxTypes: -1,
+				},
+			},
+			nil,
+			"limits must be non-negative",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeIRFile,
+				Locator: "local/ir.json",
+				Limits: &v1.IRLimitsConfig{
+					MaxBytes: 1024,
+				},
+			},
+			nil,
+			"limits can only be specified for locator of type remote",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeRemote,
+				Locator: "https://artifactory.example.com/ir.json",
+				Auth: &v1.HTTPAuthConfig{
+					BearerToken: "token",
+					Username:    "user",
+				},
+			},
+			nil,
+			"auth cannot specify both bearer-token and username/password",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:    v1.LocatorTypeAuto,
+				Locator: "local/ir.json",
+				Auth: &v1.HTTPAuthConfig{
+					BearerToken: "token",
+				},
+			},
+			nil,
+			"auth can only be specified for locator of type remote",
+		},
+	} {
+		got, err := (*config.IRLocatorConfig)(&tc.in).ToIRProvider()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got, "Case %d", i)
+	}
+}
+
+func TestIRLocatorConfigLocators(t *testing.T) {
+	for i, tc := range []struct {
+		in      v1.IRLocatorConfig
+		want    conjureplugin.IRProvider
+		wantErr string
+	}{
+		{
+			v1.IRLocatorConfig{
+				Locators: []string{"conjure/api", "conjure/internal.yml"},
+			},
+			conjureplugin.NewLocalYAMLPathsIRProvider([]string{"conjure/api", "conjure/internal.yml"}),
+			"",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:     v1.LocatorTypeIRFile,
+				Locators: []string{"ir.json"},
+			},
+			nil,
+			"locators can only be specified for locator of type yaml",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type:     v1.LocatorTypeYAML,
+				Locator:  "conjure/api",
+				Locators: []string{"conjure/internal"},
+			},
+			nil,
+			"locator and locators cannot both be specified",
+		},
+		{
+			v1.IRLocatorConfig{
+				Type: v1.LocatorTypeGit,
+				Repo: "--upload-pack=touch /tmp/pwned",
+				Ref:  "master",
+			},
+			nil,
+			`repo and ref must not start with "-" for locator of type git`,
+		},
+		{
+			v1.IRLocatorConfig{
+				Type: v1.LocatorTypeGit,
+				Repo: "https://github.com/palantir/conjure.git",
+				Ref:  "--output=/tmp/pwned",
+			},
+			nil,
+			`repo and ref must not start with "-" for locator of type git`,
+		},
+	} {
+		got, err := (*config.IRLocatorConfig)(&tc.in).ToIRProvider()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamAssetConfig(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+asset-config:
+  backcompat-checker:
+    ignore:
+      - com.example.api.Deprecated
+    strict: true
+    limits:
+      1: one
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"backcompat-checker": []byte(`{"ignore":["com.example.api.Deprecated"],"limits":{"1":"one"},"strict":true}`),
+	}, got.AssetConfig)
+	assert.Equal(t, got.AssetConfig, got.Subset([]string{"project-1"}).AssetConfig)
+}
+
+func TestConjurePluginConfigToParamAssetOptions(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+asset-options:
+  backcompat-checker:
+    optional: true
+  other-checker: {}
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]conjureplugin.AssetOptions{
+		"backcompat-checker": {
+			Optional: true,
+		},
+		"other-checker": {},
+	}, got.AssetOptions)
+	assert.True(t, got.OptionalAsset("backcompat-checker"))
+	assert.False(t, got.OptionalAsset("other-checker"))
+	assert.False(t, got.OptionalAsset("unknown-checker"))
+	assert.Equal(t, got.AssetOptions, got.Subset([]string{"project-1"}).AssetOptions)
+}
+
+func TestConjurePluginConfigToParamReadme(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    readme: true
+  project-2:
+    output-dir: outputDir2
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.True(t, got.Params["project-1"].Readme)
+	assert.False(t, got.Params["project-2"].Readme)
+}
+
+func TestConjurePluginConfigToParamIRSnapshot(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    ir-snapshot: true
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.True(t, got.Params["project-1"].IRSnapshot)
+}
+
+func TestConjurePluginConfigToParamOwners(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    owners:
+      - team-a@company.com
+      - team-b
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team-a@company.com", "team-b"}, got.Params["project-1"].Owners)
+
+	cfg, err = config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    owners:
+      - " "
+`))
+	require.NoError(t, err)
+	_, err = cfg.ToParams()
+	assert.EqualError(t, err, "owners of project-1 must not be blank")
+}
+
+func TestConjurePluginConfigToParamGenerator(t *testing.T) {
+	for i, tc := range []struct {
+		generator string
+		want      *conjureplugin.ExternalGenerator
+		wantErr   string
+	}{
+		{
+			"{type: builtin}",
+			nil,
+			"",
+		},
+		{
+			"{type: external, path: bin/conjure-go, args: [--verbose]}",
+			&conjureplugin.ExternalGenerator{
+				Path: "bin/conjure-go",
+				Args: []string{"--verbose"},
+			},
+			"",
+		},
+		{
+			"{type: external}",
+			nil,
+			`invalid generator for project-1: path must be specified if type is "external"`,
+		},
+		{
+			"{path: bin/conjure-go}",
+			nil,
+			`invalid generator for project-1: path and args may only be specified if type is "external"`,
+		},
+		{
+			"{type: unknown}",
+			nil,
+			`invalid generator for project-1: type must be "builtin", "external" or "asset", was "unknown"`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    generator: ` + tc.generator + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].ExternalGenerator, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamGeneratorAsset(t *testing.T) {
+	for i, tc := range []struct {
+		project string
+		want    *conjureplugin.GeneratorAsset
+		wantErr string
+	}{
+		{
+			"generator: {type: asset, asset: conjure-typescript}",
+			&conjureplugin.GeneratorAsset{
+				Name: "conjure-typescript",
+			},
+			"",
+		},
+		{
+			"generator: {type: asset}",
+			nil,
+			`invalid generator for project-1: asset must be specified if type is "asset"`,
+		},
+		{
+			"generator: {type: external, path: bin/conjure-go, asset: conjure-typescript}",
+			nil,
+			`invalid generator for project-1: asset may only be specified if type is "asset"`,
+		},
+		{
+			"generator: {type: asset, asset: conjure-typescript, path: bin/conjure-typescript}",
+			nil,
+			`invalid generator for project-1: path and args may only be specified if type is "external"`,
+		},
+		{
+			"generator: {type: asset, asset: conjure-typescript}\n    server: true\n    mocks: true",
+			nil,
+			`invalid generator for project-1: server, mocks only apply to generated Go code and cannot be specified if type is "asset"`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    ` + tc.project + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			assert.True(t, errors.Is(err, conjureplugin.ErrInvalidConfig), "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Nil(t, got.Params["project-1"].ExternalGenerator, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].GeneratorAsset, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamFileModePolicy(t *testing.T) {
+	for i, tc := range []struct {
+		policy  string
+		want    conjureplugin.FileModePolicy
+		wantErr string
+	}{
+		{"normalize", conjureplugin.FileModePolicyNormalize, ""},
+		{"report", conjureplugin.FileModePolicyReport, ""},
+		{"unknown", "", `invalid file-mode-policy "unknown" for project-1: must be "ignore", "report" or "normalize"`},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    file-mode-policy: ` + tc.policy + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].FileModePolicy, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamPublishTarget(t *testing.T) {
+	for i, tc := range []struct {
+		publishTarget string
+		want          *conjureplugin.NexusStaging
+		wantErr       string
+	}{
+		{
+			"{type: artifactory}",
+			nil,
+			"",
+		},
+		{
+			"{type: nexus, nexus: {staging-profile-id: 12ab34, description: Conjure IR, release: true}}",
+			&conjureplugin.NexusStaging{
+				StagingProfileID: "12ab34",
+				Description:      "Conjure IR",
+				Release:          true,
+			},
+			"",
+		},
+		{
+			"{type: nexus}",
+			nil,
+			`invalid publish-target: nexus.staging-profile-id must be specified if type is "nexus"`,
+		},
+		{
+			"{nexus: {staging-profile-id: 12ab34}}",
+			nil,
+			`invalid publish-target: nexus may only be specified if type is "nexus"`,
+		},
+		{
+			"{type: unknown}",
+			nil,
+			`invalid publish-target: type must be "artifactory", "nexus", "asset" or "maven-local", was "unknown"`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+publish-target: ` + tc.publishTarget + `
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.NexusStaging, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamPublisherAsset(t *testing.T) {
+	for i, tc := range []struct {
+		publishTarget string
+		renamedFrom   string
+		want          *conjureplugin.PublisherAsset
+		wantErr       string
+	}{
+		{
+			publishTarget: "{type: asset, asset: ir-catalog}",
+			renamedFrom:   "[old-name]",
+			want: &conjureplugin.PublisherAsset{
+				Name: "ir-catalog",
+			},
+		},
+		{
+			publishTarget: "{type: asset}",
+			renamedFrom:   "[]",
+			wantErr:       `invalid publish-target: asset must be specified if type is "asset"`,
+		},
+		{
+			publishTarget: "{type: nexus, nexus: {staging-profile-id: 12ab34}, asset: ir-catalog}",
+			renamedFrom:   "[]",
+			wantErr:       `invalid publish-target: asset may only be specified if type is "asset"`,
+		},
+		{
+			publishTarget: "{type: asset, asset: ir-catalog}",
+			renamedFrom:   "[{name: old-name, publish-relocation-pom: true}]",
+			wantErr:       `publish-relocation-pom cannot be specified for project-1 if the publish-target type is "asset"`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+publish-target: ` + tc.publishTarget + `
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    renamed-from: ` + tc.renamedFrom + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Nil(t, got.NexusStaging, "Case %d", i)
+		assert.Equal(t, tc.want, got.PublisherAsset, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamMavenLocal(t *testing.T) {
+	for i, tc := range []struct {
+		publishTarget string
+		want          *conjureplugin.MavenLocal
+		wantErr       string
+	}{
+		{
+			publishTarget: "{type: maven-local}",
+			want:          &conjureplugin.MavenLocal{},
+		},
+		{
+			publishTarget: "{type: maven-local, maven-local: {repository: build/m2}}",
+			want: &conjureplugin.MavenLocal{
+				Repository: "build/m2",
+			},
+		},
+		{
+			publishTarget: "{type: artifactory}",
+		},
+		{
+			publishTarget: "{type: asset, asset: ir-catalog, maven-local: {repository: build/m2}}",
+			wantErr:       `invalid publish-target: maven-local may only be specified if type is "maven-local"`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+publish-target: ` + tc.publishTarget + `
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.MavenLocal, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamPostRun(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+post-run:
+  - path: scripts/upload-cache.sh
+    args: [--bucket, conjure]
+  - path: notify
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, []conjureplugin.PostRunHook{
+		{Path: "scripts/upload-cache.sh", Args: []string{"--bucket", "conjure"}},
+		{Path: "notify"},
+	}, got.PostRunHooks)
+
+	cfg, err = config.ReadConfigFromBytes([]byte(`
+version: 1
+post-run:
+  - args: [--bucket, conjure]
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	_, err = cfg.ToParams()
+	assert.EqualError(t, err, "post-run entry 0 must specify a path")
+}
+
+func TestConjurePluginConfigToParamPostGenerate(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+post-generate:
+  - path: go
+    args: [build, ./...]
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    post-generate:
+      - path: scripts/lint.sh
+        args: ["{{OutputDir}}"]
+  project-2:
+    output-dir: outputDir2
+    ir-locator: input.json
+  project-3:
+    output-dir: outputDir3
+    ir-locator: input.json
+    post-generate:
+      - args: [--missing-path]
+`))
+	require.NoError(t, err)
+	_, err = cfg.ToParams()
+	assert.EqualError(t, err, "invalid post-generate for project-3: every entry must specify a path")
+
+	delete(cfg.ProjectConfigs, "project-3")
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, []conjureplugin.PostGenerateHook{
+		{Path: "go", Args: []string{"build", "./..."}},
+		{Path: "scripts/lint.sh", Args: []string{"{{OutputDir}}"}},
+	}, got.Params["project-1"].PostGenerate)
+	assert.Equal(t, []conjureplugin.PostGenerateHook{
+		{Path: "go", Args: []string{"build", "./..."}},
+	}, got.Params["project-2"].PostGenerate)
+}
+
+func TestConjurePluginConfigToParamFormat(t *testing.T) {
+	for i, tc := range []struct {
+		format  string
+		want    conjureplugin.Formatter
+		wantErr string
+	}{
+		{"goimports", conjureplugin.FormatterGoimports, ""},
+		{"gofumpt", conjureplugin.FormatterGofumpt, ""},
+		{"gofmt", "", `invalid format "gofmt" for project-1: must be "goimports" or "gofumpt"`},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    format: ` + tc.format + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].Format, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamBuildTags(t *testing.T) {
+	for i, tc := range []struct {
+		buildTags string
+		want      []string
+		wantErr   string
+	}{
+		{`[conjure_generated, "!codeanalysis"]`, []string{"conjure_generated", "!codeanalysis"}, ""},
+		{`["linux || darwin"]`, []string{"linux || darwin"}, ""},
+		{`["foo bar"]`, nil, `invalid build-tags for project-1: invalid build tag expression "foo bar": unexpected token bar`},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    build-tags: ` + tc.buildTags + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].BuildTags, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamHeader(t *testing.T) {
+	for i, tc := range []struct {
+		header  string
+		want    conjureplugin.Header
+		wantErr string
+	}{
+		{`"Code generated by conjure-plugin {{Version}}; DO NOT EDIT."`, "Code generated by conjure-plugin {{Version}}; DO NOT EDIT.", ""},
+		{`"Copyright Example\n\nCode generated from {{Project}} ({{IRHash}}). DO NOT EDIT."`, "Copyright Example\n\nCode generated from {{Project}} ({{IRHash}}). DO NOT EDIT.", ""},
+		{`"Generated by conjure-plugin {{Version}}"`, "", `invalid header for project-1: must contain a line of the form "Code generated ... DO NOT EDIT."`},
+		{`"Code generated by {{Unknown}}; DO NOT EDIT."`, "", `invalid header for project-1: failed to parse header template: template: header:1: function "Unknown" not defined`},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    header: ` + tc.header + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].Header, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamYAMLMethods(t *testing.T) {
+	for i, tc := range []struct {
+		yamlMethods string
+		want        conjureplugin.YAMLMethods
+		wantErr     string
+	}{
+		{"safeyaml", conjureplugin.YAMLMethodsSafeYAML, ""},
+		{"yaml.v3", conjureplugin.YAMLMethodsYAMLV3, ""},
+		{"none", conjureplugin.YAMLMethodsNone, ""},
+		{"yaml.v2", "", `invalid yaml-methods "yaml.v2" for project-1: must be "safeyaml", "yaml.v3" or "none"`},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    yaml-methods: ` + tc.yamlMethods + `
+`))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].YAMLMethods, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamFilter(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    filter:
+      include-services: [FooService, com.example.BarService]
+      exclude-types: [Unused]
+      exclude-packages: [com.example.internal]
+  project-2:
+    output-dir: outputDir
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.Equal(t, &conjureplugin.DefinitionFilter{
+		IncludeServices: []string{"FooService", "com.example.BarService"},
+		ExcludeTypes:    []string{"Unused"},
+		ExcludePackages: []string{"com.example.internal"},
+	}, got.Params["project-1"].Filter)
+	assert.Nil(t, got.Params["project-2"].Filter)
+}
+
+func TestConjurePluginConfigToParamPackagePaths(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			in: `
+com.palantir.foo: internal/foo/api
+com.palantir.bar.v2: bar
+`,
+			want: map[string]string{
+				"com.palantir.foo":    "internal/foo/api",
+				"com.palantir.bar.v2": "bar",
+			},
+		},
+		{
+			in: `
+com.palantir.foo: ../foo
+`,
+			wantErr: `invalid package-paths for project-1: path "../foo" for com.palantir.foo must be a clean slash-separated path within the output directory`,
+		},
+		{
+			in: `
+com.palantir.foo: /foo
+`,
+			wantErr: `invalid package-paths for project-1: path "/foo" for com.palantir.foo must be a clean slash-separated path within the output directory`,
+		},
+		{
+			in: `
+com.palantir.foo: foo//api
+`,
+			wantErr: `invalid package-paths for project-1: path "foo//api" for com.palantir.foo must be a clean slash-separated path within the output directory`,
+		},
+		{
+			in: `
+com.palantir..foo: foo
+`,
+			wantErr: `invalid package-paths for project-1: "com.palantir..foo" is not a valid Conjure package`,
+		},
+	} {
+		var packagePaths map[string]string
+		require.NoError(t, yaml.Unmarshal([]byte(tc.in), &packagePaths), "Case %d", i)
+		cfg := config.ConjurePluginConfig{
+			ProjectConfigs: map[string]v1.SingleConjureConfig{
+				"project-1": {
+					OutputDir: "outputDir",
+					IRLocator: v1.IRLocatorConfig{
+						Type:    v1.LocatorTypeIRFile,
+						Locator: "input.json",
+					},
+					PackagePaths: packagePaths,
+				},
+			},
+		}
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].PackagePaths, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamStandaloneModule(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		want    *conjureplugin.StandaloneModule
+		wantErr string
+	}{
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    standalone-module:
+      requires:
+        github.com/palantir/conjure-go-runtime/v2: v2.90.0
+`,
+			want: &conjureplugin.StandaloneModule{
+				Requires: map[string]string{
+					"github.com/palantir/conjure-go-runtime/v2": "v2.90.0",
+				},
+			},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    standalone-module: {}
+`,
+			want: &conjureplugin.StandaloneModule{},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: .
+    ir-locator: input.json
+    standalone-module: {}
+`,
+			wantErr: "invalid standalone-module for project-1: the output directory must not be the project directory",
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    standalone-module:
+      requires:
+        github.com/palantir/pkg/safejson: latest
+`,
+			wantErr: `invalid standalone-module for project-1: version "latest" of github.com/palantir/pkg/safejson is not a valid semantic version`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(tc.in))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].StandaloneModule, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamMocks(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    mocks: true
+  project-2:
+    output-dir: outputDir2
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.True(t, got.Params["project-1"].Mocks)
+	assert.False(t, got.Params["project-2"].Mocks)
+}
+
+func TestConjurePluginConfigToParamExamples(t *testing.T) {
+	cfg, err := config.ReadConfigFromBytes([]byte(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    examples: true
+  project-2:
+    output-dir: outputDir2
+    ir-locator: input.json
+`))
+	require.NoError(t, err)
+	got, err := cfg.ToParams()
+	require.NoError(t, err)
+	assert.True(t, got.Params["project-1"].Examples)
+	assert.False(t, got.Params["project-2"].Examples)
+}
+
+func TestConjurePluginConfigToParamBackCompatBaseline(t *testing.T) {
+	for i, tc := range []struct {
+		baseline string
+		want     *conjureplugin.BackCompatBaseline
+		wantErr  string
+	}{
+		{
+			baseline: "baselines/api-1.2.0.conjure.json",
+			want:     &conjureplugin.BackCompatBaseline{Path: "baselines/api-1.2.0.conjure.json"},
+		},
+		{
+			baseline: "https://example.com/api-1.2.0.conjure.json",
+			want:     &conjureplugin.BackCompatBaseline{URL: "https://example.com/api-1.2.0.conjure.json"},
+		},
+		{
+			baseline: "com.palantir.foo:foo-api:1.2.0",
+			want:     &conjureplugin.BackCompatBaseline{Maven: "com.palantir.foo:foo-api:1.2.0"},
+		},
+		{
+			baseline: "com.palantir.foo:foo-api",
+			wantErr:  `invalid backcompat-baseline for project-1: Maven coordinate "com.palantir.foo:foo-api" must have the form <group>:<artifact>:<version>`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(fmt.Sprintf(`
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    backcompat-baseline: %q
+`, tc.baseline)))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			require.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].BackCompatBaseline, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamBackCompatPolicy(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		want    map[string]conjureplugin.BackCompatPolicy
+		wantErr string
+	}{
+		{
+			in: `
+version: 1
+backcompat-policy:
+  severities:
+    added-required-field: warning
+    removed-endpoint: warning
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    backcompat-policy:
+      severities:
+        removed-endpoint: error
+      fail-on-warnings: true
+  project-2:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			want: map[string]conjureplugin.BackCompatPolicy{
+				"project-1": {
+					Severities: map[string]conjureplugin.BackCompatSeverity{
+						"added-required-field": conjureplugin.BackCompatSeverityWarning,
+						"removed-endpoint":     conjureplugin.BackCompatSeverityError,
+					},
+					FailOnWarnings: true,
+				},
+				"project-2": {
+					Severities: map[string]conjureplugin.BackCompatSeverity{
+						"added-required-field": conjureplugin.BackCompatSeverityWarning,
+						"removed-endpoint":     conjureplugin.BackCompatSeverityWarning,
+					},
+				},
+			},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			want: map[string]conjureplugin.BackCompatPolicy{
+				"project-1": {},
+			},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    backcompat-policy:
+      severities:
+        removed-endpoint: warn
+`,
+			wantErr: `invalid backcompat-policy for project-1: invalid severity for removed-endpoint: severity must be "error" or "warning", was "warn"`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(tc.in))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			require.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		for projectName, want := range tc.want {
+			assert.Equal(t, want, got.Params[projectName].BackCompatPolicy, "Case %d: %s", i, projectName)
+		}
+	}
+}
+
+func TestConjurePluginConfigToParamExtensions(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		want    conjureplugin.ProjectExtensions
+		wantErr string
+	}{
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    extensions:
+      git:
+        key: source
+        fields: [commit, dirty]
+      build:
+        fields: [timestamp, pluginVersion]
+`,
+			want: conjureplugin.ProjectExtensions{
+				Git: &conjureplugin.GitMetadataExtension{
+					Key:    "source",
+					Fields: []conjureplugin.GitMetadataField{conjureplugin.GitMetadataCommit, conjureplugin.GitMetadataDirty},
+				},
+				Build: &conjureplugin.BuildMetadataExtension{
+					Fields: []conjureplugin.BuildMetadataField{conjureplugin.BuildMetadataTimestamp, conjureplugin.BuildMetadataPluginVersion},
+				},
+			},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    extensions:
+      git: {}
+      merge-strategy: deep-merge
+    extensions-file: api-metadata.json
+`,
+			want: conjureplugin.ProjectExtensions{
+				File:          "api-metadata.json",
+				Git:           &conjureplugin.GitMetadataExtension{},
+				MergeStrategy: conjureplugin.ExtensionsMergeDeep,
+			},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    extensions:
+      schema: extensions.schema.json
+`,
+			want: conjureplugin.ProjectExtensions{
+				Schema: "extensions.schema.json",
+			},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			want: conjureplugin.ProjectExtensions{},
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    extensions:
+      git:
+        fields: [sha]
+`,
+			wantErr: `invalid extensions for project-1: invalid git: unknown Git metadata field "sha" (must be one of commit, branch, tag, dirty)`,
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    extensions:
+      build:
+        fields: [time]
+`,
+			wantErr: `invalid extensions for project-1: invalid build: unknown build metadata field "time" (must be one of timestamp, ciJobUrl, pluginVersion, conjureVersion)`,
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    extensions:
+      merge-strategy: last-wins
+`,
+			wantErr: `invalid extensions for project-1: unknown merge strategy "last-wins" (must be one of override, first-wins, deep-merge, error)`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(tc.in))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			require.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].Extensions, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamPublishSharded(t *testing.T) {
+	for i, tc := range []struct {
+		in   string
+		want bool
+	}{
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+    publish-sharded: true
+`,
+			want: true,
+		},
+		{
+			in: `
+version: 1
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			want: false,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(tc.in))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].PublishSharded, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamTargetPlatforms(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		want    *conjureplugin.TargetPlatforms
+		wantErr string
+	}{
+		{
+			in: `
+version: 1
+target-platforms:
+  platforms:
+    - linux-amd64
+    - js-wasm
+  warn-only: true
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			want: &conjureplugin.TargetPlatforms{
+				Platforms: []string{"linux-amd64", "js-wasm"},
+				WarnOnly:  true,
+			},
+		},
+		{
+			in: `
+version: 1
+target-platforms: {}
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+		},
+		{
+			in: `
+version: 1
+target-platforms:
+  platforms:
+    - linux
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			wantErr: `invalid target-platforms: platform "linux" must be of the form <GOOS>-<GOARCH>`,
+		},
+		{
+			in: `
+version: 1
+target-platforms:
+  platforms:
+    - beos-amd64
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			wantErr: `invalid target-platforms: platform "beos-amd64" has unknown GOOS "beos"`,
+		},
+		{
+			in: `
+version: 1
+target-platforms:
+  platforms:
+    - linux-amd64
+    - linux-amd64
+projects:
+  project-1:
+    output-dir: outputDir
+    ir-locator: input.json
+`,
+			wantErr: `invalid target-platforms: platform "linux-amd64" is specified multiple times`,
+		},
+	} {
+		cfg, err := config.ReadConfigFromBytes([]byte(tc.in))
+		require.NoError(t, err, "Case %d", i)
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].TargetPlatforms, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamClientConstructors(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		want    map[string]conjureplugin.ClientConstructor
+		wantErr string
+	}{
+		{
+			in: `
+FooService: NewLegacyFooServiceClient
+com.example.BarService:
+  prefix: Conjure
+`,
+			want: map[string]conjureplugin.ClientConstructor{
+				"FooService":             {Name: "NewLegacyFooServiceClient"},
+				"com.example.BarService": {Prefix: "Conjure"},
+			},
+		},
+		{
+			in: `
+FooService:
+  name: NewFoo
+  prefix: Conjure
+`,
+			wantErr: "invalid client-constructors for project-1: exactly one of name and prefix must be specified for FooService",
+		},
+		{
+			in: `
+FooService: newFooClient
+`,
+			wantErr: `invalid client-constructors for project-1: name "newFooClient" for FooService is not a valid exported Go identifier`,
+		},
+		{
+			in: `
+FooService:
+  prefix: Not-Valid
+`,
+			wantErr: `invalid client-constructors for project-1: prefix "Not-Valid" for FooService does not result in a valid Go identifier`,
+		},
+	} {
+		var constructors map[string]v1.ClientConstructorConfig
+		require.NoError(t, yaml.Unmarshal([]byte(tc.in), &constructors), "Case %d", i)
+		cfg := config.ConjurePluginConfig{
+			ProjectConfigs: map[string]v1.SingleConjureConfig{
+				"project-1": {
+					OutputDir: "outputDir",
+					IRLocator: v1.IRLocatorConfig{
+						Type:    v1.LocatorTypeIRFile,
+						Locator: "input.json",
+					},
+					ClientConstructors: constructors,
+				},
+			},
+		}
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.Params["project-1"].ClientConstructors, "Case %d", i)
+	}
+}
+
+func TestConjurePluginConfigToParamProjectLocator(t *testing.T) {
+	for i, tc := range []struct {
+		locators map[string]v1.IRLocatorConfig
+		want     map[string]conjureplugin.IRProvider
+		wantErr  string
+	}{
+		{
+			map[string]v1.IRLocatorConfig{
+				"api":     {Type: v1.LocatorTypeIRFile, Locator: "ir.json"},
+				"api-cli": {Type: v1.LocatorTypeProject, Locator: "api-go"},
+				"api-go":  {Type: v1.LocatorTypeProject, Locator: "api"},
+			},
+			map[string]conjureplugin.IRProvider{
+				"api":     conjureplugin.NewLocalFileIRProvider("ir.json"),
+				"api-cli": conjureplugin.NewProjectIRProvider("api-go", conjureplugin.NewProjectIRProvider("api", conjureplugin.NewLocalFileIRProvider("ir.json"))),
+				"api-go":  conjureplugin.NewProjectIRProvider("api", conjureplugin.NewLocalFileIRProvider("ir.json")),
+			},
+			"",
+		},
+		{
+			map[string]v1.IRLocatorConfig{
+				"api-cli": {Type: v1.LocatorTypeProject, Locator: "api"},
+			},
+			nil,
+			`failed to convert configuration for api-cli to provider: "api" is not a configured project`,
+		},
+		{
+			map[string]v1.IRLocatorConfig{
+				"api-cli": {Type: v1.LocatorTypeProject, Locator: "api"},
+				"api":     {Type: v1.LocatorTypeProject, Locator: "api-cli"},
+			},
+			nil,
+			"locators of type project form a cycle: api -> api-cli -> api",
+		},
+		{
+			map[string]v1.IRLocatorConfig{
+				"api":     {Type: v1.LocatorTypeIRFile, Locator: "ir.json"},
+				"api-cli": {Type: v1.LocatorTypeProject, Locator: "api", SHA256: "abc"},
+			},
+			nil,
+			"failed to convert configuration for api-cli to provider: only locator can be specified for locator of type project",
+		},
+	} {
+		cfg := config.ConjurePluginConfig{
+			ProjectConfigs: make(map[string]v1.SingleConjureConfig),
+		}
+		for name, locator := range tc.locators {
+			cfg.ProjectConfigs[name] = v1.SingleConjureConfig{
+				OutputDir: name,
+				IRLocator: locator,
+			}
+		}
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		for name, want := range tc.want {
+			assert.Equal(t, want, got.Params[name].IRProvider, "Case %d: %s", i, name)
+			assert.False(t, got.Params[name].Publish, "Case %d: %s", i, name)
+		}
+	}
+}
+
+func TestConjurePluginConfigToParamForbiddenPatterns(t *testing.T) {
+	for i, tc := range []struct {
+		pattern v1.ForbiddenPatternConfig
+		wantErr string
+	}{
+		{
+			v1.ForbiddenPatternConfig{Kind: "endpoint-name", Pattern: "Service\\.delete.*"},
+			"",
+		},
+		{
+			v1.ForbiddenPatternConfig{Kind: "field-name", Pattern: "foo"},
+			`invalid forbidden-patterns for project-1: unknown kind "field-name": must be one of [type-name service-name endpoint-name external-type body-param-type header-param-type path-param-type query-param-type]`,
+		},
+		{
+			v1.ForbiddenPatternConfig{Kind: "type-name", Pattern: "("},
+			"invalid forbidden-patterns for project-1: invalid pattern \"(\": error parsing regexp: missing closing ): `(`",
+		},
+	} {
+		cfg := config.ConjurePluginConfig{
+			ProjectConfigs: map[string]v1.SingleConjureConfig{
+				"project-1": {
+					OutputDir: "outputDir",
+					IRLocator: v1.IRLocatorConfig{
+						Type:    v1.LocatorTypeIRFile,
+						Locator: "input.json",
+					},
+					ForbiddenPatterns: []v1.ForbiddenPatternConfig{tc.pattern},
+				},
+			},
+		}
+		got, err := cfg.ToParams()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		require.Len(t, got.Params["project-1"].ForbiddenPatterns, 1, "Case %d", i)
+		assert.Equal(t, tc.pattern.Pattern, got.Params["project-1"].ForbiddenPatterns[0].Pattern.String(), "Case %d", i)
+	}
+}
+
+func boolPtr(in bool) *bool {
+	return &in
+}
+
+func TestVerifyCurrentVersion(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		wantErr string
+	}{
+		{
+			in: `version: 1
+projects: {}
+`,
+		},
+		{
+			in: `projects: {}
+`,
+			wantErr: `configuration must declare "version: 1", but declared version was "": run "./godelw upgrade-config" to upgrade the configuration`,
+		},
+		{
+			in: `legacy-config: true
+conjure-projects: {}
+`,
+			wantErr: `legacy configuration is not supported: run "./godelw upgrade-config" to upgrade the configuration`,
+		},
+	} {
+		err := config.VerifyCurrentVersion([]byte(tc.in))
+		if tc.wantErr == "" {
+			assert.NoError(t, err, "Case %d", i)
+			continue
+		}
+		assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+	}
+}
+
+func TestUpgradeUnversionedConfig(t *testing.T) {
+	in := `projects:
+  project:
+    output-dir: outputDir
+    ir-locator: local/yaml-dir
+`
+	got, err := config.UpgradeConfig([]byte(in))
+	require.NoError(t, err)
+	assert.Equal(t, "version: 1\n"+in, string(got))
+	assert.NoError(t, config.VerifyCurrentVersion(got))
 }
@@ -23,6 +23,164 @@ import (
 type ConjurePluginConfig struct {
 	versionedconfig.ConfigWithVersion `yaml:",inline,omitempty"`
 	ProjectConfigs                    map[string]SingleConjureConfig `yaml:"projects"`
+	// Env specifies environment variables that are set for the processes run for all projects. Values can refer to
+	// environment variables of the plugin process using $VAR or ${VAR}.
+	Env map[string]string `yaml:"env,omitempty"`
+	// JVMOptions specifies options (such as "-Xmx2g") for the JVM that runs the Conjure compiler for all projects.
+	JVMOptions []string `yaml:"jvm-options,omitempty"`
+	// ManagedJRE specifies the JRE that is downloaded and used to run the Conjure compiler instead of the "java" command
+	// on the PATH, keyed by the platform ("<GOOS>-<GOARCH>", such as "linux-amd64"). The "java" command on the PATH is
+	// used on platforms that are not specified.
+	ManagedJRE map[string]ManagedJREConfig `yaml:"managed-jre,omitempty"`
+	// AssetConfig specifies configuration for assets keyed by the name of the asset. The value for an asset is passed
+	// to it (as JSON) when it is invoked.
+	AssetConfig map[string]interface{} `yaml:"asset-config,omitempty"`
+	// AssetOptions specifies the options that the plugin applies to assets keyed by the name of the asset. Unlike
+	// AssetConfig, the options are not passed to the asset.
+	AssetOptions map[string]AssetOptionsConfig `yaml:"asset-options,omitempty"`
+	// Parallelism specifies the maximum number of projects whose IR is computed and whose code is generated
+	// concurrently. Projects are generated sequentially if unspecified.
+	Parallelism int `yaml:"parallelism,omitempty"`
+	// Incremental specifies whether generation is skipped for projects whose IR and generation options have not
+	// changed since their code was last generated or verified (and whose generated files have not been modified).
+	Incremental bool `yaml:"incremental,omitempty"`
+	// PublishTarget specifies the type of repository to which IR is published. If unspecified, IR is published to
+	// Artifactory.
+	PublishTarget *PublishTargetConfig `yaml:"publish-target,omitempty"`
+	// PostRun specifies the commands that are run after all of the projects have been generated or verified (whether
+	// or not they succeeded). Each command receives the summary of the run as JSON on stdin.
+	PostRun []PostRunHookConfig `yaml:"post-run,omitempty"`
+	// PostGenerate specifies the commands that are run after the generated files of every project are written. They
+	// are run before the post-generate commands of the project.
+	PostGenerate []PostGenerateHookConfig `yaml:"post-generate,omitempty"`
+	// TargetPlatforms specifies the platforms for which the repository is built. If specified, generation fails (or
+	// warns) if the generated code of any project is unavailable on any of the platforms.
+	TargetPlatforms *TargetPlatformsConfig `yaml:"target-platforms,omitempty"`
+	// BackCompatPolicy specifies which backwards incompatibilities fail backcompat checks for all projects.
+	BackCompatPolicy *BackCompatPolicyConfig `yaml:"backcompat-policy,omitempty"`
+}
+
+// BackCompatPolicyConfig specifies which backwards incompatibilities fail backcompat checks.
+type BackCompatPolicyConfig struct {
+	// Severities specifies the severity ("error" or "warning") of the breaks in each category keyed by category. The
+	// categories of the breaks found by the built-in checker are the kinds of changes (such as "removed-endpoint"),
+	// the category of changes to frozen projects is "frozen" and the category of the breaks found by a backcompat
+	// asset is the name of the asset. Breaks in categories that are not specified are errors.
+	Severities map[string]string `yaml:"severities,omitempty"`
+	// FailOnWarnings specifies whether breaks whose severity is "warning" fail the check. Defaults to false.
+	FailOnWarnings *bool `yaml:"fail-on-warnings,omitempty"`
+}
+
+// ExtensionsConfig specifies the extensions that are added to the IR of a project when it is published.
+type ExtensionsConfig struct {
+	// Git adds the Git metadata of the project directory (the commit, branch, tag and whether the working tree is
+	// dirty) as an extension. Specify an empty object ("git: {}") to add all of the metadata using the "git" key.
+	Git *GitExtensionConfig `yaml:"git,omitempty"`
+	// Build adds metadata about the build that publishes the IR (the time, the URL of the CI job and the versions of
+	// the plugin and the Conjure CLI) as an extension. Specify an empty object ("build: {}") to add all of the
+	// metadata using the "build" key.
+	Build *BuildExtensionConfig `yaml:"build,omitempty"`
+	// MergeStrategy specifies how extensions with the same key provided by multiple sources (the IR, the extensions
+	// file and the metadata extensions) are merged: "override" (the default, the last source wins), "first-wins",
+	// "deep-merge" (objects are merged recursively) or "error" (merging fails).
+	MergeStrategy string `yaml:"merge-strategy,omitempty"`
+	// Schema is the path of a file (relative to the project directory) that contains a JSON Schema that the merged
+	// extensions must match. If the extensions do not match the schema, the IR is not published.
+	Schema string `yaml:"schema,omitempty"`
+}
+
+// GitExtensionConfig specifies the Git metadata extension.
+type GitExtensionConfig struct {
+	// Key is the key of the extension. Defaults to "git".
+	Key string `yaml:"key,omitempty"`
+	// Fields are the fields of the metadata that are added ("commit", "branch", "tag" and "dirty"). Defaults to all
+	// fields.
+	Fields []string `yaml:"fields,omitempty"`
+}
+
+// BuildExtensionConfig specifies the build metadata extension.
+type BuildExtensionConfig struct {
+	// Key is the key of the extension. Defaults to "build".
+	Key string `yaml:"key,omitempty"`
+	// Fields are the fields of the metadata that are added ("timestamp", "ciJobUrl", "pluginVersion" and
+	// "conjureVersion"). Defaults to all fields.
+	Fields []string `yaml:"fields,omitempty"`
+}
+
+// TargetPlatformsConfig specifies the platforms for which the generated code must build.
+type TargetPlatformsConfig struct {
+	// Platforms are the target platforms of the form "<GOOS>-<GOARCH>" (such as "linux-amd64" or "js-wasm").
+	Platforms []string `yaml:"platforms,omitempty"`
+	// WarnOnly specifies that generated code that is unavailable on a target platform should print a warning rather
+	// than fail.
+	WarnOnly bool `yaml:"warn-only,omitempty"`
+}
+
+// AssetOptionsConfig specifies the options that the plugin applies to an asset.
+type AssetOptionsConfig struct {
+	// Optional specifies that if the asset fails (for example, if it cannot be invoked or exits with an unexpected
+	// status), a warning is printed and the asset is skipped rather than failing the operation. This allows new assets
+	// to be onboarded without blocking builds on their stability.
+	Optional bool `yaml:"optional,omitempty"`
+}
+
+// PostRunHookConfig specifies a command that is run after all of the projects have been generated or verified.
+type PostRunHookConfig struct {
+	// Path is the path of the executable. Relative paths are resolved against the project directory.
+	Path string `yaml:"path,omitempty"`
+	// Args are the arguments provided to the executable.
+	Args []string `yaml:"args,omitempty"`
+}
+
+type PublishTargetType string
+
+const (
+	// PublishTargetTypeArtifactory specifies that IR is published to an Artifactory repository.
+	PublishTargetTypeArtifactory = PublishTargetType("artifactory")
+	// PublishTargetTypeNexus specifies that IR is published to a Nexus staging repository.
+	PublishTargetTypeNexus = PublishTargetType("nexus")
+	// PublishTargetTypeAsset specifies that IR is published by a publisher asset provided to the plugin.
+	PublishTargetTypeAsset = PublishTargetType("asset")
+	// PublishTargetTypeMavenLocal specifies that IR is installed into a local Maven repository.
+	PublishTargetTypeMavenLocal = PublishTargetType("maven-local")
+)
+
+// PublishTargetConfig specifies the type of repository to which IR is published.
+type PublishTargetConfig struct {
+	// Type is the type of the repository: "artifactory" (the default), "nexus", "asset" or "maven-local".
+	Type PublishTargetType `yaml:"type,omitempty"`
+	// Nexus specifies the staging workflow that is used if the type is "nexus".
+	Nexus *NexusConfig `yaml:"nexus,omitempty"`
+	// Asset is the name of the publisher asset that is used if the type is "asset".
+	Asset string `yaml:"asset,omitempty"`
+	// MavenLocal specifies the local repository that is used if the type is "maven-local".
+	MavenLocal *MavenLocalConfig `yaml:"maven-local,omitempty"`
+}
+
+// MavenLocalConfig specifies how IR is installed into a local Maven repository.
+type MavenLocalConfig struct {
+	// Repository is the path of the local repository. Relative paths are resolved against the project directory.
+	// Defaults to "~/.m2/repository".
+	Repository string `yaml:"repository,omitempty"`
+}
+
+// NexusConfig specifies how IR is published to a Nexus staging repository.
+type NexusConfig struct {
+	// StagingProfileID is the ID of the staging profile for which the staging repository is started.
+	StagingProfileID string `yaml:"staging-profile-id,omitempty"`
+	// Description is the description of the staging repository. If unspecified, a description that specifies the
+	// version that is published is used.
+	Description string `yaml:"description,omitempty"`
+	// Release specifies whether the staging repository is released after it is closed.
+	Release bool `yaml:"release,omitempty"`
+}
+
+// ManagedJREConfig specifies a JRE that is downloaded and used to run the Conjure compiler.
+type ManagedJREConfig struct {
+	// URL is the URL of a tar.gz or zip archive of the JRE.
+	URL string `yaml:"url"`
+	// SHA256 is the expected SHA-256 checksum of the archive.
+	SHA256 string `yaml:"sha256"`
 }
 
 type SingleConjureConfig struct {
@@ -39,8 +197,260 @@ type SingleConjureConfig struct {
 	// AcceptFuncs indicates if we will generate lambda based visitor code.
 	// Currently this is behind a feature flag and is subject to change.
 	AcceptFuncs *bool `yaml:"accept-funcs,omitempty"`
+	// SizeBudget optionally specifies limits on the size of the generated code for this project.
+	SizeBudget *SizeBudgetConfig `yaml:"size-budget,omitempty"`
+	// RenamedFrom specifies the previous names of this project.
+	RenamedFrom []RenamedFromConfig `yaml:"renamed-from,omitempty"`
+	// PublishProperties specifies the properties that are set on the artifacts published for this project. Values are
+	// Go templates that can use the "env", "Project" and "Version" functions.
+	PublishProperties map[string]string `yaml:"publish-properties,omitempty"`
+	// PublishSharded specifies that the IR of this project is published as an index artifact and one shard artifact
+	// per Conjure package rather than as a single artifact, which bounds the size of the artifacts of very large IR.
+	PublishSharded bool `yaml:"publish-sharded,omitempty"`
+	// Env specifies environment variables that are set for the processes run for this project (such as the Conjure
+	// compiler). Values can refer to environment variables of the plugin process using $VAR or ${VAR}. Values specified
+	// here take precedence over values specified in the plugin-level "env".
+	Env map[string]string `yaml:"env,omitempty"`
+	// CompilerArgs specifies additional arguments (such as "--requireSafety") that are provided to the "compile"
+	// operation of the Conjure compiler when generating the IR of this project from YAML (including YAML in a git
+	// repository). Ignored if the IR of the project is not compiled by the plugin.
+	CompilerArgs []string `yaml:"compiler-args,omitempty"`
+	// Compiler specifies the compiler used to generate the IR of this project from YAML. If unspecified, CompilerCLI is
+	// used.
+	Compiler Compiler `yaml:"compiler,omitempty"`
+	// JVMOptions specifies options (such as "-Xmx2g") for the JVM that runs the Conjure compiler for this project. The
+	// options are added after the options specified in the plugin-level "jvm-options", so they take precedence.
+	JVMOptions []string `yaml:"jvm-options,omitempty"`
+	// Frozen specifies whether the definitions of the project are frozen. If true, generation and verification fail if
+	// the generated code would change other than in its documentation and backcompat checks fail if the IR changes
+	// other than in its documentation. Intended for APIs in maintenance mode.
+	Frozen bool `yaml:"frozen,omitempty"`
+	// BackCompatBaseline pins the baseline against which backcompat checks check the backwards compatibility of this
+	// project, which is useful for long-lived release branches. It is either the path of an IR file (relative to the
+	// project directory), the URL of an IR file or the Maven coordinate ("<group>:<artifact>:<version>") of published
+	// IR. If unspecified, the baseline specified for the backcompat check is used.
+	BackCompatBaseline string `yaml:"backcompat-baseline,omitempty"`
+	// BackCompatPolicy specifies which backwards incompatibilities of this project fail backcompat checks. The
+	// severities are merged with the severities of the plugin-level "backcompat-policy" (with the severities of this
+	// project taking precedence), and fail-on-warnings overrides the plugin-level value if it is specified.
+	BackCompatPolicy *BackCompatPolicyConfig `yaml:"backcompat-policy,omitempty"`
+	// Extensions specifies the extensions that are added to the IR of the project when it is published.
+	Extensions *ExtensionsConfig `yaml:"extensions,omitempty"`
+	// ExtensionsFile is the path of a JSON or YAML file (relative to the project directory) whose entries are added
+	// to the extensions of the IR of the project when it is published. The file is read as YAML if the path has a
+	// ".yml" or ".yaml" extension and as JSON otherwise.
+	ExtensionsFile string `yaml:"extensions-file,omitempty"`
+	// ForbiddenPatterns specifies definitions that may not appear in the IR of the project. Generation and
+	// verification fail if any definition matches any of the patterns.
+	ForbiddenPatterns []ForbiddenPatternConfig `yaml:"forbidden-patterns,omitempty"`
+	// EndpointConstants specifies whether an "endpoints.conjure.go" file that defines exported constants for the names,
+	// HTTP methods and HTTP path templates of the services and endpoints should be generated for every package that
+	// contains services.
+	EndpointConstants bool `yaml:"endpoint-constants,omitempty"`
+	// RoutesFile is the path (relative to the project directory) of a machine-readable file that lists the HTTP method,
+	// path template, endpoint name and authentication type of every endpoint of the project. The file is generated as
+	// YAML if the path has a ".yml" or ".yaml" extension and as JSON otherwise.
+	RoutesFile string `yaml:"routes-file,omitempty"`
+	// ClientOptions specifies whether a "client_options.conjure.go" file that defines functional options for the
+	// generated clients of services and a constructor that applies them should be generated for every package that
+	// contains services.
+	ClientOptions bool `yaml:"client-options,omitempty"`
+	// ClientConstructors specifies the names of the generated constructors of service clients keyed by the name of the
+	// service (either the simple name or the qualified name).
+	ClientConstructors map[string]ClientConstructorConfig `yaml:"client-constructors,omitempty"`
+	// Readme specifies whether a "README.conjure.md" file that describes the generated code should be generated in the
+	// output directory of the project.
+	Readme bool `yaml:"readme,omitempty"`
+	// IRSnapshot specifies whether the IR from which the code is generated should be written to
+	// ".conjure/ir.snapshot.json" in the output directory of the project.
+	IRSnapshot bool `yaml:"ir-snapshot,omitempty"`
+	// Owners specifies the owners of the project (such as team names or email addresses), which are named in errors,
+	// summaries and published artifacts so that consumers know whom to contact.
+	Owners []string `yaml:"owners,omitempty"`
+	// Generator specifies the generator that generates the code of the project. If unspecified, the conjure-go
+	// generator that is vendored in the plugin is used.
+	Generator *GeneratorConfig `yaml:"generator,omitempty"`
+	// FileModePolicy specifies how symlinks, executable files and generated files with unexpected permissions in the
+	// output directory are handled: "ignore" (the default), "report" or "normalize".
+	FileModePolicy string `yaml:"file-mode-policy,omitempty"`
+	// PostGenerate specifies the commands that are run after the generated files of this project are written.
+	PostGenerate []PostGenerateHookConfig `yaml:"post-generate,omitempty"`
+	// Format specifies the formatter that is run on the generated Go files: "goimports" or "gofumpt". If unspecified,
+	// the files are not formatted beyond the formatting of the generator.
+	Format string `yaml:"format,omitempty"`
+	// BuildTags specifies build tag expressions (such as "conjure_generated" or "!codeanalysis") that are combined into a
+	// "//go:build" constraint that is added to every generated Go file.
+	BuildTags []string `yaml:"build-tags,omitempty"`
+	// Header specifies a Go template for the comment at the top of the generated Go files, which must contain a line of
+	// the form "Code generated ... DO NOT EDIT.". If unspecified, the header comment of conjure-go is used.
+	Header string `yaml:"header,omitempty"`
+	// YAMLMethods specifies the YAML library used by the MarshalYAML and UnmarshalYAML methods of generated types:
+	// "safeyaml" (the default, which uses gopkg.in/yaml.v2), "yaml.v3" or "none" (the methods are not generated).
+	YAMLMethods string `yaml:"yaml-methods,omitempty"`
+	// Filter selects the definitions in the IR for which code is generated. If unspecified, code is generated for all
+	// of the definitions.
+	Filter *FilterConfig `yaml:"filter,omitempty"`
+	// PackagePaths specifies the paths (relative to the output directory) of the directories in which the code for
+	// Conjure packages (and their subpackages) is generated keyed by Conjure package, such as
+	// "com.palantir.foo: internal/foo/api". If unspecified, the directories are derived from the packages.
+	PackagePaths map[string]string `yaml:"package-paths,omitempty"`
+	// StandaloneModule specifies that a "go.mod" file that declares the output directory as a standalone Go module
+	// should be generated in the output directory so that the generated code can be published as its own module.
+	StandaloneModule *StandaloneModuleConfig `yaml:"standalone-module,omitempty"`
+	// Mocks specifies whether mock implementations of the generated client and server interfaces of the services should
+	// be generated in "services_mock.conjure.go" and "servers_mock.conjure.go" files.
+	Mocks bool `yaml:"mocks,omitempty"`
+	// Examples specifies whether Go documentation examples that construct the generated service clients and call one of
+	// their endpoints should be generated in "services_example.conjure_test.go" files.
+	Examples bool `yaml:"examples,omitempty"`
+}
+
+type GeneratorType string
+
+const (
+	// GeneratorTypeBuiltin specifies that code is generated using the conjure-go generator that is vendored in the
+	// plugin.
+	GeneratorTypeBuiltin = GeneratorType("builtin")
+	// GeneratorTypeExternal specifies that code is generated by running an external conjure-go executable.
+	GeneratorTypeExternal = GeneratorType("external")
+	// GeneratorTypeAsset specifies that output is generated by a generator asset provided to the plugin.
+	GeneratorTypeAsset = GeneratorType("asset")
+)
+
+// GeneratorConfig specifies the generator that generates the code of a project.
+type GeneratorConfig struct {
+	// Type is the type of the generator: "builtin" (the default), "external" or "asset".
+	Type GeneratorType `yaml:"type,omitempty"`
+	// Path is the path of the executable of an "external" generator. Relative paths are resolved against the project
+	// directory.
+	Path string `yaml:"path,omitempty"`
+	// Args are the arguments provided to the executable of an "external" generator before the path of the IR file and
+	// the output directory.
+	Args []string `yaml:"args,omitempty"`
+	// Asset is the name of the generator asset of an "asset" generator.
+	Asset string `yaml:"asset,omitempty"`
+}
+
+// PostGenerateHookConfig specifies a command that is run after the generated files of a project are written.
+type PostGenerateHookConfig struct {
+	// Path is the path of the executable. Relative paths are resolved against the project directory.
+	Path string `yaml:"path,omitempty"`
+	// Args are the arguments provided to the executable. They are rendered as Go templates that can use the "Project"
+	// and "OutputDir" functions.
+	Args []string `yaml:"args,omitempty"`
+}
+
+// ClientConstructorConfig specifies the name of the generated constructor of a service client. It can be specified as a
+// YAML string or as a full YAML object. If it is specified as a YAML string, then the string is used as the value of
+// "Name". Exactly one of "Name" and "Prefix" must be specified.
+type ClientConstructorConfig struct {
+	// Name is the name of the constructor (for example, "NewLegacyFooServiceClient").
+	Name string `yaml:"name,omitempty"`
+	// Prefix is inserted after "New" in the default name of the constructor (for example, "Conjure" renames
+	// "NewFooServiceClient" to "NewConjureFooServiceClient").
+	Prefix string `yaml:"prefix,omitempty"`
 }
 
+func (cfg *ClientConstructorConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var strInput string
+	if err := unmarshal(&strInput); err == nil && strInput != "" {
+		cfg.Name = strInput
+		return nil
+	}
+
+	type clientConstructorConfigAlias ClientConstructorConfig
+	var unmarshaledCfg clientConstructorConfigAlias
+	if err := unmarshal(&unmarshaledCfg); err != nil {
+		return err
+	}
+	*cfg = ClientConstructorConfig(unmarshaledCfg)
+	return nil
+}
+
+// RenamedFromConfig specifies a previous name of a project. It can be specified as a YAML string or as a full YAML
+// object. If it is specified as a YAML string, then the string is used as the value of "Name".
+type RenamedFromConfig struct {
+	Name string `yaml:"name"`
+	// OutputDir is the output directory used by the project under its previous name. If specified, generated files
+	// that remain in this directory are removed by generation and reported by verification.
+	OutputDir string `yaml:"output-dir,omitempty"`
+	// PublishRelocationPOM specifies whether publishing the project should also publish a POM that relocates the
+	// previous name to the current one.
+	PublishRelocationPOM bool `yaml:"publish-relocation-pom,omitempty"`
+}
+
+func (cfg *RenamedFromConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var strInput string
+	if err := unmarshal(&strInput); err == nil && strInput != "" {
+		cfg.Name = strInput
+		return nil
+	}
+
+	type renamedFromConfigAlias RenamedFromConfig
+	var unmarshaledCfg renamedFromConfigAlias
+	if err := unmarshal(&unmarshaledCfg); err != nil {
+		return err
+	}
+	*cfg = RenamedFromConfig(unmarshaledCfg)
+	return nil
+}
+
+// SizeBudgetConfig specifies limits on the code generated for a project. Limits that are not specified (or that are
+// specified as 0) are not enforced.
+type SizeBudgetConfig struct {
+	MaxFiles      int   `yaml:"max-files,omitempty"`
+	MaxTotalBytes int64 `yaml:"max-total-bytes,omitempty"`
+	MaxFileBytes  int64 `yaml:"max-file-bytes,omitempty"`
+	// WarnOnly specifies that exceeding the budget should print a warning rather than fail.
+	WarnOnly bool `yaml:"warn-only,omitempty"`
+}
+
+// FilterConfig selects the definitions in the IR of a project for which code is generated. Services and types are
+// specified using their simple (such as "FooService") or qualified (such as "com.example.FooService") names. If
+// include-services or include-types is specified, only the specified services and types are selected. The types
+// referenced by the selected definitions are always generated.
+type FilterConfig struct {
+	IncludeServices []string `yaml:"include-services,omitempty"`
+	ExcludeServices []string `yaml:"exclude-services,omitempty"`
+	IncludeTypes    []string `yaml:"include-types,omitempty"`
+	ExcludeTypes    []string `yaml:"exclude-types,omitempty"`
+	// IncludePackages and ExcludePackages select definitions by package. A package matches the definitions in the
+	// package and in its subpackages.
+	IncludePackages []string `yaml:"include-packages,omitempty"`
+	ExcludePackages []string `yaml:"exclude-packages,omitempty"`
+}
+
+// StandaloneModuleConfig configures the "go.mod" file that is generated for a project that is generated as a standalone
+// Go module.
+type StandaloneModuleConfig struct {
+	// Requires specifies the versions of the modules required by the generated code keyed by module path, which
+	// override the versions pinned by the plugin.
+	Requires map[string]string `yaml:"requires,omitempty"`
+}
+
+// ForbiddenPatternConfig specifies definitions of a specific kind that are forbidden.
+type ForbiddenPatternConfig struct {
+	// Kind is the kind of definition that the pattern is evaluated against: one of "type-name", "service-name",
+	// "endpoint-name", "external-type", "body-param-type", "header-param-type", "path-param-type" or
+	// "query-param-type".
+	Kind string `yaml:"kind"`
+	// Pattern is a regular expression. Definitions of the specified kind that it matches in their entirety are
+	// forbidden.
+	Pattern string `yaml:"pattern"`
+	// Message is an optional explanation that is included in failures.
+	Message string `yaml:"message,omitempty"`
+}
+
+type Compiler string
+
+const (
+	// CompilerCLI specifies that the IR is generated using the Conjure CLI.
+	CompilerCLI = Compiler("cli")
+	// CompilerNative specifies that the IR is generated using the experimental native compiler, which falls back to
+	// the Conjure CLI for definitions that it does not support.
+	CompilerNative = Compiler("native")
+)
+
 type LocatorType string
 
 const (
@@ -48,14 +458,75 @@ const (
 	LocatorTypeRemote = LocatorType("remote")
 	LocatorTypeYAML   = LocatorType("yaml")
 	LocatorTypeIRFile = LocatorType("ir-file")
+	LocatorTypeGit    = LocatorType("git")
+	// LocatorTypeProject specifies that the IR of another project (whose name is the locator) is used.
+	LocatorTypeProject = LocatorType("project")
 )
 
-// IRLocatorConfig is configuration that specifies a locator. It can be specified as a YAML string or as a full YAML
-// object. If it is specified as a YAML string, then the string is used as the value of "Locator" and LocatorTypeAuto is
-// used as the value of the type.
+// IRLocatorConfig is configuration that specifies a locator. It can be specified as a YAML string, a YAML list of
+// strings or as a full YAML object. If it is specified as a YAML string, then the string is used as the value of
+// "Locator" and LocatorTypeAuto is used as the value of the type. If it is specified as a YAML list, then the list is
+// used as the value of "Locators" and LocatorTypeYAML is used as the value of the type.
 type IRLocatorConfig struct {
 	Type    LocatorType `yaml:"type"`
 	Locator string      `yaml:"locator"`
+	// Locators are the paths to Conjure YAML files or directories that are compiled together into a single IR. Only
+	// used for the "yaml" locator type and cannot be specified together with Locator.
+	Locators []string `yaml:"locators,omitempty"`
+	// Repo is the Git repository that contains the Conjure YAML. Only used for the "git" locator type.
+	Repo string `yaml:"repo,omitempty"`
+	// Ref is the branch, tag or commit of Repo that is used. Only used for the "git" locator type.
+	Ref string `yaml:"ref,omitempty"`
+	// Path is the path within Repo to the Conjure YAML file or directory. Only used for the "git" locator type.
+	Path string `yaml:"path,omitempty"`
+	// Auth specifies the authentication used to fetch the IR. Only used for the "remote" locator type.
+	Auth *HTTPAuthConfig `yaml:"auth,omitempty"`
+	// Retry specifies how failed requests for the IR are retried. Only used for the "remote" locator type. If
+	// unspecified, failed requests are not retried.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+	// CacheTTL is the duration (for example, "24h") for which downloaded IR is cached on disk and reused rather than
+	// downloaded again. Only used for the "remote" locator type. If unspecified, IR is not cached.
+	CacheTTL string `yaml:"cache-ttl,omitempty"`
+	// SHA256 is the expected hex-encoded SHA-256 checksum of the IR. Only used for the "remote" locator type. If
+	// specified, generation fails if the checksum of the downloaded IR does not match.
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Limits specifies limits on the size of the IR. Only used for the "remote" locator type. If specified, generation
+	// fails if the downloaded IR exceeds them.
+	Limits *IRLimitsConfig `yaml:"limits,omitempty"`
+}
+
+// IRLimitsConfig specifies limits on the size of IR. Limits that are unspecified (or 0) are not enforced.
+type IRLimitsConfig struct {
+	// MaxBytes is the maximum size of the IR in bytes.
+	MaxBytes int64 `yaml:"max-bytes,omitempty"`
+	// MaxTypes is the maximum number of types defined in the IR.
+	MaxTypes int `yaml:"max-types,omitempty"`
+}
+
+// RetryConfig specifies how failed requests are retried.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int `yaml:"max-attempts,omitempty"`
+	// InitialBackoff is the duration (for example, "500ms") waited before the first retry, which doubles for every
+	// subsequent retry. Defaults to 1s.
+	InitialBackoff string `yaml:"initial-backoff,omitempty"`
+	// MaxBackoff is the maximum duration waited between attempts. Defaults to 30s.
+	MaxBackoff string `yaml:"max-backoff,omitempty"`
+	// RetryableStatusCodes are the response status codes for which requests are retried. Defaults to 429, 500, 502, 503
+	// and 504. Requests that fail without a response are always retried.
+	RetryableStatusCodes []int `yaml:"retryable-status-codes,omitempty"`
+}
+
+// HTTPAuthConfig specifies the authentication used for HTTP requests. Values can refer to environment variables using
+// $VAR or ${VAR}, which allows credentials to be provided without being written in the configuration.
+type HTTPAuthConfig struct {
+	// BearerToken is the token sent in the "Authorization: Bearer" header.
+	BearerToken string `yaml:"bearer-token,omitempty"`
+	// Username and Password are the credentials used for HTTP basic authentication.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// Headers are additional headers that are set on requests.
+	Headers map[string]string `yaml:"headers,omitempty"`
 }
 
 func (cfg *IRLocatorConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -67,6 +538,14 @@ func (cfg *IRLocatorConfig) UnmarshalYAML(unmarshal func(interface{}) error) err
 		return nil
 	}
 
+	var listInput []string
+	if err := unmarshal(&listInput); err == nil && len(listInput) > 0 {
+		// input was specified as a list: use list as value of locators with "yaml" type
+		cfg.Type = LocatorTypeYAML
+		cfg.Locators = listInput
+		return nil
+	}
+
 	type irLocatorConfigAlias IRLocatorConfig
 	var unmarshaledCfg irLocatorConfigAlias
 	if err := unmarshal(&unmarshaledCfg); err != nil {
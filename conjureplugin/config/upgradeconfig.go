@@ -21,6 +21,26 @@ import (
 	"github.com/pkg/errors"
 )
 
+// UpgradeConfigCommand is the command that upgrades the plugin configuration to the current version.
+const UpgradeConfigCommand = "./godelw upgrade-config"
+
+// VerifyCurrentVersion returns an error if the provided configuration is not explicitly declared as the current
+// configuration version. Configuration in a legacy format or that does not declare its version is otherwise implicitly
+// upgraded when it is read.
+func VerifyCurrentVersion(cfgBytes []byte) error {
+	if versionedconfig.IsLegacyConfig(cfgBytes) {
+		return errors.Errorf("legacy configuration is not supported: run %q to upgrade the configuration", UpgradeConfigCommand)
+	}
+	version, err := versionedconfig.ConfigVersion(cfgBytes)
+	if err != nil {
+		return err
+	}
+	if version != "1" {
+		return errors.Errorf(`configuration must declare "version: 1", but declared version was %q: run %q to upgrade the configuration`, version, UpgradeConfigCommand)
+	}
+	return nil
+}
+
 func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
 	if versionedconfig.IsLegacyConfig(cfgBytes) {
 		v0Bytes, err := legacy.UpgradeConfig(cfgBytes)
@@ -38,6 +58,13 @@ func UpgradeConfig(cfgBytes []byte) ([]byte, error) {
 		if len(cfgBytes) == 0 {
 			return cfgBytes, nil
 		}
+		// configuration that does not declare a version but is valid v1 configuration is implicitly treated as v1
+		// configuration when read: upgrade it by declaring the version explicitly.
+		if version == "" {
+			if _, err := v1.UpgradeConfig(cfgBytes); err == nil {
+				return append([]byte("version: 1\n"), cfgBytes...), nil
+			}
+		}
 		return nil, errors.Errorf("v0 configuration is not supported")
 	case "1":
 		return v1.UpgradeConfig(cfgBytes)
@@ -15,77 +15,604 @@
 package conjureplugin
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
-	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/palantir/conjure-go/v6/conjure"
 	conjurego "github.com/palantir/conjure-go/v6/conjure"
 	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/conjureverify"
+	"github.com/pkg/errors"
 )
 
 const indentLen = 2
 
-func Run(params ConjureProjectParams, verify bool, projectDir string, stdout io.Writer) error {
+func Run(params ConjureProjectParams, verify bool, projectDir string, stdout io.Writer, opParams ...OperationParam) (rErr error) {
+	opArgs := newOperationArgs(opParams)
+	summaries := newProjectSummaries(params)
+	defer func() {
+		rErr = summaries.finish(rErr, opArgs.summary)
+	}()
+
 	var verifyFailedIndex []int
 	verifyFailedErrors := make(map[int]string)
 	verifyFailedFn := func(name int, errStr string) {
+		if existing, ok := verifyFailedErrors[name]; ok {
+			verifyFailedErrors[name] = existing + "\n" + errStr
+			return
+		}
 		verifyFailedIndex = append(verifyFailedIndex, name)
 		verifyFailedErrors[name] = errStr
 	}
+	// verifyReportFiles and verifyReportMessages record the differences found by verification for the verify report
+	verifyReportFiles := make(map[int][]VerifyFileDiff)
+	verifyReportMessages := make(map[int][]string)
+	// verifyPatches records the patches that update the files on disk to match the generated files
+	verifyPatches := make(map[int]string)
 
-	k := 0
-	for _, currParam := range params.OrderedParams() {
-		outputDir := currParam.OutputDir
-		conjureDef, err := conjureDefinitionFromParam(currParam)
-		if err != nil {
-			return err
-		}
+	// dryRun specifies whether the changes to the files on disk are only printed. dryRunPlans records the changes that
+	// would be made for every project.
+	dryRun := opArgs.dryRun && !verify
+	dryRunPlans := make(map[int]*dryRunPlan)
+
+	// generatedFiles records the paths of all of the files generated in this run
+	generatedFiles := make(map[string]struct{})
+	// irBytesCache records the IR computed by the providers in this run so that the IR of a project that is consumed by
+	// other projects is only computed once
+	irBytesCache := newIRBytesCache()
 
-		outputConf := conjure.OutputConfiguration{
-			OutputDir:            path.Join(projectDir, outputDir),
-			GenerateServer:       currParam.Server,
-			GenerateCLI:          currParam.CLI,
-			GenerateFuncsVisitor: currParam.AcceptFuncs,
+	// writeDir is the directory relative to which output is verified and written
+	writeDir := projectDir
+	if opArgs.outputRoot != "" {
+		writeDir = opArgs.outputRoot
+	}
+
+	orderedParams := params.OrderedParams()
+	incremental := params.Incremental || opArgs.incremental
+	// unchangedProjects records the indices of the projects that were not generated because they are unchanged
+	unchangedProjects := make(map[int]struct{})
+	// generatedResults records the results of the projects that were generated if generation is incremental
+	generatedResults := make(map[int]*projectResult)
+	computeProject := func(i int, result *projectResult, out io.Writer) {
+		if len(opArgs.irValidators) > 0 {
+			// IR is validated even if the project is unchanged so that updated validators apply to all projects
+			irBytes, err := irBytesFromProvider(orderedParams[i].IRProvider, irBytesCache)
+			if err != nil {
+				result.err = err
+				return
+			}
+			if result.err = validateIR(params.SortedKeys[i], orderedParams[i], projectDir, irBytes, opArgs.irValidators, params, out); result.err != nil {
+				return
+			}
+		}
+		if incremental {
+			irBytes, err := irBytesFromProvider(orderedParams[i].IRProvider, irBytesCache)
+			if err != nil {
+				result.err = err
+				return
+			}
+			if result.generationKey, result.err = generationKey(orderedParams[i], irBytes, projectDir, opArgs.outputRoot); result.err != nil {
+				return
+			}
+			if files, ok := unchangedGeneratedFiles(generationCacheID(params.SortedKeys[i], writeDir), result.generationKey); ok {
+				result.files = files
+				result.unchanged = true
+				return
+			}
+		}
+		result.files, result.frozenViolations, result.err = computeProjectFiles(params.SortedKeys[i], orderedParams[i], verify, projectDir, opArgs.outputRoot, opArgs.pluginVersion, irBytesCache, out)
+		if !errors.Is(result.err, ErrIR) {
+			result.err = Classify(result.err, ErrGeneration)
+		}
+	}
+	applyProject := func(i int, result *projectResult) error {
+		summaries.beginAt(i, result.start)
+		if result.err != nil {
+			return result.err
+		}
+		files := result.files
+		for _, file := range files {
+			generatedFiles[file.absPath] = struct{}{}
+		}
+		if result.unchanged {
+			unchangedProjects[i] = struct{}{}
+			summaries.end(ProjectStatusSucceeded, "unchanged since last generated")
+			return nil
+		}
+		if result.frozenViolations != "" {
+			verifyFailedFn(i, result.frozenViolations)
+			verifyReportMessages[i] = append(verifyReportMessages[i], result.frozenViolations)
 		}
 		if verify {
-			diff, err := diffOnDisk(conjureDef, projectDir, outputConf)
+			diff, err := conjureverify.DiffOnDisk(verifyFiles(files), writeDir)
 			if err != nil {
 				return err
 			}
 			if len(diff.Diffs) > 0 {
-				verifyFailedFn(k, diff.String())
+				verifyFailedFn(i, diff.String())
+				if opArgs.verifyReport != nil {
+					diffs, err := conjureverify.FileDiffs(verifyFiles(files), writeDir)
+					if err != nil {
+						return err
+					}
+					verifyReportFiles[i] = append(verifyReportFiles[i], diffs...)
+				}
+				if opArgs.verifyPatch != nil {
+					patch, err := conjureverify.Patch(verifyFiles(files), writeDir)
+					if err != nil {
+						return err
+					}
+					verifyPatches[i] += patch
+				}
+			}
+		}
+		outputDir := filepath.Join(writeDir, orderedParams[i].OutputDir)
+		if verify {
+			if policy := orderedParams[i].FileModePolicy; policy == FileModePolicyReport || policy == FileModePolicyNormalize {
+				issues, err := fileModeIssues(outputDir, writeDir, files)
+				if err != nil {
+					return err
+				}
+				if len(issues) > 0 {
+					msg := fmt.Sprintf("output directory of %s has unexpected file modes:\n%s", params.SortedKeys[i], indent(strings.Join(issues, "\n"), indentLen))
+					verifyFailedFn(i, msg)
+					verifyReportMessages[i] = append(verifyReportMessages[i], msg)
+				}
+			}
+		} else if dryRun {
+			plan, err := newDryRunPlan(files, writeDir)
+			if err != nil {
+				return err
 			}
+			dryRunPlans[i] = plan
+			summaries.end(ProjectStatusSucceeded, "dry run")
+			return nil
 		} else {
-			if err := conjure.Generate(conjureDef, outputConf); err != nil {
+			if orderedParams[i].FileModePolicy == FileModePolicyNormalize {
+				if err := normalizeFileModes(outputDir, files); err != nil {
+					return err
+				}
+			}
+			if err := writeRenderedFiles(files); err != nil {
+				return err
+			}
+			summaries.projects[i].FilesWritten = len(files)
+			if orderedParams[i].FileModePolicy == FileModePolicyReport {
+				issues, err := fileModeIssues(outputDir, writeDir, files)
+				if err != nil {
+					return err
+				}
+				for _, issue := range issues {
+					_, _ = fmt.Fprintf(stdout, "Warning: %s: unexpected file mode in output directory: %s\n", params.SortedKeys[i], issue)
+				}
+			}
+			if err := runPostGenerateHooks(params.SortedKeys[i], orderedParams[i], outputDir, projectDir, stdout); err != nil {
+				return err
+			}
+		}
+		if incremental {
+			generatedResults[i] = result
+		}
+		summaries.end(ProjectStatusSucceeded, "")
+		return nil
+	}
+	parallelism := params.Parallelism
+	if opArgs.parallelism > 0 {
+		parallelism = opArgs.parallelism
+	}
+	if err := forEachProject(len(orderedParams), parallelism, stdout, computeProject, applyProject); err != nil {
+		return err
+	}
+
+	// remove or report generated files left behind in the output directories of renamed projects. Performed after all
+	// projects are generated so that files generated by any project in this run are never considered leftovers. Not
+	// performed if the output is written to an output root, since the leftovers are in the project directory.
+	if opArgs.outputRoot == "" {
+		for i, currParam := range params.OrderedParams() {
+			if _, ok := unchangedProjects[i]; ok {
+				// leftovers of unchanged projects were removed or reported when they were last generated
+				continue
+			}
+			leftovers, err := leftoverGeneratedFiles(currParam, projectDir, generatedFiles)
+			if err != nil {
+				return err
+			}
+			stale, err := staleGeneratorAssetFiles(currParam, projectDir, generatedFiles)
+			if err != nil {
+				return err
+			}
+			if len(leftovers) == 0 && len(stale) == 0 {
+				continue
+			}
+			// the files to remove are sorted because stale files are in the current output directory rather than in the
+			// output directories of previous names
+			removed := append(append([]string{}, leftovers...), stale...)
+			sort.Strings(removed)
+			if verify {
+				if len(leftovers) > 0 {
+					verifyFailedFn(i, fmt.Sprintf("generated files from previous names of %s should be removed:\n%s%s", params.SortedKeys[i], strings.Repeat(" ", indentLen), strings.Join(leftovers, "\n"+strings.Repeat(" ", indentLen))))
+				}
+				if len(stale) > 0 {
+					verifyFailedFn(i, fmt.Sprintf("files no longer generated by generator asset %s for %s should be removed:\n%s%s", currParam.GeneratorAsset.Name, params.SortedKeys[i], strings.Repeat(" ", indentLen), strings.Join(stale, "\n"+strings.Repeat(" ", indentLen))))
+				}
+				if opArgs.verifyReport != nil {
+					diffs, err := conjureverify.ExtraFileDiffs(removed, projectDir)
+					if err != nil {
+						return err
+					}
+					verifyReportFiles[i] = append(verifyReportFiles[i], diffs...)
+				}
+				if opArgs.verifyPatch != nil {
+					patch, err := conjureverify.RemovedFilesPatch(removed, projectDir)
+					if err != nil {
+						return err
+					}
+					verifyPatches[i] += patch
+				}
+				continue
+			}
+			if dryRun {
+				if dryRunPlans[i] == nil {
+					dryRunPlans[i] = &dryRunPlan{}
+				}
+				dryRunPlans[i].delete = append(dryRunPlans[i].delete, removed...)
+				continue
+			}
+			if err := removeLeftoverGeneratedFiles(removed, projectDir); err != nil {
 				return err
 			}
+			summaries.projects[i].FilesDeleted += len(removed)
+		}
+	}
+
+	if dryRun {
+		for i := range orderedParams {
+			dryRunPlans[i].print(params.SortedKeys[i], stdout)
+		}
+		return nil
+	}
+
+	// record the files generated for every project whose files now match the files on disk
+	for i := range orderedParams {
+		result, ok := generatedResults[i]
+		if !ok {
+			continue
+		}
+		if _, failed := verifyFailedErrors[i]; failed {
+			continue
+		}
+		if err := recordGeneratedFiles(generationCacheID(params.SortedKeys[i], writeDir), result.generationKey, result.files); err != nil {
+			_, _ = fmt.Fprintf(stdout, "Warning: failed to record generated files of %s: %v\n", params.SortedKeys[i], err)
 		}
-		k++
 	}
 
 	if verify && len(verifyFailedIndex) > 0 {
+		sort.Ints(verifyFailedIndex)
+		for _, currKey := range verifyFailedIndex {
+			message := "generated code differs from what currently exists"
+			if owners := orderedParams[currKey].Owners; len(owners) > 0 {
+				message += fmt.Sprintf(" (%s)", ownersContact(owners))
+			}
+			summaries.fail(currKey, message)
+		}
+		if opArgs.verifyPatch != nil {
+			for _, currKey := range verifyFailedIndex {
+				if _, err := io.WriteString(opArgs.verifyPatch, verifyPatches[currKey]); err != nil {
+					return errors.Wrapf(err, "failed to write patch")
+				}
+			}
+		}
+		if opArgs.verifyReport != nil {
+			for _, currKey := range verifyFailedIndex {
+				files := verifyReportFiles[currKey]
+				conjureverify.SortFileDiffs(files)
+				if files == nil {
+					files = []VerifyFileDiff{}
+				}
+				opArgs.verifyReport.Projects = append(opArgs.verifyReport.Projects, VerifyProjectReport{
+					Project:  params.SortedKeys[currKey],
+					Owners:   orderedParams[currKey].Owners,
+					Files:    files,
+					Messages: verifyReportMessages[currKey],
+				})
+			}
+			if !opArgs.verifyReportText {
+				return ErrVerifyFailed
+			}
+		}
 		_, _ = fmt.Fprintf(stdout, "Conjure output differs from what currently exists: %v\n", verifyFailedIndex)
 		for _, currKey := range verifyFailedIndex {
 			_, _ = fmt.Fprintf(stdout, "%s%d:\n", strings.Repeat(" ", indentLen), currKey)
 			for _, currErrLine := range strings.Split(verifyFailedErrors[currKey], "\n") {
 				_, _ = fmt.Fprintf(stdout, "%s%s\n", strings.Repeat(" ", indentLen*2), currErrLine)
 			}
+			if owners := orderedParams[currKey].Owners; len(owners) > 0 {
+				_, _ = fmt.Fprintf(stdout, "%s%s\n", strings.Repeat(" ", indentLen*2), ownersContact(owners))
+			}
 		}
-		return fmt.Errorf("conjure verify failed")
+		return ErrVerifyFailed
 	}
 	return nil
 }
 
+// computeProjectFiles returns the files generated for the provided project, rebased onto outputRoot if it is non-empty.
+// If the project is frozen and verify is true, changes to its generated code that are not allowed are returned as a
+// message rather than as an error. Output such as warnings is written to stdout.
+func computeProjectFiles(projectName string, currParam ConjureProjectParam, verify bool, projectDir, outputRoot, pluginVersion string, irBytesCache *irBytesCache, stdout io.Writer) ([]renderedFile, string, error) {
+	outputDir := currParam.OutputDir
+	if yamlProvider, ok := currParam.IRProvider.(*localYAMLIRProvider); ok && yamlProvider.hasGlobPatterns() {
+		inputPaths, err := yamlProvider.inputPaths()
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to resolve YAML inputs for %s", projectName)
+		}
+		_, _ = fmt.Fprintf(stdout, "Compiling YAML inputs for %s: %s\n", projectName, strings.Join(inputPaths, ", "))
+	}
+	irBytes, err := irBytesFromProvider(currParam.IRProvider, irBytesCache)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, hint := range irUpgradeHints(irBytes) {
+		_, _ = fmt.Fprintf(stdout, "Warning: %s: %s\n", projectName, hint)
+	}
+	conjureDef, err := conjurego.FromIRBytes(irBytes)
+	if err != nil {
+		return nil, "", Classify(err, ErrIR)
+	}
+	if conjureDef, err = filterDefinition(conjureDef, currParam.Filter); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to filter definitions of %s", projectName)
+	}
+	if violations := forbiddenPatternViolations(conjureDef, currParam.ForbiddenPatterns); len(violations) > 0 {
+		return nil, "", errors.Errorf("definitions of %s contain forbidden patterns:\n%s", projectName, indent(strings.Join(violations, "\n"), indentLen))
+	}
+
+	outputConf := conjure.OutputConfiguration{
+		OutputDir:            filepath.Join(projectDir, outputDir),
+		GenerateServer:       currParam.Server,
+		GenerateCLI:          currParam.CLI,
+		GenerateFuncsVisitor: currParam.AcceptFuncs,
+	}
+	// external generators and generator assets are provided the filtered IR
+	generatorIRBytes := irBytes
+	if currParam.Filter != nil && (currParam.ExternalGenerator != nil || currParam.GeneratorAsset != nil) {
+		if generatorIRBytes, err = json.Marshal(conjureDef); err != nil {
+			return nil, "", errors.Wrapf(err, "failed to marshal filtered IR of %s", projectName)
+		}
+	}
+	var files []renderedFile
+	if currParam.GeneratorAsset != nil {
+		// the options that operate on generated Go code cannot be specified for projects generated by assets, so the
+		// steps below do not modify the files
+		files, err = renderGeneratorAssetFiles(projectName, currParam, generatorIRBytes, outputConf.OutputDir, projectDir)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to generate output for %s", projectName)
+		}
+	} else if currParam.ExternalGenerator != nil {
+		files, err = renderExternalGeneratorFiles(*currParam.ExternalGenerator, generatorIRBytes, outputConf.OutputDir, projectDir)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to generate code for %s", projectName)
+		}
+	} else {
+		outputFiles, err := conjure.GenerateOutputFiles(conjureDef, outputConf)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "conjure failed")
+		}
+		if files, err = renderOutputFiles(outputFiles); err != nil {
+			return nil, "", err
+		}
+	}
+	if currParam.ClientOptions {
+		optionsFiles, err := renderClientOptionsFiles(conjureDef, outputConf.OutputDir)
+		if err != nil {
+			return nil, "", err
+		}
+		files = append(files, optionsFiles...)
+	}
+	files, err = renameClientConstructors(conjureDef, files, outputConf.OutputDir, currParam.ClientConstructors)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to rename client constructors of %s", projectName)
+	}
+	if files, err = rewriteYAMLMethods(files, currParam.YAMLMethods); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to rewrite YAML methods of %s", projectName)
+	}
+	if currParam.EndpointConstants {
+		constantsFiles, err := renderEndpointConstantsFiles(conjureDef, outputConf.OutputDir)
+		if err != nil {
+			return nil, "", err
+		}
+		files = append(files, constantsFiles...)
+	}
+	// mocks and examples are generated before package paths are applied so that they are moved along with the code
+	// they refer to
+	if currParam.Mocks {
+		mockFiles, err := renderMockFiles(files)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to generate mocks for %s", projectName)
+		}
+		files = append(files, mockFiles...)
+	}
+	if currParam.Examples {
+		exampleFiles, err := renderExampleFiles(files)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to generate examples for %s", projectName)
+		}
+		files = append(files, exampleFiles...)
+	}
+	if files, err = remapPackagePaths(conjureDef, files, outputConf.OutputDir, currParam.PackagePaths); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to apply package paths of %s", projectName)
+	}
+	if currParam.RoutesFile != "" {
+		routes, err := renderRoutesFile(conjureDef, currParam.RoutesFile, projectDir)
+		if err != nil {
+			return nil, "", err
+		}
+		files = append(files, routes)
+	}
+	if currParam.Readme {
+		readme, err := renderReadmeFile(projectName, currParam, conjureDef, outputConf.OutputDir)
+		if err != nil {
+			return nil, "", err
+		}
+		files = append(files, readme)
+	}
+	// headers, build constraints and formatting are applied before the size budget and frozen checks so that they
+	// operate on the code that is written
+	if files, err = addHeader(files, currParam.Header, projectName, pluginVersion, irBytes); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to add header to generated code for %s", projectName)
+	}
+	if files, err = addBuildConstraint(files, currParam.BuildTags); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to add build constraint to generated code for %s", projectName)
+	}
+	if files, err = formatGeneratedFiles(files, currParam.Format); err != nil {
+		return nil, "", err
+	}
+	if currParam.SizeBudget != nil {
+		if err := checkSizeBudget(projectName, *currParam.SizeBudget, files, projectDir, stdout); err != nil {
+			return nil, "", err
+		}
+	}
+	if currParam.TargetPlatforms != nil {
+		if err := checkTargetPlatforms(projectName, *currParam.TargetPlatforms, files, projectDir, stdout); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var frozenMsg string
+	if currParam.Frozen {
+		violations, err := frozenViolations(files, outputDir, projectDir)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(violations) > 0 {
+			frozenMsg = fmt.Sprintf("%s is frozen, but its generated code has changes other than to documentation:\n%s", projectName, indent(strings.Join(violations, "\n"), indentLen))
+			if !verify {
+				return nil, "", errors.Errorf("%s\nSet frozen to false in the configuration to allow changes", frozenMsg)
+			}
+		}
+	}
+
+	// the IR snapshot is added after the size budget and frozen checks because it is not generated code
+	if currParam.IRSnapshot {
+		files = append(files, renderIRSnapshotFile(irBytes, outputConf.OutputDir))
+	}
+	// the module file is added after the IR snapshot so that it requires the modules imported by all of the Go files
+	if currParam.StandaloneModule != nil {
+		goMod, err := renderGoModFile(files, *currParam.StandaloneModule, outputConf.OutputDir)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to generate go.mod for %s", projectName)
+		}
+		files = append(files, goMod)
+	}
+
+	// the frozen check above compares against the code in the project directory, while verification and writing
+	// operate on the output root
+	if outputRoot != "" {
+		files, err = rebaseRenderedFiles(files, projectDir, outputRoot)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return files, frozenMsg, nil
+}
+
+// checkSizeBudget returns an error if the provided files exceed the provided budget. If the budget is configured to only
+// warn, the violations are printed to stdout instead.
+func checkSizeBudget(projectName string, budget SizeBudget, files []renderedFile, projectDir string, stdout io.Writer) error {
+	violations := budget.violations(files, projectDir)
+	if len(violations) == 0 {
+		return nil
+	}
+	if budget.WarnOnly {
+		_, _ = fmt.Fprintf(stdout, "Warning: generated code for %s exceeds its size budget:\n", projectName)
+		for _, violation := range violations {
+			_, _ = fmt.Fprintf(stdout, "%s%s\n", strings.Repeat(" ", indentLen), violation)
+		}
+		return nil
+	}
+	return errors.Errorf("generated code for %s exceeds its size budget:\n%s%s", projectName, strings.Repeat(" ", indentLen), strings.Join(violations, "\n"+strings.Repeat(" ", indentLen)))
+}
+
+// checkTargetPlatforms returns an error if any of the provided files is unavailable on any of the provided target
+// platforms. If the target platforms are configured to only warn, the violations are printed to stdout instead.
+func checkTargetPlatforms(projectName string, platforms TargetPlatforms, files []renderedFile, projectDir string, stdout io.Writer) error {
+	violations, err := platforms.violations(files, projectDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check generated code for %s against target platforms", projectName)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	if platforms.WarnOnly {
+		_, _ = fmt.Fprintf(stdout, "Warning: generated code for %s is not available on all target platforms:\n", projectName)
+		for _, violation := range violations {
+			_, _ = fmt.Fprintf(stdout, "%s%s\n", strings.Repeat(" ", indentLen), violation)
+		}
+		return nil
+	}
+	return errors.Errorf("generated code for %s is not available on all target platforms:\n%s%s", projectName, strings.Repeat(" ", indentLen), strings.Join(violations, "\n"+strings.Repeat(" ", indentLen)))
+}
+
 func conjureDefinitionFromParam(param ConjureProjectParam) (spec.ConjureDefinition, error) {
-	bytes, err := param.IRProvider.IRBytes()
+	return conjureDefinitionFromProvider(param.IRProvider, nil)
+}
+
+// conjureDefinitionFromProvider returns the Conjure definition for the IR of the provided provider. The IR is computed
+// using irBytesFromProvider.
+func conjureDefinitionFromProvider(provider IRProvider, irBytesCache *irBytesCache) (spec.ConjureDefinition, error) {
+	bytes, err := irBytesFromProvider(provider, irBytesCache)
 	if err != nil {
 		return spec.ConjureDefinition{}, err
 	}
 	conjureDefinition, err := conjurego.FromIRBytes(bytes)
 	if err != nil {
-		return spec.ConjureDefinition{}, err
+		return spec.ConjureDefinition{}, Classify(err, ErrIR)
 	}
 	return conjureDefinition, nil
 }
+
+// irBytesCache records the IR computed by providers so that the IR of a project that is consumed by other projects is
+// only computed once. It is safe for concurrent use: if the IR of a provider is requested while it is being computed,
+// the request waits for the computation to finish.
+type irBytesCache struct {
+	mu      sync.Mutex
+	entries map[IRProvider]*irBytesCacheEntry
+}
+
+type irBytesCacheEntry struct {
+	once    sync.Once
+	irBytes []byte
+	err     error
+}
+
+func newIRBytesCache() *irBytesCache {
+	return &irBytesCache{
+		entries: make(map[IRProvider]*irBytesCacheEntry),
+	}
+}
+
+// irBytesFromProvider returns the IR of the provided provider. If irBytesCache is non-nil, the IR is read from and
+// recorded in it by the provider that computes it, so providers that provide the IR of another project reuse the IR
+// that was already computed for that project. Errors are classified as ErrIR.
+func irBytesFromProvider(provider IRProvider, irBytesCache *irBytesCache) ([]byte, error) {
+	source := sourceIRProvider(provider)
+	if irBytesCache == nil {
+		irBytes, err := source.IRBytes()
+		return irBytes, Classify(err, ErrIR)
+	}
+	irBytesCache.mu.Lock()
+	entry, ok := irBytesCache.entries[source]
+	if !ok {
+		entry = &irBytesCacheEntry{}
+		irBytesCache.entries[source] = entry
+	}
+	irBytesCache.mu.Unlock()
+	entry.once.Do(func() {
+		entry.irBytes, entry.err = source.IRBytes()
+		entry.err = Classify(entry.err, ErrIR)
+	})
+	return entry.irBytes, entry.err
+}
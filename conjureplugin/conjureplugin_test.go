@@ -0,0 +1,2002 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIRJSON = `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "TestCase",
+        "package" : "com.palantir.conjure.test.api"
+      },
+      "fields" : [ {
+        "fieldName" : "name",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "STRING"
+        }
+      } ]
+    }
+  } ],
+  "services" : [ ]
+}`
+
+const testServiceIRJSON = `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ ],
+  "services" : [ {
+    "serviceName" : {
+      "name" : "TestService",
+      "package" : "com.palantir.conjure.test.api"
+    },
+    "endpoints" : [ {
+      "endpointName" : "deleteAll",
+      "httpMethod" : "DELETE",
+      "httpPath" : "/all",
+      "auth" : {
+        "type" : "header",
+        "header" : { }
+      },
+      "args" : [ {
+        "argName" : "token",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "BEARERTOKEN"
+        },
+        "paramType" : {
+          "type" : "query",
+          "query" : {
+            "paramId" : "token"
+          }
+        },
+        "markers" : [ ]
+      }, {
+        "argName" : "filter",
+        "type" : {
+          "type" : "optional",
+          "optional" : {
+            "itemType" : {
+              "type" : "external",
+              "external" : {
+                "externalReference" : {
+                  "name" : "Filter",
+                  "package" : "com.example"
+                },
+                "fallback" : {
+                  "type" : "primitive",
+                  "primitive" : "STRING"
+                }
+              }
+            }
+          }
+        },
+        "paramType" : {
+          "type" : "query",
+          "query" : {
+            "paramId" : "filter"
+          }
+        },
+        "markers" : [ ]
+      } ],
+      "markers" : [ ]
+    } ]
+  } ]
+}`
+
+func TestRunSizeBudget(t *testing.T) {
+	for i, tc := range []struct {
+		name       string
+		budget     conjureplugin.SizeBudget
+		wantErr    string
+		wantOutput string
+	}{
+		{
+			name:   "within budget",
+			budget: conjureplugin.SizeBudget{MaxFiles: 10},
+		},
+		{
+			name:    "total bytes exceeded",
+			budget:  conjureplugin.SizeBudget{MaxTotalBytes: 1},
+			wantErr: "generated code for project-1 exceeds its size budget:\n  generated",
+		},
+		{
+			name:       "warn only",
+			budget:     conjureplugin.SizeBudget{MaxFileBytes: 1, WarnOnly: true},
+			wantOutput: "Warning: generated code for project-1 exceeds its size budget:\n  conjure-output/conjure/test/api/structs.conjure.go is",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cwd, err := os.Getwd()
+			require.NoError(t, err)
+			projectDir, err := os.MkdirTemp(cwd, "TestRunSizeBudget_")
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, os.RemoveAll(projectDir))
+			}()
+			irFile := filepath.Join(projectDir, "ir.json")
+			require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+			budget := tc.budget
+			params := conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{"project-1"},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:  "conjure-output",
+						IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+						SizeBudget: &budget,
+					},
+				},
+			}
+			outputBuf := &bytes.Buffer{}
+			err = conjureplugin.Run(params, false, projectDir, outputBuf)
+			if tc.wantErr != "" {
+				require.Error(t, err, "Case %d", i)
+				assert.Contains(t, err.Error(), tc.wantErr, "Case %d", i)
+				return
+			}
+			require.NoError(t, err, "Case %d", i)
+			if tc.wantOutput != "" {
+				assert.Contains(t, outputBuf.String(), tc.wantOutput, "Case %d", i)
+			}
+		})
+	}
+}
+
+func TestRunIRUpgradeHints(t *testing.T) {
+	for i, tc := range []struct {
+		name        string
+		irJSON      string
+		wantOutputs []string
+	}{
+		{
+			name:   "supported IR",
+			irJSON: testIRJSON,
+		},
+		{
+			name:   "supported IR with services",
+			irJSON: testServiceIRJSON,
+		},
+		{
+			name:   "unknown field",
+			irJSON: strings.Replace(testIRJSON, `"fieldName" : "name",`, `"fieldName" : "name", "markers" : [ "marker" ],`, 1),
+			wantOutputs: []string{
+				`Warning: project-1: this IR uses field "markers" of FieldDefinition (at types[0].object.fields[0].markers), which requires a version of the plugin that is newer than the current one`,
+			},
+		},
+		{
+			name: "newer IR version and unknown enum value",
+			irJSON: strings.Replace(strings.Replace(testIRJSON, `"version" : 1`, `"version" : 2`, 1),
+				`"fieldName" : "name",`, `"fieldName" : "name", "safety" : "SECRET",`, 1),
+			wantOutputs: []string{
+				`Warning: project-1: this IR uses IR version 2 (at version)`,
+				`Warning: project-1: this IR uses LogSafety value "SECRET" (at types[0].object.fields[0].safety)`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cwd, err := os.Getwd()
+			require.NoError(t, err)
+			projectDir, err := os.MkdirTemp(cwd, "TestRunIRUpgradeHints_")
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, os.RemoveAll(projectDir))
+			}()
+			irFile := filepath.Join(projectDir, "ir.json")
+			require.NoError(t, os.WriteFile(irFile, []byte(tc.irJSON), 0644))
+
+			params := conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{"project-1"},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:  "conjure-output",
+						IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					},
+				},
+			}
+			outputBuf := &bytes.Buffer{}
+			require.NoError(t, conjureplugin.Run(params, false, projectDir, outputBuf), "Case %d", i)
+			if len(tc.wantOutputs) == 0 {
+				assert.NotContains(t, outputBuf.String(), "Warning:", "Case %d", i)
+			}
+			for _, want := range tc.wantOutputs {
+				assert.Contains(t, outputBuf.String(), want, "Case %d", i)
+			}
+		})
+	}
+}
+
+func TestRunParallelism(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunParallelism_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		Params:      make(map[string]conjureplugin.ConjureProjectParam),
+		Parallelism: 4,
+	}
+	sourceProvider := conjureplugin.NewLocalFileIRProvider(irFile)
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("project-%d", i)
+		provider := sourceProvider
+		if i > 0 {
+			// every other project uses the IR of the first project, which is only computed once
+			provider = conjureplugin.NewProjectIRProvider("project-0", sourceProvider)
+		}
+		params.SortedKeys = append(params.SortedKeys, name)
+		params.Params[name] = conjureplugin.ConjureProjectParam{
+			OutputDir:  fmt.Sprintf("conjure-output-%d", i),
+			IRProvider: provider,
+			SizeBudget: &conjureplugin.SizeBudget{MaxFileBytes: 1, WarnOnly: true},
+		}
+	}
+
+	for i, parallelismParam := range []conjureplugin.OperationParam{nil, conjureplugin.ParallelismParam(1), conjureplugin.ParallelismParam(8)} {
+		outputBuf := &bytes.Buffer{}
+		require.NoError(t, conjureplugin.Run(params, false, projectDir, outputBuf, parallelismParam), "Case %d", i)
+		// output of every project is printed in order regardless of the order in which projects are generated
+		var gotOrder []string
+		for _, line := range strings.Split(outputBuf.String(), "\n") {
+			if strings.HasPrefix(line, "Warning: generated code for ") {
+				gotOrder = append(gotOrder, strings.Fields(line)[4])
+			}
+		}
+		assert.Equal(t, params.SortedKeys, gotOrder, "Case %d", i)
+		for j := range params.SortedKeys {
+			_, err := os.Stat(filepath.Join(projectDir, fmt.Sprintf("conjure-output-%d", j), "conjure", "test", "api", "structs.conjure.go"))
+			assert.NoError(t, err, "Case %d: project %d", i, j)
+		}
+		require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, parallelismParam), "Case %d", i)
+	}
+}
+
+func TestRunIncremental(t *testing.T) {
+	t.Setenv("GODEL_HOME", t.TempDir())
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunIncremental_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	generatedFile := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go")
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+	run := func(verify bool) (conjureplugin.ProjectSummary, error) {
+		summary := conjureplugin.NewSummary("conjure")
+		err := conjureplugin.Run(params, verify, projectDir, &bytes.Buffer{}, conjureplugin.SummaryParam(summary), conjureplugin.IncrementalParam(true))
+		require.Len(t, summary.Projects, 1)
+		return summary.Projects[0], err
+	}
+
+	got, err := run(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.FilesWritten)
+	want, err := os.ReadFile(generatedFile)
+	require.NoError(t, err)
+
+	// generation is skipped if nothing changed
+	got, err = run(false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.FilesWritten)
+	assert.Equal(t, "unchanged since last generated", got.Message)
+	got, err = run(true)
+	require.NoError(t, err)
+	assert.Equal(t, "unchanged since last generated", got.Message)
+
+	// generated files that are modified are detected by verification and regenerated
+	require.NoError(t, os.WriteFile(generatedFile, []byte("package api\n"), 0644))
+	_, err = run(true)
+	assert.EqualError(t, err, "conjure verify failed")
+	got, err = run(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.FilesWritten)
+	content, err := os.ReadFile(generatedFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(content))
+
+	// changes to the generation options regenerate the project
+	param := params.Params["project-1"]
+	param.Server = true
+	params.Params["project-1"] = param
+	got, err = run(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.FilesWritten)
+	got, err = run(false)
+	require.NoError(t, err)
+	assert.Equal(t, "unchanged since last generated", got.Message)
+}
+
+func TestRunRenamedFrom(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunRenamedFrom_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	leftoverFile := filepath.Join(projectDir, "old-output", "conjure", "test", "api", "structs.conjure.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(leftoverFile), 0755))
+	require.NoError(t, os.WriteFile(leftoverFile, []byte("package api\n"), 0644))
+	handWrittenFile := filepath.Join(projectDir, "old-output", "doc.go")
+	require.NoError(t, os.WriteFile(handWrittenFile, []byte("package output\n"), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				RenamedFrom: []conjureplugin.RenamedFrom{
+					{
+						Name:      "old-project",
+						OutputDir: "old-output",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+
+	outputBuf := &bytes.Buffer{}
+	require.NoError(t, os.MkdirAll(filepath.Dir(leftoverFile), 0755))
+	require.NoError(t, os.WriteFile(leftoverFile, []byte("package api\n"), 0644))
+	err = conjureplugin.Run(params, true, projectDir, outputBuf)
+	require.EqualError(t, err, "conjure verify failed")
+	assert.Contains(t, outputBuf.String(), "generated files from previous names of project-1 should be removed:\n      old-output/conjure/test/api/structs.conjure.go")
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	_, err = os.Stat(leftoverFile)
+	assert.True(t, os.IsNotExist(err), "leftover generated file should have been removed")
+	_, err = os.Stat(filepath.Join(projectDir, "old-output", "conjure"))
+	assert.True(t, os.IsNotExist(err), "empty directories should have been removed")
+	_, err = os.Stat(handWrittenFile)
+	assert.NoError(t, err, "files that were not generated should not be removed")
+}
+
+func TestRunFrozen(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunFrozen_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+
+	frozenParam := params.Params["project-1"]
+	frozenParam.Frozen = true
+	params.Params["project-1"] = frozenParam
+
+	// documentation changes are allowed
+	docsIR := strings.Replace(testIRJSON, `"fieldName" : "name",`, `"fieldName" : "name", "docs" : "The name of the test case.",`, 1)
+	require.NoError(t, os.WriteFile(irFile, []byte(docsIR), 0644))
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	// other changes are not allowed
+	changedIR := strings.Replace(docsIR, `"primitive" : "STRING"`, `"primitive" : "INTEGER"`, 1)
+	require.NoError(t, os.WriteFile(irFile, []byte(changedIR), 0644))
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "project-1 is frozen, but its generated code has changes other than to documentation:\n  modified: conjure-output/conjure/test/api/structs.conjure.go")
+
+	outputBuf := &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outputBuf)
+	require.EqualError(t, err, "conjure verify failed")
+	assert.Contains(t, outputBuf.String(), "project-1 is frozen")
+}
+
+func TestRunForbiddenPatterns(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunForbiddenPatterns_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				ForbiddenPatterns: []conjureplugin.ForbiddenPattern{
+					{
+						Kind:    conjureplugin.ForbiddenEndpointName,
+						Pattern: regexp.MustCompile("TestService\\.delete.*"),
+						Message: "bulk deletion is not allowed",
+					},
+					{
+						Kind:    conjureplugin.ForbiddenExternalType,
+						Pattern: regexp.MustCompile("com\\.example\\..*"),
+					},
+					{
+						Kind:    conjureplugin.ForbiddenQueryParamType,
+						Pattern: regexp.MustCompile("BEARERTOKEN"),
+					},
+					{
+						// matches only part of the endpoint name, so is not a violation
+						Kind:    conjureplugin.ForbiddenEndpointName,
+						Pattern: regexp.MustCompile("delete"),
+					},
+					{
+						// the leftmost-first match of the alternation is "TestService.delete", but the second
+						// alternative matches the entire endpoint name
+						Kind:    conjureplugin.ForbiddenEndpointName,
+						Pattern: regexp.MustCompile("TestService\\.delete|TestService\\.deleteAll"),
+					},
+				},
+			},
+		},
+	}
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Equal(t, `definitions of project-1 contain forbidden patterns:
+  endpoint-name TestService.deleteAll matches forbidden pattern "TestService\\.delete.*": bulk deletion is not allowed
+  external-type com.example.Filter (TestService.deleteAll argument filter) matches forbidden pattern "com\\.example\\..*"
+  query-param-type BEARERTOKEN (TestService.deleteAll argument token) matches forbidden pattern "BEARERTOKEN"
+  endpoint-name TestService.deleteAll matches forbidden pattern "TestService\\.delete|TestService\\.deleteAll"`, err.Error())
+}
+
+func TestRunEndpointConstants(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunEndpointConstants_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:         "conjure-output",
+				IRProvider:        conjureplugin.NewLocalFileIRProvider(irFile),
+				EndpointConstants: true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	content, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "endpoints.conjure.go"))
+	require.NoError(t, err)
+	assert.Equal(t, `// This file was generated by Conjure and should not be manually edited.
+
+package api
+
+// Names, HTTP methods and HTTP path templates of TestService and its endpoints.
+const (
+	TestServiceName                  = "TestService"
+	TestServiceDeleteAllEndpointName = "deleteAll"
+	TestServiceDeleteAllHTTPMethod   = "DELETE"
+	TestServiceDeleteAllHTTPPath     = "/all"
+)
+`, string(content))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+}
+
+func TestRunRoutesFile(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunRoutesFile_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	for _, tc := range []struct {
+		routesFile string
+		want       string
+	}{
+		{
+			"routes/routes.json",
+			`{
+  "routes": [
+    {
+      "service": "com.palantir.conjure.test.api.TestService",
+      "endpoint": "deleteAll",
+      "method": "DELETE",
+      "path": "/all",
+      "auth": "header"
+    }
+  ]
+}
+`,
+		},
+		{
+			"routes/routes.yml",
+			`routes:
+- service: com.palantir.conjure.test.api.TestService
+  endpoint: deleteAll
+  method: DELETE
+  path: /all
+  auth: header
+`,
+		},
+	} {
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure-output",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					RoutesFile: tc.routesFile,
+				},
+			},
+		}
+		require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}), tc.routesFile)
+		content, err := os.ReadFile(filepath.Join(projectDir, tc.routesFile))
+		require.NoError(t, err, tc.routesFile)
+		assert.Equal(t, tc.want, string(content), tc.routesFile)
+		require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}), tc.routesFile)
+	}
+}
+
+func TestRunReadme(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunReadme_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Server:     true,
+				Readme:     true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	content, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "README.conjure.md"))
+	require.NoError(t, err)
+	for _, want := range []string{
+		"# project-1\n",
+		"`./godelw conjure`",
+		fmt.Sprintf("* IR: ir-file %q\n", irFile),
+		"* server: true\n",
+		"* cli: false\n",
+		"| `conjure/test/api` | `com.palantir.conjure.test.api` | 0 | 0 | TestService |\n",
+	} {
+		assert.Contains(t, string(content), want)
+	}
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	// changing the flags changes the readme, so verification fails
+	param := params.Params["project-1"]
+	param.Server = false
+	params.Params["project-1"] = param
+	require.Error(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+}
+
+func TestRunOwners(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunOwners_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	owners := []string{"team-a@company.com", "team-b"}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "missing.json")),
+				Owners:     owners,
+			},
+		},
+	}
+	summary := conjureplugin.NewSummary("conjure")
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.SummaryParam(summary))
+	require.Error(t, err)
+	assert.True(t, strings.HasSuffix(err.Error(), "(contact team-a@company.com, team-b)"), err.Error())
+	require.Len(t, summary.Projects, 1)
+	assert.Equal(t, conjureplugin.ProjectStatusFailed, summary.Projects[0].Status)
+	assert.Equal(t, owners, summary.Projects[0].Owners)
+	assert.Equal(t, err.Error(), summary.Projects[0].Message)
+
+	params.Params["project-1"] = conjureplugin.ConjureProjectParam{
+		OutputDir:  "conjure-output",
+		IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+		Owners:     owners,
+	}
+	outBuf := &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outBuf)
+	require.EqualError(t, err, "conjure verify failed")
+	assert.Contains(t, outBuf.String(), "    contact team-a@company.com, team-b\n")
+}
+
+func TestRunIRSnapshot(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunIRSnapshot_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				IRSnapshot: true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	snapshotFile := filepath.Join(projectDir, "conjure-output", ".conjure", "ir.snapshot.json")
+	content, err := os.ReadFile(snapshotFile)
+	require.NoError(t, err)
+	assert.Equal(t, testServiceIRJSON, string(content))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	// the snapshot can be compared with the current IR of the project
+	require.NoError(t, os.WriteFile(irFile, []byte(strings.Replace(testServiceIRJSON, `"/all"`, `"/everything"`, 1)), 0644))
+	outBuf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Compare(params, snapshotFile, "project-1", outBuf))
+	assert.Contains(t, outBuf.String(), "DELETE /all")
+	assert.Contains(t, outBuf.String(), "DELETE /everything")
+
+	// the snapshot differs from the IR that the code would be generated from
+	require.Error(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+}
+
+func TestRunExternalGenerator(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunExternalGenerator_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the generator writes a generated file that records its first argument and a file that is not a generated file
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "generator.sh"), []byte(`#!/bin/sh
+set -e
+test -f "$2"
+mkdir -p "$3/api"
+printf 'package api\n\n// %s\n' "$1" > "$3/api/api.conjure.go"
+echo scratch > "$3/scratch.txt"
+`), 0755))
+	outputDir := filepath.Join(projectDir, "conjure-output")
+	require.NoError(t, os.MkdirAll(outputDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "handwritten.go"), []byte("package output\n"), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				ExternalGenerator: &conjureplugin.ExternalGenerator{
+					Path: "./generator.sh",
+					Args: []string{"patched"},
+				},
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	content, err := os.ReadFile(filepath.Join(outputDir, "api", "api.conjure.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package api\n\n// patched\n", string(content))
+	_, err = os.Stat(filepath.Join(outputDir, "scratch.txt"))
+	assert.True(t, os.IsNotExist(err), "only generated files should be written")
+	content, err = os.ReadFile(filepath.Join(outputDir, "handwritten.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package output\n", string(content))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	// verification does not modify the output directory
+	param := params.Params["project-1"]
+	param.ExternalGenerator.Args = []string{"updated"}
+	params.Params["project-1"] = param
+	require.Error(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+	content, err = os.ReadFile(filepath.Join(outputDir, "api", "api.conjure.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package api\n\n// patched\n", string(content))
+
+	param.ExternalGenerator.Path = "./missing.sh"
+	params.Params["project-1"] = param
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to generate code for project-1: external generator ./missing.sh failed")
+}
+
+func TestRunErrorClasses(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunErrorClasses_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "invalid-ir.json"), []byte(`{"version":"invalid"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "failing-generator.sh"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+
+	for i, tc := range []struct {
+		name      string
+		param     conjureplugin.ConjureProjectParam
+		verify    bool
+		wantClass error
+	}{
+		{
+			name: "missing IR",
+			param: conjureplugin.ConjureProjectParam{
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "missing.json")),
+			},
+			wantClass: conjureplugin.ErrIR,
+		},
+		{
+			name: "invalid IR",
+			param: conjureplugin.ConjureProjectParam{
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "invalid-ir.json")),
+			},
+			wantClass: conjureplugin.ErrIR,
+		},
+		{
+			name: "generator failure",
+			param: conjureplugin.ConjureProjectParam{
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				ExternalGenerator: &conjureplugin.ExternalGenerator{
+					Path: "./failing-generator.sh",
+				},
+			},
+			wantClass: conjureplugin.ErrGeneration,
+		},
+		{
+			name: "verify failure",
+			param: conjureplugin.ConjureProjectParam{
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+			verify:    true,
+			wantClass: conjureplugin.ErrVerifyFailed,
+		},
+	} {
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": tc.param,
+			},
+		}
+		err := conjureplugin.Run(params, tc.verify, projectDir, &bytes.Buffer{})
+		require.Error(t, err, "Case %d: %s", i, tc.name)
+		for _, class := range []error{conjureplugin.ErrIR, conjureplugin.ErrGeneration, conjureplugin.ErrVerifyFailed} {
+			assert.Equal(t, class == tc.wantClass, errors.Is(err, class), "Case %d: %s: %v", i, tc.name, class)
+		}
+		// errors that occur while processing a project name the project
+		var projectErr *conjureplugin.ProjectError
+		if assert.Equal(t, tc.wantClass != conjureplugin.ErrVerifyFailed, errors.As(err, &projectErr), "Case %d: %s", i, tc.name) && projectErr != nil {
+			assert.Equal(t, "project-1", projectErr.Project, "Case %d: %s", i, tc.name)
+		}
+	}
+}
+
+func TestRunGeneratorAsset(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunGeneratorAsset_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the asset writes the arguments it is invoked with to a TypeScript file and a file in a subdirectory
+	assetPath := filepath.Join(projectDir, "generator.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+test "$1" = generate
+outputDir=$(echo "$2" | sed 's/.*"outputDir":"\([^"]*\)".*/\1/')
+mkdir -p "$outputDir/api"
+echo "$2" | sed 's/.*"config":\({[^}]*}\).*/\1/' > "$outputDir/index.ts"
+echo "export const project = '$GENERATOR_PROJECT';" > "$outputDir/api/project.ts"
+`), 0755))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "typescript",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Env: map[string]string{
+					"GENERATOR_PROJECT": "project-1",
+				},
+				GeneratorAsset: &conjureplugin.GeneratorAsset{
+					Name:   "conjure-typescript",
+					Path:   assetPath,
+					Config: []byte(`{"flavor":"es6"}`),
+				},
+			},
+		},
+	}
+	outputDir := filepath.Join(projectDir, "typescript")
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	content, err := os.ReadFile(filepath.Join(outputDir, "index.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\"flavor\":\"es6\"}\n", string(content))
+	content, err = os.ReadFile(filepath.Join(outputDir, "api", "project.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "export const project = 'project-1';\n", string(content))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	// files in the output directory that the asset does not generate are reported by verification and removed by
+	// generation
+	require.NoError(t, os.MkdirAll(filepath.Join(outputDir, "old"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "old", "removed.ts"), []byte("export {};\n"), 0644))
+	outputBuf := &bytes.Buffer{}
+	require.Error(t, conjureplugin.Run(params, true, projectDir, outputBuf))
+	assert.Contains(t, outputBuf.String(), "files no longer generated by generator asset conjure-typescript for project-1 should be removed:\n      typescript/old/removed.ts")
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	_, err = os.Stat(filepath.Join(outputDir, "old"))
+	assert.True(t, os.IsNotExist(err), "stale files and the directories left empty should be removed")
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	param := params.Params["project-1"]
+	param.GeneratorAsset.Path = ""
+	params.Params["project-1"] = param
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to generate output for project-1: generator asset conjure-typescript is not provided to the plugin")
+}
+
+func TestRunIRValidator(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunIRValidator_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the asset rejects IR that defines objects unless it is configured to allow them
+	assetPath := filepath.Join(projectDir, "validator.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+test "$1" = validateIR || exit 2
+case "$2" in
+  *'"config":{"allowObjects":true}'*) exit 0 ;;
+esac
+irFile=$(echo "$2" | sed 's/.*"ir":"\([^"]*\)".*/\1/')
+if grep -q '"object"' "$irFile"; then
+  echo '{"findings":[{"rule":"no-objects","location":"com.palantir.base.api.SomeObject","message":"objects are not allowed"}]}'
+  exit 1
+fi
+`), 0755))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.IRValidatorsParam(conjureplugin.NewConfiguredAssetIRValidator(assetPath, "api-style", nil)))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, conjureplugin.ErrIRRejected))
+	assert.False(t, errors.Is(err, conjureplugin.ErrGeneration))
+	var validationErr *conjureplugin.IRValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "project-1", validationErr.Project)
+	assert.Equal(t, map[string][]conjureplugin.IRValidationFinding{
+		"api-style": {{
+			Rule:     "no-objects",
+			Location: "com.palantir.base.api.SomeObject",
+			Message:  "objects are not allowed",
+		}},
+	}, validationErr.Findings)
+	assert.Equal(t, "IR of project-1 was rejected by IR validators:\n  api-style:\n    [no-objects] com.palantir.base.api.SomeObject: objects are not allowed", err.Error())
+	_, err = os.Stat(filepath.Join(projectDir, "conjure"))
+	assert.True(t, os.IsNotExist(err), "code should not be generated for rejected IR")
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.IRValidatorsParam(conjureplugin.NewConfiguredAssetIRValidator(assetPath, "api-style", []byte(`{"allowObjects":true}`)))))
+	_, err = os.Stat(filepath.Join(projectDir, "conjure"))
+	assert.NoError(t, err)
+
+	// validators that fail are skipped with a warning if they are optional assets
+	failingValidator := conjureplugin.NewConfiguredAssetIRValidator(filepath.Join(projectDir, "missing.sh"), "missing", nil)
+	err = conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.IRValidatorsParam(failingValidator))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "IR validator missing failed for project-1")
+	params.AssetOptions = map[string]conjureplugin.AssetOptions{
+		"missing": {
+			Optional: true,
+		},
+	}
+	outputBuf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.IRValidatorsParam(failingValidator)))
+	assert.Contains(t, outputBuf.String(), "Warning: skipping optional IR validator missing for project-1")
+}
+
+func TestRunVerifyReport(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunVerifyReport_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Owners:     []string{"team-a"},
+				RenamedFrom: []conjureplugin.RenamedFrom{
+					{
+						Name:      "old-project",
+						OutputDir: "old-output",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	report := &conjureplugin.VerifyReport{}
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.VerifyReportParam(report)))
+	assert.Empty(t, report.Projects)
+
+	structsFile := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go")
+	generated, err := os.ReadFile(structsFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(structsFile, []byte("package api\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "old-output"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "old-output", "old.conjure.go"), []byte("package old\n"), 0644))
+
+	report = &conjureplugin.VerifyReport{}
+	outBuf := &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outBuf, conjureplugin.VerifyReportParam(report))
+	require.EqualError(t, err, "conjure verify failed")
+	assert.Empty(t, outBuf.String())
+	assert.Equal(t, []conjureplugin.VerifyProjectReport{
+		{
+			Project: "project-1",
+			Owners:  []string{"team-a"},
+			Files: []conjureplugin.VerifyFileDiff{
+				{
+					Path:           "conjure-output/conjure/test/api/structs.conjure.go",
+					Change:         conjureplugin.FileChangeModified,
+					ExpectedSHA256: fmt.Sprintf("%x", sha256.Sum256(generated)),
+					ActualSHA256:   fmt.Sprintf("%x", sha256.Sum256([]byte("package api\n"))),
+				},
+				{
+					Path:         "old-output/old.conjure.go",
+					Change:       conjureplugin.FileChangeExtra,
+					ActualSHA256: fmt.Sprintf("%x", sha256.Sum256([]byte("package old\n"))),
+				},
+			},
+		},
+	}, report.Projects)
+
+	reportBuf := &bytes.Buffer{}
+	require.NoError(t, report.PrintJSON(reportBuf))
+	assert.True(t, strings.HasPrefix(reportBuf.String(), `{"projects":[{"project":"project-1","owners":["team-a"],"files":[{"path":"conjure-output/conjure/test/api/structs.conjure.go","change":"modified","expectedSha256":"`), reportBuf.String())
+}
+
+func TestRunVerifyPatch(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunVerifyPatch_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				RenamedFrom: []conjureplugin.RenamedFrom{
+					{
+						Name:      "old-project",
+						OutputDir: "old-output",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	patchBuf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.VerifyPatchParam(patchBuf)))
+	assert.Empty(t, patchBuf.String())
+
+	structsFile := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go")
+	generated, err := os.ReadFile(structsFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(structsFile, bytes.Replace(generated, []byte("package api\n"), []byte("package apix\n"), 1), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "old-output"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "old-output", "old.conjure.go"), []byte("package old"), 0644))
+
+	patchBuf = &bytes.Buffer{}
+	require.Error(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.VerifyPatchParam(patchBuf)))
+	patch := patchBuf.String()
+	assert.True(t, strings.HasPrefix(patch, `diff --git a/conjure-output/conjure/test/api/structs.conjure.go b/conjure-output/conjure/test/api/structs.conjure.go
+--- a/conjure-output/conjure/test/api/structs.conjure.go
++++ b/conjure-output/conjure/test/api/structs.conjure.go
+@@ -`), patch)
+	assert.Contains(t, patch, "\n-package apix\n+package api\n")
+	assert.True(t, strings.HasSuffix(patch, `diff --git a/old-output/old.conjure.go b/old-output/old.conjure.go
+deleted file mode 100644
+--- a/old-output/old.conjure.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package old
+\ No newline at end of file
+`), patch)
+}
+
+func TestRunDryRun(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunDryRun_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				RenamedFrom: []conjureplugin.RenamedFrom{
+					{
+						Name:      "old-project",
+						OutputDir: "old-output",
+					},
+				},
+			},
+		},
+	}
+	outputBuf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, outputBuf, conjureplugin.DryRunParam(true)))
+	assert.Equal(t, "[DRY RUN] project-1: write conjure-output/conjure/test/api/structs.conjure.go\n", outputBuf.String())
+	structsFile := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go")
+	_, err = os.Stat(structsFile)
+	assert.True(t, os.IsNotExist(err), "dry run should not write files")
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	outputBuf = &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, outputBuf, conjureplugin.DryRunParam(true)))
+	assert.Equal(t, "[DRY RUN] project-1: no changes\n", outputBuf.String())
+
+	require.NoError(t, os.WriteFile(structsFile, []byte("package apix\n"), 0644))
+	oldFile := filepath.Join(projectDir, "old-output", "old.conjure.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(oldFile), 0755))
+	require.NoError(t, os.WriteFile(oldFile, []byte("package old\n"), 0644))
+
+	summary := conjureplugin.NewSummary("conjure")
+	outputBuf = &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, outputBuf, conjureplugin.DryRunParam(true), conjureplugin.SummaryParam(summary)))
+	assert.Equal(t, `[DRY RUN] project-1: overwrite conjure-output/conjure/test/api/structs.conjure.go
+[DRY RUN] project-1: delete old-output/old.conjure.go
+`, outputBuf.String())
+	got, err := os.ReadFile(structsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "package apix\n", string(got))
+	_, err = os.Stat(oldFile)
+	assert.NoError(t, err)
+	require.Len(t, summary.Projects, 1)
+	assert.Equal(t, "dry run", summary.Projects[0].Message)
+	assert.Equal(t, 0, summary.Projects[0].FilesWritten)
+	assert.Equal(t, 0, summary.Projects[0].FilesDeleted)
+}
+
+func TestRunPostGenerateHooks(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunPostGenerateHooks_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the hook records its arguments and environment and fails if the generated file has not been written
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "hook.sh"), []byte(`#!/bin/sh
+set -e
+test -f "$CONJURE_OUTPUT_DIR/conjure/test/api/structs.conjure.go"
+echo "$@ $CONJURE_PROJECT $CONJURE_OUTPUT_DIR $HOOK_VAR" >> hook.txt
+`), 0755))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Env: map[string]string{
+					"HOOK_VAR": "value",
+				},
+				PostGenerate: []conjureplugin.PostGenerateHook{
+					{
+						Path: "./hook.sh",
+						Args: []string{"{{Project}}", "{{OutputDir}}/conjure"},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	// hooks are not run when the output is verified
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	outputDir := filepath.Join(projectDir, "conjure-output")
+	got, err := os.ReadFile(filepath.Join(projectDir, "hook.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("project-1 %s/conjure project-1 %s value\n", outputDir, outputDir), string(got))
+
+	params.Params["project-1"] = conjureplugin.ConjureProjectParam{
+		OutputDir:  "conjure-output",
+		IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+		PostGenerate: []conjureplugin.PostGenerateHook{
+			{
+				Path: "false",
+			},
+		},
+	}
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+	assert.EqualError(t, err, `post-generate command "false" for project-1 failed: exit status 1`)
+}
+
+func TestRunFormat(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunFormat_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the fake gofumpt executable adds a comment to the start of the formatted file. It only uses shell builtins
+	// because the PATH is restricted by the test.
+	binDir := filepath.Join(projectDir, "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "gofumpt"), []byte(`#!/bin/sh
+echo "// formatted by gofumpt"
+while IFS= read -r line; do
+  printf '%s\n' "$line"
+done
+`), 0755))
+	structsFile := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go")
+	// the go executable is required by conjure-go to determine the module of the output directory
+	goPath, err := exec.LookPath("go")
+	require.NoError(t, err)
+	goDir := filepath.Dir(goPath)
+
+	for i, tc := range []struct {
+		format     conjureplugin.Formatter
+		path       string
+		wantPrefix string
+		wantErr    string
+	}{
+		{
+			format:     conjureplugin.FormatterGoimports,
+			path:       goDir,
+			wantPrefix: "// This file was generated by Conjure",
+		},
+		{
+			format:     conjureplugin.FormatterGofumpt,
+			path:       binDir + string(os.PathListSeparator) + goDir,
+			wantPrefix: "// formatted by gofumpt\n// This file was generated by Conjure",
+		},
+		{
+			format:  conjureplugin.FormatterGofumpt,
+			path:    goDir,
+			wantErr: `gofumpt executable not found on the PATH: install it using "go install mvdan.cc/gofumpt@latest"`,
+		},
+	} {
+		t.Setenv("PATH", tc.path)
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure-output",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					Format:     tc.format,
+				},
+			},
+		}
+		err := conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+		if tc.wantErr != "" {
+			require.Error(t, err, "Case %d", i)
+			assert.Contains(t, err.Error(), tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		got, err := os.ReadFile(structsFile)
+		require.NoError(t, err, "Case %d", i)
+		assert.True(t, strings.HasPrefix(string(got), tc.wantPrefix), "Case %d:\n%s", i, string(got))
+		// the formatted output is verified as up-to-date
+		require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}), "Case %d", i)
+	}
+}
+
+func TestRunBuildTags(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunBuildTags_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Readme:     true,
+				BuildTags:  []string{"conjure_generated", "!codeanalysis", "linux || darwin"},
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	got, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(got), "//go:build conjure_generated && !codeanalysis && (linux || darwin)\n\n// This file was generated by Conjure"), string(got))
+	readme, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "README.conjure.md"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(readme), "//go:build")
+
+	params.Params["project-1"] = conjureplugin.ConjureProjectParam{
+		OutputDir:  "conjure-output",
+		IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+		Readme:     true,
+	}
+	err = conjureplugin.Run(params, true, projectDir, &bytes.Buffer{})
+	assert.EqualError(t, err, "conjure verify failed")
+}
+
+func TestRunHeader(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunHeader_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				BuildTags:  []string{"conjure_generated"},
+				Header:     "Code generated by conjure-plugin {{Version}} for {{Project}}; DO NOT EDIT.\n\nIR: {{IRHash}}",
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.PluginVersionParam("1.2.3")))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.PluginVersionParam("1.2.3")))
+
+	got, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go"))
+	require.NoError(t, err)
+	wantHeader := fmt.Sprintf("//go:build conjure_generated\n\n// Code generated by conjure-plugin 1.2.3 for project-1; DO NOT EDIT.\n//\n// IR: %x\n\npackage api\n", sha256.Sum256([]byte(testIRJSON)))
+	assert.True(t, strings.HasPrefix(string(got), wantHeader), string(got))
+	assert.NotContains(t, string(got), "This file was generated by Conjure")
+
+	err = conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.PluginVersionParam("1.2.4"))
+	assert.EqualError(t, err, "conjure verify failed")
+}
+
+func TestRunYAMLMethods(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunYAMLMethods_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	for i, tc := range []struct {
+		yamlMethods    conjureplugin.YAMLMethods
+		wantContains   []string
+		wantNotContain []string
+	}{
+		{
+			"",
+			[]string{`"github.com/palantir/pkg/safeyaml"`, "UnmarshalYAML(unmarshal func(interface{}) error) error"},
+			[]string{`"gopkg.in/yaml.v3"`},
+		},
+		{
+			conjureplugin.YAMLMethodsYAMLV3,
+			[]string{`"gopkg.in/yaml.v3"`, "MarshalYAML() (interface{}, error)", "UnmarshalYAML(node *yaml.Node) error"},
+			[]string{`"github.com/palantir/pkg/safeyaml"`},
+		},
+		{
+			conjureplugin.YAMLMethodsNone,
+			nil,
+			[]string{`"github.com/palantir/pkg/safeyaml"`, `"gopkg.in/yaml.v3"`, "MarshalYAML", "UnmarshalYAML"},
+		},
+	} {
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:   "conjure-output",
+					IRProvider:  conjureplugin.NewLocalFileIRProvider(irFile),
+					YAMLMethods: tc.yamlMethods,
+				},
+			},
+		}
+		require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}), "Case %d", i)
+		require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}), "Case %d", i)
+
+		got, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "structs.conjure.go"))
+		require.NoError(t, err, "Case %d", i)
+		for _, want := range tc.wantContains {
+			assert.Contains(t, string(got), want, "Case %d", i)
+		}
+		for _, notWant := range tc.wantNotContain {
+			assert.NotContains(t, string(got), notWant, "Case %d", i)
+		}
+	}
+}
+
+func TestRunStandaloneModule(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunStandaloneModule_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	modulePath := "github.com/palantir/godel-conjure-plugin/v6/conjureplugin/" + filepath.Base(projectDir) + "/conjure-output"
+
+	for i, tc := range []struct {
+		requires map[string]string
+		want     string
+	}{
+		{
+			want: `// This file was generated by Conjure and should not be manually edited.
+
+module ` + modulePath + `
+
+go 1.23.0
+
+require (
+	github.com/palantir/pkg/safejson v1.1.0
+	github.com/palantir/pkg/safeyaml v1.1.0
+)
+`,
+		},
+		{
+			requires: map[string]string{
+				"github.com/palantir/pkg/safeyaml": "v1.2.0",
+				"github.com/example/unused":        "v1.0.0",
+			},
+			want: `// This file was generated by Conjure and should not be manually edited.
+
+module ` + modulePath + `
+
+go 1.23.0
+
+require (
+	github.com/palantir/pkg/safejson v1.1.0
+	github.com/palantir/pkg/safeyaml v1.2.0
+)
+`,
+		},
+	} {
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure-output",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					StandaloneModule: &conjureplugin.StandaloneModule{
+						Requires: tc.requires,
+					},
+				},
+			},
+		}
+		require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}), "Case %d", i)
+		// the import paths of the generated code are the same once the output directory is a module
+		require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}), "Case %d", i)
+
+		got, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "go.mod"))
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, string(got), "Case %d", i)
+	}
+}
+
+func TestRunMocks(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunMocks_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Server:     true,
+				Mocks:      true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	pkgDir := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api")
+	got, err := os.ReadFile(filepath.Join(pkgDir, "servers_mock.conjure.go"))
+	require.NoError(t, err)
+	assert.Equal(t, `// This file was generated by Conjure and should not be manually edited.
+
+package api
+
+import (
+	"context"
+
+	"github.com/palantir/pkg/bearertoken"
+)
+
+// TestServiceMock is a mock implementation of TestService.
+//
+// Every method calls the "<Method>Func" field, which must be set if the method is called.
+type TestServiceMock struct {
+	DeleteAllFunc func(ctx context.Context, authHeader bearertoken.Token, tokenArg bearertoken.Token, filterArg *string) error
+}
+
+var _ TestService = (*TestServiceMock)(nil)
+
+func (m *TestServiceMock) DeleteAll(ctx context.Context, authHeader bearertoken.Token, tokenArg bearertoken.Token, filterArg *string) error {
+	if m.DeleteAllFunc == nil {
+		panic("TestServiceMock.DeleteAllFunc is nil")
+	}
+	return m.DeleteAllFunc(ctx, authHeader, tokenArg, filterArg)
+}
+`, string(got))
+
+	got, err = os.ReadFile(filepath.Join(pkgDir, "services_mock.conjure.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(got), "type TestServiceClientMock struct {")
+	assert.Contains(t, string(got), "func (m *TestServiceClientWithAuthMock) DeleteAll(ctx context.Context, tokenArg bearertoken.Token, filterArg *string) error {")
+}
+
+func TestRunExamples(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunExamples_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Examples:   true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	examplesFile := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "services_example.conjure_test.go")
+	got, err := os.ReadFile(examplesFile)
+	require.NoError(t, err)
+	assert.Equal(t, `// This file was generated by Conjure and should not be manually edited.
+
+package api
+
+import (
+	"context"
+
+	"github.com/palantir/conjure-go-runtime/v2/conjure-go-client/httpclient"
+	"github.com/palantir/pkg/bearertoken"
+)
+
+func ExampleNewTestServiceClient() {
+	httpClient, err := httpclient.NewClient(
+		httpclient.WithConfig(httpclient.ClientConfig{
+			ServiceName: "test-service",
+			URIs:        []string{"https://localhost:8443/api"},
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	client := NewTestServiceClient(httpClient)
+
+	var (
+		authHeader bearertoken.Token
+		tokenArg   bearertoken.Token
+		filterArg  *string
+	)
+	if err := client.DeleteAll(context.Background(), authHeader, tokenArg, filterArg); err != nil {
+		panic(err)
+	}
+}
+`, string(got))
+}
+
+func TestRunTargetPlatforms(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunTargetPlatforms_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "generator.sh"), []byte(`#!/bin/sh
+set -e
+test -d "$2"
+mkdir -p "$2/api"
+printf '//go:build linux\n\npackage api\n' > "$2/api/linux.conjure.go"
+printf '//go:build !integration && (linux || windows)\n\npackage api\n' > "$2/api/tags.conjure.go"
+printf 'package api\n\nimport _ "log/syslog"\n' > "$2/api/syslog.conjure.go"
+`), 0755))
+
+	targetPlatforms := &conjureplugin.TargetPlatforms{
+		Platforms: []string{"linux-amd64", "windows-amd64", "js-wasm"},
+	}
+	for i, tc := range []struct {
+		generator *conjureplugin.ExternalGenerator
+		warnOnly  bool
+		wantErr   string
+		wantOut   string
+	}{
+		{},
+		{
+			generator: &conjureplugin.ExternalGenerator{
+				Path: "./generator.sh",
+			},
+			wantErr: `generated code for project-1 is not available on all target platforms:
+  conjure-output/api/linux.conjure.go is excluded on js-wasm by its build constraint "linux"
+  conjure-output/api/linux.conjure.go is excluded on windows-amd64 by its build constraint "linux"
+  conjure-output/api/syslog.conjure.go imports log/syslog, which is not available on windows-amd64
+  conjure-output/api/tags.conjure.go is excluded on js-wasm by its build constraint "!integration && (linux || windows)"`,
+		},
+		{
+			generator: &conjureplugin.ExternalGenerator{
+				Path: "./generator.sh",
+			},
+			warnOnly: true,
+			wantOut: `Warning: generated code for project-1 is not available on all target platforms:
+  conjure-output/api/linux.conjure.go is excluded on js-wasm by its build constraint "linux"
+`,
+		},
+	} {
+		platforms := *targetPlatforms
+		platforms.WarnOnly = tc.warnOnly
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:         "conjure-output",
+					IRProvider:        conjureplugin.NewLocalFileIRProvider(irFile),
+					Server:            true,
+					ExternalGenerator: tc.generator,
+					TargetPlatforms:   &platforms,
+				},
+			},
+		}
+		buf := &bytes.Buffer{}
+		err := conjureplugin.Run(params, false, projectDir, buf)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		if tc.wantOut != "" {
+			assert.Contains(t, buf.String(), tc.wantOut, "Case %d", i)
+		} else {
+			assert.NotContains(t, buf.String(), "Warning", "Case %d", i)
+		}
+	}
+}
+
+func TestRunFileModePolicy(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunFileModePolicy_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:      "conjure-output",
+				IRProvider:     conjureplugin.NewLocalFileIRProvider(irFile),
+				FileModePolicy: conjureplugin.FileModePolicyReport,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	outputDir := filepath.Join(projectDir, "conjure-output")
+	structsFile := filepath.Join(outputDir, "conjure", "test", "api", "structs.conjure.go")
+	require.NoError(t, os.Chmod(structsFile, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "script.sh"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.Symlink(irFile, filepath.Join(outputDir, "ir.json")))
+	wantIssues := []string{
+		"executable: conjure-output/script.sh has mode 0755",
+		"permissions: conjure-output/conjure/test/api/structs.conjure.go has mode 0600 rather than 0644",
+		"symlink: conjure-output/ir.json",
+	}
+
+	outBuf := &bytes.Buffer{}
+	require.EqualError(t, conjureplugin.Run(params, true, projectDir, outBuf), "conjure verify failed")
+	for _, issue := range wantIssues {
+		assert.Contains(t, outBuf.String(), issue)
+	}
+
+	// the report policy prints warnings when generating
+	outBuf = &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, outBuf))
+	for _, issue := range wantIssues {
+		assert.Contains(t, outBuf.String(), "Warning: project-1: unexpected file mode in output directory: "+issue)
+	}
+
+	// the normalize policy normalizes the file modes when generating
+	param := params.Params["project-1"]
+	param.FileModePolicy = conjureplugin.FileModePolicyNormalize
+	params.Params["project-1"] = param
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+	fi, err := os.Stat(structsFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+	fi, err = os.Stat(filepath.Join(outputDir, "script.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+	_, err = os.Lstat(filepath.Join(outputDir, "ir.json"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(irFile)
+	assert.NoError(t, err, "target of the symlink should not be removed")
+}
+
+func TestRunProjectLocator(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunProjectLocator_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+
+	provider := &countingIRProvider{irBytes: []byte(testIRJSON)}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: provider,
+			},
+			"project-2": {
+				OutputDir:  "conjure-cli-output",
+				IRProvider: conjureplugin.NewProjectIRProvider("project-1", provider),
+				CLI:        true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	assert.Equal(t, 1, provider.calls, "IR should only be computed once")
+	for _, outputDir := range []string{"conjure-output", "conjure-cli-output"} {
+		_, err := os.Stat(filepath.Join(projectDir, outputDir, "conjure", "test", "api", "structs.conjure.go"))
+		assert.NoError(t, err, outputDir)
+	}
+}
+
+type countingIRProvider struct {
+	irBytes []byte
+	calls   int
+}
+
+func (p *countingIRProvider) IRBytes() ([]byte, error) {
+	p.calls++
+	return p.irBytes, nil
+}
+
+func (p *countingIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+func TestRunOutputRoot(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunOutputRoot_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+	outputRoot := t.TempDir()
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.OutputRootParam(outputRoot)))
+
+	const generatedFile = "conjure-output/conjure/test/api/structs.conjure.go"
+	_, err = os.Stat(filepath.Join(projectDir, generatedFile))
+	assert.True(t, os.IsNotExist(err), "output should not be written to the project directory")
+	sandboxContent, err := os.ReadFile(filepath.Join(outputRoot, generatedFile))
+	require.NoError(t, err)
+
+	// verification operates on the output root
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.OutputRootParam(outputRoot)))
+	err = conjureplugin.Run(params, true, projectDir, &bytes.Buffer{})
+	require.EqualError(t, err, "conjure verify failed")
+
+	// generated code is the same as the code generated in the project directory
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	projectContent, err := os.ReadFile(filepath.Join(projectDir, generatedFile))
+	require.NoError(t, err)
+	assert.Equal(t, string(projectContent), string(sandboxContent))
+}
+
+func TestRunClientConstructors(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestRunClientConstructors_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+	pkgDir := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api")
+
+	paramsWithConstructors := func(constructors map[string]conjureplugin.ClientConstructor) conjureplugin.ConjureProjectParams {
+		return conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:          "conjure-output",
+					IRProvider:         conjureplugin.NewLocalFileIRProvider(irFile),
+					CLI:                true,
+					ClientConstructors: constructors,
+				},
+			},
+		}
+	}
+
+	params := paramsWithConstructors(map[string]conjureplugin.ClientConstructor{
+		"TestService": {Name: "NewLegacyTestServiceClient"},
+	})
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	services, err := os.ReadFile(filepath.Join(pkgDir, "services.conjure.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(services), "func NewLegacyTestServiceClient(")
+	assert.Contains(t, string(services), "func NewLegacyTestServiceClientWithAuth(")
+	assert.NotContains(t, string(services), "NewTestServiceClient")
+	cli, err := os.ReadFile(filepath.Join(pkgDir, "cli.conjure.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(cli), "NewLegacyTestServiceClient(client)")
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+
+	// hand-written wrapper with the original name does not conflict
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "wrapper.go"), []byte("package api\n\nfunc NewTestServiceClient() {}\n"), 0644))
+	params = paramsWithConstructors(map[string]conjureplugin.ClientConstructor{
+		"com.palantir.conjure.test.api.TestService": {Prefix: "Conjure"},
+	})
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	services, err = os.ReadFile(filepath.Join(pkgDir, "services.conjure.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(services), "func NewConjureTestServiceClient(")
+
+	// hand-written declaration with the new name conflicts
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "wrapper.go"), []byte("package api\n\nfunc NewConjureTestServiceClientWithAuth() {}\n"), 0644))
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+	assert.EqualError(t, err, fmt.Sprintf("failed to rename client constructors of project-1: client constructor NewTestServiceClientWithAuth cannot be renamed to NewConjureTestServiceClientWithAuth because NewConjureTestServiceClientWithAuth would be declared multiple times in package directory %s (in services.conjure.go, wrapper.go)", pkgDir))
+
+	err = conjureplugin.Run(paramsWithConstructors(map[string]conjureplugin.ClientConstructor{
+		"UnknownService": {Name: "NewUnknownClient"},
+	}), false, projectDir, &bytes.Buffer{})
+	assert.EqualError(t, err, "failed to rename client constructors of project-1: client-constructors specifies service UnknownService, which is not defined")
+}
+
+func TestRunClientOptions(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		name               string
+		clientConstructors map[string]conjureplugin.ClientConstructor
+		want               []string
+	}{
+		{
+			name: "options are generated for every service",
+			want: []string{
+				"type TestServiceClientOption func(*conjureOptionsClient)",
+				"func WithTestServiceHeader(key, value string) TestServiceClientOption {",
+				"func WithTestServiceTimeout(timeout time.Duration) TestServiceClientOption {",
+				"func WithTestServiceRPCMethodName(name string) TestServiceClientOption {",
+				"func NewTestServiceClientWithOptions(client httpclient.Client, options ...TestServiceClientOption) TestServiceClient {",
+				"return NewTestServiceClient(c)",
+				"type conjureOptionsClient struct {",
+			},
+		},
+		{
+			name: "options use renamed client constructors",
+			clientConstructors: map[string]conjureplugin.ClientConstructor{
+				"TestService": {Name: "NewTestClient"},
+			},
+			want: []string{
+				"func NewTestClientWithOptions(client httpclient.Client, options ...TestServiceClientOption) TestServiceClient {",
+				"return NewTestClient(c)",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			projectDir, err := os.MkdirTemp(cwd, "TestRunClientOptions_")
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, os.RemoveAll(projectDir))
+			}()
+			irFile := filepath.Join(projectDir, "ir.json")
+			require.NoError(t, os.WriteFile(irFile, []byte(testServiceIRJSON), 0644))
+
+			params := conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{"project-1"},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:          "conjure-output",
+						IRProvider:         conjureplugin.NewLocalFileIRProvider(irFile),
+						ClientOptions:      true,
+						ClientConstructors: tc.clientConstructors,
+					},
+				},
+			}
+			require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}), "Case %d", i)
+
+			content, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", "conjure", "test", "api", "client_options.conjure.go"))
+			require.NoError(t, err, "Case %d", i)
+			for _, want := range tc.want {
+				assert.Contains(t, string(content), want, "Case %d", i)
+			}
+
+			buf := &bytes.Buffer{}
+			require.NoError(t, conjureplugin.Run(params, true, projectDir, buf), "Case %d: %s", i, buf.String())
+		})
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conjureverify provides the primitives that the plugin uses to verify that generated code is up-to-date:
+// computing the checksums of generated files and of the files on disk, classifying the differences between them and
+// rendering the differences as a patch. It can be used to build custom checks on top of the verification of the
+// plugin.
+package conjureverify
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/palantir/godel/v2/pkg/dirchecksum"
+	"github.com/pkg/errors"
+)
+
+// File is a generated file.
+type File struct {
+	// Path is the absolute path to which the file is written.
+	Path string
+	// Content is the generated content of the file.
+	Content []byte
+}
+
+// Change is the way in which a file on disk differs from the file that would be generated.
+type Change string
+
+const (
+	// ChangeMissing indicates that a file would be generated but does not exist.
+	ChangeMissing = Change("missing")
+	// ChangeModified indicates that the content of a file differs from the content that would be generated.
+	ChangeModified = Change("modified")
+	// ChangeExtra indicates that a file exists but would be removed (such as a file left behind by a previous name of
+	// a project).
+	ChangeExtra = Change("extra")
+)
+
+// FileDiff describes a generated file that differs from what currently exists.
+type FileDiff struct {
+	// Path is the slash-separated path of the file relative to the directory that is verified (the project directory
+	// or the output root).
+	Path   string `json:"path"`
+	Change Change `json:"change"`
+	// ExpectedSHA256 is the SHA-256 checksum of the file that would be generated, if any.
+	ExpectedSHA256 string `json:"expectedSha256,omitempty"`
+	// ActualSHA256 is the SHA-256 checksum of the file that currently exists, if any.
+	ActualSHA256 string `json:"actualSha256,omitempty"`
+}
+
+// Checksums returns the checksums of the content of the provided files keyed by their paths relative to the provided
+// root directory.
+func Checksums(files []File, rootDir string) (dirchecksum.ChecksumSet, error) {
+	set := dirchecksum.ChecksumSet{
+		RootDir:   rootDir,
+		Checksums: map[string]dirchecksum.FileChecksumInfo{},
+	}
+	for _, file := range files {
+		relPath, err := filepath.Rel(rootDir, file.Path)
+		if err != nil {
+			return dirchecksum.ChecksumSet{}, err
+		}
+		h := sha256.New()
+		_, err = h.Write(file.Content)
+		if err != nil {
+			return dirchecksum.ChecksumSet{}, errors.Wrapf(err, "failed to checksum generated content for %s", file.Path)
+		}
+		set.Checksums[relPath] = dirchecksum.FileChecksumInfo{
+			Path:           relPath,
+			IsDir:          false,
+			SHA256checksum: fmt.Sprintf("%x", h.Sum(nil)),
+		}
+	}
+	return set, nil
+}
+
+// OnDiskChecksums returns the checksums of the files on disk at the paths of the provided files keyed by their paths
+// relative to the provided root directory. Files that do not exist on disk are omitted.
+func OnDiskChecksums(files []File, rootDir string) (dirchecksum.ChecksumSet, error) {
+	set := dirchecksum.ChecksumSet{
+		RootDir:   rootDir,
+		Checksums: map[string]dirchecksum.FileChecksumInfo{},
+	}
+	for _, file := range files {
+		relPath, err := filepath.Rel(rootDir, file.Path)
+		if err != nil {
+			return dirchecksum.ChecksumSet{}, err
+		}
+
+		f, err := os.Open(file.Path)
+		if os.IsNotExist(err) {
+			// skip nonexistent files
+			continue
+		} else if err != nil {
+			return dirchecksum.ChecksumSet{}, errors.Wrapf(err, "failed to open file for checksum %s", file.Path)
+		}
+		defer func() {
+			// file is opened for reading only, so safe to ignore errors on close
+			_ = f.Close()
+		}()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return dirchecksum.ChecksumSet{}, errors.Wrapf(err, "failed to checksum on-disk content for %s", file.Path)
+		}
+		set.Checksums[relPath] = dirchecksum.FileChecksumInfo{
+			Path:           relPath,
+			SHA256checksum: fmt.Sprintf("%x", h.Sum(nil)),
+		}
+	}
+	return set, nil
+}
+
+// DiffOnDisk compares the checksums of the provided files to the checksums of the files on disk.
+func DiffOnDisk(files []File, rootDir string) (dirchecksum.ChecksumsDiff, error) {
+	originalChecksums, err := OnDiskChecksums(files, rootDir)
+	if err != nil {
+		return dirchecksum.ChecksumsDiff{}, errors.Wrap(err, "failed to compute on-disk checksums")
+	}
+	newChecksums, err := Checksums(files, rootDir)
+	if err != nil {
+		return dirchecksum.ChecksumsDiff{}, errors.Wrap(err, "failed to compute generated checksums")
+	}
+	return originalChecksums.Diff(newChecksums), nil
+}
+
+// FileDiffs returns the differences between the provided files and the files on disk. Paths are relative to the
+// provided root directory, and the differences are sorted by path.
+func FileDiffs(files []File, rootDir string) ([]FileDiff, error) {
+	onDisk, err := OnDiskChecksums(files, rootDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute on-disk checksums")
+	}
+	rendered, err := Checksums(files, rootDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute generated checksums")
+	}
+	var out []FileDiff
+	for relPath, renderedChecksum := range rendered.Checksums {
+		onDiskChecksum, ok := onDisk.Checksums[relPath]
+		switch {
+		case !ok:
+			out = append(out, FileDiff{
+				Path:           filepath.ToSlash(relPath),
+				Change:         ChangeMissing,
+				ExpectedSHA256: renderedChecksum.SHA256checksum,
+			})
+		case onDiskChecksum.SHA256checksum != renderedChecksum.SHA256checksum:
+			out = append(out, FileDiff{
+				Path:           filepath.ToSlash(relPath),
+				Change:         ChangeModified,
+				ExpectedSHA256: renderedChecksum.SHA256checksum,
+				ActualSHA256:   onDiskChecksum.SHA256checksum,
+			})
+		}
+	}
+	SortFileDiffs(out)
+	return out, nil
+}
+
+// ExtraFileDiffs returns the differences for the files at the provided paths (relative to the provided directory),
+// which exist but would be removed.
+func ExtraFileDiffs(relPaths []string, dir string) ([]FileDiff, error) {
+	var out []FileDiff
+	for _, relPath := range relPaths {
+		content, err := os.ReadFile(filepath.Join(dir, relPath))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", relPath)
+		}
+		out = append(out, FileDiff{
+			Path:         filepath.ToSlash(relPath),
+			Change:       ChangeExtra,
+			ActualSHA256: fmt.Sprintf("%x", sha256.Sum256(content)),
+		})
+	}
+	return out, nil
+}
+
+// SortFileDiffs sorts the provided differences by path.
+func SortFileDiffs(diffs []FileDiff) {
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+}
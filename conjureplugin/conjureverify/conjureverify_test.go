@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureverify_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/conjureverify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDiffs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "api"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "same.conjure.go"), []byte("package api\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "modified.conjure.go"), []byte("package api\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "extra.conjure.go"), []byte("package api\n"), 0644))
+
+	files := []conjureverify.File{
+		{Path: filepath.Join(dir, "api", "same.conjure.go"), Content: []byte("package api\n")},
+		{Path: filepath.Join(dir, "api", "modified.conjure.go"), Content: []byte("package api\n\ntype Foo struct{}\n")},
+		{Path: filepath.Join(dir, "api", "missing.conjure.go"), Content: []byte("package api\n")},
+	}
+	checksum := func(content string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+	}
+
+	diffs, err := conjureverify.FileDiffs(files, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []conjureverify.FileDiff{
+		{
+			Path:           "api/missing.conjure.go",
+			Change:         conjureverify.ChangeMissing,
+			ExpectedSHA256: checksum("package api\n"),
+		},
+		{
+			Path:           "api/modified.conjure.go",
+			Change:         conjureverify.ChangeModified,
+			ExpectedSHA256: checksum("package api\n\ntype Foo struct{}\n"),
+			ActualSHA256:   checksum("package api\n"),
+		},
+	}, diffs)
+
+	extraDiffs, err := conjureverify.ExtraFileDiffs([]string{filepath.Join("api", "extra.conjure.go")}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []conjureverify.FileDiff{
+		{
+			Path:         "api/extra.conjure.go",
+			Change:       conjureverify.ChangeExtra,
+			ActualSHA256: checksum("package api\n"),
+		},
+	}, extraDiffs)
+
+	checksumsDiff, err := conjureverify.DiffOnDisk(files, dir)
+	require.NoError(t, err)
+	assert.Len(t, checksumsDiff.Diffs, 2)
+}
+
+func TestPatch(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "modified.conjure.go"), []byte("package api\n\ntype Foo struct{}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "removed.conjure.go"), []byte("package api\n"), 0644))
+
+	patch, err := conjureverify.Patch([]conjureverify.File{
+		{Path: filepath.Join(dir, "modified.conjure.go"), Content: []byte("package api\n\ntype Bar struct{}\n")},
+		{Path: filepath.Join(dir, "new.conjure.go"), Content: []byte("package api")},
+	}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, `diff --git a/modified.conjure.go b/modified.conjure.go
+--- a/modified.conjure.go
++++ b/modified.conjure.go
+@@ -1,3 +1,3 @@
+ package api
+ 
+-type Foo struct{}
++type Bar struct{}
+diff --git a/new.conjure.go b/new.conjure.go
+new file mode 100644
+--- /dev/null
++++ b/new.conjure.go
+@@ -0,0 +1 @@
++package api
+\ No newline at end of file
+`, patch)
+
+	removedPatch, err := conjureverify.RemovedFilesPatch([]string{"removed.conjure.go"}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, `diff --git a/removed.conjure.go b/removed.conjure.go
+deleted file mode 100644
+--- a/removed.conjure.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package api
+`, removedPatch)
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureverify
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Patch returns a patch that updates the files on disk to match the provided files. The patch is a unified diff in the
+// format of "git diff" with paths relative to the provided root directory, so it can be applied in that directory using
+// "git apply" or "patch -p1". Files whose content on disk matches are omitted.
+func Patch(files []File, rootDir string) (string, error) {
+	buf := &bytes.Buffer{}
+	for _, file := range files {
+		relPath, err := filepath.Rel(rootDir, file.Path)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		onDisk, err := os.ReadFile(file.Path)
+		if os.IsNotExist(err) {
+			onDisk = nil
+		} else if err != nil {
+			return "", errors.Wrapf(err, "failed to read %s", file.Path)
+		} else if bytes.Equal(onDisk, file.Content) {
+			continue
+		}
+		if err := WriteFilePatch(buf, filepath.ToSlash(relPath), onDisk, file.Content); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// RemovedFilesPatch returns a patch that removes the files at the provided paths (relative to the provided directory).
+func RemovedFilesPatch(relPaths []string, dir string) (string, error) {
+	buf := &bytes.Buffer{}
+	for _, relPath := range relPaths {
+		content, err := os.ReadFile(filepath.Join(dir, relPath))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read %s", relPath)
+		}
+		if err := WriteFilePatch(buf, filepath.ToSlash(relPath), content, nil); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// WriteFilePatch writes a unified diff that changes the provided old content of the file at the provided
+// slash-separated path into the provided new content to the provided writer. Nil content indicates that the file does
+// not exist.
+func WriteFilePatch(w io.Writer, path string, oldContent, newContent []byte) error {
+	fromFile, toFile := "a/"+path, "b/"+path
+	_, _ = fmt.Fprintf(w, "diff --git %s %s\n", fromFile, toFile)
+	switch {
+	case oldContent == nil:
+		fromFile = "/dev/null"
+		_, _ = fmt.Fprintf(w, "new file mode 100644\n")
+	case newContent == nil:
+		toFile = "/dev/null"
+		_, _ = fmt.Fprintf(w, "deleted file mode 100644\n")
+	}
+	if err := difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+		A:        patchLines(oldContent),
+		B:        patchLines(newContent),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to write patch for %s", path)
+	}
+	return nil
+}
+
+// patchLines returns the lines of the provided content including their line endings. If the content does not end with
+// a newline, its last line is followed by the marker that indicates that there is no newline at the end of the file.
+func patchLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	return lines
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffParams returns a description of the differences in effective behavior between the provided parameters. Each
+// element of the returned slice describes a single difference. Returns an empty slice if the parameters are
+// equivalent.
+func DiffParams(oldParams, newParams ConjureProjectParams) []string {
+	keys := make(map[string]struct{})
+	for k := range oldParams.Params {
+		keys[k] = struct{}{}
+	}
+	for k := range newParams.Params {
+		keys[k] = struct{}{}
+	}
+	var sortedKeys []string
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var out []string
+	for _, k := range sortedKeys {
+		oldParam, inOld := oldParams.Params[k]
+		newParam, inNew := newParams.Params[k]
+		switch {
+		case !inOld:
+			out = append(out, fmt.Sprintf("%s: added (%s)", k, paramSummary(newParam)))
+		case !inNew:
+			out = append(out, fmt.Sprintf("%s: removed (%s)", k, paramSummary(oldParam)))
+		default:
+			for _, diff := range diffParam(oldParam, newParam) {
+				out = append(out, fmt.Sprintf("%s: %s", k, diff))
+			}
+		}
+	}
+	return out
+}
+
+func diffParam(oldParam, newParam ConjureProjectParam) []string {
+	var out []string
+	addDiff := func(name string, oldVal, newVal interface{}) {
+		if fmt.Sprint(oldVal) == fmt.Sprint(newVal) {
+			return
+		}
+		out = append(out, fmt.Sprintf("%s changed from %v to %v", name, oldVal, newVal))
+	}
+	addDiff("output-dir", fmt.Sprintf("%q", oldParam.OutputDir), fmt.Sprintf("%q", newParam.OutputDir))
+	addDiff("ir-locator", irProviderDescription(oldParam.IRProvider), irProviderDescription(newParam.IRProvider))
+	addDiff("publish", oldParam.Publish, newParam.Publish)
+	addDiff("server", oldParam.Server, newParam.Server)
+	addDiff("cli", oldParam.CLI, newParam.CLI)
+	addDiff("accept-funcs", oldParam.AcceptFuncs, newParam.AcceptFuncs)
+	addDiff("endpoint-constants", oldParam.EndpointConstants, newParam.EndpointConstants)
+	addDiff("routes-file", fmt.Sprintf("%q", oldParam.RoutesFile), fmt.Sprintf("%q", newParam.RoutesFile))
+	addDiff("client-options", oldParam.ClientOptions, newParam.ClientOptions)
+	addDiff("readme", oldParam.Readme, newParam.Readme)
+	addDiff("ir-snapshot", oldParam.IRSnapshot, newParam.IRSnapshot)
+	addDiff("generator", generatorDescription(oldParam), generatorDescription(newParam))
+	addDiff("file-mode-policy", fmt.Sprintf("%q", oldParam.FileModePolicy), fmt.Sprintf("%q", newParam.FileModePolicy))
+	addDiff("build-tags", fmt.Sprintf("%q", oldParam.BuildTags), fmt.Sprintf("%q", newParam.BuildTags))
+	addDiff("header", fmt.Sprintf("%q", oldParam.Header), fmt.Sprintf("%q", newParam.Header))
+	addDiff("filter", filterDescription(oldParam.Filter), filterDescription(newParam.Filter))
+	addDiff("package-paths", fmt.Sprintf("%q", oldParam.PackagePaths), fmt.Sprintf("%q", newParam.PackagePaths))
+	addDiff("standalone-module", standaloneModuleDescription(oldParam.StandaloneModule), standaloneModuleDescription(newParam.StandaloneModule))
+	addDiff("mocks", oldParam.Mocks, newParam.Mocks)
+	addDiff("examples", oldParam.Examples, newParam.Examples)
+	addDiff("target-platforms", targetPlatformsDescription(oldParam.TargetPlatforms), targetPlatformsDescription(newParam.TargetPlatforms))
+	addDiff("yaml-methods", fmt.Sprintf("%q", oldParam.YAMLMethods), fmt.Sprintf("%q", newParam.YAMLMethods))
+	addDiff("format", fmt.Sprintf("%q", oldParam.Format), fmt.Sprintf("%q", newParam.Format))
+	addDiff("post-generate", fmt.Sprintf("%+v", oldParam.PostGenerate), fmt.Sprintf("%+v", newParam.PostGenerate))
+	addDiff("owners", fmt.Sprintf("%q", oldParam.Owners), fmt.Sprintf("%q", newParam.Owners))
+	addDiff("client-constructors", fmt.Sprintf("%+v", oldParam.ClientConstructors), fmt.Sprintf("%+v", newParam.ClientConstructors))
+	addDiff("size-budget", sizeBudgetDescription(oldParam.SizeBudget), sizeBudgetDescription(newParam.SizeBudget))
+	addDiff("renamed-from", fmt.Sprintf("%+v", oldParam.RenamedFrom), fmt.Sprintf("%+v", newParam.RenamedFrom))
+	addDiff("frozen", oldParam.Frozen, newParam.Frozen)
+	addDiff("backcompat-baseline", backCompatBaselineDescription(oldParam.BackCompatBaseline), backCompatBaselineDescription(newParam.BackCompatBaseline))
+	addDiff("backcompat-policy", oldParam.BackCompatPolicy, newParam.BackCompatPolicy)
+	addDiff("extensions", oldParam.Extensions, newParam.Extensions)
+	addDiff("forbidden-patterns", forbiddenPatternsDescription(oldParam.ForbiddenPatterns), forbiddenPatternsDescription(newParam.ForbiddenPatterns))
+	addDiff("compiler-args", fmt.Sprintf("%q", oldParam.CompilerArgs), fmt.Sprintf("%q", newParam.CompilerArgs))
+	addDiff("compiler", fmt.Sprintf("%q", oldParam.Compiler), fmt.Sprintf("%q", newParam.Compiler))
+	addDiff("jvm-options", fmt.Sprintf("%q", oldParam.JVMOptions), fmt.Sprintf("%q", newParam.JVMOptions))
+	addDiff("publish-properties", fmt.Sprint(oldParam.PublishProperties), fmt.Sprint(newParam.PublishProperties))
+	addDiff("publish-sharded", oldParam.PublishSharded, newParam.PublishSharded)
+	if changedKeys := changedMapKeys(oldParam.Env, newParam.Env); len(changedKeys) > 0 {
+		// values are not printed because they may contain credentials
+		out = append(out, fmt.Sprintf("env changed for %v", changedKeys))
+	}
+	return out
+}
+
+// changedMapKeys returns the sorted keys whose values differ between the provided maps.
+func changedMapKeys(oldMap, newMap map[string]string) []string {
+	var changed []string
+	for k, v := range oldMap {
+		if newV, ok := newMap[k]; !ok || newV != v {
+			changed = append(changed, k)
+		}
+	}
+	for k := range newMap {
+		if _, ok := oldMap[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func paramSummary(param ConjureProjectParam) string {
+	return fmt.Sprintf("output-dir: %q, ir-locator: %s, publish: %t", param.OutputDir, irProviderDescription(param.IRProvider), param.Publish)
+}
+
+// irProviderDescription returns a human-readable description of the source of the IR for the provided provider.
+func irProviderDescription(provider IRProvider) string {
+	switch p := provider.(type) {
+	case nil:
+		return "none"
+	case *localYAMLIRProvider:
+		if len(p.paths) == 1 {
+			return fmt.Sprintf("yaml %q", p.paths[0])
+		}
+		return fmt.Sprintf("yaml %q", p.paths)
+	case *urlIRProvider:
+		return fmt.Sprintf("remote %q", p.irURL)
+	case *localFileIRProvider:
+		return fmt.Sprintf("ir-file %q", p.path)
+	case *gitIRProvider:
+		return fmt.Sprintf("git %q at %q (path %q)", p.repo, p.ref, p.path)
+	case *projectIRProvider:
+		return fmt.Sprintf("project %q", p.project)
+	default:
+		return fmt.Sprintf("%T", provider)
+	}
+}
+
+func sizeBudgetDescription(budget *SizeBudget) string {
+	if budget == nil {
+		return "none"
+	}
+	return fmt.Sprintf("{max-files: %d, max-total-bytes: %d, max-file-bytes: %d, warn-only: %t}", budget.MaxFiles, budget.MaxTotalBytes, budget.MaxFileBytes, budget.WarnOnly)
+}
+
+func targetPlatformsDescription(platforms *TargetPlatforms) string {
+	if platforms == nil {
+		return "none"
+	}
+	return fmt.Sprintf("{platforms: %q, warn-only: %t}", platforms.Platforms, platforms.WarnOnly)
+}
+
+func generatorDescription(param ConjureProjectParam) string {
+	switch {
+	case param.GeneratorAsset != nil:
+		return fmt.Sprintf("asset %q", param.GeneratorAsset.Name)
+	case param.ExternalGenerator != nil:
+		return fmt.Sprintf("external %q with args %q", param.ExternalGenerator.Path, param.ExternalGenerator.Args)
+	default:
+		return "builtin"
+	}
+}
+
+func standaloneModuleDescription(module *StandaloneModule) string {
+	if module == nil {
+		return "none"
+	}
+	return fmt.Sprintf("{requires: %q}", module.Requires)
+}
+
+func forbiddenPatternsDescription(patterns []ForbiddenPattern) string {
+	var parts []string
+	for _, pattern := range patterns {
+		parts = append(parts, fmt.Sprintf("%s %q", pattern.Kind, pattern.Pattern.String()))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(parts, ", "))
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffParams(t *testing.T) {
+	oldParams := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "outputDir",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider("yaml-dir"),
+				Publish:    true,
+			},
+			"project-2": {
+				OutputDir:  "outputDir2",
+				IRProvider: conjureplugin.NewHTTPIRProvider("https://foo.com/ir.json"),
+			},
+		},
+	}
+	newParams := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-3"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "newOutputDir",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider("yaml-dir"),
+				Server:     true,
+			},
+			"project-3": {
+				OutputDir:  "outputDir3",
+				IRProvider: conjureplugin.NewLocalFileIRProvider("ir.json"),
+			},
+		},
+	}
+
+	assert.Empty(t, conjureplugin.DiffParams(oldParams, oldParams))
+	assert.Equal(t, []string{
+		`project-1: output-dir changed from "outputDir" to "newOutputDir"`,
+		`project-1: publish changed from true to false`,
+		`project-1: server changed from false to true`,
+		`project-2: removed (output-dir: "outputDir2", ir-locator: remote "https://foo.com/ir.json", publish: false)`,
+		`project-3: added (output-dir: "outputDir3", ir-locator: ir-file "ir.json", publish: false)`,
+	}, conjureplugin.DiffParams(oldParams, newParams))
+
+	yamlPathsParams := conjureplugin.ConjureProjectParams{
+		SortedKeys: oldParams.SortedKeys,
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "outputDir",
+				IRProvider: conjureplugin.NewLocalYAMLPathsIRProvider([]string{"yaml-dir", "internal-yaml-dir"}),
+				Publish:    true,
+			},
+			"project-2": oldParams.Params["project-2"],
+		},
+	}
+	assert.Equal(t, []string{
+		`project-1: ir-locator changed from yaml "yaml-dir" to yaml ["yaml-dir" "internal-yaml-dir"]`,
+	}, conjureplugin.DiffParams(oldParams, yamlPathsParams))
+}
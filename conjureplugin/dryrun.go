@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/conjureverify"
+)
+
+// DryRunParam returns a parameter that sets whether Run prints the files that it would write, overwrite and delete for
+// every project rather than changing any files on disk. Has no effect if the output is verified.
+func DryRunParam(dryRun bool) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.dryRun = dryRun
+	})
+}
+
+// dryRunPlan records the changes that a dry run would make to the files of a project. Paths are relative to the
+// directory to which the output is written.
+type dryRunPlan struct {
+	write     []string
+	overwrite []string
+	delete    []string
+}
+
+// newDryRunPlan returns the plan that writes the provided files: files that do not exist are written and files whose
+// content differs from the generated content are overwritten. Files whose content is unchanged are not part of the plan.
+func newDryRunPlan(files []renderedFile, writeDir string) (*dryRunPlan, error) {
+	diffs, err := conjureverify.FileDiffs(verifyFiles(files), writeDir)
+	if err != nil {
+		return nil, err
+	}
+	plan := &dryRunPlan{}
+	for _, diff := range diffs {
+		switch diff.Change {
+		case FileChangeMissing:
+			plan.write = append(plan.write, diff.Path)
+		case FileChangeModified:
+			plan.overwrite = append(plan.overwrite, diff.Path)
+		}
+	}
+	return plan, nil
+}
+
+// print prints the plan for the provided project, one line per file, or a single line stating that there are no
+// changes if the plan is empty.
+func (p *dryRunPlan) print(project string, stdout io.Writer) {
+	if p == nil || len(p.write)+len(p.overwrite)+len(p.delete) == 0 {
+		_, _ = fmt.Fprintf(stdout, "[DRY RUN] %s: no changes\n", project)
+		return
+	}
+	for _, action := range []struct {
+		name  string
+		paths []string
+	}{
+		{"write", p.write},
+		{"overwrite", p.overwrite},
+		{"delete", p.delete},
+	} {
+		for _, path := range action.paths {
+			_, _ = fmt.Fprintf(stdout, "[DRY RUN] %s: %s %s\n", project, action.name, filepath.ToSlash(path))
+		}
+	}
+}
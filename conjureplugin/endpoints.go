@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"go/format"
+	"path/filepath"
+	"sort"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/conjure-go/v6/conjure/transforms"
+	"github.com/palantir/conjure-go/v6/conjure/types"
+	"github.com/pkg/errors"
+)
+
+// endpointConstantsFileName is the name of the file that contains the endpoint constants for a package.
+const endpointConstantsFileName = "endpoints" + generatedFileSuffix
+
+// renderEndpointConstantsFiles returns a file for every package of the provided definition that contains services.
+// The file defines exported constants for the names of the services and the names, HTTP methods and HTTP path
+// templates of their endpoints. The files are written to the same directories as the rest of the generated code for
+// the packages.
+func renderEndpointConstantsFiles(conjureDef spec.ConjureDefinition, outputDir string) ([]renderedFile, error) {
+	def, err := types.NewConjureDefinition(outputDir, conjureDef)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration")
+	}
+	var pkgNames []string
+	for pkgName, pkg := range def.Packages {
+		if len(pkg.Services) > 0 {
+			pkgNames = append(pkgNames, pkgName)
+		}
+	}
+	sort.Strings(pkgNames)
+
+	var files []renderedFile
+	for _, pkgName := range pkgNames {
+		pkg := def.Packages[pkgName]
+		f := jen.NewFilePathName(pkg.ImportPath, pkg.PackageName)
+		f.HeaderComment("This file was generated by Conjure and should not be manually edited.")
+		for _, service := range pkg.Services {
+			var defs []jen.Code
+			defs = append(defs, jen.Id(service.Name+"Name").Op("=").Lit(service.Name))
+			for _, endpoint := range service.Endpoints {
+				prefix := service.Name + transforms.Export(endpoint.EndpointName)
+				defs = append(defs,
+					jen.Id(prefix+"EndpointName").Op("=").Lit(endpoint.EndpointName),
+					jen.Id(prefix+"HTTPMethod").Op("=").Lit(endpoint.HTTPMethod.String()),
+					jen.Id(prefix+"HTTPPath").Op("=").Lit(endpoint.HTTPPath),
+				)
+			}
+			f.Commentf("Names, HTTP methods and HTTP path templates of %s and its endpoints.", service.Name)
+			f.Const().Defs(defs...)
+		}
+
+		buf := &bytes.Buffer{}
+		if err := f.Render(buf); err != nil {
+			return nil, errors.Wrapf(err, "failed to generate endpoint constants for package %s", pkgName)
+		}
+		content, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to format endpoint constants for package %s", pkgName)
+		}
+		files = append(files, renderedFile{
+			absPath: filepath.Join(pkg.OutputDir, endpointConstantsFileName),
+			content: content,
+		})
+	}
+	return files, nil
+}
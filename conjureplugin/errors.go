@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"github.com/pkg/errors"
+)
+
+// The errors returned by the operations of this package (such as Run and Publish) and by the functions of the config
+// package that read configuration can be classified using errors.Is with the following errors. An error can belong to
+// more than one class (for example, an error that occurs while obtaining the IR of a project that is being published
+// is both an ErrIR and an ErrPublish). Classifying an error does not change its message.
+var (
+	// ErrInvalidConfig is the class of errors caused by configuration that cannot be read or is invalid.
+	ErrInvalidConfig = errors.New("invalid configuration")
+	// ErrIR is the class of errors that occur while obtaining the IR of a project, such as failures to compile,
+	// download or read IR and IR that cannot be parsed.
+	ErrIR = errors.New("failed to obtain IR")
+	// ErrIRRejected is the class of errors returned if an IR validator rejects the IR of a project. Such errors are
+	// *IRValidationError values that contain the findings of the validators.
+	ErrIRRejected = errors.New("IR rejected by IR validators")
+	// ErrGeneration is the class of errors that occur while generating the code of a project from its IR, including
+	// failures of external generators and generator assets and failures of the checks performed on the generated code
+	// (such as size budgets).
+	ErrGeneration = errors.New("failed to generate code")
+	// ErrVerifyFailed is returned by Run if verification finds that the generated code differs from the code on disk.
+	ErrVerifyFailed = errors.New("conjure verify failed")
+	// ErrBackCompatFailed is returned by BackCompat if the definitions of any project are not backwards compatible.
+	ErrBackCompatFailed = errors.New("conjure backcompat failed")
+	// ErrPublish is the class of errors that occur while publishing the IR of a project.
+	ErrPublish = errors.New("failed to publish IR")
+)
+
+// Classify returns the provided error classified as belonging to the provided class, which is one of the errors above,
+// so that errors.Is reports that it is the class. The message of the returned error is the message of the provided
+// error. Returns nil if err is nil.
+func Classify(err, class error) error {
+	if err == nil || errors.Is(err, class) {
+		return err
+	}
+	return &classifiedError{
+		cause: err,
+		class: class,
+	}
+}
+
+type classifiedError struct {
+	cause error
+	class error
+}
+
+func (e *classifiedError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *classifiedError) Cause() error {
+	return e.cause
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.cause
+}
+
+func (e *classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+// ProjectError is returned by operations that process projects (such as Run and Publish) if processing a project fails.
+// The message of the error is the message of the underlying error, which can be classified using errors.Is.
+type ProjectError struct {
+	// Project is the name of the project.
+	Project string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ProjectError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProjectError) Cause() error {
+	return e.Err
+}
+
+func (e *ProjectError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+const (
+	// generatedTestFileSuffix is the suffix of the Go test files generated by the plugin.
+	generatedTestFileSuffix = ".conjure_test.go"
+	// examplesFileName is the name of the file that contains the examples of the service clients of a package.
+	examplesFileName = "services_example" + generatedTestFileSuffix
+	// exampleServiceURI is the URI of the service used by the examples.
+	exampleServiceURI = "https://localhost:8443/api"
+)
+
+// isGeneratedGoFile returns true if the provided path is the path of a Go file (including a test file) generated by the
+// plugin.
+func isGeneratedGoFile(path string) bool {
+	return strings.HasSuffix(path, generatedFileSuffix) || strings.HasSuffix(path, generatedTestFileSuffix)
+}
+
+// renderExampleFiles returns a "services_example.conjure_test.go" file for every services file in the provided files
+// that defines client constructors. For every constructor that creates a client from an httpclient.Client, the file
+// defines an "Example<Constructor>" function that constructs the client from an httpclient.ClientConfig and calls the
+// first endpoint of the client with zero values for its arguments. The examples do not have output comments, so they
+// are compiled by "go test" but not run. The files are written to the same directories as the services files.
+func renderExampleFiles(files []renderedFile) ([]renderedFile, error) {
+	var examples []renderedFile
+	for _, file := range files {
+		if filepath.Base(file.absPath) != servicesFileName {
+			continue
+		}
+		content, err := renderExampleFile(file.absPath, file.content)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		examples = append(examples, renderedFile{
+			absPath: filepath.Join(filepath.Dir(file.absPath), examplesFileName),
+			content: content,
+		})
+	}
+	return examples, nil
+}
+
+// renderExampleFile returns the content of the file that contains the examples of the client constructors defined by
+// the provided generated services file. Returns nil if the file does not define any client constructors.
+func renderExampleFile(path string, content []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse generated file %s", path)
+	}
+	exprString := func(expr ast.Expr) string {
+		return string(content[fset.Position(expr.Pos()).Offset:fset.Position(expr.End()).Offset])
+	}
+
+	interfaces := make(map[string]*ast.InterfaceType)
+	for _, decl := range astFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok && typeSpec.Name.IsExported() {
+				interfaces[typeSpec.Name.Name] = interfaceType
+			}
+		}
+	}
+
+	body := &bytes.Buffer{}
+	for _, decl := range astFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv != nil || !funcDecl.Name.IsExported() {
+			continue
+		}
+		params, results := funcDecl.Type.Params.List, funcDecl.Type.Results
+		if len(params) != 1 || len(params[0].Names) > 1 || exprString(params[0].Type) != "httpclient.Client" || results == nil || len(results.List) != 1 {
+			continue
+		}
+		resultIdent, ok := results.List[0].Type.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		interfaceType, ok := interfaces[resultIdent.Name]
+		if !ok {
+			continue
+		}
+		if err := writeExample(body, funcDecl.Name.Name, strings.TrimSuffix(resultIdent.Name, "Client"), interfaceType, exprString); err != nil {
+			return nil, errors.Wrapf(err, "failed to generate example of %s in %s", funcDecl.Name.Name, path)
+		}
+	}
+	if body.Len() == 0 {
+		return nil, nil
+	}
+
+	// the examples use the imports of the services file (with the standard library imports grouped before the others),
+	// and the imports that are not used by the examples are removed below
+	var stdImports, otherImports []string
+	for _, importSpec := range astFile.Imports {
+		importLine := importSpec.Path.Value
+		if importSpec.Name != nil {
+			importLine = importSpec.Name.Name + " " + importLine
+		}
+		if isStandardLibraryImport(strings.Trim(importSpec.Path.Value, `"`)) {
+			stdImports = append(stdImports, importLine)
+		} else {
+			otherImports = append(otherImports, importLine)
+		}
+	}
+	src := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(src, "// This file was generated by Conjure and should not be manually edited.\n\npackage %s\n\n", astFile.Name.Name)
+	_, _ = fmt.Fprintf(src, "import (\n%s\n\n%s\n)\n\n", strings.Join(stdImports, "\n"), strings.Join(otherImports, "\n"))
+	src.Write(body.Bytes())
+
+	fset = token.NewFileSet()
+	exampleFile, err := parser.ParseFile(fset, path, src.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse examples of generated file %s", path)
+	}
+	for _, importSpec := range append([]*ast.ImportSpec{}, exampleFile.Imports...) {
+		importPath := strings.Trim(importSpec.Path.Value, `"`)
+		if astutil.UsesImport(exampleFile, importPath) {
+			continue
+		}
+		var name string
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		astutil.DeleteNamedImport(fset, exampleFile, name, importPath)
+	}
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, exampleFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to format examples of generated file %s", path)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeExample writes the example of the provided client constructor, which returns the provided client interface of
+// the service with the provided name, to the provided buffer. The source of the types in the signatures of the methods
+// is obtained using the provided function.
+func writeExample(buf *bytes.Buffer, constructor, serviceName string, interfaceType *ast.InterfaceType, exprString func(ast.Expr) string) error {
+	_, _ = fmt.Fprintf(buf, "func Example%s() {\n", constructor)
+	_, _ = fmt.Fprintf(buf, "\thttpClient, err := httpclient.NewClient(\n\t\thttpclient.WithConfig(httpclient.ClientConfig{\n")
+	_, _ = fmt.Fprintf(buf, "\t\t\tServiceName: %q,\n\t\t\tURIs: []string{%q},\n\t\t}),\n\t)\n", kebabCase(serviceName), exampleServiceURI)
+	_, _ = fmt.Fprintf(buf, "\tif err != nil {\n\t\tpanic(err)\n\t}\n\tclient := %s(httpClient)\n", constructor)
+	if len(interfaceType.Methods.List) == 0 {
+		_, _ = fmt.Fprintf(buf, "\t_ = client\n}\n\n")
+		return nil
+	}
+
+	method := interfaceType.Methods.List[0]
+	funcType, ok := method.Type.(*ast.FuncType)
+	if !ok || len(method.Names) != 1 {
+		return errors.Errorf("embedded interfaces are not supported")
+	}
+	// the arguments are declared as variables with zero values, except for contexts
+	reserved := map[string]struct{}{"httpClient": {}, "client": {}, "err": {}}
+	var vars, args []string
+	for _, param := range funcType.Params.List {
+		typeString := exprString(param.Type)
+		if typeString == "context.Context" {
+			for range max(len(param.Names), 1) {
+				args = append(args, "context.Background()")
+			}
+			continue
+		}
+		names := param.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent("_")}
+		}
+		for _, paramName := range names {
+			argName := paramName.Name
+			if argName == "_" {
+				argName = fmt.Sprintf("arg%d", len(args))
+			}
+			if _, ok := reserved[argName]; ok {
+				argName += "Arg"
+			}
+			if ellipsis, ok := param.Type.(*ast.Ellipsis); ok {
+				vars = append(vars, fmt.Sprintf("%s []%s", argName, exprString(ellipsis.Elt)))
+				argName += "..."
+			} else {
+				vars = append(vars, argName+" "+typeString)
+			}
+			args = append(args, argName)
+		}
+	}
+	if len(vars) > 0 {
+		_, _ = fmt.Fprintf(buf, "\n\tvar (\n\t\t%s\n\t)\n", strings.Join(vars, "\n\t\t"))
+	}
+
+	var results []string
+	if funcType.Results != nil {
+		for _, result := range funcType.Results.List {
+			for range max(len(result.Names), 1) {
+				results = append(results, "_")
+			}
+		}
+	}
+	call := fmt.Sprintf("client.%s(%s)", method.Names[0].Name, strings.Join(args, ", "))
+	switch {
+	case len(results) == 0:
+		_, _ = fmt.Fprintf(buf, "\t%s\n", call)
+	case exprString(funcType.Results.List[len(funcType.Results.List)-1].Type) == "error":
+		results[len(results)-1] = "err"
+		_, _ = fmt.Fprintf(buf, "\tif %s := %s; err != nil {\n\t\tpanic(err)\n\t}\n", strings.Join(results, ", "), call)
+	default:
+		_, _ = fmt.Fprintf(buf, "\t%s = %s\n", strings.Join(results, ", "), call)
+	}
+	_, _ = fmt.Fprintf(buf, "}\n\n")
+	return nil
+}
+
+// kebabCase returns the provided upper camel case name in kebab case (for example, "FooService" is "foo-service").
+func kebabCase(name string) string {
+	var out []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// a new word starts at an upper case letter that follows a lower case letter or that precedes a lower case
+			// letter in a run of upper case letters
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				out = append(out, '-')
+			}
+			r = unicode.ToLower(r)
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
@@ -0,0 +1,408 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/palantir/pkg/safejson"
+	"github.com/palantir/pkg/safeyaml"
+	"github.com/pkg/errors"
+)
+
+// ProjectExtensions specifies the extensions that are added to the IR of a project when it is published. The
+// extensions of the IR, the extensions file, the Git metadata extension and the build metadata extension are merged in
+// that order using the merge strategy.
+type ProjectExtensions struct {
+	// File is the path of a file (relative to the project directory) that contains a JSON object whose entries are
+	// added as extensions. The file is read as YAML if the path has a ".yml" or ".yaml" extension and as JSON
+	// otherwise. The file is read when the IR is published, so it can be generated by other tools as part of the
+	// build. If empty, no file is read.
+	File string
+	// Git adds the Git metadata of the project directory as an extension. If nil, the metadata is not added.
+	Git *GitMetadataExtension
+	// Build adds metadata about the build that publishes the IR as an extension. If nil, the metadata is not added.
+	Build *BuildMetadataExtension
+	// MergeStrategy determines how extensions with the same key provided by multiple sources are merged. If empty,
+	// ExtensionsMergeOverride is used.
+	MergeStrategy ExtensionsMergeStrategy
+	// Schema is the path of a file (relative to the project directory) that contains a JSON Schema that the merged
+	// extensions must match. The IR is not published if they do not. If empty, the extensions are not validated.
+	Schema string
+}
+
+// GitMetadataField is a field of the Git metadata extension.
+type GitMetadataField string
+
+const (
+	// GitMetadataCommit is the SHA of the commit that is checked out.
+	GitMetadataCommit = GitMetadataField("commit")
+	// GitMetadataBranch is the branch that is checked out. Omitted if HEAD is detached.
+	GitMetadataBranch = GitMetadataField("branch")
+	// GitMetadataTag is the tag that points to the commit that is checked out. Omitted if there is no such tag.
+	GitMetadataTag = GitMetadataField("tag")
+	// GitMetadataDirty is true if the working tree has uncommitted changes.
+	GitMetadataDirty = GitMetadataField("dirty")
+)
+
+// GitMetadataFields are all of the fields of the Git metadata extension.
+var GitMetadataFields = []GitMetadataField{
+	GitMetadataCommit,
+	GitMetadataBranch,
+	GitMetadataTag,
+	GitMetadataDirty,
+}
+
+// DefaultGitMetadataExtensionKey is the key of the Git metadata extension if a key is not specified.
+const DefaultGitMetadataExtensionKey = "git"
+
+// GitMetadataExtension adds the Git metadata of the project directory as an extension whose value is an object that
+// has the metadata fields:
+//
+//	{"git": {"commit": "3f0c2d81e4...", "branch": "develop", "tag": "1.2.0", "dirty": false}}
+type GitMetadataExtension struct {
+	// Key is the key of the extension. If empty, DefaultGitMetadataExtensionKey is used.
+	Key string
+	// Fields are the fields of the metadata that are added. If empty, all fields are added.
+	Fields []GitMetadataField
+}
+
+// BuildMetadataField is a field of the build metadata extension.
+type BuildMetadataField string
+
+const (
+	// BuildMetadataTimestamp is the time at which the IR is published in RFC 3339 format (in UTC). If the
+	// SOURCE_DATE_EPOCH environment variable is set, the time that it specifies is used instead so that builds are
+	// reproducible.
+	BuildMetadataTimestamp = BuildMetadataField("timestamp")
+	// BuildMetadataCIJobURL is the URL of the CI job that publishes the IR, which is determined from the environment
+	// variables set by common CI systems. Omitted if it cannot be determined.
+	BuildMetadataCIJobURL = BuildMetadataField("ciJobUrl")
+	// BuildMetadataPluginVersion is the version of the plugin.
+	BuildMetadataPluginVersion = BuildMetadataField("pluginVersion")
+	// BuildMetadataConjureVersion is the version of the Conjure CLI bundled with the plugin.
+	BuildMetadataConjureVersion = BuildMetadataField("conjureVersion")
+)
+
+// BuildMetadataFields are all of the fields of the build metadata extension.
+var BuildMetadataFields = []BuildMetadataField{
+	BuildMetadataTimestamp,
+	BuildMetadataCIJobURL,
+	BuildMetadataPluginVersion,
+	BuildMetadataConjureVersion,
+}
+
+// DefaultBuildMetadataExtensionKey is the key of the build metadata extension if a key is not specified.
+const DefaultBuildMetadataExtensionKey = "build"
+
+// BuildMetadataExtension adds metadata about the build that publishes the IR as an extension whose value is an object
+// that has the metadata fields:
+//
+//	{"build": {"timestamp": "2024-01-02T15:04:05Z", "ciJobUrl": "https://ci.example.com/jobs/1", "pluginVersion": "6.1.0", "conjureVersion": "4.35.0"}}
+type BuildMetadataExtension struct {
+	// Key is the key of the extension. If empty, DefaultBuildMetadataExtensionKey is used.
+	Key string
+	// Fields are the fields of the metadata that are added. If empty, all fields are added.
+	Fields []BuildMetadataField
+}
+
+// ParseGitMetadataField returns the field with the provided name.
+func ParseGitMetadataField(val string) (GitMetadataField, error) {
+	for _, field := range GitMetadataFields {
+		if string(field) == val {
+			return field, nil
+		}
+	}
+	var names []string
+	for _, field := range GitMetadataFields {
+		names = append(names, string(field))
+	}
+	return "", errors.Errorf("unknown Git metadata field %q (must be one of %s)", val, strings.Join(names, ", "))
+}
+
+// ParseBuildMetadataField returns the field with the provided name.
+func ParseBuildMetadataField(val string) (BuildMetadataField, error) {
+	for _, field := range BuildMetadataFields {
+		if string(field) == val {
+			return field, nil
+		}
+	}
+	var names []string
+	for _, field := range BuildMetadataFields {
+		names = append(names, string(field))
+	}
+	return "", errors.Errorf("unknown build metadata field %q (must be one of %s)", val, strings.Join(names, ", "))
+}
+
+func (e ProjectExtensions) String() string {
+	var parts []string
+	if e.MergeStrategy != "" {
+		parts = append(parts, fmt.Sprintf("merge-strategy(%s)", e.MergeStrategy))
+	}
+	if e.File != "" {
+		parts = append(parts, fmt.Sprintf("file(%q)", e.File))
+	}
+	if e.Git != nil {
+		parts = append(parts, fmt.Sprintf("git(key=%s, fields=%v)", e.Git.key(), e.Git.fields()))
+	}
+	if e.Build != nil {
+		parts = append(parts, fmt.Sprintf("build(key=%s, fields=%v)", e.Build.key(), e.Build.fields()))
+	}
+	if e.Schema != "" {
+		parts = append(parts, fmt.Sprintf("schema(%q)", e.Schema))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (e *GitMetadataExtension) key() string {
+	if e.Key == "" {
+		return DefaultGitMetadataExtensionKey
+	}
+	return e.Key
+}
+
+func (e *GitMetadataExtension) fields() []GitMetadataField {
+	if len(e.Fields) == 0 {
+		return GitMetadataFields
+	}
+	return e.Fields
+}
+
+// values returns the Git metadata of the repository that contains the provided directory.
+func (e *GitMetadataExtension) values(projectDir string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, field := range e.fields() {
+		switch field {
+		case GitMetadataCommit:
+			commit, err := gitOutput(projectDir, "rev-parse", "HEAD")
+			if err != nil {
+				return nil, err
+			}
+			values[string(field)] = strings.TrimSpace(commit)
+		case GitMetadataBranch:
+			branch, err := gitOutput(projectDir, "rev-parse", "--abbrev-ref", "HEAD")
+			if err != nil {
+				return nil, err
+			}
+			// the abbreviated name of a detached HEAD is "HEAD"
+			if branch = strings.TrimSpace(branch); branch != "HEAD" {
+				values[string(field)] = branch
+			}
+		case GitMetadataTag:
+			// fails if no tag points to HEAD
+			if tag, err := gitOutput(projectDir, "describe", "--tags", "--exact-match", "HEAD"); err == nil {
+				values[string(field)] = strings.TrimSpace(tag)
+			}
+		case GitMetadataDirty:
+			status, err := gitOutput(projectDir, "status", "--porcelain")
+			if err != nil {
+				return nil, err
+			}
+			values[string(field)] = strings.TrimSpace(status) != ""
+		default:
+			return nil, errors.Errorf("unknown Git metadata field %q", field)
+		}
+	}
+	return values, nil
+}
+
+func (e *BuildMetadataExtension) key() string {
+	if e.Key == "" {
+		return DefaultBuildMetadataExtensionKey
+	}
+	return e.Key
+}
+
+func (e *BuildMetadataExtension) fields() []BuildMetadataField {
+	if len(e.Fields) == 0 {
+		return BuildMetadataFields
+	}
+	return e.Fields
+}
+
+// values returns the metadata of the current build using the provided version of the plugin.
+func (e *BuildMetadataExtension) values(pluginVersion string) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, field := range e.fields() {
+		switch field {
+		case BuildMetadataTimestamp:
+			timestamp, err := buildTimestamp()
+			if err != nil {
+				return nil, err
+			}
+			values[string(field)] = timestamp.UTC().Format(time.RFC3339)
+		case BuildMetadataCIJobURL:
+			if jobURL := ciJobURL(); jobURL != "" {
+				values[string(field)] = jobURL
+			}
+		case BuildMetadataPluginVersion:
+			values[string(field)] = pluginVersion
+		case BuildMetadataConjureVersion:
+			values[string(field)] = conjureircli.CLIVersion
+		default:
+			return nil, errors.Errorf("unknown build metadata field %q", field)
+		}
+	}
+	return values, nil
+}
+
+// buildTimestamp returns the time specified by the SOURCE_DATE_EPOCH environment variable (see
+// https://reproducible-builds.org/specs/source-date-epoch/) if it is set and the current time otherwise.
+func buildTimestamp() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid SOURCE_DATE_EPOCH %q", epoch)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// ciJobURL returns the URL of the current CI job determined from the environment variables set by GitHub Actions,
+// GitLab CI, CircleCI and Jenkins. Returns an empty string if the URL cannot be determined.
+func ciJobURL() string {
+	if server, repo, runID := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && runID != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), repo, runID)
+	}
+	for _, envVar := range []string{"CI_JOB_URL", "CIRCLE_BUILD_URL", "BUILD_URL"} {
+		if val := os.Getenv(envVar); val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// fileExtensions returns the extensions in the file at the provided path, which is resolved against the provided
+// project directory.
+func fileExtensions(path, projectDir string) (map[string]interface{}, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectDir, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read extensions file")
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yml" || ext == ".yaml" {
+		if content, err = safeyaml.YAMLtoJSONBytes(content); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse extensions file %s as YAML", path)
+		}
+	}
+	// numbers are decoded as written so that they do not lose precision
+	var extensions map[string]interface{}
+	if err := safejson.Unmarshal(content, &extensions); err != nil {
+		return nil, errors.Wrapf(err, "extensions file %s must contain a JSON object", path)
+	}
+	return extensions, nil
+}
+
+// sources returns the sources of the extensions specified for the project in the provided directory in the order in
+// which they are merged.
+func (e ProjectExtensions) sources(projectDir, pluginVersion string) ([]extensionsSource, error) {
+	var sources []extensionsSource
+	if e.File != "" {
+		fileExtensions, err := fileExtensions(e.File, projectDir)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, extensionsSource{
+			name:       "extensions-file " + e.File,
+			extensions: fileExtensions,
+		})
+	}
+	if e.Git != nil {
+		values, err := e.Git.values(projectDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine Git metadata")
+		}
+		sources = append(sources, extensionsSource{
+			name:       "git metadata",
+			extensions: map[string]interface{}{e.Git.key(): values},
+		})
+	}
+	if e.Build != nil {
+		values, err := e.Build.values(pluginVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine build metadata")
+		}
+		sources = append(sources, extensionsSource{
+			name:       "build metadata",
+			extensions: map[string]interface{}{e.Build.key(): values},
+		})
+	}
+	return sources, nil
+}
+
+// addProjectExtensions returns the provided IR of the provided project with the extensions specified for the project
+// merged into its extensions and prints the source of every extension. If the project specifies a schema, the merged
+// extensions are validated against it. Returns the IR as-is if the project does not specify any extensions or schema.
+//
+// The IR is reproducible: the same IR and extensions always produce the same bytes. The extensions are marshaled with
+// sorted keys, and the numbers in their values are written in a canonical form (see canonicalNumber) regardless of the
+// source that provides them.
+func addProjectExtensions(projectName string, param ConjureProjectParam, projectDir, pluginVersion string, irBytes []byte, stdout io.Writer) ([]byte, error) {
+	sources, err := param.Extensions.sources(projectDir, pluginVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine extensions of %s", projectName)
+	}
+	if len(sources) == 0 && param.Extensions.Schema == "" {
+		return irBytes, nil
+	}
+	var def spec.ConjureDefinition
+	if err := json.Unmarshal(irBytes, &def); err != nil {
+		return nil, Classify(errors.Wrapf(err, "failed to parse IR of %s", projectName), ErrIR)
+	}
+	sources = append([]extensionsSource{{
+		name:       "IR",
+		extensions: def.Extensions,
+	}}, sources...)
+	for i := range sources {
+		if sources[i].extensions, err = canonicalExtensions(sources[i].extensions); err != nil {
+			return nil, errors.Wrapf(err, "invalid extensions of %s provided by %s", projectName, sources[i].name)
+		}
+	}
+	extensions, keySources, err := mergeExtensions(sources, param.Extensions.MergeStrategy)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to merge extensions of %s", projectName)
+	}
+	if param.Extensions.Schema != "" {
+		if err := validateExtensions(projectName, extensions, param.Extensions.Schema, projectDir); err != nil {
+			return nil, err
+		}
+	}
+	_, _ = fmt.Fprintf(stdout, "Extensions of %s:\n", projectName)
+	for _, k := range sortedExtensionKeys(extensions) {
+		_, _ = fmt.Fprintf(stdout, "%s%s: %s\n", strings.Repeat(" ", indentLen), k, strings.Join(keySources[k], ", "))
+	}
+	def.Extensions = extensions
+	out, err := json.Marshal(def)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal IR of %s", projectName)
+	}
+	return out, nil
+}
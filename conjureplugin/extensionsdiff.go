@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExtensionsDiff prints the differences between the extensions of the IR of the latest release of the provided project
+// published to the provided repository and the extensions of the IR that the current configuration of the project
+// produces. Extensions are compared by key, and values are compared as JSON (so formatting and the order of object keys
+// are ignored). Returns an error if the project has not been released.
+func ExtensionsDiff(params ConjureProjectParams, projectName string, repo PublishedBaseline, stdout io.Writer) error {
+	param, err := params.Param(projectName)
+	if err != nil {
+		return err
+	}
+	currentIR, err := param.IRProvider.IRBytes()
+	if err != nil {
+		return Classify(errors.Wrapf(err, "failed to determine IR of %s", projectName), ErrIR)
+	}
+	publishedIR, version, err := latestPublishedIR(repo, projectName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine published IR of %s", projectName)
+	}
+	if publishedIR == nil {
+		return errors.Errorf("no release of %s has been published to %s", projectName, repo.Repository)
+	}
+	publishedExtensions, err := irExtensions(publishedIR)
+	if err != nil {
+		return errors.Wrapf(err, "invalid published IR of %s", projectName)
+	}
+	currentExtensions, err := irExtensions(currentIR)
+	if err != nil {
+		return errors.Wrapf(err, "invalid IR of %s", projectName)
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Comparing extensions of published version %s of %s with the current configuration\n", version, projectName)
+	keys := make(map[string]struct{})
+	for k := range publishedExtensions {
+		keys[k] = struct{}{}
+	}
+	for k := range currentExtensions {
+		keys[k] = struct{}{}
+	}
+	var sortedKeys []string
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	differences := 0
+	for _, k := range sortedKeys {
+		publishedVal, inPublished := publishedExtensions[k]
+		currentVal, inCurrent := currentExtensions[k]
+		switch {
+		case !inCurrent:
+			_, _ = fmt.Fprintf(stdout, "- %s: %s\n", k, extensionValueString(publishedVal))
+		case !inPublished:
+			_, _ = fmt.Fprintf(stdout, "+ %s: %s\n", k, extensionValueString(currentVal))
+		case !reflect.DeepEqual(publishedVal, currentVal):
+			_, _ = fmt.Fprintf(stdout, "~ %s:\n", k)
+			_, _ = fmt.Fprintf(stdout, "%spublished: %s\n", strings.Repeat(" ", indentLen), extensionValueString(publishedVal))
+			_, _ = fmt.Fprintf(stdout, "%scurrent:   %s\n", strings.Repeat(" ", indentLen), extensionValueString(currentVal))
+		default:
+			continue
+		}
+		differences++
+	}
+	if differences == 0 {
+		_, _ = fmt.Fprintln(stdout, "Extensions are identical")
+	}
+	return nil
+}
+
+// irExtensions returns the extensions of the provided IR keyed by name. The values are decoded from JSON.
+func irExtensions(irBytes []byte) (map[string]interface{}, error) {
+	var ir struct {
+		Extensions map[string]interface{} `json:"extensions"`
+	}
+	if err := json.Unmarshal(irBytes, &ir); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse extensions of IR")
+	}
+	return ir.Extensions, nil
+}
+
+// extensionValueString returns the compact JSON representation of the provided extension value (in which object keys
+// are sorted).
+func extensionValueString(val interface{}) string {
+	out, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(out)
+}
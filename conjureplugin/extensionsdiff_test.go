@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtensionsDiff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/artifactory/releases/com/palantir/test/project-1/maven-metadata.xml":
+			_, _ = w.Write([]byte(`<metadata><versioning><release>1.0.0</release></versioning></metadata>`))
+		case "/artifactory/releases/com/palantir/test/project-1/1.0.0/project-1-1.0.0.conjure.json":
+			_, _ = w.Write([]byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{"removed":true,"changed":{"a":1,"b":2},"same":{"x":"y","z":[1]}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	irFile := filepath.Join(dir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{"same":{"z":[1],"x":"y"},"changed":{"a":1,"b":3},"added":"value"}}`), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+			"project-2": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+	repo := conjureplugin.PublishedBaseline{
+		URL:        server.URL,
+		Repository: "releases",
+		GroupID:    "com.palantir.test",
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.ExtensionsDiff(params, "project-1", repo, buf))
+	assert.Equal(t, `Comparing extensions of published version 1.0.0 of project-1 with the current configuration
++ added: "value"
+~ changed:
+  published: {"a":1,"b":2}
+  current:   {"a":1,"b":3}
+- removed: true
+`, buf.String())
+
+	err := conjureplugin.ExtensionsDiff(params, "project-2", repo, &bytes.Buffer{})
+	require.EqualError(t, err, "no release of project-2 has been published to releases")
+}
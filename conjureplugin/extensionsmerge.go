@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExtensionsMergeStrategy determines how extensions with the same key provided by multiple sources are merged.
+type ExtensionsMergeStrategy string
+
+const (
+	// ExtensionsMergeOverride specifies that the value of the last source that provides an extension is used.
+	ExtensionsMergeOverride = ExtensionsMergeStrategy("override")
+	// ExtensionsMergeFirstWins specifies that the value of the first source that provides an extension is used.
+	ExtensionsMergeFirstWins = ExtensionsMergeStrategy("first-wins")
+	// ExtensionsMergeDeep specifies that values that are objects are merged recursively. Other values are replaced
+	// by the value of the last source that provides them.
+	ExtensionsMergeDeep = ExtensionsMergeStrategy("deep-merge")
+	// ExtensionsMergeError specifies that merging fails if multiple sources provide the same extension.
+	ExtensionsMergeError = ExtensionsMergeStrategy("error")
+)
+
+// ExtensionsMergeStrategies are all of the merge strategies.
+var ExtensionsMergeStrategies = []ExtensionsMergeStrategy{
+	ExtensionsMergeOverride,
+	ExtensionsMergeFirstWins,
+	ExtensionsMergeDeep,
+	ExtensionsMergeError,
+}
+
+// ParseExtensionsMergeStrategy returns the merge strategy with the provided name.
+func ParseExtensionsMergeStrategy(val string) (ExtensionsMergeStrategy, error) {
+	var names []string
+	for _, strategy := range ExtensionsMergeStrategies {
+		if string(strategy) == val {
+			return strategy, nil
+		}
+		names = append(names, string(strategy))
+	}
+	return "", errors.Errorf("unknown merge strategy %q (must be one of %s)", val, strings.Join(names, ", "))
+}
+
+// extensionsSource is a source of extensions.
+type extensionsSource struct {
+	// name describes the source in output and errors.
+	name       string
+	extensions map[string]interface{}
+}
+
+// mergeExtensions merges the extensions of the provided sources in order using the provided strategy. Returns the
+// merged extensions and the names of the sources that supplied every extension keyed by the key of the extension.
+func mergeExtensions(sources []extensionsSource, strategy ExtensionsMergeStrategy) (map[string]interface{}, map[string][]string, error) {
+	if strategy == "" {
+		strategy = ExtensionsMergeOverride
+	}
+	merged := make(map[string]interface{})
+	keySources := make(map[string][]string)
+	for _, source := range sources {
+		for _, k := range sortedExtensionKeys(source.extensions) {
+			v := source.extensions[k]
+			existing, ok := merged[k]
+			if !ok {
+				merged[k] = v
+				keySources[k] = []string{source.name}
+				continue
+			}
+			switch strategy {
+			case ExtensionsMergeOverride:
+				merged[k] = v
+				keySources[k] = []string{source.name}
+			case ExtensionsMergeFirstWins:
+			case ExtensionsMergeDeep:
+				merged[k] = deepMergeExtension(existing, v)
+				keySources[k] = append(keySources[k], source.name)
+			case ExtensionsMergeError:
+				return nil, nil, errors.Errorf("extension %s is provided by both %s and %s", k, strings.Join(keySources[k], ", "), source.name)
+			default:
+				return nil, nil, errors.Errorf("unknown merge strategy %q", strategy)
+			}
+		}
+	}
+	return merged, keySources, nil
+}
+
+// deepMergeExtension returns the result of merging the provided value into the provided existing value. If both
+// values are objects, their entries are merged recursively. Otherwise, the provided value is returned.
+func deepMergeExtension(existing, val interface{}) interface{} {
+	existingObj, ok := existing.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	merged := make(map[string]interface{}, len(existingObj))
+	for k, v := range existingObj {
+		merged[k] = v
+	}
+	for k, v := range obj {
+		if existingVal, ok := merged[k]; ok {
+			v = deepMergeExtension(existingVal, v)
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// sortedExtensionKeys returns the keys of the provided extensions in sorted order.
+func sortedExtensionKeys(extensions map[string]interface{}) []string {
+	keys := make([]string, 0, len(extensions))
+	for k := range extensions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// integerLiteral matches JSON numbers that are integers written without a fraction or exponent.
+var integerLiteral = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+
+// canonicalExtensions returns the provided extensions with every number in their values replaced by its canonical
+// representation so that the IR marshaled from them does not depend on how the numbers were written in their source.
+// Objects are marshaled with sorted keys, so the canonical extensions always marshal to the same bytes.
+func canonicalExtensions(extensions map[string]interface{}) (map[string]interface{}, error) {
+	if extensions == nil {
+		return nil, nil
+	}
+	canonical, err := canonicalExtensionValue(extensions)
+	if err != nil {
+		return nil, err
+	}
+	return canonical.(map[string]interface{}), nil
+}
+
+// canonicalExtensionValue returns the provided extension value with every number replaced by its canonical
+// representation (see canonicalNumber).
+func canonicalExtensionValue(val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		canonical := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			canonicalElem, err := canonicalExtensionValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			canonical[k] = canonicalElem
+		}
+		return canonical, nil
+	case []interface{}:
+		canonical := make([]interface{}, len(v))
+		for i, elem := range v {
+			canonicalElem, err := canonicalExtensionValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			canonical[i] = canonicalElem
+		}
+		return canonical, nil
+	case json.Number:
+		return canonicalNumber(string(v))
+	case float64:
+		return canonicalNumber(strconv.FormatFloat(v, 'g', -1, 64))
+	default:
+		return val, nil
+	}
+}
+
+// canonicalNumber returns the canonical representation of the provided JSON number. Integers written without a
+// fraction or exponent are preserved as-is so that they do not lose precision. Other numbers are parsed as 64-bit
+// floating point numbers and formatted as encoding/json formats them, so "1.0", "1e0" and "1" are all represented as
+// "1" and "0.50" is represented as "0.5".
+func canonicalNumber(val string) (json.Number, error) {
+	if integerLiteral.MatchString(val) {
+		if val == "-0" {
+			return "0", nil
+		}
+		return json.Number(val), nil
+	}
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid number %s", val)
+	}
+	out, err := json.Marshal(f)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid number %s", val)
+	}
+	return json.Number(out), nil
+}
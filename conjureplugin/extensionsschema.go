@@ -0,0 +1,358 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// validateExtensions validates the provided extensions of the provided project against the JSON Schema in the file at
+// the provided path (which is resolved against the provided project directory). Returns an error that lists every
+// violation if the extensions do not match the schema.
+//
+// The validation supports the following keywords, which cover the schemas typically used to describe extensions:
+// "$ref" (references within the schema document such as "#/definitions/Name"), "type", "enum", "const", "properties",
+// "required", "additionalProperties", "items", "minItems", "maxItems", "minLength", "maxLength", "pattern", "minimum",
+// "maximum", "exclusiveMinimum", "exclusiveMaximum", "allOf", "anyOf", "oneOf" and "not". Other keywords are ignored.
+func validateExtensions(projectName string, extensions map[string]interface{}, schemaPath, projectDir string) error {
+	path := schemaPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectDir, path)
+	}
+	schemaBytes, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read extensions schema")
+	}
+	var schema interface{}
+	if err := json.Unmarshal(schemaBytes, &schema); err != nil {
+		return errors.Wrapf(err, "failed to parse extensions schema %s", schemaPath)
+	}
+	// the extensions are converted to the representation used by encoding/json so that numbers are float64 values
+	extensionsBytes, err := json.Marshal(extensions)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal extensions")
+	}
+	var val interface{}
+	if err := json.Unmarshal(extensionsBytes, &val); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal extensions")
+	}
+	v := &schemaValidator{root: schema}
+	if err := v.validate(schema, val, ""); err != nil {
+		return err
+	}
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return errors.Errorf("extensions of %s do not match schema %s:\n%s", projectName, schemaPath, indent(strings.Join(v.violations, "\n"), indentLen))
+}
+
+// schemaValidator validates values against a JSON Schema document.
+type schemaValidator struct {
+	root       interface{}
+	violations []string
+}
+
+// validate validates the provided value at the provided JSON pointer against the provided schema and records the
+// violations. Returns an error if the schema is invalid.
+func (v *schemaValidator) validate(schema, val interface{}, ptr string) error {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.violate(ptr, "no value is allowed")
+		}
+		return nil
+	case map[string]interface{}:
+		return v.validateObjectSchema(s, val, ptr)
+	default:
+		return errors.Errorf("invalid schema at %s: must be an object or a boolean", pointerString(ptr))
+	}
+}
+
+func (v *schemaValidator) validateObjectSchema(schema map[string]interface{}, val interface{}, ptr string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		refSchema, err := v.resolveRef(ref)
+		if err != nil {
+			return err
+		}
+		if err := v.validate(refSchema, val, ptr); err != nil {
+			return err
+		}
+	}
+	if types, ok := schema["type"]; ok {
+		var names []string
+		switch t := types.(type) {
+		case string:
+			names = []string{t}
+		case []interface{}:
+			for _, name := range t {
+				names = append(names, fmt.Sprint(name))
+			}
+		}
+		matched := false
+		for _, name := range names {
+			if jsonTypeMatches(name, val) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.violate(ptr, "must be of type %s but is %s", strings.Join(names, " or "), jsonTypeName(val))
+			// the remaining keywords are not meaningful for values of the wrong type
+			return nil
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, val) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.violate(ptr, "must be one of %s", extensionValueString(enum))
+		}
+	}
+	if constVal, ok := schema["const"]; ok && !reflect.DeepEqual(constVal, val) {
+		v.violate(ptr, "must be %s", extensionValueString(constVal))
+	}
+
+	switch typedVal := val.(type) {
+	case map[string]interface{}:
+		if err := v.validateObject(schema, typedVal, ptr); err != nil {
+			return err
+		}
+	case []interface{}:
+		if items, ok := schema["items"]; ok {
+			for i, elem := range typedVal {
+				if err := v.validate(items, elem, ptr+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(typedVal)) < min {
+			v.violate(ptr, "must have at least %v items", min)
+		}
+		if max, ok := schemaNumber(schema, "maxItems"); ok && float64(len(typedVal)) > max {
+			v.violate(ptr, "must have at most %v items", max)
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(typedVal))
+		if min, ok := schemaNumber(schema, "minLength"); ok && length < min {
+			v.violate(ptr, "must be at least %v characters long", min)
+		}
+		if max, ok := schemaNumber(schema, "maxLength"); ok && length > max {
+			v.violate(ptr, "must be at most %v characters long", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return errors.Wrapf(err, "invalid pattern in schema at %s", pointerString(ptr))
+			}
+			if !re.MatchString(typedVal) {
+				v.violate(ptr, "must match pattern %s", pattern)
+			}
+		}
+	case float64:
+		if min, ok := schemaNumber(schema, "minimum"); ok && typedVal < min {
+			v.violate(ptr, "must be at least %v", min)
+		}
+		if max, ok := schemaNumber(schema, "maximum"); ok && typedVal > max {
+			v.violate(ptr, "must be at most %v", max)
+		}
+		if min, ok := schemaNumber(schema, "exclusiveMinimum"); ok && typedVal <= min {
+			v.violate(ptr, "must be greater than %v", min)
+		}
+		if max, ok := schemaNumber(schema, "exclusiveMaximum"); ok && typedVal >= max {
+			v.violate(ptr, "must be less than %v", max)
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, subschema := range allOf {
+			if err := v.validate(subschema, val, ptr); err != nil {
+				return err
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		matches, err := v.matchingSubschemas(anyOf, val, ptr)
+		if err != nil {
+			return err
+		}
+		if matches == 0 {
+			v.violate(ptr, "must match at least one schema in anyOf")
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		matches, err := v.matchingSubschemas(oneOf, val, ptr)
+		if err != nil {
+			return err
+		}
+		if matches != 1 {
+			v.violate(ptr, "must match exactly one schema in oneOf but matches %d", matches)
+		}
+	}
+	if not, ok := schema["not"]; ok {
+		matches, err := v.matchingSubschemas([]interface{}{not}, val, ptr)
+		if err != nil {
+			return err
+		}
+		if matches > 0 {
+			v.violate(ptr, "must not match the schema in not")
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateObject(schema, obj map[string]interface{}, ptr string) error {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, ok := obj[fmt.Sprint(name)]; !ok {
+				v.violate(ptr, "missing required property %s", name)
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		propPtr := ptr + "/" + escapePointerToken(k)
+		if propSchema, ok := properties[k]; ok {
+			if err := v.validate(propSchema, obj[k], propPtr); err != nil {
+				return err
+			}
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			v.violate(ptr, "property %s is not allowed", k)
+			continue
+		}
+		if err := v.validate(additional, obj[k], propPtr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchingSubschemas returns the number of the provided subschemas that the provided value matches.
+func (v *schemaValidator) matchingSubschemas(subschemas []interface{}, val interface{}, ptr string) (int, error) {
+	matches := 0
+	for _, subschema := range subschemas {
+		subValidator := &schemaValidator{root: v.root}
+		if err := subValidator.validate(subschema, val, ptr); err != nil {
+			return 0, err
+		}
+		if len(subValidator.violations) == 0 {
+			matches++
+		}
+	}
+	return matches, nil
+}
+
+// resolveRef returns the schema referenced by the provided reference, which must be a JSON pointer within the schema
+// document (such as "#/definitions/Name").
+func (v *schemaValidator) resolveRef(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, errors.Errorf("unsupported $ref %s: only references within the schema are supported", ref)
+	}
+	current := v.root
+	pointer := strings.TrimPrefix(ref, "#")
+	if pointer == "" {
+		return current, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("$ref %s does not resolve to a schema", ref)
+		}
+		if current, ok = obj[token]; !ok {
+			return nil, errors.Errorf("$ref %s does not resolve to a schema", ref)
+		}
+	}
+	return current, nil
+}
+
+func (v *schemaValidator) violate(ptr, format string, args ...interface{}) {
+	v.violations = append(v.violations, fmt.Sprintf("%s: %s", pointerString(ptr), fmt.Sprintf(format, args...)))
+}
+
+// jsonTypeMatches returns true if the provided value is of the JSON Schema type with the provided name.
+func jsonTypeMatches(name string, val interface{}) bool {
+	if name == "integer" {
+		f, ok := val.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return name == jsonTypeName(val) || (name == "number" && jsonTypeName(val) == "integer")
+}
+
+// jsonTypeName returns the name of the JSON Schema type of the provided value.
+func jsonTypeName(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// schemaNumber returns the value of the provided numeric keyword of the provided schema.
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := schema[keyword].(float64)
+	return n, ok
+}
+
+// pointerString returns the provided JSON pointer in the form used in violations.
+func pointerString(ptr string) string {
+	if ptr == "" {
+		return "/"
+	}
+	return ptr
+}
+
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/pkg/errors"
+)
+
+// ExternalGenerator specifies an executable that generates the code of a project instead of the vendored conjure-go
+// generator. The executable is run in the project directory with the arguments in Args followed by the path of a file
+// that contains the IR and the path of the output directory.
+type ExternalGenerator struct {
+	// Path is the path of the executable. Relative paths are resolved against the project directory, and paths
+	// without a separator are looked up in the PATH.
+	Path string
+	// Args are the arguments provided to the executable before the path of the IR file and the output directory.
+	Args []string
+}
+
+// renderExternalGeneratorFiles returns the files generated by running the provided external generator for the provided
+// IR. The generator writes its output directly to the provided output directory, so the directory is copied before the
+// generator is run and restored afterwards: generated files are only written or verified by the caller. Only the files
+// with the generated file suffix that the generator writes are returned.
+func renderExternalGeneratorFiles(generator ExternalGenerator, irBytes []byte, outputDir, projectDir string) (rFiles []renderedFile, rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("external-generator")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	if err := os.WriteFile(irFile, irBytes, 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write IR for external generator")
+	}
+
+	backupDir := filepath.Join(tmpDir, "backup")
+	_, err = os.Stat(outputDir)
+	outputDirExists := err == nil
+	if outputDirExists {
+		if err := copyDir(outputDir, backupDir); err != nil {
+			return nil, errors.Wrapf(err, "failed to back up output directory %s", outputDir)
+		}
+	}
+	defer func() {
+		if err := os.RemoveAll(outputDir); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to restore output directory %s", outputDir)
+			return
+		}
+		if !outputDirExists {
+			return
+		}
+		if err := copyDir(backupDir, outputDir); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to restore output directory %s", outputDir)
+		}
+	}()
+
+	// remove the previously generated files so that only the files written by the generator are returned
+	if outputDirExists {
+		if err := forEachGeneratedFile(outputDir, os.Remove); err != nil {
+			return nil, errors.Wrapf(err, "failed to remove generated files from output directory %s", outputDir)
+		}
+	}
+	cmd := exec.Command(generator.Path, append(append([]string{}, generator.Args...), irFile, outputDir)...)
+	cmd.Dir = projectDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, errors.Wrapf(err, "external generator %s failed:\n%s", generator.Path, strings.TrimSpace(string(output)))
+	}
+
+	var files []renderedFile
+	if err := forEachGeneratedFile(outputDir, func(path string) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, renderedFile{
+			absPath: path,
+			content: content,
+		})
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to read files generated by external generator %s", generator.Path)
+	}
+	return files, nil
+}
+
+// externalGeneratorFingerprint returns a value that identifies the provided external generator and its executable, so
+// that code is regenerated after the executable is updated.
+func externalGeneratorFingerprint(generator ExternalGenerator, projectDir string) string {
+	fingerprint := fmt.Sprintf("external:%s:%q", generator.Path, generator.Args)
+	executable := generator.Path
+	if !strings.ContainsRune(executable, filepath.Separator) {
+		if lookedUp, err := exec.LookPath(executable); err == nil {
+			executable = lookedUp
+		}
+	} else if !filepath.IsAbs(executable) {
+		executable = filepath.Join(projectDir, executable)
+	}
+	if fi, err := os.Stat(executable); err == nil {
+		fingerprint += fmt.Sprintf(":%d:%d", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return fingerprint
+}
+
+// forEachGeneratedFile calls the provided function with the path of every file in the provided directory (or its
+// subdirectories) that has the generated file suffix. The directory must exist.
+func forEachGeneratedFile(dir string, fn func(path string) error) error {
+	var paths []string
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), generatedFileSuffix) {
+			paths = append(paths, path)
+		}
+		return nil
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, path := range paths {
+		if err := fn(path); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// copyDir copies the files in the provided source directory and its subdirectories to the provided destination
+// directory, preserving their permissions.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, relPath)
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(dstPath, fi.Mode().Perm())
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dstPath, content, fi.Mode().Perm())
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// FileModePolicy specifies how unexpected symlinks, executable files and generated files whose permissions differ from
+// generatedFileMode in the output directory of a project are handled.
+type FileModePolicy string
+
+const (
+	// FileModePolicyIgnore specifies that file modes are not checked. This is the default.
+	FileModePolicyIgnore = FileModePolicy("ignore")
+	// FileModePolicyReport specifies that unexpected file modes fail verification and are printed as warnings when
+	// code is generated.
+	FileModePolicyReport = FileModePolicy("report")
+	// FileModePolicyNormalize specifies that unexpected file modes fail verification and are normalized when code is
+	// generated: symlinks are removed, executable permissions are removed and the permissions of generated files are
+	// set to generatedFileMode.
+	FileModePolicyNormalize = FileModePolicy("normalize")
+)
+
+// generatedFileMode is the expected permissions of generated files.
+const generatedFileMode = fs.FileMode(0644)
+
+// fileModeIssues returns a description of every symlink, executable file and generated file whose permissions are not
+// generatedFileMode in the provided output directory. The provided files are the files generated in the directory.
+// Paths are reported relative to baseDir. The returned descriptions are sorted.
+func fileModeIssues(outputDir, baseDir string, files []renderedFile) ([]string, error) {
+	generated := make(map[string]struct{})
+	for _, file := range files {
+		generated[file.absPath] = struct{}{}
+	}
+	var issues []string
+	if err := walkOutputDir(outputDir, func(path string, d fs.DirEntry) error {
+		relPath, err := filepath.Rel(baseDir, path)
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			issues = append(issues, fmt.Sprintf("symlink: %s", relPath))
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := fi.Mode().Perm()
+		if _, ok := generated[path]; ok && mode != generatedFileMode {
+			issues = append(issues, fmt.Sprintf("permissions: %s has mode %#o rather than %#o", relPath, mode, generatedFileMode))
+		} else if !ok && mode&0111 != 0 {
+			issues = append(issues, fmt.Sprintf("executable: %s has mode %#o", relPath, mode))
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to check file modes in output directory %s", outputDir)
+	}
+	sort.Strings(issues)
+	return issues, nil
+}
+
+// normalizeFileModes removes the symlinks in the provided output directory and the executable permissions of its
+// files, and sets the permissions of the provided generated files that exist to generatedFileMode. Symlinks are removed
+// before generated files are written so that writing a generated file never modifies the target of a symlink.
+func normalizeFileModes(outputDir string, files []renderedFile) error {
+	generated := make(map[string]struct{})
+	for _, file := range files {
+		generated[file.absPath] = struct{}{}
+	}
+	if err := walkOutputDir(outputDir, func(path string, d fs.DirEntry) error {
+		if d.Type()&fs.ModeSymlink != 0 {
+			return os.Remove(path)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		mode := fi.Mode().Perm()
+		wantMode := mode &^ 0111
+		if _, ok := generated[path]; ok {
+			wantMode = generatedFileMode
+		}
+		if mode == wantMode {
+			return nil
+		}
+		return os.Chmod(path, wantMode)
+	}); err != nil {
+		return errors.Wrapf(err, "failed to normalize file modes in output directory %s", outputDir)
+	}
+	return nil
+}
+
+// walkOutputDir calls the provided function for every entry in the provided output directory other than the directory
+// itself. Symlinks are not followed. Does nothing if the directory does not exist.
+func walkOutputDir(outputDir string, fn func(path string, d fs.DirEntry) error) error {
+	if _, err := os.Lstat(outputDir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == outputDir {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
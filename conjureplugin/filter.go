@@ -0,0 +1,323 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+// DefinitionFilter selects the definitions in the IR of a project for which code is generated, which allows a project
+// to only generate code for a subset of a large shared IR. Names of services and types may be simple (such as
+// "FooService") or qualified (such as "com.example.FooService"), and a simple name matches every definition with that
+// name. Packages match the definitions in the package and in its subpackages.
+//
+// If IncludeServices or IncludeTypes is non-empty, only the included services and types are selected. Otherwise, all of
+// the definitions are selected. Definitions that are excluded or that are not in an included package are then removed.
+// The types referenced by the selected definitions are always retained so that the generated code compiles, and it is
+// an error for such a type to be excluded. Errors are treated as types.
+type DefinitionFilter struct {
+	IncludeServices []string
+	ExcludeServices []string
+	IncludeTypes    []string
+	ExcludeTypes    []string
+	IncludePackages []string
+	ExcludePackages []string
+}
+
+// filterDefinition returns the provided definition with only the definitions selected by the provided filter. Returns
+// the definition unmodified if the filter is nil.
+func filterDefinition(def spec.ConjureDefinition, filter *DefinitionFilter) (spec.ConjureDefinition, error) {
+	if filter == nil {
+		return def, nil
+	}
+	// typeRefsByName maps the qualified names of all of the types and errors to the types that they reference
+	typeRefsByName := make(map[string][]spec.TypeName)
+	var typeNames []spec.TypeName
+	for _, typeDef := range def.Types {
+		typeName, refs := typeDefinitionRefs(typeDef)
+		typeRefsByName[qualifiedName(typeName)] = refs
+		typeNames = append(typeNames, typeName)
+	}
+	for _, errorDef := range def.Errors {
+		var refs []spec.TypeName
+		for _, field := range append(append([]spec.FieldDefinition{}, errorDef.SafeArgs...), errorDef.UnsafeArgs...) {
+			refs = append(refs, typeRefs(field.Type)...)
+		}
+		typeRefsByName[qualifiedName(errorDef.ErrorName)] = refs
+		typeNames = append(typeNames, errorDef.ErrorName)
+	}
+	var serviceNames []spec.TypeName
+	for _, service := range def.Services {
+		serviceNames = append(serviceNames, service.ServiceName)
+	}
+
+	includedServices, err := matchFilterNames("include-services", "service", filter.IncludeServices, serviceNames)
+	if err != nil {
+		return spec.ConjureDefinition{}, err
+	}
+	excludedServices, err := matchFilterNames("exclude-services", "service", filter.ExcludeServices, serviceNames)
+	if err != nil {
+		return spec.ConjureDefinition{}, err
+	}
+	includedTypes, err := matchFilterNames("include-types", "type", filter.IncludeTypes, typeNames)
+	if err != nil {
+		return spec.ConjureDefinition{}, err
+	}
+	excludedTypes, err := matchFilterNames("exclude-types", "type", filter.ExcludeTypes, typeNames)
+	if err != nil {
+		return spec.ConjureDefinition{}, err
+	}
+	includeAll := len(filter.IncludeServices) == 0 && len(filter.IncludeTypes) == 0
+	selected := func(name spec.TypeName, included, excluded map[string]struct{}) bool {
+		qualified := qualifiedName(name)
+		if _, ok := excluded[qualified]; ok {
+			return false
+		}
+		if !filter.packageSelected(name.Package) {
+			return false
+		}
+		_, ok := included[qualified]
+		return includeAll || ok
+	}
+
+	// requiredBy records the definition that first required each retained type
+	requiredBy := make(map[string]string)
+	var queue []string
+	require := func(typeName spec.TypeName, by string) error {
+		qualified := qualifiedName(typeName)
+		if _, ok := requiredBy[qualified]; ok {
+			return nil
+		}
+		if _, ok := typeRefsByName[qualified]; !ok {
+			// references to types that are not defined in the IR are reported by the generator
+			return nil
+		}
+		if by != "" {
+			if _, ok := excludedTypes[qualified]; ok || !filter.packageSelected(typeName.Package) {
+				return errors.Errorf("type %s is referenced by %s, but is excluded by the filter", qualified, by)
+			}
+		}
+		requiredBy[qualified] = by
+		queue = append(queue, qualified)
+		return nil
+	}
+
+	var services []spec.ServiceDefinition
+	for _, service := range def.Services {
+		if !selected(service.ServiceName, includedServices, excludedServices) {
+			continue
+		}
+		services = append(services, service)
+		for _, ref := range serviceRefs(service) {
+			if err := require(ref, "service "+qualifiedName(service.ServiceName)); err != nil {
+				return spec.ConjureDefinition{}, err
+			}
+		}
+	}
+	for _, typeName := range typeNames {
+		if selected(typeName, includedTypes, excludedTypes) {
+			if err := require(typeName, ""); err != nil {
+				return spec.ConjureDefinition{}, err
+			}
+		}
+	}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		for _, ref := range typeRefsByName[curr] {
+			if err := require(ref, "type "+curr); err != nil {
+				return spec.ConjureDefinition{}, err
+			}
+		}
+	}
+
+	out := def
+	out.Services = services
+	out.Types = nil
+	for _, typeDef := range def.Types {
+		typeName, _ := typeDefinitionRefs(typeDef)
+		if _, ok := requiredBy[qualifiedName(typeName)]; ok {
+			out.Types = append(out.Types, typeDef)
+		}
+	}
+	out.Errors = nil
+	for _, errorDef := range def.Errors {
+		if _, ok := requiredBy[qualifiedName(errorDef.ErrorName)]; ok {
+			out.Errors = append(out.Errors, errorDef)
+		}
+	}
+	return out, nil
+}
+
+// packageSelected returns true if definitions in the provided package are selected by the package filters of the
+// filter.
+func (f *DefinitionFilter) packageSelected(pkg string) bool {
+	inPackage := func(prefix string) bool {
+		return pkg == prefix || strings.HasPrefix(pkg, prefix+".")
+	}
+	for _, excluded := range f.ExcludePackages {
+		if inPackage(excluded) {
+			return false
+		}
+	}
+	if len(f.IncludePackages) == 0 {
+		return true
+	}
+	for _, included := range f.IncludePackages {
+		if inPackage(included) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFilterNames returns the qualified names of the definitions matched by the provided simple or qualified names.
+// Returns an error if any of the names does not match a definition.
+func matchFilterNames(field, kind string, names []string, defs []spec.TypeName) (map[string]struct{}, error) {
+	out := make(map[string]struct{})
+	for _, name := range names {
+		matched := false
+		for _, def := range defs {
+			if def.Name == name || qualifiedName(def) == name {
+				out[qualifiedName(def)] = struct{}{}
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, errors.Errorf("%s specifies %s %s, which is not defined", field, kind, name)
+		}
+	}
+	return out, nil
+}
+
+// typeDefinitionRefs returns the name of the provided type definition and the types that it references.
+func typeDefinitionRefs(typeDef spec.TypeDefinition) (spec.TypeName, []spec.TypeName) {
+	var name spec.TypeName
+	var refs []spec.TypeName
+	addFields := func(fields []spec.FieldDefinition) {
+		for _, field := range fields {
+			refs = append(refs, typeRefs(field.Type)...)
+		}
+	}
+	_ = typeDef.AcceptFuncs(
+		func(alias spec.AliasDefinition) error {
+			name = alias.TypeName
+			refs = typeRefs(alias.Alias)
+			return nil
+		},
+		func(enum spec.EnumDefinition) error {
+			name = enum.TypeName
+			return nil
+		},
+		func(object spec.ObjectDefinition) error {
+			name = object.TypeName
+			addFields(object.Fields)
+			return nil
+		},
+		func(union spec.UnionDefinition) error {
+			name = union.TypeName
+			addFields(union.Union)
+			return nil
+		},
+		typeDef.ErrorOnUnknown,
+	)
+	return name, refs
+}
+
+// serviceRefs returns the types referenced by the endpoints of the provided service.
+func serviceRefs(service spec.ServiceDefinition) []spec.TypeName {
+	var refs []spec.TypeName
+	for _, endpoint := range service.Endpoints {
+		if endpoint.Returns != nil {
+			refs = append(refs, typeRefs(*endpoint.Returns)...)
+		}
+		for _, arg := range endpoint.Args {
+			refs = append(refs, typeRefs(arg.Type)...)
+			for _, marker := range arg.Markers {
+				refs = append(refs, typeRefs(marker)...)
+			}
+		}
+		for _, marker := range endpoint.Markers {
+			refs = append(refs, typeRefs(marker)...)
+		}
+	}
+	return refs
+}
+
+// typeRefs returns the names of the types referenced by the provided type, including the fallback types of external
+// references.
+func typeRefs(t spec.Type) []spec.TypeName {
+	var out []spec.TypeName
+	_ = t.AcceptFuncs(
+		t.PrimitiveNoopSuccess,
+		func(optional spec.OptionalType) error {
+			out = typeRefs(optional.ItemType)
+			return nil
+		},
+		func(list spec.ListType) error {
+			out = typeRefs(list.ItemType)
+			return nil
+		},
+		func(set spec.SetType) error {
+			out = typeRefs(set.ItemType)
+			return nil
+		},
+		func(mapType spec.MapType) error {
+			out = append(typeRefs(mapType.KeyType), typeRefs(mapType.ValueType)...)
+			return nil
+		},
+		func(reference spec.TypeName) error {
+			out = []spec.TypeName{reference}
+			return nil
+		},
+		func(external spec.ExternalReference) error {
+			out = typeRefs(external.Fallback)
+			return nil
+		},
+		t.ErrorOnUnknown,
+	)
+	return out
+}
+
+// filterDescription returns a description of the provided filter.
+func filterDescription(filter *DefinitionFilter) string {
+	if filter == nil {
+		return "none"
+	}
+	var parts []string
+	for _, part := range []struct {
+		name   string
+		values []string
+	}{
+		{"include-services", filter.IncludeServices},
+		{"exclude-services", filter.ExcludeServices},
+		{"include-types", filter.IncludeTypes},
+		{"exclude-types", filter.ExcludeTypes},
+		{"include-packages", filter.IncludePackages},
+		{"exclude-packages", filter.ExcludePackages},
+	} {
+		if len(part.values) > 0 {
+			values := append([]string{}, part.values...)
+			sort.Strings(values)
+			parts = append(parts, fmt.Sprintf("%s %q", part.name, values))
+		}
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFilterIRJSON = `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Request", "package" : "com.palantir.filter.api" },
+      "fields" : [ {
+        "fieldName" : "inner",
+        "type" : { "type" : "reference", "reference" : { "name" : "Inner", "package" : "com.palantir.filter.api" } }
+      } ]
+    }
+  }, {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Inner", "package" : "com.palantir.filter.api" },
+      "fields" : [ { "fieldName" : "name", "type" : { "type" : "primitive", "primitive" : "STRING" } } ]
+    }
+  }, {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Unused", "package" : "com.palantir.filter.api" },
+      "fields" : [ { "fieldName" : "name", "type" : { "type" : "primitive", "primitive" : "STRING" } } ]
+    }
+  }, {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Thing", "package" : "com.palantir.filter.other" },
+      "fields" : [ { "fieldName" : "name", "type" : { "type" : "primitive", "primitive" : "STRING" } } ]
+    }
+  } ],
+  "services" : [ {
+    "serviceName" : { "name" : "FooService", "package" : "com.palantir.filter.api" },
+    "endpoints" : [ {
+      "endpointName" : "getRequest",
+      "httpMethod" : "GET",
+      "httpPath" : "/request",
+      "args" : [ ],
+      "returns" : { "type" : "reference", "reference" : { "name" : "Request", "package" : "com.palantir.filter.api" } },
+      "markers" : [ ]
+    } ]
+  }, {
+    "serviceName" : { "name" : "BarService", "package" : "com.palantir.filter.api" },
+    "endpoints" : [ {
+      "endpointName" : "ping",
+      "httpMethod" : "GET",
+      "httpPath" : "/ping",
+      "args" : [ ],
+      "markers" : [ ]
+    } ]
+  } ]
+}`
+
+func TestRunFilter(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		filter     conjureplugin.DefinitionFilter
+		want       []string
+		wantAbsent []string
+		wantErr    string
+	}{
+		{
+			filter:     conjureplugin.DefinitionFilter{IncludeServices: []string{"FooService"}},
+			want:       []string{"type Request struct", "type Inner struct", "FooService"},
+			wantAbsent: []string{"type Unused struct", "type Thing struct", "BarService"},
+		},
+		{
+			filter: conjureplugin.DefinitionFilter{
+				ExcludeTypes:    []string{"Unused"},
+				ExcludePackages: []string{"com.palantir.filter.other"},
+			},
+			want:       []string{"type Request struct", "type Inner struct", "FooService", "BarService"},
+			wantAbsent: []string{"type Unused struct", "type Thing struct"},
+		},
+		{
+			filter:     conjureplugin.DefinitionFilter{IncludeTypes: []string{"com.palantir.filter.api.Inner"}},
+			want:       []string{"type Inner struct"},
+			wantAbsent: []string{"type Request struct", "type Unused struct", "type Thing struct", "FooService", "BarService"},
+		},
+		{
+			filter:     conjureplugin.DefinitionFilter{IncludePackages: []string{"com.palantir.filter.other"}},
+			want:       []string{"type Thing struct"},
+			wantAbsent: []string{"type Request struct", "type Inner struct", "FooService", "BarService"},
+		},
+		{
+			filter: conjureplugin.DefinitionFilter{
+				IncludeServices: []string{"FooService"},
+				ExcludeTypes:    []string{"Inner"},
+			},
+			wantErr: "failed to filter definitions of project-1: type com.palantir.filter.api.Inner is referenced by type com.palantir.filter.api.Request, but is excluded by the filter",
+		},
+		{
+			filter:  conjureplugin.DefinitionFilter{IncludeServices: []string{"MissingService"}},
+			wantErr: "failed to filter definitions of project-1: include-services specifies service MissingService, which is not defined",
+		},
+	} {
+		func() {
+			projectDir, err := os.MkdirTemp(cwd, "TestRunFilter_")
+			require.NoError(t, err, "Case %d", i)
+			defer func() {
+				assert.NoError(t, os.RemoveAll(projectDir), "Case %d", i)
+			}()
+			irFile := filepath.Join(projectDir, "ir.json")
+			require.NoError(t, os.WriteFile(irFile, []byte(testFilterIRJSON), 0644), "Case %d", i)
+
+			filter := tc.filter
+			params := conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{"project-1"},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:  "conjure-output",
+						IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+						Filter:     &filter,
+					},
+				},
+			}
+			err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+				return
+			}
+			require.NoError(t, err, "Case %d", i)
+
+			var generated []string
+			require.NoError(t, filepath.Walk(filepath.Join(projectDir, "conjure-output"), func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				generated = append(generated, string(content))
+				return nil
+			}), "Case %d", i)
+			all := strings.Join(generated, "\n")
+			for _, want := range tc.want {
+				assert.Contains(t, all, want, "Case %d", i)
+			}
+			for _, notWant := range tc.wantAbsent {
+				assert.NotContains(t, all, notWant, "Case %d", i)
+			}
+		}()
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+)
+
+// ForbiddenPatternKind is the kind of definition that a ForbiddenPattern is evaluated against.
+type ForbiddenPatternKind string
+
+const (
+	// ForbiddenTypeName patterns are matched against the qualified names ("<package>.<Name>") of types and errors.
+	ForbiddenTypeName ForbiddenPatternKind = "type-name"
+	// ForbiddenServiceName patterns are matched against the qualified names of services.
+	ForbiddenServiceName ForbiddenPatternKind = "service-name"
+	// ForbiddenEndpointName patterns are matched against the names of endpoints in the form
+	// "<ServiceName>.<endpointName>".
+	ForbiddenEndpointName ForbiddenPatternKind = "endpoint-name"
+	// ForbiddenExternalType patterns are matched against the qualified names of all external type references.
+	ForbiddenExternalType ForbiddenPatternKind = "external-type"
+	// ForbiddenBodyParamType patterns are matched against the types of body parameters.
+	ForbiddenBodyParamType ForbiddenPatternKind = "body-param-type"
+	// ForbiddenHeaderParamType patterns are matched against the types of header parameters.
+	ForbiddenHeaderParamType ForbiddenPatternKind = "header-param-type"
+	// ForbiddenPathParamType patterns are matched against the types of path parameters.
+	ForbiddenPathParamType ForbiddenPatternKind = "path-param-type"
+	// ForbiddenQueryParamType patterns are matched against the types of query parameters.
+	ForbiddenQueryParamType ForbiddenPatternKind = "query-param-type"
+)
+
+// ForbiddenPatternKinds returns all of the supported kinds of forbidden patterns.
+func ForbiddenPatternKinds() []ForbiddenPatternKind {
+	return []ForbiddenPatternKind{
+		ForbiddenTypeName,
+		ForbiddenServiceName,
+		ForbiddenEndpointName,
+		ForbiddenExternalType,
+		ForbiddenBodyParamType,
+		ForbiddenHeaderParamType,
+		ForbiddenPathParamType,
+		ForbiddenQueryParamType,
+	}
+}
+
+// ForbiddenPattern specifies definitions that may not appear in the IR of a project.
+type ForbiddenPattern struct {
+	// Kind is the kind of definition that the pattern is evaluated against.
+	Kind ForbiddenPatternKind
+	// Pattern is matched against the definitions of the specified kind. Definitions that the pattern matches in their
+	// entirety are forbidden. Types are described by their qualified name if they are references, their upper-case
+	// name if they are primitives (for example, "BEARERTOKEN") and in the form "optional<T>", "list<T>", "set<T>" or
+	// "map<K, V>" otherwise.
+	Pattern *regexp.Regexp
+	// Message is an optional explanation of why the definitions are forbidden that is included in failures.
+	Message string
+}
+
+// fullMatchRegexp returns a regular expression that matches the values that the pattern matches in their entirety. The
+// pattern is anchored rather than compared with the bounds of its match because Go returns the leftmost-first match
+// rather than the longest one, so "Foo|FooBar" matches only "Foo" of "FooBar".
+func (p ForbiddenPattern) fullMatchRegexp() *regexp.Regexp {
+	// the pattern has already been compiled, so the anchored pattern is valid
+	return regexp.MustCompile(`^(?:` + p.Pattern.String() + `)$`)
+}
+
+// forbiddenPatternViolations returns a description of every definition in the provided IR that matches any of the
+// provided patterns.
+func forbiddenPatternViolations(def spec.ConjureDefinition, patterns []ForbiddenPattern) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+	defs := make(map[ForbiddenPatternKind][]forbiddenCandidate)
+	addDef := func(kind ForbiddenPatternKind, val, location string) {
+		defs[kind] = append(defs[kind], forbiddenCandidate{val: val, location: location})
+	}
+	addTypeRefs := func(t spec.Type, location string) {
+		for _, external := range externalTypeNames(t) {
+			addDef(ForbiddenExternalType, external, location)
+		}
+	}
+	addFields := func(parent string, fields []spec.FieldDefinition) {
+		for _, field := range fields {
+			addTypeRefs(field.Type, fmt.Sprintf("%s.%s", parent, field.FieldName))
+		}
+	}
+
+	for _, typeDef := range def.Types {
+		_ = typeDef.AcceptFuncs(
+			func(alias spec.AliasDefinition) error {
+				addDef(ForbiddenTypeName, qualifiedName(alias.TypeName), "")
+				addTypeRefs(alias.Alias, qualifiedName(alias.TypeName))
+				return nil
+			},
+			func(enum spec.EnumDefinition) error {
+				addDef(ForbiddenTypeName, qualifiedName(enum.TypeName), "")
+				return nil
+			},
+			func(object spec.ObjectDefinition) error {
+				addDef(ForbiddenTypeName, qualifiedName(object.TypeName), "")
+				addFields(qualifiedName(object.TypeName), object.Fields)
+				return nil
+			},
+			func(union spec.UnionDefinition) error {
+				addDef(ForbiddenTypeName, qualifiedName(union.TypeName), "")
+				addFields(qualifiedName(union.TypeName), union.Union)
+				return nil
+			},
+			typeDef.ErrorOnUnknown,
+		)
+	}
+	for _, errorDef := range def.Errors {
+		addDef(ForbiddenTypeName, qualifiedName(errorDef.ErrorName), "")
+		addFields(qualifiedName(errorDef.ErrorName), errorDef.SafeArgs)
+		addFields(qualifiedName(errorDef.ErrorName), errorDef.UnsafeArgs)
+	}
+	for _, service := range def.Services {
+		addDef(ForbiddenServiceName, qualifiedName(service.ServiceName), "")
+		for _, endpoint := range service.Endpoints {
+			endpointName := fmt.Sprintf("%s.%s", service.ServiceName.Name, endpoint.EndpointName)
+			addDef(ForbiddenEndpointName, endpointName, "")
+			if endpoint.Returns != nil {
+				addTypeRefs(*endpoint.Returns, endpointName+" return type")
+			}
+			for _, arg := range endpoint.Args {
+				argLocation := fmt.Sprintf("%s argument %s", endpointName, arg.ArgName)
+				addTypeRefs(arg.Type, argLocation)
+				var paramKind ForbiddenPatternKind
+				_ = arg.ParamType.AcceptFuncs(
+					func(spec.BodyParameterType) error {
+						paramKind = ForbiddenBodyParamType
+						return nil
+					},
+					func(spec.HeaderParameterType) error {
+						paramKind = ForbiddenHeaderParamType
+						return nil
+					},
+					func(spec.PathParameterType) error {
+						paramKind = ForbiddenPathParamType
+						return nil
+					},
+					func(spec.QueryParameterType) error {
+						paramKind = ForbiddenQueryParamType
+						return nil
+					},
+					arg.ParamType.ErrorOnUnknown,
+				)
+				if paramKind != "" {
+					addDef(paramKind, typeString(arg.Type), argLocation)
+				}
+			}
+		}
+	}
+
+	var violations []string
+	for _, pattern := range patterns {
+		fullMatch := pattern.fullMatchRegexp()
+		for _, candidate := range defs[pattern.Kind] {
+			if !fullMatch.MatchString(candidate.val) {
+				continue
+			}
+			violation := fmt.Sprintf("%s %s", pattern.Kind, candidate.val)
+			if candidate.location != "" {
+				violation += fmt.Sprintf(" (%s)", candidate.location)
+			}
+			violation += fmt.Sprintf(" matches forbidden pattern %q", pattern.Pattern.String())
+			if pattern.Message != "" {
+				violation += ": " + pattern.Message
+			}
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+type forbiddenCandidate struct {
+	// val is the value matched against patterns.
+	val string
+	// location describes where the value appears if the value does not identify the definition by itself.
+	location string
+}
+
+func qualifiedName(typeName spec.TypeName) string {
+	return fmt.Sprintf("%s.%s", typeName.Package, typeName.Name)
+}
+
+// typeString returns the description of the provided type used for matching forbidden patterns.
+func typeString(t spec.Type) string {
+	return formatType(t, qualifiedName)
+}
+
+// formatType returns a description of the provided type in which referenced types are described using typeNameFn.
+func formatType(t spec.Type, typeNameFn func(spec.TypeName) string) string {
+	var out string
+	_ = t.AcceptFuncs(
+		func(primitive spec.PrimitiveType) error {
+			out = primitive.String()
+			return nil
+		},
+		func(optional spec.OptionalType) error {
+			out = fmt.Sprintf("optional<%s>", formatType(optional.ItemType, typeNameFn))
+			return nil
+		},
+		func(list spec.ListType) error {
+			out = fmt.Sprintf("list<%s>", formatType(list.ItemType, typeNameFn))
+			return nil
+		},
+		func(set spec.SetType) error {
+			out = fmt.Sprintf("set<%s>", formatType(set.ItemType, typeNameFn))
+			return nil
+		},
+		func(mapType spec.MapType) error {
+			out = fmt.Sprintf("map<%s, %s>", formatType(mapType.KeyType, typeNameFn), formatType(mapType.ValueType, typeNameFn))
+			return nil
+		},
+		func(reference spec.TypeName) error {
+			out = typeNameFn(reference)
+			return nil
+		},
+		func(external spec.ExternalReference) error {
+			out = typeNameFn(external.ExternalReference)
+			return nil
+		},
+		func(typeName string) error {
+			out = typeName
+			return nil
+		},
+	)
+	return out
+}
+
+// externalTypeNames returns the qualified names of all of the external references in the provided type.
+func externalTypeNames(t spec.Type) []string {
+	var out []string
+	_ = t.AcceptFuncs(
+		t.PrimitiveNoopSuccess,
+		func(optional spec.OptionalType) error {
+			out = externalTypeNames(optional.ItemType)
+			return nil
+		},
+		func(list spec.ListType) error {
+			out = externalTypeNames(list.ItemType)
+			return nil
+		},
+		func(set spec.SetType) error {
+			out = externalTypeNames(set.ItemType)
+			return nil
+		},
+		func(mapType spec.MapType) error {
+			out = append(externalTypeNames(mapType.KeyType), externalTypeNames(mapType.ValueType)...)
+			return nil
+		},
+		t.ReferenceNoopSuccess,
+		func(external spec.ExternalReference) error {
+			out = []string{qualifiedName(external.ExternalReference)}
+			return nil
+		},
+		t.ErrorOnUnknown,
+	)
+	return out
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/imports"
+)
+
+// Formatter specifies a formatter that is run on the generated Go files of a project after they are generated.
+type Formatter string
+
+const (
+	// FormatterGoimports specifies that generated Go files are formatted using goimports.
+	FormatterGoimports = Formatter("goimports")
+	// FormatterGofumpt specifies that generated Go files are formatted using the "gofumpt" executable on the PATH,
+	// which is not bundled with the plugin.
+	FormatterGofumpt = Formatter("gofumpt")
+)
+
+// formatGeneratedFiles returns the provided files with the content of every generated Go file formatted using the
+// provided formatter. Other files are returned unmodified. If the formatter is empty, the files are returned as-is.
+func formatGeneratedFiles(files []renderedFile, formatter Formatter) ([]renderedFile, error) {
+	if formatter == "" {
+		return files, nil
+	}
+	out := make([]renderedFile, 0, len(files))
+	for _, file := range files {
+		if !isGeneratedGoFile(file.absPath) {
+			out = append(out, file)
+			continue
+		}
+		formatted, err := formatSource(file.absPath, file.content, formatter)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to format %s using %s", file.absPath, formatter)
+		}
+		out = append(out, renderedFile{
+			absPath: file.absPath,
+			content: formatted,
+		})
+	}
+	return out, nil
+}
+
+func formatSource(filename string, src []byte, formatter Formatter) ([]byte, error) {
+	switch formatter {
+	case FormatterGoimports:
+		// the imports configuration is global, so formatting is guarded by the same lock as rendering. Imports are
+		// already resolved by conjure-go, so only the formatting and import grouping of goimports is applied.
+		renderMu.Lock()
+		defer renderMu.Unlock()
+		return imports.Process(filename, src, &imports.Options{
+			Comments:   true,
+			TabIndent:  true,
+			TabWidth:   8,
+			FormatOnly: true,
+		})
+	case FormatterGofumpt:
+		cmd := exec.Command(string(FormatterGofumpt))
+		cmd.Stdin = bytes.NewReader(src)
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return nil, errors.Errorf("gofumpt executable not found on the PATH: install it using \"go install mvdan.cc/gofumpt@latest\"")
+			}
+			return nil, errors.Wrapf(err, "gofumpt failed:\n%s", strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	default:
+		return nil, errors.Errorf("unknown formatter %q", formatter)
+	}
+}
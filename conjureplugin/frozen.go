@@ -0,0 +1,171 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// frozenViolations returns descriptions of the changes other than changes to comments between the generated files in
+// the provided output directory and the provided files. Files other than Go files and the readme file must be
+// identical. Generated files that would be added or removed are considered changes. The returned descriptions are
+// sorted.
+func frozenViolations(files []renderedFile, outputDir, projectDir string) ([]string, error) {
+	var violations []string
+	rendered := make(map[string]struct{})
+	for _, file := range files {
+		rendered[file.absPath] = struct{}{}
+		if filepath.Base(file.absPath) == readmeFileName {
+			// the readme file only contains documentation, so changes to it are permitted like changes to comments
+			continue
+		}
+		relPath, err := filepath.Rel(projectDir, file.absPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		onDisk, err := os.ReadFile(file.absPath)
+		if os.IsNotExist(err) {
+			violations = append(violations, fmt.Sprintf("added: %s", relPath))
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", file.absPath)
+		}
+		same := bytes.Equal(onDisk, file.content)
+		if !same && strings.HasSuffix(file.absPath, ".go") {
+			if same, err = equalIgnoringComments(onDisk, file.content); err != nil {
+				return nil, errors.Wrapf(err, "failed to compare %s", relPath)
+			}
+		}
+		if !same {
+			violations = append(violations, fmt.Sprintf("modified: %s", relPath))
+		}
+	}
+
+	absOutputDir := filepath.Join(projectDir, outputDir)
+	if _, err := os.Stat(absOutputDir); err == nil {
+		if err := filepath.WalkDir(absOutputDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(d.Name(), generatedFileSuffix) {
+				return nil
+			}
+			if _, ok := rendered[path]; ok {
+				return nil
+			}
+			relPath, err := filepath.Rel(projectDir, path)
+			if err != nil {
+				return err
+			}
+			violations = append(violations, fmt.Sprintf("removed: %s", relPath))
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to find generated files in output directory %s", outputDir)
+		}
+	}
+	sort.Strings(violations)
+	return violations, nil
+}
+
+// equalIgnoringComments returns true if the provided Go source files are equal other than their comments, their
+// formatting and the "conjure-docs" struct tags that conjure-go generates from documentation.
+func equalIgnoringComments(src1, src2 []byte) (bool, error) {
+	stripped1, err := stripComments(src1)
+	if err != nil {
+		return false, err
+	}
+	stripped2, err := stripComments(src2)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(stripped1, stripped2), nil
+}
+
+var conjureDocsTagRegexp = regexp.MustCompile(`conjure-docs:"(?:[^"\\]|\\.)*"\s*`)
+
+// stripComments returns the provided Go source formatted without its comments and "conjure-docs" struct tags.
+func stripComments(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	// parsing without the parser.ParseComments mode discards all comments
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		field, ok := node.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+		if tag = strings.TrimSpace(conjureDocsTagRegexp.ReplaceAllString(tag, "")); tag == "" {
+			field.Tag = nil
+		} else {
+			field.Tag.Value = strconv.Quote(tag)
+		}
+		return true
+	})
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, file); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
+
+// irEqualIgnoringDocs returns true if the provided IR documents are equal other than their documentation.
+func irEqualIgnoringDocs(ir1, ir2 []byte) (bool, error) {
+	var val1, val2 interface{}
+	if err := json.Unmarshal(ir1, &val1); err != nil {
+		return false, errors.Wrapf(err, "failed to parse IR")
+	}
+	if err := json.Unmarshal(ir2, &val2); err != nil {
+		return false, errors.Wrapf(err, "failed to parse IR")
+	}
+	return reflect.DeepEqual(removeDocs(val1), removeDocs(val2)), nil
+}
+
+// removeDocs removes the "docs" keys from all of the objects in the provided JSON value.
+func removeDocs(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		delete(v, "docs")
+		for k, elem := range v {
+			v[k] = removeDocs(elem)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = removeDocs(elem)
+		}
+	}
+	return val
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/pkg/errors"
+)
+
+// GeneratorAsset specifies a generator asset that generates the output of a project (typically code in a language other
+// than Go) instead of the vendored conjure-go generator. The asset is invoked as "<asset> generate <json>", where <json>
+// is a JSON object with the keys "project", "projectDir", "ir" (the path of a file that contains the IR), "outputDir"
+// (an empty directory to which the asset must write its output) and "config" (omitted if the asset is not configured).
+// Every file that the asset writes is generated output: the plugin writes or verifies the files in the output directory
+// of the project, and files in that directory that the asset no longer generates are removed by generation and
+// reported by verification.
+type GeneratorAsset struct {
+	// Name is the name of the asset.
+	Name string
+	// Path is the path of the asset. It is empty until the assets provided to the plugin are resolved.
+	Path string
+	// Config is the configuration for the asset as JSON. If empty, the "config" key is omitted.
+	Config []byte
+}
+
+type generateArgs struct {
+	Project    string          `json:"project"`
+	ProjectDir string          `json:"projectDir"`
+	IR         string          `json:"ir"`
+	OutputDir  string          `json:"outputDir"`
+	Config     json.RawMessage `json:"config,omitempty"`
+}
+
+// renderGeneratorAssetFiles returns the files generated by running the provided generator asset for the provided IR. The
+// asset writes its output to a temporary directory, and the returned files are the files that it writes rebased onto the
+// provided output directory.
+func renderGeneratorAssetFiles(projectName string, param ConjureProjectParam, irBytes []byte, outputDir, projectDir string) (rFiles []renderedFile, rErr error) {
+	asset := param.GeneratorAsset
+	if asset.Path == "" {
+		return nil, errors.Errorf("generator asset %s is not provided to the plugin", asset.Name)
+	}
+	tmpDir, err := tempfilecreator.MkdirTemp("generator-asset-" + projectName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+
+	args := generateArgs{
+		Project:    projectName,
+		ProjectDir: projectDir,
+		IR:         filepath.Join(tmpDir, "ir.json"),
+		OutputDir:  filepath.Join(tmpDir, "output"),
+		Config:     asset.Config,
+	}
+	if absProjectDir, err := filepath.Abs(projectDir); err == nil {
+		args.ProjectDir = absProjectDir
+	}
+	if err := os.WriteFile(args.IR, irBytes, 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write IR for generator asset")
+	}
+	if err := os.Mkdir(args.OutputDir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create output directory for generator asset")
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cmd := exec.Command(asset.Path, "generate", string(argsJSON))
+	cmd.Dir = projectDir
+	cmd.Env = append(os.Environ(), envSlice(param.Env)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, errors.Wrapf(err, "generator asset %s failed:\n%s", asset.Name, strings.TrimSpace(string(output)))
+	}
+
+	var files []renderedFile
+	if err := filepath.WalkDir(args.OutputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		relPath, err := filepath.Rel(args.OutputDir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, renderedFile{
+			absPath: filepath.Join(outputDir, relPath),
+			content: content,
+		})
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to read files generated by generator asset %s", asset.Name)
+	}
+	return files, nil
+}
+
+// generatorAssetFingerprint returns a value that identifies the provided generator asset, its executable and its
+// configuration, so that output is regenerated after the asset is updated or reconfigured.
+func generatorAssetFingerprint(asset GeneratorAsset) string {
+	fingerprint := fmt.Sprintf("asset:%s:%s:%s", asset.Name, asset.Path, asset.Config)
+	if fi, err := os.Stat(asset.Path); err == nil {
+		fingerprint += fmt.Sprintf(":%d:%d", fi.Size(), fi.ModTime().UnixNano())
+	}
+	return fingerprint
+}
+
+// staleGeneratorAssetFiles returns the paths (relative to projectDir) of all of the files in the output directory of the
+// provided project that were not generated by the current run if the project is generated by a generator asset, which
+// owns its output directory. Returns nil otherwise.
+func staleGeneratorAssetFiles(param ConjureProjectParam, projectDir string, currentFiles map[string]struct{}) ([]string, error) {
+	if param.GeneratorAsset == nil {
+		return nil, nil
+	}
+	outputDir := filepath.Join(projectDir, param.OutputDir)
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var stale []string
+	if err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if _, ok := currentFiles[path]; ok {
+			return nil
+		}
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		stale = append(stale, relPath)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to find files in output directory %s", param.OutputDir)
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/modfile"
+)
+
+// goModFileName is the name of the module file that is written to the output directory of projects that are generated
+// as standalone modules.
+const goModFileName = "go.mod"
+
+// StandaloneModule specifies that the output directory of a project is generated as a standalone Go module.
+type StandaloneModule struct {
+	// Requires specifies the versions of the modules required by the generated code keyed by module path. The versions
+	// override the versions pinned by the plugin, and modules that are not pinned by the plugin must be specified if
+	// the generated code imports them.
+	Requires map[string]string
+}
+
+// standaloneModuleVersions are the versions of the modules imported by the code generated by conjure-go that are
+// required by the module files of standalone modules.
+var standaloneModuleVersions = map[string]string{
+	"github.com/palantir/conjure-go-runtime/v2":   "v2.85.0",
+	"github.com/palantir/pkg/bearertoken":         "v1.1.0",
+	"github.com/palantir/pkg/binary":              "v1.1.0",
+	"github.com/palantir/pkg/boolean":             "v1.1.0",
+	"github.com/palantir/pkg/datetime":            "v1.1.0",
+	"github.com/palantir/pkg/rid":                 "v1.1.0",
+	"github.com/palantir/pkg/safejson":            "v1.1.0",
+	"github.com/palantir/pkg/safelong":            "v1.1.0",
+	"github.com/palantir/pkg/safeyaml":            "v1.1.0",
+	"github.com/palantir/pkg/uuid":                "v1.1.0",
+	"github.com/palantir/witchcraft-go-error":     "v1.40.0",
+	"github.com/palantir/witchcraft-go-logging":   "v1.51.0",
+	"github.com/palantir/witchcraft-go-params":    "v1.37.0",
+	"github.com/palantir/witchcraft-go-server/v2": "v2.78.0",
+	"github.com/palantir/witchcraft-go-tracing":   "v1.33.0",
+	"github.com/spf13/cobra":                      "v1.8.1",
+	"github.com/spf13/pflag":                      "v1.0.5",
+	"github.com/tidwall/gjson":                    "v1.17.1",
+	"gopkg.in/yaml.v3":                            "v3.0.1",
+}
+
+// renderGoModFile returns a module file written to the provided output directory that declares the module of the
+// generated code and requires the modules imported by the provided files. The path of the module is the import path of
+// the output directory in the module that contains it (which is the import path that conjure-go uses for the generated
+// code), and the module uses the Go version of the module that contains it.
+func renderGoModFile(files []renderedFile, module StandaloneModule, outputDir string) (renderedFile, error) {
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return renderedFile{}, errors.WithStack(err)
+	}
+	parentModFile, parentModDir, err := parentGoModule(absOutputDir)
+	if err != nil {
+		return renderedFile{}, err
+	}
+	relPath, err := filepath.Rel(parentModDir, absOutputDir)
+	if err != nil {
+		return renderedFile{}, errors.WithStack(err)
+	}
+	modulePath := path.Join(parentModFile.Module.Mod.Path, filepath.ToSlash(relPath))
+
+	versions := make(map[string]string)
+	for modPath, version := range standaloneModuleVersions {
+		versions[modPath] = version
+	}
+	for modPath, version := range module.Requires {
+		versions[modPath] = version
+	}
+	required := make(map[string]struct{})
+	for _, file := range files {
+		if !strings.HasSuffix(file.absPath, ".go") {
+			continue
+		}
+		astFile, err := parser.ParseFile(token.NewFileSet(), file.absPath, file.content, parser.ImportsOnly)
+		if err != nil {
+			return renderedFile{}, errors.Wrapf(err, "failed to parse generated file %s", file.absPath)
+		}
+		for _, importSpec := range astFile.Imports {
+			importPath, err := strconv.Unquote(importSpec.Path.Value)
+			if err != nil {
+				return renderedFile{}, errors.Wrapf(err, "invalid import in generated file %s", file.absPath)
+			}
+			if isStandardLibraryImport(importPath) || importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/") {
+				continue
+			}
+			modPath := importModulePath(importPath, versions)
+			if modPath == "" {
+				return renderedFile{}, errors.Errorf("generated code imports %s, which is not provided by a module with a pinned version: specify the version of its module in requires", importPath)
+			}
+			required[modPath] = struct{}{}
+		}
+	}
+	var modPaths []string
+	for modPath := range required {
+		modPaths = append(modPaths, modPath)
+	}
+	sort.Strings(modPaths)
+
+	modFile := new(modfile.File)
+	modFile.AddComment("// This file was generated by Conjure and should not be manually edited.")
+	if err := modFile.AddModuleStmt(modulePath); err != nil {
+		return renderedFile{}, errors.WithStack(err)
+	}
+	if parentModFile.Go != nil {
+		if err := modFile.AddGoStmt(parentModFile.Go.Version); err != nil {
+			return renderedFile{}, errors.WithStack(err)
+		}
+	}
+	for _, modPath := range modPaths {
+		modFile.AddNewRequire(modPath, versions[modPath], false)
+	}
+	modFile.Cleanup()
+	return renderedFile{
+		absPath: filepath.Join(absOutputDir, goModFileName),
+		content: modfile.Format(modFile.Syntax),
+	}, nil
+}
+
+// parentGoModule returns the parsed module file and the directory of the module that contains the provided directory,
+// ignoring a module file in the directory itself. Returns an error if the directory is not within a module.
+func parentGoModule(dir string) (*modfile.File, string, error) {
+	for currDir := filepath.Dir(dir); ; currDir = filepath.Dir(currDir) {
+		modFilePath := filepath.Join(currDir, goModFileName)
+		content, err := os.ReadFile(modFilePath)
+		if err == nil {
+			modFile, err := modfile.Parse(modFilePath, content, nil)
+			if err != nil {
+				return nil, "", errors.Wrapf(err, "failed to parse %s", modFilePath)
+			}
+			if modFile.Module == nil {
+				return nil, "", errors.Errorf("%s does not declare a module", modFilePath)
+			}
+			return modFile, currDir, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, "", errors.Wrapf(err, "failed to read %s", modFilePath)
+		}
+		if filepath.Dir(currDir) == currDir {
+			return nil, "", errors.Errorf("output directory %s is not within a Go module", dir)
+		}
+	}
+}
+
+// isStandardLibraryImport returns true if the provided import path is the path of a package in the standard library,
+// whose first element does not contain a dot.
+func isStandardLibraryImport(importPath string) bool {
+	return !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".")
+}
+
+// importModulePath returns the path of the module in the provided versions that provides the package with the provided
+// import path, or an empty string if no module provides it. If multiple modules match, the most specific one is used.
+func importModulePath(importPath string, versions map[string]string) string {
+	var match string
+	for modPath := range versions {
+		if (importPath == modPath || strings.HasPrefix(importPath, modPath+"/")) && len(modPath) > len(match) {
+			match = modPath
+		}
+	}
+	return match
+}
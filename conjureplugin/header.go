@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// conjureHeaderComment is the header comment of the Go files generated by conjure-go and the plugin.
+const conjureHeaderComment = "// This file was generated by Conjure and should not be manually edited."
+
+// generatedCodeComment matches the comment that marks a Go file as generated (see https://go.dev/s/generatedcode).
+var generatedCodeComment = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// PluginVersionParam returns a parameter that sets the version of the plugin that is provided to the header templates
+// of projects and recorded by the build metadata extension.
+func PluginVersionParam(version string) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.pluginVersion = version
+	})
+}
+
+// Header is a Go template for the comment at the top of the generated Go files of a project. The template can use the
+// "Project", "Version" and "IRHash" functions to refer to the name of the project, the version of the plugin and the
+// hex-encoded SHA-256 checksum of the IR of the project. Every line of the rendered template is written as a "//"
+// comment, and the rendered comment must contain a line of the form "Code generated ... DO NOT EDIT." so that go vet
+// and linters recognize the files as generated.
+type Header string
+
+// Validate returns an error if the header is not a valid template or does not render a line of the form
+// "Code generated ... DO NOT EDIT.".
+func (h Header) Validate() error {
+	comment, err := h.render("project", "version", nil)
+	if err != nil {
+		return err
+	}
+	if !generatedCodeComment.MatchString(comment) {
+		return errors.Errorf(`must contain a line of the form "Code generated ... DO NOT EDIT."`)
+	}
+	return nil
+}
+
+// render returns the comment rendered by the header for the provided project, plugin version and IR.
+func (h Header) render(projectName, version string, irBytes []byte) (string, error) {
+	funcs := template.FuncMap{
+		"Project": func() string { return projectName },
+		"Version": func() string { return version },
+		"IRHash":  func() string { return fmt.Sprintf("%x", sha256.Sum256(irBytes)) },
+	}
+	tmpl, err := template.New("header").Funcs(funcs).Parse(string(h))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse header template")
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, nil); err != nil {
+		return "", errors.Wrapf(err, "failed to execute header template")
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		lines = append(lines, strings.TrimRight("// "+line, " "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// addHeader returns the provided files with the header comment of every generated Go file replaced by the comment
+// rendered by the provided header. The comment is prepended to generated Go files that do not have the header comment
+// of conjure-go (such as files generated by an external generator). If the header is empty, the files are returned
+// as-is.
+func addHeader(files []renderedFile, header Header, projectName, version string, irBytes []byte) ([]renderedFile, error) {
+	if header == "" {
+		return files, nil
+	}
+	comment, err := header.render(projectName, version, irBytes)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]renderedFile, 0, len(files))
+	for _, file := range files {
+		if isGeneratedGoFile(file.absPath) {
+			if bytes.Contains(file.content, []byte(conjureHeaderComment+"\n")) {
+				file.content = bytes.Replace(file.content, []byte(conjureHeaderComment), []byte(comment), 1)
+			} else {
+				file.content = append([]byte(comment+"\n\n"), file.content...)
+			}
+		}
+		out = append(out, file)
+	}
+	return out, nil
+}
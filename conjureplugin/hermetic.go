@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+)
+
+const (
+	hermeticTempDirPlaceholder    = "$TMPDIR"
+	hermeticProjectDirPlaceholder = "$PROJECT_DIR"
+	hermeticHomePlaceholder       = "$HOME"
+	hermeticHostnamePlaceholder   = "$HOSTNAME"
+)
+
+// tempNameSuffix matches the random suffix of the names of the temporary files and directories created by the plugin.
+var tempNameSuffix = regexp.MustCompile(`(conjure-plugin-(?:[a-zA-Z0-9._-]*-)?)[0-9]+\b`)
+
+// Normalizer replaces values that depend on the environment in which the plugin runs (the temporary directory, the
+// project directory, the home directory, the host name and the random suffixes of temporary files) with stable
+// placeholders so that the output of runs in different environments can be compared.
+type Normalizer struct {
+	replacer *strings.Replacer
+}
+
+// NewNormalizer returns a normalizer for a run in the provided project directory.
+func NewNormalizer(projectDir string) *Normalizer {
+	placeholders := make(map[string]string)
+	addPath := func(path, placeholder string) {
+		if path == "" {
+			return
+		}
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+		placeholders[path] = placeholder
+		// also replace the resolved path so that paths are normalized on systems where they are symlinks (such as
+		// the temporary directory on macOS)
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			placeholders[resolved] = placeholder
+		}
+	}
+	addPath(os.Getenv("HOME"), hermeticHomePlaceholder)
+	addPath(tempfilecreator.Root(), hermeticTempDirPlaceholder)
+	addPath(projectDir, hermeticProjectDirPlaceholder)
+	if hostname, err := os.Hostname(); err == nil && hostname != "" && hostname != "localhost" {
+		placeholders[hostname] = hermeticHostnamePlaceholder
+	}
+	// replace longer values first so that a path is replaced by the placeholder of the most specific directory that
+	// contains it (for example, a project directory in the home directory)
+	var values []string
+	for value := range placeholders {
+		if value == string(filepath.Separator) {
+			continue
+		}
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	var oldNew []string
+	for _, value := range values {
+		oldNew = append(oldNew, value, placeholders[value])
+	}
+	return &Normalizer{
+		replacer: strings.NewReplacer(oldNew...),
+	}
+}
+
+// Normalize returns the provided string with the values that depend on the environment replaced by placeholders.
+func (n *Normalizer) Normalize(s string) string {
+	if n == nil {
+		return s
+	}
+	return tempNameSuffix.ReplaceAllString(n.replacer.Replace(s), "${1}*")
+}
+
+// Normalize sets the durations recorded in the summary to 0 and normalizes the messages of its projects using the
+// provided normalizer.
+func (s *Summary) Normalize(n *Normalizer) {
+	if s == nil {
+		return
+	}
+	s.Duration = 0
+	for i := range s.Projects {
+		s.Projects[i].Duration = 0
+		s.Projects[i].Message = n.Normalize(s.Projects[i].Message)
+	}
+}
+
+// Normalize normalizes the messages in the report using the provided normalizer.
+func (r *VerifyReport) Normalize(n *Normalizer) {
+	if r == nil {
+		return
+	}
+	for i := range r.Projects {
+		for j := range r.Projects[i].Messages {
+			r.Projects[i].Messages[j] = n.Normalize(r.Projects[i].Messages[j])
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/gencache"
+	"github.com/pkg/errors"
+)
+
+// IncrementalParam returns a parameter that sets whether generation is skipped for projects whose IR and generation
+// options have not changed since their code was last generated or verified, provided that the generated files have
+// not been modified since. If true, it takes precedence over ConjureProjectParams.Incremental.
+func IncrementalParam(incremental bool) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.incremental = incremental
+	})
+}
+
+// generationOptions are the options that determine the code generated for a project other than its IR.
+type generationOptions struct {
+	Generator          string
+	ProjectDir         string
+	OutputRoot         string
+	OutputDir          string
+	Server             bool
+	CLI                bool
+	AcceptFuncs        bool
+	SizeBudget         *SizeBudget
+	Frozen             bool
+	ForbiddenPatterns  []string
+	EndpointConstants  bool
+	RoutesFile         string
+	ClientOptions      bool
+	ClientConstructors map[string]ClientConstructor
+	Readme             bool
+	IRSnapshot         bool
+	Owners             []string
+	ExternalGenerator  *ExternalGenerator
+	GeneratorAsset     *GeneratorAsset
+	FileModePolicy     FileModePolicy
+	Format             Formatter
+	BuildTags          []string
+	Header             Header
+	YAMLMethods        YAMLMethods
+	Filter             *DefinitionFilter
+	PackagePaths       map[string]string
+	StandaloneModule   *StandaloneModule
+	Mocks              bool
+	Examples           bool
+	TargetPlatforms    *TargetPlatforms
+}
+
+// generationCacheID returns the ID of the generation cache entry for the provided project whose output is written
+// relative to the provided directory.
+func generationCacheID(projectName, writeDir string) string {
+	return writeDir + string(os.PathListSeparator) + projectName
+}
+
+// generationKey returns a key that identifies the inputs used to generate the code for the provided project: its IR,
+// its generation options and the plugin executable that generates it.
+func generationKey(param ConjureProjectParam, irBytes []byte, projectDir, outputRoot string) (string, error) {
+	opts := generationOptions{
+		Generator:          generatorFingerprint(),
+		ProjectDir:         projectDir,
+		OutputRoot:         outputRoot,
+		OutputDir:          param.OutputDir,
+		Server:             param.Server,
+		CLI:                param.CLI,
+		AcceptFuncs:        param.AcceptFuncs,
+		SizeBudget:         param.SizeBudget,
+		Frozen:             param.Frozen,
+		EndpointConstants:  param.EndpointConstants,
+		RoutesFile:         param.RoutesFile,
+		ClientOptions:      param.ClientOptions,
+		ClientConstructors: param.ClientConstructors,
+		Readme:             param.Readme,
+		IRSnapshot:         param.IRSnapshot,
+		Owners:             param.Owners,
+		ExternalGenerator:  param.ExternalGenerator,
+		GeneratorAsset:     param.GeneratorAsset,
+		FileModePolicy:     param.FileModePolicy,
+		Format:             param.Format,
+		BuildTags:          param.BuildTags,
+		Header:             param.Header,
+		YAMLMethods:        param.YAMLMethods,
+		Filter:             param.Filter,
+		PackagePaths:       param.PackagePaths,
+		StandaloneModule:   param.StandaloneModule,
+		Mocks:              param.Mocks,
+		Examples:           param.Examples,
+		TargetPlatforms:    param.TargetPlatforms,
+	}
+	if param.ExternalGenerator != nil {
+		opts.Generator = externalGeneratorFingerprint(*param.ExternalGenerator, projectDir)
+	}
+	if param.GeneratorAsset != nil {
+		opts.Generator = generatorAssetFingerprint(*param.GeneratorAsset)
+	}
+	for _, pattern := range param.ForbiddenPatterns {
+		opts.ForbiddenPatterns = append(opts.ForbiddenPatterns, fmt.Sprintf("%s:%s", pattern.Kind, pattern.Pattern))
+	}
+	optsBytes, err := json.Marshal(opts)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal generation options")
+	}
+	h := sha256.New()
+	_, _ = h.Write(optsBytes)
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(irBytes)
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// generatorFingerprint returns a value that identifies the plugin executable and the version of conjure-go it uses,
+// so that code is regenerated after the plugin is updated or rebuilt.
+func generatorFingerprint() string {
+	fingerprint := vendoredConjureGoVersion()
+	if executable, err := os.Executable(); err == nil {
+		if fi, err := os.Stat(executable); err == nil {
+			fingerprint += fmt.Sprintf(":%s:%d:%d", executable, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return fingerprint
+}
+
+// unchangedGeneratedFiles returns the files generated for the project with the provided cache ID if they were generated
+// using the provided key and have not been modified since. The returned files only specify their paths.
+func unchangedGeneratedFiles(id, key string) ([]renderedFile, bool) {
+	state, ok := gencache.Get(id)
+	if !ok || !state.Unchanged(key) {
+		return nil, false
+	}
+	var paths []string
+	for path := range state.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	files := make([]renderedFile, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, renderedFile{absPath: path})
+	}
+	return files, true
+}
+
+// recordGeneratedFiles records that the provided files were generated for the project with the provided cache ID using
+// the provided key.
+func recordGeneratedFiles(id, key string, files []renderedFile) error {
+	contents := make(map[string][]byte, len(files))
+	for _, file := range files {
+		contents[file.absPath] = file.content
+	}
+	return gencache.Put(id, gencache.NewState(key, contents))
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+)
+
+const (
+	// supportedIRVersion is the version of the IR supported by the vendored conjure-go generator.
+	supportedIRVersion  = 1
+	conjureGoModulePath = "github.com/palantir/conjure-go/v6"
+)
+
+// irFeatureUsage records the use of an IR construct that is not supported by the vendored conjure-go generator.
+type irFeatureUsage struct {
+	// feature describes the construct (for example, `field "safety" of FieldDefinition`).
+	feature string
+	// path is the location of the first use of the construct in the IR.
+	path string
+	// count is the number of times the construct is used in the IR.
+	count int
+}
+
+// unsupportedIRFeatures returns the constructs used by the provided IR that are not supported by the vendored
+// conjure-go generator in the order in which they first appear. The capabilities of the generator are determined from
+// the vendored IR definitions: object fields that they do not define, union variants and enum values that they do not
+// know and IR versions newer than supportedIRVersion are all reported. Such constructs are typically added by newer
+// versions of Conjure and would otherwise be silently ignored by the generator. Returns nil if the IR is not valid JSON.
+func unsupportedIRFeatures(irBytes []byte) []irFeatureUsage {
+	dec := json.NewDecoder(bytes.NewReader(irBytes))
+	dec.UseNumber()
+	var ir interface{}
+	if err := dec.Decode(&ir); err != nil {
+		return nil
+	}
+
+	var usages []irFeatureUsage
+	usageIndex := make(map[string]int)
+	record := func(feature, path string) {
+		if i, ok := usageIndex[feature]; ok {
+			usages[i].count++
+			return
+		}
+		usageIndex[feature] = len(usages)
+		usages = append(usages, irFeatureUsage{
+			feature: feature,
+			path:    path,
+			count:   1,
+		})
+	}
+	if obj, ok := ir.(map[string]interface{}); ok {
+		if version, ok := obj["version"].(json.Number); ok {
+			if v, err := version.Int64(); err == nil && v > supportedIRVersion {
+				record(fmt.Sprintf("IR version %d", v), "version")
+			}
+		}
+	}
+	walkIRFeatures(ir, reflect.TypeOf(spec.ConjureDefinition{}), "", record)
+	return usages
+}
+
+// walkIRFeatures records the constructs in the provided decoded JSON value that are not supported by the provided type
+// of the vendored IR definitions.
+func walkIRFeatures(val interface{}, typ reflect.Type, path string, record func(feature, path string)) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if isEmptyJSON(val) {
+		return
+	}
+	if isIREnum(typ) {
+		str, ok := val.(string)
+		if !ok {
+			return
+		}
+		enumVal := reflect.New(typ)
+		if err := enumVal.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+			return
+		}
+		if enumVal.Elem().MethodByName("IsUnknown").Call(nil)[0].Bool() {
+			record(fmt.Sprintf("%s value %q", typ.Name(), str), path)
+		}
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		if isIRUnion(typ) {
+			variant, _ := obj["type"].(string)
+			field, ok := unionVariantField(typ, variant)
+			if !ok {
+				record(fmt.Sprintf("%s variant %q", typ.Name(), variant), path)
+				return
+			}
+			walkIRFeatures(obj[variant], field.Type, joinIRPath(path, variant), record)
+			return
+		}
+		fields := make(map[string]reflect.StructField)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+				fields[name] = field
+			}
+		}
+		for _, key := range sortedJSONKeys(obj) {
+			field, ok := fields[key]
+			if !ok {
+				if !isEmptyJSON(obj[key]) {
+					record(fmt.Sprintf("field %q of %s", key, typ.Name()), joinIRPath(path, key))
+				}
+				continue
+			}
+			walkIRFeatures(obj[key], field.Type, joinIRPath(path, key), record)
+		}
+	case reflect.Slice:
+		arr, ok := val.([]interface{})
+		if !ok {
+			return
+		}
+		for i, elem := range arr {
+			walkIRFeatures(elem, typ.Elem(), fmt.Sprintf("%s[%d]", path, i), record)
+		}
+	case reflect.Map:
+		obj, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, key := range sortedJSONKeys(obj) {
+			walkIRFeatures(obj[key], typ.Elem(), joinIRPath(path, key), record)
+		}
+	}
+}
+
+// isIREnum returns true if the provided type is a Conjure enum, which is represented as a struct with an unexported
+// "val" field that implements encoding.TextUnmarshaler and provides an IsUnknown method.
+func isIREnum(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	if _, ok := typ.FieldByName("val"); !ok {
+		return false
+	}
+	_, hasIsUnknown := typ.MethodByName("IsUnknown")
+	return hasIsUnknown && reflect.PtrTo(typ).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem())
+}
+
+// isIRUnion returns true if the provided type is a Conjure union, which is represented as a struct with an unexported
+// "typ" field and an unexported field for every variant.
+func isIRUnion(typ reflect.Type) bool {
+	field, ok := typ.FieldByName("typ")
+	return ok && field.Type.Kind() == reflect.String
+}
+
+// unionVariantField returns the field of the provided union type that stores the provided variant. Variants whose
+// names are Go keywords are stored in fields whose names have a "_" suffix.
+func unionVariantField(typ reflect.Type, variant string) (reflect.StructField, bool) {
+	if variant == "" || variant == "typ" {
+		return reflect.StructField{}, false
+	}
+	if field, ok := typ.FieldByName(variant); ok {
+		return field, true
+	}
+	return typ.FieldByName(variant + "_")
+}
+
+func isEmptyJSON(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func sortedJSONKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinIRPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// irUpgradeHints returns messages that describe the constructs used by the provided IR that are not supported by the
+// vendored conjure-go generator.
+func irUpgradeHints(irBytes []byte) []string {
+	usages := unsupportedIRFeatures(irBytes)
+	if len(usages) == 0 {
+		return nil
+	}
+	conjureGoVersion := vendoredConjureGoVersion()
+	var hints []string
+	for _, usage := range usages {
+		location := usage.path
+		if usage.count > 1 {
+			location = fmt.Sprintf("%s and %d other locations", usage.path, usage.count-1)
+		}
+		hints = append(hints, fmt.Sprintf("this IR uses %s (at %s), which requires a version of the plugin that is newer than the current one (which generates code using conjure-go %s)", usage.feature, location, conjureGoVersion))
+	}
+	return hints
+}
+
+// vendoredConjureGoVersion returns the version of conjure-go that the plugin was built with.
+func vendoredConjureGoVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == conjureGoModulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "(unknown version)"
+}
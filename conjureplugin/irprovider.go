@@ -15,9 +15,24 @@
 package conjureplugin
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/palantir/godel-conjure-plugin/v6/internal/ircache"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
 	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
 	"github.com/palantir/pkg/safehttp"
 	"github.com/pkg/errors"
@@ -32,21 +47,76 @@ type IRProvider interface {
 var _ IRProvider = &localYAMLIRProvider{}
 
 type localYAMLIRProvider struct {
-	path   string
+	paths  []string
 	params []conjureircli.Param
 }
 
 // NewLocalYAMLIRProvider returns an IRProvider that provides IR generated from local YAML. The provided path must be a
 // path to a Conjure YAML file or a directory that contains Conjure YAML files.
 func NewLocalYAMLIRProvider(path string, params ...conjureircli.Param) IRProvider {
+	return NewLocalYAMLPathsIRProvider([]string{path}, params...)
+}
+
+// NewLocalYAMLPathsIRProvider returns an IRProvider that provides IR generated by compiling the local YAML at all of
+// the provided paths together. Each path must be a path to a Conjure YAML file or a directory that contains Conjure
+// YAML files, or a glob pattern (as supported by filepath.Match) that matches such paths.
+func NewLocalYAMLPathsIRProvider(paths []string, params ...conjureircli.Param) IRProvider {
 	return &localYAMLIRProvider{
-		path:   path,
+		paths:  paths,
 		params: params,
 	}
 }
 
 func (p *localYAMLIRProvider) IRBytes() ([]byte, error) {
-	return conjureircli.InputPathToIRWithParams(p.path, p.params...)
+	inputPaths, err := p.inputPaths()
+	if err != nil {
+		return nil, err
+	}
+	return conjureircli.InputPathsToIRWithParams(inputPaths, p.params...)
+}
+
+// hasGlobPatterns returns true if any of the paths of the provider is a glob pattern.
+func (p *localYAMLIRProvider) hasGlobPatterns() bool {
+	for _, path := range p.paths {
+		if isGlobPattern(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// inputPaths returns the paths that are compiled by the provider. Glob patterns are expanded to the paths that they
+// match in sorted order, and paths matched by multiple patterns are only included once. Returns an error if a pattern
+// does not match any paths.
+func (p *localYAMLIRProvider) inputPaths() ([]string, error) {
+	var inputPaths []string
+	seen := make(map[string]struct{})
+	for _, path := range p.paths {
+		matches := []string{path}
+		if isGlobPattern(path) {
+			globMatches, err := filepath.Glob(path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid glob pattern %s", path)
+			}
+			if len(globMatches) == 0 {
+				return nil, errors.Errorf("no Conjure YAML files or directories match %s", path)
+			}
+			sort.Strings(globMatches)
+			matches = globMatches
+		}
+		for _, match := range matches {
+			if _, ok := seen[match]; ok {
+				continue
+			}
+			seen[match] = struct{}{}
+			inputPaths = append(inputPaths, match)
+		}
+	}
+	return inputPaths, nil
+}
+
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
 }
 
 func (p *localYAMLIRProvider) GeneratedFromYAML() bool {
@@ -56,26 +126,355 @@ func (p *localYAMLIRProvider) GeneratedFromYAML() bool {
 var _ IRProvider = &urlIRProvider{}
 
 type urlIRProvider struct {
-	irURL string
+	irURL       string
+	bearerToken string
+	basicAuth   *basicAuth
+	headers     map[string]string
+	retry       *RetryPolicy
+	cacheTTL    time.Duration
+	sha256      string
+	limits      IRLimits
+}
+
+// RetryPolicy specifies how failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one. Values less than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the time waited before the first retry, which doubles for every subsequent retry. If 0,
+	// defaultInitialBackoff is used.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time waited between attempts. If 0, defaultMaxBackoff is used.
+	MaxBackoff time.Duration
+	// RetryableStatusCodes are the response status codes for which requests are retried. If empty,
+	// DefaultRetryableStatusCodes is used. Requests that fail without a response are always retried.
+	RetryableStatusCodes []int
+}
+
+const (
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// DefaultRetryableStatusCodes returns the response status codes for which requests are retried if a RetryPolicy does
+// not specify them.
+func DefaultRetryableStatusCodes() []int {
+	return []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+}
+
+// backoff returns the time to wait before the provided retry (where the first retry is 1).
+func (r RetryPolicy) backoff(retry int) time.Duration {
+	backoff, maxBackoff := r.InitialBackoff, r.MaxBackoff
+	if backoff == 0 {
+		backoff = defaultInitialBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+func (r RetryPolicy) retryable(statusCode int) bool {
+	codes := r.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = DefaultRetryableStatusCodes()
+	}
+	for _, code := range codes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+// HTTPIRProviderParam configures an IRProvider returned by NewHTTPIRProvider.
+type HTTPIRProviderParam interface {
+	apply(*urlIRProvider)
 }
 
-// NewHTTPIRProvider returns an IRProvider that that provides IR downloaded from the provided URL over HTTP.
-func NewHTTPIRProvider(irURL string) IRProvider {
-	return &urlIRProvider{
+type httpIRProviderParamFn func(*urlIRProvider)
+
+func (fn httpIRProviderParamFn) apply(p *urlIRProvider) {
+	fn(p)
+}
+
+// HTTPIRProviderBearerToken returns a parameter that authenticates requests using the provided bearer token.
+func HTTPIRProviderBearerToken(token string) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		p.bearerToken = token
+	})
+}
+
+// HTTPIRProviderBasicAuth returns a parameter that authenticates requests using HTTP basic authentication with the
+// provided credentials.
+func HTTPIRProviderBasicAuth(username, password string) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		p.basicAuth = &basicAuth{
+			username: username,
+			password: password,
+		}
+	})
+}
+
+// HTTPIRProviderHeader returns a parameter that sets the provided header on requests.
+func HTTPIRProviderHeader(key, value string) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		if p.headers == nil {
+			p.headers = make(map[string]string)
+		}
+		p.headers[key] = value
+	})
+}
+
+// HTTPIRProviderRetry returns a parameter that retries failed requests using the provided policy.
+func HTTPIRProviderRetry(policy RetryPolicy) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		p.retry = &policy
+	})
+}
+
+// HTTPIRProviderCache returns a parameter that caches downloaded IR on disk and uses the cached IR for the provided
+// duration rather than downloading it again.
+func HTTPIRProviderCache(ttl time.Duration) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		p.cacheTTL = ttl
+	})
+}
+
+// HTTPIRProviderSHA256 returns a parameter that requires the SHA-256 checksum of the downloaded IR to match the provided
+// hex-encoded checksum.
+func HTTPIRProviderSHA256(checksum string) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		p.sha256 = strings.ToLower(checksum)
+	})
+}
+
+// IRLimits specifies limits on the size of IR. Limits that are 0 are not enforced.
+type IRLimits struct {
+	// MaxBytes is the maximum size of the IR in bytes.
+	MaxBytes int64
+	// MaxTypes is the maximum number of types defined in the IR.
+	MaxTypes int
+}
+
+// HTTPIRProviderLimits returns a parameter that fails if the downloaded IR exceeds the provided limits. No more than
+// the maximum number of bytes (plus one) is read from the response.
+func HTTPIRProviderLimits(limits IRLimits) HTTPIRProviderParam {
+	return httpIRProviderParamFn(func(p *urlIRProvider) {
+		p.limits = limits
+	})
+}
+
+// NewHTTPIRProvider returns an IRProvider that that provides IR downloaded from the provided URL over HTTP. The IR may
+// be compressed using gzip, and if it is the index of sharded IR, the shards are downloaded from the URLs relative to
+// the provided URL (using the same parameters) and assembled into a single IR document.
+func NewHTTPIRProvider(irURL string, params ...HTTPIRProviderParam) IRProvider {
+	provider := &urlIRProvider{
 		irURL: irURL,
 	}
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		param.apply(provider)
+	}
+	return provider
 }
 
 func (p *urlIRProvider) IRBytes() ([]byte, error) {
-	resp, cleanup, err := safehttp.Get(http.DefaultClient, p.irURL)
+	// include the expected checksum in the key so that changing the pinned checksum invalidates the cached IR
+	cacheKey := p.irURL
+	if p.sha256 != "" {
+		cacheKey += "@sha256:" + p.sha256
+	}
+	irBytes, err := p.cachedDownload(p.irURL, cacheKey, p.verifyChecksum)
+	if err != nil {
+		return nil, err
+	}
+	irBytes, err = resolveIR(irBytes, p.limits.MaxBytes, func(shardPath string, verify func([]byte) error) ([]byte, error) {
+		// the shards are only pinned by the checksum of the index if the index specifies their checksums
+		if p.sha256 != "" && verify == nil {
+			return nil, errors.Errorf("the index does not specify the SHA-256 checksum of the shard, which is required because the checksum of the IR is pinned")
+		}
+		base, err := url.Parse(p.irURL)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ref, err := url.Parse(shardPath)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		shardURL := base.ResolveReference(ref).String()
+		// the shard is verified before it is cached, and cached content that does not match the checksum is downloaded
+		// again
+		return p.cachedDownload(shardURL, shardURL, verify)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid IR from remote source %s", p.irURL)
+	}
+	if err := p.verifyTypes(irBytes); err != nil {
+		return nil, err
+	}
+	return irBytes, nil
+}
+
+// cachedDownload returns the content at the provided URL, which is verified using the provided function (if non-nil)
+// and the size limit of the provider. If caching is enabled, the content is read from and recorded in the cache using
+// the provided key.
+func (p *urlIRProvider) cachedDownload(downloadURL, cacheKey string, verify func([]byte) error) ([]byte, error) {
+	verified := func(content []byte) bool {
+		return p.verifySize(downloadURL, content) == nil && (verify == nil || verify(content) == nil)
+	}
+	if offline.Enabled() {
+		// in offline mode, cached IR is used regardless of its age since it cannot be downloaded again
+		if p.cacheTTL > 0 {
+			if content, ok := ircache.Get(cacheKey, math.MaxInt64); ok && verified(content) {
+				return content, nil
+			}
+		}
+		return nil, offline.Check("download IR from remote source", downloadURL, "use a locator that provides the IR locally (such as ir-file) or specify cache-ttl for the locator and run once with network access so that the IR is cached")
+	}
+	if p.cacheTTL <= 0 {
+		return p.verifiedDownload(downloadURL, verify)
+	}
+	if content, ok := ircache.Get(cacheKey, p.cacheTTL); ok && verified(content) {
+		return content, nil
+	}
+	content, err := p.verifiedDownload(downloadURL, verify)
+	if err != nil {
+		return nil, err
+	}
+	// failing to cache the IR does not affect the result, so the error is ignored
+	_ = ircache.Put(cacheKey, content)
+	return content, nil
+}
+
+// verifiedDownload downloads the content at the provided URL and verifies its size and, if the provided function is
+// non-nil, verifies it using the function.
+func (p *urlIRProvider) verifiedDownload(downloadURL string, verify func([]byte) error) ([]byte, error) {
+	content, err := p.download(downloadURL)
 	if err != nil {
-		return nil, errors.WithStack(err)
+		return nil, err
+	}
+	if err := p.verifySize(downloadURL, content); err != nil {
+		return nil, err
+	}
+	if verify != nil {
+		if err := verify(content); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// verifyChecksum returns an error if a checksum is pinned and the checksum of the provided IR does not match it.
+func (p *urlIRProvider) verifyChecksum(irBytes []byte) error {
+	if p.sha256 == "" {
+		return nil
+	}
+	if actual := fmt.Sprintf("%x", sha256.Sum256(irBytes)); actual != p.sha256 {
+		return errors.Errorf("SHA-256 checksum of IR from remote source %s does not match the expected checksum: expected %s, but was %s", p.irURL, p.sha256, actual)
+	}
+	return nil
+}
+
+// verifySize returns an error if the provided content downloaded from the provided URL exceeds the size limit of the
+// provider.
+func (p *urlIRProvider) verifySize(downloadURL string, content []byte) error {
+	if p.limits.MaxBytes > 0 && int64(len(content)) > p.limits.MaxBytes {
+		return errors.Errorf("IR from remote source %s is larger than the limit of %d bytes", downloadURL, p.limits.MaxBytes)
+	}
+	return nil
+}
+
+// verifyTypes returns an error if the provided IR exceeds the limit on the number of types of the provider.
+func (p *urlIRProvider) verifyTypes(irBytes []byte) error {
+	if p.limits.MaxTypes > 0 {
+		var ir struct {
+			Types []json.RawMessage `json:"types"`
+		}
+		if err := json.Unmarshal(irBytes, &ir); err != nil {
+			return errors.Wrapf(err, "failed to determine number of types in IR from remote source %s", p.irURL)
+		}
+		if len(ir.Types) > p.limits.MaxTypes {
+			return errors.Errorf("IR from remote source %s defines %d types, which exceeds the limit of %d types", p.irURL, len(ir.Types), p.limits.MaxTypes)
+		}
+	}
+	return nil
+}
+
+// download downloads the content at the provided URL, retrying failed requests as specified by the retry policy.
+func (p *urlIRProvider) download(downloadURL string) ([]byte, error) {
+	maxAttempts := 1
+	if p.retry != nil && p.retry.MaxAttempts > 1 {
+		maxAttempts = p.retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		irBytes, statusCode, err := p.fetch(downloadURL)
+		if err == nil {
+			return irBytes, nil
+		}
+		// statusCode is 0 if the request failed without a response
+		if attempt == maxAttempts || (statusCode != 0 && !p.retry.retryable(statusCode)) {
+			if attempt > 1 {
+				return nil, errors.Wrapf(err, "failed after %d attempts", attempt)
+			}
+			return nil, err
+		}
+		time.Sleep(p.retry.backoff(attempt))
+	}
+}
+
+// fetch performs a single request for the content at the provided URL. If the request fails, returns the status code
+// of the response or 0 if no response was received.
+func (p *urlIRProvider) fetch(downloadURL string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to create request for IR from remote source %s", downloadURL)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	if p.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.bearerToken)
+	}
+	if p.basicAuth != nil {
+		req.SetBasicAuth(p.basicAuth.username, p.basicAuth.password)
+	}
+	resp, cleanup, err := safehttp.Do(http.DefaultClient, req)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
 	}
 	defer cleanup()
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("expected response status 200 when fetching IR from remote source %s, but got %d", p.irURL, resp.StatusCode)
+		return nil, resp.StatusCode, errors.Errorf("expected response status 200 when fetching IR from remote source %s, but got %d", downloadURL, resp.StatusCode)
+	}
+	var body io.Reader = resp.Body
+	if p.limits.MaxBytes > 0 {
+		// read at most one byte more than the limit so that IR that exceeds it is detected without reading all of it
+		body = io.LimitReader(body, p.limits.MaxBytes+1)
+	}
+	irBytes, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to read IR from remote source %s", downloadURL)
 	}
-	return ioutil.ReadAll(resp.Body)
+	return irBytes, 0, nil
 }
 
 func (p *urlIRProvider) GeneratedFromYAML() bool {
@@ -88,7 +487,9 @@ type localFileIRProvider struct {
 	path string
 }
 
-// NewLocalFileIRProvider returns an IRProvider that that provides IR from the local file at the specified path.
+// NewLocalFileIRProvider returns an IRProvider that that provides IR from the local file at the specified path. The
+// file may be compressed using gzip, and if it is the index of sharded IR, the shards are read from the paths relative
+// to the directory of the file and assembled into a single IR document.
 func NewLocalFileIRProvider(path string) IRProvider {
 	return &localFileIRProvider{
 		path: path,
@@ -96,9 +497,140 @@ func NewLocalFileIRProvider(path string) IRProvider {
 }
 
 func (p *localFileIRProvider) IRBytes() ([]byte, error) {
-	return ioutil.ReadFile(p.path)
+	irBytes, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	irBytes, err = resolveIR(irBytes, 0, func(shardPath string, verify func([]byte) error) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(filepath.Dir(p.path), filepath.FromSlash(shardPath)))
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid IR file %s", p.path)
+	}
+	return irBytes, nil
 }
 
 func (p *localFileIRProvider) GeneratedFromYAML() bool {
 	return false
 }
+
+var _ IRProvider = &gitIRProvider{}
+
+type gitIRProvider struct {
+	repo   string
+	ref    string
+	path   string
+	params []conjureircli.Param
+}
+
+// NewGitIRProvider returns an IRProvider that provides IR generated from the Conjure YAML in a Git repository. The
+// repository is fetched at the provided ref (which may be a branch, tag or commit) and the provided path must be a path
+// within the repository to a Conjure YAML file or a directory that contains Conjure YAML files. If the path is empty,
+// the root directory of the repository is used.
+func NewGitIRProvider(repo, ref, path string, params ...conjureircli.Param) IRProvider {
+	return &gitIRProvider{
+		repo:   repo,
+		ref:    ref,
+		path:   path,
+		params: params,
+	}
+}
+
+func (p *gitIRProvider) IRBytes() (rBytes []byte, rErr error) {
+	// the ref cannot be separated from the options of "git checkout" using "--" (which precedes paths), so values
+	// that Git would parse as options are rejected
+	if strings.HasPrefix(p.repo, "-") || strings.HasPrefix(p.ref, "-") {
+		return nil, errors.Errorf("invalid Git repository %q or ref %q: must not start with \"-\"", p.repo, p.ref)
+	}
+	repo := p.repo
+	// repositories that are local directories can be fetched without network access
+	if _, err := os.Stat(repo); err != nil {
+		if err := offline.Check("fetch Git repository", repo, "use a locator that provides the definitions locally (such as yaml or ir-file)"); err != nil {
+			return nil, err
+		}
+	} else {
+		// Git is run in a temporary directory, so relative paths to local repositories are resolved against the working
+		// directory (the project directory) first
+		if repo, err = filepath.Abs(repo); err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve path of Git repository %s", p.repo)
+		}
+	}
+	tmpDir, err := tempfilecreator.MkdirTemp("git-" + filepath.Base(p.repo))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+
+	if err := runGit(tmpDir, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+	// fetch only the requested ref if possible. Some servers do not allow commits to be fetched directly, so fall back
+	// to fetching all refs and resolving the ref locally.
+	checkoutRef := "FETCH_HEAD"
+	if err := runGit(tmpDir, "fetch", "--quiet", "--depth", "1", "--", repo, p.ref); err != nil {
+		if err := runGit(tmpDir, "fetch", "--quiet", "--tags", "--", repo, "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch %s from Git repository %s", p.ref, p.repo)
+		}
+		checkoutRef = p.ref
+	}
+	if err := runGit(tmpDir, "checkout", "--quiet", checkoutRef, "--"); err != nil {
+		return nil, errors.Wrapf(err, "failed to check out %s from Git repository %s", p.ref, p.repo)
+	}
+	return conjureircli.InputPathToIRWithParams(filepath.Join(tmpDir, p.path), p.params...)
+}
+
+// GeneratedFromYAML returns false: although the IR is generated from YAML, the YAML is defined in another repository,
+// so the IR should not be published by default.
+func (p *gitIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to execute %v\nOutput:\n%s", cmd.Args, string(output))
+	}
+	return nil
+}
+
+var _ IRProvider = &projectIRProvider{}
+
+type projectIRProvider struct {
+	project  string
+	provider IRProvider
+}
+
+// NewProjectIRProvider returns an IRProvider that provides the IR of another project. The provided project is the name
+// of the other project and the provided provider is its IRProvider.
+func NewProjectIRProvider(project string, provider IRProvider) IRProvider {
+	return &projectIRProvider{
+		project:  project,
+		provider: provider,
+	}
+}
+
+func (p *projectIRProvider) IRBytes() ([]byte, error) {
+	return p.provider.IRBytes()
+}
+
+// GeneratedFromYAML returns false: the IR is published by the project that it is provided by.
+func (p *projectIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+// sourceIRProvider returns the provider that computes the IR of the provided provider, which is the provider itself
+// unless it provides the IR of another project.
+func sourceIRProvider(provider IRProvider) IRProvider {
+	for {
+		projectProvider, ok := provider.(*projectIRProvider)
+		if !ok {
+			return provider
+		}
+		provider = projectProvider.provider
+	}
+}
@@ -0,0 +1,460 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPIRProviderAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Client") != "godel" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(testIRJSON))
+	}))
+	defer server.Close()
+
+	_, err := conjureplugin.NewHTTPIRProvider(server.URL).IRBytes()
+	assert.EqualError(t, err, "expected response status 200 when fetching IR from remote source "+server.URL+", but got 401")
+
+	got, err := conjureplugin.NewHTTPIRProvider(server.URL,
+		conjureplugin.HTTPIRProviderBearerToken("token"),
+		conjureplugin.HTTPIRProviderHeader("X-Client", "godel"),
+	).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+}
+
+func TestHTTPIRProviderRetry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/flaky":
+			if requests < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(testIRJSON))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	retry := conjureplugin.HTTPIRProviderRetry(conjureplugin.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	})
+
+	got, err := conjureplugin.NewHTTPIRProvider(server.URL+"/flaky", retry).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+	assert.Equal(t, 3, requests)
+
+	requests = 0
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL+"/missing", retry).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("expected response status 200 when fetching IR from remote source %s/missing, but got 404", server.URL))
+	assert.Equal(t, 1, requests, "non-retryable status should not be retried")
+
+	requests = 0
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL+"/unavailable", retry).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("failed after 3 attempts: expected response status 200 when fetching IR from remote source %s/unavailable, but got 503", server.URL))
+	assert.Equal(t, 3, requests)
+}
+
+func TestHTTPIRProviderCache(t *testing.T) {
+	t.Setenv("GODEL_HOME", t.TempDir())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(testIRJSON))
+	}))
+	defer server.Close()
+
+	provider := conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderCache(time.Hour))
+	for i := 0; i < 2; i++ {
+		got, err := provider.IRBytes()
+		require.NoError(t, err)
+		assert.Equal(t, testIRJSON, string(got))
+	}
+	assert.Equal(t, 1, requests, "cached IR should be used")
+
+	_, err := conjureplugin.NewHTTPIRProvider(server.URL).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests, "IR should not be cached if caching is not enabled")
+}
+
+func TestHTTPIRProviderOffline(t *testing.T) {
+	t.Setenv("GODEL_HOME", t.TempDir())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(testIRJSON))
+	}))
+	defer server.Close()
+
+	// cache the IR with a TTL that has expired by the time it is read in offline mode
+	_, err := conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderCache(time.Nanosecond)).IRBytes()
+	require.NoError(t, err)
+	require.Equal(t, 1, requests)
+
+	t.Setenv(offline.EnvVar, "true")
+	got, err := conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderCache(time.Nanosecond)).IRBytes()
+	require.NoError(t, err, "cached IR should be used in offline mode regardless of its age")
+	assert.Equal(t, testIRJSON, string(got))
+
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("offline mode is enabled (--offline or CONJURE_PLUGIN_OFFLINE=true), so cannot download IR from remote source %s: use a locator that provides the IR locally (such as ir-file) or specify cache-ttl for the locator and run once with network access so that the IR is cached", server.URL))
+
+	_, err = conjureplugin.NewGitIRProvider("https://github.com/palantir/conjure.git", "master", "").IRBytes()
+	assert.EqualError(t, err, "offline mode is enabled (--offline or CONJURE_PLUGIN_OFFLINE=true), so cannot fetch Git repository https://github.com/palantir/conjure.git: use a locator that provides the definitions locally (such as yaml or ir-file)")
+	assert.Equal(t, 1, requests, "no requests should be made in offline mode")
+}
+
+func TestGitIRProviderRejectsOptions(t *testing.T) {
+	for i, tc := range []struct {
+		repo string
+		ref  string
+	}{
+		{"--upload-pack=touch pwned", "master"},
+		{"https://github.com/palantir/conjure.git", "--output=pwned"},
+	} {
+		_, err := conjureplugin.NewGitIRProvider(tc.repo, tc.ref, "").IRBytes()
+		assert.EqualError(t, err, fmt.Sprintf(`invalid Git repository %q or ref %q: must not start with "-"`, tc.repo, tc.ref), "Case %d", i)
+	}
+}
+
+func TestGitIRProviderLocalRepo(t *testing.T) {
+	rootDir := t.TempDir()
+	srcDir := filepath.Join(rootDir, "api-src")
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "conjure"), 0755))
+	gitCmd := func(dir string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+	}
+	gitCmd(srcDir, "init", "--quiet")
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "conjure", "api.yml"), []byte(`
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`), 0644))
+	gitCmd(srcDir, "add", ".")
+	gitCmd(srcDir, "commit", "--quiet", "-m", "add API")
+	gitCmd(srcDir, "tag", "1.0.0")
+	gitCmd(srcDir, "branch", "-M", "main")
+	gitCmd(rootDir, "clone", "--quiet", "--bare", "api-src", "api-repo.git")
+
+	// the repository is referenced relative to the project directory, which is the working directory of the plugin
+	projectDir := filepath.Join(rootDir, "project")
+	require.NoError(t, os.Mkdir(projectDir, 0755))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(projectDir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	for i, ref := range []string{"1.0.0", "main"} {
+		got, err := conjureplugin.NewGitIRProvider(filepath.Join("..", "api-repo.git"), ref, "conjure", conjureircli.NativeCompilerParam()).IRBytes()
+		require.NoError(t, err, "Case %d: %s", i, ref)
+		assert.Contains(t, string(got), `"BooleanExample"`, "Case %d: %s", i, ref)
+	}
+}
+
+func TestHTTPIRProviderSHA256(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testIRJSON))
+	}))
+	defer server.Close()
+
+	checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(testIRJSON)))
+	got, err := conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderSHA256(strings.ToUpper(checksum))).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+
+	wrongChecksum := strings.Repeat("0", 64)
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderSHA256(wrongChecksum)).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("SHA-256 checksum of IR from remote source %s does not match the expected checksum: expected %s, but was %s", server.URL, wrongChecksum, checksum))
+}
+
+func TestHTTPIRProviderLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testIRJSON))
+	}))
+	defer server.Close()
+
+	got, err := conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxBytes: int64(len(testIRJSON)),
+		MaxTypes: 1,
+	})).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxBytes: 10,
+	})).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("IR from remote source %s is larger than the limit of 10 bytes", server.URL))
+
+	typesServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version":1,"types":[{},{}]}`))
+	}))
+	defer typesServer.Close()
+
+	_, err = conjureplugin.NewHTTPIRProvider(typesServer.URL, conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxTypes: 1,
+	})).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("IR from remote source %s defines 2 types, which exceeds the limit of 1 types", typesServer.URL))
+
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL, conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxTypes: -1,
+	})).IRBytes()
+	require.NoError(t, err, "negative limits are not enforced")
+}
+
+const (
+	testShardIndexJSON = `{"version":1,"shards":["shards/foo.conjure.json","shards/bar.conjure.json"],"extensions":{"source":"test"}}`
+	testFooShardJSON   = `{"version":1,"types":[{"type":"object","object":{"typeName":{"name":"Foo","package":"com.palantir.foo"},"fields":[]}}]}`
+	testBarShardJSON   = `{"version":1,"services":[{"serviceName":{"name":"BarService","package":"com.palantir.bar"},"endpoints":[]}]}`
+)
+
+func TestLocalFileIRProviderShards(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "shards"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shards", "foo.conjure.json"), gzipBytes(t, testFooShardJSON), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shards", "bar.conjure.json"), []byte(testBarShardJSON), 0644))
+
+	for i, tc := range []struct {
+		name    string
+		content []byte
+		want    string
+		wantErr string
+	}{
+		{
+			"plain.conjure.json",
+			[]byte(testIRJSON),
+			testIRJSON,
+			"",
+		},
+		{
+			"compressed.conjure.json",
+			gzipBytes(t, testIRJSON),
+			testIRJSON,
+			"",
+		},
+		{
+			"index.conjure.json",
+			gzipBytes(t, testShardIndexJSON),
+			`{"version":1,"errors":[],"types":[{"type":"object","object":{"typeName":{"name":"Foo","package":"com.palantir.foo"},"fields":[],"docs":null}}],"services":[{"serviceName":{"name":"BarService","package":"com.palantir.bar"},"endpoints":[],"docs":null}],"extensions":{"source":"test"}}`,
+			"",
+		},
+		{
+			"duplicate.conjure.json",
+			[]byte(`{"version":1,"shards":["shards/foo.conjure.json","shards/foo.conjure.json"]}`),
+			"",
+			"type com.palantir.foo.Foo is defined by shards shards/foo.conjure.json and shards/foo.conjure.json",
+		},
+		{
+			"parent.conjure.json",
+			[]byte(`{"version":1,"shards":["../foo.conjure.json"]}`),
+			"",
+			`invalid IR shard path "../foo.conjure.json": must be a relative path within the directory of the index`,
+		},
+		{
+			"mixed.conjure.json",
+			[]byte(`{"version":1,"shards":[],"types":[]}`),
+			"",
+			"index of sharded IR must not define types",
+		},
+		{
+			"version.conjure.json",
+			[]byte(`{"version":2,"shards":["shards/bar.conjure.json"]}`),
+			"",
+			"IR shard shards/bar.conjure.json has version 1, but the index has version 2",
+		},
+	} {
+		irPath := filepath.Join(dir, tc.name)
+		require.NoError(t, os.WriteFile(irPath, tc.content, 0644), "Case %d", i)
+
+		got, err := conjureplugin.NewLocalFileIRProvider(irPath).IRBytes()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, fmt.Sprintf("invalid IR file %s: %s", irPath, tc.wantErr), "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, string(got), "Case %d", i)
+	}
+}
+
+func TestHTTPIRProviderShards(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/ir/index.conjure.json":
+			_, _ = w.Write([]byte(testShardIndexJSON))
+		case "/ir/shards/foo.conjure.json":
+			_, _ = w.Write([]byte(testFooShardJSON))
+		case "/ir/shards/bar.conjure.json":
+			_, _ = w.Write(gzipBytes(t, testBarShardJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	got, err := conjureplugin.NewHTTPIRProvider(server.URL+"/ir/index.conjure.json", conjureplugin.HTTPIRProviderBearerToken("token")).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/ir/index.conjure.json", "/ir/shards/foo.conjure.json", "/ir/shards/bar.conjure.json"}, requested)
+	assert.Contains(t, string(got), `"name":"Foo"`)
+	assert.Contains(t, string(got), `"name":"BarService"`)
+
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL+"/ir/index.conjure.json", conjureplugin.HTTPIRProviderBearerToken("token"), conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxBytes: int64(len(testShardIndexJSON)),
+	})).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("invalid IR from remote source %s/ir/index.conjure.json: failed to load IR shard shards/foo.conjure.json: IR from remote source %s/ir/shards/foo.conjure.json is larger than the limit of %d bytes", server.URL, server.URL, len(testShardIndexJSON)))
+}
+
+func TestHTTPIRProviderShardsMaxBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ir/index.conjure.json":
+			_, _ = w.Write([]byte(testShardIndexJSON))
+		case "/ir/shards/foo.conjure.json":
+			_, _ = w.Write(gzipBytes(t, testFooShardJSON))
+		case "/ir/shards/bar.conjure.json":
+			_, _ = w.Write([]byte(testBarShardJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// every document is within the limit, but the assembled IR exceeds it
+	maxBytes := int64(len(testShardIndexJSON) + len(testFooShardJSON) + len(testBarShardJSON) - 1)
+	for _, size := range []int{len(testShardIndexJSON), len(testFooShardJSON), len(testBarShardJSON)} {
+		require.Less(t, int64(size), maxBytes)
+	}
+	_, err := conjureplugin.NewHTTPIRProvider(server.URL+"/ir/index.conjure.json", conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxBytes: maxBytes,
+	})).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("invalid IR from remote source %s/ir/index.conjure.json: sharded IR is larger than the limit of %d bytes: the index and the shards up to shards/bar.conjure.json have %d bytes", server.URL, maxBytes, maxBytes+1))
+
+	_, err = conjureplugin.NewHTTPIRProvider(server.URL+"/ir/index.conjure.json", conjureplugin.HTTPIRProviderLimits(conjureplugin.IRLimits{
+		MaxBytes: maxBytes + 1,
+	})).IRBytes()
+	require.NoError(t, err)
+}
+
+func TestHTTPIRProviderShardChecksums(t *testing.T) {
+	t.Setenv("GODEL_HOME", t.TempDir())
+	checksum := func(content string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+	}
+	indexJSON := `{"version":1,"shards":["shards/foo.conjure.json","shards/bar.conjure.json"],"shardSha256":{"shards/foo.conjure.json":"` + checksum(testFooShardJSON) + `","shards/bar.conjure.json":"` + checksum(testBarShardJSON) + `"}}`
+	tamperedFooShardJSON := strings.Replace(testFooShardJSON, "Foo", "Evil", 1)
+	fooShard := tamperedFooShardJSON
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ir/index.conjure.json":
+			_, _ = w.Write([]byte(indexJSON))
+		case "/ir/unpinned.conjure.json":
+			_, _ = w.Write([]byte(testShardIndexJSON))
+		case "/ir/shards/foo.conjure.json":
+			_, _ = w.Write([]byte(fooShard))
+		case "/ir/shards/bar.conjure.json":
+			_, _ = w.Write([]byte(testBarShardJSON))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	newProvider := func(path, pinned string) conjureplugin.IRProvider {
+		return conjureplugin.NewHTTPIRProvider(server.URL+path, conjureplugin.HTTPIRProviderSHA256(pinned), conjureplugin.HTTPIRProviderCache(time.Hour))
+	}
+	_, err := newProvider("/ir/index.conjure.json", checksum(indexJSON)).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("invalid IR from remote source %s/ir/index.conjure.json: failed to load IR shard shards/foo.conjure.json: SHA-256 checksum of IR shard shards/foo.conjure.json does not match the checksum specified by the index: expected %s, but was %s", server.URL, checksum(testFooShardJSON), checksum(tamperedFooShardJSON)))
+
+	// the tampered shard was not cached, so the genuine shard is downloaded once it is served
+	fooShard = testFooShardJSON
+	got, err := newProvider("/ir/index.conjure.json", checksum(indexJSON)).IRBytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(got), `"name":"Foo"`)
+	assert.NotContains(t, string(got), `"name":"Evil"`)
+
+	_, err = newProvider("/ir/unpinned.conjure.json", checksum(testShardIndexJSON)).IRBytes()
+	assert.EqualError(t, err, fmt.Sprintf("invalid IR from remote source %s/ir/unpinned.conjure.json: failed to load IR shard shards/foo.conjure.json: the index does not specify the SHA-256 checksum of the shard, which is required because the checksum of the IR is pinned", server.URL))
+}
+
+func gzipBytes(t *testing.T, content string) []byte {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	_, err := zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestLocalYAMLIRProviderGlob(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "apis", "foo", "conjure"), 0755))
+
+	for i, tc := range []struct {
+		paths   []string
+		wantErr string
+	}{
+		{
+			[]string{filepath.Join(dir, "apis", "*", "definitions")},
+			fmt.Sprintf("no Conjure YAML files or directories match %s", filepath.Join(dir, "apis", "*", "definitions")),
+		},
+		{
+			[]string{filepath.Join(dir, "apis", "[", "conjure")},
+			fmt.Sprintf("invalid glob pattern %s: syntax error in pattern", filepath.Join(dir, "apis", "[", "conjure")),
+		},
+	} {
+		_, err := conjureplugin.NewLocalYAMLPathsIRProvider(tc.paths).IRBytes()
+		assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+	}
+}
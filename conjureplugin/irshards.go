@@ -0,0 +1,281 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+// IR can be provided as a single document or in a sharded layout that consists of an index document and shards. The
+// index is a JSON object that has the version and extensions of the IR and lists the paths of its shards (relative to
+// the location of the index) in a "shards" field:
+//
+//	{"version": 1, "shards": ["com.palantir.foo.conjure.json", "com.palantir.bar.conjure.json"], "extensions": {}}
+//
+// Every shard is an IR document that defines the types, errors and services of (typically) one package. The index and
+// the shards may be compressed using gzip. The index may specify the hex-encoded SHA-256 checksum of the content of
+// every shard (as it is stored, so before it is decompressed) in a "shardSha256" field keyed by the path of the shard,
+// in which case every shard is verified against its checksum. Pinning the checksum of the index therefore pins the
+// checksums of all of the shards.
+
+// irShardIndex is the index of IR in the sharded layout.
+type irShardIndex struct {
+	Version     int                    `json:"version"`
+	Shards      []string               `json:"shards"`
+	ShardSHA256 map[string]string      `json:"shardSha256,omitempty"`
+	Extensions  map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// irShard is a shard of IR in the sharded layout.
+type irShard struct {
+	// name is the path of the shard relative to the index.
+	name    string
+	content []byte
+}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompressIR returns the provided IR decompressed if it is compressed using gzip and as-is otherwise. If maxBytes is
+// positive, returns an error if the decompressed IR is larger than maxBytes.
+func decompressIR(irBytes []byte, maxBytes int64) ([]byte, error) {
+	if !bytes.HasPrefix(irBytes, gzipMagic) {
+		return irBytes, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(irBytes))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decompress IR")
+	}
+	var r io.Reader = zr
+	if maxBytes > 0 {
+		// read at most one byte more than the limit so that IR that exceeds it is detected without decompressing all
+		// of it
+		r = io.LimitReader(r, maxBytes+1)
+	}
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decompress IR")
+	}
+	if maxBytes > 0 && int64(len(decompressed)) > maxBytes {
+		return nil, errors.Errorf("decompressed IR is larger than the limit of %d bytes", maxBytes)
+	}
+	return decompressed, nil
+}
+
+// resolveIR returns the IR for the provided IR document. The document is decompressed if it is compressed, and if it
+// is the index of sharded IR, the shards are loaded using the provided function and assembled into a single document.
+// The function is called with the path of every shard relative to the index and, if the index specifies the checksum
+// of the shard, a function that verifies the content of the shard against it (which is nil otherwise). The function
+// should verify the content before it is cached, and resolveIR verifies it again after it is loaded. Otherwise, the
+// decompressed document is returned as-is. If maxBytes is positive, it limits the decompressed size of the document
+// and, for sharded IR, the total decompressed size of the index and all of the shards, so sharding IR does not raise
+// the limit. Only one shard is held in memory at a time in addition to the assembled definitions.
+func resolveIR(irBytes []byte, maxBytes int64, loadShard func(shardPath string, verify func([]byte) error) ([]byte, error)) ([]byte, error) {
+	irBytes, err := decompressIR(irBytes, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	index, ok, err := parseIRShardIndex(irBytes)
+	if err != nil || !ok {
+		return irBytes, err
+	}
+	assembled := spec.ConjureDefinition{
+		Version:    index.Version,
+		Extensions: index.Extensions,
+	}
+	// totalBytes is the decompressed size of the index and the shards that have been loaded
+	totalBytes := int64(len(irBytes))
+	definedBy := make(map[string]string)
+	define := func(kind string, name spec.TypeName, shardPath string) error {
+		key := kind + " " + qualifiedName(name)
+		if other, ok := definedBy[key]; ok {
+			return errors.Errorf("%s is defined by shards %s and %s", key, other, shardPath)
+		}
+		definedBy[key] = shardPath
+		return nil
+	}
+	for _, shardPath := range index.Shards {
+		if err := validateShardPath(shardPath); err != nil {
+			return nil, err
+		}
+		verify := index.shardVerifier(shardPath)
+		shardBytes, err := loadShard(shardPath, verify)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load IR shard %s", shardPath)
+		}
+		if verify != nil {
+			if err := verify(shardBytes); err != nil {
+				return nil, err
+			}
+		}
+		if shardBytes, err = decompressIR(shardBytes, maxBytes); err != nil {
+			return nil, errors.Wrapf(err, "invalid IR shard %s", shardPath)
+		}
+		if totalBytes += int64(len(shardBytes)); maxBytes > 0 && totalBytes > maxBytes {
+			return nil, errors.Errorf("sharded IR is larger than the limit of %d bytes: the index and the shards up to %s have %d bytes", maxBytes, shardPath, totalBytes)
+		}
+		var shard spec.ConjureDefinition
+		if err := json.Unmarshal(shardBytes, &shard); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse IR shard %s", shardPath)
+		}
+		if shard.Version != index.Version {
+			return nil, errors.Errorf("IR shard %s has version %d, but the index has version %d", shardPath, shard.Version, index.Version)
+		}
+		for _, typeDef := range shard.Types {
+			name, _ := typeDefinitionRefs(typeDef)
+			if err := define("type", name, shardPath); err != nil {
+				return nil, err
+			}
+		}
+		for _, errorDef := range shard.Errors {
+			if err := define("error", errorDef.ErrorName, shardPath); err != nil {
+				return nil, err
+			}
+		}
+		for _, serviceDef := range shard.Services {
+			if err := define("service", serviceDef.ServiceName, shardPath); err != nil {
+				return nil, err
+			}
+		}
+		assembled.Types = append(assembled.Types, shard.Types...)
+		assembled.Errors = append(assembled.Errors, shard.Errors...)
+		assembled.Services = append(assembled.Services, shard.Services...)
+	}
+	assembledBytes, err := json.Marshal(assembled)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to assemble sharded IR")
+	}
+	return assembledBytes, nil
+}
+
+// shardVerifier returns a function that returns an error if the provided content of the shard with the provided path
+// does not match the checksum of the shard specified by the index. Returns nil if the index does not specify a checksum
+// for the shard.
+func (i irShardIndex) shardVerifier(shardPath string) func([]byte) error {
+	checksum, ok := i.ShardSHA256[shardPath]
+	if !ok {
+		return nil
+	}
+	checksum = strings.ToLower(checksum)
+	return func(content []byte) error {
+		if actual := fmt.Sprintf("%x", sha256.Sum256(content)); actual != checksum {
+			return errors.Errorf("SHA-256 checksum of IR shard %s does not match the checksum specified by the index: expected %s, but was %s", shardPath, checksum, actual)
+		}
+		return nil
+	}
+}
+
+// parseIRShardIndex returns the index of sharded IR if the provided IR document is an index. Returns false if the
+// document is not an index (including if it is not a JSON object, in which case the error is left to the consumer of
+// the IR).
+func parseIRShardIndex(irBytes []byte) (irShardIndex, bool, error) {
+	if !bytes.Contains(irBytes, []byte(`"shards"`)) {
+		return irShardIndex{}, false, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(irBytes, &fields); err != nil {
+		return irShardIndex{}, false, nil
+	}
+	if _, ok := fields["shards"]; !ok {
+		return irShardIndex{}, false, nil
+	}
+	for _, field := range []string{"types", "errors", "services"} {
+		if _, ok := fields[field]; ok {
+			return irShardIndex{}, false, errors.Errorf("index of sharded IR must not define %s", field)
+		}
+	}
+	var index irShardIndex
+	if err := json.Unmarshal(irBytes, &index); err != nil {
+		return irShardIndex{}, false, errors.Wrapf(err, "failed to parse index of sharded IR")
+	}
+	return index, true, nil
+}
+
+// validateShardPath returns an error if the provided path of a shard is not a slash-separated path relative to the
+// location of the index that does not refer to a parent directory.
+func validateShardPath(shardPath string) error {
+	if shardPath == "" || path.IsAbs(shardPath) || strings.Contains(shardPath, `\`) || strings.Contains(shardPath, "://") || path.Clean(shardPath) != shardPath || shardPath == ".." || strings.HasPrefix(shardPath, "../") {
+		return errors.Errorf("invalid IR shard path %q: must be a relative path within the directory of the index", shardPath)
+	}
+	return nil
+}
+
+// shardIR returns the provided IR in the sharded layout: an index document and a shard for every package that defines
+// types, errors or services. The name of every shard is the provided prefix followed by the package and
+// ".conjure.json", and the shards are sorted by name.
+func shardIR(irBytes []byte, prefix string) ([]byte, []irShard, error) {
+	var def spec.ConjureDefinition
+	if err := json.Unmarshal(irBytes, &def); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse IR")
+	}
+	defsByPkg := make(map[string]*spec.ConjureDefinition)
+	pkgDef := func(pkg string) *spec.ConjureDefinition {
+		if defsByPkg[pkg] == nil {
+			defsByPkg[pkg] = &spec.ConjureDefinition{Version: def.Version}
+		}
+		return defsByPkg[pkg]
+	}
+	for _, typeDef := range def.Types {
+		name, _ := typeDefinitionRefs(typeDef)
+		pkgDef(name.Package).Types = append(pkgDef(name.Package).Types, typeDef)
+	}
+	for _, errorDef := range def.Errors {
+		pkgDef(errorDef.ErrorName.Package).Errors = append(pkgDef(errorDef.ErrorName.Package).Errors, errorDef)
+	}
+	for _, serviceDef := range def.Services {
+		pkgDef(serviceDef.ServiceName.Package).Services = append(pkgDef(serviceDef.ServiceName.Package).Services, serviceDef)
+	}
+	var pkgs []string
+	for pkg := range defsByPkg {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	index := irShardIndex{
+		Version:     def.Version,
+		Shards:      []string{},
+		ShardSHA256: make(map[string]string),
+		Extensions:  def.Extensions,
+	}
+	var shards []irShard
+	for _, pkg := range pkgs {
+		content, err := json.Marshal(defsByPkg[pkg])
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to marshal IR shard for package %s", pkg)
+		}
+		name := fmt.Sprintf("%s%s.conjure.json", prefix, pkg)
+		index.Shards = append(index.Shards, name)
+		index.ShardSHA256[name] = fmt.Sprintf("%x", sha256.Sum256(content))
+		shards = append(shards, irShard{
+			name:    name,
+			content: content,
+		})
+	}
+	indexBytes, err := json.Marshal(index)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to marshal index of sharded IR")
+	}
+	return indexBytes, shards, nil
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"path/filepath"
+)
+
+const (
+	// irSnapshotFileName is the name of the file that contains the IR from which the code of a project was generated.
+	irSnapshotFileName = "ir.snapshot.json"
+	// irSnapshotDir is the directory relative to the output directory of a project in which the IR snapshot is written.
+	irSnapshotDir = ".conjure"
+)
+
+// renderIRSnapshotFile returns a file written to ".conjure/ir.snapshot.json" in the provided output directory that
+// contains the provided IR exactly as it was used to generate code.
+func renderIRSnapshotFile(irBytes []byte, outputDir string) renderedFile {
+	return renderedFile{
+		absPath: filepath.Join(outputDir, irSnapshotDir, irSnapshotFileName),
+		content: irBytes,
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/pkg/errors"
+)
+
+// IRValidator validates the IR of a project before its code is generated or its IR is published.
+type IRValidator interface {
+	// Name returns the name of the validator, which identifies it in output.
+	Name() string
+	// ValidateIR validates the provided IR of a project. Returns the findings that reject the IR, which are empty if
+	// the IR is valid. Returns an error if the validation could not be performed.
+	ValidateIR(projectName string, param ConjureProjectParam, projectDir string, irBytes []byte) ([]IRValidationFinding, error)
+}
+
+// IRValidationFinding is a problem found in the IR of a project by an IRValidator.
+type IRValidationFinding struct {
+	// Rule identifies the rule that the IR violates. May be empty.
+	Rule string `json:"rule,omitempty"`
+	// Location identifies the definition that violates the rule (for example, the name of a type or endpoint). May be
+	// empty.
+	Location string `json:"location,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+func (f IRValidationFinding) String() string {
+	var prefix string
+	if f.Rule != "" {
+		prefix += "[" + f.Rule + "] "
+	}
+	if f.Location != "" {
+		prefix += f.Location + ": "
+	}
+	return prefix + f.Message
+}
+
+// IRValidationError is returned by Run and Publish if an IRValidator rejects the IR of a project. It is classified as
+// ErrIRRejected.
+type IRValidationError struct {
+	// Project is the name of the project.
+	Project string
+	// Findings are the findings of the validators that rejected the IR keyed by the name of the validator.
+	Findings map[string][]IRValidationFinding
+	// Validators are the names of the validators that rejected the IR in the order in which they were run.
+	Validators []string
+}
+
+func (e *IRValidationError) Error() string {
+	var lines []string
+	for _, name := range e.Validators {
+		lines = append(lines, name+":")
+		for _, finding := range e.Findings[name] {
+			lines = append(lines, indent(finding.String(), indentLen))
+		}
+	}
+	return fmt.Sprintf("IR of %s was rejected by IR validators:\n%s", e.Project, indent(strings.Join(lines, "\n"), indentLen))
+}
+
+func (e *IRValidationError) Is(target error) bool {
+	return target == ErrIRRejected
+}
+
+// IRValidatorsParam returns a parameter that validates the IR of every project using the provided validators before its
+// code is generated or its IR is published.
+func IRValidatorsParam(validators ...IRValidator) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.irValidators = append(a.irValidators, validators...)
+	})
+}
+
+// validateIR validates the provided IR of a project using the provided validators. Returns an *IRValidationError if any
+// validator rejects the IR. If a validator whose name is an optional asset fails, a warning is printed and the validator
+// is skipped.
+func validateIR(projectName string, param ConjureProjectParam, projectDir string, irBytes []byte, validators []IRValidator, params ConjureProjectParams, stdout io.Writer) error {
+	var validationErr *IRValidationError
+	for _, validator := range validators {
+		findings, err := validator.ValidateIR(projectName, param, projectDir, irBytes)
+		if err != nil {
+			if !params.OptionalAsset(validator.Name()) {
+				return errors.Wrapf(err, "IR validator %s failed for %s", validator.Name(), projectName)
+			}
+			_, _ = fmt.Fprintf(stdout, "Warning: skipping optional IR validator %s for %s: %v\n", validator.Name(), projectName, err)
+			continue
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		if validationErr == nil {
+			validationErr = &IRValidationError{
+				Project:  projectName,
+				Findings: make(map[string][]IRValidationFinding),
+			}
+		}
+		validationErr.Validators = append(validationErr.Validators, validator.Name())
+		validationErr.Findings[validator.Name()] = findings
+	}
+	if validationErr != nil {
+		return validationErr
+	}
+	return nil
+}
+
+type assetIRValidator struct {
+	assetPath string
+	name      string
+	config    []byte
+}
+
+// NewConfiguredAssetIRValidator returns an IRValidator that validates IR using the IR validator asset at the provided
+// path with the provided name. The asset is invoked in the project directory as "<asset> validateIR <json>", where <json>
+// is a JSON object with the keys "project", "projectDir", "ir" (the path of a file that contains the IR) and "config"
+// (the provided configuration, which must be JSON; the key is omitted if it is empty). The asset must exit with status 0
+// if the IR is valid and with status 1 if it is rejected, in which case its standard output should be a JSON object
+// whose "findings" key is an array of objects with the keys "rule", "location" and "message". If the output is not such
+// an object, the output is the message of a single finding. Any other exit status is treated as a failure of the
+// validation.
+func NewConfiguredAssetIRValidator(assetPath, name string, config []byte) IRValidator {
+	return &assetIRValidator{
+		assetPath: assetPath,
+		name:      name,
+		config:    config,
+	}
+}
+
+func (v *assetIRValidator) Name() string {
+	return v.name
+}
+
+type validateIRArgs struct {
+	Project    string          `json:"project"`
+	ProjectDir string          `json:"projectDir"`
+	IR         string          `json:"ir"`
+	Config     json.RawMessage `json:"config,omitempty"`
+}
+
+type validateIROutput struct {
+	Findings []IRValidationFinding `json:"findings"`
+}
+
+func (v *assetIRValidator) ValidateIR(projectName string, param ConjureProjectParam, projectDir string, irBytes []byte) (rFindings []IRValidationFinding, rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("ir-validator-" + projectName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+
+	args := validateIRArgs{
+		Project:    projectName,
+		ProjectDir: projectDir,
+		IR:         filepath.Join(tmpDir, "ir.json"),
+		Config:     v.config,
+	}
+	if absProjectDir, err := filepath.Abs(projectDir); err == nil {
+		args.ProjectDir = absProjectDir
+	}
+	if err := os.WriteFile(args.IR, irBytes, 0644); err != nil {
+		return nil, errors.Wrapf(err, "failed to write IR")
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	cmd := exec.Command(v.assetPath, "validateIR", string(argsJSON))
+	cmd.Dir = projectDir
+	cmd.Env = append(os.Environ(), envSlice(param.Env)...)
+	stderr := &strings.Builder{}
+	cmd.Stderr = stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			var parsed validateIROutput
+			if err := json.Unmarshal(output, &parsed); err == nil && len(parsed.Findings) > 0 {
+				return parsed.Findings, nil
+			}
+			message := strings.TrimSpace(string(output) + "\n" + stderr.String())
+			if message == "" {
+				message = "IR is not valid"
+			}
+			return []IRValidationFinding{{Message: message}}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to execute %v\nOutput:\n%s%s", cmd.Args, string(output), stderr.String())
+	}
+	return nil, nil
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+// jsonSchemaDraft is the JSON Schema draft used for exported schemas. Draft 7 is used because it is supported by most
+// tools, including TypeScript type generators.
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// ExportJSONSchema writes a JSON Schema document that describes the object, alias, enum and union types of the project
+// with the provided name to the provided writer. Every type is defined in the "definitions" of the document under its
+// qualified name ("<package>.<Name>").
+func ExportJSONSchema(params ConjureProjectParams, projectName string, w io.Writer) error {
+	param, err := params.Param(projectName)
+	if err != nil {
+		return err
+	}
+	conjureDef, err := conjureDefinitionFromParam(param)
+	if err != nil {
+		return err
+	}
+	schemaBytes, err := json.MarshalIndent(jsonSchema(conjureDef), "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal JSON Schema")
+	}
+	if _, err := fmt.Fprintln(w, string(schemaBytes)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// jsonSchema returns the JSON Schema document for the types of the provided definition. Maps are used for all objects
+// so that the keys of the marshaled document are sorted.
+func jsonSchema(def spec.ConjureDefinition) map[string]interface{} {
+	definitions := make(map[string]interface{})
+	for _, typeDef := range def.Types {
+		_ = typeDef.AcceptFuncs(
+			func(alias spec.AliasDefinition) error {
+				definitions[qualifiedName(alias.TypeName)] = withDocs(typeSchema(alias.Alias), alias.Docs)
+				return nil
+			},
+			func(enum spec.EnumDefinition) error {
+				values := []string{}
+				for _, value := range enum.Values {
+					values = append(values, value.Value)
+				}
+				definitions[qualifiedName(enum.TypeName)] = withDocs(map[string]interface{}{
+					"type": "string",
+					"enum": values,
+				}, enum.Docs)
+				return nil
+			},
+			func(object spec.ObjectDefinition) error {
+				definitions[qualifiedName(object.TypeName)] = withDocs(objectSchema(object.Fields), object.Docs)
+				return nil
+			},
+			func(union spec.UnionDefinition) error {
+				var variants []interface{}
+				for _, field := range union.Union {
+					variants = append(variants, map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"type":                  map[string]interface{}{"const": string(field.FieldName)},
+							string(field.FieldName): withDocs(typeSchema(field.Type), field.Docs),
+						},
+						"required": []string{"type", string(field.FieldName)},
+					})
+				}
+				definitions[qualifiedName(union.TypeName)] = withDocs(map[string]interface{}{
+					"oneOf": variants,
+				}, union.Docs)
+				return nil
+			},
+			typeDef.ErrorOnUnknown,
+		)
+	}
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDraft,
+		"definitions": definitions,
+	}
+}
+
+func objectSchema(fields []spec.FieldDefinition) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, field := range fields {
+		properties[string(field.FieldName)] = withDocs(typeSchema(field.Type), field.Docs)
+		if isRequiredType(field.Type) {
+			required = append(required, string(field.FieldName))
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// typeSchema returns the JSON Schema for the provided type. Optional types are described by the schema of their item
+// type: objects describe optional fields by omitting them from the required properties.
+func typeSchema(t spec.Type) map[string]interface{} {
+	var out map[string]interface{}
+	_ = t.AcceptFuncs(
+		func(primitive spec.PrimitiveType) error {
+			out = primitiveSchema(primitive)
+			return nil
+		},
+		func(optional spec.OptionalType) error {
+			out = typeSchema(optional.ItemType)
+			return nil
+		},
+		func(list spec.ListType) error {
+			out = map[string]interface{}{
+				"type":  "array",
+				"items": typeSchema(list.ItemType),
+			}
+			return nil
+		},
+		func(set spec.SetType) error {
+			out = map[string]interface{}{
+				"type":        "array",
+				"items":       typeSchema(set.ItemType),
+				"uniqueItems": true,
+			}
+			return nil
+		},
+		func(mapType spec.MapType) error {
+			out = map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": typeSchema(mapType.ValueType),
+			}
+			return nil
+		},
+		func(reference spec.TypeName) error {
+			out = map[string]interface{}{
+				"$ref": "#/definitions/" + qualifiedName(reference),
+			}
+			return nil
+		},
+		func(external spec.ExternalReference) error {
+			out = typeSchema(external.Fallback)
+			return nil
+		},
+		func(string) error {
+			out = map[string]interface{}{}
+			return nil
+		},
+	)
+	return out
+}
+
+func primitiveSchema(primitive spec.PrimitiveType) map[string]interface{} {
+	switch primitive.Value() {
+	case spec.PrimitiveType_STRING, spec.PrimitiveType_RID, spec.PrimitiveType_BEARERTOKEN:
+		return map[string]interface{}{"type": "string"}
+	case spec.PrimitiveType_DATETIME:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case spec.PrimitiveType_UUID:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case spec.PrimitiveType_BINARY:
+		return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+	case spec.PrimitiveType_INTEGER:
+		return map[string]interface{}{"type": "integer", "minimum": -1 << 31, "maximum": 1<<31 - 1}
+	case spec.PrimitiveType_SAFELONG:
+		return map[string]interface{}{"type": "integer", "minimum": -(1<<53 - 1), "maximum": 1<<53 - 1}
+	case spec.PrimitiveType_DOUBLE:
+		// Conjure represents non-finite values as strings
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "number"},
+				map[string]interface{}{"enum": []string{"NaN", "Infinity", "-Infinity"}},
+			},
+		}
+	case spec.PrimitiveType_BOOLEAN:
+		return map[string]interface{}{"type": "boolean"}
+	default:
+		// ANY and unknown primitives accept any value
+		return map[string]interface{}{}
+	}
+}
+
+// isRequiredType returns true if values of the provided type must be present when serialized. Optional values and
+// empty collections may be omitted in the Conjure wire format.
+func isRequiredType(t spec.Type) bool {
+	required := true
+	notRequired := func() {
+		required = false
+	}
+	_ = t.AcceptFuncs(
+		t.PrimitiveNoopSuccess,
+		func(spec.OptionalType) error {
+			notRequired()
+			return nil
+		},
+		func(spec.ListType) error {
+			notRequired()
+			return nil
+		},
+		func(spec.SetType) error {
+			notRequired()
+			return nil
+		},
+		func(spec.MapType) error {
+			notRequired()
+			return nil
+		},
+		t.ReferenceNoopSuccess,
+		t.ExternalNoopSuccess,
+		t.ErrorOnUnknown,
+	)
+	return required
+}
+
+// withDocs returns the provided schema with its description set to the provided documentation if it is non-empty.
+// Schemas that are references are wrapped so that the description is not ignored.
+func withDocs(schema map[string]interface{}, docs *spec.Documentation) map[string]interface{} {
+	if docs == nil || *docs == "" {
+		return schema
+	}
+	if _, ok := schema["$ref"]; ok {
+		schema = map[string]interface{}{
+			"allOf": []interface{}{schema},
+		}
+	}
+	schema["description"] = string(*docs)
+	return schema
+}
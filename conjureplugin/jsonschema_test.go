@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testJSONSchemaIRJSON = `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "Book",
+        "package" : "com.example.api"
+      },
+      "fields" : [ {
+        "fieldName" : "title",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "STRING"
+        },
+        "docs" : "The title of the book."
+      }, {
+        "fieldName" : "tags",
+        "type" : {
+          "type" : "set",
+          "set" : {
+            "itemType" : {
+              "type" : "primitive",
+              "primitive" : "STRING"
+            }
+          }
+        }
+      }, {
+        "fieldName" : "genre",
+        "type" : {
+          "type" : "optional",
+          "optional" : {
+            "itemType" : {
+              "type" : "reference",
+              "reference" : {
+                "name" : "Genre",
+                "package" : "com.example.api"
+              }
+            }
+          }
+        }
+      } ]
+    }
+  }, {
+    "type" : "enum",
+    "enum" : {
+      "typeName" : {
+        "name" : "Genre",
+        "package" : "com.example.api"
+      },
+      "values" : [ {
+        "value" : "FICTION"
+      }, {
+        "value" : "HISTORY"
+      } ]
+    }
+  }, {
+    "type" : "union",
+    "union" : {
+      "typeName" : {
+        "name" : "Identifier",
+        "package" : "com.example.api"
+      },
+      "union" : [ {
+        "fieldName" : "isbn",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "STRING"
+        }
+      } ]
+    }
+  } ],
+  "services" : [ ]
+}`
+
+func TestExportJSONSchema(t *testing.T) {
+	irFile := filepath.Join(t.TempDir(), "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testJSONSchemaIRJSON), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.ExportJSONSchema(params, "project-1", buf))
+	assert.Equal(t, `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "definitions": {
+    "com.example.api.Book": {
+      "properties": {
+        "genre": {
+          "$ref": "#/definitions/com.example.api.Genre"
+        },
+        "tags": {
+          "items": {
+            "type": "string"
+          },
+          "type": "array",
+          "uniqueItems": true
+        },
+        "title": {
+          "description": "The title of the book.",
+          "type": "string"
+        }
+      },
+      "required": [
+        "title"
+      ],
+      "type": "object"
+    },
+    "com.example.api.Genre": {
+      "enum": [
+        "FICTION",
+        "HISTORY"
+      ],
+      "type": "string"
+    },
+    "com.example.api.Identifier": {
+      "oneOf": [
+        {
+          "properties": {
+            "isbn": {
+              "type": "string"
+            },
+            "type": {
+              "const": "isbn"
+            }
+          },
+          "required": [
+            "type",
+            "isbn"
+          ],
+          "type": "object"
+        }
+      ]
+    }
+  }
+}
+`, buf.String())
+
+	err := conjureplugin.ExportJSONSchema(params, "project-2", &bytes.Buffer{})
+	assert.EqualError(t, err, `project "project-2" is not defined in the configuration: valid projects are [project-1]`)
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/distgo/distgo"
+	"github.com/palantir/distgo/publisher"
+	"github.com/palantir/distgo/publisher/maven"
+	"github.com/pkg/errors"
+)
+
+// mavenLocalMetadataFile is the name of the file in the directory of an artifact in a local Maven repository that
+// records the versions of the artifact that have been installed.
+const mavenLocalMetadataFile = "maven-metadata-local.xml"
+
+// MavenLocal specifies that IR is installed into a local Maven repository (such as ~/.m2/repository) rather than
+// published to a remote repository, which allows consumers to build against IR that has not been published. The IR
+// artifacts and the POM of every project are written using the layout of a Maven repository, and the
+// maven-metadata-local.xml file of the project is updated to record the installed version.
+type MavenLocal struct {
+	// Repository is the path of the local repository. Relative paths are resolved against the project directory, and a
+	// leading "~" is resolved to the home directory of the user. If empty, the "repository" directory in the ".m2"
+	// directory of the home directory of the user is used.
+	Repository string
+}
+
+// repositoryDir returns the path of the local repository, resolving relative paths against the provided project
+// directory.
+func (m MavenLocal) repositoryDir(projectDir string) (string, error) {
+	repository := m.Repository
+	if repository == "" || repository == "~" || strings.HasPrefix(repository, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to determine home directory")
+		}
+		if repository == "" {
+			return filepath.Join(homeDir, ".m2", "repository"), nil
+		}
+		return filepath.Join(homeDir, strings.TrimPrefix(repository, "~")), nil
+	}
+	if !filepath.IsAbs(repository) {
+		repository = filepath.Join(projectDir, repository)
+	}
+	return repository, nil
+}
+
+// installToMavenLocal installs the IR artifacts with the provided names in the provided directory and the POM of the
+// provided product (unless the publisher flags specify that no POM should be installed) into the provided local
+// repository and records the version in the metadata of the product.
+func installToMavenLocal(params MavenLocal, projectDir string, outputInfo distgo.ProductTaskOutputInfo, artifactDir string, artifactNames []string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) error {
+	repositoryDir, err := params.repositoryDir(projectDir)
+	if err != nil {
+		return err
+	}
+	groupID, err := publisher.GetRequiredGroupID(flagVals, outputInfo)
+	if err != nil {
+		return err
+	}
+	versionDir := filepath.Join(repositoryDir, filepath.FromSlash(publisher.MavenProductPath(outputInfo, groupID)))
+
+	files := make(map[string][]byte)
+	fileNames := append([]string(nil), artifactNames...)
+	for _, artifactName := range artifactNames {
+		content, err := os.ReadFile(filepath.Join(artifactDir, artifactName))
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", artifactName)
+		}
+		files[artifactName] = content
+	}
+	var noPOM bool
+	if err := publisher.SetConfigValue(flagVals, maven.NoPOMFlag, &noPOM); err != nil {
+		return err
+	}
+	if !noPOM {
+		pomName, pomContent, err := maven.POM(groupID, outputInfo)
+		if err != nil {
+			return err
+		}
+		files[pomName] = []byte(pomContent)
+		fileNames = append(fileNames, pomName)
+	}
+
+	for _, fileName := range fileNames {
+		if err := installFile(filepath.Join(versionDir, fileName), files[fileName], dryRun, stdout); err != nil {
+			return err
+		}
+	}
+	return updateMavenLocalMetadata(filepath.Dir(versionDir), groupID, outputInfo.Product.Name, outputInfo.Project.Version, dryRun, stdout)
+}
+
+// installFile writes the provided content to the provided path in a local repository (creating its directory if
+// necessary). A dry run only prints the path.
+func installFile(path string, content []byte, dryRun bool, stdout io.Writer) error {
+	if dryRun {
+		_, _ = fmt.Fprintf(stdout, "[DRY RUN] Installing %s\n", path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", path)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return errors.Wrapf(err, "failed to install %s", path)
+	}
+	_, _ = fmt.Fprintf(stdout, "Installed %s\n", path)
+	return nil
+}
+
+// mavenLocalMetadata is the content of a maven-metadata-local.xml file.
+type mavenLocalMetadata struct {
+	XMLName    xml.Name `xml:"metadata"`
+	GroupID    string   `xml:"groupId"`
+	ArtifactID string   `xml:"artifactId"`
+	Versioning struct {
+		Release     string   `xml:"release,omitempty"`
+		Versions    []string `xml:"versions>version"`
+		LastUpdated string   `xml:"lastUpdated,omitempty"`
+	} `xml:"versioning"`
+}
+
+// updateMavenLocalMetadata records the provided version as the release of the artifact whose directory in a local
+// repository is the provided directory in the maven-metadata-local.xml file of the artifact, which Maven uses to
+// resolve version ranges and the latest release. The versions that were recorded previously are preserved. The update
+// time is determined like the timestamp of the build metadata extension, so it honors SOURCE_DATE_EPOCH.
+func updateMavenLocalMetadata(artifactDir, groupID, artifactID, version string, dryRun bool, stdout io.Writer) error {
+	metadataPath := filepath.Join(artifactDir, mavenLocalMetadataFile)
+	metadata := mavenLocalMetadata{
+		GroupID:    groupID,
+		ArtifactID: artifactID,
+	}
+	metadataBytes, err := os.ReadFile(metadataPath)
+	switch {
+	case err == nil:
+		if err := xml.Unmarshal(metadataBytes, &metadata); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", metadataPath)
+		}
+	case !os.IsNotExist(err):
+		return errors.Wrapf(err, "failed to read %s", metadataPath)
+	}
+	found := false
+	for _, existing := range metadata.Versioning.Versions {
+		if existing == version {
+			found = true
+			break
+		}
+	}
+	if !found {
+		metadata.Versioning.Versions = append(metadata.Versioning.Versions, version)
+	}
+	metadata.Versioning.Release = version
+	timestamp, err := buildTimestamp()
+	if err != nil {
+		return err
+	}
+	metadata.Versioning.LastUpdated = timestamp.UTC().Format("20060102150405")
+
+	out, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s", metadataPath)
+	}
+	return installFile(metadataPath, append([]byte(xml.Header), append(out, '\n')...), dryRun, stdout)
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+const (
+	// servicesFileName is the name of the file generated by conjure-go that defines the client interfaces of the
+	// services of a package.
+	servicesFileName = "services" + generatedFileSuffix
+	// serversFileName is the name of the file generated by conjure-go that defines the server interfaces of the
+	// services of a package.
+	serversFileName = "servers" + generatedFileSuffix
+	// mockFileSuffix is the suffix of the files that contain the mocks of the interfaces of a generated file.
+	mockFileSuffix = "_mock" + generatedFileSuffix
+)
+
+// renderMockFiles returns a "<name>_mock.conjure.go" file for every services and servers file in the provided files that
+// defines interfaces. For every interface, the mock file defines a "<Interface>Mock" struct that implements the
+// interface by calling a "<Method>Func" field for every method, which panics if the field is nil. The files are written
+// to the same directories as the files that define the interfaces.
+func renderMockFiles(files []renderedFile) ([]renderedFile, error) {
+	var mocks []renderedFile
+	for _, file := range files {
+		if base := filepath.Base(file.absPath); base != servicesFileName && base != serversFileName {
+			continue
+		}
+		content, err := renderMockFile(file.absPath, file.content)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		mocks = append(mocks, renderedFile{
+			absPath: strings.TrimSuffix(file.absPath, generatedFileSuffix) + mockFileSuffix,
+			content: content,
+		})
+	}
+	return mocks, nil
+}
+
+// renderMockFile returns the content of the file that contains the mocks of the interfaces defined by the provided
+// generated Go file. Returns nil if the file does not define any interfaces.
+func renderMockFile(path string, content []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse generated file %s", path)
+	}
+	exprString := func(expr ast.Expr) string {
+		return string(content[fset.Position(expr.Pos()).Offset:fset.Position(expr.End()).Offset])
+	}
+
+	body := &bytes.Buffer{}
+	for _, decl := range astFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok || !typeSpec.Name.IsExported() {
+				continue
+			}
+			if err := writeMock(body, typeSpec.Name.Name, interfaceType, exprString); err != nil {
+				return nil, errors.Wrapf(err, "failed to generate mock of %s in %s", typeSpec.Name.Name, path)
+			}
+		}
+	}
+	if body.Len() == 0 {
+		return nil, nil
+	}
+
+	// the mocks use the imports of the file that defines the interfaces (with the standard library imports grouped
+	// before the others), and the imports that are not used by the mocks are removed below
+	var stdImports, otherImports []string
+	for _, importSpec := range astFile.Imports {
+		importLine := importSpec.Path.Value
+		if importSpec.Name != nil {
+			importLine = importSpec.Name.Name + " " + importLine
+		}
+		if isStandardLibraryImport(strings.Trim(importSpec.Path.Value, `"`)) {
+			stdImports = append(stdImports, importLine)
+		} else {
+			otherImports = append(otherImports, importLine)
+		}
+	}
+	src := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(src, "// This file was generated by Conjure and should not be manually edited.\n\npackage %s\n\n", astFile.Name.Name)
+	if len(astFile.Imports) > 0 {
+		_, _ = fmt.Fprintf(src, "import (\n%s\n\n%s\n)\n\n", strings.Join(stdImports, "\n"), strings.Join(otherImports, "\n"))
+	}
+	src.Write(body.Bytes())
+
+	fset = token.NewFileSet()
+	mockFile, err := parser.ParseFile(fset, path, src.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse mocks of generated file %s", path)
+	}
+	for _, importSpec := range append([]*ast.ImportSpec{}, mockFile.Imports...) {
+		importPath := strings.Trim(importSpec.Path.Value, `"`)
+		if astutil.UsesImport(mockFile, importPath) {
+			continue
+		}
+		var name string
+		if importSpec.Name != nil {
+			name = importSpec.Name.Name
+		}
+		astutil.DeleteNamedImport(fset, mockFile, name, importPath)
+	}
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, mockFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to format mocks of generated file %s", path)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeMock writes the mock of the provided interface to the provided buffer. The source of the types in the signatures
+// of the methods is obtained using the provided function.
+func writeMock(buf *bytes.Buffer, name string, interfaceType *ast.InterfaceType, exprString func(ast.Expr) string) error {
+	mockName := name + "Mock"
+	type mockMethod struct {
+		name       string
+		params     []string
+		paramNames []string
+		results    string
+	}
+	var methods []mockMethod
+	usedNames := make(map[string]struct{})
+	for _, field := range interfaceType.Methods.List {
+		funcType, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) != 1 {
+			return errors.Errorf("embedded interfaces are not supported")
+		}
+		method := mockMethod{
+			name: field.Names[0].Name,
+		}
+		for _, param := range funcType.Params.List {
+			typeString := exprString(param.Type)
+			names := param.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent("_")}
+			}
+			for _, paramName := range names {
+				argName := paramName.Name
+				if argName == "_" {
+					argName = fmt.Sprintf("arg%d", len(method.paramNames))
+				}
+				usedNames[argName] = struct{}{}
+				method.params = append(method.params, argName+" "+typeString)
+				if _, ok := param.Type.(*ast.Ellipsis); ok {
+					argName += "..."
+				}
+				method.paramNames = append(method.paramNames, argName)
+			}
+		}
+		if funcType.Results != nil {
+			var results []string
+			for _, result := range funcType.Results.List {
+				typeString := exprString(result.Type)
+				results = append(results, typeString)
+				for i := 1; i < len(result.Names); i++ {
+					results = append(results, typeString)
+				}
+			}
+			method.results = strings.Join(results, ", ")
+			if len(results) > 1 {
+				method.results = "(" + method.results + ")"
+			}
+		}
+		methods = append(methods, method)
+	}
+
+	// the receiver must not shadow a parameter
+	recvName := "m"
+	for i := 0; ; i++ {
+		if _, ok := usedNames[recvName]; !ok {
+			break
+		}
+		recvName = fmt.Sprintf("m%d", i)
+	}
+
+	_, _ = fmt.Fprintf(buf, "// %s is a mock implementation of %s.\n//\n// Every method calls the \"<Method>Func\" field, which must be set if the method is called.\n", mockName, name)
+	_, _ = fmt.Fprintf(buf, "type %s struct {\n", mockName)
+	for _, method := range methods {
+		_, _ = fmt.Fprintf(buf, "\t%sFunc func(%s) %s\n", method.name, strings.Join(method.params, ", "), method.results)
+	}
+	_, _ = fmt.Fprintf(buf, "}\n\nvar _ %s = (*%s)(nil)\n\n", name, mockName)
+	for _, method := range methods {
+		_, _ = fmt.Fprintf(buf, "func (%s *%s) %s(%s) %s {\n", recvName, mockName, method.name, strings.Join(method.params, ", "), method.results)
+		_, _ = fmt.Fprintf(buf, "\tif %s.%sFunc == nil {\n\t\tpanic(%q)\n\t}\n", recvName, method.name, mockName+"."+method.name+"Func is nil")
+		call := fmt.Sprintf("%s.%sFunc(%s)", recvName, method.name, strings.Join(method.paramNames, ", "))
+		if method.results != "" {
+			call = "return " + call
+		}
+		_, _ = fmt.Fprintf(buf, "\t%s\n}\n\n", call)
+	}
+	return nil
+}
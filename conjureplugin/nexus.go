@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/palantir/distgo/distgo"
+	"github.com/palantir/distgo/publisher"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/nexus"
+)
+
+// dryRunStagingRepositoryID is the placeholder for the ID of the staging repository in the operations printed by a dry
+// run, which does not start a staging repository.
+const dryRunStagingRepositoryID = "<staging-repository-id>"
+
+// NexusStaging specifies that IR is published to a Nexus staging repository rather than to an Artifactory repository.
+// A single staging repository is started for all of the projects that are published, and it is closed (and optionally
+// released) after all of the artifacts have been uploaded. The staging repository is dropped if publishing fails.
+type NexusStaging struct {
+	// StagingProfileID is the ID of the staging profile for which the staging repository is started.
+	StagingProfileID string
+	// Description is the description of the staging repository. If empty, a description that specifies the version
+	// that is published is used.
+	Description string
+	// Release specifies whether the staging repository is released after it is closed. If false, the closed staging
+	// repository must be released (or dropped) separately.
+	Release bool
+}
+
+// nexusStagingRepository is a staging repository that was started by Publish.
+type nexusStagingRepository struct {
+	client       *nexus.Client
+	params       NexusStaging
+	description  string
+	repositoryID string
+	dryRun       bool
+	stdout       io.Writer
+}
+
+func startNexusStaging(params NexusStaging, connectionInfo publisher.BasicConnectionInfo, version string, dryRun bool, stdout io.Writer) (*nexusStagingRepository, error) {
+	description := params.Description
+	if description == "" {
+		description = fmt.Sprintf("Conjure IR %s", version)
+	}
+	repo := &nexusStagingRepository{
+		client: &nexus.Client{
+			URL:      connectionInfo.URL,
+			Username: connectionInfo.Username,
+			Password: connectionInfo.Password,
+		},
+		params:       params,
+		description:  description,
+		repositoryID: dryRunStagingRepositoryID,
+		dryRun:       dryRun,
+		stdout:       stdout,
+	}
+	distgo.PrintlnOrDryRunPrintln(stdout, fmt.Sprintf("Starting staging repository for staging profile %s", params.StagingProfileID), dryRun)
+	if dryRun {
+		return repo, nil
+	}
+	repositoryID, err := repo.client.Start(params.StagingProfileID, description)
+	if err != nil {
+		return nil, err
+	}
+	repo.repositoryID = repositoryID
+	return repo, nil
+}
+
+// deployURL returns the base URL to which artifacts are uploaded to add them to the staging repository.
+func (r *nexusStagingRepository) deployURL() string {
+	return r.client.DeployURL(r.repositoryID)
+}
+
+// finish closes the staging repository and releases it if the configuration specifies that it should be released.
+func (r *nexusStagingRepository) finish() error {
+	distgo.PrintlnOrDryRunPrintln(r.stdout, fmt.Sprintf("Closing staging repository %s", r.repositoryID), r.dryRun)
+	if !r.dryRun {
+		if err := r.client.Close(r.params.StagingProfileID, r.repositoryID, r.description); err != nil {
+			return err
+		}
+	}
+	if !r.params.Release {
+		return nil
+	}
+	distgo.PrintlnOrDryRunPrintln(r.stdout, fmt.Sprintf("Releasing staging repository %s", r.repositoryID), r.dryRun)
+	if r.dryRun {
+		return nil
+	}
+	return r.client.Release(r.repositoryID, r.description)
+}
+
+// drop drops the staging repository. Failures are printed rather than returned because dropping is only done to clean
+// up after another failure.
+func (r *nexusStagingRepository) drop() {
+	distgo.PrintlnOrDryRunPrintln(r.stdout, fmt.Sprintf("Dropping staging repository %s", r.repositoryID), r.dryRun)
+	if r.dryRun {
+		return
+	}
+	if err := r.client.Drop(r.repositoryID, r.description); err != nil {
+		_, _ = fmt.Fprintf(r.stdout, "Failed to drop staging repository %s: %v\n", r.repositoryID, err)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/palantir/conjure-go/v6/conjure"
+	"github.com/pkg/errors"
+)
+
+// renderedFile is a file generated by conjure-go along with its rendered content. Output files should only be rendered
+// once because rendering an output file mutates it.
+type renderedFile struct {
+	absPath string
+	content []byte
+}
+
+// renderMu guards rendering of output files, which formats the files using goimports. The goimports configuration is
+// global, so output files cannot be rendered concurrently.
+var renderMu sync.Mutex
+
+func renderOutputFiles(files []*conjure.OutputFile) ([]renderedFile, error) {
+	renderMu.Lock()
+	defer renderMu.Unlock()
+
+	var rendered []renderedFile
+	for _, file := range files {
+		output, err := file.Render()
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, renderedFile{
+			absPath: file.AbsPath(),
+			content: output,
+		})
+	}
+	return rendered, nil
+}
+
+func writeRenderedFiles(files []renderedFile) error {
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.absPath), 0755); err != nil {
+			return errors.Wrapf(err, "failed to create parent directory for Go file output %s", file.absPath)
+		}
+		if err := os.WriteFile(file.absPath, file.content, 0644); err != nil {
+			return errors.Wrapf(err, "failed to write Go file output to %s", file.absPath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// OutputRootParam returns a parameter that writes the output of every project under the provided directory instead of
+// the project directory. The output is rebased so that its path relative to the provided directory is the same as its
+// path relative to the project directory would have been, and the generated code is the same as it would have been if
+// it were written to the project directory (in particular, import paths are unchanged). Leftover files from renamed
+// projects are not removed, since they are in the project directory.
+func OutputRootParam(dir string) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.outputRoot = dir
+	})
+}
+
+// rebaseRenderedFiles returns the provided files with their paths rebased from projectDir to outputRoot. Returns an
+// error if any of the files is not in projectDir.
+func rebaseRenderedFiles(files []renderedFile, projectDir, outputRoot string) ([]renderedFile, error) {
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine absolute path of %s", projectDir)
+	}
+	absOutputRoot, err := filepath.Abs(outputRoot)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to determine absolute path of %s", outputRoot)
+	}
+	rebased := make([]renderedFile, 0, len(files))
+	for _, file := range files {
+		absPath, err := filepath.Abs(file.absPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to determine absolute path of %s", file.absPath)
+		}
+		relPath, err := filepath.Rel(absProjectDir, absPath)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return nil, errors.Errorf("cannot write %s under output root %s because it is not in the project directory %s", file.absPath, outputRoot, projectDir)
+		}
+		rebased = append(rebased, renderedFile{
+			absPath: filepath.Join(absOutputRoot, relPath),
+			content: file.content,
+		})
+	}
+	return rebased, nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ownersPublishProperty is the key of the property of published IR artifacts that lists the owners of the project.
+const ownersPublishProperty = "conjure.owners"
+
+// ownersError is an error that occurred while processing a project that has owners. Its message names the owners so
+// that the people who encounter the error know whom to contact.
+type ownersError struct {
+	cause  error
+	owners []string
+}
+
+// withOwners returns the provided error with a message that names the provided owners. Returns the error unmodified if
+// it is nil, if there are no owners or if it already names its owners.
+func withOwners(err error, owners []string) error {
+	if err == nil || len(owners) == 0 {
+		return err
+	}
+	if _, ok := err.(*ownersError); ok {
+		return err
+	}
+	return &ownersError{
+		cause:  err,
+		owners: owners,
+	}
+}
+
+func (e *ownersError) Error() string {
+	return fmt.Sprintf("%v (%s)", e.cause, ownersContact(e.owners))
+}
+
+func (e *ownersError) Cause() error {
+	return e.cause
+}
+
+func (e *ownersError) Unwrap() error {
+	return e.cause
+}
+
+// ownersContact returns a description of whom to contact about a project with the provided owners.
+func ownersContact(owners []string) string {
+	return "contact " + strings.Join(owners, ", ")
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/conjure-go/v6/conjure/transforms"
+	"github.com/palantir/conjure-go/v6/conjure/types"
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// packagePath returns the slash-separated path (relative to the output directory) of the directory in which the code
+// for the provided Conjure package is generated. If the package or one of its parent packages is a key of the provided
+// paths, the path of the most specific key is used and the remaining elements of the package are appended to it.
+// Otherwise, the path derived from the package by conjure-go is returned.
+func packagePath(paths map[string]string, conjurePkg string) string {
+	var match string
+	for prefix := range paths {
+		if (conjurePkg == prefix || strings.HasPrefix(conjurePkg, prefix+".")) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match == "" {
+		return transforms.PackagePath(conjurePkg)
+	}
+	return path.Join(append([]string{paths[match]}, strings.Split(strings.TrimPrefix(conjurePkg[len(match):], "."), ".")...)...)
+}
+
+// packageMove describes the move of the generated code of a Conjure package from the directory derived by conjure-go to
+// the directory specified by the package paths of a project.
+type packageMove struct {
+	oldDir        string
+	newDir        string
+	oldImportPath string
+	newImportPath string
+	oldName       string
+	newName       string
+}
+
+// remapPackagePaths returns the provided files with the generated code of every Conjure package of the provided
+// definition moved to the directory specified by the provided package paths. The package clauses of the moved files
+// are renamed to match their new directories and the imports of the moved packages in all of the generated Go files are
+// rewritten. Returns an error if the code of multiple packages would be generated in the same directory.
+func remapPackagePaths(conjureDef spec.ConjureDefinition, files []renderedFile, outputDir string, paths map[string]string) ([]renderedFile, error) {
+	if len(paths) == 0 {
+		return files, nil
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	def, err := types.NewConjureDefinition(absOutputDir, conjureDef)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration")
+	}
+	var pkgNames []string
+	for pkgName := range def.Packages {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Strings(pkgNames)
+
+	movesByDir := make(map[string]packageMove)
+	movesByImportPath := make(map[string]packageMove)
+	pkgsByDir := make(map[string]string)
+	for _, pkgName := range pkgNames {
+		pkg := def.Packages[pkgName]
+		relPath := packagePath(paths, pkgName)
+		dir := filepath.Join(absOutputDir, filepath.FromSlash(relPath))
+		if other, ok := pkgsByDir[dir]; ok {
+			return nil, errors.Errorf("code for packages %s and %s would both be generated in %s", other, pkgName, relPath)
+		}
+		pkgsByDir[dir] = pkgName
+		defaultPath := transforms.PackagePath(pkgName)
+		if relPath == defaultPath {
+			continue
+		}
+		newImportPath := strings.TrimSuffix(pkg.ImportPath, defaultPath) + relPath
+		move := packageMove{
+			oldDir:        filepath.Join(absOutputDir, filepath.FromSlash(defaultPath)),
+			newDir:        dir,
+			oldImportPath: pkg.ImportPath,
+			newImportPath: newImportPath,
+			oldName:       pkg.PackageName,
+			newName:       goPackageName(newImportPath),
+		}
+		movesByDir[move.oldDir] = move
+		movesByImportPath[move.oldImportPath] = move
+	}
+
+	out := make([]renderedFile, 0, len(files))
+	for _, file := range files {
+		move, moved := movesByDir[filepath.Dir(file.absPath)]
+		if strings.HasSuffix(file.absPath, ".go") && (moved || containsAnyImportPath(file.content, movesByImportPath)) {
+			var pkgName string
+			if moved {
+				pkgName = move.newName
+			}
+			content, err := rewritePackageImports(file.absPath, file.content, pkgName, movesByImportPath)
+			if err != nil {
+				return nil, err
+			}
+			file.content = content
+		}
+		if moved {
+			file.absPath = filepath.Join(move.newDir, filepath.Base(file.absPath))
+		}
+		out = append(out, file)
+	}
+	return out, nil
+}
+
+func containsAnyImportPath(content []byte, movesByImportPath map[string]packageMove) bool {
+	for importPath := range movesByImportPath {
+		if bytes.Contains(content, []byte(strconv.Quote(importPath))) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewritePackageImports returns the provided content of a generated Go file with the imports of the moved packages
+// rewritten to their new import paths. If pkgName is non-empty, the package clause of the file is renamed to it. If
+// the name of a moved package changes, references to the package are renamed unless the new name is already used in
+// the file, in which case the import is aliased to the old name instead.
+func rewritePackageImports(filePath string, content []byte, pkgName string, movesByImportPath map[string]packageMove) ([]byte, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse generated file %s", filePath)
+	}
+	if pkgName != "" {
+		astFile.Name.Name = pkgName
+	}
+	usedNames := make(map[string]struct{})
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			usedNames[ident.Name] = struct{}{}
+		}
+		return true
+	})
+	for _, importSpec := range astFile.Imports {
+		importPath, err := strconv.Unquote(importSpec.Path.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid import in generated file %s", filePath)
+		}
+		move, ok := movesByImportPath[importPath]
+		if !ok {
+			continue
+		}
+		astutil.RewriteImport(fset, astFile, move.oldImportPath, move.newImportPath)
+		if importSpec.Name != nil || move.newName == move.oldName {
+			continue
+		}
+		if _, ok := usedNames[move.newName]; ok {
+			importSpec.Name = ast.NewIdent(move.oldName)
+			continue
+		}
+		renamePackageReferences(astFile, move.oldName, move.newName)
+		usedNames[move.newName] = struct{}{}
+	}
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, astFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to format generated file %s", filePath)
+	}
+	return buf.Bytes(), nil
+}
+
+// renamePackageReferences renames the qualifiers of the references to the imported package with the provided name.
+// Identifiers that are resolved to declarations in the file are not package qualifiers and are not renamed.
+func renamePackageReferences(astFile *ast.File, oldName, newName string) {
+	ast.Inspect(astFile, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == oldName && ident.Obj == nil {
+			ident.Name = newName
+		}
+		return true
+	})
+}
+
+var nonAlphanumericRegexp = regexp.MustCompile(`[^a-z0-9]`)
+
+// goPackageName returns the name of the Go package with the provided import path, which is derived from the last
+// element of the path in the same manner as conjure-go.
+func goPackageName(importPath string) string {
+	name := nonAlphanumericRegexp.ReplaceAllString(strings.ToLower(path.Base(importPath)), "")
+	name = strings.TrimLeftFunc(name, unicode.IsDigit)
+	if name == "" {
+		return "pkg"
+	}
+	return name
+}
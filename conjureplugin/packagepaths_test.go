@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPackagePathsIRJSON = `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Request", "package" : "com.palantir.paths.api" },
+      "fields" : [ {
+        "fieldName" : "item",
+        "type" : { "type" : "reference", "reference" : { "name" : "Item", "package" : "com.palantir.paths.model" } }
+      } ]
+    }
+  }, {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Item", "package" : "com.palantir.paths.model" },
+      "fields" : [ { "fieldName" : "name", "type" : { "type" : "primitive", "primitive" : "STRING" } } ]
+    }
+  }, {
+    "type" : "object",
+    "object" : {
+      "typeName" : { "name" : "Detail", "package" : "com.palantir.paths.model.detail" },
+      "fields" : [ { "fieldName" : "name", "type" : { "type" : "primitive", "primitive" : "STRING" } } ]
+    }
+  } ],
+  "services" : [ ]
+}`
+
+func TestRunPackagePaths(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		packagePaths map[string]string
+		want         map[string][]string
+		wantAbsent   []string
+		wantErr      string
+	}{
+		{
+			packagePaths: map[string]string{
+				"com.palantir.paths.model": "internal/model/types",
+			},
+			want: map[string][]string{
+				"paths/api/structs.conjure.go": {
+					"package api",
+					`/conjure-output/internal/model/types"`,
+					"Item types.Item",
+				},
+				"internal/model/types/structs.conjure.go": {
+					"package types",
+					"type Item struct",
+				},
+				"internal/model/types/detail/structs.conjure.go": {
+					"package detail",
+					"type Detail struct",
+				},
+			},
+			wantAbsent: []string{
+				"paths/model/structs.conjure.go",
+				"paths/model/detail/structs.conjure.go",
+			},
+		},
+		{
+			packagePaths: map[string]string{
+				"com.palantir.paths":              "v2",
+				"com.palantir.paths.model.detail": "detail",
+			},
+			want: map[string][]string{
+				"v2/api/structs.conjure.go": {
+					"package api",
+					`/conjure-output/v2/model"`,
+					"Item model.Item",
+				},
+				"v2/model/structs.conjure.go": {
+					"package model",
+				},
+				"detail/structs.conjure.go": {
+					"package detail",
+				},
+			},
+			wantAbsent: []string{
+				"paths/api/structs.conjure.go",
+				"v2/model/detail/structs.conjure.go",
+			},
+		},
+		{
+			packagePaths: map[string]string{
+				"com.palantir.paths.model": "paths/api",
+			},
+			wantErr: "failed to apply package paths of project-1: code for packages com.palantir.paths.api and com.palantir.paths.model would both be generated in paths/api",
+		},
+	} {
+		projectDir, err := os.MkdirTemp(cwd, "TestRunPackagePaths_")
+		require.NoError(t, err, "Case %d", i)
+		irFile := filepath.Join(projectDir, "ir.json")
+		require.NoError(t, os.WriteFile(irFile, []byte(testPackagePathsIRJSON), 0644), "Case %d", i)
+
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:    "conjure-output",
+					IRProvider:   conjureplugin.NewLocalFileIRProvider(irFile),
+					PackagePaths: tc.packagePaths,
+				},
+			},
+		}
+		err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{})
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+		} else {
+			require.NoError(t, err, "Case %d", i)
+			require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}), "Case %d", i)
+			for file, wantContents := range tc.want {
+				got, err := os.ReadFile(filepath.Join(projectDir, "conjure-output", file))
+				require.NoError(t, err, "Case %d: %s", i, file)
+				for _, want := range wantContents {
+					assert.Contains(t, string(got), want, "Case %d: %s", i, file)
+				}
+			}
+			for _, file := range tc.wantAbsent {
+				assert.NoFileExists(t, filepath.Join(projectDir, "conjure-output", file), "Case %d", i)
+			}
+		}
+		require.NoError(t, os.RemoveAll(projectDir), "Case %d", i)
+	}
+}
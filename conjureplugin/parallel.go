@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+)
+
+// ParallelismParam returns a parameter that sets the maximum number of projects whose IR is computed and whose code is
+// generated concurrently. If the value is positive, it takes precedence over ConjureProjectParams.Parallelism.
+func ParallelismParam(parallelism int) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.parallelism = parallelism
+	})
+}
+
+// projectResult is the outcome of computing the generated files for a project.
+type projectResult struct {
+	// start is the time at which the computation started.
+	start time.Time
+	// output is the output written while computing the result when projects are computed concurrently.
+	output bytes.Buffer
+	files  []renderedFile
+	// frozenViolations describes the changes to the generated code of a frozen project that are not allowed.
+	frozenViolations string
+	// generationKey identifies the inputs used to generate the files if generation is incremental.
+	generationKey string
+	// unchanged specifies that the files were not generated because they are unchanged since they were last generated
+	// using the same inputs. The files only specify their paths.
+	unchanged bool
+	err       error
+}
+
+// forEachProject computes the result for each of the n projects using compute and provides the results to apply in
+// order. If parallelism is less than 2, each project is computed immediately before it is applied and compute writes
+// its output to stdout. Otherwise, up to parallelism projects are computed concurrently with each other and with apply,
+// the output of every project is buffered and written to stdout in order before its result is applied. No further
+// projects are started once apply returns an error, and the error is returned once all started projects finish.
+func forEachProject(n, parallelism int, stdout io.Writer, compute func(i int, result *projectResult, out io.Writer), apply func(i int, result *projectResult) error) error {
+	if parallelism < 2 {
+		for i := 0; i < n; i++ {
+			result := &projectResult{start: time.Now()}
+			compute(i, result, stdout)
+			if err := apply(i, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	results := make([]*projectResult, n)
+	done := make([]chan struct{}, n)
+	for i := range done {
+		results[i] = &projectResult{}
+		done[i] = make(chan struct{})
+	}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(stop)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sem := make(chan struct{}, parallelism)
+		for i := 0; i < n; i++ {
+			select {
+			case sem <- struct{}{}:
+			case <-stop:
+				return
+			}
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() {
+					<-sem
+				}()
+				defer close(done[i])
+				results[i].start = time.Now()
+				compute(i, results[i], &results[i].output)
+			}(i)
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		<-done[i]
+		_, _ = stdout.Write(results[i].output.Bytes())
+		if err := apply(i, results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -14,9 +14,48 @@
 
 package conjureplugin
 
+import (
+	"github.com/pkg/errors"
+)
+
 type ConjureProjectParams struct {
 	SortedKeys []string
 	Params     map[string]ConjureProjectParam
+	// AssetConfig is the configuration for assets keyed by the name of the asset. The values are JSON.
+	AssetConfig map[string][]byte
+	// AssetOptions are the options that the plugin applies to assets keyed by the name of the asset.
+	AssetOptions map[string]AssetOptions
+	// Parallelism is the maximum number of projects that are generated concurrently. Projects are generated
+	// sequentially if the value is less than 2.
+	Parallelism int
+	// Incremental specifies whether generation is skipped for projects whose IR and generation options have not
+	// changed since their code was last generated or verified.
+	Incremental bool
+	// NexusStaging specifies that IR is published to a Nexus staging repository. If nil, IR is published to
+	// Artifactory.
+	NexusStaging *NexusStaging
+	// PublisherAsset specifies that IR is published by a publisher asset. If nil, IR is published to Artifactory or
+	// to NexusStaging.
+	PublisherAsset *PublisherAsset
+	// MavenLocal specifies that IR is installed into a local Maven repository. If nil, IR is published to
+	// Artifactory, to NexusStaging or by PublisherAsset.
+	MavenLocal *MavenLocal
+	// PostRunHooks are run after all of the projects have been generated or verified.
+	PostRunHooks []PostRunHook
+}
+
+// AssetOptions are the options that the plugin applies to an asset. Unlike the configuration of an asset, they are not
+// provided to the asset.
+type AssetOptions struct {
+	// Optional specifies that if the asset fails, a warning is printed and the asset is skipped rather than failing the
+	// operation. Results reported by an asset that runs successfully (such as backwards incompatibilities) are not
+	// affected.
+	Optional bool
+}
+
+// OptionalAsset returns true if the asset with the provided name is optional.
+func (p *ConjureProjectParams) OptionalAsset(name string) bool {
+	return p.AssetOptions[name].Optional
 }
 
 func (p *ConjureProjectParams) OrderedParams() []ConjureProjectParam {
@@ -27,6 +66,30 @@ func (p *ConjureProjectParams) OrderedParams() []ConjureProjectParam {
 	return out
 }
 
+// Param returns the parameter for the project with the provided name. Returns an error if no such project exists.
+func (p *ConjureProjectParams) Param(projectName string) (ConjureProjectParam, error) {
+	param, ok := p.Params[projectName]
+	if !ok {
+		return ConjureProjectParam{}, errors.Errorf("project %q is not defined in the configuration: valid projects are %v", projectName, p.SortedKeys)
+	}
+	return param, nil
+}
+
+// Select returns the parameters that only contain the projects with the provided names. Unlike Subset, returns an
+// error if any of the provided names is not the name of a project.
+func (p *ConjureProjectParams) Select(names []string) (ConjureProjectParams, error) {
+	var unknown []string
+	for _, name := range names {
+		if _, ok := p.Params[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return ConjureProjectParams{}, errors.Errorf("projects %v are not defined in the configuration: valid projects are %v", unknown, p.SortedKeys)
+	}
+	return p.Subset(names), nil
+}
+
 type ConjureProjectParam struct {
 	OutputDir    string
 	IRProvider   IRProvider
@@ -39,4 +102,117 @@ type ConjureProjectParam struct {
 	AcceptFuncs bool
 	// Publish specifies whether or not this Conjure project should be included in the "publish" operation.
 	Publish bool
+	// SizeBudget optionally specifies limits on the size of the generated code. If nil, no limits are enforced.
+	SizeBudget *SizeBudget
+	// RenamedFrom specifies the previous names of this project.
+	RenamedFrom []RenamedFrom
+	// PublishProperties are the Artifactory properties that are set on the artifacts published for this project. The
+	// values are rendered as Go templates that can use the "env", "Project" and "Version" functions.
+	PublishProperties map[string]string
+	// PublishSharded specifies that the IR of this project is published as sharded IR: an index artifact that lists
+	// the shard artifacts, each of which contains the definitions of a single Conjure package.
+	PublishSharded bool
+	// Env specifies the environment variables that are set for the processes run for this project (such as the Conjure
+	// compiler) in addition to the environment of the plugin process.
+	Env map[string]string
+	// CompilerArgs specifies the additional arguments that are provided to the Conjure compiler when generating the IR
+	// of this project from YAML.
+	CompilerArgs []string
+	// Compiler is the compiler that is used to generate the IR of this project from YAML ("cli" or "native"). If empty,
+	// the Conjure CLI is used.
+	Compiler string
+	// JVMOptions specifies the options for the JVM that runs the Conjure compiler for this project.
+	JVMOptions []string
+	// Frozen specifies whether the definitions of the project are frozen. If true, any change to the generated code
+	// other than to its documentation is an error, and backcompat checks fail for any change to the IR other than to
+	// its documentation.
+	Frozen bool
+	// BackCompatBaseline is the pinned baseline against which backcompat checks check the backwards compatibility of
+	// the project. If nil, the baseline specified for all projects is used.
+	BackCompatBaseline *BackCompatBaseline
+	// BackCompatPolicy determines which backwards incompatibilities of this project fail backcompat checks.
+	BackCompatPolicy BackCompatPolicy
+	// Extensions specifies the extensions that are added to the IR of the project when it is published.
+	Extensions ProjectExtensions
+	// ForbiddenPatterns specifies definitions that may not appear in the IR of the project.
+	ForbiddenPatterns []ForbiddenPattern
+	// EndpointConstants specifies whether an "endpoints.conjure.go" file that defines constants for the names, HTTP
+	// methods and HTTP path templates of services and endpoints should be generated for every package with services.
+	EndpointConstants bool
+	// RoutesFile is the path (relative to the project directory) of a file that lists the HTTP routes of all of the
+	// endpoints of the project. The file is rendered as YAML if the path has a ".yml" or ".yaml" extension and as JSON
+	// otherwise. If empty, no routes file is generated.
+	RoutesFile string
+	// ClientOptions specifies whether a "client_options.conjure.go" file that defines functional options (default
+	// headers, a timeout and an RPC method name override) and a "New<Service>ClientWithOptions" constructor for every
+	// service should be generated for every package with services.
+	ClientOptions bool
+	// ClientConstructors specifies the names of the generated constructors of service clients keyed by the simple or
+	// qualified name of the service. The names of the "WithAuth" and "WithTokenProvider" variants of the constructors
+	// are derived from the specified name.
+	ClientConstructors map[string]ClientConstructor
+	// Readme specifies whether a "README.conjure.md" file that describes the generated packages, the source of the IR,
+	// the generation flags and how to regenerate the code should be generated in the output directory.
+	Readme bool
+	// IRSnapshot specifies whether the IR from which the code is generated should be written to
+	// ".conjure/ir.snapshot.json" in the output directory so that the generated code can be reproduced offline and the
+	// IR can be compared with newer IR.
+	IRSnapshot bool
+	// Owners specifies the owners of the project (such as team names or email addresses). The owners are named in
+	// errors that occur while processing the project, in summaries, in readme files and in the properties of
+	// published artifacts so that consumers know whom to contact.
+	Owners []string
+	// ExternalGenerator specifies an executable that generates the code of the project instead of the vendored
+	// conjure-go generator. If nil, the vendored generator is used.
+	ExternalGenerator *ExternalGenerator
+	// GeneratorAsset specifies a generator asset that generates the output of the project instead of the vendored
+	// conjure-go generator. If nil, the project is generated by ExternalGenerator or by the vendored generator.
+	GeneratorAsset *GeneratorAsset
+	// FileModePolicy specifies how symlinks, executable files and generated files with unexpected permissions in the
+	// output directory are handled. If empty, they are ignored.
+	FileModePolicy FileModePolicy
+	// PostGenerate are run in order after the generated files of this project are written.
+	PostGenerate []PostGenerateHook
+	// Format specifies the formatter that is run on the generated Go files of this project. If empty, the files are
+	// not formatted beyond the formatting of the generator.
+	Format Formatter
+	// BuildTags are build tag expressions (such as "conjure_generated" or "!codeanalysis") that are combined into a
+	// "//go:build" constraint that is added to every generated Go file of this project.
+	BuildTags []string
+	// Header replaces the header comment of the generated Go files of this project. If empty, the header comment of
+	// conjure-go is used.
+	Header Header
+	// YAMLMethods specifies the YAML library used by the MarshalYAML and UnmarshalYAML methods of the generated types of
+	// this project. If empty, the methods generated by conjure-go (which use safeyaml) are used.
+	YAMLMethods YAMLMethods
+	// Filter selects the definitions in the IR for which code is generated. If nil, code is generated for all of the
+	// definitions.
+	Filter *DefinitionFilter
+	// PackagePaths specifies the paths (relative to the output directory) of the directories in which the code for
+	// Conjure packages is generated keyed by Conjure package. A key also applies to the subpackages of the package. If
+	// a package is not matched by any key, the directory derived from the package by conjure-go is used.
+	PackagePaths map[string]string
+	// StandaloneModule specifies that a "go.mod" file that declares the output directory as a standalone Go module
+	// should be generated in the output directory. If nil, the generated code is part of the module that contains the
+	// output directory.
+	StandaloneModule *StandaloneModule
+	// Mocks specifies whether a "<name>_mock.conjure.go" file that defines a mock implementation of every service
+	// interface should be generated for every generated file that defines client or server interfaces.
+	Mocks bool
+	// Examples specifies whether a "services_example.conjure_test.go" file that defines a Go documentation example for
+	// every service client should be generated for every generated file that defines service clients.
+	Examples bool
+	// TargetPlatforms specifies the platforms on which the generated code must be available. If nil, the generated code
+	// is not checked.
+	TargetPlatforms *TargetPlatforms
+}
+
+// ClientConstructor specifies the name of the generated constructor of a service client. Exactly one of Name and Prefix
+// should be non-empty.
+type ClientConstructor struct {
+	// Name is the name of the constructor (for example, "NewLegacyFooServiceClient").
+	Name string
+	// Prefix is inserted after "New" in the default name of the constructor (for example, "Conjure" renames
+	// "NewFooServiceClient" to "NewConjureFooServiceClient").
+	Prefix string
 }
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"a", "b", "c"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"a": {OutputDir: "a"},
+			"b": {OutputDir: "b"},
+			"c": {OutputDir: "c"},
+		},
+		Parallelism: 2,
+	}
+
+	got, err := params.Select([]string{"c", "a"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, got.SortedKeys)
+	assert.Equal(t, "c", got.Params["c"].OutputDir)
+	assert.Equal(t, 2, got.Parallelism)
+
+	_, err = params.Select([]string{"a", "unknown", "other"})
+	assert.EqualError(t, err, "projects [unknown other] are not defined in the configuration: valid projects are [a b c]")
+}
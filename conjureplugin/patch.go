@@ -0,0 +1,29 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"io"
+)
+
+// VerifyPatchParam returns a parameter that configures verification to write a patch that updates the files on disk to
+// match the generated files of every project whose generated code differs to the provided writer. The patch is a
+// unified diff in the format of "git diff" with paths relative to the directory that is verified (the project directory
+// or the output root), so it can be applied in that directory using "git apply" or "patch -p1".
+func VerifyPatchParam(w io.Writer) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.verifyPatch = w
+	})
+}
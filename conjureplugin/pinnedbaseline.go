@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BackCompatBaseline is a pinned baseline against which BackCompat checks the backwards compatibility of a project
+// rather than against the baseline computed for all projects. Exactly one of the fields is non-empty.
+type BackCompatBaseline struct {
+	// Path is the path of a file that contains the baseline IR. Relative paths are resolved against the project
+	// directory.
+	Path string
+	// URL is the URL of the baseline IR.
+	URL string
+	// Maven is the Maven coordinate ("<group>:<artifact>:<version>") of the baseline IR, which is resolved from the
+	// repository provided using BaselineRepositoryParam.
+	Maven string
+}
+
+func (b BackCompatBaseline) String() string {
+	switch {
+	case b.Path != "":
+		return b.Path
+	case b.URL != "":
+		return b.URL
+	default:
+		return b.Maven
+	}
+}
+
+// ParseBackCompatBaseline returns the baseline specified by the provided value, which is either a Maven coordinate of
+// the form "<group>:<artifact>:<version>", an HTTP or HTTPS URL or the path of a file.
+func ParseBackCompatBaseline(val string) (BackCompatBaseline, error) {
+	switch {
+	case val == "":
+		return BackCompatBaseline{}, errors.Errorf("baseline must not be empty")
+	case strings.HasPrefix(val, "http://") || strings.HasPrefix(val, "https://"):
+		return BackCompatBaseline{URL: val}, nil
+	case strings.Contains(val, ":") && !strings.ContainsAny(val, `/\`):
+		parts := strings.Split(val, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return BackCompatBaseline{}, errors.Errorf("Maven coordinate %q must have the form <group>:<artifact>:<version>", val)
+		}
+		return BackCompatBaseline{Maven: val}, nil
+	default:
+		return BackCompatBaseline{Path: val}, nil
+	}
+}
+
+// BaselineRepositoryParam returns a parameter that specifies the repository from which BackCompat resolves pinned
+// baselines specified as Maven coordinates. Only the URL, repository and credentials of the provided repository are
+// used. PublishedBaselineParam also specifies the repository.
+func BaselineRepositoryParam(repo PublishedBaseline) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.baselineRepository = &repo
+	})
+}
+
+// pinnedBaselineSource provides the pinned baseline of a single project.
+type pinnedBaselineSource struct {
+	baseline BackCompatBaseline
+	repo     *PublishedBaseline
+}
+
+func (s *pinnedBaselineSource) String() string {
+	return "pinned baseline " + s.baseline.String()
+}
+
+func (s *pinnedBaselineSource) missingReason() (string, string) {
+	// pinned baselines always exist: failures to resolve them are errors
+	return "", ""
+}
+
+func (s *pinnedBaselineSource) baselineIR(projectName string, param ConjureProjectParam, projectDir string) ([]byte, string, error) {
+	irBytes, err := s.pinnedIR(projectDir)
+	if err != nil {
+		return nil, "", err
+	}
+	return irBytes, s.baseline.String(), nil
+}
+
+func (s *pinnedBaselineSource) pinnedIR(projectDir string) ([]byte, error) {
+	switch {
+	case s.baseline.Path != "":
+		path := s.baseline.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+		return NewLocalFileIRProvider(path).IRBytes()
+	case s.baseline.URL != "":
+		return NewHTTPIRProvider(s.baseline.URL).IRBytes()
+	default:
+		if s.repo == nil || s.repo.URL == "" || s.repo.Repository == "" {
+			return nil, errors.Errorf("a repository must be specified to resolve Maven coordinate %s", s.baseline.Maven)
+		}
+		parts := strings.Split(s.baseline.Maven, ":")
+		if len(parts) != 3 {
+			return nil, errors.Errorf("Maven coordinate %q must have the form <group>:<artifact>:<version>", s.baseline.Maven)
+		}
+		return publishedIR(*s.repo, parts[0], parts[1], parts[2])
+	}
+}
+
+// projectBaselineSource returns the source of the baseline of the provided project, which is its pinned baseline if
+// it has one and the provided baseline for all projects otherwise. Returns an error if the project does not have a
+// pinned baseline and the provided baseline is nil.
+func projectBaselineSource(projectName string, param ConjureProjectParam, baseline baselineSource, repo *PublishedBaseline) (baselineSource, error) {
+	if param.BackCompatBaseline != nil {
+		return &pinnedBaselineSource{
+			baseline: *param.BackCompatBaseline,
+			repo:     repo,
+		}, nil
+	}
+	if baseline == nil {
+		return nil, errors.Errorf("%s does not have a pinned baseline, so a baseline Git ref must be specified", projectName)
+	}
+	return baseline, nil
+}
+
+// backCompatBaselineDescription returns the description of the provided pinned baseline used when comparing
+// parameters.
+func backCompatBaselineDescription(baseline *BackCompatBaseline) string {
+	if baseline == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%q", baseline.String())
+}
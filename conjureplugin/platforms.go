@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"go/build"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TargetPlatforms specifies the platforms for which the generated code of a project must build.
+type TargetPlatforms struct {
+	// Platforms are the target platforms in the form "<GOOS>-<GOARCH>" (such as "linux-amd64").
+	Platforms []string
+	// WarnOnly specifies that code that is unavailable on a target platform should be printed as a warning rather than
+	// failing the operation.
+	WarnOnly bool
+}
+
+// knownOS and knownArch are the values of GOOS and GOARCH recognized by the go tool.
+var (
+	knownOS = map[string]struct{}{
+		"aix": {}, "android": {}, "darwin": {}, "dragonfly": {}, "freebsd": {}, "hurd": {}, "illumos": {}, "ios": {},
+		"js": {}, "linux": {}, "nacl": {}, "netbsd": {}, "openbsd": {}, "plan9": {}, "solaris": {}, "wasip1": {},
+		"windows": {}, "zos": {},
+	}
+	knownArch = map[string]struct{}{
+		"386": {}, "amd64": {}, "amd64p32": {}, "arm": {}, "armbe": {}, "arm64": {}, "arm64be": {}, "loong64": {},
+		"mips": {}, "mipsle": {}, "mips64": {}, "mips64le": {}, "mips64p32": {}, "mips64p32le": {}, "ppc": {},
+		"ppc64": {}, "ppc64le": {}, "riscv": {}, "riscv64": {}, "s390": {}, "s390x": {}, "sparc": {}, "sparc64": {},
+		"wasm": {},
+	}
+	// unixOS are the values of GOOS that satisfy the "unix" build constraint.
+	unixOS = map[string]struct{}{
+		"aix": {}, "android": {}, "darwin": {}, "dragonfly": {}, "freebsd": {}, "hurd": {}, "illumos": {}, "ios": {},
+		"linux": {}, "netbsd": {}, "openbsd": {}, "solaris": {},
+	}
+	// impliedOS are the values of GOOS that also satisfy the build constraint for another GOOS.
+	impliedOS = map[string]string{
+		"android": "linux",
+		"illumos": "solaris",
+		"ios":     "darwin",
+	}
+	releaseTagRegexp = regexp.MustCompile(`^go1\.[0-9]+$`)
+)
+
+// maxCustomBuildTags is the maximum number of build tags other than platform tags in a build constraint for which all
+// assignments are evaluated. Constraints with more custom tags are not checked.
+const maxCustomBuildTags = 10
+
+// ParsePlatform returns the GOOS and GOARCH of the provided platform of the form "<GOOS>-<GOARCH>". Returns an error if
+// the platform is not of that form or does not specify a known GOOS and GOARCH.
+func ParsePlatform(platform string) (string, string, error) {
+	parts := strings.Split(platform, "-")
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("platform %q must be of the form <GOOS>-<GOARCH>", platform)
+	}
+	if _, ok := knownOS[parts[0]]; !ok {
+		return "", "", errors.Errorf("platform %q has unknown GOOS %q", platform, parts[0])
+	}
+	if _, ok := knownArch[parts[1]]; !ok {
+		return "", "", errors.Errorf("platform %q has unknown GOARCH %q", platform, parts[1])
+	}
+	return parts[0], parts[1], nil
+}
+
+// violations returns a description of every generated Go file in the provided files that is unavailable on any of the
+// target platforms: files whose build constraint cannot be satisfied on the platform (for any assignment of build tags
+// other than platform tags) and files that import standard library packages that have no Go files for the platform
+// (with cgo disabled, as is typical when cross-compiling). Paths are reported relative to projectDir.
+func (p TargetPlatforms) violations(files []renderedFile, projectDir string) ([]string, error) {
+	// the availability of standard library packages is cached by platform and import path
+	available := make(map[string]bool)
+	var out []string
+	for _, file := range files {
+		if !strings.HasSuffix(file.absPath, ".go") {
+			continue
+		}
+		displayPath := file.absPath
+		if relPath, err := filepath.Rel(projectDir, file.absPath); err == nil {
+			displayPath = relPath
+		}
+		fset := token.NewFileSet()
+		astFile, err := parser.ParseFile(fset, file.absPath, file.content, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse generated file %s", displayPath)
+		}
+		var buildConstraint constraint.Expr
+		for _, group := range astFile.Comments {
+			if group.Pos() >= astFile.Package {
+				break
+			}
+			for _, comment := range group.List {
+				if constraint.IsGoBuild(comment.Text) {
+					if buildConstraint, err = constraint.Parse(comment.Text); err != nil {
+						return nil, errors.Wrapf(err, "invalid build constraint in generated file %s", displayPath)
+					}
+				}
+			}
+		}
+		var stdImports []string
+		for _, importSpec := range astFile.Imports {
+			importPath, err := strconv.Unquote(importSpec.Path.Value)
+			if err == nil && isStandardLibraryImport(importPath) && importPath != "C" {
+				stdImports = append(stdImports, importPath)
+			}
+		}
+
+		for _, platform := range p.Platforms {
+			goos, goarch, err := ParsePlatform(platform)
+			if err != nil {
+				return nil, err
+			}
+			if buildConstraint != nil && !satisfiableOnPlatform(buildConstraint, goos, goarch) {
+				out = append(out, fmt.Sprintf("%s is excluded on %s by its build constraint %q", displayPath, platform, buildConstraint.String()))
+				continue
+			}
+			for _, importPath := range stdImports {
+				key := platform + ":" + importPath
+				if _, ok := available[key]; !ok {
+					available[key] = stdPackageAvailable(importPath, goos, goarch)
+				}
+				if !available[key] {
+					out = append(out, fmt.Sprintf("%s imports %s, which is not available on %s", displayPath, importPath, platform))
+				}
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// satisfiableOnPlatform returns true if the provided build constraint is satisfied on the provided platform for some
+// assignment of the build tags that are not platform tags. Returns true if the constraint has too many such tags to
+// evaluate all of their assignments.
+func satisfiableOnPlatform(expr constraint.Expr, goos, goarch string) bool {
+	customTags := make(map[string]struct{})
+	expr.Eval(func(tag string) bool {
+		if !isPlatformTag(tag) {
+			customTags[tag] = struct{}{}
+		}
+		return false
+	})
+	if len(customTags) > maxCustomBuildTags {
+		return true
+	}
+	var tags []string
+	for tag := range customTags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for assignment := 0; assignment < 1<<len(tags); assignment++ {
+		satisfied := expr.Eval(func(tag string) bool {
+			if idx := sort.SearchStrings(tags, tag); idx < len(tags) && tags[idx] == tag {
+				return assignment&(1<<idx) != 0
+			}
+			return platformTagSatisfied(tag, goos, goarch)
+		})
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlatformTag returns true if the provided build tag is determined by the platform, toolchain or Go version rather
+// than specified by the user.
+func isPlatformTag(tag string) bool {
+	if _, ok := knownOS[tag]; ok {
+		return true
+	}
+	if _, ok := knownArch[tag]; ok {
+		return true
+	}
+	switch tag {
+	case "unix", "cgo", "gc", "gccgo":
+		return true
+	}
+	return releaseTagRegexp.MatchString(tag)
+}
+
+// platformTagSatisfied returns true if the provided platform tag is satisfied when building for the provided platform
+// using the gc toolchain of the plugin with cgo disabled.
+func platformTagSatisfied(tag, goos, goarch string) bool {
+	switch {
+	case tag == goos || tag == goarch || tag == impliedOS[goos] || tag == "gc":
+		return true
+	case tag == "unix":
+		_, ok := unixOS[goos]
+		return ok
+	case releaseTagRegexp.MatchString(tag):
+		for _, releaseTag := range build.Default.ReleaseTags {
+			if releaseTag == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stdPackageAvailable returns true if the standard library package with the provided import path has Go files other than
+// its package documentation (which some packages, such as log/syslog, provide on all platforms) for the provided platform
+// with cgo disabled. Packages that cannot be found in the GOROOT of the plugin are assumed to be available.
+func stdPackageAvailable(importPath, goos, goarch string) bool {
+	ctx := build.Default
+	ctx.GOOS = goos
+	ctx.GOARCH = goarch
+	ctx.CgoEnabled = false
+	pkg, err := ctx.Import(importPath, "", 0)
+	if _, noGo := err.(*build.NoGoError); noGo {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	for _, goFile := range pkg.GoFiles {
+		if goFile != "doc.go" {
+			return true
+		}
+	}
+	return false
+}
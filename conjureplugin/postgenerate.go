@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// postGenerateProjectEnvVar is the environment variable that specifies the name of the project to post-generate
+	// hooks.
+	postGenerateProjectEnvVar = "CONJURE_PROJECT"
+	// postGenerateOutputDirEnvVar is the environment variable that specifies the absolute path of the output directory
+	// of the project to post-generate hooks.
+	postGenerateOutputDirEnvVar = "CONJURE_OUTPUT_DIR"
+)
+
+// PostGenerateHook specifies an executable that is run after the generated files of a project are written. The
+// executable is run in the project directory with the environment variables of the project, and the name of the
+// project and the absolute path of its output directory are provided in the CONJURE_PROJECT and CONJURE_OUTPUT_DIR
+// environment variables.
+type PostGenerateHook struct {
+	// Path is the path of the executable. Relative paths are resolved against the project directory, and paths
+	// without a separator are looked up in the PATH.
+	Path string
+	// Args are the arguments provided to the executable. They are rendered as Go templates that can use the "Project"
+	// and "OutputDir" functions to refer to the name of the project and the absolute path of its output directory.
+	Args []string
+}
+
+// runPostGenerateHooks runs the post-generate hooks of the provided project in order after its files were written to
+// the provided output directory. The output of the hooks is written to stdout. Returns an error if any hook fails, in
+// which case the remaining hooks are not run.
+func runPostGenerateHooks(projectName string, param ConjureProjectParam, outputDir, projectDir string, stdout io.Writer) error {
+	if len(param.PostGenerate) == 0 {
+		return nil
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine absolute path of %s", outputDir)
+	}
+	funcs := template.FuncMap{
+		"Project":   func() string { return projectName },
+		"OutputDir": func() string { return absOutputDir },
+	}
+	env := envSlice(param.Env)
+	env = append(env, postGenerateProjectEnvVar+"="+projectName, postGenerateOutputDirEnvVar+"="+absOutputDir)
+	for _, hook := range param.PostGenerate {
+		var args []string
+		for _, arg := range hook.Args {
+			tmpl, err := template.New("arg").Funcs(funcs).Parse(arg)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse template for post-generate argument %q of %s", arg, projectName)
+			}
+			buf := &bytes.Buffer{}
+			if err := tmpl.Execute(buf, nil); err != nil {
+				return errors.Wrapf(err, "failed to execute template for post-generate argument %q of %s", arg, projectName)
+			}
+			args = append(args, buf.String())
+		}
+		cmd := exec.Command(hook.Path, args...)
+		cmd.Dir = projectDir
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = stdout
+		cmd.Stderr = stdout
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "post-generate command %q for %s failed", strings.Join(append([]string{hook.Path}, args...), " "), projectName)
+		}
+	}
+	return nil
+}
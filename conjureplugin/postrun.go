@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PostRunHook specifies an executable that is run after all of the projects have been processed, whether or not they
+// succeeded. The executable is run in the project directory with the arguments in Args and receives the summary of the
+// run as a single line of JSON (in the format printed by Summary.PrintJSON) on stdin.
+type PostRunHook struct {
+	// Path is the path of the executable. Relative paths are resolved against the project directory, and paths
+	// without a separator are looked up in the PATH.
+	Path string
+	// Args are the arguments provided to the executable.
+	Args []string
+}
+
+// PostRunHookError is the error returned by RunPostRunHooks if any of the hooks fail. It is distinct from the errors
+// returned by the operation that the hooks were run after.
+type PostRunHookError struct {
+	// Failures are the messages that describe the hooks that failed.
+	Failures []string
+}
+
+func (e *PostRunHookError) Error() string {
+	return fmt.Sprintf("post-run hooks failed:\n%s", indent(strings.Join(e.Failures, "\n"), indentLen))
+}
+
+// RunPostRunHooks runs the provided hooks in order, providing the provided summary to each of them. The output of the
+// hooks is written to stdout. Every hook is run even if an earlier hook fails. Returns a *PostRunHookError that
+// describes every hook that failed.
+func RunPostRunHooks(hooks []PostRunHook, summary *Summary, projectDir string, stdout io.Writer) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	summaryJSON := &bytes.Buffer{}
+	if err := summary.PrintJSON(summaryJSON); err != nil {
+		return err
+	}
+	var failures []string
+	for _, hook := range hooks {
+		cmd := exec.Command(hook.Path, hook.Args...)
+		cmd.Dir = projectDir
+		cmd.Stdin = bytes.NewReader(summaryJSON.Bytes())
+		cmd.Stdout = stdout
+		cmd.Stderr = stdout
+		if err := cmd.Run(); err != nil {
+			failures = append(failures, errors.Wrapf(err, "%s", strings.Join(append([]string{hook.Path}, hook.Args...), " ")).Error())
+		}
+	}
+	if len(failures) > 0 {
+		return &PostRunHookError{
+			Failures: failures,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/conjureverify"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PreviewDiffFileName is the name of the file in a preview bundle that contains the unified diff that updates the
+	// committed outputs to the regenerated code.
+	PreviewDiffFileName = "preview.diff"
+	// PreviewSummaryFileName is the name of the file in a preview bundle that contains the summary of the generation
+	// as JSON.
+	PreviewSummaryFileName = "summary.json"
+)
+
+// Preview regenerates the code of the provided projects into a temporary directory and writes a zip archive to w that
+// contains a unified diff that updates the committed outputs in the project directory to the regenerated code
+// (PreviewDiffFileName) and the summary of the generation as JSON (PreviewSummaryFileName). The diff is in the format
+// of "git diff" with paths relative to the project directory. The files in the project directory are not modified.
+// Incremental generation is not used for previews.
+func Preview(params ConjureProjectParams, projectDir string, w io.Writer, stdout io.Writer, opParams ...OperationParam) error {
+	opArgs := newOperationArgs(opParams)
+
+	tmpDir, err := tempfilecreator.MkdirTemp("preview")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	params.Incremental = false
+	summary := NewSummary("conjure-preview")
+	runErr := Run(params, false, projectDir, stdout, append(opParams,
+		IncrementalParam(false),
+		DryRunParam(false),
+		OutputRootParam(tmpDir),
+		SummaryParam(summary),
+	)...)
+	summary.Finish()
+	opArgs.summary.add(summary.Projects...)
+	if runErr != nil {
+		return runErr
+	}
+
+	patch, err := previewPatch(params, projectDir, tmpDir)
+	if err != nil {
+		return err
+	}
+	summaryJSON := &bytes.Buffer{}
+	if err := summary.PrintJSON(summaryJSON); err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	for _, entry := range []struct {
+		name    string
+		content []byte
+	}{
+		{PreviewDiffFileName, []byte(patch)},
+		{PreviewSummaryFileName, summaryJSON.Bytes()},
+	} {
+		fw, err := zw.Create(entry.name)
+		if err != nil {
+			return errors.Wrapf(err, "failed to add %s to preview", entry.name)
+		}
+		if _, err := fw.Write(entry.content); err != nil {
+			return errors.Wrapf(err, "failed to add %s to preview", entry.name)
+		}
+	}
+	return errors.Wrapf(zw.Close(), "failed to write preview")
+}
+
+// previewPatch returns a patch that updates the files in the project directory to match the files that were generated
+// in the provided directory. Generated files in the output directories of the provided projects that were not
+// regenerated are removed by the patch.
+func previewPatch(params ConjureProjectParams, projectDir, generatedDir string) (string, error) {
+	relPaths := make(map[string]struct{})
+	if err := filepath.WalkDir(generatedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(generatedDir, path)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPaths[relPath] = struct{}{}
+		return nil
+	}); err != nil {
+		return "", errors.Wrapf(err, "failed to walk generated files")
+	}
+	for _, param := range params.OrderedParams() {
+		outputDir := filepath.Join(projectDir, param.OutputDir)
+		if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+			continue
+		}
+		if err := filepath.WalkDir(outputDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !isGeneratedFileName(d.Name()) {
+				return err
+			}
+			relPath, err := filepath.Rel(projectDir, path)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			relPaths[relPath] = struct{}{}
+			return nil
+		}); err != nil {
+			return "", errors.Wrapf(err, "failed to walk output directory %s", outputDir)
+		}
+	}
+
+	sortedRelPaths := make([]string, 0, len(relPaths))
+	for relPath := range relPaths {
+		sortedRelPaths = append(sortedRelPaths, relPath)
+	}
+	sort.Strings(sortedRelPaths)
+
+	buf := &bytes.Buffer{}
+	for _, relPath := range sortedRelPaths {
+		oldContent, err := readFileIfExists(filepath.Join(projectDir, relPath))
+		if err != nil {
+			return "", err
+		}
+		newContent, err := readFileIfExists(filepath.Join(generatedDir, relPath))
+		if err != nil {
+			return "", err
+		}
+		if oldContent != nil && newContent != nil && bytes.Equal(oldContent, newContent) {
+			continue
+		}
+		if err := conjureverify.WriteFilePatch(buf, filepath.ToSlash(relPath), oldContent, newContent); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// readFileIfExists returns the content of the file at the provided path or nil if it does not exist.
+func readFileIfExists(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	if content == nil {
+		content = []byte{}
+	}
+	return content, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	projectDir, err := os.MkdirTemp(cwd, "TestPreview_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(projectDir))
+	}()
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure-output",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+
+	// modify a generated file and add a generated file that is no longer generated
+	apiDir := filepath.Join(projectDir, "conjure-output", "conjure", "test", "api")
+	structsFile := filepath.Join(apiDir, "structs.conjure.go")
+	original, err := os.ReadFile(structsFile)
+	require.NoError(t, err)
+	modified := append(append([]byte{}, original...), []byte("\n// modified\n")...)
+	require.NoError(t, os.WriteFile(structsFile, modified, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "old.conjure.go"), []byte("package api\n"), 0644))
+
+	summary := conjureplugin.NewSummary("conjure-preview")
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Preview(params, projectDir, buf, &bytes.Buffer{}, conjureplugin.SummaryParam(summary)))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	contents := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		contents[f.Name] = string(content)
+	}
+	diff := contents[conjureplugin.PreviewDiffFileName]
+	wantPrefix := `diff --git a/conjure-output/conjure/test/api/old.conjure.go b/conjure-output/conjure/test/api/old.conjure.go
+deleted file mode 100644
+--- a/conjure-output/conjure/test/api/old.conjure.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package api
+diff --git a/conjure-output/conjure/test/api/structs.conjure.go b/conjure-output/conjure/test/api/structs.conjure.go
+--- a/conjure-output/conjure/test/api/structs.conjure.go
++++ b/conjure-output/conjure/test/api/structs.conjure.go
+@@ -`
+	assert.True(t, strings.HasPrefix(diff, wantPrefix), diff)
+	assert.Contains(t, diff, "-// modified\n")
+
+	var gotSummary struct {
+		Command  string `json:"command"`
+		Projects []struct {
+			Project string `json:"project"`
+			Status  string `json:"status"`
+		} `json:"projects"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(contents[conjureplugin.PreviewSummaryFileName]), &gotSummary))
+	assert.Equal(t, "conjure-preview", gotSummary.Command)
+	require.Len(t, gotSummary.Projects, 1)
+	assert.Equal(t, "project-1", gotSummary.Projects[0].Project)
+	assert.Equal(t, "succeeded", gotSummary.Projects[0].Status)
+	require.Len(t, summary.Projects, 1)
+	assert.Equal(t, conjureplugin.ProjectStatusSucceeded, summary.Projects[0].Status)
+
+	// the project directory is not modified
+	got, err := os.ReadFile(structsFile)
+	require.NoError(t, err)
+	assert.Equal(t, modified, got)
+	_, err = os.Stat(filepath.Join(apiDir, "old.conjure.go"))
+	assert.NoError(t, err)
+}
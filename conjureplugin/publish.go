@@ -15,31 +15,56 @@
 package conjureplugin
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
-	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
 
 	"github.com/palantir/distgo/distgo"
 	gitversioner "github.com/palantir/distgo/projectversioner/git"
+	"github.com/palantir/distgo/publisher"
 	"github.com/palantir/distgo/publisher/artifactory"
+	artifactoryconfig "github.com/palantir/distgo/publisher/artifactory/config"
+	"github.com/palantir/distgo/publisher/maven"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
 	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
 )
 
-func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) error {
+func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer, opParams ...OperationParam) (rErr error) {
+	opArgs := newOperationArgs(opParams)
+	summaries := newProjectSummaries(params)
+	defer func() {
+		rErr = summaries.finish(rErr, opArgs.summary)
+	}()
+
 	var paramsToPublishKeys []string
 	var paramsToPublish []ConjureProjectParam
+	// paramsToPublishIndices are the indices of the projects to publish in params.SortedKeys
+	var paramsToPublishIndices []int
 	for i, param := range params.OrderedParams() {
 		if !param.Publish {
+			summaries.projects[i].Message = "publish is false"
 			continue
 		}
 		paramsToPublishKeys = append(paramsToPublishKeys, params.SortedKeys[i])
 		paramsToPublish = append(paramsToPublish, param)
+		paramsToPublishIndices = append(paramsToPublishIndices, i)
 	}
 	// nothing to publish
 	if len(paramsToPublish) == 0 {
 		return nil
 	}
+	// installing into a local repository does not require network access
+	if !dryRun && params.MavenLocal == nil {
+		if err := offline.Check("publish", strings.Join(paramsToPublishKeys, ", "), "use --dry-run to determine what would be published"); err != nil {
+			return err
+		}
+	}
 
 	// publishing at least 1 artifact: determine version. Note that this is currently hard-coded to use the Git
 	// project versioner.
@@ -49,23 +74,67 @@ func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo
 		return err
 	}
 
-	publisher := artifactory.NewArtifactoryPublisher()
-	tmpDir, err := os.MkdirTemp("", "")
+	artifactoryPublisher := artifactory.NewArtifactoryPublisher()
+	var staging *nexusStagingRepository
+	if params.NexusStaging != nil {
+		staging, err = startPublishNexusStaging(*params.NexusStaging, version, flagVals, dryRun, stdout)
+		if err != nil {
+			return Classify(err, ErrPublish)
+		}
+		defer func() {
+			if rErr != nil {
+				staging.drop()
+			}
+		}()
+	}
+	tmpDir, err := tempfilecreator.MkdirTemp("publish")
 	if err != nil {
-		return errors.WithStack(err)
+		return err
 	}
 	defer func() {
 		_ = os.RemoveAll(tmpDir)
 	}()
 
 	for i, param := range paramsToPublish {
+		summaries.begin(paramsToPublishIndices[i])
 		key := paramsToPublishKeys[i]
-		currDir := path.Join(tmpDir, fmt.Sprintf("conjure-%s", key))
+		currDir := filepath.Join(tmpDir, fmt.Sprintf("conjure-%s", key))
 		irFileName := fmt.Sprintf("%s-%s.conjure.json", key, version)
 		keyAsDistID := distgo.DistID(key)
 		if err := os.Mkdir(currDir, 0755); err != nil {
 			return errors.WithStack(err)
 		}
+
+		irBytes, err := param.IRProvider.IRBytes()
+		if err != nil {
+			return Classify(err, ErrIR)
+		}
+		if irBytes, err = addProjectExtensions(key, param, projectDir, opArgs.pluginVersion, irBytes, stdout); err != nil {
+			return err
+		}
+		if err := validateIR(key, param, projectDir, irBytes, opArgs.irValidators, params, stdout); err != nil {
+			return err
+		}
+		artifacts := []irShard{{
+			name:    irFileName,
+			content: irBytes,
+		}}
+		if param.PublishSharded {
+			// the index is published using the name of the IR artifact so that consumers locate it in the usual way
+			indexBytes, shards, err := shardIR(irBytes, fmt.Sprintf("%s-%s.", key, version))
+			if err != nil {
+				return errors.Wrapf(err, "failed to shard IR of %s", key)
+			}
+			artifacts = append([]irShard{{
+				name:    irFileName,
+				content: indexBytes,
+			}}, shards...)
+		}
+		var artifactNames []string
+		for _, artifact := range artifacts {
+			artifactNames = append(artifactNames, artifact.name)
+		}
+
 		projectInfo := distgo.ProjectInfo{
 			ProjectDir: currDir,
 			Version:    version,
@@ -78,10 +147,8 @@ func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo
 				DistInfos: map[distgo.DistID]distgo.DistOutputInfo{
 					keyAsDistID: {
 						DistNameTemplateRendered: irFileName,
-						DistArtifactNames: []string{
-							irFileName,
-						},
-						PackagingExtension: "json",
+						DistArtifactNames:        artifactNames,
+						PackagingExtension:       "json",
 					},
 				},
 			},
@@ -97,26 +164,220 @@ func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo
 			return errors.WithStack(err)
 		}
 
-		irBytes, err := param.IRProvider.IRBytes()
-		if err != nil {
-			return err
+		for _, artifact := range artifacts {
+			if err := os.WriteFile(filepath.Join(directoryPath, artifact.name), artifact.content, 0644); err != nil {
+				return errors.WithStack(err)
+			}
 		}
-
-		irFilePath := path.Join(directoryPath, irFileName)
-		if err := os.WriteFile(irFilePath, irBytes, 0644); err != nil {
-			return errors.WithStack(err)
+		irFilePath := filepath.Join(directoryPath, irFileName)
+		if opArgs.verifyArtifacts {
+			if err := verifyPublishArtifact(key, version, irFilePath); err != nil {
+				return err
+			}
 		}
 
-		if err := publisher.RunPublish(distgo.ProductTaskOutputInfo{
+		outputInfo := distgo.ProductTaskOutputInfo{
 			Project: projectInfo,
 			Product: productOutputInfo,
-		}, nil, flagVals, dryRun, stdout); err != nil {
+		}
+		if params.PublisherAsset != nil {
+			if err := publishToAsset(*params.PublisherAsset, key, param, version, directoryPath, artifactNames, flagVals, dryRun, stdout); err != nil {
+				return Classify(err, ErrPublish)
+			}
+		} else if staging != nil {
+			if err := publishToNexusStaging(staging, outputInfo, directoryPath, artifactNames, flagVals, dryRun, stdout); err != nil {
+				return Classify(err, ErrPublish)
+			}
+		} else if params.MavenLocal != nil {
+			if err := installToMavenLocal(*params.MavenLocal, projectDir, outputInfo, directoryPath, artifactNames, flagVals, dryRun, stdout); err != nil {
+				return Classify(err, ErrPublish)
+			}
+		} else {
+			cfgYML, err := artifactoryConfigYML(key, param, version)
+			if err != nil {
+				return err
+			}
+			if err := artifactoryPublisher.RunPublish(outputInfo, cfgYML, flagVals, dryRun, stdout); err != nil {
+				return Classify(err, ErrPublish)
+			}
+		}
+		numRelocationPOMs, err := publishRelocationPOMs(key, param, version, staging, params.MavenLocal, projectDir, flagVals, dryRun, stdout)
+		if err != nil {
+			return Classify(err, ErrPublish)
+		}
+		if dryRun {
+			summaries.end(ProjectStatusSucceeded, "dry run")
+			continue
+		}
+		summaries.projects[paramsToPublishIndices[i]].ArtifactsPublished = len(artifacts) + numRelocationPOMs
+		summaries.end(ProjectStatusSucceeded, "")
+	}
+	if staging != nil {
+		if err := staging.finish(); err != nil {
+			// the artifacts of all of the projects are dropped along with the staging repository
+			for _, idx := range paramsToPublishIndices {
+				summaries.projects[idx].ArtifactsPublished = 0
+				summaries.fail(idx, err.Error())
+			}
+			return Classify(err, ErrPublish)
+		}
+	}
+	return nil
+}
+
+// startPublishNexusStaging starts the Nexus staging repository to which the projects are published using the connection
+// information specified by the provided publisher flags.
+func startPublishNexusStaging(params NexusStaging, version string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) (*nexusStagingRepository, error) {
+	var connectionInfo publisher.BasicConnectionInfo
+	if err := connectionInfo.SetValuesFromFlags(flagVals); err != nil {
+		return nil, err
+	}
+	return startNexusStaging(params, connectionInfo, version, dryRun, stdout)
+}
+
+// publishToNexusStaging uploads the IR artifacts with the provided names in the provided directory and the POM of the
+// provided product (unless the publisher flags specify that no POM should be published) to the provided staging
+// repository.
+func publishToNexusStaging(staging *nexusStagingRepository, outputInfo distgo.ProductTaskOutputInfo, artifactDir string, artifactNames []string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) error {
+	var connectionInfo publisher.BasicConnectionInfo
+	if err := connectionInfo.SetValuesFromFlags(flagVals); err != nil {
+		return err
+	}
+	groupID, err := publisher.GetRequiredGroupID(flagVals, outputInfo)
+	if err != nil {
+		return err
+	}
+	baseURL := strings.Join([]string{staging.deployURL(), publisher.MavenProductPath(outputInfo, groupID)}, "/")
+
+	for _, artifactName := range artifactNames {
+		artifactPath := filepath.Join(artifactDir, artifactName)
+		fileInfo := publisher.FileInfo{
+			Path: artifactPath,
+		}
+		if !dryRun {
+			if fileInfo, err = publisher.NewFileInfo(artifactPath); err != nil {
+				return err
+			}
+		}
+		if _, err := connectionInfo.UploadFile(fileInfo, baseURL, artifactName, nil, dryRun, stdout); err != nil {
 			return err
 		}
 	}
+
+	var noPOM bool
+	if err := publisher.SetConfigValue(flagVals, maven.NoPOMFlag, &noPOM); err != nil {
+		return err
+	}
+	if noPOM {
+		return nil
+	}
+	pomName, pomContent, err := maven.POM(groupID, outputInfo)
+	if err != nil {
+		return err
+	}
+	if _, err := connectionInfo.UploadFile(publisher.NewFileInfoFromBytes([]byte(pomContent)), baseURL, pomName, nil, dryRun, stdout); err != nil {
+		return err
+	}
 	return nil
 }
 
+// artifactoryConfigYML returns the YAML configuration for the Artifactory publisher for the provided project. The
+// values of the publish properties of the project are rendered as Go templates that can use the "env", "Project" and
+// "Version" functions. If the project has owners, they are specified by the "conjure.owners" property unless the
+// publish properties specify it.
+func artifactoryConfigYML(key string, param ConjureProjectParam, version string) ([]byte, error) {
+	if len(param.PublishProperties) == 0 && len(param.Owners) == 0 {
+		return nil, nil
+	}
+	properties := make(map[string]string)
+	if len(param.Owners) > 0 {
+		properties[ownersPublishProperty] = strings.Join(param.Owners, ",")
+	}
+	for k, v := range param.PublishProperties {
+		tmpl, err := template.New("property").Funcs(template.FuncMap{
+			"env":     os.Getenv,
+			"Project": func() string { return key },
+			"Version": func() string { return version },
+		}).Parse(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse template for publish property %s of %s", k, key)
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, nil); err != nil {
+			return nil, errors.Wrapf(err, "failed to execute template for publish property %s of %s", k, key)
+		}
+		properties[k] = buf.String()
+	}
+	cfgYML, err := yaml.Marshal(artifactoryconfig.Artifactory{
+		Properties: properties,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal Artifactory publisher configuration")
+	}
+	return cfgYML, nil
+}
+
+// publishRelocationPOMs publishes a POM that relocates each of the previous names of the provided project that is
+// configured to publish a relocation POM to the current name of the project. The POMs are published to the provided
+// staging repository if it is non-nil and installed into the provided local repository if it is non-nil. Returns the
+// number of POMs published.
+func publishRelocationPOMs(key string, param ConjureProjectParam, version string, staging *nexusStagingRepository, mavenLocal *MavenLocal, projectDir string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) (int, error) {
+	var relocations []RenamedFrom
+	for _, renamedFrom := range param.RenamedFrom {
+		if renamedFrom.PublishRelocationPOM {
+			relocations = append(relocations, renamedFrom)
+		}
+	}
+	if len(relocations) == 0 {
+		return 0, nil
+	}
+	if mavenLocal != nil {
+		repositoryDir, err := mavenLocal.repositoryDir(projectDir)
+		if err != nil {
+			return 0, err
+		}
+		groupID, err := publisher.GetRequiredGroupID(flagVals, distgo.ProductTaskOutputInfo{})
+		if err != nil {
+			return 0, err
+		}
+		for _, relocation := range relocations {
+			pomPath := filepath.Join(repositoryDir, filepath.FromSlash(strings.Replace(groupID, ".", "/", -1)), relocation.Name, version, fmt.Sprintf("%s-%s.pom", relocation.Name, version))
+			if err := installFile(pomPath, []byte(relocationPOM(groupID, relocation.Name, key, version)), dryRun, stdout); err != nil {
+				return 0, errors.Wrapf(err, "failed to install relocation POM for %s", relocation.Name)
+			}
+		}
+		return len(relocations), nil
+	}
+
+	var connectionInfo publisher.BasicConnectionInfo
+	if err := connectionInfo.SetValuesFromFlags(flagVals); err != nil {
+		return 0, err
+	}
+	var repositoryURL string
+	if staging != nil {
+		repositoryURL = staging.deployURL()
+	} else {
+		var repository string
+		if err := publisher.SetRequiredStringConfigValue(flagVals, artifactory.PublisherRepositoryFlag, &repository); err != nil {
+			return 0, err
+		}
+		repositoryURL = strings.Join([]string{connectionInfo.URL, "artifactory", repository}, "/")
+	}
+	groupID, err := publisher.GetRequiredGroupID(flagVals, distgo.ProductTaskOutputInfo{})
+	if err != nil {
+		return 0, err
+	}
+	for _, relocation := range relocations {
+		baseURL := strings.Join([]string{repositoryURL, strings.Replace(groupID, ".", "/", -1), relocation.Name, version}, "/")
+		pomName := fmt.Sprintf("%s-%s.pom", relocation.Name, version)
+		pomContent := relocationPOM(groupID, relocation.Name, key, version)
+		if _, err := connectionInfo.UploadFile(publisher.NewFileInfoFromBytes([]byte(pomContent)), baseURL, pomName, nil, dryRun, stdout); err != nil {
+			return 0, errors.Wrapf(err, "failed to publish relocation POM for %s", relocation.Name)
+		}
+	}
+	return len(relocations), nil
+}
+
 func PublisherFlags() ([]distgo.PublisherFlag, error) {
 	return artifactory.NewArtifactoryPublisher().Flags()
 }
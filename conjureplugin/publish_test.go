@@ -16,8 +16,12 @@ package conjureplugin_test
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -27,8 +31,11 @@ import (
 	"github.com/palantir/distgo/distgo"
 	"github.com/palantir/distgo/publisher"
 	"github.com/palantir/distgo/publisher/artifactory"
+	"github.com/palantir/distgo/publisher/maven"
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
@@ -89,3 +96,828 @@ projects:
 	wantRegexp = regexp.QuoteMeta("[DRY RUN]") + " Uploading to " + regexp.QuoteMeta("http://artifactory.domain.com/artifactory/repo/com/palantir/foo/") + ".*?" + regexp.QuoteMeta(".pom")
 	assert.Regexp(t, wantRegexp, lines[1])
 }
+
+func TestPublishProperties(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishProperties_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	t.Setenv("TEST_PUBLISH_TIER", "1")
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+				PublishProperties: map[string]string{
+					"team":    "foo",
+					"tier":    `{{ env "TEST_PUBLISH_TIER" }}`,
+					"project": "{{ Project }}",
+				},
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err = conjureplugin.Publish(params, tmpDir, map[distgo.PublisherFlagName]interface{}{
+		publisher.ConnectionInfoURLFlag.Name:     "http://artifactory.domain.com",
+		publisher.GroupIDFlag.Name:               "com.palantir.foo",
+		artifactory.PublisherRepositoryFlag.Name: "repo",
+	}, true, outputBuf)
+	require.NoError(t, err, "failed to publish Conjure")
+
+	lines := strings.Split(outputBuf.String(), "\n")
+	wantRegexp := regexp.QuoteMeta("[DRY RUN]") + " Uploading .*?" + regexp.QuoteMeta(".conjure.json") + " to " + regexp.QuoteMeta("http://artifactory.domain.com/artifactory/repo;project=project-1;team=foo;tier=1/com/palantir/foo/project-1/")
+	assert.Regexp(t, wantRegexp, lines[0])
+}
+
+func TestPublishOwners(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishOwners_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+				Owners:     []string{"team-a@company.com", "team-b"},
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	summary := conjureplugin.NewSummary("conjure-publish")
+	err = conjureplugin.Publish(params, tmpDir, map[distgo.PublisherFlagName]interface{}{
+		publisher.ConnectionInfoURLFlag.Name:     "http://artifactory.domain.com",
+		publisher.GroupIDFlag.Name:               "com.palantir.foo",
+		artifactory.PublisherRepositoryFlag.Name: "repo",
+	}, true, outputBuf, conjureplugin.SummaryParam(summary))
+	require.NoError(t, err, "failed to publish Conjure")
+
+	lines := strings.Split(outputBuf.String(), "\n")
+	wantRegexp := regexp.QuoteMeta("[DRY RUN]") + " Uploading .*?" + regexp.QuoteMeta(".conjure.json") + " to " + regexp.QuoteMeta("http://artifactory.domain.com/artifactory/repo;conjure.owners=team-a@company.com%2Cteam-b/com/palantir/foo/project-1/")
+	assert.Regexp(t, wantRegexp, lines[0])
+	require.Len(t, summary.Projects, 1)
+	assert.Equal(t, []string{"team-a@company.com", "team-b"}, summary.Projects[0].Owners)
+}
+
+func TestPublishNexusStaging(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishNexusStaging_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	for i, tc := range []struct {
+		name        string
+		closedState string
+		wantErr     string
+		want        []string
+	}{
+		{
+			name:        "staging repository is closed and released",
+			closedState: "closed",
+			want: []string{
+				"POST /service/local/staging/profiles/profile-1/start",
+				"PUT /service/local/staging/deployByRepositoryId/repo-1001/com/palantir/foo/project-1/.+/project-1-.+\\.conjure\\.json",
+				"PUT /service/local/staging/deployByRepositoryId/repo-1001/com/palantir/foo/project-1/.+/project-1-.+\\.pom",
+				"POST /service/local/staging/profiles/profile-1/finish",
+				"GET /service/local/staging/repository/repo-1001",
+				"POST /service/local/staging/bulk/promote",
+				"GET /service/local/staging/repository/repo-1001",
+			},
+		},
+		{
+			name:        "staging repository that fails to close is dropped",
+			closedState: "open",
+			wantErr:     "staging repository repo-1001 is open rather than closed: see the activity of the repository in Nexus for the cause",
+			want: []string{
+				"POST /service/local/staging/profiles/profile-1/start",
+				"PUT /service/local/staging/deployByRepositoryId/repo-1001/com/palantir/foo/project-1/.+/project-1-.+\\.conjure\\.json",
+				"PUT /service/local/staging/deployByRepositoryId/repo-1001/com/palantir/foo/project-1/.+/project-1-.+\\.pom",
+				"POST /service/local/staging/profiles/profile-1/finish",
+				"GET /service/local/staging/repository/repo-1001",
+				"POST /service/local/staging/bulk/drop",
+			},
+		},
+	} {
+		var requests []string
+		state := "open"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			switch r.URL.Path {
+			case "/service/local/staging/profiles/profile-1/start":
+				_, _ = w.Write([]byte(`{"data":{"stagedRepositoryId":"repo-1001"}}`))
+			case "/service/local/staging/profiles/profile-1/finish":
+				state = tc.closedState
+			case "/service/local/staging/bulk/promote":
+				state = "released"
+			case "/service/local/staging/repository/repo-1001":
+				_, _ = w.Write([]byte(`{"repositoryId":"repo-1001","type":"` + state + `","transitioning":false}`))
+			}
+		}))
+
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					Publish:    true,
+				},
+			},
+			NexusStaging: &conjureplugin.NexusStaging{
+				StagingProfileID: "profile-1",
+				Release:          true,
+			},
+		}
+		summary := conjureplugin.NewSummary("conjure-publish")
+		err := conjureplugin.Publish(params, tmpDir, map[distgo.PublisherFlagName]interface{}{
+			publisher.ConnectionInfoURLFlag.Name: server.URL,
+			publisher.GroupIDFlag.Name:           "com.palantir.foo",
+		}, false, ioutil.Discard, conjureplugin.SummaryParam(summary))
+		server.Close()
+
+		require.Len(t, requests, len(tc.want), "Case %d: %s\n%v", i, tc.name, requests)
+		for j, want := range tc.want {
+			assert.Regexp(t, "^"+want+"$", requests[j], "Case %d: %s", i, tc.name)
+		}
+		require.Len(t, summary.Projects, 1, "Case %d: %s", i, tc.name)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			assert.Equal(t, conjureplugin.ProjectStatusFailed, summary.Projects[0].Status, "Case %d: %s", i, tc.name)
+			assert.Equal(t, 0, summary.Projects[0].ArtifactsPublished, "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, conjureplugin.ProjectStatusSucceeded, summary.Projects[0].Status, "Case %d: %s", i, tc.name)
+		assert.Equal(t, 1, summary.Projects[0].ArtifactsPublished, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestPublishNexusStagingDryRun(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishNexusStagingDryRun_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+			},
+		},
+		NexusStaging: &conjureplugin.NexusStaging{
+			StagingProfileID: "profile-1",
+		},
+	}
+	outputBuf := &bytes.Buffer{}
+	err = conjureplugin.Publish(params, tmpDir, map[distgo.PublisherFlagName]interface{}{
+		publisher.ConnectionInfoURLFlag.Name: "http://nexus.domain.com",
+		publisher.GroupIDFlag.Name:           "com.palantir.foo",
+		maven.NoPOMFlag.Name:                 true,
+	}, true, outputBuf)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(outputBuf.String()), "\n")
+	require.Len(t, lines, 3, outputBuf.String())
+	assert.Equal(t, "[DRY RUN] Starting staging repository for staging profile profile-1", lines[0])
+	assert.Regexp(t, regexp.QuoteMeta("[DRY RUN] Uploading ")+".*?"+regexp.QuoteMeta(".conjure.json to http://nexus.domain.com/service/local/staging/deployByRepositoryId/<staging-repository-id>/com/palantir/foo/project-1/"), lines[1])
+	assert.Equal(t, "[DRY RUN] Closing staging repository <staging-repository-id>", lines[2])
+}
+
+func TestPublishAsset(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishAsset_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the asset records the arguments it is invoked with and the IR artifact
+	argsFile := filepath.Join(tmpDir, "args.json")
+	assetPath := filepath.Join(tmpDir, "publisher.sh")
+	require.NoError(t, ioutil.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+test "$1" = publish
+echo "$2" > "`+argsFile+`"
+irFile=$(echo "$2" | sed 's/.*"irFile":"\([^"]*\)".*/\1/')
+cp "$irFile" "`+tmpDir+`/published.json"
+echo "Published to $CATALOG_URL"
+`), 0755))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+				Env: map[string]string{
+					"CATALOG_URL": "https://catalog.domain.com",
+				},
+			},
+		},
+		PublisherAsset: &conjureplugin.PublisherAsset{
+			Name:   "ir-catalog",
+			Path:   assetPath,
+			Config: []byte(`{"team":"foo"}`),
+		},
+	}
+	outputBuf := &bytes.Buffer{}
+	summary := conjureplugin.NewSummary("conjure-publish")
+	err = conjureplugin.Publish(params, tmpDir, map[distgo.PublisherFlagName]interface{}{
+		publisher.GroupIDFlag.Name: "com.palantir.foo",
+	}, false, outputBuf, conjureplugin.SummaryParam(summary))
+	require.NoError(t, err)
+	assert.Equal(t, "Published to https://catalog.domain.com\n", outputBuf.String())
+	require.Len(t, summary.Projects, 1)
+	assert.Equal(t, 1, summary.Projects[0].ArtifactsPublished)
+
+	published, err := ioutil.ReadFile(filepath.Join(tmpDir, "published.json"))
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(published))
+	args, err := ioutil.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Regexp(t, `^\{"project":"project-1","groupId":"com.palantir.foo","artifactId":"project-1","version":"[^"]+","irFile":"[^"]+/project-1-[^"]+\.conjure\.json","artifacts":\["[^"]+\.conjure\.json"\],"flags":\{"group-id":"com.palantir.foo"\},"dryRun":false,"config":\{"team":"foo"\}\}$`, strings.TrimSpace(string(args)))
+
+	params.PublisherAsset.Path = filepath.Join(tmpDir, "missing.sh")
+	err = conjureplugin.Publish(params, tmpDir, nil, true, ioutil.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "publisher asset ir-catalog failed to publish project-1")
+	assert.True(t, errors.Is(err, conjureplugin.ErrPublish))
+}
+
+func TestPublishMavenLocal(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishMavenLocal_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+	// the metadata records a version that was installed previously
+	artifactDir := filepath.Join(tmpDir, "m2", "com", "palantir", "foo", "project-1")
+	require.NoError(t, os.MkdirAll(artifactDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(artifactDir, "maven-metadata-local.xml"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.palantir.foo</groupId>
+  <artifactId>project-1</artifactId>
+  <versioning>
+    <release>0.1.0</release>
+    <versions>
+      <version>0.1.0</version>
+    </versions>
+    <lastUpdated>20230101000000</lastUpdated>
+  </versioning>
+</metadata>
+`), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+				RenamedFrom: []conjureplugin.RenamedFrom{
+					{
+						Name:                 "old-project",
+						PublishRelocationPOM: true,
+					},
+				},
+			},
+		},
+		MavenLocal: &conjureplugin.MavenLocal{
+			Repository: "m2",
+		},
+	}
+	flagVals := map[distgo.PublisherFlagName]interface{}{
+		publisher.GroupIDFlag.Name: "com.palantir.foo",
+	}
+
+	// a dry run does not install anything
+	outputBuf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Publish(params, tmpDir, flagVals, true, outputBuf))
+	assert.Regexp(t, `^(\[DRY RUN\] Installing [^\n]+\n){4}$`, outputBuf.String())
+	versionDirs, err := filepath.Glob(filepath.Join(artifactDir, "*", "*.conjure.json"))
+	require.NoError(t, err)
+	assert.Empty(t, versionDirs)
+
+	outputBuf = &bytes.Buffer{}
+	summary := conjureplugin.NewSummary("conjure-publish")
+	require.NoError(t, conjureplugin.Publish(params, tmpDir, flagVals, false, outputBuf, conjureplugin.SummaryParam(summary)))
+	require.Len(t, summary.Projects, 1)
+	assert.Equal(t, 2, summary.Projects[0].ArtifactsPublished)
+
+	irFiles, err := filepath.Glob(filepath.Join(artifactDir, "*", "project-1-*.conjure.json"))
+	require.NoError(t, err)
+	require.Len(t, irFiles, 1)
+	version := filepath.Base(filepath.Dir(irFiles[0]))
+	published, err := ioutil.ReadFile(irFiles[0])
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(published))
+	pom, err := ioutil.ReadFile(filepath.Join(artifactDir, version, "project-1-"+version+".pom"))
+	require.NoError(t, err)
+	assert.Contains(t, string(pom), "<artifactId>project-1</artifactId>")
+	relocationPOM, err := ioutil.ReadFile(filepath.Join(tmpDir, "m2", "com", "palantir", "foo", "old-project", version, "old-project-"+version+".pom"))
+	require.NoError(t, err)
+	assert.Contains(t, string(relocationPOM), "<artifactId>project-1</artifactId>")
+
+	metadata, err := ioutil.ReadFile(filepath.Join(artifactDir, "maven-metadata-local.xml"))
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.palantir.foo</groupId>
+  <artifactId>project-1</artifactId>
+  <versioning>
+    <release>`+version+`</release>
+    <versions>
+      <version>0.1.0</version>
+      <version>`+version+`</version>
+    </versions>
+    <lastUpdated>20231114221320</lastUpdated>
+  </versioning>
+</metadata>
+`, string(metadata))
+	assert.Contains(t, outputBuf.String(), "Installed "+irFiles[0]+"\n")
+
+	err = conjureplugin.Publish(params, tmpDir, nil, false, ioutil.Discard)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, conjureplugin.ErrPublish))
+}
+
+func TestPublishGitMetadataExtension(t *testing.T) {
+	repoDir := t.TempDir()
+	gitCmd := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed: %s", args, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	gitCmd("init", "--quiet")
+	irFile := filepath.Join(repoDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{"team":"foo"}}`), 0644))
+	gitCmd("add", ".")
+	gitCmd("commit", "--quiet", "-m", "initial")
+	gitCmd("tag", "1.0.0")
+	gitCmd("checkout", "--quiet", "-b", "develop")
+	commit := gitCmd("rev-parse", "HEAD")
+
+	// the asset copies the IR artifact outside of the repository so that the working tree stays clean
+	outDir := t.TempDir()
+	assetPath := filepath.Join(outDir, "publisher.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+irFile=$(echo "$2" | sed 's/.*"irFile":"\([^"]*\)".*/\1/')
+cp "$irFile" "`+outDir+`/published.json"
+`), 0755))
+	publish := func(git *conjureplugin.GitMetadataExtension) string {
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					Publish:    true,
+					Extensions: conjureplugin.ProjectExtensions{Git: git},
+				},
+			},
+			PublisherAsset: &conjureplugin.PublisherAsset{
+				Name: "ir-catalog",
+				Path: assetPath,
+			},
+		}
+		err := conjureplugin.Publish(params, repoDir, nil, false, ioutil.Discard)
+		require.NoError(t, err)
+		published, err := os.ReadFile(filepath.Join(outDir, "published.json"))
+		require.NoError(t, err)
+		return string(published)
+	}
+
+	assert.Equal(t, `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"git":{"branch":"develop","commit":"`+commit+`","dirty":false,"tag":"1.0.0"},"team":"foo"}}`, publish(&conjureplugin.GitMetadataExtension{}))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "untracked.txt"), []byte("untracked"), 0644))
+	assert.Equal(t, `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"source":{"commit":"`+commit+`","dirty":true},"team":"foo"}}`, publish(&conjureplugin.GitMetadataExtension{
+		Key:    "source",
+		Fields: []conjureplugin.GitMetadataField{conjureplugin.GitMetadataCommit, conjureplugin.GitMetadataDirty},
+	}))
+}
+
+func TestPublishBuildMetadataExtension(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishBuildMetadataExtension_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{}}`), 0644))
+	assetPath := filepath.Join(tmpDir, "publisher.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+irFile=$(echo "$2" | sed 's/.*"irFile":"\([^"]*\)".*/\1/')
+cp "$irFile" "`+tmpDir+`/published.json"
+`), 0755))
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "palantir/example")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+				Extensions: conjureplugin.ProjectExtensions{
+					Build: &conjureplugin.BuildMetadataExtension{},
+				},
+			},
+		},
+		PublisherAsset: &conjureplugin.PublisherAsset{
+			Name: "ir-catalog",
+			Path: assetPath,
+		},
+	}
+	err = conjureplugin.Publish(params, tmpDir, nil, false, ioutil.Discard, conjureplugin.PluginVersionParam("6.1.0"))
+	require.NoError(t, err)
+	published, err := os.ReadFile(filepath.Join(tmpDir, "published.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"build":{"ciJobUrl":"https://github.com/palantir/example/actions/runs/42","conjureVersion":"`+conjureircli.CLIVersion+`","pluginVersion":"6.1.0","timestamp":"2023-11-14T22:13:20Z"}}}`, string(published))
+}
+
+func TestPublishExtensionsFile(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishExtensionsFile_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{"team":"foo","owner":{"name":"foo","email":"foo@example.com"}}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "api-metadata.yml"), []byte(`team: bar
+owner:
+  name: bar
+recommended-product-dependencies:
+  - product-group: com.palantir.example
+    product-name: example
+    minimum-version: 1.0.0
+    maximum-version: 1.x.x
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "invalid.json"), []byte(`["not", "an", "object"]`), 0644))
+	assetPath := filepath.Join(tmpDir, "publisher.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+irFile=$(echo "$2" | sed 's/.*"irFile":"\([^"]*\)".*/\1/')
+cp "$irFile" "`+tmpDir+`/published.json"
+`), 0755))
+	publish := func(extensions conjureplugin.ProjectExtensions, stdout io.Writer) error {
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					Publish:    true,
+					Extensions: extensions,
+				},
+			},
+			PublisherAsset: &conjureplugin.PublisherAsset{
+				Name: "ir-catalog",
+				Path: assetPath,
+			},
+		}
+		return conjureplugin.Publish(params, tmpDir, nil, false, stdout)
+	}
+
+	const pdeps = `"recommended-product-dependencies":[{"maximum-version":"1.x.x","minimum-version":"1.0.0","product-group":"com.palantir.example","product-name":"example"}]`
+	for i, tc := range []struct {
+		strategy   conjureplugin.ExtensionsMergeStrategy
+		wantIR     string
+		wantOutput string
+	}{
+		{
+			strategy: "",
+			wantIR:   `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"owner":{"name":"bar"},` + pdeps + `,"team":"bar"}}`,
+			wantOutput: `Extensions of project-1:
+  owner: extensions-file api-metadata.yml
+  recommended-product-dependencies: extensions-file api-metadata.yml
+  team: extensions-file api-metadata.yml
+`,
+		},
+		{
+			strategy: conjureplugin.ExtensionsMergeFirstWins,
+			wantIR:   `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"owner":{"email":"foo@example.com","name":"foo"},` + pdeps + `,"team":"foo"}}`,
+			wantOutput: `Extensions of project-1:
+  owner: IR
+  recommended-product-dependencies: extensions-file api-metadata.yml
+  team: IR
+`,
+		},
+		{
+			strategy: conjureplugin.ExtensionsMergeDeep,
+			wantIR:   `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"owner":{"email":"foo@example.com","name":"bar"},` + pdeps + `,"team":"bar"}}`,
+			wantOutput: `Extensions of project-1:
+  owner: IR, extensions-file api-metadata.yml
+  recommended-product-dependencies: extensions-file api-metadata.yml
+  team: IR, extensions-file api-metadata.yml
+`,
+		},
+	} {
+		outputBuf := &bytes.Buffer{}
+		require.NoError(t, publish(conjureplugin.ProjectExtensions{
+			File:          "api-metadata.yml",
+			MergeStrategy: tc.strategy,
+		}, outputBuf), "Case %d", i)
+		published, err := os.ReadFile(filepath.Join(tmpDir, "published.json"))
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.wantIR, string(published), "Case %d", i)
+		assert.Equal(t, tc.wantOutput, outputBuf.String(), "Case %d", i)
+	}
+
+	err = publish(conjureplugin.ProjectExtensions{
+		File:          "api-metadata.yml",
+		MergeStrategy: conjureplugin.ExtensionsMergeError,
+	}, ioutil.Discard)
+	require.EqualError(t, err, "failed to merge extensions of project-1: extension owner is provided by both IR and extensions-file api-metadata.yml")
+
+	err = publish(conjureplugin.ProjectExtensions{File: "invalid.json"}, ioutil.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to determine extensions of project-1: extensions file "+filepath.Join(tmpDir, "invalid.json")+" must contain a JSON object")
+}
+
+func TestPublishExtensionsReproducible(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishExtensionsReproducible_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{"weights":{"b":1.50,"a":1E3,"c":-0},"team":"foo"}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "api-metadata.yml"), []byte(`limits:
+  requests: 12345678901234567890
+  ratio: 0.250
+  burst: 1e2
+weights:
+  d: 2.0
+`), 0644))
+	assetPath := filepath.Join(tmpDir, "publisher.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+irFile=$(echo "$2" | sed 's/.*"irFile":"\([^"]*\)".*/\1/')
+cp "$irFile" "`+tmpDir+`/published.json"
+`), 0755))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+				Publish:    true,
+				Extensions: conjureplugin.ProjectExtensions{
+					File:          "api-metadata.yml",
+					MergeStrategy: conjureplugin.ExtensionsMergeDeep,
+				},
+			},
+		},
+		PublisherAsset: &conjureplugin.PublisherAsset{
+			Name: "ir-catalog",
+			Path: assetPath,
+		},
+	}
+
+	var published []string
+	for i := 0; i < 5; i++ {
+		require.NoError(t, conjureplugin.Publish(params, tmpDir, nil, false, ioutil.Discard), "Run %d", i)
+		publishedBytes, err := os.ReadFile(filepath.Join(tmpDir, "published.json"))
+		require.NoError(t, err, "Run %d", i)
+		published = append(published, string(publishedBytes))
+	}
+	want := `{"version":1,"errors":[],"types":[],"services":[],"extensions":{"limits":{"burst":100,"ratio":0.25,"requests":12345678901234567890},"team":"foo","weights":{"a":1000,"b":1.5,"c":0,"d":2}}}`
+	for i, got := range published {
+		assert.Equal(t, want, got, "Run %d", i)
+	}
+}
+
+func TestPublishExtensionsSchema(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	tmpDir, err := ioutil.TempDir(cwd, "TestPublishExtensionsSchema_")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{"team":"foo"}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "extensions.schema.json"), []byte(`{
+  "type": "object",
+  "required": ["team", "owner"],
+  "properties": {
+    "team": {"type": "string", "enum": ["foo", "bar"]},
+    "owner": {"$ref": "#/definitions/owner"},
+    "recommended-product-dependencies": {
+      "type": "array",
+      "minItems": 1,
+      "items": {"type": "object", "required": ["product-group", "product-name", "minimum-version"]}
+    }
+  },
+  "additionalProperties": false,
+  "definitions": {
+    "owner": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string", "minLength": 1},
+        "email": {"type": "string", "pattern": "^[^@]+@[^@]+$"}
+      }
+    }
+  }
+}`), 0644))
+	assetPath := filepath.Join(tmpDir, "publisher.sh")
+	require.NoError(t, os.WriteFile(assetPath, []byte(`#!/bin/sh
+set -e
+irFile=$(echo "$2" | sed 's/.*"irFile":"\([^"]*\)".*/\1/')
+cp "$irFile" "`+tmpDir+`/published.json"
+`), 0755))
+
+	for i, tc := range []struct {
+		name       string
+		extensions string
+		wantErr    string
+	}{
+		{
+			name:       "valid extensions",
+			extensions: `{"owner": {"name": "foo", "email": "foo@example.com"}}`,
+		},
+		{
+			name:       "missing required extension",
+			extensions: `{}`,
+			wantErr: `extensions of project-1 do not match schema extensions.schema.json:
+  /: missing required property owner`,
+		},
+		{
+			name:       "multiple violations",
+			extensions: `{"team": "baz", "owner": {"name": "", "email": "foo"}, "recommended-product-dependencies": [{"product-group": "com.palantir.foo"}], "other": 1}`,
+			wantErr: `extensions of project-1 do not match schema extensions.schema.json:
+  /: property other is not allowed
+  /owner/email: must match pattern ^[^@]+@[^@]+$
+  /owner/name: must be at least 1 characters long
+  /recommended-product-dependencies/0: missing required property product-name
+  /recommended-product-dependencies/0: missing required property minimum-version
+  /team: must be one of ["foo","bar"]`,
+		},
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "extensions.json"), []byte(tc.extensions), 0644), "Case %d: %s", i, tc.name)
+		require.NoError(t, os.RemoveAll(filepath.Join(tmpDir, "published.json")), "Case %d: %s", i, tc.name)
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					OutputDir:  "conjure",
+					IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+					Publish:    true,
+					Extensions: conjureplugin.ProjectExtensions{
+						File:   "extensions.json",
+						Schema: "extensions.schema.json",
+					},
+				},
+			},
+			PublisherAsset: &conjureplugin.PublisherAsset{
+				Name: "ir-catalog",
+				Path: assetPath,
+			},
+		}
+		err := conjureplugin.Publish(params, tmpDir, nil, false, ioutil.Discard)
+		if tc.wantErr != "" {
+			require.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.name)
+			_, err := os.Stat(filepath.Join(tmpDir, "published.json"))
+			assert.True(t, os.IsNotExist(err), "Case %d: %s", i, tc.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		_, err = os.Stat(filepath.Join(tmpDir, "published.json"))
+		assert.NoError(t, err, "Case %d: %s", i, tc.name)
+	}
+}
+
+func TestPublishVerifyArtifacts(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		name    string
+		key     string
+		ir      string
+		wantErr []string
+	}{
+		{
+			name: "valid artifact",
+			key:  "project-1",
+			ir:   testIRJSON,
+		},
+		{
+			name:    "artifact that is not JSON",
+			key:     "project-1",
+			ir:      `not JSON`,
+			wantErr: []string{"IR artifact project-1-", "IR is not a JSON object"},
+		},
+		{
+			name: "artifact with duplicate types and malformed extensions",
+			key:  "project-1",
+			ir: `{
+  "version": 1,
+  "types": [
+    {"type": "alias", "alias": {"typeName": {"name": "Foo", "package": "com.palantir.foo"}, "alias": {"type": "primitive", "primitive": "STRING"}}},
+    {"type": "alias", "alias": {"typeName": {"name": "Foo", "package": "com.palantir.foo"}, "alias": {"type": "primitive", "primitive": "STRING"}}}
+  ],
+  "errors": [],
+  "services": [],
+  "extensions": {"recommended-product-dependencies": [{"product-group": "com.palantir.foo", "product-name": "foo"}]}
+}`,
+			wantErr: []string{
+				"com.palantir.foo.Foo is defined multiple times",
+				"recommended-product-dependencies extension entry 0 does not specify minimum-version",
+				"recommended-product-dependencies extension entry 0 does not specify maximum-version",
+			},
+		},
+		{
+			name:    "project name that is not a valid artifact ID",
+			key:     "project 1",
+			ir:      testIRJSON,
+			wantErr: []string{`project name "project 1" is not a valid artifact ID`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir, err := os.MkdirTemp(cwd, "TestPublishVerifyArtifacts_")
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, os.RemoveAll(tmpDir))
+			}()
+			irFile := filepath.Join(tmpDir, "ir.json")
+			require.NoError(t, os.WriteFile(irFile, []byte(tc.ir), 0644))
+
+			params := conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{tc.key},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					tc.key: {
+						OutputDir:  "conjure",
+						IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+						Publish:    true,
+					},
+				},
+			}
+			err = conjureplugin.Publish(params, tmpDir, map[distgo.PublisherFlagName]interface{}{
+				publisher.ConnectionInfoURLFlag.Name:     "http://artifactory.domain.com",
+				publisher.GroupIDFlag.Name:               "com.palantir.foo",
+				artifactory.PublisherRepositoryFlag.Name: "repo",
+			}, true, &bytes.Buffer{}, conjureplugin.VerifyArtifactsParam(true))
+			if len(tc.wantErr) == 0 {
+				assert.NoError(t, err, "Case %d", i)
+				return
+			}
+			require.Error(t, err, "Case %d", i)
+			for _, want := range tc.wantErr {
+				assert.Contains(t, err.Error(), want, "Case %d", i)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/pkg/safehttp"
+	"github.com/pkg/errors"
+)
+
+// PublishedBaseline specifies an Artifactory repository to which IR is published. BackCompat can resolve the baseline IR
+// of every project from it, and ExtensionsDiff compares the published extensions with the current extensions. The IR
+// resolved for a project is the IR of the latest release of the project published to the repository using the group ID,
+// which is the artifact published by Publish for the same repository and group ID.
+type PublishedBaseline struct {
+	// URL is the base URL of the Artifactory instance (for example, "https://artifactory.example.com").
+	URL string
+	// Repository is the name of the repository.
+	Repository string
+	// GroupID is the group ID of the published IR.
+	GroupID string
+	// Username and Password are the credentials used to access the repository. If Username is empty, the repository
+	// is accessed anonymously.
+	Username string
+	Password string
+}
+
+// PublishedBaselineParam returns a parameter that makes BackCompat resolve the baseline of every project from the
+// provided repository rather than compiling its definitions at a Git ref.
+func PublishedBaselineParam(baseline PublishedBaseline) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.publishedBaseline = &baseline
+		if a.baselineRepository == nil {
+			a.baselineRepository = &baseline
+		}
+	})
+}
+
+// baselineSource provides the baseline IR of projects for BackCompat.
+type baselineSource interface {
+	// baselineIR returns the baseline IR of the provided project and the version of the baseline, which identifies
+	// the baseline against which breaks are accepted. Returns nil if the project does not have a baseline.
+	baselineIR(projectName string, param ConjureProjectParam, projectDir string) ([]byte, string, error)
+	// missingReason returns the reason printed when a project does not have a baseline and the shorter form of it
+	// recorded in the summary.
+	missingReason() (string, string)
+	// String returns the description of the baseline used in output.
+	String() string
+}
+
+// publishedBaselineSource resolves the baseline IR of projects from an Artifactory repository.
+type publishedBaselineSource struct {
+	baseline PublishedBaseline
+	stdout   io.Writer
+}
+
+func (s *publishedBaselineSource) String() string {
+	return fmt.Sprintf("the latest releases in %s", s.baseline.Repository)
+}
+
+func (s *publishedBaselineSource) missingReason() (string, string) {
+	return fmt.Sprintf("no release has been published to %s", s.baseline.Repository), "no published release"
+}
+
+func (s *publishedBaselineSource) baselineIR(projectName string, param ConjureProjectParam, projectDir string) ([]byte, string, error) {
+	irBytes, version, err := latestPublishedIR(s.baseline, projectName)
+	if err != nil || irBytes == nil {
+		return nil, "", err
+	}
+	_, _ = fmt.Fprintf(s.stdout, "Using published version %s of %s as the baseline\n", version, projectName)
+	return irBytes, version, nil
+}
+
+// latestPublishedIR returns the IR and version of the latest release of the provided project published to the provided
+// repository. The release is determined from the maven-metadata.xml file of the project in the repository: it is the
+// version specified by the "release" element of the metadata or, if it is not specified, the last version in its
+// "versions" element. Returns nil if the project has not been released.
+func latestPublishedIR(repo PublishedBaseline, projectName string) ([]byte, string, error) {
+	projectURL := strings.Join([]string{
+		strings.TrimSuffix(repo.URL, "/"),
+		"artifactory",
+		repo.Repository,
+		strings.Replace(repo.GroupID, ".", "/", -1),
+		projectName,
+	}, "/")
+	if err := offline.Check("resolve the published IR of", projectName, ""); err != nil {
+		return nil, "", err
+	}
+	metadataBytes, err := fetchPublished(repo, projectURL+"/maven-metadata.xml")
+	if err != nil || metadataBytes == nil {
+		return nil, "", err
+	}
+	var metadata struct {
+		Versioning struct {
+			Release  string   `xml:"release"`
+			Versions []string `xml:"versions>version"`
+		} `xml:"versioning"`
+	}
+	if err := xml.Unmarshal(metadataBytes, &metadata); err != nil {
+		return nil, "", errors.Wrapf(err, "failed to parse maven-metadata.xml of %s", projectName)
+	}
+	version := metadata.Versioning.Release
+	if version == "" && len(metadata.Versioning.Versions) > 0 {
+		version = metadata.Versioning.Versions[len(metadata.Versioning.Versions)-1]
+	}
+	if version == "" {
+		return nil, "", nil
+	}
+
+	irBytes, err := publishedIR(repo, repo.GroupID, projectName, version)
+	if err != nil {
+		return nil, "", err
+	}
+	return irBytes, version, nil
+}
+
+// publishedIR returns the IR of the provided version of the artifact with the provided group and artifact ID published
+// to the provided repository. Returns an error if the IR does not exist.
+func publishedIR(repo PublishedBaseline, groupID, artifactID, version string) ([]byte, error) {
+	versionURL := strings.Join([]string{
+		strings.TrimSuffix(repo.URL, "/"),
+		"artifactory",
+		repo.Repository,
+		strings.Replace(groupID, ".", "/", -1),
+		artifactID,
+		version,
+	}, "/")
+	irURL := fmt.Sprintf("%s/%s-%s.conjure.json", versionURL, artifactID, version)
+	irBytes, err := fetchPublished(repo, irURL)
+	if err != nil {
+		return nil, err
+	}
+	if irBytes == nil {
+		return nil, errors.Errorf("IR of published version %s of %s does not exist at %s", version, artifactID, irURL)
+	}
+	// the shards of sharded IR are published alongside the index
+	return resolveIR(irBytes, 0, func(shardPath string, verify func([]byte) error) ([]byte, error) {
+		shardBytes, err := fetchPublished(repo, versionURL+"/"+shardPath)
+		if err == nil && shardBytes == nil {
+			err = errors.Errorf("%s/%s does not exist", versionURL, shardPath)
+		}
+		return shardBytes, err
+	})
+}
+
+// fetchPublished returns the content at the provided URL using the credentials of the provided repository. Returns nil
+// if the content does not exist.
+func fetchPublished(repo PublishedBaseline, fetchURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, fetchURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create request for %s", fetchURL)
+	}
+	if repo.Username != "" {
+		req.SetBasicAuth(repo.Username, repo.Password)
+	}
+	resp, cleanup, err := safehttp.Do(http.DefaultClient, req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer cleanup()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("expected response status 200 when fetching %s, but got %d", fetchURL, resp.StatusCode)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", fetchURL)
+	}
+	return content, nil
+}
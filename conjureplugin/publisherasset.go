@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/palantir/distgo/distgo"
+	"github.com/palantir/distgo/publisher"
+	"github.com/pkg/errors"
+)
+
+// PublisherAsset specifies a publisher asset that publishes IR to a custom destination (such as an internal catalog)
+// instead of an Artifactory repository. The asset is invoked once for every project that is published as
+// "<asset> publish <json>", where <json> is a JSON object with the keys "project", "groupId" (omitted if the group ID
+// flag is not specified), "artifactId", "version", "irFile" (the path of the IR artifact), "artifacts" (the paths of
+// all of the artifacts of the project, which are the IR artifact followed by its shards if the IR is published
+// sharded), "flags" (the values of the publish flags that were specified keyed by flag name), "dryRun" and "config"
+// (omitted if the asset is not configured). The output of the asset is written to stdout.
+type PublisherAsset struct {
+	// Name is the name of the asset.
+	Name string
+	// Path is the path of the asset. It is empty until the assets provided to the plugin are resolved.
+	Path string
+	// Config is the configuration for the asset as JSON. If empty, the "config" key is omitted.
+	Config []byte
+}
+
+type publishArgs struct {
+	Project    string                 `json:"project"`
+	GroupID    string                 `json:"groupId,omitempty"`
+	ArtifactID string                 `json:"artifactId"`
+	Version    string                 `json:"version"`
+	IRFile     string                 `json:"irFile"`
+	Artifacts  []string               `json:"artifacts"`
+	Flags      map[string]interface{} `json:"flags"`
+	DryRun     bool                   `json:"dryRun"`
+	Config     json.RawMessage        `json:"config,omitempty"`
+}
+
+// publishToAsset publishes the IR artifacts with the provided names in the provided directory (the first of which is the
+// IR artifact) for the provided project using the provided publisher asset.
+func publishToAsset(asset PublisherAsset, key string, param ConjureProjectParam, version, artifactDir string, artifactNames []string, flagVals map[distgo.PublisherFlagName]interface{}, dryRun bool, stdout io.Writer) error {
+	if asset.Path == "" {
+		return errors.Errorf("publisher asset %s is not provided to the plugin", asset.Name)
+	}
+	args := publishArgs{
+		Project:    key,
+		ArtifactID: key,
+		Version:    version,
+		Flags:      make(map[string]interface{}),
+		DryRun:     dryRun,
+		Config:     asset.Config,
+	}
+	if err := publisher.SetConfigValue(flagVals, publisher.GroupIDFlag, &args.GroupID); err != nil {
+		return err
+	}
+	for _, artifactName := range artifactNames {
+		artifactPath, err := filepath.Abs(filepath.Join(artifactDir, artifactName))
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		args.Artifacts = append(args.Artifacts, artifactPath)
+	}
+	args.IRFile = args.Artifacts[0]
+	for name, val := range flagVals {
+		args.Flags[string(name)] = val
+	}
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	cmd := exec.Command(asset.Path, "publish", string(argsJSON))
+	cmd.Env = append(os.Environ(), envSlice(param.Env)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stdout
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "publisher asset %s failed to publish %s", asset.Name, key)
+	}
+	return nil
+}
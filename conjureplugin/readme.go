@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/conjure-go/v6/conjure/types"
+	"github.com/pkg/errors"
+)
+
+// readmeFileName is the name of the file that describes the code generated for a project.
+const readmeFileName = "README.conjure.md"
+
+// renderReadmeFile returns a Markdown file written to the root of the output directory of the provided project that
+// describes the packages generated for the project, its owners, the source of its IR, the flags used to generate it and
+// how to regenerate it. The content only depends on the definition and the configuration of the project so that it is
+// stable across runs.
+func renderReadmeFile(projectName string, param ConjureProjectParam, conjureDef spec.ConjureDefinition, outputDir string) (renderedFile, error) {
+	def, err := types.NewConjureDefinition(outputDir, conjureDef)
+	if err != nil {
+		return renderedFile{}, errors.Wrapf(err, "invalid configuration")
+	}
+	var pkgNames []string
+	for pkgName := range def.Packages {
+		pkgNames = append(pkgNames, pkgName)
+	}
+	sort.Slice(pkgNames, func(i, j int) bool {
+		return packagePath(param.PackagePaths, pkgNames[i]) < packagePath(param.PackagePaths, pkgNames[j])
+	})
+
+	buf := &bytes.Buffer{}
+	_, _ = fmt.Fprintf(buf, "# %s\n\n", projectName)
+	_, _ = fmt.Fprintf(buf, "This directory contains Go code generated by [conjure-go](https://github.com/palantir/conjure-go) for the Conjure project `%s`. ", projectName)
+	_, _ = fmt.Fprintf(buf, "This file was generated by Conjure and should not be manually edited. ")
+	_, _ = fmt.Fprintf(buf, "Do not modify the generated code: change the Conjure definitions or the project configuration and regenerate the code by running `./godelw conjure`.\n\n")
+
+	if len(param.Owners) > 0 {
+		_, _ = fmt.Fprintf(buf, "## Owners\n\n")
+		for _, owner := range param.Owners {
+			_, _ = fmt.Fprintf(buf, "* %s\n", owner)
+		}
+		_, _ = fmt.Fprintf(buf, "\n")
+	}
+	_, _ = fmt.Fprintf(buf, "## Source\n\n")
+	_, _ = fmt.Fprintf(buf, "* IR: %s\n\n", irProviderDescription(param.IRProvider))
+
+	_, _ = fmt.Fprintf(buf, "## Generation flags\n\n")
+	for _, flag := range []struct {
+		name string
+		val  bool
+	}{
+		{"server", param.Server},
+		{"cli", param.CLI},
+		{"accept-funcs", param.AcceptFuncs},
+		{"client-options", param.ClientOptions},
+		{"endpoint-constants", param.EndpointConstants},
+	} {
+		_, _ = fmt.Fprintf(buf, "* %s: %t\n", flag.name, flag.val)
+	}
+	if len(param.ClientConstructors) > 0 {
+		_, _ = fmt.Fprintf(buf, "* client-constructors: %s\n", strings.Join(sortedClientConstructorServices(param.ClientConstructors), ", "))
+	}
+	_, _ = fmt.Fprintf(buf, "\n## Packages\n\n")
+	if len(pkgNames) == 0 {
+		_, _ = fmt.Fprintf(buf, "The project does not define any types, errors or services.\n")
+	} else {
+		_, _ = fmt.Fprintf(buf, "| Directory | Conjure package | Types | Errors | Services |\n")
+		_, _ = fmt.Fprintf(buf, "| --- | --- | --- | --- | --- |\n")
+		for _, pkgName := range pkgNames {
+			pkg := def.Packages[pkgName]
+			var services []string
+			for _, service := range pkg.Services {
+				services = append(services, service.Name)
+			}
+			numTypes := len(pkg.Aliases) + len(pkg.Enums) + len(pkg.Objects) + len(pkg.Unions)
+			_, _ = fmt.Fprintf(buf, "| `%s` | `%s` | %d | %d | %s |\n", packagePath(param.PackagePaths, pkgName), pkg.ConjurePackage, numTypes, len(pkg.Errors), strings.Join(services, ", "))
+		}
+	}
+	return renderedFile{
+		absPath: filepath.Join(outputDir, readmeFileName),
+		content: buf.Bytes(),
+	}, nil
+}
+
+func sortedClientConstructorServices(constructors map[string]ClientConstructor) []string {
+	var services []string
+	for service := range constructors {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// generatedFileSuffix is the suffix of all Go files generated by conjure-go.
+const generatedFileSuffix = ".conjure.go"
+
+// isGeneratedFileName returns true if the provided file name is the name of a file generated by the plugin.
+func isGeneratedFileName(name string) bool {
+	return isGeneratedGoFile(name) || name == readmeFileName || name == irSnapshotFileName
+}
+
+// RenamedFrom describes a previous name of a project.
+type RenamedFrom struct {
+	// Name is the previous name of the project.
+	Name string
+	// OutputDir is the output directory that was used by the project under its previous name. If it is non-empty and
+	// differs from the current output directory of the project, any generated files that remain in it are removed by
+	// generation and reported by verification.
+	OutputDir string
+	// PublishRelocationPOM specifies whether publishing the project should also publish a POM for the previous name
+	// that relocates it to the current name.
+	PublishRelocationPOM bool
+}
+
+// leftoverGeneratedFiles returns the paths (relative to projectDir) of all of the generated files in the output
+// directories of the previous names of the provided project that were not generated by the current run. The returned
+// paths are sorted.
+func leftoverGeneratedFiles(param ConjureProjectParam, projectDir string, currentFiles map[string]struct{}) ([]string, error) {
+	var leftovers []string
+	for _, renamedFrom := range param.RenamedFrom {
+		if renamedFrom.OutputDir == "" || filepath.Clean(renamedFrom.OutputDir) == filepath.Clean(param.OutputDir) {
+			continue
+		}
+		oldOutputDir := filepath.Join(projectDir, renamedFrom.OutputDir)
+		if _, err := os.Stat(oldOutputDir); os.IsNotExist(err) {
+			continue
+		}
+		if err := filepath.WalkDir(oldOutputDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !isGeneratedFileName(d.Name()) {
+				return nil
+			}
+			if _, ok := currentFiles[path]; ok {
+				return nil
+			}
+			relPath, err := filepath.Rel(projectDir, path)
+			if err != nil {
+				return err
+			}
+			leftovers = append(leftovers, relPath)
+			return nil
+		}); err != nil {
+			return nil, errors.Wrapf(err, "failed to find generated files in output directory %s of previous project %s", renamedFrom.OutputDir, renamedFrom.Name)
+		}
+	}
+	sort.Strings(leftovers)
+	return leftovers, nil
+}
+
+// removeLeftoverGeneratedFiles removes the provided files (specified relative to projectDir) along with any parent
+// directories that are empty after the removal.
+func removeLeftoverGeneratedFiles(leftovers []string, projectDir string) error {
+	projectDir = filepath.Clean(projectDir)
+	for _, leftover := range leftovers {
+		leftoverPath := filepath.Join(projectDir, leftover)
+		if err := os.Remove(leftoverPath); err != nil {
+			return errors.Wrapf(err, "failed to remove generated file %s", leftover)
+		}
+		for dir := filepath.Dir(leftoverPath); dir != projectDir && strings.HasPrefix(dir, projectDir); dir = filepath.Dir(dir) {
+			entries, err := os.ReadDir(dir)
+			if err != nil || len(entries) > 0 {
+				break
+			}
+			if err := os.Remove(dir); err != nil {
+				return errors.Wrapf(err, "failed to remove empty directory %s", dir)
+			}
+		}
+	}
+	return nil
+}
+
+// relocationPOM returns the content of a Maven POM that relocates the artifact with the provided previous name to the
+// artifact with the provided new name.
+func relocationPOM(groupID, oldName, newName, version string) string {
+	return fmt.Sprintf(`<project xmlns="http://maven.apache.org/POM/4.0.0" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+  xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 http://maven.apache.org/xsd/maven-4.0.0.xsd">
+  <modelVersion>4.0.0</modelVersion>
+
+  <groupId>%s</groupId>
+  <artifactId>%s</artifactId>
+  <version>%s</version>
+  <distributionManagement>
+    <relocation>
+      <artifactId>%s</artifactId>
+      <message>%s has been renamed to %s</message>
+    </relocation>
+  </distributionManagement>
+</project>
+`, groupID, oldName, version, newName, oldName, newName)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// route describes the HTTP route of a single endpoint.
+type route struct {
+	// Service is the qualified name of the service.
+	Service string `json:"service" yaml:"service"`
+	// Endpoint is the name of the endpoint.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+	// Method is the HTTP method of the endpoint.
+	Method string `json:"method" yaml:"method"`
+	// Path is the HTTP path template of the endpoint.
+	Path string `json:"path" yaml:"path"`
+	// Auth is the authentication required by the endpoint: "none", "header" or "cookie:<cookie name>".
+	Auth string `json:"auth" yaml:"auth"`
+}
+
+type routesFile struct {
+	Routes []route `json:"routes" yaml:"routes"`
+}
+
+// renderRoutesFile returns a file that lists the HTTP routes of all of the endpoints of the provided definition. The
+// file is written to routesFilePath (relative to projectDir) and is rendered as YAML if the path has a ".yml" or
+// ".yaml" extension and as JSON otherwise. Services are sorted by their qualified name and endpoints are listed in
+// the order in which they are defined.
+func renderRoutesFile(def spec.ConjureDefinition, routesFilePath, projectDir string) (renderedFile, error) {
+	services := append([]spec.ServiceDefinition(nil), def.Services...)
+	sort.SliceStable(services, func(i, j int) bool {
+		return qualifiedName(services[i].ServiceName) < qualifiedName(services[j].ServiceName)
+	})
+	routes := routesFile{
+		Routes: []route{},
+	}
+	for _, service := range services {
+		for _, endpoint := range service.Endpoints {
+			routes.Routes = append(routes.Routes, route{
+				Service:  qualifiedName(service.ServiceName),
+				Endpoint: string(endpoint.EndpointName),
+				Method:   endpoint.HttpMethod.String(),
+				Path:     string(endpoint.HttpPath),
+				Auth:     authDescription(endpoint.Auth),
+			})
+		}
+	}
+
+	var content []byte
+	switch ext := strings.ToLower(filepath.Ext(routesFilePath)); ext {
+	case ".yml", ".yaml":
+		yamlBytes, err := yaml.Marshal(routes)
+		if err != nil {
+			return renderedFile{}, errors.Wrapf(err, "failed to marshal routes as YAML")
+		}
+		content = yamlBytes
+	default:
+		jsonBytes, err := json.MarshalIndent(routes, "", "  ")
+		if err != nil {
+			return renderedFile{}, errors.Wrapf(err, "failed to marshal routes as JSON")
+		}
+		content = append(jsonBytes, '\n')
+	}
+	return renderedFile{
+		absPath: filepath.Join(projectDir, routesFilePath),
+		content: content,
+	}, nil
+}
+
+func authDescription(auth *spec.AuthType) string {
+	if auth == nil {
+		return "none"
+	}
+	description := "unknown"
+	_ = auth.AcceptFuncs(
+		func(spec.HeaderAuthType) error {
+			description = "header"
+			return nil
+		},
+		func(cookie spec.CookieAuthType) error {
+			description = "cookie:" + cookie.CookieName
+			return nil
+		},
+		auth.ErrorOnUnknown,
+	)
+	return description
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifRootBaseID is the URI base ID against which the paths of the files in results are resolved.
+	sarifRootBaseID = "PROJECTROOT"
+
+	sarifToolName           = "godel-conjure-plugin"
+	sarifToolInformationURI = "https://github.com/palantir/godel-conjure-plugin"
+
+	sarifVerifyRulePrefix     = "conjure-verify/"
+	sarifBackCompatRulePrefix = "conjure-backcompat/"
+	// sarifVerifyMessageRule is the rule of the differences found by verification that are not specific to a file.
+	sarifVerifyMessageRule = sarifVerifyRulePrefix + "out-of-date"
+	sarifLevelError        = "error"
+)
+
+// SARIFOptions configures the SARIF logs written by WriteVerifySARIF and WriteBackCompatSARIF.
+type SARIFOptions struct {
+	// ToolVersion is the version of the plugin recorded in the log.
+	ToolVersion string
+	// RootDir is the absolute path of the directory against which the paths in the log are resolved (the project
+	// directory or the output root). If empty, the directory is not recorded, so the log does not depend on the
+	// environment in which it is produced.
+	RootDir string
+	// ConfigFile is the slash-separated path of the configuration file relative to RootDir. Results that are not
+	// specific to a file (such as backcompat breaks) are located in it. If empty, such results do not have a location.
+	ConfigFile string
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// WriteVerifySARIF writes the differences recorded in the provided verify report as a SARIF 2.1.0 log to the provided
+// writer. Every file that differs is a result located in the file, and every message is a result located in the
+// configuration file. Results are reported at the "error" level.
+func WriteVerifySARIF(w io.Writer, report *VerifyReport, opts SARIFOptions) error {
+	rules := map[string]string{
+		sarifVerifyRulePrefix + string(FileChangeMissing):  "Generated file does not exist",
+		sarifVerifyRulePrefix + string(FileChangeModified): "Generated file is out of date",
+		sarifVerifyRulePrefix + string(FileChangeExtra):    "File is no longer generated",
+		sarifVerifyMessageRule:                             "Generated code is out of date",
+	}
+	var results []sarifResult
+	if report != nil {
+		for _, project := range report.Projects {
+			for _, file := range project.Files {
+				results = append(results, sarifResult{
+					RuleID:    sarifVerifyRulePrefix + string(file.Change),
+					Level:     sarifLevelError,
+					Message:   sarifMessage{Text: verifySARIFMessage(project, file)},
+					Locations: []sarifLocation{opts.location(project.Project, file.Path)},
+				})
+			}
+			for _, msg := range project.Messages {
+				results = append(results, sarifResult{
+					RuleID:    sarifVerifyMessageRule,
+					Level:     sarifLevelError,
+					Message:   sarifMessage{Text: fmt.Sprintf("Generated code of %s is out of date: %s", project.Project, msg)},
+					Locations: []sarifLocation{opts.location(project.Project, opts.ConfigFile)},
+				})
+			}
+		}
+	}
+	return opts.write(w, rules, results)
+}
+
+// verifySARIFMessage returns the message of the result for the provided file of the provided project.
+func verifySARIFMessage(project VerifyProjectReport, file VerifyFileDiff) string {
+	var msg string
+	switch file.Change {
+	case FileChangeMissing:
+		msg = fmt.Sprintf("%s is generated for %s but does not exist.", file.Path, project.Project)
+	case FileChangeExtra:
+		msg = fmt.Sprintf("%s is no longer generated for %s and should be removed.", file.Path, project.Project)
+	default:
+		msg = fmt.Sprintf("%s differs from the code generated for %s.", file.Path, project.Project)
+	}
+	msg += " Run ./godelw conjure to update the generated code."
+	if len(project.Owners) > 0 {
+		msg += " " + ownersContact(project.Owners)
+	}
+	return msg
+}
+
+// WriteBackCompatSARIF writes the breaks recorded in the provided backcompat report as a SARIF 2.1.0 log to the
+// provided writer. Every break that has not been accepted is a result located in the configuration file whose rule is
+// determined by its category and whose level is determined by its severity ("error" or "warning"). The ID of the break
+// is recorded as a fingerprint so that the result is tracked across runs.
+func WriteBackCompatSARIF(w io.Writer, report *BackCompatReport, opts SARIFOptions) error {
+	rules := make(map[string]string)
+	var results []sarifResult
+	if report != nil {
+		for _, project := range report.Projects {
+			for _, projectBreak := range project.Breaks {
+				ruleID := sarifBackCompatRulePrefix + projectBreak.Category
+				rules[ruleID] = fmt.Sprintf("Backwards incompatible change (%s)", projectBreak.Category)
+				level := projectBreak.Severity
+				if level == "" {
+					level = BackCompatSeverityError
+				}
+				results = append(results, sarifResult{
+					RuleID:    ruleID,
+					Level:     string(level),
+					Message:   sarifMessage{Text: fmt.Sprintf("%s is not backwards compatible with %s: %s", project.Project, project.Baseline, projectBreak)},
+					Locations: []sarifLocation{opts.location(project.Project, opts.ConfigFile)},
+					PartialFingerprints: map[string]string{
+						"backCompatBreakId": projectBreak.ID,
+					},
+				})
+			}
+		}
+	}
+	return opts.write(w, rules, results)
+}
+
+// location returns the location of a result for the provided project in the file with the provided slash-separated
+// path relative to the root directory. The location does not have a physical location if the path is empty.
+func (o SARIFOptions) location(projectName, path string) sarifLocation {
+	loc := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{
+			Name: projectName,
+			Kind: "module",
+		}},
+	}
+	if path != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{
+				URI:       (&url.URL{Path: path}).String(),
+				URIBaseID: sarifRootBaseID,
+			},
+		}
+	}
+	return loc
+}
+
+// write writes a log with a single run that has the provided rules (descriptions keyed by ID) and results.
+func (o SARIFOptions) write(w io.Writer, rules map[string]string, results []sarifResult) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           sarifToolName,
+				Version:        o.ToolVersion,
+				InformationURI: sarifToolInformationURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: results,
+	}
+	var ruleIDs []string
+	for id := range rules {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+	for _, id := range ruleIDs {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: rules[id]},
+		})
+	}
+	if run.Results == nil {
+		run.Results = []sarifResult{}
+	}
+	if o.RootDir != "" {
+		rootURL := url.URL{Scheme: "file", Path: filepath.ToSlash(o.RootDir)}
+		if !strings.HasSuffix(rootURL.Path, "/") {
+			rootURL.Path += "/"
+		}
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{
+			sarifRootBaseID: {URI: rootURL.String()},
+		}
+	}
+	jsonBytes, err := json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal SARIF log")
+	}
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return errors.WithStack(err)
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteVerifySARIF(t *testing.T) {
+	report := &conjureplugin.VerifyReport{
+		Projects: []conjureplugin.VerifyProjectReport{
+			{
+				Project: "project-1",
+				Files: []conjureplugin.VerifyFileDiff{
+					{Path: "outputDir/api/structs.conjure.go", Change: conjureplugin.FileChangeModified},
+				},
+				Messages: []string{"frozen project changed"},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.WriteVerifySARIF(buf, report, conjureplugin.SARIFOptions{
+		ToolVersion: "1.0.0",
+		RootDir:     "/project dir",
+		ConfigFile:  "godel/config/conjure-plugin.yml",
+	}))
+	assert.Equal(t, `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "godel-conjure-plugin",
+          "version": "1.0.0",
+          "informationUri": "https://github.com/palantir/godel-conjure-plugin",
+          "rules": [
+            {
+              "id": "conjure-verify/extra",
+              "shortDescription": {
+                "text": "File is no longer generated"
+              }
+            },
+            {
+              "id": "conjure-verify/missing",
+              "shortDescription": {
+                "text": "Generated file does not exist"
+              }
+            },
+            {
+              "id": "conjure-verify/modified",
+              "shortDescription": {
+                "text": "Generated file is out of date"
+              }
+            },
+            {
+              "id": "conjure-verify/out-of-date",
+              "shortDescription": {
+                "text": "Generated code is out of date"
+              }
+            }
+          ]
+        }
+      },
+      "originalUriBaseIds": {
+        "PROJECTROOT": {
+          "uri": "file:///project%20dir/"
+        }
+      },
+      "results": [
+        {
+          "ruleId": "conjure-verify/modified",
+          "level": "error",
+          "message": {
+            "text": "outputDir/api/structs.conjure.go differs from the code generated for project-1. Run ./godelw conjure to update the generated code."
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "outputDir/api/structs.conjure.go",
+                  "uriBaseId": "PROJECTROOT"
+                }
+              },
+              "logicalLocations": [
+                {
+                  "name": "project-1",
+                  "kind": "module"
+                }
+              ]
+            }
+          ]
+        },
+        {
+          "ruleId": "conjure-verify/out-of-date",
+          "level": "error",
+          "message": {
+            "text": "Generated code of project-1 is out of date: frozen project changed"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "godel/config/conjure-plugin.yml",
+                  "uriBaseId": "PROJECTROOT"
+                }
+              },
+              "logicalLocations": [
+                {
+                  "name": "project-1",
+                  "kind": "module"
+                }
+              ]
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`, buf.String())
+}
+
+func TestWriteBackCompatSARIF(t *testing.T) {
+	report := &conjureplugin.BackCompatReport{
+		Projects: []conjureplugin.BackCompatProjectReport{
+			{
+				Project:  "project-1",
+				Status:   conjureplugin.ProjectStatusFailed,
+				Baseline: "origin/develop",
+				Breaks: []conjureplugin.BackCompatBreak{
+					{
+						ID:          "3f0c2d81e4",
+						Checker:     "builtin",
+						Category:    "removed-endpoint",
+						Description: "endpoint com.palantir.example.Service.get was removed",
+						Severity:    conjureplugin.BackCompatSeverityWarning,
+					},
+				},
+				AcceptedBreaks: []conjureplugin.BackCompatBreak{
+					{
+						ID:          "4a1b2c3d4e",
+						Checker:     "builtin",
+						Category:    "removed-type",
+						Description: "type com.palantir.example.Object was removed",
+						Severity:    conjureplugin.BackCompatSeverityError,
+					},
+				},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.WriteBackCompatSARIF(buf, report, conjureplugin.SARIFOptions{}))
+	// accepted breaks are not reported and results do not have a physical location if the configuration file is not
+	// known
+	assert.Equal(t, `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "godel-conjure-plugin",
+          "informationUri": "https://github.com/palantir/godel-conjure-plugin",
+          "rules": [
+            {
+              "id": "conjure-backcompat/removed-endpoint",
+              "shortDescription": {
+                "text": "Backwards incompatible change (removed-endpoint)"
+              }
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "conjure-backcompat/removed-endpoint",
+          "level": "warning",
+          "message": {
+            "text": "project-1 is not backwards compatible with origin/develop: [3f0c2d81e4] endpoint com.palantir.example.Service.get was removed"
+          },
+          "locations": [
+            {
+              "logicalLocations": [
+                {
+                  "name": "project-1",
+                  "kind": "module"
+                }
+              ]
+            }
+          ],
+          "partialFingerprints": {
+            "backCompatBreakId": "3f0c2d81e4"
+          }
+        }
+      ]
+    }
+  ]
+}
+`, buf.String())
+}
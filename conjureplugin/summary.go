@@ -0,0 +1,306 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OperationParam configures an operation (such as Run or Publish).
+type OperationParam interface {
+	apply(*operationArgs)
+}
+
+type operationArgs struct {
+	summary            *Summary
+	outputRoot         string
+	verifyArtifacts    bool
+	parallelism        int
+	incremental        bool
+	verifyReport       *VerifyReport
+	verifyReportText   bool
+	verifyPatch        io.Writer
+	dryRun             bool
+	pluginVersion      string
+	irValidators       []IRValidator
+	publishedBaseline  *PublishedBaseline
+	baselineRepository *PublishedBaseline
+	backCompatReport   *BackCompatReport
+	acceptBreakIDs     []string
+}
+
+type operationParamFn func(*operationArgs)
+
+func (fn operationParamFn) apply(a *operationArgs) {
+	fn(a)
+}
+
+// SummaryParam returns a parameter that records the outcome of the operation for every project in the provided
+// summary.
+func SummaryParam(summary *Summary) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.summary = summary
+	})
+}
+
+func newOperationArgs(params []OperationParam) operationArgs {
+	var args operationArgs
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		param.apply(&args)
+	}
+	return args
+}
+
+// ProjectStatus is the outcome of an operation for a project.
+type ProjectStatus string
+
+const (
+	ProjectStatusSucceeded = ProjectStatus("succeeded")
+	ProjectStatusFailed    = ProjectStatus("failed")
+	ProjectStatusSkipped   = ProjectStatus("skipped")
+)
+
+// ProjectSummary is the outcome of an operation for a single project.
+type ProjectSummary struct {
+	Project            string
+	Status             ProjectStatus
+	Duration           time.Duration
+	FilesWritten       int
+	FilesDeleted       int
+	ArtifactsPublished int
+	// Owners are the owners of the project, if any.
+	Owners []string
+	// Message optionally describes the status (such as the reason that the project was skipped or failed).
+	Message string
+}
+
+// Summary records the outcome of an operation for every project. The methods of a nil *Summary are no-ops.
+type Summary struct {
+	Command  string
+	Projects []ProjectSummary
+	Duration time.Duration
+
+	start time.Time
+}
+
+// NewSummary returns a new summary for the command with the provided name. The duration of the command is measured
+// from the time the summary is created until Finish is called.
+func NewSummary(command string) *Summary {
+	return &Summary{
+		Command: command,
+		start:   time.Now(),
+	}
+}
+
+// Skip records that the projects with the provided names were skipped for the provided reason.
+func (s *Summary) Skip(projects []string, reason string) {
+	for _, project := range projects {
+		s.add(ProjectSummary{
+			Project: project,
+			Status:  ProjectStatusSkipped,
+			Message: reason,
+		})
+	}
+}
+
+// Finish records the duration of the command.
+func (s *Summary) Finish() {
+	if s == nil {
+		return
+	}
+	s.Duration = time.Since(s.start)
+}
+
+func (s *Summary) add(projects ...ProjectSummary) {
+	if s == nil {
+		return
+	}
+	s.Projects = append(s.Projects, projects...)
+}
+
+// counts returns the number of projects with each status.
+func (s *Summary) counts() map[ProjectStatus]int {
+	counts := make(map[ProjectStatus]int)
+	for _, project := range s.Projects {
+		counts[project.Status]++
+	}
+	return counts
+}
+
+// Print prints the summary as a table to the provided writer.
+func (s *Summary) Print(w io.Writer) {
+	if s == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Summary of %s:\n", s.Command)
+	if len(s.Projects) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  PROJECT\tSTATUS\tDURATION\tWRITTEN\tDELETED\tPUBLISHED\tMESSAGE")
+		for _, project := range s.Projects {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%d\t%d\t%d\t%s\n", project.Project, project.Status, formatDuration(project.Duration), project.FilesWritten, project.FilesDeleted, project.ArtifactsPublished, firstLine(project.Message))
+		}
+		_ = tw.Flush()
+	}
+	counts := s.counts()
+	_, _ = fmt.Fprintf(w, "  %d projects: %d succeeded, %d skipped, %d failed in %s\n", len(s.Projects), counts[ProjectStatusSucceeded], counts[ProjectStatusSkipped], counts[ProjectStatusFailed], formatDuration(s.Duration))
+}
+
+type jsonProjectSummary struct {
+	Project            string        `json:"project"`
+	Status             ProjectStatus `json:"status"`
+	DurationMillis     int64         `json:"durationMillis"`
+	FilesWritten       int           `json:"filesWritten"`
+	FilesDeleted       int           `json:"filesDeleted"`
+	ArtifactsPublished int           `json:"artifactsPublished"`
+	Owners             []string      `json:"owners,omitempty"`
+	Message            string        `json:"message,omitempty"`
+}
+
+type jsonSummary struct {
+	Command        string               `json:"command"`
+	DurationMillis int64                `json:"durationMillis"`
+	Processed      int                  `json:"processed"`
+	Succeeded      int                  `json:"succeeded"`
+	Skipped        int                  `json:"skipped"`
+	Failed         int                  `json:"failed"`
+	Projects       []jsonProjectSummary `json:"projects"`
+}
+
+// PrintJSON prints the summary as a single line of JSON to the provided writer.
+func (s *Summary) PrintJSON(w io.Writer) error {
+	if s == nil {
+		return nil
+	}
+	counts := s.counts()
+	out := jsonSummary{
+		Command:        s.Command,
+		DurationMillis: s.Duration.Milliseconds(),
+		Processed:      len(s.Projects),
+		Succeeded:      counts[ProjectStatusSucceeded],
+		Skipped:        counts[ProjectStatusSkipped],
+		Failed:         counts[ProjectStatusFailed],
+		Projects:       []jsonProjectSummary{},
+	}
+	for _, project := range s.Projects {
+		out.Projects = append(out.Projects, jsonProjectSummary{
+			Project:            project.Project,
+			Status:             project.Status,
+			DurationMillis:     project.Duration.Milliseconds(),
+			FilesWritten:       project.FilesWritten,
+			FilesDeleted:       project.FilesDeleted,
+			ArtifactsPublished: project.ArtifactsPublished,
+			Owners:             project.Owners,
+			Message:            project.Message,
+		})
+	}
+	jsonBytes, err := json.Marshal(out)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal summary")
+	}
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return errors.WithStack(err)
+}
+
+// projectSummaries tracks the outcome of an operation for a fixed set of projects. Projects are skipped unless their
+// outcome is recorded.
+type projectSummaries struct {
+	projects []ProjectSummary
+	start    time.Time
+	current  int
+}
+
+func newProjectSummaries(params ConjureProjectParams) *projectSummaries {
+	out := &projectSummaries{
+		current: -1,
+	}
+	for _, project := range params.SortedKeys {
+		out.projects = append(out.projects, ProjectSummary{
+			Project: project,
+			Status:  ProjectStatusSkipped,
+			Owners:  params.Params[project].Owners,
+			Message: "not processed",
+		})
+	}
+	return out
+}
+
+// begin records that processing of the project at the provided index has started.
+func (p *projectSummaries) begin(i int) {
+	p.beginAt(i, time.Now())
+}
+
+// beginAt records that processing of the project at the provided index started at the provided time.
+func (p *projectSummaries) beginAt(i int, start time.Time) {
+	p.current = i
+	p.start = start
+}
+
+// end records that processing of the current project finished with the provided status and message.
+func (p *projectSummaries) end(status ProjectStatus, message string) {
+	if p.current < 0 {
+		return
+	}
+	p.projects[p.current].Status = status
+	p.projects[p.current].Message = message
+	p.projects[p.current].Duration = time.Since(p.start)
+	p.current = -1
+}
+
+// fail records that the project at the provided index failed with the provided message.
+func (p *projectSummaries) fail(i int, message string) {
+	p.projects[i].Status = ProjectStatusFailed
+	p.projects[i].Message = message
+}
+
+// finish records the outcome of the current project (if any) as failed with the provided error if it is non-nil and
+// adds the summaries to the provided summary. Returns the provided error, which is a *ProjectError that names the owners
+// of the current project if it has any.
+func (p *projectSummaries) finish(err error, summary *Summary) error {
+	if p.current >= 0 {
+		err = withOwners(err, p.projects[p.current].Owners)
+		message := ""
+		if err != nil {
+			message = err.Error()
+			err = &ProjectError{
+				Project: p.projects[p.current].Project,
+				Err:     err,
+			}
+		}
+		p.end(ProjectStatusFailed, message)
+	}
+	summary.add(p.projects...)
+	return err
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+func firstLine(s string) string {
+	if idx := strings.Index(s, "\n"); idx != -1 {
+		return s[:idx]
+	}
+	return s
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	dir := t.TempDir()
+	irFile := filepath.Join(dir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+			"project-2": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(dir, "missing.json")),
+			},
+		},
+	}
+
+	summary := conjureplugin.NewSummary("conjure-write-ir")
+	summary.Skip([]string{"project-0"}, "unchanged since origin/develop")
+	err := conjureplugin.WriteIR(params, "", filepath.Join(dir, "out"), &bytes.Buffer{}, conjureplugin.SummaryParam(summary))
+	require.Error(t, err)
+	summary.Finish()
+
+	require.Len(t, summary.Projects, 3)
+	assert.Equal(t, "project-0", summary.Projects[0].Project)
+	assert.Equal(t, conjureplugin.ProjectStatusSkipped, summary.Projects[0].Status)
+	assert.Equal(t, "project-1", summary.Projects[1].Project)
+	assert.Equal(t, conjureplugin.ProjectStatusSucceeded, summary.Projects[1].Status)
+	assert.Equal(t, 1, summary.Projects[1].FilesWritten)
+	assert.Equal(t, "project-2", summary.Projects[2].Project)
+	assert.Equal(t, conjureplugin.ProjectStatusFailed, summary.Projects[2].Status)
+	assert.NotEmpty(t, summary.Projects[2].Message)
+
+	buf := &bytes.Buffer{}
+	summary.Print(buf)
+	assert.Contains(t, buf.String(), "Summary of conjure-write-ir:\n")
+	assert.Contains(t, buf.String(), "unchanged since origin/develop")
+	assert.Contains(t, buf.String(), "3 projects: 1 succeeded, 1 skipped, 1 failed in ")
+
+	buf = &bytes.Buffer{}
+	require.NoError(t, summary.PrintJSON(buf))
+	var out struct {
+		Command   string `json:"command"`
+		Processed int    `json:"processed"`
+		Succeeded int    `json:"succeeded"`
+		Skipped   int    `json:"skipped"`
+		Failed    int    `json:"failed"`
+		Projects  []struct {
+			Project      string `json:"project"`
+			Status       string `json:"status"`
+			FilesWritten int    `json:"filesWritten"`
+		} `json:"projects"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, "conjure-write-ir", out.Command)
+	assert.Equal(t, 3, out.Processed)
+	assert.Equal(t, 1, out.Succeeded)
+	assert.Equal(t, 1, out.Skipped)
+	assert.Equal(t, 1, out.Failed)
+	require.Len(t, out.Projects, 3)
+	assert.Equal(t, "succeeded", out.Projects[1].Status)
+	assert.Equal(t, 1, out.Projects[1].FilesWritten)
+
+	// methods of a nil summary are no-ops
+	var nilSummary *conjureplugin.Summary
+	nilSummary.Skip([]string{"project-1"}, "reason")
+	nilSummary.Finish()
+	buf = &bytes.Buffer{}
+	nilSummary.Print(buf)
+	require.NoError(t, nilSummary.PrintJSON(buf))
+	assert.Empty(t, buf.String())
+}
+
+// tempDirIRProvider is an IRProvider that creates a temporary directory and fails with an error that includes its path.
+type tempDirIRProvider struct{}
+
+func (tempDirIRProvider) IRBytes() ([]byte, error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("ir")
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("failed to read IR in %s", tmpDir)
+}
+
+func (tempDirIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+func TestSummaryNormalize(t *testing.T) {
+	// runs WriteIR in a project directory with the provided name using a temporary directory with the provided name and
+	// returns the normalized summary as JSON
+	runInEnv := func(projectDirName, tempDirName string) string {
+		dir := t.TempDir()
+		projectDir := filepath.Join(dir, projectDirName)
+		require.NoError(t, os.MkdirAll(projectDir, 0755))
+		tempfilecreator.SetRoot(filepath.Join(dir, tempDirName))
+		defer tempfilecreator.SetRoot("")
+
+		params := conjureplugin.ConjureProjectParams{
+			SortedKeys: []string{"project-1", "project-2"},
+			Params: map[string]conjureplugin.ConjureProjectParam{
+				"project-1": {
+					IRProvider: conjureplugin.NewLocalFileIRProvider(filepath.Join(projectDir, "missing.json")),
+				},
+				"project-2": {
+					IRProvider: tempDirIRProvider{},
+				},
+			},
+		}
+		summary := conjureplugin.NewSummary("conjure-write-ir")
+		for _, project := range params.SortedKeys {
+			err := conjureplugin.WriteIR(params, project, filepath.Join(projectDir, "out"), &bytes.Buffer{}, conjureplugin.SummaryParam(summary))
+			require.Error(t, err)
+		}
+		summary.Finish()
+		summary.Normalize(conjureplugin.NewNormalizer(projectDir))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, summary.PrintJSON(buf))
+		return buf.String()
+	}
+
+	got := runInEnv("project-a", "tmp-a")
+	assert.Equal(t, got, runInEnv("project-b", "tmp-b"))
+	assert.Contains(t, got, `"durationMillis":0`)
+	assert.Contains(t, got, "$PROJECT_DIR/missing.json")
+	assert.Contains(t, got, "failed to read IR in $TMPDIR/conjure-plugin-ir-*")
+
+	// a nil normalizer does not change the provided string
+	var nilNormalizer *conjureplugin.Normalizer
+	assert.Equal(t, "/tmp/conjure-plugin-ir-123", nilNormalizer.Normalize("/tmp/conjure-plugin-ir-123"))
+}
+
+func TestRunPostRunHooks(t *testing.T) {
+	dir := t.TempDir()
+	// the first hook records its arguments and the summary it receives on stdin, and the second hook fails
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "record.sh"), []byte(`#!/bin/sh
+echo "$@" > args.txt
+cat > summary.json
+echo recorded
+`), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fail.sh"), []byte(`#!/bin/sh
+echo failing
+exit 3
+`), 0755))
+
+	summary := conjureplugin.NewSummary("conjure")
+	summary.Skip([]string{"project-1"}, "not selected")
+	outputBuf := &bytes.Buffer{}
+	err := conjureplugin.RunPostRunHooks([]conjureplugin.PostRunHook{
+		{Path: "./fail.sh"},
+		{Path: "./record.sh", Args: []string{"--upload", "cache"}},
+	}, summary, dir, outputBuf)
+	require.Error(t, err)
+	var hookErr *conjureplugin.PostRunHookError
+	require.ErrorAs(t, err, &hookErr)
+	assert.Equal(t, []string{"./fail.sh: exit status 3"}, hookErr.Failures)
+	assert.Equal(t, "post-run hooks failed:\n  ./fail.sh: exit status 3", err.Error())
+	assert.Equal(t, "failing\nrecorded\n", outputBuf.String())
+
+	args, err := os.ReadFile(filepath.Join(dir, "args.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "--upload cache\n", string(args))
+	summaryJSON, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(summaryJSON, &got))
+	assert.Equal(t, "conjure", got["command"])
+	assert.Equal(t, float64(1), got["skipped"])
+
+	require.NoError(t, conjureplugin.RunPostRunHooks(nil, summary, dir, outputBuf))
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	conjurego "github.com/palantir/conjure-go/v6/conjure"
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+// VerifyArtifactsParam returns a parameter that configures Publish to verify every IR artifact after it is built and
+// before it is published (or, for a dry run, before the operations that would be performed are printed). The
+// verification checks that the artifact is valid Conjure IR, that its extensions are well-formed and that its name
+// matches the conventions for published artifacts.
+func VerifyArtifactsParam(verify bool) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.verifyArtifacts = verify
+	})
+}
+
+var (
+	// artifactIDRegexp matches the names of projects that are valid Maven artifact IDs.
+	artifactIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// artifactVersionRegexp matches versions that can be used in artifact names and repository paths.
+	artifactVersionRegexp = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+)
+
+// recommendedProductDependenciesExtension is the key of the IR extension that specifies the recommended product
+// dependencies of the IR.
+const recommendedProductDependenciesExtension = "recommended-product-dependencies"
+
+// verifyPublishArtifact returns an error that describes all of the problems with the IR artifact at the provided path
+// that is published for the project with the provided key and version. If the artifact is the index of sharded IR, the
+// IR assembled from its shards is verified. Returns nil if there are no problems.
+func verifyPublishArtifact(key, version, artifactPath string) error {
+	var problems []string
+	if !artifactIDRegexp.MatchString(key) {
+		problems = append(problems, fmt.Sprintf("project name %q is not a valid artifact ID: must match %s", key, artifactIDRegexp))
+	}
+	if !artifactVersionRegexp.MatchString(version) {
+		problems = append(problems, fmt.Sprintf("version %q cannot be used in an artifact name: must match %s", version, artifactVersionRegexp))
+	}
+	if wantName := fmt.Sprintf("%s-%s.conjure.json", key, version); filepath.Base(artifactPath) != wantName {
+		problems = append(problems, fmt.Sprintf("artifact name %s does not match expected name %s", filepath.Base(artifactPath), wantName))
+	}
+
+	irBytes, err := NewLocalFileIRProvider(artifactPath).IRBytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed to read IR artifact for %s", key)
+	}
+	problems = append(problems, irProblems(irBytes)...)
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.Errorf("IR artifact %s for %s is invalid:\n  %s", filepath.Base(artifactPath), key, strings.Join(problems, "\n  "))
+}
+
+// irProblems returns the problems with the provided IR, which should be a valid Conjure definition with well-formed
+// extensions.
+func irProblems(irBytes []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(irBytes, &fields); err != nil {
+		return []string{fmt.Sprintf("IR is not a JSON object: %v", err)}
+	}
+	def, err := conjurego.FromIRBytes(irBytes)
+	if err != nil {
+		return []string{fmt.Sprintf("IR is not a valid Conjure definition: %v", errors.Cause(err))}
+	}
+
+	var problems []string
+	if def.Version != 1 {
+		problems = append(problems, fmt.Sprintf("IR has version %d, but only version 1 is supported", def.Version))
+	}
+	seenNames := make(map[string]struct{})
+	addName := func(kind string, name spec.TypeName) {
+		qualifiedName := name.Package + "." + name.Name
+		if name.Name == "" || name.Package == "" {
+			problems = append(problems, fmt.Sprintf("%s %q does not have both a name and a package", kind, qualifiedName))
+			return
+		}
+		if _, ok := seenNames[qualifiedName]; ok {
+			problems = append(problems, fmt.Sprintf("%s is defined multiple times", qualifiedName))
+		}
+		seenNames[qualifiedName] = struct{}{}
+	}
+	for i, typeDef := range def.Types {
+		if err := typeDef.AcceptFuncs(
+			func(alias spec.AliasDefinition) error {
+				addName("type", alias.TypeName)
+				return nil
+			},
+			func(enum spec.EnumDefinition) error {
+				addName("type", enum.TypeName)
+				return nil
+			},
+			func(object spec.ObjectDefinition) error {
+				addName("type", object.TypeName)
+				return nil
+			},
+			func(union spec.UnionDefinition) error {
+				addName("type", union.TypeName)
+				return nil
+			},
+			typeDef.ErrorOnUnknown,
+		); err != nil {
+			problems = append(problems, fmt.Sprintf("type definition %d is invalid: %v", i, err))
+		}
+	}
+	for _, errorDef := range def.Errors {
+		addName("error", errorDef.ErrorName)
+	}
+	for _, serviceDef := range def.Services {
+		addName("service", serviceDef.ServiceName)
+	}
+	problems = append(problems, extensionsProblems(fields["extensions"])...)
+	return problems
+}
+
+// extensionsProblems returns the problems with the provided "extensions" value of IR, which should be a JSON object (if
+// present) whose recommended product dependencies (if present) specify a product group, product name, minimum version
+// and maximum version.
+func extensionsProblems(extensionsJSON json.RawMessage) []string {
+	if len(extensionsJSON) == 0 || string(extensionsJSON) == "null" {
+		return nil
+	}
+	var extensions map[string]json.RawMessage
+	if err := json.Unmarshal(extensionsJSON, &extensions); err != nil {
+		return []string{fmt.Sprintf("extensions are not a JSON object: %v", err)}
+	}
+	pdepsJSON, ok := extensions[recommendedProductDependenciesExtension]
+	if !ok {
+		return nil
+	}
+	var pdeps []map[string]interface{}
+	if err := json.Unmarshal(pdepsJSON, &pdeps); err != nil {
+		return []string{fmt.Sprintf("%s extension is not a list of objects: %v", recommendedProductDependenciesExtension, err)}
+	}
+	var problems []string
+	for i, pdep := range pdeps {
+		for _, field := range []string{"product-group", "product-name", "minimum-version", "maximum-version"} {
+			if val, ok := pdep[field].(string); !ok || val == "" {
+				problems = append(problems, fmt.Sprintf("%s extension entry %d does not specify %s", recommendedProductDependenciesExtension, i, field))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/conjureverify"
+	"github.com/pkg/errors"
+)
+
+// VerifyReportParam returns a parameter that configures verification to record the differences that it finds in the
+// provided report rather than printing them as text.
+func VerifyReportParam(report *VerifyReport) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.verifyReport = report
+		a.verifyReportText = false
+	})
+}
+
+// VerifyRecordParam returns a parameter that configures verification to record the differences that it finds in the
+// provided report in addition to printing them as text.
+func VerifyRecordParam(report *VerifyReport) OperationParam {
+	return operationParamFn(func(a *operationArgs) {
+		a.verifyReport = report
+		a.verifyReportText = true
+	})
+}
+
+// FileChange is the way in which a file on disk differs from the file that would be generated.
+type FileChange = conjureverify.Change
+
+const (
+	// FileChangeMissing indicates that a file would be generated but does not exist.
+	FileChangeMissing = conjureverify.ChangeMissing
+	// FileChangeModified indicates that the content of a file differs from the content that would be generated.
+	FileChangeModified = conjureverify.ChangeModified
+	// FileChangeExtra indicates that a file exists but would be removed (such as a file left behind by a previous name
+	// of a project).
+	FileChangeExtra = conjureverify.ChangeExtra
+)
+
+// VerifyReport records the differences found by verification for every project whose generated code differs from what
+// currently exists.
+type VerifyReport struct {
+	Projects []VerifyProjectReport `json:"projects"`
+}
+
+// VerifyProjectReport records the differences found by verification for a single project.
+type VerifyProjectReport struct {
+	Project string   `json:"project"`
+	Owners  []string `json:"owners,omitempty"`
+	// Files are the files that differ sorted by their path.
+	Files []VerifyFileDiff `json:"files"`
+	// Messages describe differences that are not described by Files (such as changes to frozen projects).
+	Messages []string `json:"messages,omitempty"`
+}
+
+// VerifyFileDiff describes a generated file that differs from what currently exists.
+type VerifyFileDiff = conjureverify.FileDiff
+
+// PrintJSON prints the report as a single line of JSON to the provided writer.
+func (r *VerifyReport) PrintJSON(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+	out := *r
+	if out.Projects == nil {
+		out.Projects = []VerifyProjectReport{}
+	}
+	jsonBytes, err := json.Marshal(out)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal verify report")
+	}
+	_, err = fmt.Fprintln(w, string(jsonBytes))
+	return errors.WithStack(err)
+}
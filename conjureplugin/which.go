@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Which writes a report that describes the provenance of the generated file at the provided path to the provided
+// writer: the project that generated it, the source of the IR of the project, the Conjure YAML files that define the
+// package of the file (if the IR of the project is generated from local YAML) and the command that regenerates it. The
+// project is the project whose output directory (or routes file) contains the file. If the output directories of
+// multiple projects contain the file, the project with the most specific output directory is reported. Returns an error
+// if the file is not in the output directory of any project.
+func Which(params ConjureProjectParams, projectDir, path string, stdout io.Writer) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine absolute path of %s", path)
+	}
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine absolute path of %s", projectDir)
+	}
+	projectName, relDir, err := whichProject(params, absProjectDir, absPath)
+	if err != nil {
+		return err
+	}
+	param := params.Params[projectName]
+
+	displayPath := absPath
+	if rel, err := filepath.Rel(absProjectDir, absPath); err == nil && !strings.HasPrefix(rel, "..") {
+		displayPath = rel
+	}
+	_, _ = fmt.Fprintf(stdout, "File: %s\n", displayPath)
+	_, _ = fmt.Fprintf(stdout, "Project: %s\n", projectName)
+	if len(param.Owners) > 0 {
+		_, _ = fmt.Fprintf(stdout, "Owners: %s\n", strings.Join(param.Owners, ", "))
+	}
+	_, _ = fmt.Fprintf(stdout, "IR source: %s\n", irProviderDescription(param.IRProvider))
+	definitions, err := whichDefinitions(params, param.IRProvider, param.PackagePaths, relDir)
+	if err != nil {
+		return err
+	}
+	if len(definitions) > 0 {
+		_, _ = fmt.Fprintf(stdout, "Definitions:\n")
+		for _, definition := range definitions {
+			_, _ = fmt.Fprintf(stdout, "  %s\n", definition)
+		}
+	}
+	_, _ = fmt.Fprintf(stdout, "Regenerate: ./godelw conjure --projects %s\n", projectName)
+	return nil
+}
+
+// whichProject returns the name of the project that generated the file at the provided absolute path along with the
+// slash-separated directory of the file relative to the output directory of the project. The directory is empty if the
+// file is the routes file of the project or is not in a package directory of the output directory.
+func whichProject(params ConjureProjectParams, absProjectDir, absPath string) (string, string, error) {
+	var (
+		match      string
+		matchDir   string
+		matchDepth = -1
+	)
+	for _, name := range params.SortedKeys {
+		param := params.Params[name]
+		if param.RoutesFile != "" && filepath.Join(absProjectDir, param.RoutesFile) == absPath {
+			return name, "", nil
+		}
+		absOutputDir := filepath.Join(absProjectDir, param.OutputDir)
+		rel, err := filepath.Rel(absOutputDir, absPath)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if depth := strings.Count(absOutputDir, string(filepath.Separator)); depth > matchDepth {
+			match = name
+			matchDir = filepath.ToSlash(filepath.Dir(rel))
+			matchDepth = depth
+		}
+	}
+	if match == "" {
+		return "", "", errors.Errorf("%s is not in the output directory of any project", absPath)
+	}
+	if matchDir == "." {
+		matchDir = ""
+	}
+	return match, matchDir, nil
+}
+
+// whichDefinitions returns the paths of the Conjure YAML files compiled by the provided provider that define types,
+// errors or services in the package that is generated in the provided directory (relative to the output directory,
+// where the directories of packages are determined by the provided package paths). If
+// the directory is empty, all of the files are returned. If the provider provides the IR of another project, the files
+// of that project are returned. Returns nil if the IR of the provider is not generated from local YAML.
+func whichDefinitions(params ConjureProjectParams, provider IRProvider, packagePaths map[string]string, relDir string) ([]string, error) {
+	switch p := provider.(type) {
+	case *projectIRProvider:
+		if other, ok := params.Params[p.project]; ok {
+			return whichDefinitions(params, other.IRProvider, packagePaths, relDir)
+		}
+		return whichDefinitions(params, p.provider, packagePaths, relDir)
+	case *localYAMLIRProvider:
+		inputPaths, err := p.inputPaths()
+		if err != nil {
+			return nil, err
+		}
+		yamlFiles, err := conjureYAMLFiles(inputPaths)
+		if err != nil {
+			return nil, err
+		}
+		if relDir == "" {
+			return yamlFiles, nil
+		}
+		var definitions []string
+		for _, yamlFile := range yamlFiles {
+			pkgs, err := conjureYAMLPackages(yamlFile)
+			if err != nil {
+				return nil, err
+			}
+			for _, pkg := range pkgs {
+				if packagePath(packagePaths, pkg) == relDir {
+					definitions = append(definitions, yamlFile)
+					break
+				}
+			}
+		}
+		return definitions, nil
+	default:
+		return nil, nil
+	}
+}
+
+// conjureYAMLFiles returns the Conjure YAML files at the provided paths. Directories are expanded to the ".yml" and
+// ".yaml" files that they contain.
+func conjureYAMLFiles(inputPaths []string) ([]string, error) {
+	var files []string
+	for _, inputPath := range inputPaths {
+		fi, err := os.Stat(inputPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to stat %s", inputPath)
+		}
+		if !fi.IsDir() {
+			files = append(files, inputPath)
+			continue
+		}
+		entries, err := os.ReadDir(inputPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read directory %s", inputPath)
+		}
+		for _, entry := range entries {
+			if ext := filepath.Ext(entry.Name()); !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+				files = append(files, filepath.Join(inputPath, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// conjureYAMLFile is the subset of a Conjure YAML file that specifies the packages of its definitions.
+type conjureYAMLFile struct {
+	Types struct {
+		Definitions struct {
+			DefaultPackage string                        `yaml:"default-package"`
+			Objects        map[string]conjureYAMLPackage `yaml:"objects"`
+			Errors         map[string]conjureYAMLPackage `yaml:"errors"`
+		} `yaml:"definitions"`
+	} `yaml:"types"`
+	Services map[string]conjureYAMLPackage `yaml:"services"`
+}
+
+type conjureYAMLPackage struct {
+	Package string `yaml:"package"`
+}
+
+// conjureYAMLPackages returns the sorted Conjure packages of the types, errors and services defined in the Conjure YAML
+// file at the provided path.
+func conjureYAMLPackages(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", path)
+	}
+	var file conjureYAMLFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s as Conjure YAML", path)
+	}
+	pkgs := make(map[string]struct{})
+	definitions := file.Types.Definitions
+	for _, defs := range []map[string]conjureYAMLPackage{definitions.Objects, definitions.Errors} {
+		for _, def := range defs {
+			pkg := def.Package
+			if pkg == "" {
+				pkg = definitions.DefaultPackage
+			}
+			pkgs[pkg] = struct{}{}
+		}
+	}
+	for _, service := range file.Services {
+		pkgs[service.Package] = struct{}{}
+	}
+	var sorted []string
+	for pkg := range pkgs {
+		if pkg != "" {
+			sorted = append(sorted, pkg)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted, nil
+}
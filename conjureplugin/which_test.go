@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhich(t *testing.T) {
+	projectDir := t.TempDir()
+	apiDir := filepath.Join(projectDir, "api")
+	require.NoError(t, os.MkdirAll(apiDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "objects.yml"), []byte(`types:
+  definitions:
+    default-package: com.palantir.test.api
+    objects:
+      Foo:
+        fields:
+          bar: string
+      Baz:
+        package: com.palantir.test.other
+        fields:
+          bar: string
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(apiDir, "services.yml"), []byte(`services:
+  TestService:
+    name: Test Service
+    package: com.palantir.test.service
+    endpoints:
+      get:
+        http: GET /get
+`), 0644))
+	irFile := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2", "project-3"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(apiDir),
+				RoutesFile: "routes.yml",
+				Owners:     []string{"team-a"},
+			},
+			"project-2": {
+				OutputDir:  filepath.Join("conjure", "ir"),
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+			"project-3": {
+				OutputDir:  "conjure-copy",
+				IRProvider: conjureplugin.NewProjectIRProvider("project-1", conjureplugin.NewLocalYAMLIRProvider(apiDir)),
+			},
+		},
+	}
+
+	for i, tc := range []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{
+			"conjure/test/api/structs.conjure.go",
+			`File: conjure/test/api/structs.conjure.go
+Project: project-1
+Owners: team-a
+IR source: yaml "` + apiDir + `"
+Definitions:
+  ` + filepath.Join(apiDir, "objects.yml") + `
+Regenerate: ./godelw conjure --projects project-1
+`,
+			"",
+		},
+		{
+			"conjure/test/service/services.conjure.go",
+			`File: conjure/test/service/services.conjure.go
+Project: project-1
+Owners: team-a
+IR source: yaml "` + apiDir + `"
+Definitions:
+  ` + filepath.Join(apiDir, "services.yml") + `
+Regenerate: ./godelw conjure --projects project-1
+`,
+			"",
+		},
+		{
+			"routes.yml",
+			`File: routes.yml
+Project: project-1
+Owners: team-a
+IR source: yaml "` + apiDir + `"
+Definitions:
+  ` + filepath.Join(apiDir, "objects.yml") + `
+  ` + filepath.Join(apiDir, "services.yml") + `
+Regenerate: ./godelw conjure --projects project-1
+`,
+			"",
+		},
+		{
+			"conjure/ir/test/api/structs.conjure.go",
+			`File: conjure/ir/test/api/structs.conjure.go
+Project: project-2
+IR source: ir-file "` + irFile + `"
+Regenerate: ./godelw conjure --projects project-2
+`,
+			"",
+		},
+		{
+			"conjure-copy/test/other/structs.conjure.go",
+			`File: conjure-copy/test/other/structs.conjure.go
+Project: project-3
+IR source: project "project-1"
+Definitions:
+  ` + filepath.Join(apiDir, "objects.yml") + `
+Regenerate: ./godelw conjure --projects project-3
+`,
+			"",
+		},
+		{
+			"other/structs.conjure.go",
+			"",
+			filepath.Join(projectDir, "other", "structs.conjure.go") + " is not in the output directory of any project",
+		},
+	} {
+		buf := &bytes.Buffer{}
+		err := conjureplugin.Which(params, projectDir, filepath.Join(projectDir, tc.path), buf)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, buf.String(), "Case %d", i)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteIR writes the IR of projects without generating code. If projectName is non-empty, the IR of that project is
+// written to the file at the provided output path, or to stdout if the output path is empty or "-". Otherwise, the IR
+// of every project is written to "<project>.conjure.json" in the directory at the provided output path.
+func WriteIR(params ConjureProjectParams, projectName, output string, stdout io.Writer, opParams ...OperationParam) (rErr error) {
+	opArgs := newOperationArgs(opParams)
+	if projectName != "" {
+		param, err := params.Param(projectName)
+		if err != nil {
+			return err
+		}
+		summaries := newProjectSummaries(params.Subset([]string{projectName}))
+		defer func() {
+			rErr = summaries.finish(rErr, opArgs.summary)
+		}()
+		summaries.begin(0)
+		irBytes, err := param.IRProvider.IRBytes()
+		if err != nil {
+			return Classify(errors.Wrapf(err, "failed to compute IR for %s", projectName), ErrIR)
+		}
+		if output == "" || output == "-" {
+			if _, err := stdout.Write(irBytes); err != nil {
+				return errors.WithStack(err)
+			}
+		} else {
+			if err := writeIRFile(irBytes, output); err != nil {
+				return err
+			}
+			summaries.projects[0].FilesWritten = 1
+		}
+		summaries.end(ProjectStatusSucceeded, "")
+		return nil
+	}
+
+	if output == "" || output == "-" {
+		return errors.Errorf("output must be a directory if a project is not specified")
+	}
+	summaries := newProjectSummaries(params)
+	defer func() {
+		rErr = summaries.finish(rErr, opArgs.summary)
+	}()
+	irBytesCache := newIRBytesCache()
+	for i, currProject := range params.SortedKeys {
+		summaries.begin(i)
+		irBytes, err := irBytesFromProvider(params.Params[currProject].IRProvider, irBytesCache)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compute IR for %s", currProject)
+		}
+		irPath := filepath.Join(output, currProject+".conjure.json")
+		if err := writeIRFile(irBytes, irPath); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "Wrote IR for %s to %s\n", currProject, irPath)
+		summaries.projects[i].FilesWritten = 1
+		summaries.end(ProjectStatusSucceeded, "")
+	}
+	return nil
+}
+
+func writeIRFile(irBytes []byte, irPath string) error {
+	if err := os.MkdirAll(filepath.Dir(irPath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", irPath)
+	}
+	if err := os.WriteFile(irPath, irBytes, 0644); err != nil {
+		return errors.Wrapf(err, "failed to write IR to %s", irPath)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIR(t *testing.T) {
+	dir := t.TempDir()
+	irFile := filepath.Join(dir, "ir.json")
+	require.NoError(t, os.WriteFile(irFile, []byte(testIRJSON), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+			"project-2": {
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+
+	// single project to stdout
+	buf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.WriteIR(params, "project-1", "-", buf))
+	assert.Equal(t, testIRJSON, buf.String())
+
+	// single project to file
+	outFile := filepath.Join(dir, "out", "project-1.json")
+	require.NoError(t, conjureplugin.WriteIR(params, "project-1", outFile, &bytes.Buffer{}))
+	content, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(content))
+
+	// all projects to directory
+	outDir := filepath.Join(dir, "all")
+	buf = &bytes.Buffer{}
+	require.NoError(t, conjureplugin.WriteIR(params, "", outDir, buf))
+	for _, project := range params.SortedKeys {
+		content, err := os.ReadFile(filepath.Join(outDir, project+".conjure.json"))
+		require.NoError(t, err)
+		assert.Equal(t, testIRJSON, string(content))
+		assert.Contains(t, buf.String(), "Wrote IR for "+project)
+	}
+
+	assert.EqualError(t, conjureplugin.WriteIR(params, "", "-", &bytes.Buffer{}), "output must be a directory if a project is not specified")
+}
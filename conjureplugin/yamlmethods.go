@@ -0,0 +1,199 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// YAMLMethods specifies the YAML library used by the MarshalYAML and UnmarshalYAML methods of generated types.
+type YAMLMethods string
+
+const (
+	// YAMLMethodsSafeYAML specifies that the methods use github.com/palantir/pkg/safeyaml (which uses gopkg.in/yaml.v2).
+	// This is the behavior of conjure-go.
+	YAMLMethodsSafeYAML = YAMLMethods("safeyaml")
+	// YAMLMethodsYAMLV3 specifies that the methods use gopkg.in/yaml.v3.
+	YAMLMethodsYAMLV3 = YAMLMethods("yaml.v3")
+	// YAMLMethodsNone specifies that the methods are not generated.
+	YAMLMethodsNone = YAMLMethods("none")
+)
+
+const (
+	safeJSONImportPath = "github.com/palantir/pkg/safejson"
+	safeYAMLImportPath = "github.com/palantir/pkg/safeyaml"
+	yamlV3ImportPath   = "gopkg.in/yaml.v3"
+)
+
+// yamlV3MarshalYAML is the MarshalYAML method that uses gopkg.in/yaml.v3 for the receiver with the provided name and
+// type. The JSON representation of the value is parsed as YAML (which preserves the order of its keys), and the style of
+// the parsed nodes is cleared so that the value is encoded in block style rather than in the flow style of JSON.
+const yamlV3MarshalYAML = `func (%[1]s %[2]s) MarshalYAML() (interface{}, error) {
+	jsonBytes, err := safejson.Marshal(%[1]s)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonBytes, &node); err != nil {
+		return nil, err
+	}
+	var clearStyle func(*yaml.Node)
+	clearStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			clearStyle(child)
+		}
+	}
+	clearStyle(&node)
+	return node.Content[0], nil
+}`
+
+// yamlV3UnmarshalYAML is the UnmarshalYAML method that uses gopkg.in/yaml.v3 for the receiver with the provided name and
+// type.
+const yamlV3UnmarshalYAML = `func (%[1]s *%[2]s) UnmarshalYAML(node *yaml.Node) error {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+	jsonBytes, err := safejson.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return safejson.Unmarshal(jsonBytes, %[1]s)
+}`
+
+// rewriteYAMLMethods returns the provided files with the MarshalYAML and UnmarshalYAML methods generated by conjure-go
+// rewritten to use the provided YAML library or removed. Other files are returned unmodified. If methods is empty or
+// YAMLMethodsSafeYAML, the files are returned as-is.
+func rewriteYAMLMethods(files []renderedFile, methods YAMLMethods) ([]renderedFile, error) {
+	if methods == "" || methods == YAMLMethodsSafeYAML {
+		return files, nil
+	}
+	out := make([]renderedFile, 0, len(files))
+	for _, file := range files {
+		if strings.HasSuffix(file.absPath, generatedFileSuffix) && bytes.Contains(file.content, []byte(safeYAMLImportPath)) {
+			content, err := rewriteFileYAMLMethods(file.absPath, file.content, methods)
+			if err != nil {
+				return nil, err
+			}
+			file.content = content
+		}
+		out = append(out, file)
+	}
+	return out, nil
+}
+
+// rewriteFileYAMLMethods returns the provided content of a generated Go file with its YAML methods rewritten to use the
+// provided YAML library or removed.
+func rewriteFileYAMLMethods(path string, content []byte, methods YAMLMethods) ([]byte, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse generated file %s", path)
+	}
+	type replacement struct {
+		start, end int
+		text       string
+	}
+	var replacements []replacement
+	for _, decl := range astFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || !isGeneratedYAMLMethod(funcDecl) {
+			continue
+		}
+		var text string
+		if methods == YAMLMethodsYAMLV3 {
+			recvName := funcDecl.Recv.List[0].Names[0].Name
+			recvType := funcDecl.Recv.List[0].Type
+			if star, ok := recvType.(*ast.StarExpr); ok {
+				recvType = star.X
+			}
+			recvTypeName, ok := recvType.(*ast.Ident)
+			if !ok {
+				return nil, errors.Errorf("unexpected receiver of %s in generated file %s", funcDecl.Name.Name, path)
+			}
+			tmpl := yamlV3MarshalYAML
+			if funcDecl.Name.Name == "UnmarshalYAML" {
+				tmpl = yamlV3UnmarshalYAML
+			}
+			text = fmt.Sprintf(tmpl, recvName, recvTypeName.Name)
+		}
+		replacements = append(replacements, replacement{
+			start: fset.Position(funcDecl.Pos()).Offset,
+			end:   fset.Position(funcDecl.End()).Offset,
+			text:  text,
+		})
+	}
+	if len(replacements) == 0 {
+		return content, nil
+	}
+	// apply the replacements from the end of the file so that the offsets of earlier replacements remain valid
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+	rewritten := append([]byte{}, content...)
+	for _, r := range replacements {
+		rewritten = append(rewritten[:r.start], append([]byte(r.text), rewritten[r.end:]...)...)
+	}
+
+	fset = token.NewFileSet()
+	if astFile, err = parser.ParseFile(fset, path, rewritten, parser.ParseComments); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse rewritten generated file %s", path)
+	}
+	if methods == YAMLMethodsYAMLV3 {
+		astutil.AddImport(fset, astFile, yamlV3ImportPath)
+	}
+	for _, importPath := range []string{safeJSONImportPath, safeYAMLImportPath} {
+		if !astutil.UsesImport(astFile, importPath) {
+			astutil.DeleteImport(fset, astFile, importPath)
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, astFile); err != nil {
+		return nil, errors.Wrapf(err, "failed to format generated file %s", path)
+	}
+	return buf.Bytes(), nil
+}
+
+// isGeneratedYAMLMethod returns true if the provided function is a MarshalYAML or UnmarshalYAML method generated by
+// conjure-go, which uses safeyaml.
+func isGeneratedYAMLMethod(funcDecl *ast.FuncDecl) bool {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) != 1 || len(funcDecl.Recv.List[0].Names) != 1 || funcDecl.Body == nil {
+		return false
+	}
+	if name := funcDecl.Name.Name; name != "MarshalYAML" && name != "UnmarshalYAML" {
+		return false
+	}
+	usesSafeYAML := false
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == "safeyaml" {
+				usesSafeYAML = true
+			}
+		}
+		return !usesSafeYAML
+	})
+	return usesSafeYAML
+}
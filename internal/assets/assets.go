@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assets loads the assets provided to the plugin by godel. An asset is an executable that implements
+// functionality of a specific type (such as checking definitions for backwards compatibility). The type of an asset is
+// determined by invoking it with the "_assetInfo" argument, which must print a JSON object of the form
+// {"type":"<type>"}. The object can optionally specify a "name" for the asset, which is the key of its configuration in
+// the "asset-config" section of the plugin configuration. If a name is not specified, the name of the asset is the base
+// name of its path.
+package assets
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+type AssetType string
+
+const (
+	// BackCompatAssetType is the type of assets that check whether Conjure definitions are backwards compatible with
+	// a baseline.
+	BackCompatAssetType AssetType = "backcompat"
+	// GeneratorAssetType is the type of assets that generate the output of a project from its IR (typically code in a
+	// language other than Go).
+	GeneratorAssetType AssetType = "generator"
+	// PublisherAssetType is the type of assets that publish IR to custom destinations.
+	PublisherAssetType AssetType = "publisher"
+	// IRValidatorAssetType is the type of assets that validate the IR of projects before their code is generated or
+	// their IR is published.
+	IRValidatorAssetType AssetType = "ir-validator"
+)
+
+const assetInfoCommand = "_assetInfo"
+
+// Asset is a loaded asset.
+type Asset struct {
+	Path string
+	Name string
+}
+
+// SkippedAsset is an optional asset that could not be loaded.
+type SkippedAsset struct {
+	Asset
+	// Err is the reason the asset could not be loaded.
+	Err error
+}
+
+// Assets stores the loaded assets by type.
+type Assets struct {
+	BackCompat  []Asset
+	Generator   []Asset
+	Publisher   []Asset
+	IRValidator []Asset
+	// Skipped are the optional assets that could not be loaded.
+	Skipped []SkippedAsset
+}
+
+// Names returns the names of all of the provided assets, including the optional assets that could not be loaded.
+func (a Assets) Names() []string {
+	var names []string
+	for _, asset := range a.BackCompat {
+		names = append(names, asset.Name)
+	}
+	for _, asset := range a.Generator {
+		names = append(names, asset.Name)
+	}
+	for _, asset := range a.Publisher {
+		names = append(names, asset.Name)
+	}
+	for _, asset := range a.IRValidator {
+		names = append(names, asset.Name)
+	}
+	for _, asset := range a.Skipped {
+		names = append(names, asset.Name)
+	}
+	return names
+}
+
+type assetInfo struct {
+	Type    AssetType `json:"type"`
+	Name    string    `json:"name,omitempty"`
+	Version string    `json:"version,omitempty"`
+}
+
+// Load returns the Assets for the assets at the provided paths. Returns an error if the type of any asset cannot be
+// determined or is not supported, unless the provided function (which may be nil) reports that the asset is optional,
+// in which case the asset is recorded as skipped. The name of an asset whose type cannot be determined is the base name
+// of its path.
+func Load(paths []string, optional func(name string) bool) (Assets, error) {
+	var loaded Assets
+	for _, path := range paths {
+		asset := Asset{
+			Path: path,
+			Name: filepath.Base(path),
+		}
+		info, err := readAssetInfo(exec.Command(path, assetInfoCommand))
+		if err == nil {
+			if info.Name != "" {
+				asset.Name = info.Name
+			}
+			switch info.Type {
+			case BackCompatAssetType:
+				loaded.BackCompat = append(loaded.BackCompat, asset)
+				continue
+			case GeneratorAssetType:
+				loaded.Generator = append(loaded.Generator, asset)
+				continue
+			case PublisherAssetType:
+				loaded.Publisher = append(loaded.Publisher, asset)
+				continue
+			case IRValidatorAssetType:
+				loaded.IRValidator = append(loaded.IRValidator, asset)
+				continue
+			default:
+				err = errors.Errorf("asset %s has unsupported type %q", path, info.Type)
+			}
+		}
+		if optional == nil || !optional(asset.Name) {
+			return Assets{}, err
+		}
+		loaded.Skipped = append(loaded.Skipped, SkippedAsset{
+			Asset: asset,
+			Err:   err,
+		})
+	}
+	return loaded, nil
+}
+
+// readAssetInfo runs the provided "_assetInfo" command of an asset and returns the information that it prints.
+func readAssetInfo(cmd *exec.Cmd) (assetInfo, error) {
+	output, err := cmd.Output()
+	if err != nil {
+		return assetInfo{}, errors.Wrapf(err, "failed to determine type of asset %s", cmd.Path)
+	}
+	var info assetInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return assetInfo{}, errors.Wrapf(err, "failed to parse output of %s %s as asset information", cmd.Path, assetInfoCommand)
+	}
+	return info, nil
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/assets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOptional(t *testing.T) {
+	dir := t.TempDir()
+	checkerPath := filepath.Join(dir, "checker")
+	require.NoError(t, os.WriteFile(checkerPath, []byte("#!/bin/sh\necho '{\"type\":\"backcompat\",\"name\":\"conjure-backcompat\"}'\n"), 0755))
+	brokenPath := filepath.Join(dir, "broken")
+	require.NoError(t, os.WriteFile(brokenPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	unsupportedPath := filepath.Join(dir, "unsupported")
+	require.NoError(t, os.WriteFile(unsupportedPath, []byte("#!/bin/sh\necho '{\"type\":\"lint\",\"name\":\"conjure-lint\"}'\n"), 0755))
+
+	_, err := assets.Load([]string{checkerPath, brokenPath}, nil)
+	assert.EqualError(t, err, "failed to determine type of asset "+brokenPath+": exit status 1")
+
+	optional := func(name string) bool {
+		return name == "broken" || name == "conjure-lint"
+	}
+	loaded, err := assets.Load([]string{checkerPath, brokenPath, unsupportedPath}, optional)
+	require.NoError(t, err)
+	assert.Equal(t, []assets.Asset{{Path: checkerPath, Name: "conjure-backcompat"}}, loaded.BackCompat)
+	require.Len(t, loaded.Skipped, 2)
+	assert.Equal(t, assets.Asset{Path: brokenPath, Name: "broken"}, loaded.Skipped[0].Asset)
+	assert.EqualError(t, loaded.Skipped[1].Err, `asset `+unsupportedPath+` has unsupported type "lint"`)
+	assert.Equal(t, []string{"conjure-backcompat", "broken", "conjure-lint"}, loaded.Names())
+}
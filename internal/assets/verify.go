@@ -0,0 +1,258 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/pkg/errors"
+)
+
+// verifyTimeout is the maximum amount of time that each command run to verify an asset may take.
+const verifyTimeout = 30 * time.Second
+
+// emptyIR is valid IR that contains no definitions. It is used to verify that backcompat assets consider IR to be
+// backwards compatible with itself.
+const emptyIR = `{"version":1,"errors":[],"types":[],"services":[],"extensions":{}}`
+
+// Report is the result of verifying an asset.
+type Report struct {
+	Path    string
+	Name    string
+	Type    AssetType
+	Version string
+	// Problems describes the ways in which the asset is misconfigured. Empty if the asset is valid.
+	Problems []string
+}
+
+// OK returns true if no problems were found with the asset.
+func (r Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify verifies the asset at the provided path and returns a report of the problems found. The asset must be an
+// executable file that handles the "_assetInfo" command and reports a supported type. Assets are also checked for
+// compliance with the protocol of their type: backcompat assets must report that IR is backwards compatible with
+// itself, generator assets must succeed when generating output for IR that contains no definitions, publisher assets
+// must succeed when performing a dry run of publishing such IR and IR validator assets must report that such IR is
+// valid.
+func Verify(path string) Report {
+	report := Report{
+		Path: path,
+		Name: filepath.Base(path),
+	}
+	fi, err := os.Stat(path)
+	switch {
+	case err != nil:
+		report.Problems = append(report.Problems, fmt.Sprintf("failed to stat asset: %v", err))
+		return report
+	case !fi.Mode().IsRegular():
+		report.Problems = append(report.Problems, fmt.Sprintf("asset is not a regular file (mode %s)", fi.Mode()))
+		return report
+	case fi.Mode().Perm()&0111 == 0:
+		report.Problems = append(report.Problems, fmt.Sprintf("asset is not executable (mode %s)", fi.Mode()))
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	info, err := readAssetInfo(exec.CommandContext(ctx, path, assetInfoCommand))
+	if err != nil {
+		report.Problems = append(report.Problems, err.Error())
+		return report
+	}
+	report.Type = info.Type
+	report.Version = info.Version
+	if info.Name != "" {
+		report.Name = info.Name
+	}
+	switch info.Type {
+	case BackCompatAssetType:
+		if err := verifyBackCompatAsset(path); err != nil {
+			report.Problems = append(report.Problems, err.Error())
+		}
+	case GeneratorAssetType:
+		if err := verifyGeneratorAsset(path); err != nil {
+			report.Problems = append(report.Problems, err.Error())
+		}
+	case PublisherAssetType:
+		if err := verifyPublisherAsset(path); err != nil {
+			report.Problems = append(report.Problems, err.Error())
+		}
+	case IRValidatorAssetType:
+		if err := verifyIRValidatorAsset(path); err != nil {
+			report.Problems = append(report.Problems, err.Error())
+		}
+	case "":
+		report.Problems = append(report.Problems, fmt.Sprintf("output of %s does not specify a type", assetInfoCommand))
+	default:
+		report.Problems = append(report.Problems, fmt.Sprintf("asset has unsupported type %q", info.Type))
+	}
+	return report
+}
+
+// verifyBackCompatAsset returns an error if the backcompat asset at the provided path does not report that IR is
+// backwards compatible with itself.
+func verifyBackCompatAsset(path string) (rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("verify-asset")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	irPath := filepath.Join(tmpDir, "ir.json")
+	if err := os.WriteFile(irPath, []byte(emptyIR), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write IR")
+	}
+	argsJSON, err := json.Marshal(map[string]string{
+		"project":    "asset-verification",
+		"projectDir": tmpDir,
+		"baseIR":     irPath,
+		"currentIR":  irPath,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "checkBackCompat", string(argsJSON))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "checkBackCompat did not report that IR is backwards compatible with itself\nOutput:\n%s", string(output))
+	}
+	return nil
+}
+
+// verifyGeneratorAsset returns an error if the generator asset at the provided path fails to generate output for IR that
+// contains no definitions.
+func verifyGeneratorAsset(path string) (rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("verify-asset")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	irPath := filepath.Join(tmpDir, "ir.json")
+	if err := os.WriteFile(irPath, []byte(emptyIR), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write IR")
+	}
+	outputDir := filepath.Join(tmpDir, "output")
+	if err := os.Mkdir(outputDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create output directory")
+	}
+	argsJSON, err := json.Marshal(map[string]string{
+		"project":    "asset-verification",
+		"projectDir": tmpDir,
+		"ir":         irPath,
+		"outputDir":  outputDir,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "generate", string(argsJSON))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "generate failed for IR that contains no definitions\nOutput:\n%s", string(output))
+	}
+	return nil
+}
+
+// verifyPublisherAsset returns an error if the publisher asset at the provided path fails to perform a dry run of
+// publishing IR that contains no definitions.
+func verifyPublisherAsset(path string) (rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("verify-asset")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	irPath := filepath.Join(tmpDir, "asset-verification-0.0.0.conjure.json")
+	if err := os.WriteFile(irPath, []byte(emptyIR), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write IR")
+	}
+	argsJSON, err := json.Marshal(map[string]interface{}{
+		"project":    "asset-verification",
+		"artifactId": "asset-verification",
+		"version":    "0.0.0",
+		"irFile":     irPath,
+		"artifacts":  []string{irPath},
+		"flags":      map[string]interface{}{},
+		"dryRun":     true,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "publish", string(argsJSON))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "publish failed for a dry run of IR that contains no definitions\nOutput:\n%s", string(output))
+	}
+	return nil
+}
+
+// verifyIRValidatorAsset returns an error if the IR validator asset at the provided path does not report that IR that
+// contains no definitions is valid.
+func verifyIRValidatorAsset(path string) (rErr error) {
+	tmpDir, err := tempfilecreator.MkdirTemp("verify-asset")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	irPath := filepath.Join(tmpDir, "ir.json")
+	if err := os.WriteFile(irPath, []byte(emptyIR), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write IR")
+	}
+	argsJSON, err := json.Marshal(map[string]string{
+		"project":    "asset-verification",
+		"projectDir": tmpDir,
+		"ir":         irPath,
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, "validateIR", string(argsJSON))
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "validateIR did not report that IR that contains no definitions is valid\nOutput:\n%s", string(output))
+	}
+	return nil
+}
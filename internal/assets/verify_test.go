@@ -0,0 +1,178 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package assets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/assets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	for i, tc := range []struct {
+		name         string
+		script       string
+		mode         os.FileMode
+		wantName     string
+		wantType     assets.AssetType
+		wantVersion  string
+		wantProblems []string
+	}{
+		{
+			name:        "valid backcompat asset",
+			script:      `[ "$1" = "_assetInfo" ] && echo '{"type":"backcompat","name":"checker","version":"1.2.3"}'; exit 0`,
+			mode:        0755,
+			wantName:    "checker",
+			wantType:    assets.BackCompatAssetType,
+			wantVersion: "1.2.3",
+		},
+		{
+			name:     "valid generator asset",
+			script:   `[ "$1" = "_assetInfo" ] && echo '{"type":"generator","name":"conjure-typescript"}'; exit 0`,
+			mode:     0755,
+			wantName: "conjure-typescript",
+			wantType: assets.GeneratorAssetType,
+		},
+		{
+			name:     "valid publisher asset",
+			script:   `[ "$1" = "_assetInfo" ] && echo '{"type":"publisher","name":"ir-catalog"}'; exit 0`,
+			mode:     0755,
+			wantName: "ir-catalog",
+			wantType: assets.PublisherAssetType,
+		},
+		{
+			name:     "valid IR validator asset",
+			script:   `[ "$1" = "_assetInfo" ] && echo '{"type":"ir-validator","name":"api-style"}'; exit 0`,
+			mode:     0755,
+			wantName: "api-style",
+			wantType: assets.IRValidatorAssetType,
+		},
+		{
+			name:         "not executable",
+			script:       `echo '{"type":"backcompat"}'`,
+			mode:         0644,
+			wantName:     "asset",
+			wantProblems: []string{"asset is not executable (mode -rw-r--r--)"},
+		},
+		{
+			name:         "unsupported type",
+			script:       `echo '{"type":"unknown"}'`,
+			mode:         0755,
+			wantName:     "asset",
+			wantType:     "unknown",
+			wantProblems: []string{`asset has unsupported type "unknown"`},
+		},
+		{
+			name:     "missing type",
+			script:   `echo '{}'`,
+			mode:     0755,
+			wantName: "asset",
+			wantProblems: []string{
+				"output of _assetInfo does not specify a type",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assetPath := filepath.Join(t.TempDir(), "asset")
+			require.NoError(t, os.WriteFile(assetPath, []byte("#!/bin/sh\n"+tc.script+"\n"), tc.mode), "Case %d", i)
+
+			report := assets.Verify(assetPath)
+			assert.Equal(t, assetPath, report.Path, "Case %d", i)
+			assert.Equal(t, tc.wantName, report.Name, "Case %d", i)
+			assert.Equal(t, tc.wantType, report.Type, "Case %d", i)
+			assert.Equal(t, tc.wantVersion, report.Version, "Case %d", i)
+			assert.Equal(t, tc.wantProblems, report.Problems, "Case %d", i)
+			assert.Equal(t, len(tc.wantProblems) == 0, report.OK(), "Case %d", i)
+		})
+	}
+}
+
+func TestVerifyProtocolViolations(t *testing.T) {
+	dir := t.TempDir()
+
+	invalidJSONPath := filepath.Join(dir, "invalid-json")
+	require.NoError(t, os.WriteFile(invalidJSONPath, []byte("#!/bin/sh\necho 'not json'\n"), 0755))
+	report := assets.Verify(invalidJSONPath)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "failed to parse output of "+invalidJSONPath+" _assetInfo as asset information")
+
+	incompatiblePath := filepath.Join(dir, "incompatible")
+	require.NoError(t, os.WriteFile(incompatiblePath, []byte(`#!/bin/sh
+if [ "$1" = "_assetInfo" ]; then
+  echo '{"type":"backcompat"}'
+  exit 0
+fi
+echo 'everything is a break'
+exit 1
+`), 0755))
+	report = assets.Verify(incompatiblePath)
+	assert.Equal(t, assets.BackCompatAssetType, report.Type)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "checkBackCompat did not report that IR is backwards compatible with itself")
+	assert.Contains(t, report.Problems[0], "everything is a break")
+
+	failingGeneratorPath := filepath.Join(dir, "failing-generator")
+	require.NoError(t, os.WriteFile(failingGeneratorPath, []byte(`#!/bin/sh
+if [ "$1" = "_assetInfo" ]; then
+  echo '{"type":"generator"}'
+  exit 0
+fi
+echo 'unsupported IR'
+exit 1
+`), 0755))
+	report = assets.Verify(failingGeneratorPath)
+	assert.Equal(t, assets.GeneratorAssetType, report.Type)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "generate failed for IR that contains no definitions")
+	assert.Contains(t, report.Problems[0], "unsupported IR")
+
+	failingPublisherPath := filepath.Join(dir, "failing-publisher")
+	require.NoError(t, os.WriteFile(failingPublisherPath, []byte(`#!/bin/sh
+if [ "$1" = "_assetInfo" ]; then
+  echo '{"type":"publisher"}'
+  exit 0
+fi
+echo 'catalog unreachable'
+exit 1
+`), 0755))
+	report = assets.Verify(failingPublisherPath)
+	assert.Equal(t, assets.PublisherAssetType, report.Type)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "publish failed for a dry run of IR that contains no definitions")
+	assert.Contains(t, report.Problems[0], "catalog unreachable")
+
+	rejectingValidatorPath := filepath.Join(dir, "rejecting-validator")
+	require.NoError(t, os.WriteFile(rejectingValidatorPath, []byte(`#!/bin/sh
+if [ "$1" = "_assetInfo" ]; then
+  echo '{"type":"ir-validator"}'
+  exit 0
+fi
+echo 'IR must define a service'
+exit 1
+`), 0755))
+	report = assets.Verify(rejectingValidatorPath)
+	assert.Equal(t, assets.IRValidatorAssetType, report.Type)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "validateIR did not report that IR that contains no definitions is valid")
+	assert.Contains(t, report.Problems[0], "IR must define a service")
+
+	report = assets.Verify(filepath.Join(dir, "missing"))
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0], "failed to stat asset")
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gencache records the state of the code generated for projects so that generation can be skipped when its
+// inputs have not changed. The state of a project consists of a key that identifies the inputs used to generate it
+// (such as a hash of its IR and generation options) and the hashes of the files that were generated. The cache is
+// stored in the "cache/conjure-plugin/generate" directory of the gödel home directory.
+package gencache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/godel/v2/framework/builtintasks/installupdate/layout"
+	"github.com/pkg/errors"
+)
+
+// State is the recorded state of the code generated for a project.
+type State struct {
+	// Key identifies the inputs that were used to generate the files.
+	Key string `json:"key"`
+	// Files are the SHA-256 checksums of the generated files keyed by their absolute paths.
+	Files map[string]string `json:"files"`
+}
+
+// NewState returns the state for the provided key and generated files (keyed by their absolute paths).
+func NewState(key string, files map[string][]byte) State {
+	state := State{
+		Key:   key,
+		Files: make(map[string]string, len(files)),
+	}
+	for path, content := range files {
+		state.Files[path] = checksum(content)
+	}
+	return state
+}
+
+// Unchanged returns true if the state has the provided key and every recorded file exists on disk with the recorded
+// content.
+func (s State) Unchanged(key string) bool {
+	if s.Key != key {
+		return false
+	}
+	for path, want := range s.Files {
+		content, err := os.ReadFile(path)
+		if err != nil || checksum(content) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// Dir returns the directory in which the cache is stored.
+func Dir() (string, error) {
+	godelHome, err := layout.GodelHomePath()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine generation cache directory")
+	}
+	return filepath.Join(godelHome, layout.CacheDir, "conjure-plugin", "generate"), nil
+}
+
+// Get returns the recorded state for the provided ID. Returns false if no state is recorded.
+func Get(id string) (State, bool) {
+	path, err := entryPath(id)
+	if err != nil {
+		return State{}, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return State{}, false
+	}
+	var state State
+	if err := json.Unmarshal(content, &state); err != nil {
+		return State{}, false
+	}
+	return state, true
+}
+
+// Put records the provided state for the provided ID.
+func Put(id string, state State) error {
+	path, err := entryPath(id)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal generation cache entry")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create generation cache directory")
+	}
+	// write to a temporary file and rename it so that concurrent readers never observe a partially written entry
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create generation cache entry")
+	}
+	_, writeErr := tmpFile.Write(content)
+	closeErr := tmpFile.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmpFile.Name())
+		return errors.Errorf("failed to write generation cache entry: %v", firstErr(writeErr, closeErr))
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return errors.Wrapf(err, "failed to write generation cache entry")
+	}
+	return nil
+}
+
+// Delete removes the recorded state for the provided ID if it exists.
+func Delete(id string) error {
+	path, err := entryPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove generation cache entry")
+	}
+	return nil
+}
+
+func entryPath(id string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(id)))), nil
+}
+
+func checksum(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gencache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/gencache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Setenv("GODEL_HOME", t.TempDir())
+	file := filepath.Join(t.TempDir(), "file.go")
+	require.NoError(t, os.WriteFile(file, []byte("package foo\n"), 0644))
+
+	_, ok := gencache.Get("project")
+	assert.False(t, ok)
+
+	require.NoError(t, gencache.Put("project", gencache.NewState("key", map[string][]byte{
+		file: []byte("package foo\n"),
+	})))
+	state, ok := gencache.Get("project")
+	require.True(t, ok)
+	assert.True(t, state.Unchanged("key"))
+	assert.False(t, state.Unchanged("other-key"), "state with a different key should be changed")
+
+	_, ok = gencache.Get("other-project")
+	assert.False(t, ok, "entries should be keyed by ID")
+
+	require.NoError(t, os.WriteFile(file, []byte("package bar\n"), 0644))
+	assert.False(t, state.Unchanged("key"), "state should be changed if a file is modified")
+	require.NoError(t, os.Remove(file))
+	assert.False(t, state.Unchanged("key"), "state should be changed if a file is removed")
+
+	require.NoError(t, gencache.Delete("project"))
+	_, ok = gencache.Get("project")
+	assert.False(t, ok)
+	assert.NoError(t, gencache.Delete("project"), "deleting a missing entry should succeed")
+}
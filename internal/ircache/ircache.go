@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ircache stores IR downloaded from remote sources on disk so that it does not have to be downloaded again by
+// subsequent invocations. Entries are keyed by the URL of the IR and expire after a time-to-live specified when they are
+// read. The cache is stored in the "cache/conjure-plugin/ir" directory of the gödel home directory.
+package ircache
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/palantir/godel/v2/framework/builtintasks/installupdate/layout"
+	"github.com/pkg/errors"
+)
+
+var (
+	refreshMutex sync.RWMutex
+	refresh      bool
+)
+
+// SetRefresh sets whether existing cache entries should be ignored. If true, Get never returns a cached value, but
+// Put still stores values so that the cache is refreshed.
+func SetRefresh(val bool) {
+	refreshMutex.Lock()
+	defer refreshMutex.Unlock()
+	refresh = val
+}
+
+func refreshEnabled() bool {
+	refreshMutex.RLock()
+	defer refreshMutex.RUnlock()
+	return refresh
+}
+
+// Dir returns the directory in which the cache is stored.
+func Dir() (string, error) {
+	godelHome, err := layout.GodelHomePath()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine IR cache directory")
+	}
+	return filepath.Join(godelHome, layout.CacheDir, "conjure-plugin", "ir"), nil
+}
+
+// Get returns the cached content for the provided key if it exists and was stored less than ttl ago. Returns false if
+// there is no such entry or if refreshing is enabled.
+func Get(key string, ttl time.Duration) ([]byte, bool) {
+	if refreshEnabled() {
+		return nil, false
+	}
+	path, err := entryPath(key)
+	if err != nil {
+		return nil, false
+	}
+	fi, err := os.Stat(path)
+	if err != nil || time.Since(fi.ModTime()) >= ttl {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
+// Put stores the provided content for the provided key.
+func Put(key string, content []byte) error {
+	path, err := entryPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create IR cache directory")
+	}
+	// write to a temporary file and rename it so that concurrent readers never observe a partially written entry
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create IR cache entry")
+	}
+	_, writeErr := tmpFile.Write(content)
+	closeErr := tmpFile.Close()
+	if writeErr != nil || closeErr != nil {
+		_ = os.Remove(tmpFile.Name())
+		return errors.Errorf("failed to write IR cache entry: %v", firstErr(writeErr, closeErr))
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return errors.Wrapf(err, "failed to write IR cache entry")
+	}
+	return nil
+}
+
+func entryPath(key string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key)))), nil
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
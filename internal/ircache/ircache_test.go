@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ircache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/ircache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Setenv("GODEL_HOME", t.TempDir())
+
+	_, ok := ircache.Get("https://example.com/ir.json", time.Hour)
+	assert.False(t, ok)
+
+	require.NoError(t, ircache.Put("https://example.com/ir.json", []byte("content")))
+	got, ok := ircache.Get("https://example.com/ir.json", time.Hour)
+	assert.True(t, ok)
+	assert.Equal(t, "content", string(got))
+
+	_, ok = ircache.Get("https://example.com/other-ir.json", time.Hour)
+	assert.False(t, ok, "entries should be keyed by URL")
+
+	_, ok = ircache.Get("https://example.com/ir.json", 0)
+	assert.False(t, ok, "expired entries should not be returned")
+
+	ircache.SetRefresh(true)
+	defer ircache.SetRefresh(false)
+	_, ok = ircache.Get("https://example.com/ir.json", time.Hour)
+	assert.False(t, ok, "entries should not be returned when refreshing")
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nativeir
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// formatJSON re-formats the provided JSON in the style of the pretty printer used by the Conjure CLI: members are
+// written as "key" : value with objects indented by 2 spaces, arrays are written inline and null members are omitted.
+func formatJSON(in []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	val, err := decodeValue(dec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode JSON")
+	}
+	buf := &bytes.Buffer{}
+	if err := writeValue(buf, val, 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type member struct {
+	key string
+	val interface{}
+}
+
+// object is a JSON object whose members are in the order in which they were decoded.
+type object []member
+
+// decodeValue decodes the next value from dec, preserving the order of object members and omitting null members.
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			val, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			if val == nil {
+				continue
+			}
+			obj = append(obj, member{key: keyTok.(string), val: val})
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case json.Delim('['):
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return tok, nil
+	}
+}
+
+func writeValue(w *bytes.Buffer, val interface{}, depth int) error {
+	switch v := val.(type) {
+	case object:
+		if len(v) == 0 {
+			w.WriteString("{ }")
+			return nil
+		}
+		w.WriteString("{")
+		for i, m := range v {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n")
+			w.WriteString(strings.Repeat("  ", depth+1))
+			if err := writeString(w, m.key); err != nil {
+				return err
+			}
+			w.WriteString(" : ")
+			if err := writeValue(w, m.val, depth+1); err != nil {
+				return err
+			}
+		}
+		w.WriteString("\n")
+		w.WriteString(strings.Repeat("  ", depth))
+		w.WriteString("}")
+	case []interface{}:
+		if len(v) == 0 {
+			w.WriteString("[ ]")
+			return nil
+		}
+		w.WriteString("[ ")
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			if err := writeValue(w, elem, depth); err != nil {
+				return err
+			}
+		}
+		w.WriteString(" ]")
+	case string:
+		return writeString(w, v)
+	case json.Number:
+		w.WriteString(v.String())
+	case bool:
+		if v {
+			w.WriteString("true")
+		} else {
+			w.WriteString("false")
+		}
+	case nil:
+		w.WriteString("null")
+	default:
+		return errors.Errorf("unexpected JSON value of type %T", val)
+	}
+	return nil
+}
+
+func writeString(w io.Writer, s string) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
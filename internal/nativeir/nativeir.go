@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nativeir compiles Conjure YAML to IR in Go, without running the Conjure CLI. It supports a subset of Conjure:
+// type definitions (objects, aliases, enums and unions) that only refer to primitive types, containers and other types
+// defined in the same file. Compile returns an error for any input that uses other constructs (such as services,
+// errors, imports or external types) or that it cannot verify is valid, in which case callers should compile the input
+// using the Conjure CLI instead.
+package nativeir
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Compile returns the IR for the Conjure YAML file or directory of YAML files (which is searched recursively) at the
+// provided path. If extensionsJSON is non-empty, it must be a JSON object, which is used as the extensions of the IR.
+// The IR is formatted in the same manner as the IR written by the Conjure CLI.
+func Compile(inPath string, extensionsJSON []byte) ([]byte, error) {
+	files, err := inputFiles(inPath)
+	if err != nil {
+		return nil, err
+	}
+	def := spec.ConjureDefinition{
+		Version:    1,
+		Errors:     []spec.ErrorDefinition{},
+		Types:      []spec.TypeDefinition{},
+		Services:   []spec.ServiceDefinition{},
+		Extensions: map[string]interface{}{},
+	}
+	if len(extensionsJSON) > 0 {
+		if err := json.Unmarshal(extensionsJSON, &def.Extensions); err != nil {
+			return nil, errors.Wrapf(err, "extensions must be a JSON object")
+		}
+	}
+	definedTypes := make(map[string]string)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		typeDefs, err := compileFile(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compile %s", file)
+		}
+		for _, typeDef := range typeDefs {
+			name := typeDefinitionName(typeDef)
+			qualifiedName := name.Package + "." + name.Name
+			if prevFile, ok := definedTypes[qualifiedName]; ok {
+				return nil, errors.Errorf("type %s is defined in both %s and %s", qualifiedName, prevFile, file)
+			}
+			definedTypes[qualifiedName] = file
+		}
+		def.Types = append(def.Types, typeDefs...)
+	}
+	jsonBytes, err := json.Marshal(def)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal IR")
+	}
+	return formatJSON(jsonBytes)
+}
+
+// inputFiles returns the paths of the YAML files to compile for the provided input path in lexical order. Returns an
+// error if a directory contains files with the ".yaml" extension, since whether they are compiled by the Conjure CLI
+// cannot be determined.
+func inputFiles(inPath string) ([]string, error) {
+	fi, err := os.Stat(inPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !fi.IsDir() {
+		return []string{inPath}, nil
+	}
+	var files []string
+	if err := filepath.WalkDir(inPath, func(currPath string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return nil
+		case strings.HasSuffix(d.Name(), ".yml"):
+			files = append(files, currPath)
+		case strings.HasSuffix(d.Name(), ".yaml"):
+			return errors.Errorf("files with the .yaml extension are not supported: %s", currPath)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// compileFile returns the type definitions defined by the provided Conjure YAML file.
+func compileFile(content []byte) ([]spec.TypeDefinition, error) {
+	var root yaml.MapSlice
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, errors.Wrapf(err, "invalid YAML")
+	}
+	var typesVal interface{}
+	for _, item := range root {
+		switch item.Key {
+		case "types":
+			typesVal = item.Value
+		case "services":
+			if !isEmpty(item.Value) {
+				return nil, unsupportedf("services")
+			}
+		default:
+			return nil, unsupportedf("top-level key %v", item.Key)
+		}
+	}
+	if isEmpty(typesVal) {
+		return nil, nil
+	}
+	types, err := toMapSlice(typesVal, "types")
+	if err != nil {
+		return nil, err
+	}
+	var definitionsVal interface{}
+	for _, item := range types {
+		switch item.Key {
+		case "definitions":
+			definitionsVal = item.Value
+		default:
+			return nil, unsupportedf("key %v in types", item.Key)
+		}
+	}
+	if isEmpty(definitionsVal) {
+		return nil, nil
+	}
+	definitions, err := toMapSlice(definitionsVal, "types.definitions")
+	if err != nil {
+		return nil, err
+	}
+	var defaultPackage string
+	var objects yaml.MapSlice
+	for _, item := range definitions {
+		switch item.Key {
+		case "default-package":
+			if defaultPackage, err = toString(item.Value, "default-package"); err != nil {
+				return nil, err
+			}
+		case "objects":
+			if isEmpty(item.Value) {
+				continue
+			}
+			if objects, err = toMapSlice(item.Value, "objects"); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, unsupportedf("key %v in types.definitions", item.Key)
+		}
+	}
+	return compileObjects(objects, defaultPackage)
+}
+
+// unsupportedf returns an error that indicates that the described construct is not supported.
+func unsupportedf(format string, args ...interface{}) error {
+	return errors.Errorf("unsupported construct: "+format, args...)
+}
+
+func isEmpty(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case yaml.MapSlice:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func toMapSlice(val interface{}, desc string) (yaml.MapSlice, error) {
+	mapSlice, ok := val.(yaml.MapSlice)
+	if !ok {
+		return nil, errors.Errorf("%s must be a map", desc)
+	}
+	return mapSlice, nil
+}
+
+func toString(val interface{}, desc string) (string, error) {
+	str, ok := val.(string)
+	if !ok {
+		return "", errors.Errorf("%s must be a string", desc)
+	}
+	return str, nil
+}
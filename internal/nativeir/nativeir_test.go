@@ -0,0 +1,277 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nativeir_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/nativeir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	for i, tc := range []struct {
+		name       string
+		in         string
+		extensions string
+		want       string
+	}{
+		{
+			name: "object",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`,
+			want: `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "BooleanExample",
+        "package" : "com.palantir.conjure"
+      },
+      "fields" : [ {
+        "fieldName" : "value",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "BOOLEAN"
+        }
+      } ]
+    }
+  } ],
+  "services" : [ ],
+  "extensions" : { }
+}`,
+		},
+		{
+			name: "object with extensions",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`,
+			extensions: `{"recommended-product-dependencies":[{"product-group":"com.palantir.assetserver","product-name":"asset-server","minimum-version":"2.78.0","maximum-version":"2.x.x"}]}`,
+			want: `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "BooleanExample",
+        "package" : "com.palantir.conjure"
+      },
+      "fields" : [ {
+        "fieldName" : "value",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "BOOLEAN"
+        }
+      } ]
+    }
+  } ],
+  "services" : [ ],
+  "extensions" : {
+    "recommended-product-dependencies" : [ {
+      "maximum-version" : "2.x.x",
+      "minimum-version" : "2.78.0",
+      "product-group" : "com.palantir.assetserver",
+      "product-name" : "asset-server"
+    } ]
+  }
+}`,
+		},
+		{
+			name: "alias, enum and union",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      Names:
+        alias: map<Color, list<string>>
+        docs: Names by <color>.
+      Color:
+        values:
+          - RED
+          - value: DARK_BLUE
+            docs: Dark blue.
+      Shape:
+        union:
+          circle: double
+          square: { type: double, deprecated: Use circle. }
+`,
+			want: `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "alias",
+    "alias" : {
+      "typeName" : {
+        "name" : "Names",
+        "package" : "com.palantir.conjure"
+      },
+      "alias" : {
+        "type" : "map",
+        "map" : {
+          "keyType" : {
+            "type" : "reference",
+            "reference" : {
+              "name" : "Color",
+              "package" : "com.palantir.conjure"
+            }
+          },
+          "valueType" : {
+            "type" : "list",
+            "list" : {
+              "itemType" : {
+                "type" : "primitive",
+                "primitive" : "STRING"
+              }
+            }
+          }
+        }
+      },
+      "docs" : "Names by <color>."
+    }
+  }, {
+    "type" : "enum",
+    "enum" : {
+      "typeName" : {
+        "name" : "Color",
+        "package" : "com.palantir.conjure"
+      },
+      "values" : [ {
+        "value" : "RED"
+      }, {
+        "value" : "DARK_BLUE",
+        "docs" : "Dark blue."
+      } ]
+    }
+  }, {
+    "type" : "union",
+    "union" : {
+      "typeName" : {
+        "name" : "Shape",
+        "package" : "com.palantir.conjure"
+      },
+      "union" : [ {
+        "fieldName" : "circle",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "DOUBLE"
+        }
+      }, {
+        "fieldName" : "square",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "DOUBLE"
+        },
+        "deprecated" : "Use circle."
+      } ]
+    }
+  } ],
+  "services" : [ ],
+  "extensions" : { }
+}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inPath := filepath.Join(t.TempDir(), "in.yml")
+			require.NoError(t, os.WriteFile(inPath, []byte(tc.in), 0644))
+			got, err := nativeir.Compile(inPath, []byte(tc.extensions))
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, tc.want, string(got), "Case %d\nGot:\n%s", i, got)
+		})
+	}
+}
+
+func TestCompileUnsupported(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{
+			name: "services",
+			in: `
+services:
+  TestService:
+    name: Test Service
+    package: com.palantir.conjure
+    endpoints:
+      ping:
+        http: GET /ping
+`,
+			wantErr: "unsupported construct: services",
+		},
+		{
+			name: "imports",
+			in: `
+types:
+  imports:
+    ExternalLong:
+      base-type: string
+      external:
+        java: java.lang.Long
+`,
+			wantErr: "unsupported construct: key imports in types",
+		},
+		{
+			name: "optional union member",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      Shape:
+        union:
+          square: optional<double>
+`,
+			wantErr: "unsupported construct: optional type that is not the type of a field or alias",
+		},
+		{
+			name: "recursive type",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      Node:
+        fields:
+          children: list<Node>
+`,
+			wantErr: "unsupported construct: recursive type Node",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inPath := filepath.Join(t.TempDir(), "in.yml")
+			require.NoError(t, os.WriteFile(inPath, []byte(tc.in), 0644))
+			_, err := nativeir.Compile(inPath, nil)
+			require.Error(t, err, "Case %d", i)
+			assert.Contains(t, err.Error(), tc.wantErr, "Case %d", i)
+		})
+	}
+}
@@ -0,0 +1,493 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nativeir
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	typeNameRegexp  = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	packageRegexp   = regexp.MustCompile(`^[a-z][a-z0-9]*(\.[a-z][a-z0-9]*)*$`)
+	fieldNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9]*([A-Z0-9][a-z0-9]*)*$`)
+	enumValueRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+)
+
+var primitiveTypes = map[string]spec.PrimitiveType_Value{
+	"string":      spec.PrimitiveType_STRING,
+	"datetime":    spec.PrimitiveType_DATETIME,
+	"integer":     spec.PrimitiveType_INTEGER,
+	"double":      spec.PrimitiveType_DOUBLE,
+	"safelong":    spec.PrimitiveType_SAFELONG,
+	"binary":      spec.PrimitiveType_BINARY,
+	"any":         spec.PrimitiveType_ANY,
+	"boolean":     spec.PrimitiveType_BOOLEAN,
+	"uuid":        spec.PrimitiveType_UUID,
+	"rid":         spec.PrimitiveType_RID,
+	"bearertoken": spec.PrimitiveType_BEARERTOKEN,
+}
+
+// mapKeyPrimitiveTypes are the primitive types that are supported as map keys.
+var mapKeyPrimitiveTypes = map[spec.PrimitiveType_Value]struct{}{
+	spec.PrimitiveType_STRING:   {},
+	spec.PrimitiveType_DATETIME: {},
+	spec.PrimitiveType_INTEGER:  {},
+	spec.PrimitiveType_SAFELONG: {},
+	spec.PrimitiveType_BOOLEAN:  {},
+	spec.PrimitiveType_UUID:     {},
+	spec.PrimitiveType_RID:      {},
+}
+
+// typePosition describes where a type appears, which determines the types that are supported.
+type typePosition int
+
+const (
+	// positionTopLevel is the type of a field or alias, which can be optional or binary.
+	positionTopLevel typePosition = iota
+	// positionUnionMember is the type of a union member, which cannot be optional.
+	positionUnionMember
+	// positionContainerItem is the type of an element of a container, which cannot be optional or binary.
+	positionContainerItem
+	// positionMapKey is the key type of a map.
+	positionMapKey
+)
+
+type objectDef struct {
+	name     spec.TypeName
+	kind     string
+	body     yaml.MapSlice
+	docs     *spec.Documentation
+	typeDefn spec.TypeDefinition
+}
+
+// compileObjects returns the type definitions for the provided "objects" of a Conjure YAML file in the order in which
+// they are defined.
+func compileObjects(objects yaml.MapSlice, defaultPackage string) ([]spec.TypeDefinition, error) {
+	defs := make([]*objectDef, 0, len(objects))
+	defsByName := make(map[string]*objectDef)
+	for _, item := range objects {
+		name, err := toString(item.Key, "type name")
+		if err != nil {
+			return nil, err
+		}
+		if !typeNameRegexp.MatchString(name) {
+			return nil, errors.Errorf("type name %s must be UpperCamelCase", name)
+		}
+		if _, ok := defsByName[name]; ok {
+			return nil, errors.Errorf("type %s is defined multiple times", name)
+		}
+		body, err := toMapSlice(item.Value, "definition of "+name)
+		if err != nil {
+			return nil, err
+		}
+		def := &objectDef{
+			name: spec.TypeName{
+				Name:    name,
+				Package: defaultPackage,
+			},
+			body: yaml.MapSlice{},
+		}
+		for _, bodyItem := range body {
+			switch bodyItem.Key {
+			case "package":
+				if def.name.Package, err = toString(bodyItem.Value, "package of "+name); err != nil {
+					return nil, err
+				}
+			case "docs":
+				if def.docs, err = toDocs(bodyItem.Value, "docs of "+name); err != nil {
+					return nil, err
+				}
+			case "fields", "alias", "values", "union":
+				if def.kind != "" {
+					return nil, errors.Errorf("%s must specify exactly one of fields, alias, values and union", name)
+				}
+				def.kind = bodyItem.Key.(string)
+				def.body = append(def.body, bodyItem)
+			default:
+				return nil, unsupportedf("key %v in definition of %s", bodyItem.Key, name)
+			}
+		}
+		if def.kind == "" {
+			return nil, errors.Errorf("%s must specify one of fields, alias, values and union", name)
+		}
+		if def.name.Package == "" {
+			return nil, errors.Errorf("%s does not specify a package and there is no default-package", name)
+		}
+		if !packageRegexp.MatchString(def.name.Package) {
+			return nil, errors.Errorf("package %s of %s is invalid", def.name.Package, name)
+		}
+		defs = append(defs, def)
+		defsByName[name] = def
+	}
+
+	c := &compiler{defs: defsByName}
+	var typeDefs []spec.TypeDefinition
+	for _, def := range defs {
+		typeDef, err := c.compileObject(def)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid definition of %s", def.name.Name)
+		}
+		typeDefs = append(typeDefs, typeDef)
+	}
+	if err := c.checkAcyclic(); err != nil {
+		return nil, err
+	}
+	return typeDefs, nil
+}
+
+type compiler struct {
+	defs map[string]*objectDef
+	// references records the names of the types referenced by every type.
+	references map[string][]string
+	// currName is the name of the type being compiled.
+	currName string
+}
+
+func (c *compiler) compileObject(def *objectDef) (spec.TypeDefinition, error) {
+	c.currName = def.name.Name
+	val := def.body[0].Value
+	switch def.kind {
+	case "fields":
+		fields, err := c.compileFields(val, positionTopLevel)
+		if err != nil {
+			return spec.TypeDefinition{}, err
+		}
+		return spec.NewTypeDefinitionFromObject(spec.ObjectDefinition{
+			TypeName: def.name,
+			Fields:   fields,
+			Docs:     def.docs,
+		}), nil
+	case "alias":
+		aliasType, err := c.compileTypeValue(val, positionTopLevel)
+		if err != nil {
+			return spec.TypeDefinition{}, err
+		}
+		return spec.NewTypeDefinitionFromAlias(spec.AliasDefinition{
+			TypeName: def.name,
+			Alias:    aliasType,
+			Docs:     def.docs,
+		}), nil
+	case "values":
+		values, err := compileEnumValues(val)
+		if err != nil {
+			return spec.TypeDefinition{}, err
+		}
+		return spec.NewTypeDefinitionFromEnum(spec.EnumDefinition{
+			TypeName: def.name,
+			Values:   values,
+			Docs:     def.docs,
+		}), nil
+	default:
+		members, err := c.compileFields(val, positionUnionMember)
+		if err != nil {
+			return spec.TypeDefinition{}, err
+		}
+		if len(members) == 0 {
+			return spec.TypeDefinition{}, errors.Errorf("union must have at least one member")
+		}
+		return spec.NewTypeDefinitionFromUnion(spec.UnionDefinition{
+			TypeName: def.name,
+			Union:    members,
+			Docs:     def.docs,
+		}), nil
+	}
+}
+
+func (c *compiler) compileFields(val interface{}, position typePosition) ([]spec.FieldDefinition, error) {
+	if val == nil {
+		return []spec.FieldDefinition{}, nil
+	}
+	items, err := toMapSlice(val, "fields")
+	if err != nil {
+		return nil, err
+	}
+	fields := []spec.FieldDefinition{}
+	seen := make(map[string]struct{})
+	for _, item := range items {
+		name, err := toString(item.Key, "field name")
+		if err != nil {
+			return nil, err
+		}
+		if !fieldNameRegexp.MatchString(name) {
+			return nil, unsupportedf("field name %s that is not lowerCamelCase", name)
+		}
+		if _, ok := seen[strings.ToLower(name)]; ok {
+			return nil, errors.Errorf("field %s is defined multiple times", name)
+		}
+		seen[strings.ToLower(name)] = struct{}{}
+
+		field := spec.FieldDefinition{
+			FieldName: spec.FieldName(name),
+		}
+		switch v := item.Value.(type) {
+		case string:
+			if field.Type, err = c.compileType(v, position); err != nil {
+				return nil, errors.Wrapf(err, "invalid type of field %s", name)
+			}
+		case yaml.MapSlice:
+			var hasType bool
+			for _, fieldItem := range v {
+				switch fieldItem.Key {
+				case "type":
+					hasType = true
+					if field.Type, err = c.compileTypeValue(fieldItem.Value, position); err != nil {
+						return nil, errors.Wrapf(err, "invalid type of field %s", name)
+					}
+				case "docs":
+					if field.Docs, err = toDocs(fieldItem.Value, "docs of field "+name); err != nil {
+						return nil, err
+					}
+				case "deprecated":
+					if field.Deprecated, err = toDocs(fieldItem.Value, "deprecated of field "+name); err != nil {
+						return nil, err
+					}
+				default:
+					return nil, unsupportedf("key %v in field %s", fieldItem.Key, name)
+				}
+			}
+			if !hasType {
+				return nil, errors.Errorf("field %s must specify a type", name)
+			}
+		default:
+			return nil, errors.Errorf("field %s must be a type or a map", name)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func compileEnumValues(val interface{}) ([]spec.EnumValueDefinition, error) {
+	items, ok := val.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.Errorf("values must be a non-empty list")
+	}
+	var values []spec.EnumValueDefinition
+	seen := make(map[string]struct{})
+	for _, item := range items {
+		var value spec.EnumValueDefinition
+		switch v := item.(type) {
+		case string:
+			value.Value = v
+		case yaml.MapSlice:
+			for _, valueItem := range v {
+				var err error
+				switch valueItem.Key {
+				case "value":
+					if value.Value, err = toString(valueItem.Value, "enum value"); err != nil {
+						return nil, err
+					}
+				case "docs":
+					if value.Docs, err = toDocs(valueItem.Value, "docs of enum value"); err != nil {
+						return nil, err
+					}
+				case "deprecated":
+					if value.Deprecated, err = toDocs(valueItem.Value, "deprecated of enum value"); err != nil {
+						return nil, err
+					}
+				default:
+					return nil, unsupportedf("key %v in enum value", valueItem.Key)
+				}
+			}
+		default:
+			return nil, errors.Errorf("enum values must be strings or maps")
+		}
+		if !enumValueRegexp.MatchString(value.Value) {
+			return nil, errors.Errorf("enum value %q must be UPPER_SNAKE_CASE", value.Value)
+		}
+		if _, ok := seen[value.Value]; ok {
+			return nil, errors.Errorf("enum value %s is defined multiple times", value.Value)
+		}
+		seen[value.Value] = struct{}{}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (c *compiler) compileTypeValue(val interface{}, position typePosition) (spec.Type, error) {
+	typeStr, err := toString(val, "type")
+	if err != nil {
+		return spec.Type{}, err
+	}
+	return c.compileType(typeStr, position)
+}
+
+// compileType returns the type for the provided type expression (such as "map<string, list<Foo>>").
+func (c *compiler) compileType(typeStr string, position typePosition) (spec.Type, error) {
+	typeStr = strings.TrimSpace(typeStr)
+	if primitive, ok := primitiveTypes[typeStr]; ok {
+		switch {
+		case position == positionMapKey:
+			if _, ok := mapKeyPrimitiveTypes[primitive]; !ok {
+				return spec.Type{}, unsupportedf("map key type %s", typeStr)
+			}
+		case primitive == spec.PrimitiveType_BINARY && position != positionTopLevel:
+			return spec.Type{}, unsupportedf("binary type that is not the type of a field or alias")
+		}
+		return spec.NewTypeFromPrimitive(spec.New_PrimitiveType(primitive)), nil
+	}
+
+	if open := strings.Index(typeStr, "<"); open != -1 {
+		if !strings.HasSuffix(typeStr, ">") {
+			return spec.Type{}, errors.Errorf("invalid type %q", typeStr)
+		}
+		container, args := typeStr[:open], typeStr[open+1:len(typeStr)-1]
+		if position == positionMapKey {
+			return spec.Type{}, unsupportedf("map key type %s", typeStr)
+		}
+		switch container {
+		case "optional":
+			if position != positionTopLevel {
+				return spec.Type{}, unsupportedf("optional type that is not the type of a field or alias")
+			}
+			itemType, err := c.compileType(args, positionContainerItem)
+			if err != nil {
+				return spec.Type{}, err
+			}
+			return spec.NewTypeFromOptional(spec.OptionalType{ItemType: itemType}), nil
+		case "list", "set":
+			itemType, err := c.compileType(args, positionContainerItem)
+			if err != nil {
+				return spec.Type{}, err
+			}
+			if container == "list" {
+				return spec.NewTypeFromList(spec.ListType{ItemType: itemType}), nil
+			}
+			return spec.NewTypeFromSet(spec.SetType{ItemType: itemType}), nil
+		case "map":
+			keyStr, valueStr, ok := splitMapArgs(args)
+			if !ok {
+				return spec.Type{}, errors.Errorf("invalid map type %q", typeStr)
+			}
+			keyType, err := c.compileType(keyStr, positionMapKey)
+			if err != nil {
+				return spec.Type{}, err
+			}
+			valueType, err := c.compileType(valueStr, positionContainerItem)
+			if err != nil {
+				return spec.Type{}, err
+			}
+			return spec.NewTypeFromMap(spec.MapType{KeyType: keyType, ValueType: valueType}), nil
+		default:
+			return spec.Type{}, errors.Errorf("unknown container type %q", container)
+		}
+	}
+
+	if strings.Contains(typeStr, ".") {
+		return spec.Type{}, unsupportedf("imported type %s", typeStr)
+	}
+	def, ok := c.defs[typeStr]
+	if !ok {
+		return spec.Type{}, errors.Errorf("unknown type %q", typeStr)
+	}
+	if position == positionMapKey && def.kind != "values" {
+		return spec.Type{}, unsupportedf("map key type %s that is not an enum", typeStr)
+	}
+	if c.references == nil {
+		c.references = make(map[string][]string)
+	}
+	c.references[c.currName] = append(c.references[c.currName], typeStr)
+	return spec.NewTypeFromReference(def.name), nil
+}
+
+// splitMapArgs splits the arguments of a map type into the key and value types at the top-level comma.
+func splitMapArgs(args string) (string, string, bool) {
+	depth := 0
+	for i, r := range args {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				return args[:i], args[i+1:], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// checkAcyclic returns an error if the references between types form a cycle. Recursive types are only valid in some
+// forms, so they are not supported.
+func (c *compiler) checkAcyclic() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return unsupportedf("recursive type %s", name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, ref := range c.references[name] {
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for name := range c.defs {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toDocs(val interface{}, desc string) (*spec.Documentation, error) {
+	str, err := toString(val, desc)
+	if err != nil {
+		return nil, err
+	}
+	docs := spec.Documentation(str)
+	return &docs, nil
+}
+
+// typeDefinitionName returns the name of the provided type definition.
+func typeDefinitionName(typeDef spec.TypeDefinition) spec.TypeName {
+	var name spec.TypeName
+	_ = typeDef.AcceptFuncs(
+		func(alias spec.AliasDefinition) error {
+			name = alias.TypeName
+			return nil
+		},
+		func(enum spec.EnumDefinition) error {
+			name = enum.TypeName
+			return nil
+		},
+		func(object spec.ObjectDefinition) error {
+			name = object.TypeName
+			return nil
+		},
+		func(union spec.UnionDefinition) error {
+			name = union.TypeName
+			return nil
+		},
+		typeDef.ErrorOnUnknown,
+	)
+	return name
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nexus implements a client for the staging workflow of Nexus Repository Manager 2. Artifacts are uploaded to a
+// staging repository that is started for a staging profile. The repository is then closed, which runs the validation
+// rules of the profile, and can then be released (promoted) to the release repository of the profile or dropped.
+package nexus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	defaultTimeout      = 10 * time.Minute
+)
+
+// Client performs staging operations against the Nexus instance at URL.
+type Client struct {
+	// URL is the base URL of the Nexus instance (such as https://nexus.domain.com/nexus).
+	URL      string
+	Username string
+	Password string
+	// PollInterval is the interval at which the state of a staging repository is checked while it is transitioning.
+	// Defaults to 5 seconds if unspecified.
+	PollInterval time.Duration
+	// Timeout is the maximum amount of time to wait for a staging repository to finish transitioning. Defaults to 10
+	// minutes if unspecified.
+	Timeout time.Duration
+}
+
+// Repository is the state of a staging repository.
+type Repository struct {
+	ID string `json:"repositoryId"`
+	// Type is the state of the repository: "open", "closed" or "released".
+	Type          string `json:"type"`
+	Transitioning bool   `json:"transitioning"`
+}
+
+// Start starts a new staging repository for the provided staging profile and returns its ID.
+func (c *Client) Start(profileID, description string) (string, error) {
+	var resp struct {
+		Data struct {
+			StagedRepositoryID string `json:"stagedRepositoryId"`
+		} `json:"data"`
+	}
+	if err := c.do(http.MethodPost, "/service/local/staging/profiles/"+profileID+"/start", map[string]interface{}{
+		"data": map[string]interface{}{
+			"description": description,
+		},
+	}, &resp); err != nil {
+		return "", errors.Wrapf(err, "failed to start staging repository for staging profile %s", profileID)
+	}
+	if resp.Data.StagedRepositoryID == "" {
+		return "", errors.Errorf("failed to start staging repository for staging profile %s: response did not specify the ID of the repository", profileID)
+	}
+	return resp.Data.StagedRepositoryID, nil
+}
+
+// DeployURL returns the base URL to which artifacts are uploaded to add them to the provided staging repository. The
+// path of an artifact relative to this URL is its Maven path.
+func (c *Client) DeployURL(repositoryID string) string {
+	return c.baseURL() + "/service/local/staging/deployByRepositoryId/" + repositoryID
+}
+
+// Close closes the provided staging repository and waits until it is closed. Returns an error if the repository could
+// not be closed (for example, because its contents do not satisfy the rules of the staging profile).
+func (c *Client) Close(profileID, repositoryID, description string) error {
+	if err := c.do(http.MethodPost, "/service/local/staging/profiles/"+profileID+"/finish", map[string]interface{}{
+		"data": map[string]interface{}{
+			"stagedRepositoryId": repositoryID,
+			"description":        description,
+		},
+	}, nil); err != nil {
+		return errors.Wrapf(err, "failed to close staging repository %s", repositoryID)
+	}
+	return c.waitFor(repositoryID, "closed")
+}
+
+// Release releases the provided closed staging repository to the release repository of its staging profile and waits
+// until it is released.
+func (c *Client) Release(repositoryID, description string) error {
+	if err := c.bulk("promote", repositoryID, description); err != nil {
+		return errors.Wrapf(err, "failed to release staging repository %s", repositoryID)
+	}
+	return c.waitFor(repositoryID, "released")
+}
+
+// Drop drops the provided staging repository and its contents.
+func (c *Client) Drop(repositoryID, description string) error {
+	if err := c.bulk("drop", repositoryID, description); err != nil {
+		return errors.Wrapf(err, "failed to drop staging repository %s", repositoryID)
+	}
+	return nil
+}
+
+// Repository returns the current state of the provided staging repository.
+func (c *Client) Repository(repositoryID string) (Repository, error) {
+	var repo Repository
+	if err := c.do(http.MethodGet, "/service/local/staging/repository/"+repositoryID, nil, &repo); err != nil {
+		return Repository{}, errors.Wrapf(err, "failed to get state of staging repository %s", repositoryID)
+	}
+	return repo, nil
+}
+
+func (c *Client) bulk(operation, repositoryID, description string) error {
+	return c.do(http.MethodPost, "/service/local/staging/bulk/"+operation, map[string]interface{}{
+		"data": map[string]interface{}{
+			"stagedRepositoryIds": []string{repositoryID},
+			"description":         description,
+		},
+	}, nil)
+}
+
+// waitFor waits until the provided staging repository is no longer transitioning and returns an error if it is not in
+// the wanted state at that point or if it is still transitioning when the timeout elapses.
+func (c *Client) waitFor(repositoryID, wantType string) error {
+	pollInterval, timeout := c.PollInterval, c.Timeout
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		repo, err := c.Repository(repositoryID)
+		if err != nil {
+			return err
+		}
+		if !repo.Transitioning {
+			if repo.Type != wantType {
+				return errors.Errorf("staging repository %s is %s rather than %s: see the activity of the repository in Nexus for the cause", repositoryID, repo.Type, wantType)
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("staging repository %s did not become %s within %v", repositoryID, wantType, timeout)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func (c *Client) do(method, path string, reqBody, respBody interface{}) (rErr error) {
+	var body io.Reader
+	if reqBody != nil {
+		reqBytes, err := json.Marshal(reqBody)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal request")
+		}
+		body = bytes.NewReader(reqBytes)
+	}
+	reqURL := c.baseURL() + path
+	req, err := http.NewRequest(method, reqURL, body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create request for %s", reqURL)
+	}
+	req.Header.Set("Accept", "application/json")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s failed", method, reqURL)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil && rErr == nil {
+			rErr = errors.Wrapf(err, "failed to close response body for %s", reqURL)
+		}
+	}()
+	respBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read response body for %s", reqURL)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg := fmt.Sprintf("%s %s resulted in response %q", method, reqURL, resp.Status)
+		if len(respBytes) > 0 {
+			msg += ":\n" + string(respBytes)
+		}
+		return errors.New(msg)
+	}
+	if respBody != nil {
+		if err := json.Unmarshal(respBytes, respBody); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal response for %s", reqURL)
+		}
+	}
+	return nil
+}
+
+func (c *Client) baseURL() string {
+	return strings.TrimSuffix(c.URL, "/")
+}
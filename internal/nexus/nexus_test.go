@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nexus_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/nexus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStagingWorkflow(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests []string
+		state    string
+		polls    int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+
+		switch r.URL.Path {
+		case "/nexus/service/local/staging/profiles/profile-1/start":
+			_, _ = w.Write([]byte(`{"data":{"stagedRepositoryId":"repo-1001"}}`))
+		case "/nexus/service/local/staging/profiles/profile-1/finish":
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			state = "closed"
+		case "/nexus/service/local/staging/bulk/promote":
+			var body struct {
+				Data struct {
+					StagedRepositoryIDs []string `json:"stagedRepositoryIds"`
+				} `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, []string{"repo-1001"}, body.Data.StagedRepositoryIDs)
+			state = "released"
+		case "/nexus/service/local/staging/repository/repo-1001":
+			// the repository is transitioning the first time that its state is requested
+			polls++
+			_ = json.NewEncoder(w).Encode(nexus.Repository{
+				ID:            "repo-1001",
+				Type:          state,
+				Transitioning: polls == 1,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &nexus.Client{
+		URL:          server.URL + "/nexus/",
+		Username:     "user",
+		Password:     "pass",
+		PollInterval: time.Millisecond,
+	}
+	repoID, err := client.Start("profile-1", "description")
+	require.NoError(t, err)
+	assert.Equal(t, "repo-1001", repoID)
+	assert.Equal(t, server.URL+"/nexus/service/local/staging/deployByRepositoryId/repo-1001", client.DeployURL(repoID))
+
+	require.NoError(t, client.Close("profile-1", repoID, "description"))
+	require.NoError(t, client.Release(repoID, "description"))
+	assert.Equal(t, []string{
+		"POST /nexus/service/local/staging/profiles/profile-1/start",
+		"POST /nexus/service/local/staging/profiles/profile-1/finish",
+		"GET /nexus/service/local/staging/repository/repo-1001",
+		"GET /nexus/service/local/staging/repository/repo-1001",
+		"POST /nexus/service/local/staging/bulk/promote",
+		"GET /nexus/service/local/staging/repository/repo-1001",
+	}, requests)
+}
+
+func TestCloseFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/service/local/staging/profiles/profile-1/finish":
+		case "/service/local/staging/repository/repo-1001":
+			_, _ = w.Write([]byte(`{"repositoryId":"repo-1001","type":"open","transitioning":false}`))
+		case "/service/local/staging/profiles/profile-2/finish":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`unknown profile`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &nexus.Client{
+		URL: server.URL,
+	}
+	err := client.Close("profile-1", "repo-1001", "description")
+	assert.EqualError(t, err, "staging repository repo-1001 is open rather than closed: see the activity of the repository in Nexus for the cause")
+
+	err = client.Close("profile-2", "repo-1001", "description")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to close staging repository repo-1001")
+	assert.Contains(t, err.Error(), `resulted in response "400 Bad Request":`+"\nunknown profile")
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package offline controls whether the plugin may access the network. In offline mode, operations that would access
+// the network (such as downloading remote IR or the Conjure CLI and publishing) fail immediately with an error that
+// describes what would have been accessed, rather than failing with network errors or timeouts.
+package offline
+
+import (
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// EnvVar is the environment variable that, if set to "true", enables offline mode even if it has not been enabled
+// using SetEnabled.
+const EnvVar = "CONJURE_PLUGIN_OFFLINE"
+
+var (
+	enabledMutex sync.RWMutex
+	enabled      bool
+)
+
+// SetEnabled sets whether offline mode is enabled. Offline mode is also enabled if EnvVar is set to "true".
+func SetEnabled(val bool) {
+	enabledMutex.Lock()
+	defer enabledMutex.Unlock()
+	enabled = val
+}
+
+// Enabled returns true if offline mode is enabled.
+func Enabled() bool {
+	enabledMutex.RLock()
+	defer enabledMutex.RUnlock()
+	return enabled || os.Getenv(EnvVar) == "true"
+}
+
+// Check returns an error if offline mode is enabled. The error states that the provided action requires network access
+// to the provided target and includes the provided hint (if non-empty), which should describe how to proceed without
+// network access.
+func Check(action, target, hint string) error {
+	if !Enabled() {
+		return nil
+	}
+	msg := "offline mode is enabled (--offline or " + EnvVar + "=true), so cannot " + action + " " + target
+	if hint != "" {
+		msg += ": " + hint
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offline_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	t.Setenv(offline.EnvVar, "")
+	defer offline.SetEnabled(false)
+
+	offline.SetEnabled(false)
+	assert.False(t, offline.Enabled())
+	assert.NoError(t, offline.Check("download IR from", "https://example.com/ir.json", ""))
+
+	offline.SetEnabled(true)
+	assert.True(t, offline.Enabled())
+	assert.EqualError(t, offline.Check("download IR from", "https://example.com/ir.json", "use a local IR file"),
+		"offline mode is enabled (--offline or CONJURE_PLUGIN_OFFLINE=true), so cannot download IR from https://example.com/ir.json: use a local IR file")
+
+	offline.SetEnabled(false)
+	t.Setenv(offline.EnvVar, "true")
+	assert.True(t, offline.Enabled())
+	assert.EqualError(t, offline.Check("publish to", "https://artifactory.example.com", ""),
+		"offline mode is enabled (--offline or CONJURE_PLUGIN_OFFLINE=true), so cannot publish to https://artifactory.example.com")
+}
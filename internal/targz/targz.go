@@ -0,0 +1,292 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package targz extracts gzip-compressed tar archives and zip archives (the conventional format of archives for
+// Windows). Extraction is strict: it fails if an entry has a path that is absolute, outside of the destination
+// directory or contains ".." segments, if a symbolic link has a target that is absolute, outside of the destination
+// directory or reached through another symbolic link, if an entry is nested under a symbolic link or if an entry is of
+// any type other than a directory, regular file or symbolic link. The permissions of extracted entries are normalized
+// rather than taken from the archive.
+package targz
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ExtractFile extracts the tar.gz archive at the provided path into the destination directory.
+func ExtractFile(archivePath, dst string) (rErr error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err := f.Close(); rErr == nil && err != nil {
+			rErr = errors.WithStack(err)
+		}
+	}()
+	return Extract(f, dst)
+}
+
+type symlink struct {
+	path   string
+	target string
+}
+
+// Extract extracts the tar.gz archive read from the provided reader into the destination directory, which is created
+// if it does not exist. Existing files are overwritten. Symbolic links are created after all other entries have been
+// extracted so that no entry is ever written through a symbolic link.
+func Extract(r io.Reader, dst string) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "failed to read gzip stream")
+	}
+	defer func() {
+		_ = gzr.Close()
+	}()
+
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	var entryPaths []string
+	var symlinks []symlink
+	tr := tar.NewReader(gzr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read tar entry")
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			// PAX global headers do not correspond to a file
+			continue
+		}
+		relPath, err := sanitizePath(hdr.Name)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			if hdr.Typeflag == tar.TypeDir {
+				// entry for the destination directory itself, which already exists
+				continue
+			}
+			return errors.Errorf("entry %s is not a directory but refers to the destination directory", hdr.Name)
+		}
+		entryPaths = append(entryPaths, relPath)
+		if hdr.Typeflag == tar.TypeSymlink {
+			symlinks = append(symlinks, symlink{
+				path:   relPath,
+				target: hdr.Linkname,
+			})
+			continue
+		}
+		if err := extractEntry(tr, hdr, filepath.Join(dst, filepath.FromSlash(relPath))); err != nil {
+			return errors.Wrapf(err, "failed to extract %s", hdr.Name)
+		}
+	}
+	return extractSymlinks(dst, entryPaths, symlinks)
+}
+
+// extractSymlinks verifies that none of the provided entries is nested under any of the provided symbolic links and
+// that the targets of the symbolic links are safe, and then creates the symbolic links.
+func extractSymlinks(dst string, entryPaths []string, symlinks []symlink) error {
+	symlinkPaths := make(map[string]struct{}, len(symlinks))
+	for _, link := range symlinks {
+		symlinkPaths[link.path] = struct{}{}
+	}
+	for _, entryPath := range entryPaths {
+		for dir := path.Dir(entryPath); dir != "."; dir = path.Dir(dir) {
+			if _, ok := symlinkPaths[dir]; ok {
+				return errors.Errorf("entry %s is nested under symbolic link %s", entryPath, dir)
+			}
+		}
+	}
+	for _, link := range symlinks {
+		if err := checkSymlinkTarget(link, symlinkPaths); err != nil {
+			return err
+		}
+		target := filepath.Join(dst, filepath.FromSlash(link.path))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := os.Symlink(filepath.FromSlash(link.target), target); err != nil {
+			return errors.Wrapf(err, "failed to extract %s", link.path)
+		}
+	}
+	return nil
+}
+
+const (
+	dirPerm         os.FileMode = 0755
+	execFilePerm    os.FileMode = 0755
+	nonExecFilePerm os.FileMode = 0644
+)
+
+// filePerm returns the normalized permissions for a regular file with the provided mode in an archive: files that are
+// executable by anyone are executable by everyone and writable only by the owner, and all other files are readable by
+// everyone and writable only by the owner. Special bits such as setuid are never set.
+func filePerm(mode os.FileMode) os.FileMode {
+	if mode.Perm()&0111 != 0 {
+		return execFilePerm
+	}
+	return nonExecFilePerm
+}
+
+func extractEntry(tr *tar.Reader, hdr *tar.Header, target string) error {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return errors.WithStack(os.MkdirAll(target, dirPerm))
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+		return writeFile(target, tr, filePerm(hdr.FileInfo().Mode()))
+	default:
+		return errors.Errorf("entries of type %q are not supported", hdr.Typeflag)
+	}
+}
+
+// sanitizePath returns the cleaned form of the provided slash-separated archive path. Returns an error if the path is
+// absolute, refers to a location outside of the destination directory or contains ".." segments.
+func sanitizePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", errors.Errorf("path %s is absolute", name)
+	}
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.Errorf("path %s is outside of the destination directory", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			// paths in well-formed archives never contain ".." segments even if they stay within the destination
+			return "", errors.Errorf("path %s contains a %q segment", name, "..")
+		}
+	}
+	return cleaned, nil
+}
+
+// checkSymlinkTarget returns an error if the target of the provided link is absolute, is outside of the destination
+// directory or is resolved through another symbolic link. Because no entry is nested under a symbolic link and the
+// target is not resolved through one, the lexical resolution of the target matches its resolution by the filesystem.
+func checkSymlinkTarget(link symlink, symlinkPaths map[string]struct{}) error {
+	if path.IsAbs(link.target) || filepath.IsAbs(link.target) || filepath.VolumeName(link.target) != "" {
+		return errors.Errorf("symbolic link %s has absolute target %s", link.path, link.target)
+	}
+	var resolved []string
+	if dir := path.Dir(link.path); dir != "." {
+		resolved = strings.Split(dir, "/")
+	}
+	for _, component := range strings.Split(strings.ReplaceAll(link.target, `\`, "/"), "/") {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return errors.Errorf("symbolic link %s has target %s, which is outside of the destination directory", link.path, link.target)
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		resolved = append(resolved, component)
+		if _, ok := symlinkPaths[strings.Join(resolved, "/")]; ok {
+			return errors.Errorf("symbolic link %s has target %s, which is resolved through another symbolic link", link.path, link.target)
+		}
+	}
+	return nil
+}
+
+func writeFile(target string, r io.Reader, perm os.FileMode) (rErr error) {
+	// remove any existing entry so that the file is always created rather than written through an existing entry
+	if err := os.RemoveAll(target); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if err := f.Close(); rErr == nil && err != nil {
+			rErr = errors.WithStack(err)
+		}
+	}()
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// VerifyContained returns an error if any entry in the provided directory is not a directory, regular file or symbolic
+// link, if any symbolic link does not resolve to a location within the directory or if any file or directory has the
+// setuid or setgid bits set or is writable by users other than its owner. It verifies the result of an extraction
+// independently of how the extraction was performed.
+func VerifyContained(dir string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return filepath.WalkDir(root, func(currPath string, d os.DirEntry, err error) error {
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		relPath, err := filepath.Rel(root, currPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		mode := fi.Mode()
+		switch {
+		case mode&os.ModeSymlink != 0:
+			resolved, err := filepath.EvalSymlinks(currPath)
+			if err != nil {
+				return errors.Wrapf(err, "failed to resolve symbolic link %s", relPath)
+			}
+			if !isWithin(root, resolved) {
+				return errors.Errorf("symbolic link %s resolves to %s, which is outside of %s", relPath, resolved, dir)
+			}
+			return nil
+		case !mode.IsDir() && !mode.IsRegular():
+			return errors.Errorf("%s has unsupported file mode %s", relPath, mode)
+		case mode&(os.ModeSetuid|os.ModeSetgid) != 0:
+			return errors.Errorf("%s has setuid or setgid bit set", relPath)
+		case runtime.GOOS != "windows" && mode.Perm()&0022 != 0:
+			// permissions other than read-only are not represented on Windows, where every writable file has mode 0666
+			return errors.Errorf("%s is writable by users other than its owner (mode %s)", relPath, mode.Perm())
+		}
+		return nil
+	})
+}
+
+// isWithin returns true if the provided path is the provided root directory or is within it.
+func isWithin(root, p string) bool {
+	relPath, err := filepath.Rel(root, p)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
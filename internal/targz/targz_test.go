@@ -0,0 +1,256 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package targz_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/targz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+	mode     int64
+}
+
+func TestExtract(t *testing.T) {
+	dst := t.TempDir()
+	require.NoError(t, targz.Extract(newArchive(t, []entry{
+		{name: "./", typeflag: tar.TypeDir, mode: 0755},
+		{name: "conjure/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "conjure/bin/conjure", typeflag: tar.TypeReg, content: "#!/bin/sh", mode: 0755},
+		{name: "conjure/lib/conjure.jar", typeflag: tar.TypeReg, content: "jar", mode: 0644},
+		{name: "conjure/lib/current.jar", typeflag: tar.TypeSymlink, linkname: "conjure.jar"},
+		{name: "conjure/bin/lib", typeflag: tar.TypeSymlink, linkname: "../lib"},
+	}), dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "conjure", "bin", "conjure"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh", string(content))
+	fi, err := os.Stat(filepath.Join(dst, "conjure", "bin", "conjure"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+
+	content, err = os.ReadFile(filepath.Join(dst, "conjure", "lib", "current.jar"))
+	require.NoError(t, err)
+	assert.Equal(t, "jar", string(content))
+	content, err = os.ReadFile(filepath.Join(dst, "conjure", "bin", "lib", "conjure.jar"))
+	require.NoError(t, err)
+	assert.Equal(t, "jar", string(content))
+}
+
+func TestExtractRejectsUnsafeEntries(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		entries []entry
+		wantErr string
+	}{
+		{
+			name: "path traversal",
+			entries: []entry{
+				{name: "../evil", typeflag: tar.TypeReg, content: "evil", mode: 0644},
+			},
+			wantErr: "path ../evil is outside of the destination directory",
+		},
+		{
+			name: "nested path traversal",
+			entries: []entry{
+				{name: "foo/../../evil", typeflag: tar.TypeReg, content: "evil", mode: 0644},
+			},
+			wantErr: "path foo/../../evil is outside of the destination directory",
+		},
+		{
+			name: "path traversal with backslashes",
+			entries: []entry{
+				{name: `..\evil`, typeflag: tar.TypeReg, content: "evil", mode: 0644},
+			},
+			wantErr: "path ../evil is outside of the destination directory",
+		},
+		{
+			name: "dot-dot segment within destination",
+			entries: []entry{
+				{name: "foo/../bar", typeflag: tar.TypeReg, content: "content", mode: 0644},
+			},
+			wantErr: `path foo/../bar contains a ".." segment`,
+		},
+		{
+			name: "absolute path",
+			entries: []entry{
+				{name: "/tmp/evil", typeflag: tar.TypeReg, content: "evil", mode: 0644},
+			},
+			wantErr: "path /tmp/evil is absolute",
+		},
+		{
+			name: "absolute symbolic link target",
+			entries: []entry{
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+			},
+			wantErr: "symbolic link link has absolute target /etc/passwd",
+		},
+		{
+			name: "symbolic link target outside of destination",
+			entries: []entry{
+				{name: "foo/link", typeflag: tar.TypeSymlink, linkname: "../../evil"},
+			},
+			wantErr: "symbolic link foo/link has target ../../evil, which is outside of the destination directory",
+		},
+		{
+			name: "symbolic link target resolved through symbolic link",
+			entries: []entry{
+				{name: "up", typeflag: tar.TypeSymlink, linkname: "."},
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "up/.."},
+			},
+			wantErr: "symbolic link link has target up/.., which is resolved through another symbolic link",
+		},
+		{
+			name: "entry nested under symbolic link",
+			entries: []entry{
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "."},
+				{name: "link/file", typeflag: tar.TypeReg, content: "content", mode: 0644},
+			},
+			wantErr: "entry link/file is nested under symbolic link link",
+		},
+		{
+			name: "hard link",
+			entries: []entry{
+				{name: "file", typeflag: tar.TypeReg, content: "content", mode: 0644},
+				{name: "link", typeflag: tar.TypeLink, linkname: "file"},
+			},
+			wantErr: `failed to extract link: entries of type '1' are not supported`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			parentDir := t.TempDir()
+			dst := filepath.Join(parentDir, "dst")
+			err := targz.Extract(newArchive(t, tc.entries), dst)
+			require.Error(t, err, "Case %d", i)
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			_, err = os.Lstat(filepath.Join(parentDir, "evil"))
+			assert.True(t, os.IsNotExist(err), "Case %d", i)
+		})
+	}
+}
+
+func TestExtractNormalizesPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not represented on Windows")
+	}
+	dst := t.TempDir()
+	require.NoError(t, targz.Extract(newArchive(t, []entry{
+		{name: "dir/", typeflag: tar.TypeDir, mode: 0777},
+		{name: "dir/setuid", typeflag: tar.TypeReg, content: "content", mode: 04777},
+		{name: "dir/group-exec", typeflag: tar.TypeReg, content: "content", mode: 0710},
+		{name: "dir/world-writable", typeflag: tar.TypeReg, content: "content", mode: 0666},
+		{name: "dir/private", typeflag: tar.TypeReg, content: "content", mode: 0600},
+	}), dst))
+
+	for name, want := range map[string]os.FileMode{
+		"dir":                0755 | os.ModeDir,
+		"dir/setuid":         0755,
+		"dir/group-exec":     0755,
+		"dir/world-writable": 0644,
+		"dir/private":        0644,
+	} {
+		fi, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name)))
+		require.NoError(t, err)
+		assert.Equal(t, want, fi.Mode(), name)
+	}
+	assert.NoError(t, targz.VerifyContained(dst))
+}
+
+func TestVerifyContained(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test creates symbolic links")
+	}
+	for i, tc := range []struct {
+		name    string
+		setup   func(t *testing.T, dir, outsideDir string)
+		wantErr string
+	}{
+		{
+			name: "valid directory",
+			setup: func(t *testing.T, dir, outsideDir string) {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "conjure.jar"), []byte("jar"), 0644))
+				require.NoError(t, os.Symlink("lib/conjure.jar", filepath.Join(dir, "current.jar")))
+			},
+		},
+		{
+			name: "symbolic link outside of directory",
+			setup: func(t *testing.T, dir, outsideDir string) {
+				require.NoError(t, os.Symlink(outsideDir, filepath.Join(dir, "link")))
+			},
+			wantErr: "symbolic link link resolves to ",
+		},
+		{
+			name: "dangling symbolic link",
+			setup: func(t *testing.T, dir, outsideDir string) {
+				require.NoError(t, os.Symlink("missing", filepath.Join(dir, "link")))
+			},
+			wantErr: "failed to resolve symbolic link link",
+		},
+		{
+			name: "file writable by others",
+			setup: func(t *testing.T, dir, outsideDir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "file"), []byte("content"), 0644))
+				require.NoError(t, os.Chmod(filepath.Join(dir, "file"), 0666))
+			},
+			wantErr: "file is writable by users other than its owner (mode -rw-rw-rw-)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tc.setup(t, dir, t.TempDir())
+			err := targz.VerifyContained(dir)
+			if tc.wantErr == "" {
+				assert.NoError(t, err, "Case %d", i)
+				return
+			}
+			require.Error(t, err, "Case %d", i)
+			assert.Contains(t, err.Error(), tc.wantErr, "Case %d", i)
+		})
+	}
+}
+
+func newArchive(t *testing.T, entries []entry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gzw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gzw)
+	for _, e := range entries {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     e.name,
+			Typeflag: e.typeflag,
+			Linkname: e.linkname,
+			Mode:     e.mode,
+			Size:     int64(len(e.content)),
+		}))
+		_, err := tw.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return buf
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package targz
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ExtractZip extracts the zip archive read from the provided reader, which has the provided size, into the destination
+// directory using the same checks as Extract. The destination directory is created if it does not exist.
+func ExtractZip(r io.ReaderAt, size int64, dst string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return errors.Wrap(err, "failed to read zip archive")
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+
+	var entryPaths []string
+	var symlinks []symlink
+	for _, f := range zr.File {
+		relPath, err := sanitizePath(f.Name)
+		if err != nil {
+			return err
+		}
+		mode := f.Mode()
+		if relPath == "." {
+			if mode.IsDir() {
+				// entry for the destination directory itself, which already exists
+				continue
+			}
+			return errors.Errorf("entry %s is not a directory but refers to the destination directory", f.Name)
+		}
+		entryPaths = append(entryPaths, relPath)
+		if mode&os.ModeSymlink != 0 {
+			// the target of a symbolic link is stored as its content
+			target, err := readZipEntry(f)
+			if err != nil {
+				return errors.Wrapf(err, "failed to extract %s", f.Name)
+			}
+			symlinks = append(symlinks, symlink{
+				path:   relPath,
+				target: string(target),
+			})
+			continue
+		}
+		if err := extractZipEntry(f, filepath.Join(dst, filepath.FromSlash(relPath))); err != nil {
+			return errors.Wrapf(err, "failed to extract %s", f.Name)
+		}
+	}
+	return extractSymlinks(dst, entryPaths, symlinks)
+}
+
+func extractZipEntry(f *zip.File, target string) (rErr error) {
+	mode := f.Mode()
+	switch {
+	case mode.IsDir():
+		return errors.WithStack(os.MkdirAll(target, dirPerm))
+	case mode.IsRegular():
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer func() {
+			if err := rc.Close(); rErr == nil && err != nil {
+				rErr = errors.WithStack(err)
+			}
+		}()
+		return writeFile(target, rc, filePerm(mode))
+	default:
+		return errors.Errorf("entries with mode %s are not supported", mode)
+	}
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	content, err := io.ReadAll(rc)
+	return content, errors.WithStack(err)
+}
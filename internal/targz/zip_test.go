@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package targz_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/targz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type zipEntry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+func TestExtractZip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test creates symbolic links")
+	}
+	dst := t.TempDir()
+	archive := newZipArchive(t, []zipEntry{
+		{name: "jre/", mode: os.ModeDir | 0755},
+		{name: "jre/bin/java.exe", mode: 0755, content: "java"},
+		{name: "jre/lib/modules", mode: 0666, content: "modules"},
+		{name: "jre/lib/current", mode: os.ModeSymlink | 0777, content: "modules"},
+	})
+	require.NoError(t, targz.ExtractZip(bytes.NewReader(archive), int64(len(archive)), dst))
+
+	content, err := os.ReadFile(filepath.Join(dst, "jre", "bin", "java.exe"))
+	require.NoError(t, err)
+	assert.Equal(t, "java", string(content))
+	fi, err := os.Stat(filepath.Join(dst, "jre", "bin", "java.exe"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+	fi, err = os.Stat(filepath.Join(dst, "jre", "lib", "modules"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+
+	content, err = os.ReadFile(filepath.Join(dst, "jre", "lib", "current"))
+	require.NoError(t, err)
+	assert.Equal(t, "modules", string(content))
+	require.NoError(t, targz.VerifyContained(dst))
+}
+
+func TestExtractZipRejectsUnsafeEntries(t *testing.T) {
+	for i, tc := range []struct {
+		name    string
+		entries []zipEntry
+		wantErr string
+	}{
+		{
+			name: "path traversal",
+			entries: []zipEntry{
+				{name: "../evil", mode: 0644, content: "evil"},
+			},
+			wantErr: "path ../evil is outside of the destination directory",
+		},
+		{
+			name: "path traversal with backslashes",
+			entries: []zipEntry{
+				{name: `..\evil`, mode: 0644, content: "evil"},
+			},
+			wantErr: "path ../evil is outside of the destination directory",
+		},
+		{
+			name: "absolute path",
+			entries: []zipEntry{
+				{name: "/tmp/evil", mode: 0644, content: "evil"},
+			},
+			wantErr: "path /tmp/evil is absolute",
+		},
+		{
+			name: "symbolic link target outside of destination",
+			entries: []zipEntry{
+				{name: "foo/link", mode: os.ModeSymlink | 0777, content: "../../evil"},
+			},
+			wantErr: "symbolic link foo/link has target ../../evil, which is outside of the destination directory",
+		},
+		{
+			name: "entry nested under symbolic link",
+			entries: []zipEntry{
+				{name: "link", mode: os.ModeSymlink | 0777, content: "."},
+				{name: "link/file", mode: 0644, content: "content"},
+			},
+			wantErr: "entry link/file is nested under symbolic link link",
+		},
+		{
+			name: "named pipe",
+			entries: []zipEntry{
+				{name: "pipe", mode: os.ModeNamedPipe | 0644},
+			},
+			wantErr: "failed to extract pipe: entries with mode prw-r--r-- are not supported",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			parentDir := t.TempDir()
+			dst := filepath.Join(parentDir, "dst")
+			archive := newZipArchive(t, tc.entries)
+			err := targz.ExtractZip(bytes.NewReader(archive), int64(len(archive)), dst)
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			_, err = os.Lstat(filepath.Join(parentDir, "evil"))
+			assert.True(t, os.IsNotExist(err), "Case %d", i)
+		})
+	}
+}
+
+func newZipArchive(t *testing.T, entries []zipEntry) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{
+			Name:   e.name,
+			Method: zip.Deflate,
+		}
+		hdr.SetMode(e.mode)
+		w, err := zw.CreateHeader(hdr)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(e.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
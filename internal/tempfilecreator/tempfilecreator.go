@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tempfilecreator creates the temporary files and directories used by the plugin. All temporary files are
+// created in a configurable root directory and have names that include an identifier for the operation or project that
+// created them, which makes it easier to determine the origin of any files that are left behind.
+package tempfilecreator
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RootEnvVar is the environment variable that specifies the root directory in which temporary files are created if
+// the root has not been set explicitly using SetRoot.
+const RootEnvVar = "CONJURE_PLUGIN_TEMP_DIR"
+
+var (
+	rootMutex sync.RWMutex
+	root      string
+)
+
+// SetRoot sets the root directory in which temporary files are created. A relative path is resolved against the
+// current working directory. If the provided value is empty, the root is determined by the value of RootEnvVar or the
+// default temporary directory.
+func SetRoot(dir string) {
+	if dir != "" {
+		if absDir, err := filepath.Abs(dir); err == nil {
+			dir = absDir
+		}
+	}
+	rootMutex.Lock()
+	defer rootMutex.Unlock()
+	root = dir
+}
+
+// Root returns the root directory in which temporary files are created. Returns the value set by SetRoot if it is
+// non-empty, then the value of RootEnvVar if it is non-empty, and otherwise the default temporary directory.
+func Root() string {
+	rootMutex.RLock()
+	defer rootMutex.RUnlock()
+	if root != "" {
+		return root
+	}
+	if envRoot := os.Getenv(RootEnvVar); envRoot != "" {
+		return envRoot
+	}
+	return os.TempDir()
+}
+
+// MkdirTemp creates a new temporary directory in the root directory and returns its path. The name of the directory
+// includes the provided identifier.
+func MkdirTemp(identifier string) (string, error) {
+	dir, err := ensureRoot()
+	if err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp(dir, pattern(identifier))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create temporary directory")
+	}
+	return tmpDir, nil
+}
+
+// CreateTemp creates a new temporary file in the root directory and opens it for reading and writing. The name of the
+// file includes the provided identifier and ends with the provided suffix.
+func CreateTemp(identifier, suffix string) (*os.File, error) {
+	dir, err := ensureRoot()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(dir, pattern(identifier)+suffix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create temporary file")
+	}
+	return f, nil
+}
+
+func ensureRoot() (string, error) {
+	dir := Root()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrapf(err, "failed to create temporary file root directory %s", dir)
+	}
+	return dir, nil
+}
+
+var invalidIdentifierChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// pattern returns the pattern used for temporary file names for the provided identifier.
+func pattern(identifier string) string {
+	if identifier = invalidIdentifierChars.ReplaceAllString(identifier, "_"); identifier == "" {
+		return "conjure-plugin-*"
+	}
+	return "conjure-plugin-" + identifier + "-*"
+}
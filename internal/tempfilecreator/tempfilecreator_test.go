@@ -0,0 +1,48 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tempfilecreator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMkdirTemp(t *testing.T) {
+	envRoot := filepath.Join(t.TempDir(), "env-root")
+	t.Setenv(tempfilecreator.RootEnvVar, envRoot)
+
+	tmpDir, err := tempfilecreator.MkdirTemp("project/1")
+	require.NoError(t, err)
+	assert.Equal(t, envRoot, filepath.Dir(tmpDir))
+	assert.True(t, strings.HasPrefix(filepath.Base(tmpDir), "conjure-plugin-project_1-"), "unexpected name: %s", tmpDir)
+
+	explicitRoot := filepath.Join(t.TempDir(), "explicit-root")
+	tempfilecreator.SetRoot(explicitRoot)
+	defer tempfilecreator.SetRoot("")
+
+	f, err := tempfilecreator.CreateTemp("project-2", ".json")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, explicitRoot, filepath.Dir(f.Name()))
+	assert.True(t, strings.HasSuffix(f.Name(), ".json"), "unexpected name: %s", f.Name())
+	_, err = os.Stat(f.Name())
+	assert.NoError(t, err)
+}
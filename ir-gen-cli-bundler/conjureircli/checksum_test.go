@@ -0,0 +1,37 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCLIChecksum verifies that the checksum against which the CLI is verified when it is downloaded at runtime (see
+// the "conjure_download" tag) matches the embedded CLI.
+func TestCLIChecksum(t *testing.T) {
+	assert.Equal(t, internal.Version, internal.ChecksumVersion, `checksum was computed for a different version of the CLI: run "./godelw generate"`)
+
+	tgz, err := os.ReadFile(filepath.Join("internal", "conjure.tgz"))
+	require.NoError(t, err)
+	assert.Equal(t, internal.SHA256, fmt.Sprintf("%x", sha256.Sum256(tgz)), `checksum does not match the embedded CLI: run "./godelw generate"`)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCLICmdPathForOS(t *testing.T) {
+	for i, tc := range []struct {
+		goos    string
+		want    string
+		wantErr string
+	}{
+		{
+			goos: "darwin",
+			want: filepath.Join(cliArchiveDir(), "bin", "conjure"),
+		},
+		{
+			goos: "linux",
+			want: filepath.Join(cliArchiveDir(), "bin", "conjure"),
+		},
+		{
+			goos: "windows",
+			want: filepath.Join(cliArchiveDir(), "bin", "conjure.bat"),
+		},
+		{
+			goos:    "plan9",
+			wantErr: "OS plan9 not supported",
+		},
+	} {
+		got, err := cliCmdPathForOS(tc.goos)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d: %s", i, tc.goos)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, tc.goos)
+		assert.Equal(t, tc.want, got, "Case %d: %s", i, tc.goos)
+	}
+}
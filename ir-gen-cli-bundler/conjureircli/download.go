@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build conjure_download
+
+package conjureircli
+
+import (
+	"os"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli/internal"
+)
+
+// DownloadURLEnvVar is the environment variable that, if set, specifies the URL from which the tarball of the Conjure
+// CLI is downloaded instead of Maven Central (for example, an internal mirror). Only used when built with the
+// "conjure_download" tag.
+const DownloadURLEnvVar = "CONJURE_PLUGIN_CONJURE_CLI_URL"
+
+// conjureCLITGZ returns the tarball of the Conjure CLI, which is downloaded at runtime because the binary was built with
+// the "conjure_download" tag. Returns an error if the SHA-256 checksum of the downloaded tarball does not match the
+// expected checksum for the version of the CLI. The downloaded tarball is only used to unpack the CLI, so it is only
+// downloaded if the CLI has not already been unpacked.
+func conjureCLITGZ() ([]byte, error) {
+	url := internal.DownloadURL
+	if envURL := os.Getenv(DownloadURLEnvVar); envURL != "" {
+		url = envURL
+	}
+	if err := offline.Check("download the Conjure CLI from", url, "build without the conjure_download tag so that the CLI is embedded, or run once with network access so that the CLI is unpacked to "+cliUnpackDir()); err != nil {
+		return nil, err
+	}
+	return downloadVerified("Conjure CLI", url, internal.SHA256)
+}
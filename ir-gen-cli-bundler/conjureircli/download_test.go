@@ -0,0 +1,52 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build conjure_download
+
+package conjureircli_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadVerifiesChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("not the Conjure CLI"))
+	}))
+	defer server.Close()
+	t.Setenv(conjureircli.DownloadURLEnvVar, server.URL)
+	t.Setenv(tempfilecreator.RootEnvVar, t.TempDir())
+
+	_, err := conjureircli.YAMLtoIR([]byte(`types: {}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Conjure CLI downloaded from "+server.URL+" has SHA-256 checksum")
+}
+
+func TestDownloadOffline(t *testing.T) {
+	t.Setenv(offline.EnvVar, "true")
+	t.Setenv(conjureircli.DownloadURLEnvVar, "https://example.com/conjure.tgz")
+	t.Setenv(tempfilecreator.RootEnvVar, t.TempDir())
+
+	_, err := conjureircli.YAMLtoIR([]byte(`types: {}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "offline mode is enabled (--offline or CONJURE_PLUGIN_OFFLINE=true), so cannot download the Conjure CLI from https://example.com/conjure.tgz")
+}
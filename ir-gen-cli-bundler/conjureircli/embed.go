@@ -0,0 +1,32 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !conjure_download
+
+package conjureircli
+
+import (
+	_ "embed" // required for go:embed directive
+)
+
+var (
+	//go:embed internal/conjure.tgz
+	conjureCliTGZ []byte
+)
+
+// conjureCLITGZ returns the tarball of the Conjure CLI, which is embedded in the binary. Building with the
+// "conjure_download" tag downloads the tarball at runtime instead.
+func conjureCLITGZ() ([]byte, error) {
+	return conjureCliTGZ, nil
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const downloadTimeout = 5 * time.Minute
+
+// downloadVerified returns the content downloaded from the provided URL. Returns an error if the SHA-256 checksum of the
+// content does not match the provided checksum. description describes the downloaded content in errors.
+func downloadVerified(description, url, wantSHA256 string) ([]byte, error) {
+	client := &http.Client{
+		Timeout: downloadTimeout,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s from %s", description, url)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to download %s from %s: server returned status %s", description, url, resp.Status)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to download %s from %s", description, url)
+	}
+	if sha := fmt.Sprintf("%x", sha256.Sum256(content)); sha != strings.ToLower(wantSHA256) {
+		return nil, errors.Errorf("%s downloaded from %s has SHA-256 checksum %s, but expected %s", description, url, sha, wantSHA256)
+	}
+	return content, nil
+}
@@ -26,36 +26,56 @@ import (
 	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli/internal"
 )
 
-const conjureTgzPath = "../internal/conjure.tgz"
+const (
+	conjureTgzPath = "../internal/conjure.tgz"
+	checksumGoPath = "../internal/checksum.go"
+	checksumGoTmpl = `// Code generated by ../generator/generate.go. DO NOT EDIT.
 
-var conjureURL = fmt.Sprintf(
-	"https://search.maven.org/remotecontent?filepath=com/palantir/conjure/conjure/%s/conjure-%s.tgz",
-	internal.Version, internal.Version)
+package internal
+
+const (
+	// ChecksumVersion is the version of the Conjure CLI for which SHA256 was computed.
+	ChecksumVersion = %q
+	// SHA256 is the SHA-256 checksum of the tarball of the Conjure CLI (conjure.tgz).
+	SHA256 = %q
+)
+`
+)
 
 func main() {
-	if err := downloadFile(conjureTgzPath, conjureURL); err != nil {
+	sha, err := downloadFile(conjureTgzPath, internal.DownloadURL)
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(checksumGoPath, []byte(fmt.Sprintf(checksumGoTmpl, internal.Version, sha)), 0644); err != nil {
 		panic(err)
 	}
 }
 
-func downloadFile(filepath string, url string) error {
-	if _, err := os.Stat(filepath); err == nil {
+// downloadFile downloads the provided URL to the provided path and returns the SHA-256 checksum of the downloaded
+// content. The file is not downloaded again if it was downloaded for the current version and its checksum matches the
+// recorded checksum.
+func downloadFile(filepath string, url string) (string, error) {
+	if _, err := os.Stat(filepath); err == nil && internal.ChecksumVersion == internal.Version {
 		hash := sha256.New()
 		existing, err := os.OpenFile(filepath, os.O_RDONLY, 0)
 		if err != nil {
-			return err
+			return "", err
 		}
+		defer func() {
+			_ = existing.Close()
+		}()
 		if _, err := io.Copy(hash, existing); err != nil {
-			return err
+			return "", err
 		}
 		if sha := fmt.Sprintf("%x", hash.Sum(nil)); sha == internal.SHA256 {
 			// existing file up to date
-			return nil
+			return sha, nil
 		}
 	}
 	out, err := os.Create(filepath)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer func() {
 		_ = out.Close()
@@ -63,20 +83,18 @@ func downloadFile(filepath string, url string) error {
 
 	resp, err := http.Get(url)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
-
-	hash := sha256.New()
-	reader := io.TeeReader(resp.Body, hash)
-	if _, err := io.Copy(out, reader); err != nil {
-		return err
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, resp.Status)
 	}
 
-	if sha := fmt.Sprintf("%x", hash.Sum(nil)); sha != internal.SHA256 {
-		return fmt.Errorf("unexpected download sha256 %s", sha)
+	hash := sha256.New()
+	if _, err := io.Copy(out, io.TeeReader(resp.Body, hash)); err != nil {
+		return "", err
 	}
-	return nil
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
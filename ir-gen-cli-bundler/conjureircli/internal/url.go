@@ -0,0 +1,10 @@
+package internal
+
+import (
+	"fmt"
+)
+
+// DownloadURL is the URL from which the tarball of the Conjure CLI with version Version can be downloaded.
+var DownloadURL = fmt.Sprintf(
+	"https://search.maven.org/remotecontent?filepath=com/palantir/conjure/conjure/%s/conjure-%s.tgz",
+	Version, Version)
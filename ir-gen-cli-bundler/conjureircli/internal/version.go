@@ -1,6 +1,5 @@
 package internal
 
-const (
-	Version = "4.35.0"
-	SHA256  = "5087877495d936504fccdb7f8746c51f5394b2667b3839d98c5fbd59c7add93f"
-)
+// Version is the version of the Conjure CLI. After it is updated, "./godelw generate" downloads the CLI and updates
+// conjure.tgz and the checksum in checksum.go.
+const Version = "4.35.0"
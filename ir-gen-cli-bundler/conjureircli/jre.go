@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/targz"
+	"github.com/pkg/errors"
+)
+
+// JRE specifies a Java runtime environment that is downloaded and used to run the Conjure CLI instead of the "java"
+// command on the PATH.
+type JRE struct {
+	// URL is the URL of a tar.gz or zip archive of the JRE. The format of the archive is determined by the extension
+	// of the URL.
+	URL string
+	// SHA256 is the expected SHA-256 checksum of the archive.
+	SHA256 string
+}
+
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// Validate returns an error if the URL or SHA-256 checksum of the JRE is not specified or is malformed.
+func (j JRE) Validate() error {
+	if j.URL == "" {
+		return errors.Errorf("url must be specified")
+	}
+	if !strings.HasSuffix(j.URL, ".tar.gz") && !strings.HasSuffix(j.URL, ".tgz") && !strings.HasSuffix(j.URL, ".zip") {
+		return errors.Errorf("url %s must refer to a .tar.gz, .tgz or .zip archive", j.URL)
+	}
+	if !sha256Regexp.MatchString(j.SHA256) {
+		return errors.Errorf("sha256 %q must be a hex-encoded SHA-256 checksum", j.SHA256)
+	}
+	return nil
+}
+
+// JREParam returns a parameter that runs the Conjure CLI using the provided JRE. The first time the JRE is required, it
+// is downloaded, its checksum is verified and it is unpacked to {{tmp}}/_conjureircli/jre-{{sha256}}, where it is
+// reused by subsequent invocations. The CLI is run with JAVA_HOME set to the unpacked JRE.
+func JREParam(jre JRE) Param {
+	return paramFn(func(r *runArgs) {
+		r.jre = &jre
+	})
+}
+
+// jreDir returns the directory into which the provided JRE is unpacked. The directory is keyed by the checksum of the
+// archive so that a change to the pinned JRE results in a new installation.
+func jreDir(jre JRE) string {
+	return filepath.Join(cliUnpackDir(), "jre-"+strings.ToLower(jre.SHA256))
+}
+
+// ensureJRE installs the provided JRE if it has not already been installed and returns its Java home directory.
+func ensureJRE(jre JRE) (string, error) {
+	if err := jre.Validate(); err != nil {
+		return "", errors.Wrap(err, "invalid JRE")
+	}
+	dir := jreDir(jre)
+	if err := installDir(dir, "JRE", func(dir string) error {
+		_, err := findJavaHome(dir)
+		return err
+	}, func(tmpDir string) (string, error) {
+		if err := offline.Check("download the JRE from", jre.URL, "run once with network access so that the JRE is unpacked to "+dir); err != nil {
+			return "", err
+		}
+		archive, err := downloadVerified("JRE", jre.URL, jre.SHA256)
+		if err != nil {
+			return "", err
+		}
+		unpackedDir := filepath.Join(tmpDir, "jre")
+		if strings.HasSuffix(jre.URL, ".zip") {
+			// JREs for Windows are typically distributed as zip archives
+			err = targz.ExtractZip(bytes.NewReader(archive), int64(len(archive)), unpackedDir)
+		} else {
+			err = targz.Extract(bytes.NewReader(archive), unpackedDir)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to unpack JRE downloaded from %s", jre.URL)
+		}
+		if _, err := findJavaHome(unpackedDir); err != nil {
+			return "", errors.Wrapf(err, "JRE downloaded from %s is invalid", jre.URL)
+		}
+		return unpackedDir, nil
+	}); err != nil {
+		return "", err
+	}
+	return findJavaHome(dir)
+}
+
+// findJavaHome returns the Java home directory (the directory that contains "bin/java") within the provided unpacked
+// JRE archive. Archives typically contain a single top-level directory, which is the Java home directory on Linux and
+// Windows and contains "Contents/Home" on macOS.
+func findJavaHome(dir string) (string, error) {
+	candidates := []string{dir}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			candidates = append(candidates, filepath.Join(dir, entry.Name()), filepath.Join(dir, entry.Name(), "Contents", "Home"))
+		}
+	}
+	javaName := "java"
+	if runtime.GOOS == "windows" {
+		javaName = "java.exe"
+	}
+	for _, candidate := range candidates {
+		if checkCliExists(filepath.Join(candidate, "bin", javaName)) == nil {
+			return candidate, nil
+		}
+	}
+	return "", errors.Errorf("bin/%s not found in %s", javaName, dir)
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package conjureircli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFileExclusive(t *testing.T) {
+	lockPath := filepath.Join(t.TempDir(), "test.lock")
+
+	unlock, err := lockFile(lockPath)
+	require.NoError(t, err)
+
+	acquired := make(chan func() error)
+	errs := make(chan error)
+	go func() {
+		secondUnlock, err := lockFile(lockPath)
+		if err != nil {
+			errs <- err
+			return
+		}
+		acquired <- secondUnlock
+	}()
+
+	// the second lock must block while the first one is held
+	select {
+	case <-acquired:
+		require.Fail(t, "second lock was acquired while the first lock was held")
+	case err := <-errs:
+		require.NoError(t, err)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	require.NoError(t, unlock())
+
+	select {
+	case secondUnlock := <-acquired:
+		assert.NoError(t, secondUnlock())
+	case err := <-errs:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "second lock was not acquired after the first lock was released")
+	}
+}
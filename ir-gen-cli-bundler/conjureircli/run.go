@@ -15,34 +15,37 @@
 package conjureircli
 
 import (
-	_ "embed" // required for go:embed directive
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/mholt/archiver/v3"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/nativeir"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/targz"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
 	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli/internal"
 	"github.com/palantir/pkg/safejson"
 	"github.com/pkg/errors"
 )
 
-var (
-	//go:embed internal/conjure.tgz
-	conjureCliTGZ []byte
-)
+// CLIVersion is the version of the Conjure CLI that is used to compile YAML to IR.
+const CLIVersion = internal.Version
 
 func YAMLtoIR(in []byte) (rBytes []byte, rErr error) {
 	return YAMLtoIRWithParams(in)
 }
 
 func YAMLtoIRWithParams(in []byte, params ...Param) (rBytes []byte, rErr error) {
-	tmpDir, err := ioutil.TempDir("", "")
+	tmpDir, err := tempfilecreator.MkdirTemp("yaml-input")
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create temporary directory")
+		return nil, err
 	}
 	defer func() {
 		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
@@ -50,21 +53,37 @@ func YAMLtoIRWithParams(in []byte, params ...Param) (rBytes []byte, rErr error)
 		}
 	}()
 
-	inPath := path.Join(tmpDir, "in.yml")
+	inPath := filepath.Join(tmpDir, "in.yml")
 	if err := ioutil.WriteFile(inPath, in, 0644); err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return InputPathToIRWithParams(inPath, params...)
 }
 
+// ReaderToIR returns the IR compiled from the Conjure YAML read from the provided reader.
+func ReaderToIR(r io.Reader) ([]byte, error) {
+	return ReaderToIRWithParams(r)
+}
+
+// ReaderToIRWithParams returns the IR compiled from the Conjure YAML read from the provided reader using the provided
+// params. The YAML is written to a temporary file that is removed after compilation, so callers that generate YAML
+// programmatically do not have to manage temporary files themselves.
+func ReaderToIRWithParams(r io.Reader, params ...Param) ([]byte, error) {
+	in, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read Conjure YAML")
+	}
+	return YAMLtoIRWithParams(in, params...)
+}
+
 func InputPathToIR(inPath string) (rBytes []byte, rErr error) {
 	return InputPathToIRWithParams(inPath)
 }
 
 func InputPathToIRWithParams(inPath string, params ...Param) (rBytes []byte, rErr error) {
-	tmpDir, err := ioutil.TempDir("", "")
+	tmpDir, err := tempfilecreator.MkdirTemp("ir-" + filepath.Base(inPath))
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create temporary directory")
+		return nil, err
 	}
 	defer func() {
 		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
@@ -72,7 +91,7 @@ func InputPathToIRWithParams(inPath string, params ...Param) (rBytes []byte, rEr
 		}
 	}()
 
-	outPath := path.Join(tmpDir, "out.json")
+	outPath := filepath.Join(tmpDir, "out.json")
 	if err := RunWithParams(inPath, outPath, params...); err != nil {
 		return nil, err
 	}
@@ -83,13 +102,84 @@ func InputPathToIRWithParams(inPath string, params ...Param) (rBytes []byte, rEr
 	return irBytes, nil
 }
 
+// InputPathsToIRWithParams returns the IR compiled from all of the provided input paths, each of which is a Conjure
+// YAML file or a directory that contains Conjure YAML files. If multiple paths are provided, they are copied into a
+// single temporary directory (each into its own subdirectory, so files with the same name do not conflict) that is
+// compiled as a single input.
+func InputPathsToIRWithParams(inPaths []string, params ...Param) (rBytes []byte, rErr error) {
+	switch len(inPaths) {
+	case 0:
+		return nil, errors.Errorf("at least one input path must be provided")
+	case 1:
+		return InputPathToIRWithParams(inPaths[0], params...)
+	}
+
+	tmpDir, err := tempfilecreator.MkdirTemp("yaml-inputs")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+
+	mergedDir := filepath.Join(tmpDir, "in")
+	for i, inPath := range inPaths {
+		dstPath := filepath.Join(mergedDir, strconv.Itoa(i), filepath.Base(inPath))
+		if err := copyInput(inPath, dstPath); err != nil {
+			return nil, errors.Wrapf(err, "failed to copy input %s", inPath)
+		}
+	}
+	return InputPathToIRWithParams(mergedDir, params...)
+}
+
+// copyInput copies the file or directory at src to dst.
+func copyInput(src, dst string) error {
+	return filepath.WalkDir(src, func(currPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(src, currPath)
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dst, relPath)
+		if d.IsDir() {
+			return os.MkdirAll(dstPath, 0755)
+		}
+		content, err := os.ReadFile(currPath)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(dstPath, content, 0644)
+	})
+}
+
 // Run invokes the "compile" operation on the Conjure CLI with the provided inPath and outPath as arguments.
 func Run(inPath, outPath string) error {
 	return RunWithParams(inPath, outPath)
 }
 
+// JVMOptionsEnvVar is the environment variable that, if set, specifies JVM options (such as "-Xmx2g") for the Conjure
+// CLI. The options are applied after any options provided using JVMOptionsParam, so they take precedence.
+const JVMOptionsEnvVar = "CONJURE_PLUGIN_JVM_OPTS"
+
+// cliJVMOptionsEnvVar is the environment variable read by the launcher script of the Conjure CLI that specifies JVM
+// options for the CLI. It is used rather than JAVA_OPTS so that JVM options intended for other Java processes are not
+// affected.
+const cliJVMOptionsEnvVar = "CONJURE_OPTS"
+
 type runArgs struct {
 	extensionsContent []byte
+	compilerArgs      []string
+	jvmOptions        []string
+	jre               *JRE
+	env               []string
+	native            bool
 }
 
 type Param interface {
@@ -117,17 +207,66 @@ func ExtensionsParam(extensionsContent map[string]interface{}) (Param, error) {
 	}), nil
 }
 
-// RunWithParams invokes the "compile" operation on the Conjure CLI with the provided inPath and outPath as arguments.
-// Any arguments or configuration supplied by the provided params are also applied.
-func RunWithParams(inPath, outPath string, params ...Param) error {
-	cliPath, err := cliCmdPath()
-	if err != nil {
-		return err
+// EnvParam returns a parameter that adds the provided environment variables to the environment of the Conjure CLI
+// process. The process otherwise inherits the environment of the current process. Returns a no-op parameter if the
+// provided map is nil or empty.
+func EnvParam(env map[string]string) Param {
+	if len(env) == 0 {
+		return nil
 	}
-	if err := ensureCLIExists(cliPath); err != nil {
-		return err
+	var keys []string
+	for k := range env {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	var envVars []string
+	for _, k := range keys {
+		envVars = append(envVars, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	return paramFn(func(r *runArgs) {
+		r.env = append(r.env, envVars...)
+	})
+}
+
+// CompilerArgsParam returns a parameter that appends the provided arguments (for example, "--requireSafety") to the
+// arguments of the "compile" operation of the Conjure CLI. The arguments are added after the arguments supplied by the
+// plugin and before the input and output paths. Returns a no-op parameter if no arguments are provided.
+func CompilerArgsParam(args ...string) Param {
+	if len(args) == 0 {
+		return nil
+	}
+	args = append([]string(nil), args...)
+	return paramFn(func(r *runArgs) {
+		r.compilerArgs = append(r.compilerArgs, args...)
+	})
+}
+
+// JVMOptionsParam returns a parameter that provides the provided options (for example, "-Xmx2g") to the JVM that runs
+// the Conjure CLI. The options are added to any options specified by the CONJURE_OPTS environment variable, and options
+// specified by the JVMOptionsEnvVar environment variable are added after them. Returns a no-op parameter if no options
+// are provided.
+func JVMOptionsParam(opts ...string) Param {
+	if len(opts) == 0 {
+		return nil
+	}
+	opts = append([]string(nil), opts...)
+	return paramFn(func(r *runArgs) {
+		r.jvmOptions = append(r.jvmOptions, opts...)
+	})
+}
+
+// NativeCompilerParam returns a parameter that compiles the input using the experimental native compiler, which
+// compiles Conjure YAML to IR in Go without running the Conjure CLI. The Conjure CLI is used instead if the input uses
+// any construct that is not supported by the native compiler or if any compiler arguments are specified.
+func NativeCompilerParam() Param {
+	return paramFn(func(r *runArgs) {
+		r.native = true
+	})
+}
 
+// RunWithParams invokes the "compile" operation on the Conjure CLI with the provided inPath and outPath as arguments.
+// Any arguments or configuration supplied by the provided params are also applied.
+func RunWithParams(inPath, outPath string, params ...Param) error {
 	// apply provided params
 	var runArgCollector runArgs
 	for _, param := range params {
@@ -137,6 +276,21 @@ func RunWithParams(inPath, outPath string, params ...Param) error {
 		param.apply(&runArgCollector)
 	}
 
+	if runArgCollector.native && len(runArgCollector.compilerArgs) == 0 {
+		// fall back to the Conjure CLI if the native compiler does not support the input
+		if irBytes, err := nativeir.Compile(inPath, runArgCollector.extensionsContent); err == nil {
+			return errors.WithStack(os.WriteFile(outPath, irBytes, 0644))
+		}
+	}
+
+	cliPath, err := cliCmdPath()
+	if err != nil {
+		return err
+	}
+	if err := ensureCLIExists(cliPath); err != nil {
+		return err
+	}
+
 	// invoke the "compile" command
 	args := []string{"compile"}
 
@@ -145,65 +299,164 @@ func RunWithParams(inPath, outPath string, params ...Param) error {
 		args = append(args, "--extensions", string(runArgCollector.extensionsContent))
 	}
 
+	// add any additional compiler arguments
+	args = append(args, runArgCollector.compilerArgs...)
+
 	// set the inPath and outPath as final arguments
 	args = append(args, inPath, outPath)
 
+	env := runArgCollector.env
+	if runArgCollector.jre != nil {
+		javaHome, err := ensureJRE(*runArgCollector.jre)
+		if err != nil {
+			return err
+		}
+		env = append(env, "JAVA_HOME="+javaHome)
+	}
+	if jvmOptsEnv := jvmOptionsEnv(runArgCollector); jvmOptsEnv != "" {
+		env = append(env, jvmOptsEnv)
+	}
 	cmd := exec.Command(cliPath, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return errors.Wrapf(err, "failed to execute %v\nOutput:\n%s", cmd.Args, string(output))
 	}
 	return nil
 }
 
-// cliUnpackDir is the directory into which the tarball is unpacked
-var cliUnpackDir = path.Join(os.TempDir(), "_conjureircli")
+// jvmOptionsEnv returns the environment variable assignment that sets the JVM options of the Conjure CLI to the options
+// specified by the environment (including the environment provided by params), the provided run arguments and the
+// JVMOptionsEnvVar environment variable (in that order). Returns an empty string if no JVM options are specified by the
+// run arguments or JVMOptionsEnvVar.
+func jvmOptionsEnv(r runArgs) string {
+	envVarOpts := strings.TrimSpace(os.Getenv(JVMOptionsEnvVar))
+	if len(r.jvmOptions) == 0 && envVarOpts == "" {
+		return ""
+	}
+	var opts []string
+	existing := os.Getenv(cliJVMOptionsEnvVar)
+	for _, kv := range r.env {
+		if strings.HasPrefix(kv, cliJVMOptionsEnvVar+"=") {
+			existing = strings.TrimPrefix(kv, cliJVMOptionsEnvVar+"=")
+		}
+	}
+	if existing = strings.TrimSpace(existing); existing != "" {
+		opts = append(opts, existing)
+	}
+	opts = append(opts, r.jvmOptions...)
+	if envVarOpts != "" {
+		opts = append(opts, envVarOpts)
+	}
+	return cliJVMOptionsEnvVar + "=" + strings.Join(opts, " ")
+}
+
+// cliUnpackDir returns the directory into which the tarball is unpacked
+func cliUnpackDir() string {
+	return filepath.Join(tempfilecreator.Root(), "_conjureircli")
+}
 
-// cliArchiveDir is the top-level directory of the unpacked archive
-var cliArchiveDir = path.Join(cliUnpackDir, fmt.Sprintf("conjure-%v", internal.Version))
+// cliArchiveDir returns the top-level directory of the unpacked archive
+func cliArchiveDir() string {
+	return filepath.Join(cliUnpackDir(), fmt.Sprintf("conjure-%v", internal.Version))
+}
 
-// cliCmdPath is the path to the conjure compiler executable
+// cliCmdPath is the path to the conjure compiler executable. On Windows, this is the batch launcher script included in
+// the distribution, which os/exec runs using cmd.exe.
 func cliCmdPath() (string, error) {
-	switch runtime.GOOS {
+	return cliCmdPathForOS(runtime.GOOS)
+}
+
+// cliCmdPathForOS is the path to the conjure compiler executable for the provided GOOS.
+func cliCmdPathForOS(goos string) (string, error) {
+	switch goos {
 	case "darwin", "linux":
-		return path.Join(cliArchiveDir, "bin", "conjure"), nil
+		return filepath.Join(cliArchiveDir(), "bin", "conjure"), nil
+	case "windows":
+		return filepath.Join(cliArchiveDir(), "bin", "conjure.bat"), nil
 	default:
-		return "", errors.Errorf("OS %s not supported", runtime.GOOS)
+		return "", errors.Errorf("OS %s not supported", goos)
 	}
 }
 
 // ensureCLIExists installs the conjure compiler if it does not already exist or it appears malformed.
 func ensureCLIExists(cliPath string) error {
-	if checkCliExists(cliPath) == nil {
+	return installDir(cliArchiveDir(), "Conjure CLI", func(dir string) error {
+		return checkCliExists(filepath.Join(dir, "bin", filepath.Base(cliPath)))
+	}, func(tmpDir string) (string, error) {
+		cliTGZ, err := conjureCLITGZ()
+		if err != nil {
+			return "", err
+		}
+		tmpTGZPath := filepath.Join(tmpDir, "conjure-cli.tgz")
+		if err := os.WriteFile(tmpTGZPath, cliTGZ, 0644); err != nil {
+			return "", errors.Wrap(err, "failed to write Conjure CLI TGZ")
+		}
+		if err := targz.ExtractFile(tmpTGZPath, tmpDir); err != nil {
+			return "", errors.Wrap(err, "failed to unpack Conjure CLI TGZ")
+		}
+		unpackedDir := filepath.Join(tmpDir, filepath.Base(cliArchiveDir()))
+		if err := checkCliExists(filepath.Join(unpackedDir, "bin", filepath.Base(cliPath))); err != nil {
+			return "", errors.Wrap(err, "failed to stat cli file after unpacking; please comment on godel-conjure-plugin#84 and retry")
+		}
+		return unpackedDir, nil
+	})
+}
+
+// installDir installs the directory dst if installed returns an error for it. Installation is guarded by an advisory
+// file lock so that concurrent invocations (including from other processes) do not race. unpack is called with a
+// temporary directory in the same directory as dst and returns the directory that it unpacked within it, which is
+// verified using targz.VerifyContained and renamed into place so that dst is never observed in a partially unpacked
+// state. description describes the installed directory in errors.
+func installDir(dst, description string, installed func(dir string) error, unpack func(tmpDir string) (string, error)) (rErr error) {
+	if installed(dst) == nil {
 		// destination already exists
 		return nil
 	}
 
-	// destination does not exist or is malformed, remove the archive dir just in case of a previous bad install
-	if err := os.RemoveAll(cliArchiveDir); err != nil {
-		return errors.Wrap(err, "failed to remove destination dir before unpacking cli archive")
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for %s", description)
 	}
-
-	// expand asset into destination
-	tmpDir, err := os.MkdirTemp("", "conjure-cli-*")
+	unlock, err := lockFile(dst + ".lock")
 	if err != nil {
-		return errors.Wrap(err, "failed to create temporary directory for CLI TGZ")
+		return err
 	}
-	tmpTGZPath := filepath.Join(tmpDir, "conjure-cli.tgz")
-	if err := os.WriteFile(tmpTGZPath, conjureCliTGZ, 0644); err != nil {
-		return errors.Wrap(err, "failed to write Conjure CLI TGZ")
+	defer func() {
+		if err := unlock(); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to release lock for %s", description)
+		}
+	}()
+	if installed(dst) == nil {
+		// installed by another invocation while waiting for the lock
+		return nil
 	}
 
-	tarGZ := archiver.NewTarGz()
-	tarGZ.OverwriteExisting = true
-	if err := tarGZ.Unarchive(tmpTGZPath, cliUnpackDir); err != nil {
-		return errors.WithStack(err)
+	// unpack into a temporary directory in the same directory as the destination so that it can be renamed into place
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), filepath.Base(dst)+".tmp-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary directory for %s", description)
 	}
-
-	// check that we can now find the cli
-	if err := checkCliExists(cliPath); err != nil {
-		return errors.Wrap(err, "failed to stat cli file after unpacking; please comment on godel-conjure-plugin#84 and retry")
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	unpackedDir, err := unpack(tmpDir)
+	if err != nil {
+		return err
+	}
+	if err := targz.VerifyContained(unpackedDir); err != nil {
+		return errors.Wrapf(err, "unpacked %s failed verification", description)
 	}
 
+	// destination does not exist or is malformed, remove it just in case of a previous bad install
+	if err := os.RemoveAll(dst); err != nil {
+		return errors.Wrapf(err, "failed to remove destination directory before installing %s", description)
+	}
+	if err := os.Rename(unpackedDir, dst); err != nil {
+		return errors.Wrapf(err, "failed to move unpacked %s into place", description)
+	}
 	return nil
 }
 
@@ -15,9 +15,26 @@
 package conjureircli_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/palantir/godel-conjure-plugin/v6/internal/offline"
+	"github.com/palantir/godel-conjure-plugin/v6/internal/tempfilecreator"
 	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli/internal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -116,6 +133,21 @@ types:
 	}
 }
 
+func TestReaderToIR(t *testing.T) {
+	in := `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`
+	want, err := conjureircli.YAMLtoIR([]byte(in))
+	require.NoError(t, err)
+	got, err := conjureircli.ReaderToIR(strings.NewReader(in))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
 func mustExtensionsParam(in map[string]interface{}) conjureircli.Param {
 	param, err := conjureircli.ExtensionsParam(in)
 	if err != nil {
@@ -123,3 +155,293 @@ func mustExtensionsParam(in map[string]interface{}) conjureircli.Param {
 	}
 	return param
 }
+
+func TestConcurrentCLIInstall(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(tempfilecreator.RootEnvVar, root)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = conjureircli.YAMLtoIR([]byte(`types: {}`))
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		// the CLI may fail to run (for example, if Java is not available), but installing it must not fail
+		if err != nil {
+			assert.Contains(t, err.Error(), "failed to execute", "Case %d", i)
+		}
+	}
+
+	archiveDirName := "conjure-" + internal.Version
+	fi, err := os.Stat(filepath.Join(root, "_conjureircli", archiveDirName, "bin", "conjure"))
+	require.NoError(t, err)
+	assert.True(t, fi.Size() > 0)
+	entries, err := os.ReadDir(filepath.Join(root, "_conjureircli"))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{archiveDirName, archiveDirName + ".lock"}, names, "temporary directories should be removed")
+}
+
+func TestRunWithParamsCompilerArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script in place of the Conjure CLI")
+	}
+	// install a fake CLI that writes its arguments to the output path (the last argument)
+	installFakeCLI(t, `echo "$@" > "$last"`)
+
+	outPath := filepath.Join(t.TempDir(), "out.json")
+	extensionsParam := mustExtensionsParam(map[string]interface{}{"key": "value"})
+	require.NoError(t, conjureircli.RunWithParams("in.yml", outPath, extensionsParam, conjureircli.CompilerArgsParam("--requireSafety", "--verbose")))
+
+	got, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Equal(t, `compile --extensions {"key":"value"} --requireSafety --verbose in.yml `+outPath+"\n", string(got))
+}
+
+func TestRunWithParamsNativeCompiler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script in place of the Conjure CLI")
+	}
+	// install a fake CLI that writes a marker to the output path
+	installFakeCLI(t, `echo "conjure-cli" > "$last"`)
+
+	for i, tc := range []struct {
+		name   string
+		in     string
+		params []conjureircli.Param
+		want   string
+	}{
+		{
+			name: "supported input is compiled natively",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`,
+			want: `"name" : "BooleanExample"`,
+		},
+		{
+			name: "unsupported input falls back to Conjure CLI",
+			in: `
+services:
+  TestService:
+    name: Test Service
+    package: com.palantir.conjure
+    endpoints:
+      ping:
+        http: GET /ping
+`,
+			want: "conjure-cli",
+		},
+		{
+			name: "compiler arguments fall back to Conjure CLI",
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`,
+			params: []conjureircli.Param{conjureircli.CompilerArgsParam("--requireSafety")},
+			want:   "conjure-cli",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			inPath := filepath.Join(dir, "in.yml")
+			require.NoError(t, os.WriteFile(inPath, []byte(tc.in), 0644))
+			outPath := filepath.Join(dir, "out.json")
+			require.NoError(t, conjureircli.RunWithParams(inPath, outPath, append(tc.params, conjureircli.NativeCompilerParam())...), "Case %d", i)
+
+			got, err := os.ReadFile(outPath)
+			require.NoError(t, err, "Case %d", i)
+			assert.Contains(t, string(got), tc.want, "Case %d", i)
+		})
+	}
+}
+
+func TestRunWithParamsJVMOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script in place of the Conjure CLI")
+	}
+	// install a fake CLI that writes the JVM options read by the launcher script to the output path
+	installFakeCLI(t, `echo "$CONJURE_OPTS" > "$last"`)
+
+	for i, tc := range []struct {
+		name   string
+		envVar string
+		params []conjureircli.Param
+		want   string
+	}{
+		{
+			name: "no options",
+			want: "",
+		},
+		{
+			name:   "options from param",
+			params: []conjureircli.Param{conjureircli.JVMOptionsParam("-Xmx1g", "-XX:+UseSerialGC")},
+			want:   "-Xmx1g -XX:+UseSerialGC",
+		},
+		{
+			name:   "options from environment variable take precedence",
+			envVar: "-Xmx4g",
+			params: []conjureircli.Param{conjureircli.JVMOptionsParam("-Xmx1g")},
+			want:   "-Xmx1g -Xmx4g",
+		},
+		{
+			name: "options are added to CONJURE_OPTS set by env param",
+			params: []conjureircli.Param{
+				conjureircli.EnvParam(map[string]string{"CONJURE_OPTS": "-Dfoo=bar"}),
+				conjureircli.JVMOptionsParam("-Xmx1g"),
+			},
+			want: "-Dfoo=bar -Xmx1g",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(conjureircli.JVMOptionsEnvVar, tc.envVar)
+			t.Setenv("CONJURE_OPTS", "")
+
+			outPath := filepath.Join(t.TempDir(), "out.json")
+			require.NoError(t, conjureircli.RunWithParams("in.yml", outPath, tc.params...), "Case %d", i)
+			got, err := os.ReadFile(outPath)
+			require.NoError(t, err, "Case %d", i)
+			assert.Equal(t, tc.want+"\n", string(got), "Case %d", i)
+		})
+	}
+}
+
+// installFakeCLI installs a shell script that runs the provided command in place of the Conjure CLI in a temporary root
+// directory. The variable "last" is set to the last argument provided to the script (the output path).
+func installFakeCLI(t *testing.T, command string) {
+	root := t.TempDir()
+	t.Setenv(tempfilecreator.RootEnvVar, root)
+	binDir := filepath.Join(root, "_conjureircli", "conjure-"+internal.Version, "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "conjure"), []byte("#!/bin/sh\nfor last; do :; done\n"+command+"\n"), 0755))
+}
+
+func TestRunWithParamsJRE(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script in place of the Conjure CLI")
+	}
+	// install a fake CLI that writes the Java home directory used by the launcher script to the output path
+	installFakeCLI(t, `echo "$JAVA_HOME" > "$last"`)
+
+	jreArchive := newJREArchive(t)
+	jreSHA256 := fmt.Sprintf("%x", sha256.Sum256(jreArchive))
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write(jreArchive)
+	}))
+	defer server.Close()
+	jre := conjureircli.JRE{
+		URL:    server.URL + "/jre.tar.gz",
+		SHA256: jreSHA256,
+	}
+
+	for i := 0; i < 2; i++ {
+		outPath := filepath.Join(t.TempDir(), "out.json")
+		require.NoError(t, conjureircli.RunWithParams("in.yml", outPath, conjureircli.JREParam(jre)), "Run %d", i)
+		got, err := os.ReadFile(outPath)
+		require.NoError(t, err, "Run %d", i)
+		assert.Equal(t, filepath.Join(os.Getenv(tempfilecreator.RootEnvVar), "_conjureircli", "jre-"+jreSHA256, "jdk-17-jre")+"\n", string(got), "Run %d", i)
+	}
+	assert.Equal(t, 1, requests, "JRE should only be downloaded once")
+
+	// a different checksum is a different JRE, which must be downloaded and verified
+	err := conjureircli.RunWithParams("in.yml", filepath.Join(t.TempDir(), "out.json"), conjureircli.JREParam(conjureircli.JRE{
+		URL:    jre.URL,
+		SHA256: strings.Repeat("0", 64),
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JRE downloaded from "+jre.URL+" has SHA-256 checksum "+jreSHA256+", but expected "+strings.Repeat("0", 64))
+
+	t.Setenv(offline.EnvVar, "true")
+	err = conjureircli.RunWithParams("in.yml", filepath.Join(t.TempDir(), "out.json"), conjureircli.JREParam(conjureircli.JRE{
+		URL:    jre.URL,
+		SHA256: strings.Repeat("1", 64),
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "offline mode is enabled (--offline or CONJURE_PLUGIN_OFFLINE=true), so cannot download the JRE from "+jre.URL)
+}
+
+func TestRunWithParamsJREZip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses a shell script in place of the Conjure CLI")
+	}
+	installFakeCLI(t, `echo "$JAVA_HOME" > "$last"`)
+
+	jreArchive := newJREZipArchive(t)
+	jreSHA256 := fmt.Sprintf("%x", sha256.Sum256(jreArchive))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(jreArchive)
+	}))
+	defer server.Close()
+
+	outPath := filepath.Join(t.TempDir(), "out.json")
+	require.NoError(t, conjureircli.RunWithParams("in.yml", outPath, conjureircli.JREParam(conjureircli.JRE{
+		URL:    server.URL + "/jre.zip",
+		SHA256: jreSHA256,
+	})))
+	got, err := os.ReadFile(outPath)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(os.Getenv(tempfilecreator.RootEnvVar), "_conjureircli", "jre-"+jreSHA256, "jdk-17-jre")+"\n", string(got))
+}
+
+// newJREArchive returns a tar.gz archive that has the layout of a JRE archive.
+func newJREArchive(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	gzw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gzw)
+	for _, hdr := range []*tar.Header{
+		{Name: "jdk-17-jre/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "jdk-17-jre/bin/", Typeflag: tar.TypeDir, Mode: 0755},
+		{Name: "jdk-17-jre/bin/java", Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len("#!/bin/sh\n"))},
+	} {
+		require.NoError(t, tw.WriteHeader(hdr))
+		if hdr.Typeflag == tar.TypeReg {
+			_, err := tw.Write([]byte("#!/bin/sh\n"))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+// newJREZipArchive returns a zip archive that has the layout of a JRE archive.
+func newJREZipArchive(t *testing.T) []byte {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for _, e := range []struct {
+		name string
+		mode os.FileMode
+	}{
+		{"jdk-17-jre/", os.ModeDir | 0755},
+		{"jdk-17-jre/bin/", os.ModeDir | 0755},
+		{"jdk-17-jre/bin/java", 0755},
+	} {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(e.mode)
+		w, err := zw.CreateHeader(hdr)
+		require.NoError(t, err)
+		if e.mode.IsRegular() {
+			_, err := w.Write([]byte("#!/bin/sh\n"))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}